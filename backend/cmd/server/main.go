@@ -28,6 +28,7 @@ import (
 	"github.com/poly-pro/backend/internal/api"
 	"github.com/poly-pro/backend/internal/config"
 	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/tasks"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -51,6 +52,9 @@ func main() {
 		os.Exit(1)
 	}
 	logger.Info("configuration loaded successfully")
+	if missing := cfg.MissingCLOBCredentials(); len(missing) > 0 {
+		logger.Warn("CLOB credentials are only partially set; trading is disabled and the stream falls back to mock data", "missing", missing)
+	}
 
 	// ------------------------------------------------------------------
 	// Database Connection
@@ -92,6 +96,19 @@ func main() {
 		logger.Error("cannot parse redis URL", "error", err)
 		os.Exit(1)
 	}
+	// Apply the configured retry/backoff; unset values keep the go-redis defaults.
+	if cfg.RedisMaxRetries != 0 {
+		redisOpts.MaxRetries = cfg.RedisMaxRetries
+	}
+	if cfg.RedisMinRetryBackoff > 0 {
+		redisOpts.MinRetryBackoff = cfg.RedisMinRetryBackoff
+	}
+	if cfg.RedisMaxRetryBackoff > 0 {
+		redisOpts.MaxRetryBackoff = cfg.RedisMaxRetryBackoff
+	}
+	if cfg.RedisDialTimeout > 0 {
+		redisOpts.DialTimeout = cfg.RedisDialTimeout
+	}
 	redisClient := redis.NewClient(redisOpts)
 	if _, err := redisClient.Ping(context.Background()).Result(); err != nil {
 		logger.Error("redis ping failed", "error", err)
@@ -104,6 +121,10 @@ func main() {
 	rootCtx, cancelRootCtx := context.WithCancel(context.Background())
 	defer cancelRootCtx()
 
+	// Background services are started through the task manager, which is shut down last
+	// so that the process exits only after they have all stopped.
+	taskManager := tasks.NewManager(rootCtx, logger)
+
 	// ------------------------------------------------------------------
 	// Server Initialization
 	// ------------------------------------------------------------------
@@ -112,7 +133,7 @@ func main() {
 	store := db.New(connPool)
 
 	// Create a new server instance, passing in the context, configuration, database store, and redis client.
-	server := api.NewServer(rootCtx, cfg, store, redisClient)
+	server := api.NewServer(taskManager.Context(), cfg, store, redisClient, taskManager)
 
 	// Create a new HTTP server based on the Gin router.
 	httpServer := &http.Server{
@@ -132,6 +153,22 @@ func main() {
 		serverErrors <- httpServer.ListenAndServe()
 	}()
 
+	// Start the internal listener (debug endpoints) if configured.
+	// It binds to localhost only so it is never reachable from outside the host.
+	var internalServer *http.Server
+	if cfg.InternalPort != "" {
+		internalServer = &http.Server{
+			Addr:    "127.0.0.1:" + cfg.InternalPort,
+			Handler: server.InternalRouter,
+		}
+		go func() {
+			logger.Info("starting internal server", "address", internalServer.Addr)
+			if err := internalServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("internal server error", "error", err)
+			}
+		}()
+	}
+
 	// Create a channel to listen for OS interrupt signals.
 	shutdownChannel := make(chan os.Signal, 1)
 	signal.Notify(shutdownChannel, syscall.SIGINT, syscall.SIGTERM)
@@ -153,12 +190,31 @@ func main() {
 		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancelShutdown()
 
+		// Shut down the internal listener first; it serves no user traffic.
+		if internalServer != nil {
+			if err := internalServer.Shutdown(shutdownCtx); err != nil {
+				logger.Error("graceful internal server shutdown failed", "error", err)
+			}
+		}
+
 		// Attempt to gracefully shut down the server.
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			logger.Error("graceful http server shutdown failed", "error", err)
 			os.Exit(1)
 		}
 
+		// Wait for the background services to stop before releasing their resources.
+		if err := taskManager.Shutdown(shutdownCtx); err != nil {
+			logger.Error("background tasks did not shut down cleanly", "error", err)
+		}
+
+		// Save the open OHLCV bars and publish the buffered Redis messages while the database
+		// pool and the Redis client are still open. The flush has its own timeout, as the root
+		// context is cancelled and the shutdown one may be spent.
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 15*time.Second)
+		server.FlushOnShutdown(flushCtx)
+		cancelFlush()
+
 		// Close all server connections and resources.
 		if err := server.Close(); err != nil {
 			logger.Error("failed to close server resources", "error", err)
@@ -0,0 +1,198 @@
+/**
+ * @description
+ * This file contains HTTP handlers for internal admin jobs. Like the debug routes,
+ * they are registered only on the internal router, which is served on a
+ * localhost-only listener and is never exposed publicly.
+ *
+ * Key features:
+ * - History Re-key Backfill: `POST /admin/backfill/market-history-keys` moves OHLCV bars
+ *   stored under asset IDs to their canonical condition IDs.
+ * - Dry Run by Default: The job only reports what it would change unless `?dry_run=false`
+ *   is given explicitly.
+ * - Chart Consistency Check: `GET /admin/markets/:id/consistency` compares stored bars with
+ *   Polymarket's prices-history for the market's YES token.
+ * - Redis Memory Report: `GET /admin/redis/memory` estimates the memory used by each cache
+ *   prefix, by sampling `MEMORY USAGE` over a SCAN of the prefix.
+ * - Publish Buffer Report: `GET /admin/redis/publish-buffer` reports the Pub/Sub messages
+ *   buffered after failed publishes, and how many were recovered or dropped.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/cachekeys"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+const (
+	// defaultMemorySampleSize is the number of keys per prefix whose memory usage is read.
+	defaultMemorySampleSize = 100
+	// maxMemorySampleSize bounds the sample query parameter.
+	maxMemorySampleSize = 1000
+	// maxMemoryScanKeys bounds the number of keys scanned per prefix.
+	maxMemoryScanKeys = 100000
+	// defaultPublishBufferSample is the number of channels listed in the publish buffer report.
+	defaultPublishBufferSample = 50
+)
+
+/**
+ * @function backfillMarketHistoryKeys
+ * @description A Gin handler that runs the market history re-key backfill.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query dry_run (optional): Set to "false" to apply the changes (default: "true").
+ *
+ * @notes
+ * - Asset IDs are resolved with the market stream's asset→condition mapping, so the job
+ *   refuses to run until the stream has loaded that mapping.
+ */
+func (server *Server) backfillMarketHistoryKeys(c *gin.Context) {
+	dryRun := c.DefaultQuery("dry_run", "true") != "false"
+
+	if server.marketStreamService.AssetMappingSize() == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "message": "Asset to condition ID mapping is not loaded yet"})
+		return
+	}
+
+	report, err := server.backfillService.RekeyAssetIDBars(c.Request.Context(), dryRun)
+	if err != nil {
+		server.logger.Error("market history re-key backfill failed", "error", err, "dry_run", dryRun)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Backfill failed: " + err.Error(), "data": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": report})
+}
+
+/**
+ * @function getMarketConsistency
+ * @description A Gin handler that compares a market's stored bars with Polymarket's prices-history.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query resolution (required): One of the enabled bar resolutions (by default "1", "5", "15", "60", or "D").
+ * @query from (required): Start of the range, Unix timestamp in seconds (inclusive).
+ * @query to (required): End of the range, Unix timestamp in seconds (inclusive).
+ *
+ * @notes
+ * - Large ranges are checked in the background: the handler responds 202 with the job
+ *   status, and repeating the same request returns the cached result once it completes.
+ */
+func (server *Server) getMarketConsistency(c *gin.Context) {
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'from' timestamp"})
+		return
+	}
+	to, err := strconv.ParseInt(c.Query("to"), 10, 64)
+	if err != nil || to <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'to' timestamp"})
+		return
+	}
+	if from >= to {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "'from' timestamp must be less than 'to' timestamp"})
+		return
+	}
+
+	job, err := server.consistencyService.Check(c.Request.Context(), services.ConsistencyParams{
+		MarketID:   c.Param("id"),
+		Resolution: c.Query("resolution"),
+		From:       from,
+		To:         to,
+	})
+	switch {
+	case errors.Is(err, services.ErrUnsupportedResolution):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'resolution' parameter"})
+		return
+	case errors.Is(err, services.ErrConsistencyRangeTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Requested range spans too many buckets"})
+		return
+	case err != nil:
+		server.logger.Error("chart consistency check failed", "error", err, "market_id", c.Param("id"))
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Consistency check failed"})
+		return
+	}
+
+	switch job.Status {
+	case services.ConsistencyJobRunning:
+		c.JSON(http.StatusAccepted, gin.H{"status": "success", "data": job})
+	case services.ConsistencyJobFailed:
+		c.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": "Consistency check failed: " + job.Error, "data": job})
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "success", "data": job})
+	}
+}
+
+/**
+ * @function getRedisMemory
+ * @description A Gin handler that reports the estimated Redis memory used by each cache prefix.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query sample (optional): Keys per prefix whose memory usage is read (default 100, max 1000).
+ *
+ * @notes
+ * - Estimates extrapolate the sampled average to every key found; at most 100000 keys are
+ *   scanned per prefix, beyond which the report is marked truncated.
+ */
+func (server *Server) getRedisMemory(c *gin.Context) {
+	sampleSize := defaultMemorySampleSize
+	if sample := c.Query("sample"); sample != "" {
+		parsed, err := strconv.Atoi(sample)
+		if err != nil || parsed <= 0 || parsed > maxMemorySampleSize {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'sample' parameter"})
+			return
+		}
+		sampleSize = parsed
+	}
+
+	var total int64
+	prefixes := make([]cachekeys.PrefixUsage, 0, len(cachekeys.All()))
+	for _, purpose := range cachekeys.All() {
+		usage, err := cachekeys.MeasureUsage(c.Request.Context(), server.redisClient, purpose, sampleSize, maxMemoryScanKeys)
+		if err != nil {
+			server.logger.Error("redis memory report failed", "error", err, "prefix", purpose.Prefix)
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Redis memory report failed"})
+			return
+		}
+		total += usage.EstimatedBytes
+		prefixes = append(prefixes, usage)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{
+		"prefixes":              prefixes,
+		"total_estimated_bytes": total,
+	}})
+}
+
+/**
+ * @function getRedisPublishBuffer
+ * @description A Gin handler that reports the Pub/Sub messages buffered after failed publishes.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query sample (optional): Channels listed, those with the most buffered messages (default 50).
+ *
+ * @notes
+ * - `dropped` counts messages lost to a full channel ring (`overflow`) or to the age limit of
+ *   order books (`stale`).
+ */
+func (server *Server) getRedisPublishBuffer(c *gin.Context) {
+	sample := defaultPublishBufferSample
+	if sampleStr := c.Query("sample"); sampleStr != "" {
+		parsed, err := strconv.Atoi(sampleStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'sample' parameter"})
+			return
+		}
+		sample = parsed
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": server.publishBuffer.Stats(sample)})
+}
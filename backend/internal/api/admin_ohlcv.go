@@ -0,0 +1,43 @@
+/**
+ * @description
+ * This file contains HTTP handlers for admin operations on the OHLCV pipeline that are
+ * exposed on the public API. Unlike the internal admin jobs, they are reachable remotely,
+ * so they are protected by authentication and restricted to the admin role.
+ *
+ * Key features:
+ * - Manual Flush: `POST /api/v1/admin/ohlcv/flush` persists every in-memory bar, including
+ *   bars still in progress, without waiting for the periodic flush.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/auth"
+)
+
+/**
+ * @function flushOHLCVAggregator
+ * @description A Gin handler that flushes the OHLCV aggregator's in-memory bars to the database.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @notes
+ * - Responds 200 with the number of bars written, or 500 with the per-bar errors if any
+ *   bar could not be saved (the other bars are still written).
+ */
+func (server *Server) flushOHLCVAggregator(c *gin.Context) {
+	result := server.marketStreamService.Aggregator().FlushAll()
+	clerkUserID := c.GetString(string(auth.ClerkUserIDKey))
+
+	if result.BarsFailed > 0 {
+		server.logger.Error("manual OHLCV flush failed for some bars", "clerk_id", clerkUserID, "bars_written", result.BarsWritten, "bars_failed", result.BarsFailed)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Some bars could not be flushed", "data": result})
+		return
+	}
+
+	server.logger.Info("manual OHLCV flush completed", "clerk_id", clerkUserID, "bars_written", result.BarsWritten)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": result})
+}
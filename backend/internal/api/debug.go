@@ -0,0 +1,57 @@
+/**
+ * @description
+ * This file contains HTTP handlers for internal diagnostics. These routes are
+ * registered only on the internal router, which is served on a localhost-only
+ * listener and is never exposed publicly.
+ *
+ * Key features:
+ * - State Dump: `GET /debug/state` assembles a one-shot snapshot of the WebSocket hub,
+ *   the market stream service, the OHLCV aggregator, the pipeline monitor, the order
+ *   submission retry queue, the order lifecycle latencies, and the Go runtime.
+ * - Bounded Output: Large maps are truncated to a small sample unless `?full=true` is given.
+ */
+
+package api
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debugStateSampleLimit is the number of entries included per map when the dump is truncated.
+const debugStateSampleLimit = 20
+
+/**
+ * @function getDebugState
+ * @description A Gin handler that returns a snapshot of the server's internal state.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query full (optional): When "true", maps are returned in full instead of truncated.
+ */
+func (server *Server) getDebugState(c *gin.Context) {
+	sampleLimit := debugStateSampleLimit
+	if c.Query("full") == "true" {
+		sampleLimit = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"generated_at":  time.Now().UTC().Format(time.RFC3339),
+			"hub":           server.hub.Stats(sampleLimit),
+			"stream":        server.marketStreamService.Stats(sampleLimit),
+			"pipeline":      server.pipelineMonitor.Health(),
+			"bar_watchdog":  server.barWatchdog.Status(),
+			"order_retries": server.polymarketService.OrderRetryStats(),
+			"order_signing": server.polymarketService.SigningStats(),
+			"order_latency": server.polymarketService.OrderLatencyStats(),
+			"runtime": gin.H{
+				"goroutines": runtime.NumGoroutine(),
+			},
+		},
+	})
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/config"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/services"
+	"github.com/poly-pro/backend/internal/websocket"
+)
+
+// debugStore is a db.Querier without any bars, which the OHLCV aggregator can start on.
+type debugStore struct {
+	historyStore
+}
+
+func (s *debugStore) ListMarketIDsWithBarsSince(context.Context, db.ListMarketIDsWithBarsSinceParams) ([]string, error) {
+	return nil, nil
+}
+
+// newDebugTestServer creates a server with the components the state dump and the status
+// summary read, none of them connected to Redis, the database or Polymarket.
+func newDebugTestServer(t *testing.T) *Server {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	stream := services.NewMarketStreamService(ctx, logger, nil, config.Config{}, &debugStore{}, nil, nil)
+	hub := websocket.NewHub(ctx, logger, nil, nil, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Run()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return &Server{
+		logger:              logger,
+		hub:                 hub,
+		marketStreamService: stream,
+		pipelineMonitor:     services.NewPipelineMonitor(ctx, logger, stream, func() int { return 0 }, 0),
+		barWatchdog:         services.NewBarWatchdog(ctx, logger, nil, stream, 0, false),
+		polymarketService:   services.NewPolymarketService(nil, logger, nil, nil, config.Config{}),
+	}
+}
+
+// debugObject returns the JSON object at a dot-separated path of state, failing if it is
+// missing or not an object (a null map included).
+func debugObject(t *testing.T, state map[string]any, path string) map[string]any {
+	t.Helper()
+	object := state
+	for _, key := range strings.Split(path, ".") {
+		next, ok := object[key].(map[string]any)
+		if !ok {
+			t.Fatalf("%s is %T, want an object", path, object[key])
+		}
+		object = next
+	}
+	return object
+}
+
+// TestGetDebugStateShape checks the sections of the state dump and the fields of each that
+// operators rely on, both sampled and with ?full=true.
+func TestGetDebugStateShape(t *testing.T) {
+	server := newDebugTestServer(t)
+	if err := server.marketStreamService.Aggregator().UpdateTrade("0xmarket", 0.5, 3, time.Now()); err != nil {
+		t.Fatalf("update trade: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/debug/state", server.getDebugState)
+
+	for _, target := range []string{"/debug/state", "/debug/state?full=true"} {
+		t.Run(target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d", w.Code)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body["status"] != "success" {
+				t.Errorf("status = %v", body["status"])
+			}
+
+			data := debugObject(t, body, "data")
+			sections := []string{"generated_at", "hub", "stream", "pipeline", "bar_watchdog", "order_retries", "order_signing", "order_latency", "runtime"}
+			if len(data) != len(sections) {
+				t.Errorf("data has %d sections, want %d", len(data), len(sections))
+			}
+			generatedAt, _ := data["generated_at"].(string)
+			if _, err := time.Parse(time.RFC3339, generatedAt); err != nil {
+				t.Errorf("generated_at = %q: %v", generatedAt, err)
+			}
+			for _, section := range sections[1:] {
+				debugObject(t, data, section)
+			}
+
+			fields := map[string][]string{
+				"hub":                                  {"clients", "subscribed_markets", "redis_listener_count", "truncated"},
+				"hub.subscriptions":                    nil,
+				"hub.redis_listeners":                  nil,
+				"stream":                               {"mode", "messages_processed", "asset_mapping_size", "truncated"},
+				"stream.asset_mapping_sample":          nil,
+				"stream.aggregator":                    {"markets", "active_bars", "total_updates"},
+				"stream.aggregator.bars_by_resolution": nil,
+				"pipeline":                             {"status", "stall_threshold"},
+				"bar_watchdog":                         {"window", "missing", "truncated"},
+				"order_retries":                        {"enabled", "depth"},
+				"runtime":                              {"goroutines"},
+			}
+			for path, keys := range fields {
+				object := debugObject(t, data, path)
+				for _, key := range keys {
+					if _, ok := object[key]; !ok {
+						t.Errorf("%s has no %q", path, key)
+					}
+				}
+			}
+
+			aggregator := debugObject(t, data, "stream.aggregator")
+			if aggregator["markets"] != 1.0 || len(debugObject(t, data, "stream.aggregator.bars_by_resolution")) == 0 {
+				t.Errorf("aggregator = %v, want the traded market's bars", aggregator)
+			}
+			if goroutines, _ := debugObject(t, data, "runtime")["goroutines"].(float64); goroutines < 1 {
+				t.Errorf("goroutines = %v", goroutines)
+			}
+		})
+	}
+}
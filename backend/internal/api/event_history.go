@@ -0,0 +1,261 @@
+/**
+ * @description
+ * This file contains the HTTP handler for the combined price history of a multi-outcome
+ * event (e.g. an election), whose outcomes are separate binary markets, so that its leading
+ * outcomes can be charted as one stacked or overlaid instrument.
+ *
+ * Key features:
+ * - Event History Endpoint: `GET /api/v1/events/:slug/history?resolution=&from=&to=&top=`
+ *   returns one series per outcome market, on a shared time axis.
+ * - Outcome Selection: The top N outcome markets of the event by liquidity are charted. The
+ *   selection is cached per event for `eventOutcomesCacheTTL`, so that the successive range
+ *   requests of a chart page through the same outcomes.
+ * - Shared Implementation: Ranges are validated and bars are loaded like the per-market
+ *   history endpoint (see market_history.go).
+ * - Bucket Alignment: The time axis is the union of the outcomes' bar times. An outcome
+ *   missing a bucket after its first bar carries its previous close forward with no volume,
+ *   like the aggregator's gap filling; buckets before its first bar are null.
+ */
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+const (
+	// Default and maximum number of outcomes returned by the event history endpoint.
+	defaultEventOutcomes = 5
+	maxEventOutcomes     = 10
+	// eventOutcomesCacheTTL is how long the outcome selection of an event is reused.
+	eventOutcomesCacheTTL = 5 * time.Minute
+)
+
+// eventOutcome is an outcome market of an event.
+type eventOutcome struct {
+	MarketID  string  `json:"market_id"` // Condition ID
+	Outcome   string  `json:"outcome"`
+	Liquidity float64 `json:"liquidity"`
+}
+
+// eventOutcomeSeries is the price series of an outcome, aligned to the event's time axis.
+// Values are null for the buckets before the outcome's first bar.
+type eventOutcomeSeries struct {
+	eventOutcome
+	Closes  []*float64 `json:"c"`
+	Volumes []*float64 `json:"v"`
+}
+
+// eventOutcomesCache holds the outcome selections of recently charted events.
+type eventOutcomesCache struct {
+	mu      sync.Mutex
+	entries map[string]eventOutcomesEntry // Keyed by event slug and outcome count
+}
+
+// eventOutcomesEntry is a cached outcome selection.
+type eventOutcomesEntry struct {
+	title    string
+	outcomes []eventOutcome
+	expires  time.Time
+}
+
+/**
+ * @function getEventHistory
+ * @description A Gin handler that returns the aligned price series of an event's top outcomes.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query resolution (required): One of the enabled bar resolutions.
+ * @query from (required): Start of the range, Unix timestamp in seconds (inclusive).
+ * @query to (required): End of the range, Unix timestamp in seconds (inclusive).
+ * @query top (optional): Number of outcomes, by liquidity (default: 5, max: 10).
+ *
+ * @notes
+ * - The response is `{"s":"ok","event":...,"title":...,"t":[...],"series":[...]}`, with each
+ *   series' `c` and `v` arrays indexed like `t`, or `{"s":"no_data"}` if no outcome has bars.
+ */
+func (server *Server) getEventHistory(c *gin.Context) {
+	slug := c.Param("slug")
+	if !isValidMarketID(slug) || strings.HasPrefix(slug, "0x") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":      "error",
+			"errmsg": "invalid event slug",
+		})
+		return
+	}
+
+	top := defaultEventOutcomes
+	if topStr := c.Query("top"); topStr != "" {
+		parsed, err := strconv.Atoi(topStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"s":      "error",
+				"errmsg": "invalid top",
+			})
+			return
+		}
+		top = min(parsed, maxEventOutcomes)
+	}
+
+	resolution := c.Query("resolution")
+	fromTime, toTime, ok := parseHistoryRange(c, resolution, c.Query("from"), c.Query("to"))
+	if !ok {
+		return
+	}
+
+	title, outcomes, err := server.eventOutcomes(c.Request.Context(), slug, top)
+	if err != nil {
+		server.logger.Warn("failed to fetch event from Gamma API", "error", err, "slug", slug)
+		c.JSON(http.StatusNotFound, gin.H{
+			"s":      "error",
+			"errmsg": "unknown event",
+		})
+		return
+	}
+
+	barsByOutcome := make([][]TradingViewBar, len(outcomes))
+	for i, outcome := range outcomes {
+		bars, err := server.loadHistoryBars(c.Request.Context(), outcome.MarketID, resolution, fromTime, toTime)
+		if err != nil {
+			server.logger.Error("failed to query market price history", "error", err, "market_id", outcome.MarketID, "event", slug)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"s":      "error",
+				"errmsg": "failed to fetch historical data",
+			})
+			return
+		}
+		barsByOutcome[i] = bars
+	}
+
+	times, closes, volumes := alignOutcomeBars(barsByOutcome)
+	if len(times) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"s": "no_data",
+		})
+		return
+	}
+
+	series := make([]eventOutcomeSeries, len(outcomes))
+	for i, outcome := range outcomes {
+		series[i] = eventOutcomeSeries{eventOutcome: outcome, Closes: closes[i], Volumes: volumes[i]}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"s":      "ok",
+		"event":  slug,
+		"title":  title,
+		"t":      times,
+		"series": series,
+	})
+}
+
+// eventOutcomes returns the title and the top outcomes by liquidity of an event, from the
+// cache or the Gamma API.
+func (server *Server) eventOutcomes(ctx context.Context, slug string, top int) (string, []eventOutcome, error) {
+	key := slug + ":" + strconv.Itoa(top)
+	cache := &server.eventOutcomesCache
+	now := time.Now()
+
+	cache.mu.Lock()
+	if entry, ok := cache.entries[key]; ok && now.Before(entry.expires) {
+		cache.mu.Unlock()
+		return entry.title, entry.outcomes, nil
+	}
+	cache.mu.Unlock()
+
+	event, err := server.gammaClient.GetEventBySlug(ctx, slug)
+	if err != nil {
+		return "", nil, err
+	}
+	outcomes := topEventOutcomes(event.Markets, top)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.entries == nil {
+		cache.entries = make(map[string]eventOutcomesEntry)
+	}
+	for k, entry := range cache.entries {
+		if now.After(entry.expires) {
+			delete(cache.entries, k)
+		}
+	}
+	cache.entries[key] = eventOutcomesEntry{title: event.Title, outcomes: outcomes, expires: now.Add(eventOutcomesCacheTTL)}
+	return event.Title, outcomes, nil
+}
+
+// topEventOutcomes returns the top outcome markets of an event by liquidity. Markets without
+// a condition ID cannot have bars and are left out.
+func topEventOutcomes(markets []polymarket.GammaMarket, top int) []eventOutcome {
+	outcomes := make([]eventOutcome, 0, len(markets))
+	for _, market := range markets {
+		if market.ConditionID == "" {
+			continue
+		}
+		name := market.GroupItemTitle
+		if name == "" {
+			name = market.Question
+		}
+		liquidity, _ := strconv.ParseFloat(market.Liquidity, 64)
+		outcomes = append(outcomes, eventOutcome{MarketID: market.ConditionID, Outcome: name, Liquidity: liquidity})
+	}
+	sort.SliceStable(outcomes, func(i, j int) bool { return outcomes[i].Liquidity > outcomes[j].Liquidity })
+	if len(outcomes) > top {
+		outcomes = outcomes[:top]
+	}
+	return outcomes
+}
+
+/**
+ * @description
+ * alignOutcomeBars aligns the bars of several outcomes on a shared time axis, the sorted
+ * union of their bar times.
+ *
+ * @param barsByOutcome The bars of each outcome, in ascending time order.
+ * @returns The time axis, and the closes and volumes of each outcome indexed like it. A bucket
+ * an outcome has no bar for carries its previous close with zero volume, or is nil before its
+ * first bar.
+ */
+func alignOutcomeBars(barsByOutcome [][]TradingViewBar) ([]int64, [][]*float64, [][]*float64) {
+	timeSet := make(map[int64]bool)
+	for _, bars := range barsByOutcome {
+		for _, bar := range bars {
+			timeSet[bar.Time] = true
+		}
+	}
+	times := make([]int64, 0, len(timeSet))
+	for t := range timeSet {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	closes := make([][]*float64, len(barsByOutcome))
+	volumes := make([][]*float64, len(barsByOutcome))
+	for i, bars := range barsByOutcome {
+		closes[i] = make([]*float64, len(times))
+		volumes[i] = make([]*float64, len(times))
+		next := 0
+		var lastClose *float64
+		for j, t := range times {
+			if next < len(bars) && bars[next].Time == t {
+				closeValue, volume := bars[next].Close, bars[next].Volume
+				lastClose = &closeValue
+				closes[i][j], volumes[i][j] = lastClose, &volume
+				next++
+				continue
+			}
+			if lastClose != nil {
+				zero := 0.0
+				closes[i][j], volumes[i][j] = lastClose, &zero
+			}
+		}
+	}
+	return times, closes, volumes
+}
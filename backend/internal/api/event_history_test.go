@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// values dereferences an aligned series, with nil for the buckets without a value.
+func values(series []*float64) []any {
+	out := make([]any, len(series))
+	for i, value := range series {
+		if value != nil {
+			out[i] = *value
+		}
+	}
+	return out
+}
+
+// TestAlignOutcomeBars aligns outcomes whose histories start at different times, and one
+// without history, and checks the shared time axis and the carried closes.
+func TestAlignOutcomeBars(t *testing.T) {
+	bar := func(minute int64, close, volume float64) TradingViewBar {
+		return TradingViewBar{Time: minute * 60, Close: close, Volume: volume}
+	}
+	early := []TradingViewBar{bar(1, 0.50, 10), bar(2, 0.52, 20), bar(3, 0.55, 30), bar(4, 0.53, 40)}
+	// Starts two buckets later, and misses the fourth bucket.
+	late := []TradingViewBar{bar(3, 0.10, 5), bar(5, 0.12, 7)}
+
+	times, closes, volumes := alignOutcomeBars([][]TradingViewBar{early, late, nil})
+	if want := []int64{60, 120, 180, 240, 300}; !reflect.DeepEqual(times, want) {
+		t.Fatalf("times = %v, want %v", times, want)
+	}
+	tests := []struct {
+		name        string
+		closes      []any
+		volumes     []any
+		wantCloses  []any
+		wantVolumes []any
+	}{
+		// After its last bar, the previous close is carried with no volume.
+		{"early", values(closes[0]), values(volumes[0]), []any{0.50, 0.52, 0.55, 0.53, 0.53}, []any{10.0, 20.0, 30.0, 40.0, 0.0}},
+		// Before its first bar there is no value; in its gap the close is carried.
+		{"late", values(closes[1]), values(volumes[1]), []any{nil, nil, 0.10, 0.10, 0.12}, []any{nil, nil, 5.0, 0.0, 7.0}},
+		{"no history", values(closes[2]), values(volumes[2]), []any{nil, nil, nil, nil, nil}, []any{nil, nil, nil, nil, nil}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.closes, tt.wantCloses) || !reflect.DeepEqual(tt.volumes, tt.wantVolumes) {
+			t.Errorf("%s: closes %v, volumes %v; want %v, %v", tt.name, tt.closes, tt.volumes, tt.wantCloses, tt.wantVolumes)
+		}
+	}
+
+	if times, _, _ := alignOutcomeBars([][]TradingViewBar{nil, nil}); len(times) != 0 {
+		t.Errorf("times without bars = %v, want none", times)
+	}
+}
+
+func TestTopEventOutcomes(t *testing.T) {
+	markets := []polymarket.GammaMarket{
+		{ConditionID: "0xa", GroupItemTitle: "Alice", Liquidity: "1000"},
+		{ConditionID: "0xb", GroupItemTitle: "Bob", Liquidity: "5000.5"},
+		// Without a condition ID a market cannot have bars.
+		{GroupItemTitle: "Carol", Liquidity: "9000"},
+		{ConditionID: "0xd", Question: "Will Dave win?", Liquidity: "1000"},
+		{ConditionID: "0xe", GroupItemTitle: "Eve", Liquidity: "not a number"},
+	}
+	tests := []struct {
+		top  int
+		want []eventOutcome
+	}{
+		// Ties keep the event's order.
+		{3, []eventOutcome{{"0xb", "Bob", 5000.5}, {"0xa", "Alice", 1000}, {"0xd", "Will Dave win?", 1000}}},
+		{10, []eventOutcome{{"0xb", "Bob", 5000.5}, {"0xa", "Alice", 1000}, {"0xd", "Will Dave win?", 1000}, {"0xe", "Eve", 0}}},
+	}
+	for _, tt := range tests {
+		if got := topEventOutcomes(markets, tt.top); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("top %d = %+v, want %+v", tt.top, got, tt.want)
+		}
+	}
+}
+
+// eventHistoryStore is a db.Querier that keeps the price history of each market.
+type eventHistoryStore struct {
+	db.Querier
+	bars map[string][]db.MarketPriceHistory
+}
+
+func (s *eventHistoryStore) GetMarketPriceHistory(_ context.Context, arg db.GetMarketPriceHistoryParams) ([]db.MarketPriceHistory, error) {
+	return s.bars[arg.MarketID], nil
+}
+
+// TestGetEventHistory charts the top two outcomes of an event whose leading outcome's history
+// starts later, and checks the aligned series and that the outcome selection is cached.
+func TestGetEventHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	start := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+	bar := func(minute int, close string) db.MarketPriceHistory {
+		return db.MarketPriceHistory{
+			Time:   pgtype.Timestamptz{Time: start.Add(time.Duration(minute) * time.Minute), Valid: true},
+			Open:   numeric(t, close),
+			High:   numeric(t, close),
+			Low:    numeric(t, close),
+			Close:  numeric(t, close),
+			Volume: numeric(t, "1"),
+		}
+	}
+	store := &eventHistoryStore{bars: map[string][]db.MarketPriceHistory{
+		"0xleader": {bar(2, "0.6"), bar(3, "0.65")},
+		"0xsecond": {bar(0, "0.3"), bar(1, "0.32"), bar(3, "0.3")},
+		"0xthird":  {bar(0, "0.1")},
+	}}
+
+	var lookups atomic.Int32
+	gamma := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events/slug/election" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"error":"not found"}`)
+			return
+		}
+		lookups.Add(1)
+		io.WriteString(w, `{"id":"1","slug":"election","title":"Who will win?","markets":[
+			{"conditionId":"0xthird","groupItemTitle":"Carol","liquidity":"100"},
+			{"conditionId":"0xleader","groupItemTitle":"Alice","liquidity":"9000"},
+			{"conditionId":"0xsecond","groupItemTitle":"Bob","liquidity":"4000"}]}`)
+	}))
+	defer gamma.Close()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := &Server{store: store, gammaClient: polymarket.NewGammaAPIClient(gamma.URL, logger), logger: logger}
+	request := func(slug, query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/events/"+slug+"/history?"+query, nil)
+		c.Params = gin.Params{{Key: "slug", Value: slug}}
+		server.getEventHistory(c)
+		return recorder
+	}
+	rangeQuery := fmt.Sprintf("resolution=1&from=%d&to=%d", start.Unix(), start.Add(3*time.Minute).Unix())
+	query := rangeQuery + "&top=2"
+
+	recorder := request("election", query)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+	}
+	var response struct {
+		S      string  `json:"s"`
+		Title  string  `json:"title"`
+		T      []int64 `json:"t"`
+		Series []struct {
+			MarketID string     `json:"market_id"`
+			Outcome  string     `json:"outcome"`
+			C        []*float64 `json:"c"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode %s: %v", recorder.Body, err)
+	}
+	minute := func(m int) int64 { return start.Add(time.Duration(m) * time.Minute).Unix() }
+	if response.S != "ok" || response.Title != "Who will win?" || !reflect.DeepEqual(response.T, []int64{minute(0), minute(1), minute(2), minute(3)}) {
+		t.Errorf("response %s, %q, times %v; want ok, the event's title and four buckets", response.S, response.Title, response.T)
+	}
+	if len(response.Series) != 2 {
+		t.Fatalf("%d series, want the top 2", len(response.Series))
+	}
+	wantSeries := []struct {
+		marketID, outcome string
+		closes            []any
+	}{
+		{"0xleader", "Alice", []any{nil, nil, 0.6, 0.65}},
+		{"0xsecond", "Bob", []any{0.3, 0.32, 0.32, 0.3}},
+	}
+	for i, want := range wantSeries {
+		got := response.Series[i]
+		if got.MarketID != want.marketID || got.Outcome != want.outcome || !reflect.DeepEqual(values(got.C), want.closes) {
+			t.Errorf("series %d = %s (%s) %v, want %s (%s) %v", i, got.MarketID, got.Outcome, values(got.C), want.marketID, want.outcome, want.closes)
+		}
+	}
+
+	// A chart paging to another range reuses the outcome selection.
+	earlier := fmt.Sprintf("resolution=1&from=%d&to=%d&top=2", start.Add(-time.Hour).Unix(), start.Unix())
+	if recorder := request("election", earlier); recorder.Code != http.StatusOK || lookups.Load() != 1 {
+		t.Errorf("second request: status %d, %d Gamma lookups; want 200 and 1", recorder.Code, lookups.Load())
+	}
+
+	errorCases := []struct {
+		name, slug, query string
+		want              int
+	}{
+		{"unknown event", "unknown", query, http.StatusNotFound},
+		{"condition ID", "0x" + fmt.Sprintf("%064x", 1), query, http.StatusBadRequest},
+		{"invalid top", "election", rangeQuery + "&top=0", http.StatusBadRequest},
+		{"unsupported resolution", "election", fmt.Sprintf("resolution=7&from=%d&to=%d", start.Unix(), start.Unix()), http.StatusBadRequest},
+	}
+	for _, tt := range errorCases {
+		if recorder := request(tt.slug, tt.query); recorder.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
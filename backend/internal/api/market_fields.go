@@ -0,0 +1,92 @@
+/**
+ * @description
+ * This file implements partial responses for the markets list, so that clients that only
+ * need a few fields (e.g. a market dropdown) do not download every field of every market.
+ *
+ * Key features:
+ * - Fields Selector: `fields=id,title,category` keeps only the named fields of each market.
+ *   Names are the JSON names of the `MarketListItem` fields, resolved from its struct tags.
+ * - Compact Form: `compact=true` returns each market as an `[id, title]` tuple.
+ *
+ * @notes
+ * - Unknown field names are rejected rather than ignored, so typos surface as a 400.
+ * - Selected fields are always present in the output, even those omitted when empty in the
+ *   full form.
+ */
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// marketListItemFields maps the JSON name of each MarketListItem field to its struct index.
+var marketListItemFields = jsonFieldIndex(reflect.TypeOf(MarketListItem{}))
+
+// jsonFieldIndex maps the JSON names of a struct type's fields to their indices.
+func jsonFieldIndex(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+/**
+ * @description
+ * parseMarketFields parses and validates a comma-separated `fields` parameter against the
+ * MarketListItem fields.
+ *
+ * @param param The raw `fields` query parameter.
+ * @returns The field names in the requested order, without duplicates.
+ * @returns An error naming the first unknown field, or if no field is named.
+ */
+func parseMarketFields(param string) ([]string, error) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := marketListItemFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("fields must name at least one field")
+	}
+	return fields, nil
+}
+
+// selectMarketFields prunes each market to the given (validated) fields.
+func selectMarketFields(markets []MarketListItem, fields []string) []map[string]any {
+	selected := make([]map[string]any, 0, len(markets))
+	for _, market := range markets {
+		value := reflect.ValueOf(market)
+		item := make(map[string]any, len(fields))
+		for _, name := range fields {
+			item[name] = value.Field(marketListItemFields[name]).Interface()
+		}
+		selected = append(selected, item)
+	}
+	return selected
+}
+
+// compactMarkets returns each market as an [id, title] tuple.
+func compactMarkets(markets []MarketListItem) [][2]string {
+	compact := make([][2]string, 0, len(markets))
+	for _, market := range markets {
+		compact = append(compact, [2]string{market.ID, market.Title})
+	}
+	return compact
+}
@@ -11,11 +11,19 @@
  * - TradingView Compatibility: The response format is structured specifically for
  *   TradingView's UDF (Unified Data Format) adapter, with fields for time, open, high,
  *   low, close, and volume.
+ * - Shared Implementation: The UDF datafeed's `/api/v1/udf/history` (see udf.go) is served by
+ *   the same code, so both routes behave identically.
+ * - Bad Bar Isolation: Bars with a NaN or infinite value are skipped with a warning, since
+ *   they cannot be encoded as JSON and would otherwise break the whole response.
  */
 
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -23,6 +31,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/services"
 )
 
 // TradingViewBar represents a single OHLCV bar for the TradingView chart.
@@ -43,16 +52,25 @@ type TradingViewBar struct {
  *
  * @param c *gin.Context The Gin context for the request.
  *
+ * @query from (required): Start of the range, Unix timestamp in seconds (inclusive).
+ * @query to (required): End of the range, Unix timestamp in seconds (inclusive).
+ * @query resolution (required): One of the enabled bar resolutions (by default "1", "5", "15", "60", or "D").
+ *
  * @notes
+ * - Timestamps are seconds since the Unix epoch and are interpreted in UTC, whatever the
+ *   server's time zone. `to` is clamped to the current time, and both boundaries are snapped
+ *   to the start of their bar, so a range starting mid-bar (e.g. a daily query starting
+ *   after UTC midnight) still includes that bar.
  * - This handler queries the `market_price_history` partitioned table for real historical data.
  * - The response structure is tailored for the TradingView charting library's UDF adapter.
  * - Data is filtered by market ID, time range, and resolution for efficient querying.
+ * - Resolutions the aggregator does not produce (see services.Resolutions) are rejected.
  */
 func (server *Server) getMarketHistory(c *gin.Context) {
 	marketID := c.Param("id")
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
-	resolution := c.Query("resolution") // e.g., "1", "5", "15", "60", "D"
+	resolution := c.Query("resolution") // One of the enabled resolutions, e.g. "1", "5", "15", "60", "D"
 
 	server.logger.Info("received market history request",
 		"market_id", marketID,
@@ -61,15 +79,106 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 		"resolution", resolution,
 	)
 
-	// Validate marketID
-	if marketID == "" {
+	server.writeMarketHistory(c, marketID, resolution, fromStr, toStr)
+}
+
+/**
+ * @description
+ * writeMarketHistory validates a history request and writes the market's bars in the UDF
+ * format. It serves both getMarketHistory and the UDF datafeed's history endpoint.
+ *
+ * @param c The Gin context to write the response to.
+ * @param marketID The market's condition ID or slug.
+ * @param resolution One of the enabled bar resolutions.
+ * @param fromStr Start of the range, Unix timestamp in seconds (inclusive).
+ * @param toStr End of the range, Unix timestamp in seconds (inclusive).
+ */
+func (server *Server) writeMarketHistory(c *gin.Context, marketID, resolution, fromStr, toStr string) {
+	// Validate marketID (a condition ID or slug; anything else is rejected before querying)
+	if !isValidMarketID(marketID) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"s":    "error",
-			"errmsg": "market ID is required",
+			"errmsg": "invalid market ID",
+		})
+		return
+	}
+
+	fromTime, toTime, ok := parseHistoryRange(c, resolution, fromStr, toStr)
+	if !ok {
+		return
+	}
+
+	bars, err := server.loadHistoryBars(c.Request.Context(), marketID, resolution, fromTime, toTime)
+	if err != nil {
+		server.logger.Error("failed to query market price history", "error", err, "market_id", marketID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"s":    "error",
+			"errmsg": "failed to fetch historical data",
 		})
 		return
 	}
 
+	// TradingView UDF adapter expects `s: "ok"` for success and `s: "no_data"` if no bars are found.
+	if len(bars) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"s": "no_data",
+		})
+		return
+	}
+
+	// Build response arrays safely without type assertions
+	times := make([]int64, len(bars))
+	opens := make([]float64, len(bars))
+	highs := make([]float64, len(bars))
+	lows := make([]float64, len(bars))
+	closes := make([]float64, len(bars))
+	volumes := make([]float64, len(bars))
+
+	for i, b := range bars {
+		times[i] = b.Time
+		opens[i] = b.Open
+		highs[i] = b.High
+		lows[i] = b.Low
+		closes[i] = b.Close
+		volumes[i] = b.Volume
+	}
+
+	// The UDF format expects separate arrays for each field.
+	response := gin.H{
+		"s": "ok",
+		"t": times,
+		"o": opens,
+		"h": highs,
+		"l": lows,
+		"c": closes,
+		"v": volumes,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+/**
+ * @description
+ * parseHistoryRange validates the resolution and time range of a history request, writing a
+ * UDF error response if they are invalid.
+ *
+ * @param c The Gin context to write an error response to.
+ * @param resolution One of the enabled bar resolutions.
+ * @param fromStr Start of the range, Unix timestamp in seconds (inclusive).
+ * @param toStr End of the range, Unix timestamp in seconds (inclusive).
+ * @returns The range snapped to bar starts, and whether it is valid.
+ */
+func parseHistoryRange(c *gin.Context, resolution, fromStr, toStr string) (time.Time, time.Time, bool) {
+	// Only resolutions the aggregator produces can have bars
+	_, ok := services.ResolutionDuration(resolution)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":    "error",
+			"errmsg": "unsupported resolution",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+
 	// Parse and validate timestamps
 	from, err := strconv.ParseInt(fromStr, 10, 64)
 	if err != nil || from <= 0 {
@@ -77,7 +186,7 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 			"s":    "error",
 			"errmsg": "invalid 'from' timestamp",
 		})
-		return
+		return time.Time{}, time.Time{}, false
 	}
 
 	to, err := strconv.ParseInt(toStr, 10, 64)
@@ -86,7 +195,7 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 			"s":    "error",
 			"errmsg": "invalid 'to' timestamp",
 		})
-		return
+		return time.Time{}, time.Time{}, false
 	}
 
 	if from >= to {
@@ -94,32 +203,43 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 			"s":    "error",
 			"errmsg": "'from' timestamp must be less than 'to' timestamp",
 		})
-		return
+		return time.Time{}, time.Time{}, false
 	}
 
-	// Convert Unix timestamps to time.Time
-	fromTime := time.Unix(from, 0)
-	toTime := time.Unix(to, 0)
+	// Convert the Unix timestamps to UTC times snapped to bar starts, which are aligned to
+	// the Unix epoch (daily bars start at UTC midnight, weekly bars on Monday). Bars are
+	// stored under their start time, so the bar containing 'from' would otherwise be missed.
+	toTime := time.Unix(to, 0).UTC()
+	if now := time.Now().UTC(); toTime.After(now) {
+		toTime = now
+	}
+	fromTime := services.BarStart(time.Unix(from, 0).UTC(), resolution)
+	toTime = services.BarStart(toTime, resolution)
 
+	return fromTime, toTime, true
+}
+
+/**
+ * @description
+ * loadHistoryBars loads the stored bars of a market in a range, skipping bars with invalid values.
+ *
+ * @param ctx The context for the database query.
+ * @param marketID The market's condition ID.
+ * @param resolution One of the enabled bar resolutions.
+ * @param fromTime Start of the range, a bar start (inclusive).
+ * @param toTime End of the range, a bar start (inclusive).
+ * @returns The bars in ascending time order.
+ */
+func (server *Server) loadHistoryBars(ctx context.Context, marketID, resolution string, fromTime, toTime time.Time) ([]TradingViewBar, error) {
 	// Query the database for historical data
 	var fromTimeVal pgtype.Timestamptz
 	if err := fromTimeVal.Scan(fromTime); err != nil {
-		server.logger.Error("failed to convert from time", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"s":    "error",
-			"errmsg": "failed to process time range",
-		})
-		return
+		return nil, fmt.Errorf("failed to convert from time: %w", err)
 	}
 
 	var toTimeVal pgtype.Timestamptz
 	if err := toTimeVal.Scan(toTime); err != nil {
-		server.logger.Error("failed to convert to time", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"s":    "error",
-			"errmsg": "failed to process time range",
-		})
-		return
+		return nil, fmt.Errorf("failed to convert to time: %w", err)
 	}
 
 	// Query database with resolution filter
@@ -130,14 +250,9 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 		Resolution: resolution,
 	}
 
-	dbBars, err := server.store.GetMarketPriceHistory(c.Request.Context(), arg)
+	dbBars, err := server.store.GetMarketPriceHistory(ctx, arg)
 	if err != nil {
-		server.logger.Error("failed to query market price history", "error", err, "market_id", marketID)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"s":    "error",
-			"errmsg": "failed to fetch historical data",
-		})
-		return
+		return nil, err
 	}
 
 	// Convert database results to TradingViewBar format
@@ -151,49 +266,33 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 		}
 		barTime := dbBar.Time.Time
 
-		// Convert pgtype.Numeric to float64
-		convertNumeric := func(n pgtype.Numeric) (float64, error) {
-			if !n.Valid {
-				return 0, nil
-			}
-			// Use Float64Value() which returns a Float8, then get its value
-			float8Val, err := n.Float64Value()
-			if err != nil {
-				return 0, err
-			}
-			if !float8Val.Valid {
-				return 0, nil
-			}
-			return float8Val.Float64, nil
-		}
-
 		open, err := convertNumeric(dbBar.Open)
 		if err != nil {
-			server.logger.Warn("failed to convert open price", "error", err)
+			server.logger.Warn("failed to convert open price, skipping bar", "error", err, "market_id", marketID, "time", barTime)
 			continue
 		}
 
 		high, err := convertNumeric(dbBar.High)
 		if err != nil {
-			server.logger.Warn("failed to convert high price", "error", err)
+			server.logger.Warn("failed to convert high price, skipping bar", "error", err, "market_id", marketID, "time", barTime)
 			continue
 		}
 
 		low, err := convertNumeric(dbBar.Low)
 		if err != nil {
-			server.logger.Warn("failed to convert low price", "error", err)
+			server.logger.Warn("failed to convert low price, skipping bar", "error", err, "market_id", marketID, "time", barTime)
 			continue
 		}
 
 		close, err := convertNumeric(dbBar.Close)
 		if err != nil {
-			server.logger.Warn("failed to convert close price", "error", err)
+			server.logger.Warn("failed to convert close price, skipping bar", "error", err, "market_id", marketID, "time", barTime)
 			continue
 		}
 
 		volume, err := convertNumeric(dbBar.Volume)
 		if err != nil {
-			server.logger.Warn("failed to convert volume", "error", err)
+			server.logger.Warn("failed to convert volume, skipping bar", "error", err, "market_id", marketID, "time", barTime)
 			continue
 		}
 
@@ -206,43 +305,34 @@ func (server *Server) getMarketHistory(c *gin.Context) {
 			Volume: volume,
 		})
 	}
+	return bars, nil
+}
 
-	// TradingView UDF adapter expects `s: "ok"` for success and `s: "no_data"` if no bars are found.
-	if len(bars) == 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"s": "no_data",
-		})
-		return
-	}
-
-	// Build response arrays safely without type assertions
-	times := make([]int64, len(bars))
-	opens := make([]float64, len(bars))
-	highs := make([]float64, len(bars))
-	lows := make([]float64, len(bars))
-	closes := make([]float64, len(bars))
-	volumes := make([]float64, len(bars))
+// errNonFiniteNumeric is returned for NaN or infinite numerics, which cannot be encoded as JSON.
+var errNonFiniteNumeric = errors.New("numeric is not finite")
 
-	for i, b := range bars {
-		times[i] = b.Time
-		opens[i] = b.Open
-		highs[i] = b.High
-		lows[i] = b.Low
-		closes[i] = b.Close
-		volumes[i] = b.Volume
+// convertNumeric converts a pgtype.Numeric to float64. NULL converts to 0, and NaN or
+// infinite values (including finite numerics too large for a float64) are rejected.
+func convertNumeric(n pgtype.Numeric) (float64, error) {
+	if !n.Valid {
+		return 0, nil
 	}
-
-	// The UDF format expects separate arrays for each field.
-	response := gin.H{
-		"s": "ok",
-		"t": times,
-		"o": opens,
-		"h": highs,
-		"l": lows,
-		"c": closes,
-		"v": volumes,
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return 0, errNonFiniteNumeric
 	}
-
-	c.JSON(http.StatusOK, response)
+	// Use Float64Value() which returns a Float8, then get its value
+	float8Val, err := n.Float64Value()
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("%w: %v", errNonFiniteNumeric, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !float8Val.Valid {
+		return 0, nil
+	}
+	if math.IsNaN(float8Val.Float64) || math.IsInf(float8Val.Float64, 0) {
+		return 0, fmt.Errorf("%w: %v", errNonFiniteNumeric, float8Val.Float64)
+	}
+	return float8Val.Float64, nil
 }
-
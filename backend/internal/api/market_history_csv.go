@@ -0,0 +1,146 @@
+/**
+ * @description
+ * This file contains the HTTP handler for exporting historical OHLCV data as CSV,
+ * for offline analysis of a market's price history.
+ *
+ * Key features:
+ * - CSV Export Endpoint: Exposes `GET /api/v1/markets/:id/history.csv` returning the bars
+ *   as `time,open,high,low,close,volume` rows, where time is a Unix timestamp (seconds).
+ * - Streaming: The range is read in fixed-size windows with the same query as the UDF
+ *   history endpoint, and each window is flushed to the client before the next is read,
+ *   so large exports never hold the whole result in memory.
+ * - Range Guard: A single export may cover at most `maxHistoryExportBars` bars of the
+ *   requested resolution.
+ */
+
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+const (
+	// maxHistoryExportBars caps the number of bars a single CSV export can span.
+	maxHistoryExportBars = 100000
+	// historyExportWindowBars is the number of bars read from the database per window.
+	historyExportWindowBars = 5000
+)
+
+/**
+ * @function exportMarketHistoryCSV
+ * @description A Gin handler that streams historical OHLCV data for a market as CSV.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query from (required): Start of the range, Unix timestamp in seconds (inclusive).
+ * @query to (required): End of the range, Unix timestamp in seconds (inclusive).
+ * @query resolution (required): One of the enabled bar resolutions (by default "1", "5", "15", "60", or "D").
+ *
+ * @notes
+ * - Validation errors are reported as JSON before any CSV is written. Once streaming has
+ *   started, a database error truncates the download and is only logged.
+ */
+func (server *Server) exportMarketHistoryCSV(c *gin.Context) {
+	marketID := c.Param("id")
+	resolution := c.Query("resolution")
+
+	if !isValidMarketID(marketID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid market ID"})
+		return
+	}
+
+	barDuration, ok := services.ResolutionDuration(resolution)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'resolution' parameter"})
+		return
+	}
+
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'from' timestamp"})
+		return
+	}
+	to, err := strconv.ParseInt(c.Query("to"), 10, 64)
+	if err != nil || to <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'to' timestamp"})
+		return
+	}
+	if from >= to {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "'from' timestamp must be less than 'to' timestamp"})
+		return
+	}
+
+	fromTime := time.Unix(from, 0).UTC()
+	toTime := time.Unix(to, 0).UTC()
+	if maxRange := barDuration * maxHistoryExportBars; toTime.Sub(fromTime) > maxRange {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": fmt.Sprintf("Requested range is too large; at most %d bars (%s) can be exported at resolution %s", maxHistoryExportBars, maxRange, resolution),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s_%d_%d.csv", marketID, resolution, from, to)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"time", "open", "high", "low", "close", "volume"})
+
+	// Windows are half-open except the last, because the query bounds are inclusive.
+	window := barDuration * historyExportWindowBars
+	rows := 0
+	for windowStart := fromTime; !windowStart.After(toTime); windowStart = windowStart.Add(window) {
+		windowEnd := windowStart.Add(window - time.Nanosecond)
+		if windowEnd.After(toTime) {
+			windowEnd = toTime
+		}
+
+		bars, err := server.store.GetMarketPriceHistory(c.Request.Context(), db.GetMarketPriceHistoryParams{
+			MarketID:   marketID,
+			Time:       pgtype.Timestamptz{Time: windowStart, Valid: true},
+			Time_2:     pgtype.Timestamptz{Time: windowEnd, Valid: true},
+			Resolution: resolution,
+		})
+		if err != nil {
+			server.logger.Error("failed to query market price history for CSV export", "error", err, "market_id", marketID, "rows_written", rows)
+			writer.Flush()
+			c.Abort()
+			return
+		}
+
+		for _, bar := range bars {
+			if !bar.Time.Valid {
+				continue
+			}
+			writer.Write([]string{
+				strconv.FormatInt(bar.Time.Time.Unix(), 10),
+				numericString(bar.Open),
+				numericString(bar.High),
+				numericString(bar.Low),
+				numericString(bar.Close),
+				numericString(bar.Volume),
+			})
+			rows++
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			server.logger.Warn("CSV export aborted while writing", "error", err, "market_id", marketID, "rows_written", rows)
+			return
+		}
+		c.Writer.Flush()
+	}
+
+	server.logger.Info("exported market history as CSV", "market_id", marketID, "resolution", resolution, "rows", rows)
+}
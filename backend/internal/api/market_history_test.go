@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// numeric builds a pgtype.Numeric from a decimal string such as "0.42", "NaN" or "Infinity".
+func numeric(t *testing.T, value string) pgtype.Numeric {
+	t.Helper()
+	var n pgtype.Numeric
+	if err := n.Scan(value); err != nil {
+		t.Fatalf("numeric %q: %v", value, err)
+	}
+	return n
+}
+
+func TestConvertNumeric(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   pgtype.Numeric
+		want    float64
+		wantErr bool
+	}{
+		{"decimal", numeric(t, "0.42"), 0.42, false},
+		{"negative", numeric(t, "-17.5"), -17.5, false},
+		{"zero", numeric(t, "0"), 0, false},
+		{"null", pgtype.Numeric{}, 0, false},
+		{"large exponent within float64", pgtype.Numeric{Int: big.NewInt(12), Exp: 300, Valid: true}, 12e300, false},
+		{"small exponent", pgtype.Numeric{Int: big.NewInt(5), Exp: -320, Valid: true}, 5e-320, false},
+		{"NaN", numeric(t, "NaN"), 0, true},
+		{"infinity", numeric(t, "Infinity"), 0, true},
+		{"negative infinity", numeric(t, "-Infinity"), 0, true},
+		// Finite in PostgreSQL, but infinite once converted to a float64.
+		{"exponent beyond float64", pgtype.Numeric{Int: big.NewInt(1), Exp: 400, Valid: true}, 0, true},
+		{"negative exponent beyond float64", pgtype.Numeric{Int: big.NewInt(-1), Exp: 400, Valid: true}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertNumeric(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, errNonFiniteNumeric) {
+					t.Errorf("convertNumeric = %v, %v; want %v", got, err, errNonFiniteNumeric)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("convertNumeric = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// historyStore is a db.Querier that returns a fixed market price history.
+type historyStore struct {
+	db.Querier
+	bars []db.MarketPriceHistory
+}
+
+func (s *historyStore) GetMarketPriceHistory(context.Context, db.GetMarketPriceHistoryParams) ([]db.MarketPriceHistory, error) {
+	return s.bars, nil
+}
+
+// TestLoadHistoryBarsSkipsNonFiniteBars checks that a bar with a NaN or infinite value is
+// left out, and that the other bars still encode as valid JSON.
+func TestLoadHistoryBarsSkipsNonFiniteBars(t *testing.T) {
+	start := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+	bar := func(minute int, open, close string) db.MarketPriceHistory {
+		return db.MarketPriceHistory{
+			Time:   pgtype.Timestamptz{Time: start.Add(time.Duration(minute) * time.Minute), Valid: true},
+			Open:   numeric(t, open),
+			High:   numeric(t, "0.5"),
+			Low:    numeric(t, "0.4"),
+			Close:  numeric(t, close),
+			Volume: numeric(t, "10"),
+		}
+	}
+	server := &Server{
+		store:  &historyStore{bars: []db.MarketPriceHistory{bar(0, "0.41", "0.42"), bar(1, "NaN", "0.43"), bar(2, "0.43", "Infinity"), bar(3, "0.44", "0.45")}},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	bars, err := server.loadHistoryBars(context.Background(), "0xmarket", "1", start, start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("loadHistoryBars: %v", err)
+	}
+	if len(bars) != 2 || bars[0].Close != 0.42 || bars[1].Close != 0.45 {
+		t.Fatalf("bars = %+v, want the first and last bar", bars)
+	}
+	if _, err := json.Marshal(bars); err != nil {
+		t.Errorf("bars do not encode as JSON: %v", err)
+	}
+}
+
+// TestParseHistoryRangeDailyNearMidnight requests daily bars just before and just after UTC
+// midnight with the server in several local zones, and checks that the range is snapped to
+// the same UTC midnights in each zone.
+func TestParseHistoryRangeDailyNearMidnight(t *testing.T) {
+	midnight := time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		from, to time.Time
+		wantFrom time.Time
+		wantTo   time.Time
+	}{
+		{"just before midnight", midnight.AddDate(0, 0, -2).Add(time.Second), midnight.Add(-time.Second), midnight.AddDate(0, 0, -2), midnight.AddDate(0, 0, -1)},
+		{"just after midnight", midnight.AddDate(0, 0, -2).Add(time.Second), midnight.Add(time.Second), midnight.AddDate(0, 0, -2), midnight},
+		{"on midnight", midnight.AddDate(0, 0, -1), midnight, midnight.AddDate(0, 0, -1), midnight},
+	}
+
+	original := time.Local
+	t.Cleanup(func() { time.Local = original })
+	for _, zone := range []string{"UTC", "America/New_York", "Asia/Kolkata", "Pacific/Kiritimati"} {
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			t.Fatalf("load location: %v", err)
+		}
+		time.Local = location
+		for _, tt := range tests {
+			t.Run(zone+"/"+tt.name, func(t *testing.T) {
+				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+				from, to, ok := parseHistoryRange(c, "D", strconv.FormatInt(tt.from.Unix(), 10), strconv.FormatInt(tt.to.Unix(), 10))
+				if !ok {
+					t.Fatal("range rejected")
+				}
+				if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+					t.Errorf("range = %s to %s, want %s to %s", from, to, tt.wantFrom, tt.wantTo)
+				}
+				if from.Location() != time.UTC || to.Location() != time.UTC {
+					t.Errorf("range in %s and %s, want UTC", from.Location(), to.Location())
+				}
+			})
+		}
+	}
+}
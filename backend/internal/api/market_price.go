@@ -0,0 +1,94 @@
+/**
+ * @description
+ * This file contains the HTTP handler for a market's latest price.
+ *
+ * Key features:
+ * - Price Endpoint: Exposes `GET /api/v1/markets/:id/price` returning the latest mid-price,
+ *   its time, and whether it came from the Redis cache or the stored bars.
+ * - Service Delegation: The cache read and database fallback live in the `AnalyticsService`.
+ * - Last Price Endpoint: Exposes `GET /api/v1/markets/:id/last-price` returning the close of the
+ *   market's current in-memory bar, falling back to its latest stored bar, in the
+ *   `s`/`errmsg` format of the history endpoint.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+/**
+ * @function getMarketPrice
+ * @description A Gin handler that returns the latest price of a market.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ */
+func (server *Server) getMarketPrice(c *gin.Context) {
+	marketID := c.Param("id")
+	if !isValidMarketID(marketID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid market ID"})
+		return
+	}
+
+	price, err := server.analyticsService.LastPrice(c.Request.Context(), marketID)
+	if err != nil {
+		if errors.Is(err, services.ErrPriceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "No price recorded for market"})
+			return
+		}
+		server.logger.Error("failed to read market price", "error", err, "market_id", marketID)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to read market price"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": price})
+}
+
+/**
+ * @function getMarketLastPrice
+ * @description A Gin handler that returns the latest price of a market as `{market_id, price, time}`,
+ * from the OHLCV aggregator's current bar or, failing that, the latest stored bar.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @notes
+ * - Like getMarketHistory, the response has `s: "ok"`, or `s: "no_data"` (here with status 404)
+ *   when the market has no bars, and errors are reported as `s: "error"` with `errmsg`.
+ * - `time` is a Unix timestamp in seconds: the time of the latest update of the current bar,
+ *   or the start of the stored bar.
+ */
+func (server *Server) getMarketLastPrice(c *gin.Context) {
+	marketID := c.Param("id")
+	if !isValidMarketID(marketID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":      "error",
+			"errmsg": "invalid market ID",
+		})
+		return
+	}
+
+	price, err := server.marketStreamService.Aggregator().LatestPrice(c.Request.Context(), marketID)
+	if err != nil {
+		if errors.Is(err, services.ErrPriceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"s": "no_data"})
+			return
+		}
+		server.logger.Error("failed to read market last price", "error", err, "market_id", marketID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"s":      "error",
+			"errmsg": "failed to fetch last price",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"s":         "ok",
+		"market_id": price.MarketID,
+		"price":     price.Price,
+		"time":      price.Timestamp.Unix(),
+	})
+}
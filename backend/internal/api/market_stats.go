@@ -0,0 +1,51 @@
+/**
+ * @description
+ * This file contains the HTTP handler for a market's volatility and range statistics,
+ * computed server-side from stored daily bars.
+ *
+ * Key features:
+ * - Statistics Endpoint: Exposes `GET /api/v1/markets/:id/stats?window=7d|30d` returning
+ *   realized volatility, average daily range, max drawup/drawdown, and bar coverage.
+ * - Service Delegation: The computation and caching live in the `AnalyticsService`.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+/**
+ * @function getMarketStats
+ * @description A Gin handler that returns the statistics of a market's daily bars.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query window (optional): "7d" (default) or "30d".
+ */
+func (server *Server) getMarketStats(c *gin.Context) {
+	marketID := c.Param("id")
+	window := c.DefaultQuery("window", "7d")
+
+	if !isValidMarketID(marketID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid market ID"})
+		return
+	}
+
+	stats, err := server.analyticsService.MarketStats(c.Request.Context(), marketID, window)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStatsWindow) {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'window' parameter: " + err.Error()})
+			return
+		}
+		server.logger.Error("failed to compute market stats", "error", err, "market_id", marketID, "window", window)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to compute market statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": stats})
+}
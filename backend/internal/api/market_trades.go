@@ -0,0 +1,112 @@
+/**
+ * @description
+ * This file contains the HTTP handler for fetching the public trade tape
+ * (time & sales) for a market.
+ *
+ * Key features:
+ * - Trade Tape Endpoint: Exposes `GET /api/v1/markets/:id/trades` returning the most
+ *   recent public trades for a market, newest first.
+ * - CLOB Integration: Trades are sourced from Polymarket's market-scoped CLOB trades endpoint.
+ * - Bounded Results: The `limit` query parameter is capped to keep responses small.
+ * - Aggressor Tagging: Each trade carries the aggressor side inferred from the top of book
+ *   that prevailed at its time, with a confidence flag (see services.InferAggressor).
+ */
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+const (
+	// defaultMarketTradesLimit is the number of trades returned when no limit is given.
+	defaultMarketTradesLimit = 50
+	// maxMarketTradesLimit caps the number of trades a client can request at once.
+	maxMarketTradesLimit = 500
+)
+
+// MarketTrade represents a single public trade on a market's trade tape.
+type MarketTrade struct {
+	ID        string `json:"id"`
+	AssetID   string `json:"asset_id"`
+	Side      string `json:"side"`
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	Outcome   string `json:"outcome,omitempty"`
+	Timestamp int64  `json:"timestamp"` // Unix timestamp (seconds)
+	// Aggressor is the inferred side that crossed the spread: "buy", "sell", or "unknown"
+	Aggressor           string `json:"aggressor"`
+	AggressorConfidence string `json:"aggressor_confidence"` // "high", "low", or "none"
+}
+
+/**
+ * @function getMarketTrades
+ * @description A Gin handler that returns the most recent public trades for a market.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query limit (optional): Maximum number of trades to return (default: 50, max: 500)
+ *
+ * @notes
+ * - The 'id' parameter is the market's condition ID.
+ * - Limits above the maximum are clamped rather than rejected.
+ */
+func (server *Server) getMarketTrades(c *gin.Context) {
+	marketID := c.Param("id")
+	if !isValidMarketID(marketID) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid market ID"})
+		return
+	}
+
+	limit := defaultMarketTradesLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'limit' parameter"})
+			return
+		}
+		limit = min(parsedLimit, maxMarketTradesLimit)
+	}
+
+	trades, err := server.clobClient.GetMarketTrades(c.Request.Context(), marketID, limit)
+	if err != nil {
+		server.logger.Error("failed to fetch market trades", "error", err, "market_id", marketID)
+		c.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": "Failed to fetch market trades"})
+		return
+	}
+
+	marketTrades := make([]MarketTrade, 0, len(trades))
+	for _, trade := range trades {
+		// match_time is a Unix timestamp in seconds; unparseable values are reported as 0.
+		timestamp, _ := strconv.ParseInt(trade.MatchTime, 10, 64)
+		aggressor, confidence := services.AggressorUnknown, services.AggressorConfidenceNone
+		if price, err := strconv.ParseFloat(trade.Price, 64); err == nil && timestamp > 0 {
+			aggressor, confidence = server.marketStreamService.InferTradeAggressor(trade.AssetID, price, time.Unix(timestamp, 0))
+		}
+		marketTrades = append(marketTrades, MarketTrade{
+			ID:                  trade.ID,
+			AssetID:             trade.AssetID,
+			Side:                trade.Side,
+			Price:               trade.Price,
+			Size:                trade.Size,
+			Outcome:             trade.Outcome,
+			Timestamp:           timestamp,
+			Aggressor:           aggressor,
+			AggressorConfidence: confidence,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   marketTrades,
+		"meta": gin.H{
+			"count": len(marketTrades),
+			"limit": limit,
+		},
+	})
+}
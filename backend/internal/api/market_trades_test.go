@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/polymarket"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+// TestGetMarketTradesWithoutBook serves trades for which the stream has recorded no book,
+// and checks that each is tagged as an unknown aggressor rather than guessed.
+func TestGetMarketTradesWithoutBook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[
+			{"id":"t1","asset_id":"12345","side":"BUY","price":"0.52","size":"10","match_time":"1788000000"},
+			{"id":"t2","asset_id":"12345","side":"SELL","price":"not a price","size":"5","match_time":"1788000001"},
+			{"id":"t3","asset_id":"12345","side":"BUY","price":"0.52","size":"1","match_time":""}
+		]`)
+	}))
+	t.Cleanup(clob.Close)
+	server := newDebugTestServer(t)
+	server.clobClient = polymarket.NewCLOBAPIClient(clob.URL, "", "", "", server.logger)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Params = gin.Params{{Key: "id", Value: testConditionID}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/markets/"+testConditionID+"/trades", nil)
+	server.getMarketTrades(c)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+	}
+	var response struct {
+		Data []MarketTrade `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Data) != 3 {
+		t.Fatalf("trades = %+v, want 3", response.Data)
+	}
+	for _, trade := range response.Data {
+		if trade.Aggressor != services.AggressorUnknown || trade.AggressorConfidence != services.AggressorConfidenceNone {
+			t.Errorf("trade %s tagged %s with %s confidence, want unknown with none", trade.ID, trade.Aggressor, trade.AggressorConfidence)
+		}
+	}
+	// The CLOB's own side label is passed through untouched.
+	if response.Data[0].Side != "BUY" || response.Data[1].Side != "SELL" {
+		t.Errorf("sides = %s, %s; want BUY, SELL", response.Data[0].Side, response.Data[1].Side)
+	}
+}
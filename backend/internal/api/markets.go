@@ -13,6 +13,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"sort"
 	"strconv"
@@ -23,15 +25,21 @@ import (
 
 // MarketDetails represents the basic details of a market.
 // This structure will be sent to the frontend upon initial page load.
+// Dates are RFC3339 in UTC, or null when Gamma has none or it cannot be parsed.
 type MarketDetails struct {
-	ID               string `json:"id"`
-	Title            string `json:"title"`
-	Description      string `json:"description"`
-	ResolutionSource string `json:"resolution_source"`
-	ClobTokenIds     string `json:"clobTokenIds"`
+	ID               string  `json:"id"`
+	Title            string  `json:"title"`
+	Description      string  `json:"description"`
+	ResolutionSource string  `json:"resolution_source"`
+	ClobTokenIds     string  `json:"clobTokenIds"`
+	StartDate        *string `json:"start_date"`
+	EndDate          *string `json:"end_date"`
+	CreatedAt        *string `json:"created_at"`
+	UpdatedAt        *string `json:"updated_at"`
 }
 
 // MarketListItem represents a simplified market entry for listing pages.
+// Dates are RFC3339 in UTC, and omitted when Gamma has none or it cannot be parsed.
 type MarketListItem struct {
 	ID               string  `json:"id"`
 	Title            string  `json:"title"`
@@ -41,7 +49,10 @@ type MarketListItem struct {
 	Category         string  `json:"category"`
 	Liquidity        string  `json:"liquidity"`
 	Volume           string  `json:"volume,omitempty"` // Total volume for sorting/display
+	StartDate        *string `json:"start_date,omitempty"`
 	EndDate          *string `json:"end_date,omitempty"`
+	CreatedAt        *string `json:"created_at,omitempty"`
+	UpdatedAt        *string `json:"updated_at,omitempty"`
 }
 
 /**
@@ -60,33 +71,18 @@ type MarketListItem struct {
  */
 func (server *Server) getMarketDetails(c *gin.Context) {
 	marketIdentifier := c.Param("id")
+	if !isValidMarketID(marketIdentifier) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid market ID"})
+		return
+	}
 
 	server.logger.Info("fetching details for market", "identifier", marketIdentifier)
 
-	var gammaMarket *polymarket.GammaMarket
-	var err error
-
-	// Try fetching by slug first (if it doesn't look like a condition ID)
-	// Condition IDs typically start with "0x" and are hex strings
-	if len(marketIdentifier) > 2 && marketIdentifier[:2] != "0x" {
-		// Try slug first
-		gammaMarket, err = server.gammaClient.GetMarketBySlug(c.Request.Context(), marketIdentifier)
-		if err == nil {
-			server.logger.Info("successfully fetched market by slug", "slug", marketIdentifier)
-		} else {
-			server.logger.Debug("failed to fetch market by slug, will try condition ID", "slug", marketIdentifier, "error", err)
-		}
-	}
-
-	// If slug lookup failed or identifier looks like a condition ID, try condition ID
-	if gammaMarket == nil {
-		gammaMarket, err = server.gammaClient.GetMarketByConditionID(c.Request.Context(), marketIdentifier)
-		if err != nil {
-			server.logger.Warn("failed to fetch market from Gamma API", "error", err, "identifier", marketIdentifier)
-			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Market not found"})
-			return
-		}
-		server.logger.Info("successfully fetched market by condition ID", "condition_id", marketIdentifier)
+	gammaMarket, err := server.fetchGammaMarket(c.Request.Context(), marketIdentifier)
+	if err != nil {
+		server.logger.Warn("failed to fetch market from Gamma API", "error", err, "identifier", marketIdentifier)
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Market not found"})
+		return
 	}
 
 	// Convert Gamma API response to our MarketDetails format
@@ -96,11 +92,43 @@ func (server *Server) getMarketDetails(c *gin.Context) {
 		Description:      gammaMarket.Question, // Gamma API doesn't have a separate description field
 		ResolutionSource: gammaMarket.ResolutionSource,
 		ClobTokenIds:     gammaMarket.ClobTokenIds,
+		StartDate:        timePtr(gammaMarket.StartTime()),
+		EndDate:          timePtr(gammaMarket.EndTime()),
+		CreatedAt:        timePtr(gammaMarket.CreatedTime()),
+		UpdatedAt:        timePtr(gammaMarket.UpdatedTime()),
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": marketDetails})
 }
 
+// fetchGammaMarket fetches a market from the Gamma API by slug or condition ID. Identifiers
+// that do not look like condition IDs are tried as slugs first.
+func (server *Server) fetchGammaMarket(ctx context.Context, marketIdentifier string) (*polymarket.GammaMarket, error) {
+	// Try fetching by slug first (if it doesn't look like a condition ID)
+	// Condition IDs typically start with "0x" and are hex strings
+	if len(marketIdentifier) > 2 && marketIdentifier[:2] != "0x" {
+		// Try slug first
+		gammaMarket, err := server.gammaClient.GetMarketBySlug(ctx, marketIdentifier)
+		if err == nil {
+			server.logger.Info("successfully fetched market by slug", "slug", marketIdentifier)
+			return gammaMarket, nil
+		}
+		// A slug Gamma does not know is not a condition ID either
+		if errors.Is(err, polymarket.ErrMarketNotFound) {
+			return nil, err
+		}
+		server.logger.Warn("failed to fetch market by slug, will try condition ID", "slug", marketIdentifier, "error", err)
+	}
+
+	// If slug lookup failed or identifier looks like a condition ID, try condition ID
+	gammaMarket, err := server.gammaClient.GetMarketByConditionID(ctx, marketIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	server.logger.Info("successfully fetched market by condition ID", "condition_id", marketIdentifier)
+	return gammaMarket, nil
+}
+
 /**
  * @function listMarkets
  * @description A Gin handler that fetches and returns a list of all active markets from Polymarket's Gamma API.
@@ -110,11 +138,14 @@ func (server *Server) getMarketDetails(c *gin.Context) {
  *
  * @query limit (optional): Maximum number of markets to return (default: 100, max: 100)
  * @query offset (optional): Number of markets to skip (default: 0)
+ * @query fields (optional): Comma-separated market fields to return (e.g. "id,title,category")
+ * @query compact (optional): "true" to return each market as an [id, title] tuple
  *
  * @notes
  * - This handler fetches real market data from Polymarket's Gamma API.
  * - Returns only active (non-closed) markets.
  * - Supports pagination for large result sets.
+ * - Unknown field names, or combining `fields` with `compact`, return 400 Bad Request.
  */
 func (server *Server) listMarkets(c *gin.Context) {
 	// Parse query parameters for pagination
@@ -133,6 +164,22 @@ func (server *Server) listMarkets(c *gin.Context) {
 		}
 	}
 
+	// Parse the partial response options before fetching, so invalid requests fail fast
+	compact := c.Query("compact") == "true"
+	var fields []string
+	if fieldsParam, ok := c.GetQuery("fields"); ok {
+		if compact {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "fields and compact cannot be combined"})
+			return
+		}
+		parsedFields, err := parseMarketFields(fieldsParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid fields: " + err.Error()})
+			return
+		}
+		fields = parsedFields
+	}
+
 	server.logger.Info("fetching active markets from Gamma API", "limit", limit, "offset", offset)
 
 	// Fetch markets from Gamma API
@@ -175,7 +222,10 @@ func (server *Server) listMarkets(c *gin.Context) {
 				Category:         gammaMarket.Category,
 				Liquidity:        gammaMarket.Liquidity,
 				Volume:           gammaMarket.Volume, // Include volume for display
-				EndDate:          gammaMarket.EndDate,
+				StartDate:        timePtr(gammaMarket.StartTime()),
+				EndDate:          timePtr(gammaMarket.EndTime()),
+				CreatedAt:        timePtr(gammaMarket.CreatedTime()),
+				UpdatedAt:        timePtr(gammaMarket.UpdatedTime()),
 			},
 			volumeNum: volNum,
 		})
@@ -195,9 +245,16 @@ func (server *Server) listMarkets(c *gin.Context) {
 
 	server.logger.Info("successfully fetched markets", "count", len(markets))
 
+	var data any = markets
+	if compact {
+		data = compactMarkets(markets)
+	} else if fields != nil {
+		data = selectMarketFields(markets, fields)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   markets,
+		"data":   data,
 		"meta": gin.H{
 			"count":  len(markets),
 			"limit":  limit,
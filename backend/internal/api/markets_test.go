@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+const testConditionID = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+
+// TestFetchGammaMarketIdentifiers checks which Gamma endpoints are tried for slugs and
+// condition IDs, and when a failed slug lookup falls back to the condition ID.
+func TestFetchGammaMarketIdentifiers(t *testing.T) {
+	const (
+		bySlug        = "/markets/slug/fed-rate-cut"
+		byConditionID = "/markets?conditionId=fed-rate-cut"
+	)
+	tests := []struct {
+		name         string
+		identifier   string
+		slugStatus   int
+		slugBody     string
+		wantRequests []string
+		wantErr      error // nil when a market is found
+	}{
+		{"slug found", "fed-rate-cut", http.StatusOK, `{"conditionId":"` + testConditionID + `"}`, []string{bySlug}, nil},
+		{"slug found as an array", "fed-rate-cut", http.StatusOK, `[{"conditionId":"` + testConditionID + `"}]`, []string{bySlug}, nil},
+		// A slug Gamma does not know is not looked up as a condition ID.
+		{"unknown slug", "fed-rate-cut", http.StatusNotFound, `{"error":"not found"}`, []string{bySlug}, polymarket.ErrMarketNotFound},
+		{"unknown slug as an empty array", "fed-rate-cut", http.StatusOK, `[]`, []string{bySlug}, polymarket.ErrMarketNotFound},
+		{"slug lookup fails", "fed-rate-cut", http.StatusBadGateway, `upstream error`, []string{bySlug, byConditionID}, nil},
+		{"condition ID", testConditionID, http.StatusOK, ``, []string{"/markets?conditionId=" + testConditionID}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			gamma := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.URL.RequestURI())
+				if r.URL.Path == "/markets" {
+					io.WriteString(w, `[{"conditionId":"`+testConditionID+`"}]`)
+					return
+				}
+				w.WriteHeader(tt.slugStatus)
+				io.WriteString(w, tt.slugBody)
+			}))
+			t.Cleanup(gamma.Close)
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			server := &Server{gammaClient: polymarket.NewGammaAPIClient(gamma.URL, logger), logger: logger}
+
+			market, err := server.fetchGammaMarket(context.Background(), tt.identifier)
+			if !reflect.DeepEqual(requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", requests, tt.wantRequests)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("fetchGammaMarket error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || market.ConditionID != testConditionID {
+				t.Errorf("fetchGammaMarket = %+v, %v", market, err)
+			}
+		})
+	}
+}
+
+// TestListMarketsPartialResponses lists two markets with the fields selector and the compact
+// form, and checks that invalid selections are rejected before Gamma is called.
+func TestListMarketsPartialResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const gammaMarkets = `[
+		{"conditionId":"0xlow","question":"Will it rain?","category":"Weather","volume":"10","volumeNum":10},
+		{"conditionId":"0xhigh","question":"Will the Fed cut rates?","category":"Economics","volume":"900","volumeNum":900,"endDate":"2026-12-31T00:00:00Z"}
+	]`
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantData   string // JSON of the data field, for successful requests
+	}{
+		{"compact", "compact=true", http.StatusOK, `[["0xhigh","Will the Fed cut rates?"],["0xlow","Will it rain?"]]`},
+		{"selected fields", "fields=id,category", http.StatusOK, `[{"category":"Economics","id":"0xhigh"},{"category":"Weather","id":"0xlow"}]`},
+		// A selected field is present even where the full form would omit it.
+		{"omitempty field", "fields=id,end_date", http.StatusOK, `[{"end_date":"2026-12-31T00:00:00Z","id":"0xhigh"},{"end_date":null,"id":"0xlow"}]`},
+		{"duplicates and blanks", "fields=title,,title", http.StatusOK, `[{"title":"Will the Fed cut rates?"},{"title":"Will it rain?"}]`},
+		{"compact=false", "compact=false&fields=id", http.StatusOK, `[{"id":"0xhigh"},{"id":"0xlow"}]`},
+		{"unknown field", "fields=id,titel", http.StatusBadRequest, ""},
+		// Only JSON names are accepted, not Go field names.
+		{"Go field name", "fields=ResolutionSource", http.StatusBadRequest, ""},
+		{"empty fields", "fields=", http.StatusBadRequest, ""},
+		{"fields with compact", "fields=id&compact=true", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gammaCalls := 0
+			gamma := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gammaCalls++
+				io.WriteString(w, gammaMarkets)
+			}))
+			t.Cleanup(gamma.Close)
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			server := &Server{gammaClient: polymarket.NewGammaAPIClient(gamma.URL, logger), logger: logger}
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/markets?"+tt.query, nil)
+			server.listMarkets(c)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			var response struct {
+				Status  string          `json:"status"`
+				Message string          `json:"message"`
+				Data    json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if response.Status != "error" || response.Message == "" || gammaCalls != 0 {
+					t.Errorf("response = %+v after %d Gamma calls, want an error before calling Gamma", response, gammaCalls)
+				}
+				if tt.name == "unknown field" && !strings.Contains(response.Message, `"titel"`) {
+					t.Errorf("message %q does not name the unknown field", response.Message)
+				}
+				return
+			}
+			if string(response.Data) != tt.wantData {
+				t.Errorf("data = %s, want %s", response.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+// TestGetMarketDetailsDates checks that Gamma dates in each observed format are returned as
+// RFC3339 in UTC, and unknown ones as null.
+func TestGetMarketDetailsDates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gamma := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"conditionId":"`+testConditionID+`","question":"Will it rain?",
+			"startDate":"2024-01-04T22:57:23.336Z","endDate":"2024-11-05",
+			"createdAt":"2020-11-02 18:31:01+02","updatedAt":"TBD"}]`)
+	}))
+	t.Cleanup(gamma.Close)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := &Server{gammaClient: polymarket.NewGammaAPIClient(gamma.URL, logger), logger: logger}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/markets/"+testConditionID, nil)
+	c.Params = gin.Params{{Key: "id", Value: testConditionID}}
+	server.getMarketDetails(c)
+
+	var response struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode %s: %v", recorder.Body, err)
+	}
+	want := map[string]any{
+		"start_date": "2024-01-04T22:57:23Z",
+		"end_date":   "2024-11-05T00:00:00Z",
+		"created_at": "2020-11-02T16:31:01Z",
+		"updated_at": nil,
+	}
+	for field, wantValue := range want {
+		got, ok := response.Data[field]
+		if !ok || got != wantValue {
+			t.Errorf("%s = %v (present %v), want %v", field, got, ok, wantValue)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 /**
  * @description
  * This file contains the HTTP handlers for order-related API endpoints.
- * It is responsible for handling requests to place, cancel, and view orders.
+ * It is responsible for handling requests to place, cancel, and list orders.
  *
  * Key features:
  * - Input Validation: The `placeOrder` handler validates the incoming request body
@@ -16,15 +16,40 @@
 package api
 
 import (
+	"errors"
 	"log/slog"
 	"math/big"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/poly-pro/backend/internal/auth"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
 	"github.com/poly-pro/backend/internal/services"
 )
 
+const (
+	// defaultListOrdersLimit is the number of orders returned when no limit is given.
+	defaultListOrdersLimit = 50
+	// maxListOrdersLimit caps the number of orders returned per page.
+	maxListOrdersLimit = 100
+)
+
+// orderStatuses are the values accepted by the `status` filter of `GET /api/v1/orders`.
+var orderStatuses = map[string]bool{
+	services.OrderStatusPending:           true,
+	services.OrderStatusPendingSubmission: true,
+	services.OrderStatusOpen:              true,
+	services.OrderStatusDelayed:           true,
+	services.OrderStatusFilled:            true,
+	services.OrderStatusCancelled:         true,
+	services.OrderStatusExpired:           true,
+	services.OrderStatusRejected:          true,
+}
+
 // placeOrderRequest defines the structure of the JSON body expected
 // for a request to the `POST /api/v1/orders` endpoint.
 type placeOrderRequest struct {
@@ -33,6 +58,7 @@ type placeOrderRequest struct {
 	Price    float64 `json:"price" binding:"required,gt=0,lt=1"`
 	Size     float64 `json:"size" binding:"required,gt=0"`
 	Side     string  `json:"side" binding:"required,oneof=BUY SELL"`
+	Taker    string  `json:"taker"` // Optional checksummed counterparty of a directed order
 }
 
 /**
@@ -45,8 +71,20 @@ type placeOrderRequest struct {
  * - This handler must be used with the authentication middleware.
  * - It parses the order details, validates them, and then calls the PolymarketService
  *   to handle the EIP-712 signing workflow.
- * - For the MVP, this endpoint returns the signed order but does not yet submit it to
- *   the Polymarket API.
+ * - When a CLOB client is configured, the order is also submitted to Polymarket, and the
+ *   response carries its `polymarketOrderId` and `clobStatus` (the order's local status
+ *   after submission).
+ * - Prices that are not a multiple of the token's current tick size, and sizes that round
+ *   down to zero token units, return 400 Bad Request.
+ * - An optional `taker` directs the order to a single counterparty; it must be a checksummed
+ *   address, or 400 Bad Request is returned. Without it the order is public.
+ * - If the CLOB is temporarily unavailable and submission retries are enabled, the order is
+ *   queued with status 'pending_submission' and 202 Accepted is returned; its outcome is
+ *   delivered through order_update events.
+ * - An optional `Idempotency-Key` header makes retries safe: a request with a key already
+ *   used by the user returns the order it placed with 200 OK (and a null `signed_order`)
+ *   instead of placing another one, and 409 Conflict while that order is still being placed.
+ *   Keys are remembered for ORDER_IDEMPOTENCY_TTL_MS (24 hours by default).
  */
 func (server *Server) placeOrder(c *gin.Context) {
 	// 1. Retrieve the authenticated user's Clerk ID from the context.
@@ -59,9 +97,7 @@ func (server *Server) placeOrder(c *gin.Context) {
 
 	// 2. Parse and validate the incoming JSON request body.
 	var req placeOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		server.logger.Warn("invalid place order request", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid request body: " + err.Error()})
+	if !server.bindJSON(c, &req) {
 		return
 	}
 
@@ -80,21 +116,40 @@ func (server *Server) placeOrder(c *gin.Context) {
 		"price", req.Price,
 		"size", req.Size,
 		"side", req.Side,
+		"taker", req.Taker,
 	)
 
 	// 4. Call the PolymarketService to create and sign the order.
-	// For Polymarket proxy wallets (email login), the signature type is 1.
+	// The signature type is taken from the user's wallet.
 	params := services.PlaceOrderParams{
-		UserID:        clerkUserID.(string),
-		MarketID:      req.MarketID,
-		TokenID:       tokenID,
-		Price:         req.Price,
-		Size:          req.Size,
-		Side:          req.Side,
-		SignatureType: 1, // POLY_PROXY for email-based accounts
+		UserID:   clerkUserID.(string),
+		MarketID: req.MarketID,
+		TokenID:  tokenID,
+		Price:    req.Price,
+		Size:     req.Size,
+		Side:     req.Side,
+		Taker:    req.Taker,
+
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
 	}
 
-	signedOrder, dbOrder, err := server.polymarketService.CreateAndSignOrder(c.Request.Context(), params)
+	placed, err := server.polymarketService.CreateAndSignOrder(c.Request.Context(), params)
+	if errors.Is(err, services.ErrInvalidOrderPrice) || errors.Is(err, services.ErrInvalidOrderSize) || errors.Is(err, services.ErrInvalidOrderTaker) || errors.Is(err, services.ErrInvalidIdempotencyKey) {
+		server.logger.Warn("order price, size or taker rejected", "error", err, "user_id", clerkUserID, "token_id", req.TokenID)
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+		server.logger.Warn("order rejected: idempotency key in use", "user_id", clerkUserID)
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrSignerBusy) {
+		server.logger.Warn("order rejected: no signing slot available", "user_id", clerkUserID)
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 	if err != nil {
 		server.logger.Error("failed to create and sign order", "error", err, "user_id", clerkUserID)
 		// Here you could inspect the error to return a more specific status code
@@ -103,18 +158,210 @@ func (server *Server) placeOrder(c *gin.Context) {
 		return
 	}
 
-	// 5. Return the signed order and database order in the response.
+	// 5. Return the signed order and database order in the response. The order is the row
+	// as stored after the submission; if the submission outcome could not be recorded, a
+	// warning says so.
+	// When the order was submitted to the CLOB, its Polymarket order ID and resulting
+	// status are also returned at the top level; both are null otherwise.
+	signedOrder, dbOrder := placed.Signed, placed.Order
+	var polymarketOrderID, clobStatus *string
+	if dbOrder.PolymarketOrderID.Valid {
+		polymarketOrderID = &dbOrder.PolymarketOrderID.String
+		clobStatus = &dbOrder.Status
+	}
 	server.logger.Info("order successfully created and signed", 
 		"user_id", clerkUserID, 
 		"order_id", dbOrder.ID,
+		"polymarket_order_id", dbOrder.PolymarketOrderID.String,
 		slog.Any("signed_order", signedOrder))
+	statusCode, message := http.StatusOK, "Order placed successfully"
+	if placed.Replayed {
+		message = "Order already placed with this idempotency key"
+	} else if dbOrder.Status == services.OrderStatusPendingSubmission {
+		statusCode, message = http.StatusAccepted, "Order queued for submission"
+	}
+	data := gin.H{
+		"order":             newOrderResponse(dbOrder),
+		"signed_order":      signedOrder,
+		"polymarketOrderId": polymarketOrderID,
+		"clobStatus":        clobStatus,
+	}
+	if placed.Warning != "" {
+		server.logger.Warn("order placed with an out-of-date record", "order_id", dbOrder.ID, "warning", placed.Warning)
+		data["warning"] = placed.Warning
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    data,
+	})
+}
+
+/**
+ * @description
+ * cancelOrder is a Gin handler that cancels one of the authenticated user's orders.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @notes
+ * - This handler must be used with the authentication middleware.
+ * - Only orders resting on the CLOB ('open' or 'delayed') can be cancelled; other orders
+ *   return 409 Conflict. Cancelling an already cancelled order returns it unchanged.
+ * - Orders that do not exist or belong to another user return 404 Not Found.
+ * - If the CLOB is not configured or temporarily unavailable, 503 Service Unavailable is returned.
+ */
+func (server *Server) cancelOrder(c *gin.Context) {
+	clerkUserID, exists := c.Get(string(auth.ClerkUserIDKey))
+	if !exists {
+		server.logger.Error("clerkUserID not found in context for cancelOrder")
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "User identifier not found in request context"})
+		return
+	}
+
+	var orderID pgtype.UUID
+	if err := orderID.Scan(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid order ID"})
+		return
+	}
+
+	dbOrder, warning, err := server.polymarketService.CancelOrder(c.Request.Context(), clerkUserID.(string), orderID)
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Order not found"})
+		return
+	case errors.Is(err, services.ErrOrderNotCancellable):
+		server.logger.Info("order cancellation rejected", "error", err, "user_id", clerkUserID, "order_id", c.Param("id"))
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Order cannot be cancelled", "data": gin.H{"order": newOrderResponse(dbOrder)}})
+		return
+	case errors.Is(err, services.ErrTradingNotConfigured), errors.Is(err, polymarket.ErrCLOBUnavailable):
+		server.logger.Warn("order cancellation unavailable", "error", err, "user_id", clerkUserID, "order_id", c.Param("id"))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "message": "Order cancellation is temporarily unavailable"})
+		return
+	case err != nil:
+		server.logger.Error("failed to cancel order", "error", err, "user_id", clerkUserID, "order_id", c.Param("id"))
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to cancel order"})
+		return
+	}
+
+	server.logger.Info("order cancelled", "user_id", clerkUserID, "order_id", dbOrder.ID, "polymarket_order_id", dbOrder.PolymarketOrderID.String)
+	data := gin.H{"order": newOrderResponse(dbOrder)}
+	if warning != "" {
+		server.logger.Warn("order cancelled with an out-of-date record", "order_id", dbOrder.ID, "warning", warning)
+		data["warning"] = warning
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "Order placed successfully",
-		"data": gin.H{
-			"order":       dbOrder,
-			"signed_order": signedOrder,
-		},
+		"message": "Order cancelled successfully",
+		"data":    data,
 	})
 }
 
+/**
+ * @description
+ * listOrders is a Gin handler that returns a page of the authenticated user's orders,
+ * newest first.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query limit (optional): Maximum number of orders to return (default: 50, max: 100)
+ * @query offset (optional): Number of orders to skip (default: 0)
+ * @query status (optional): Only return orders in this status (e.g. "open")
+ * @query market_id (optional): Only return orders placed on this market
+ *
+ * @notes
+ * - This handler must be used with the authentication middleware.
+ * - Limits above the maximum are clamped; other invalid parameters return 400 Bad Request.
+ * - `meta.total` is the number of orders matching the filters across all pages.
+ */
+func (server *Server) listOrders(c *gin.Context) {
+	clerkUserID, exists := c.Get(string(auth.ClerkUserIDKey))
+	if !exists {
+		server.logger.Error("clerkUserID not found in context for listOrders")
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "User identifier not found in request context"})
+		return
+	}
+
+	limit := defaultListOrdersLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'limit' parameter"})
+			return
+		}
+		limit = min(parsedLimit, maxListOrdersLimit)
+	}
+
+	offset := 0
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		parsedOffset, err := strconv.ParseInt(offsetParam, 10, 32)
+		if err != nil || parsedOffset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'offset' parameter"})
+			return
+		}
+		offset = int(parsedOffset)
+	}
+
+	var status, marketID pgtype.Text
+	if statusParam := c.Query("status"); statusParam != "" {
+		if !orderStatuses[statusParam] {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid 'status' parameter"})
+			return
+		}
+		status = pgtype.Text{String: statusParam, Valid: true}
+	}
+	if marketParam := c.Query("market_id"); marketParam != "" {
+		marketID = pgtype.Text{String: marketParam, Valid: true}
+	}
+
+	ctx := c.Request.Context()
+	user, err := server.userService.GetUserByClerkID(ctx, clerkUserID.(string))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			server.logger.Warn("user authenticated but not found in DB", "clerk_id", clerkUserID)
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Authenticated user not found in the system"})
+			return
+		}
+		server.logger.Error("failed to get user by clerk ID", "error", err, "clerk_id", clerkUserID)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve orders"})
+		return
+	}
+
+	orders, err := server.store.ListOrdersByUserID(ctx, db.ListOrdersByUserIDParams{
+		UserID:    user.ID,
+		Status:    status,
+		MarketID:  marketID,
+		RowLimit:  int32(limit),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		server.logger.Error("failed to list orders", "error", err, "user_id", user.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve orders"})
+		return
+	}
+	total, err := server.store.CountOrdersByUserID(ctx, db.CountOrdersByUserIDParams{
+		UserID:   user.ID,
+		Status:   status,
+		MarketID: marketID,
+	})
+	if err != nil {
+		server.logger.Error("failed to count orders", "error", err, "user_id", user.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to retrieve orders"})
+		return
+	}
+
+	data := make([]orderResponse, 0, len(orders))
+	for _, order := range orders {
+		data = append(data, newOrderResponse(order))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   data,
+		"meta": gin.H{
+			"count":  len(data),
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
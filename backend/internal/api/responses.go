@@ -0,0 +1,188 @@
+/**
+ * @description
+ * This file defines the JSON response shapes for database-backed resources
+ * (orders, users, and wallets) returned by the API, along with the mapping functions
+ * that convert sqlc-generated `db` models into them.
+ *
+ * Key features:
+ * - Canonical Encoding: UUIDs are rendered as canonical strings, timestamps as
+ *   RFC3339 in UTC, and numerics as decimal strings, instead of leaking pgtype internals.
+ * - Stable Contract: The frontend depends on these shapes, so handlers should always
+ *   map `db` models through these functions rather than returning them directly.
+ *
+ * @notes
+ * - The `db` package is generated by sqlc and must not be edited by hand, which is why
+ *   the mapping lives in the API layer.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// orderResponse is the JSON representation of an order returned by the API.
+type orderResponse struct {
+	ID                string          `json:"id"`
+	UserID            string          `json:"user_id"`
+	MarketID          string          `json:"market_id"`
+	TokenID           string          `json:"token_id"`
+	PolymarketOrderID *string         `json:"polymarket_order_id"`
+	Side              string          `json:"side"`
+	Size              string          `json:"size"`
+	Price             string          `json:"price"`
+	Status            string          `json:"status"`
+	Taker             string          `json:"taker"` // Zero address for public orders
+	SignedOrder       json.RawMessage `json:"signed_order"`
+	SignedAt          *string         `json:"signed_at"`
+	SubmittedAt       *string         `json:"submitted_at"`
+	AcknowledgedAt    *string         `json:"acknowledged_at"`
+	FilledAt          *string         `json:"filled_at"`
+	CancelledAt       *string         `json:"cancelled_at"`
+	CreatedAt         *string         `json:"created_at"`
+	UpdatedAt         *string         `json:"updated_at"`
+	EventSeq          int64           `json:"event_seq"` // Matches the event_seq of the latest order_update event
+}
+
+// userResponse is the JSON representation of a user returned by the API.
+type userResponse struct {
+	ID          string  `json:"id"`
+	ClerkUserID string  `json:"clerk_user_id"`
+	Email       string  `json:"email"`
+	CreatedAt   *string `json:"created_at"`
+	UpdatedAt   *string `json:"updated_at"`
+}
+
+// walletResponse is the JSON representation of a wallet returned by the API.
+// The signer secret reference is deliberately omitted.
+type walletResponse struct {
+	ID                      string  `json:"id"`
+	PolymarketFunderAddress string  `json:"polymarket_funder_address"`
+	IsActive                bool    `json:"is_active"`
+	IsVerified              bool    `json:"is_verified"`
+	VerifiedAt              *string `json:"verified_at"`
+	CreatedAt               *string `json:"created_at"`
+	UpdatedAt               *string `json:"updated_at"`
+}
+
+// walletChallengeResponse is the JSON representation of a wallet verification challenge.
+type walletChallengeResponse struct {
+	Nonce     string  `json:"nonce"`
+	Message   string  `json:"message"`
+	ExpiresAt *string `json:"expires_at"`
+}
+
+// newOrderResponse maps a database order to its API representation.
+func newOrderResponse(order db.Order) orderResponse {
+	resp := orderResponse{
+		ID:                uuidString(order.ID),
+		UserID:            uuidString(order.UserID),
+		MarketID:          order.MarketID,
+		TokenID:           order.TokenID,
+		PolymarketOrderID: textPtr(order.PolymarketOrderID),
+		Side:              order.Side,
+		Size:              numericString(order.Size),
+		Price:             numericString(order.Price),
+		Status:            order.Status,
+		Taker:             order.Taker,
+		SignedAt:          timestampPtr(order.SignedAt),
+		SubmittedAt:       timestampPtr(order.SubmittedAt),
+		AcknowledgedAt:    timestampPtr(order.AcknowledgedAt),
+		FilledAt:          timestampPtr(order.FilledAt),
+		CancelledAt:       timestampPtr(order.CancelledAt),
+		CreatedAt:         timestampPtr(order.CreatedAt),
+		UpdatedAt:         timestampPtr(order.UpdatedAt),
+		EventSeq:          order.EventSeq,
+	}
+	// signed_order is stored as JSONB, so pass it through verbatim instead of
+	// letting encoding/json base64-encode the raw bytes.
+	if len(order.SignedOrder) > 0 && json.Valid(order.SignedOrder) {
+		resp.SignedOrder = json.RawMessage(order.SignedOrder)
+	}
+	return resp
+}
+
+// newUserResponse maps a database user to its API representation.
+func newUserResponse(user db.User) userResponse {
+	return userResponse{
+		ID:          uuidString(user.ID),
+		ClerkUserID: user.ClerkUserID,
+		Email:       user.Email,
+		CreatedAt:   timestampPtr(user.CreatedAt),
+		UpdatedAt:   timestampPtr(user.UpdatedAt),
+	}
+}
+
+// newWalletResponse maps a database wallet to its API representation.
+func newWalletResponse(wallet db.Wallet) walletResponse {
+	return walletResponse{
+		ID:                      uuidString(wallet.ID),
+		PolymarketFunderAddress: wallet.PolymarketFunderAddress,
+		IsActive:                wallet.IsActive,
+		IsVerified:              wallet.VerifiedAt.Valid,
+		VerifiedAt:              timestampPtr(wallet.VerifiedAt),
+		CreatedAt:               timestampPtr(wallet.CreatedAt),
+		UpdatedAt:               timestampPtr(wallet.UpdatedAt),
+	}
+}
+
+// newWalletChallengeResponse maps a database wallet challenge to its API representation.
+func newWalletChallengeResponse(challenge db.WalletChallenge) walletChallengeResponse {
+	return walletChallengeResponse{
+		Nonce:     challenge.Nonce,
+		Message:   challenge.Message,
+		ExpiresAt: timestampPtr(challenge.ExpiresAt),
+	}
+}
+
+// uuidString renders a UUID in its canonical 8-4-4-4-12 form, or "" if it is NULL.
+func uuidString(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return id.String()
+}
+
+// textPtr returns a pointer to the text value, or nil if it is NULL.
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.String
+	return &s
+}
+
+// timestampPtr formats a timestamp as RFC3339 in UTC, or returns nil if it is NULL or infinite.
+func timestampPtr(ts pgtype.Timestamptz) *string {
+	if !ts.Valid || ts.InfinityModifier != pgtype.Finite {
+		return nil
+	}
+	s := ts.Time.UTC().Format(time.RFC3339)
+	return &s
+}
+
+// timePtr formats an optional time as RFC3339 in UTC, or returns nil if it is nil.
+func timePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.UTC().Format(time.RFC3339)
+	return &s
+}
+
+// numericString renders a numeric as a plain decimal string, or "" if it is NULL.
+func numericString(n pgtype.Numeric) string {
+	if !n.Valid {
+		return ""
+	}
+	value, err := n.Value()
+	if err != nil {
+		return ""
+	}
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// fixtureTime is a timestamp off UTC and with fractional seconds, as read from the database.
+var fixtureTime = time.Date(2026, 10, 18, 14, 30, 15, 123456789, time.FixedZone("CEST", 2*60*60))
+
+func fixtureUUID(last byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{0x6f, 0x1c, 0x2d, 0x9e, 0x8a, 0x4b, 0x4c, 0x3d, 0x9e, 0x2f, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e, last}, Valid: true}
+}
+
+func fixtureTimestamp(offset time.Duration) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: fixtureTime.Add(offset), Valid: true}
+}
+
+// assertJSON checks that v marshals to exactly want.
+func assertJSON(t *testing.T, v any, want string) {
+	t.Helper()
+	got, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("JSON mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestOrderResponseJSON(t *testing.T) {
+	order := db.Order{
+		ID:                fixtureUUID(0x01),
+		UserID:            fixtureUUID(0x02),
+		MarketID:          "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1",
+		TokenID:           "71321045679252212594626385532706912750332728571942532289631379312455583992563",
+		PolymarketOrderID: pgtype.Text{String: "0xorderhash", Valid: true},
+		Side:              "BUY",
+		Size:              pgtype.Numeric{Int: big.NewInt(1250), Exp: -2, Valid: true},
+		Price:             pgtype.Numeric{Int: big.NewInt(5500), Exp: -4, Valid: true},
+		Status:            "filled",
+		SignedOrder:       []byte(`{"salt": 1, "signature": "0xsig"}`),
+		SubmittedAt:       fixtureTimestamp(2 * time.Second),
+		FilledAt:          fixtureTimestamp(time.Minute),
+		CreatedAt:         fixtureTimestamp(0),
+		UpdatedAt:         fixtureTimestamp(time.Minute),
+		EventSeq:          3,
+		Taker:             "0x0000000000000000000000000000000000000000",
+		SignedAt:          fixtureTimestamp(time.Second),
+		AcknowledgedAt:    fixtureTimestamp(2500 * time.Millisecond),
+	}
+	assertJSON(t, newOrderResponse(order), `{`+
+		`"id":"6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e01",`+
+		`"user_id":"6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e02",`+
+		`"market_id":"0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1",`+
+		`"token_id":"71321045679252212594626385532706912750332728571942532289631379312455583992563",`+
+		`"polymarket_order_id":"0xorderhash",`+
+		`"side":"BUY",`+
+		`"size":"12.50",`+
+		`"price":"0.5500",`+
+		`"status":"filled",`+
+		`"taker":"0x0000000000000000000000000000000000000000",`+
+		`"signed_order":{"salt":1,"signature":"0xsig"},`+
+		`"signed_at":"2026-10-18T12:30:16Z",`+
+		`"submitted_at":"2026-10-18T12:30:17Z",`+
+		`"acknowledged_at":"2026-10-18T12:30:17Z",`+
+		`"filled_at":"2026-10-18T12:31:15Z",`+
+		`"cancelled_at":null,`+
+		`"created_at":"2026-10-18T12:30:15Z",`+
+		`"updated_at":"2026-10-18T12:31:15Z",`+
+		`"event_seq":3}`)
+
+	// A pending order has no Polymarket order ID, signed order, or lifecycle timestamps.
+	pending := db.Order{
+		ID:          fixtureUUID(0x03),
+		UserID:      fixtureUUID(0x02),
+		MarketID:    "0xmarket",
+		TokenID:     "1",
+		Side:        "SELL",
+		Size:        pgtype.Numeric{Int: big.NewInt(5), Exp: 0, Valid: true},
+		Price:       pgtype.Numeric{Int: big.NewInt(1), Exp: -3, Valid: true},
+		Status:      "pending",
+		SignedOrder: []byte("not json"),
+		CreatedAt:   fixtureTimestamp(0),
+		UpdatedAt:   pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true},
+		Taker:       "0x0000000000000000000000000000000000000000",
+	}
+	assertJSON(t, newOrderResponse(pending), `{`+
+		`"id":"6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e03",`+
+		`"user_id":"6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e02",`+
+		`"market_id":"0xmarket",`+
+		`"token_id":"1",`+
+		`"polymarket_order_id":null,`+
+		`"side":"SELL",`+
+		`"size":"5",`+
+		`"price":"0.001",`+
+		`"status":"pending",`+
+		`"taker":"0x0000000000000000000000000000000000000000",`+
+		`"signed_order":null,`+
+		`"signed_at":null,`+
+		`"submitted_at":null,`+
+		`"acknowledged_at":null,`+
+		`"filled_at":null,`+
+		`"cancelled_at":null,`+
+		`"created_at":"2026-10-18T12:30:15Z",`+
+		`"updated_at":null,`+
+		`"event_seq":0}`)
+}
+
+func TestUserResponseJSON(t *testing.T) {
+	user := db.User{
+		ID:          fixtureUUID(0x02),
+		ClerkUserID: "user_2abcDEF",
+		Email:       "trader@example.com",
+		CreatedAt:   fixtureTimestamp(0),
+		UpdatedAt:   fixtureTimestamp(24 * time.Hour),
+	}
+	assertJSON(t, newUserResponse(user), `{`+
+		`"id":"6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e02",`+
+		`"clerk_user_id":"user_2abcDEF",`+
+		`"email":"trader@example.com",`+
+		`"created_at":"2026-10-18T12:30:15Z",`+
+		`"updated_at":"2026-10-19T12:30:15Z"}`)
+
+	assertJSON(t, newUserResponse(db.User{ClerkUserID: "user_new", Email: "new@example.com"}), `{`+
+		`"id":"",`+
+		`"clerk_user_id":"user_new",`+
+		`"email":"new@example.com",`+
+		`"created_at":null,`+
+		`"updated_at":null}`)
+}
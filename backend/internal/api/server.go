@@ -20,11 +20,15 @@ import (
 	"os"
 
 	"github.com/gin-gonic/gin"
+	gorillaWS "github.com/gorilla/websocket"
 	"github.com/poly-pro/backend/internal/auth"
+	"github.com/poly-pro/backend/internal/cachekeys"
 	"github.com/poly-pro/backend/internal/config"
 	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/logsafe"
 	"github.com/poly-pro/backend/internal/polymarket"
 	"github.com/poly-pro/backend/internal/services"
+	"github.com/poly-pro/backend/internal/tasks"
 	"github.com/poly-pro/backend/internal/websocket"
 	"github.com/redis/go-redis/v9"
 )
@@ -34,14 +38,29 @@ type Server struct {
 	config              config.Config
 	store               db.Querier
 	Router              *gin.Engine
+	InternalRouter      *gin.Engine
 	logger              *slog.Logger
 	userService         *services.UserService
+	walletService       *services.WalletService
 	polymarketService   *services.PolymarketService
+	orderSyncService    *services.OrderSyncService
+	orderRetryService   *services.OrderRetryService
 	marketStreamService *services.MarketStreamService
+	backfillService     *services.MarketHistoryBackfillService
+	consistencyService  *services.ChartConsistencyService
+	analyticsService    *services.AnalyticsService
+	pipelineMonitor     *services.PipelineMonitor
+	barWatchdog         *services.BarWatchdog
+	publishBuffer       *services.PublishBuffer
 	signerClient        services.SignerClient
 	hub                 *websocket.Hub
+	wsUpgrader          *gorillaWS.Upgrader
+	verifier            *auth.Verifier // Validates Clerk tokens; also identifies WebSocket clients
 	redisClient         *redis.Client
 	gammaClient         *polymarket.GammaAPIClient
+	clobClient          *polymarket.CLOBAPIClient
+	statusCache         statusCache
+	eventOutcomesCache  eventOutcomesCache
 }
 
 /**
@@ -52,17 +71,31 @@ type Server struct {
  * @param config The application configuration.
  * @param store The database querier for database operations.
  * @param redisClient The client for connecting to Redis.
+ * @param taskManager The registry that background services are started with.
  * @returns A pointer to a new Server instance.
  *
  * @notes
  * - This function encapsulates the entire setup of the Gin router and related services,
  *   making it easy to instantiate the server from the main application entry point.
  */
-func NewServer(ctx context.Context, config config.Config, store db.Querier, redisClient *redis.Client) *Server {
+func NewServer(ctx context.Context, config config.Config, store db.Querier, redisClient *redis.Client, taskManager *tasks.Manager) *Server {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
+	// The resolutions produced by the aggregator are the ones the history endpoints accept.
+	if err := services.ConfigureResolutions(config.OHLCVResolutions); err != nil {
+		logger.Error("invalid OHLCV_RESOLUTIONS", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("OHLCV resolutions configured", "resolutions", services.Resolutions())
+
+	if err := cachekeys.Disable(config.CacheDisabled); err != nil {
+		logger.Error("invalid CACHE_DISABLED", "error", err)
+		os.Exit(1)
+	}
+	logsafe.SetMaxLength(config.LogBodyMaxBytes)
+
 	// Initialize gRPC client for the remote signer
-	signerClient, err := services.NewSignerClient(config.RemoteSignerAddress, logger)
+	signerClient, err := services.NewSignerClient(config.RemoteSignerAddress, logger, config.SignerSimulationAllowed)
 	if err != nil {
 		logger.Error("failed to create signer client", "error", err)
 		os.Exit(1)
@@ -71,13 +104,44 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 	// Initialize Gamma API client
 	gammaClient := polymarket.NewGammaAPIClient(config.GammaAPIURL, logger)
 
+	// Initialize CLOB API client for public market data (credentials are optional here)
+	clobClient := polymarket.NewCLOBAPIClient(config.CLOBAPIURL, config.CLOBAPIKey, config.CLOBAPISecret, config.CLOBAPIPassphrase, logger)
+
 	// Initialize services
 	userService := services.NewUserService(store, logger)
-	polymarketService := services.NewPolymarketService(store, logger, signerClient, config)
-	marketStreamService := services.NewMarketStreamService(ctx, logger, redisClient, config, store, gammaClient)
+	walletService := services.NewWalletService(store, logger)
+	polymarketService := services.NewPolymarketService(store, logger, signerClient, redisClient, config)
+	orderSyncService := services.NewOrderSyncService(ctx, store, polymarketService, logger)
+	orderRetryService := services.NewOrderRetryService(ctx, store, polymarketService, logger)
+	marketStreamService := services.NewMarketStreamService(ctx, logger, redisClient, config, store, gammaClient, clobClient)
+	backfillService := services.NewMarketHistoryBackfillService(store, marketStreamService, logger)
+	consistencyService := services.NewChartConsistencyService(ctx, store, gammaClient, clobClient, logger)
+	analyticsService := services.NewAnalyticsService(store, redisClient, logger)
+
+	// Retry the Pub/Sub messages that fail to publish while Redis is unavailable
+	publishBuffer := services.NewPublishBuffer(ctx, logger, redisClient, services.PublishBufferPolicy{
+		Size:            config.PublishBufferSize,
+		EphemeralMaxAge: config.PublishBufferBookMaxAge,
+	})
+	marketStreamService.SetPublishBuffer(publishBuffer)
+	polymarketService.SetPublishBuffer(publishBuffer)
 
 	// Initialize the WebSocket Hub
-	hub := websocket.NewHub(ctx, logger, redisClient)
+	hub := websocket.NewHub(ctx, logger, redisClient, config.WSAllowedMarkets, marketStreamService.Catalog())
+	hub.SetLimitsProvider(wsPlanLimits(config))
+	hub.SetBarResolutions(services.Resolutions())
+	hub.SetMaxListeners(config.WSMaxRedisListeners)
+
+	// Markets clients are watching are prioritized in the stream's subscription budget
+	marketStreamService.SetMarketDemand(hub.SubscribedMarkets)
+
+	// Watch for the OHLCV pipeline stalling while clients are subscribed
+	pipelineMonitor := services.NewPipelineMonitor(ctx, logger, marketStreamService, func() int {
+		return hub.Stats(1).SubscribedMarkets
+	}, config.OHLCVStallAfter)
+
+	// Watch for individual markets whose bars stop being saved
+	barWatchdog := services.NewBarWatchdog(ctx, logger, store, marketStreamService, config.OHLCVBarWatchdogWindow, config.OHLCVBarWatchdogReflush)
 
 	// Initialize a new Server instance
 	server := &Server{
@@ -85,14 +149,28 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 		store:               store,
 		logger:              logger,
 		userService:         userService,
+		walletService:       walletService,
 		polymarketService:   polymarketService,
+		orderSyncService:    orderSyncService,
+		orderRetryService:   orderRetryService,
 		marketStreamService: marketStreamService,
+		backfillService:     backfillService,
+		consistencyService:  consistencyService,
+		analyticsService:    analyticsService,
+		pipelineMonitor:     pipelineMonitor,
+		barWatchdog:         barWatchdog,
+		publishBuffer:       publishBuffer,
 		signerClient:        signerClient,
 		hub:                 hub,
+		wsUpgrader:          newUpgrader(config.WSCompressionEnabled),
 		redisClient:         redisClient,
 		gammaClient:         gammaClient,
+		clobClient:          clobClient,
 	}
 
+	// Register custom binding validators before any request is bound.
+	registerValidators()
+
 	// Initialize the Gin router with default middleware (logger and recovery)
 	router := gin.Default()
 
@@ -145,21 +223,35 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 	// Route Definitions
 	// ------------------------------------------------------------------
 	// A simple health check endpoint to confirm the server is running.
+	// It reports unhealthy if the market data stream has stopped.
 	router.GET("/health", func(c *gin.Context) {
+		if failure := server.marketStreamService.Failure(); failure != "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"message": "Market data stream is unavailable: " + failure,
+			})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "success",
 			"message": "Poly-Pro Analytics Backend is healthy and running!",
 		})
 	})
 
+	// Readiness check: fails when the market stream has stopped or the OHLCV pipeline has stalled.
+	router.GET("/readyz", server.getReadiness)
+
 	// Group all API routes under `/api/v1` for versioning.
 	v1 := router.Group("/api/v1")
 	{
 		// --- Public Routes ---
-		// WebSocket route - does not require JWT auth for connection,
-		// but could be implemented to require it.
+		// WebSocket route - does not require JWT auth for connection; an optional
+		// Clerk token or API key selects the client's plan and limits.
 		v1.GET("/ws", server.serveWs)
 
+		// Public platform status summary (API, stream freshness, degradations). Cached briefly.
+		v1.GET("/status", server.getStatus)
+
 		// Endpoint to list all active markets. Public data.
 		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
 		v1.GET("/markets", server.listMarkets)
@@ -168,9 +260,43 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
 		v1.GET("/markets/:id/history", server.getMarketHistory)
 
+		// Endpoint to download historical OHLCV data for a market as CSV. Public data.
+		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
+		v1.GET("/markets/:id/history.csv", server.exportMarketHistoryCSV)
+
+		// Endpoint to get the public trade tape (time & sales) for a market.
+		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
+		v1.GET("/markets/:id/trades", server.getMarketTrades)
+
+		// Endpoint to get volatility and range statistics for a market. Public data.
+		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
+		v1.GET("/markets/:id/stats", server.getMarketStats)
+
+		// Endpoint to get the latest price of a market, served from Redis when cached. Public data.
+		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
+		v1.GET("/markets/:id/price", server.getMarketPrice)
+
+		// Endpoint to get the latest price of a market from the in-memory OHLCV bar. Public data.
+		// This route must be registered BEFORE /markets/:id to avoid route conflicts.
+		v1.GET("/markets/:id/last-price", server.getMarketLastPrice)
+
 		// Endpoint to get static details for a market. This is public data.
 		v1.GET("/markets/:id", server.getMarketDetails)
 
+		// Endpoint to fetch the aligned price history of a multi-outcome event's top outcomes.
+		v1.GET("/events/:slug/history", server.getEventHistory)
+
+		// TradingView UDF datafeed (see udf.go). Public data for charting; /markets/:id/history
+		// stays available for existing clients.
+		udfGroup := v1.Group("/udf")
+		{
+			udfGroup.GET("/config", server.getUDFConfig)
+			udfGroup.GET("/symbols", server.getUDFSymbol)
+			udfGroup.GET("/search", server.searchUDFSymbols)
+			udfGroup.GET("/time", server.getUDFTime)
+			udfGroup.GET("/history", server.getUDFHistory)
+		}
+
 		// Webhook routes are public but should have their own verification logic.
 		webhookGroup := v1.Group("/webhooks")
 		{
@@ -180,11 +306,13 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 
 		// --- Protected Routes ---
 		// Initialize the authentication middleware.
-		authMiddleware, err := auth.NewAuthMiddleware(config.ClerkIssuerURL)
+		verifier, err := auth.NewVerifier(config.ClerkIssuerURL)
 		if err != nil {
 			logger.Error("failed to create auth middleware", "error", err)
 			os.Exit(1) // Exit if middleware can't be created, as it's critical.
 		}
+		server.verifier = verifier
+		authMiddleware := verifier.Middleware()
 
 		// Create a new group for routes that require authentication.
 		authGroup := v1.Group("/")
@@ -197,11 +325,32 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 				userRoutes.GET("/me", server.getMe)
 			}
 
+			// Wallet-related protected routes
+			walletRoutes := authGroup.Group("/wallets")
+			{
+				// Endpoint to request a nonce message to sign with the wallet's key.
+				walletRoutes.POST("/:id/challenge", server.createWalletChallenge)
+				// Endpoint to submit the signed challenge and mark the wallet verified.
+				walletRoutes.POST("/:id/verify", server.verifyWallet)
+			}
+
 			// Order-related protected routes
 			orderRoutes := authGroup.Group("/orders")
 			{
+				// Endpoint to list the user's orders, newest first.
+				orderRoutes.GET("/", server.listOrders)
 				// Endpoint to place a new order.
 				orderRoutes.POST("/", server.placeOrder)
+				// Endpoint to cancel one of the user's resting orders.
+				orderRoutes.DELETE("/:id", server.cancelOrder)
+			}
+
+			// Admin routes, restricted to users with the admin role
+			adminRoutes := authGroup.Group("/admin")
+			adminRoutes.Use(auth.RequireRole(auth.RoleAdmin))
+			{
+				// Endpoint to persist the OHLCV aggregator's in-memory bars immediately.
+				adminRoutes.POST("/ohlcv/flush", server.flushOHLCVAggregator)
 			}
 		}
 	}
@@ -209,13 +358,59 @@ func NewServer(ctx context.Context, config config.Config, store db.Querier, redi
 	// Attach the configured router to our server instance
 	server.Router = router
 
-	// Start the WebSocket hub and market stream service in the background.
-	go server.hub.Run()
-	go server.marketStreamService.RunStream()
+	// ------------------------------------------------------------------
+	// Internal Route Definitions
+	// ------------------------------------------------------------------
+	// Internal routes are served on a separate, localhost-only listener and are
+	// never exposed through the public router.
+	internalRouter := gin.New()
+	internalRouter.Use(gin.Recovery())
+	internalRouter.GET("/debug/state", server.getDebugState)
+	internalRouter.POST("/admin/backfill/market-history-keys", server.backfillMarketHistoryKeys)
+	internalRouter.GET("/admin/markets/:id/consistency", server.getMarketConsistency)
+	internalRouter.GET("/admin/redis/memory", server.getRedisMemory)
+	internalRouter.GET("/admin/redis/publish-buffer", server.getRedisPublishBuffer)
+	server.InternalRouter = internalRouter
+
+	// Start the background services through the task manager, so shutdown can wait for them.
+	taskManager.Go("websocket-hub", server.hub.Run)
+	taskManager.Go("market-stream", server.marketStreamService.RunStream)
+	taskManager.Go("user-stream", server.marketStreamService.RunUserStream)
+	taskManager.Go("ohlcv-flush", server.marketStreamService.Aggregator().RunPeriodicFlush)
+	taskManager.Go("ohlcv-status-log", server.marketStreamService.Aggregator().RunStatusLog)
+	taskManager.Go("ohlcv-checkpoint", server.marketStreamService.Aggregator().RunCheckpoints)
+	taskManager.Go("redis-publish-buffer", server.publishBuffer.Run)
+	taskManager.Go("ohlcv-pipeline-monitor", server.pipelineMonitor.Run)
+	taskManager.Go("ohlcv-bar-watchdog", server.barWatchdog.Run)
+	taskManager.Go("order-sync", server.orderSyncService.Run)
+	taskManager.Go("order-retry", server.orderRetryService.Run)
 
 	return server
 }
 
+/**
+ * @description
+ * FlushOnShutdown saves all current OHLCV bars to the database, including bars still in
+ * progress, and publishes the buffered Redis messages, so that they are not lost on shutdown.
+ * It should be called after the background tasks have stopped, so that no update races the
+ * flush, and before the database pool and the Redis client are closed.
+ *
+ * @param ctx Bounds the database writes and Redis calls; it must not be the cancelled root context.
+ * @returns The outcome of the bar flush.
+ */
+func (s *Server) FlushOnShutdown(ctx context.Context) services.FlushResult {
+	result, unpublished := s.marketStreamService.FlushOnShutdown(ctx)
+	if result.BarsFailed > 0 {
+		s.logger.Error("failed to flush some OHLCV bars on shutdown", "bars_written", result.BarsWritten, "bars_failed", result.BarsFailed)
+	} else {
+		s.logger.Info("flushed OHLCV bars on shutdown", "bars_written", result.BarsWritten)
+	}
+	if unpublished > 0 {
+		s.logger.Error("buffered redis publishes lost on shutdown", "unpublished", unpublished)
+	}
+	return result
+}
+
 /**
  * @description
  * Close closes all connections and resources held by the server.
@@ -0,0 +1,189 @@
+/**
+ * @description
+ * This file contains the HTTP handler for the public status summary, which lets users tell
+ * whether the platform is degraded or a market is simply quiet.
+ *
+ * Key features:
+ * - Status Endpoint: Exposes `GET /api/v1/status` with the API state, market stream state,
+ *   data freshness, the latest completed bar per resolution, and active degradation flags.
+ * - Reduced Granularity: Freshness is reported as a bucket (live/delayed/stale) rather than
+ *   an exact age, and no internal errors, counts, or addresses are exposed.
+ * - Readiness: `GET /readyz` reports the market stream and OHLCV pipeline checks in detail,
+ *   returning 503 when either is degraded. Bar persistence latency is reported alongside
+ *   them, but lagging persistence alone does not fail readiness.
+ * - Caching: The summary is computed at most once per `statusCacheTTL` and served with a
+ *   matching public `Cache-Control` header.
+ *
+ * @notes
+ * - The summary is built from the same sources as the internal `/debug/state` dump.
+ */
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+const (
+	// statusCacheTTL is how long a computed status summary is served.
+	statusCacheTTL = 15 * time.Second
+	// statusRedisTimeout bounds the Redis health check.
+	statusRedisTimeout = 500 * time.Millisecond
+
+	// liveMaxAge and delayedMaxAge bound the data freshness buckets.
+	liveMaxAge    = 30 * time.Second
+	delayedMaxAge = 5 * time.Minute
+)
+
+// Data freshness buckets.
+const (
+	freshnessLive    = "live"
+	freshnessDelayed = "delayed"
+	freshnessStale   = "stale"
+	freshnessNone    = "no_data"
+)
+
+// Degradation flags.
+const (
+	degradedStream      = "stream_unavailable"
+	degradedMockData    = "mock_data"
+	degradedPersistence = "persistence_degraded"
+	degradedRedis       = "redis_degraded"
+	degradedPipeline    = "pipeline_stalled"
+	degradedPersistLag  = "persistence_lagging"
+)
+
+// streamStatus is the public view of the market data stream.
+type streamStatus struct {
+	Connected        bool   `json:"connected"`
+	Freshness        string `json:"freshness"`
+	MarketsStreaming int    `json:"markets_streaming"`
+}
+
+// platformStatus is the JSON body of the status endpoint.
+type platformStatus struct {
+	API               string               `json:"api"`
+	Stream            streamStatus         `json:"stream"`
+	LastCompletedBars map[string]time.Time `json:"last_completed_bars"` // Resolution -> bar start
+	Degradations      []string             `json:"degradations"`
+	GeneratedAt       time.Time            `json:"generated_at"`
+}
+
+// statusCache holds the most recently computed status summary.
+type statusCache struct {
+	mu      sync.Mutex
+	value   *platformStatus
+	expires time.Time
+}
+
+/**
+ * @function getStatus
+ * @description A Gin handler that returns the public platform status summary.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ */
+func (server *Server) getStatus(c *gin.Context) {
+	server.statusCache.mu.Lock()
+	if server.statusCache.value == nil || time.Now().After(server.statusCache.expires) {
+		server.statusCache.value = server.buildStatus()
+		server.statusCache.expires = time.Now().Add(statusCacheTTL)
+	}
+	status := server.statusCache.value
+	server.statusCache.mu.Unlock()
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(statusCacheTTL.Seconds())))
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": status})
+}
+
+// buildStatus assembles the status summary from the stream, aggregator, and Redis.
+// It does not use the request context, as the result is shared with other requests.
+func (server *Server) buildStatus() *platformStatus {
+	now := time.Now().UTC()
+	streamStats := server.marketStreamService.Stats(1)
+
+	var lastMessageAge time.Duration
+	if streamStats.LastMessageAt != nil {
+		lastMessageAge = now.Sub(*streamStats.LastMessageAt)
+	}
+
+	status := &platformStatus{
+		API: "up",
+		Stream: streamStatus{
+			Connected:        streamStats.Mode == "websocket",
+			Freshness:        freshnessBucket(lastMessageAge, streamStats.LastMessageAt != nil),
+			MarketsStreaming: server.marketStreamService.Catalog().Size(),
+		},
+		LastCompletedBars: streamStats.Aggregator.LastSavedBars,
+		Degradations:      []string{},
+		GeneratedAt:       now,
+	}
+
+	switch streamStats.Mode {
+	case "failed":
+		status.Degradations = append(status.Degradations, degradedStream)
+	case "mock":
+		status.Degradations = append(status.Degradations, degradedMockData)
+	}
+	if streamStats.Aggregator.LastSaveFailed {
+		status.Degradations = append(status.Degradations, degradedPersistence)
+	}
+	if streamStats.Aggregator.PersistLag.Lagging {
+		status.Degradations = append(status.Degradations, degradedPersistLag)
+	}
+	if server.pipelineMonitor.Health().Status == services.PipelineDegraded {
+		status.Degradations = append(status.Degradations, degradedPipeline)
+	}
+
+	pingCtx, cancel := context.WithTimeout(context.Background(), statusRedisTimeout)
+	defer cancel()
+	if err := server.redisClient.Ping(pingCtx).Err(); err != nil {
+		server.logger.Warn("status check: redis ping failed", "error", err)
+		status.Degradations = append(status.Degradations, degradedRedis)
+	}
+
+	return status
+}
+
+/**
+ * @function getReadiness
+ * @description A Gin handler that reports whether the server is ready to serve live data.
+ * It returns 503 if the market stream has stopped or the OHLCV pipeline has stalled.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ */
+func (server *Server) getReadiness(c *gin.Context) {
+	pipeline := server.pipelineMonitor.Health()
+	streamFailure := server.marketStreamService.Failure()
+
+	checks := gin.H{
+		"stream":      gin.H{"ok": streamFailure == "", "error": streamFailure},
+		"pipeline":    pipeline,
+		"persist_lag": server.marketStreamService.Aggregator().Stats().PersistLag,
+	}
+	if streamFailure != "" || pipeline.Status == services.PipelineDegraded {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "message": "Service is degraded", "data": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": checks})
+}
+
+// freshnessBucket classifies the age of the latest stream message.
+func freshnessBucket(age time.Duration, seen bool) string {
+	switch {
+	case !seen:
+		return freshnessNone
+	case age <= liveMaxAge:
+		return freshnessLive
+	case age <= delayedMaxAge:
+		return freshnessDelayed
+	default:
+		return freshnessStale
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestFreshnessBucket(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		seen bool
+		want string
+	}{
+		{0, false, freshnessNone},
+		{time.Hour, false, freshnessNone},
+		{0, true, freshnessLive},
+		{-2 * time.Second, true, freshnessLive}, // A message timestamp slightly ahead of the clock
+		{liveMaxAge, true, freshnessLive},
+		{liveMaxAge + time.Millisecond, true, freshnessDelayed},
+		{delayedMaxAge, true, freshnessDelayed},
+		{delayedMaxAge + time.Millisecond, true, freshnessStale},
+		{24 * time.Hour, true, freshnessStale},
+	}
+	for _, tt := range tests {
+		if got := freshnessBucket(tt.age, tt.seen); got != tt.want {
+			t.Errorf("freshnessBucket(%s, %v) = %s, want %s", tt.age, tt.seen, got, tt.want)
+		}
+	}
+}
+
+// TestGetStatusCaching checks that the summary is served from the cache with a public
+// Cache-Control header until it expires, and that it reports an unreachable Redis without
+// exposing the underlying error.
+func TestGetStatusCaching(t *testing.T) {
+	server := newDebugTestServer(t)
+	server.redisClient = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { server.redisClient.Close() })
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/status", server.getStatus)
+
+	get := func() (platformStatus, *httptest.ResponseRecorder) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d", w.Code)
+		}
+		var body struct {
+			Data platformStatus `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body.Data, w
+	}
+
+	first, w := get()
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=15" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	if first.API != "up" || first.Stream.Connected || first.Stream.Freshness != freshnessNone {
+		t.Errorf("status = %+v", first)
+	}
+	if !reflect.DeepEqual(first.Degradations, []string{degradedRedis}) {
+		t.Errorf("degradations = %v, want [%s]", first.Degradations, degradedRedis)
+	}
+	if body := w.Body.String(); strings.Contains(body, "127.0.0.1") || strings.Contains(body, "refused") {
+		t.Errorf("status exposes internal details: %s", body)
+	}
+
+	// Within the TTL the cached summary is served, even though Redis has not recovered.
+	second, w := get()
+	if !second.GeneratedAt.Equal(first.GeneratedAt) {
+		t.Errorf("summary regenerated within the TTL: %s, then %s", first.GeneratedAt, second.GeneratedAt)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=15" {
+		t.Errorf("cached response Cache-Control = %q", got)
+	}
+
+	server.statusCache.expires = time.Now().Add(-time.Second)
+	time.Sleep(time.Millisecond)
+	if third, _ := get(); !third.GeneratedAt.After(first.GeneratedAt) {
+		t.Errorf("expired summary served again, generated at %s", third.GeneratedAt)
+	}
+}
@@ -0,0 +1,238 @@
+/**
+ * @description
+ * This file contains the HTTP handlers of the TradingView UDF (Universal Data Feed) datafeed,
+ * served under `/api/v1/udf`, so that the charting library's UDF adapter can be pointed at
+ * a single base URL.
+ *
+ * Key features:
+ * - Configuration: `GET /udf/config` reports the enabled resolutions, symbol search, and the
+ *   unsupported optional features (group requests, marks).
+ * - Symbol Info: `GET /udf/symbols?symbol=<id>` resolves a market by condition ID or slug.
+ * - Symbol Search: `GET /udf/search?query=&limit=` proxies the Gamma API's market search.
+ * - Server Time: `GET /udf/time` returns the current Unix time in seconds, as plain text.
+ * - History: `GET /udf/history?symbol=&resolution=&from=&to=` is served by the same code as
+ *   `GET /markets/:id/history`, which remains available.
+ *
+ * @notes
+ * - Symbols are market condition IDs or slugs; the symbol info names a market by its
+ *   condition ID, so the chart's later history requests use it.
+ * - TradingView's "1D", "1W", and "1M" resolutions are accepted as our "D", "W", and "M".
+ * - Errors use the UDF format, `{"s":"error","errmsg":...}`.
+ */
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+const (
+	// udfPriceScale gives prices three decimals, the finest tick size of Polymarket markets.
+	udfPriceScale = 1000
+	// udfVolumePrecision is the number of decimals of bar volumes.
+	udfVolumePrecision = 2
+	// udfSymbolType is the instrument type reported for markets.
+	udfSymbolType = "prediction"
+	// udfExchange is the exchange reported for markets.
+	udfExchange = "Polymarket"
+	// Default and maximum number of symbols returned by a search.
+	defaultUDFSearchLimit = 30
+	maxUDFSearchLimit     = 100
+)
+
+// udfResolutionAliases maps TradingView resolution names to our resolution names.
+var udfResolutionAliases = map[string]string{
+	"1D": "D",
+	"1W": "W",
+	"1M": "M",
+}
+
+// udfSymbolInfo is the UDF symbol info of a market.
+type udfSymbolInfo struct {
+	Name                 string   `json:"name"`
+	Ticker               string   `json:"ticker"`
+	Description          string   `json:"description"`
+	Type                 string   `json:"type"`
+	Session              string   `json:"session"`
+	Timezone             string   `json:"timezone"`
+	Exchange             string   `json:"exchange"`
+	ListedExchange       string   `json:"listed_exchange"`
+	MinMov               int      `json:"minmov"`
+	PriceScale           int      `json:"pricescale"`
+	HasIntraday          bool     `json:"has_intraday"`
+	HasWeeklyAndMonthly  bool     `json:"has_weekly_and_monthly"`
+	SupportedResolutions []string `json:"supported_resolutions"`
+	VolumePrecision      int      `json:"volume_precision"`
+	DataStatus           string   `json:"data_status"`
+}
+
+// udfSearchResult is a UDF symbol search result.
+type udfSearchResult struct {
+	Symbol      string `json:"symbol"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Exchange    string `json:"exchange"`
+	Ticker      string `json:"ticker"`
+	Type        string `json:"type"`
+}
+
+/**
+ * @function getUDFConfig
+ * @description A Gin handler that returns the UDF datafeed configuration.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ */
+func (server *Server) getUDFConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"supported_resolutions":    services.Resolutions(),
+		"supports_search":          true,
+		"supports_group_request":   false,
+		"supports_marks":           false,
+		"supports_timescale_marks": false,
+		"supports_time":            true,
+	})
+}
+
+/**
+ * @function getUDFSymbol
+ * @description A Gin handler that returns the UDF symbol info of a market.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query symbol (required): The market's condition ID or slug.
+ */
+func (server *Server) getUDFSymbol(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if !isValidMarketID(symbol) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":      "error",
+			"errmsg": "invalid symbol",
+		})
+		return
+	}
+
+	gammaMarket, err := server.fetchGammaMarket(c.Request.Context(), symbol)
+	if err != nil {
+		server.logger.Warn("failed to fetch UDF symbol from Gamma API", "error", err, "symbol", symbol)
+		c.JSON(http.StatusNotFound, gin.H{
+			"s":      "error",
+			"errmsg": "unknown symbol",
+		})
+		return
+	}
+
+	resolutions := services.Resolutions()
+	hasWeekly := false
+	for _, resolution := range resolutions {
+		hasWeekly = hasWeekly || resolution == "W" || resolution == "M"
+	}
+	c.JSON(http.StatusOK, udfSymbolInfo{
+		Name:                 gammaMarket.ConditionID,
+		Ticker:               gammaMarket.ConditionID,
+		Description:          gammaMarket.Question,
+		Type:                 udfSymbolType,
+		Session:              "24x7",
+		Timezone:             "Etc/UTC",
+		Exchange:             udfExchange,
+		ListedExchange:       udfExchange,
+		MinMov:               1,
+		PriceScale:           udfPriceScale,
+		HasIntraday:          true,
+		HasWeeklyAndMonthly:  hasWeekly,
+		SupportedResolutions: resolutions,
+		VolumePrecision:      udfVolumePrecision,
+		DataStatus:           "streaming",
+	})
+}
+
+/**
+ * @function searchUDFSymbols
+ * @description A Gin handler that searches markets for the UDF symbol search.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query query (required): The search text.
+ * @query limit (optional): Maximum number of results (default: 30, max: 100).
+ *
+ * @notes
+ * - The UDF `type` and `exchange` filters are ignored: every market has the same type and exchange.
+ * - Results are named by condition ID, like the symbol info.
+ */
+func (server *Server) searchUDFSymbols(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("query"))
+	if query == "" {
+		c.JSON(http.StatusOK, []udfSearchResult{})
+		return
+	}
+
+	limit := defaultUDFSearchLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"s":      "error",
+				"errmsg": "invalid limit",
+			})
+			return
+		}
+		limit = min(parsed, maxUDFSearchLimit)
+	}
+
+	markets, err := server.gammaClient.SearchMarkets(c.Request.Context(), query, limit)
+	if err != nil {
+		server.logger.Error("failed to search markets with Gamma API", "error", err, "query", query)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"s":      "error",
+			"errmsg": "failed to search symbols",
+		})
+		return
+	}
+
+	results := make([]udfSearchResult, len(markets))
+	for i, market := range markets {
+		results[i] = udfSearchResult{
+			Symbol:      market.ConditionID,
+			FullName:    market.ConditionID,
+			Description: market.Question,
+			Exchange:    udfExchange,
+			Ticker:      market.ConditionID,
+			Type:        udfSymbolType,
+		}
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+/**
+ * @function getUDFTime
+ * @description A Gin handler that returns the server time, Unix timestamp in seconds, as plain text.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ */
+func (server *Server) getUDFTime(c *gin.Context) {
+	c.String(http.StatusOK, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+/**
+ * @function getUDFHistory
+ * @description A Gin handler that returns the bars of a market in the UDF format, like getMarketHistory.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @query symbol (required): The market's condition ID or slug.
+ * @query resolution (required): One of the enabled bar resolutions; "1D", "1W", and "1M" are accepted for "D", "W", and "M".
+ * @query from (required): Start of the range, Unix timestamp in seconds (inclusive).
+ * @query to (required): End of the range, Unix timestamp in seconds (inclusive).
+ */
+func (server *Server) getUDFHistory(c *gin.Context) {
+	resolution := c.Query("resolution")
+	if alias, ok := udfResolutionAliases[resolution]; ok {
+		resolution = alias
+	}
+	server.writeMarketHistory(c, c.Query("symbol"), resolution, c.Query("from"), c.Query("to"))
+}
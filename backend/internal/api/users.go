@@ -60,6 +60,6 @@ func (server *Server) getMe(c *gin.Context) {
 	}
 
 	// 3. Return the user's data.
-	c.JSON(http.StatusOK, gin.H{"status": "success", "data": user})
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": newUserResponse(user)})
 }
 
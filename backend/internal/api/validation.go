@@ -0,0 +1,165 @@
+/**
+ * @description
+ * This file contains the shared request binding helper and the translation of validator
+ * errors into field-level messages that the frontend can display next to form inputs.
+ *
+ * Key features:
+ * - Field Errors: Validation failures are returned as `{field, rule, message}` entries,
+ *   where `field` is the JSON name of the field rather than the Go struct field.
+ * - Readable Messages: Each supported rule (required, gt, lt, oneof, ...) has a
+ *   human-readable message that includes the rule's parameter.
+ * - Market IDs: `isValidMarketID` checks path parameters before they reach upstream
+ *   URLs or database queries.
+ * - Custom Validators: Registers the `eth_address`, `decimal_string`, and `resolution`
+ *   binding tags with Gin's validator engine.
+ *
+ * @notes
+ * - Handlers should bind request bodies with `bindJSON` instead of calling
+ *   `ShouldBindJSON` directly, so that every endpoint reports errors the same way.
+ */
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+var (
+	ethAddressRegexp    = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	decimalStringRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+	marketSlugRegexp    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{0,199}$`)
+
+	registerValidatorsOnce sync.Once
+)
+
+// fieldError describes a single field that failed validation.
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// isValidMarketID reports whether id is a well-formed condition ID (0x followed by 64 hex
+// characters) or a market slug of at most 200 letters, digits, and hyphens. Identifiers
+// starting with "0x" must be condition IDs.
+func isValidMarketID(id string) bool {
+	if strings.HasPrefix(id, "0x") {
+		return channels.IsConditionID(id)
+	}
+	return marketSlugRegexp.MatchString(id)
+}
+
+// registerValidators registers the custom binding tags and makes validation errors report
+// JSON field names. It is safe to call more than once.
+func registerValidators() {
+	registerValidatorsOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+		v.RegisterValidation("eth_address", func(fl validator.FieldLevel) bool {
+			return ethAddressRegexp.MatchString(fl.Field().String())
+		})
+		v.RegisterValidation("decimal_string", func(fl validator.FieldLevel) bool {
+			return decimalStringRegexp.MatchString(fl.Field().String())
+		})
+		v.RegisterValidation("resolution", func(fl validator.FieldLevel) bool {
+			_, ok := services.ResolutionDuration(fl.Field().String())
+			return ok
+		})
+	})
+}
+
+/**
+ * @description
+ * bindJSON binds the request body into obj and, on failure, writes a 400 response with
+ * field-level errors.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ * @param obj A pointer to the request struct.
+ * @returns true if the body was bound and validated; false if a response has been written.
+ */
+func (server *Server) bindJSON(c *gin.Context, obj interface{}) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		server.logger.Warn("malformed request body", "path", c.FullPath(), "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Request body must be valid JSON"})
+		return false
+	}
+
+	fields := translateValidationErrors(validationErrors)
+	server.logger.Warn("request validation failed", "path", c.FullPath(), "errors", fields)
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":  "error",
+		"message": "Invalid request body",
+		"errors":  fields,
+	})
+	return false
+}
+
+// translateValidationErrors converts validator errors into field errors.
+func translateValidationErrors(errs validator.ValidationErrors) []fieldError {
+	fields := make([]fieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, fieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: validationMessage(fe),
+		})
+	}
+	return fields
+}
+
+// validationMessage returns a human-readable message for a single failed rule.
+func validationMessage(fe validator.FieldError) string {
+	field, param := fe.Field(), fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, param)
+	case "lte":
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(strings.Fields(param), ", "))
+	case "eth_address":
+		return fmt.Sprintf("%s must be a 0x-prefixed Ethereum address", field)
+	case "decimal_string":
+		return fmt.Sprintf("%s must be a non-negative decimal number", field)
+	case "resolution":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(services.Resolutions(), ", "))
+	default:
+		return fmt.Sprintf("%s is invalid", field)
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+// validatedRequest uses each rule that has its own message.
+type validatedRequest struct {
+	MarketID   string  `json:"marketId" binding:"required"`
+	Price      float64 `json:"price" binding:"omitempty,gt=0,lt=1"`
+	Size       float64 `json:"size" binding:"omitempty,gte=5,lte=1000"`
+	Side       string  `json:"side" binding:"omitempty,oneof=BUY SELL"`
+	Wallet     string  `json:"wallet" binding:"omitempty,eth_address"`
+	Amount     string  `json:"amount" binding:"omitempty,decimal_string"`
+	Resolution string  `json:"resolution" binding:"omitempty,resolution"`
+	Email      string  `json:"email" binding:"omitempty,email"`
+}
+
+// bindTestRequest binds body with bindJSON, returning whether it was accepted and the
+// response written otherwise.
+func bindTestRequest(t *testing.T, body string) (bool, *httptest.ResponseRecorder) {
+	t.Helper()
+	registerValidators()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	server := &Server{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	var request validatedRequest
+	return server.bindJSON(c, &request), w
+}
+
+func TestBindJSONFieldErrors(t *testing.T) {
+	resolutions := strings.Join(services.Resolutions(), ", ")
+	tests := []struct {
+		name string
+		body string
+		want fieldError
+	}{
+		{"required", `{}`, fieldError{"marketId", "required", "marketId is required"}},
+		{"gt", `{"marketId":"m","price":-0.5}`, fieldError{"price", "gt", "price must be greater than 0"}},
+		{"lt", `{"marketId":"m","price":1}`, fieldError{"price", "lt", "price must be less than 1"}},
+		{"gte", `{"marketId":"m","size":4.99}`, fieldError{"size", "gte", "size must be at least 5"}},
+		{"lte", `{"marketId":"m","size":1000.5}`, fieldError{"size", "lte", "size must be at most 1000"}},
+		{"oneof", `{"marketId":"m","side":"buy"}`, fieldError{"side", "oneof", "side must be one of: BUY, SELL"}},
+		{"eth_address", `{"marketId":"m","wallet":"0x123"}`, fieldError{"wallet", "eth_address", "wallet must be a 0x-prefixed Ethereum address"}},
+		{"decimal_string", `{"marketId":"m","amount":"1e5"}`, fieldError{"amount", "decimal_string", "amount must be a non-negative decimal number"}},
+		{"resolution", `{"marketId":"m","resolution":"2"}`, fieldError{"resolution", "resolution", "resolution must be one of: " + resolutions}},
+		{"rule without a message", `{"marketId":"m","email":"not an email"}`, fieldError{"email", "email", "email is invalid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, w := bindTestRequest(t, tt.body)
+			if ok || w.Code != http.StatusBadRequest {
+				t.Fatalf("bindJSON = %v with status %d, want a 400", ok, w.Code)
+			}
+			var body struct {
+				Status  string       `json:"status"`
+				Message string       `json:"message"`
+				Errors  []fieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %s: %v", w.Body, err)
+			}
+			if body.Status != "error" || body.Message != "Invalid request body" {
+				t.Errorf("status, message = %q, %q", body.Status, body.Message)
+			}
+			if len(body.Errors) != 1 || body.Errors[0] != tt.want {
+				t.Errorf("errors = %+v, want [%+v]", body.Errors, tt.want)
+			}
+		})
+	}
+}
+
+// TestBindJSONReportsEveryField checks that all failing fields are reported, by JSON name.
+func TestBindJSONReportsEveryField(t *testing.T) {
+	ok, w := bindTestRequest(t, `{"price":2,"side":"HOLD"}`)
+	if ok {
+		t.Fatal("invalid request accepted")
+	}
+	var body struct {
+		Errors []fieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var fields []string
+	for _, fe := range body.Errors {
+		fields = append(fields, fe.Field+":"+fe.Rule)
+	}
+	if got := strings.Join(fields, " "); got != "marketId:required price:lt side:oneof" {
+		t.Errorf("errors = %s", got)
+	}
+	if strings.Contains(w.Body.String(), "validatedRequest") || strings.Contains(w.Body.String(), "MarketID") {
+		t.Errorf("response exposes Go names: %s", w.Body)
+	}
+}
+
+func TestBindJSONMalformedBody(t *testing.T) {
+	for _, body := range []string{`{"marketId":`, `[]`, `{"marketId":"m","price":"0.5"}`} {
+		ok, w := bindTestRequest(t, body)
+		if ok || w.Code != http.StatusBadRequest {
+			t.Fatalf("bindJSON(%s) = %v with status %d, want a 400", body, ok, w.Code)
+		}
+		if want := `{"message":"Request body must be valid JSON","status":"error"}`; w.Body.String() != want {
+			t.Errorf("bindJSON(%s) response = %s, want %s", body, w.Body, want)
+		}
+	}
+}
+
+func TestBindJSONAcceptsValidRequest(t *testing.T) {
+	body := `{"marketId":"m","price":0.55,"size":5,"side":"SELL","wallet":"0xAbC0000000000000000000000000000000000def","amount":"12.50","resolution":"D"}`
+	if ok, w := bindTestRequest(t, body); !ok {
+		t.Errorf("valid request rejected: %s", w.Body)
+	}
+}
+
+func TestCustomValidators(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		valid bool
+	}{
+		{"wallet", "0x52908400098527886E0F7030069857D2E4169EE7", true},
+		{"wallet", "0x52908400098527886e0f7030069857d2e4169ee7", true},
+		{"wallet", "52908400098527886E0F7030069857D2E4169EE7", false},
+		{"wallet", "0x52908400098527886E0F7030069857D2E4169EE", false},
+		{"wallet", "0x52908400098527886E0F7030069857D2E4169EEZ", false},
+		{"amount", "0", true},
+		{"amount", "10.25", true},
+		{"amount", "-1", false},
+		{"amount", ".5", false},
+		{"amount", "1.", false},
+		{"amount", "1,000", false},
+		{"resolution", "1", true},
+		{"resolution", "D", true},
+		{"resolution", "d", false},
+		{"resolution", "1m", false},
+	}
+	for _, tt := range tests {
+		value, _ := json.Marshal(tt.value)
+		ok, w := bindTestRequest(t, `{"marketId":"m","`+tt.field+`":`+string(value)+`}`)
+		if ok != tt.valid {
+			t.Errorf("%s %q: accepted = %v, want %v (%s)", tt.field, tt.value, ok, tt.valid, w.Body)
+		}
+	}
+}
+
+func TestIsValidMarketID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1", true},
+		{"fed-rate-cut-december", true},
+		{"Will-BTC-Hit-100k", true},
+		{strings.Repeat("a", 200), true},
+		{strings.Repeat("a", 201), false},
+		{"0x5f65", false}, // Looks like a condition ID, but is not one
+		{"-leading-hyphen", false},
+		{"slug/../admin", false},
+		{"slug?x=1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isValidMarketID(tt.id); got != tt.want {
+			t.Errorf("isValidMarketID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/**
+ * @description
+ * This file contains the HTTP handlers for wallet ownership verification.
+ * A user proves they control a wallet's Polymarket funder address by signing a
+ * one-time challenge message with that address's key.
+ *
+ * Key features:
+ * - Challenge Issuance: `POST /api/v1/wallets/:id/challenge` returns a random nonce message.
+ * - Signature Verification: `POST /api/v1/wallets/:id/verify` accepts the EIP-191 signature
+ *   over that message and marks the wallet verified if it recovers to the funder address.
+ * - Ownership Scoping: Wallets belonging to other users are reported as not found.
+ *
+ * @notes
+ * - The message is intended to be signed in the browser with `personal_sign`. Custodial keys
+ *   held by the remote signer cannot sign it yet, as the signer only supports EIP-712 payloads.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/poly-pro/backend/internal/auth"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+// verifyWalletRequest defines the structure of the JSON body expected
+// for a request to the `POST /api/v1/wallets/:id/verify` endpoint.
+type verifyWalletRequest struct {
+	Nonce     string `json:"nonce" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+/**
+ * @description
+ * createWalletChallenge is a Gin handler that issues a verification challenge for a wallet.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @notes
+ * - This handler must be used with the authentication middleware.
+ * - The 'id' parameter is the wallet's UUID.
+ */
+func (server *Server) createWalletChallenge(c *gin.Context) {
+	clerkUserID, exists := c.Get(string(auth.ClerkUserIDKey))
+	if !exists {
+		server.logger.Error("clerkUserID not found in context for createWalletChallenge")
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "User identifier not found in request context"})
+		return
+	}
+
+	var walletID pgtype.UUID
+	if err := walletID.Scan(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid wallet ID"})
+		return
+	}
+
+	challenge, err := server.walletService.CreateChallenge(c.Request.Context(), clerkUserID.(string), walletID)
+	if err != nil {
+		server.respondWalletError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": newWalletChallengeResponse(challenge)})
+}
+
+/**
+ * @description
+ * verifyWallet is a Gin handler that checks a signed challenge and marks the wallet verified.
+ *
+ * @param c *gin.Context The Gin context for the request.
+ *
+ * @notes
+ * - This handler must be used with the authentication middleware.
+ * - Each challenge can be submitted only once, whether or not the signature is valid.
+ */
+func (server *Server) verifyWallet(c *gin.Context) {
+	clerkUserID, exists := c.Get(string(auth.ClerkUserIDKey))
+	if !exists {
+		server.logger.Error("clerkUserID not found in context for verifyWallet")
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "User identifier not found in request context"})
+		return
+	}
+
+	var walletID pgtype.UUID
+	if err := walletID.Scan(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid wallet ID"})
+		return
+	}
+
+	var req verifyWalletRequest
+	if !server.bindJSON(c, &req) {
+		return
+	}
+
+	wallet, err := server.walletService.VerifyChallenge(c.Request.Context(), clerkUserID.(string), walletID, req.Nonce, req.Signature)
+	if err != nil {
+		server.respondWalletError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": newWalletResponse(wallet)})
+}
+
+// respondWalletError maps wallet service errors to HTTP responses.
+func (server *Server) respondWalletError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWalletNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Wallet not found"})
+	case errors.Is(err, services.ErrWalletAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Wallet is already verified"})
+	case errors.Is(err, services.ErrChallengeInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Challenge is invalid, expired, or already used"})
+	case errors.Is(err, services.ErrInvalidSignature):
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Signature is malformed"})
+	case errors.Is(err, services.ErrSignatureAddressMismatch):
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Signature does not match the wallet address"})
+	default:
+		server.logger.Error("wallet verification request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to process wallet verification"})
+	}
+}
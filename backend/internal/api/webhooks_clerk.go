@@ -6,7 +6,11 @@
  *
  * Key features:
  * - Secure Webhook Verification: Implements Svix signature verification to verify the
- *   authenticity of incoming webhooks using HMAC-SHA256 with the secret key.
+ *   authenticity of incoming webhooks using HMAC-SHA256 with the secret key. Several secrets
+ *   may be configured during a rotation window; a signature made with any of them is accepted.
+ * - Instance Check (strict mode): When allowed instance IDs or a user ID prefix are configured,
+ *   events from other Clerk instances are rejected with 403 and an audit log entry, so that a
+ *   leaked signing secret cannot be used to create users from another Clerk application.
  * - Decoupled Logic: The handler is responsible only for the HTTP-level interaction
  *   (request/response), while the actual business logic of creating a user
  *   is delegated to the UserService.
@@ -21,19 +25,24 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/logsafe"
 	"github.com/poly-pro/backend/internal/services"
 )
 
 // clerkUserCreatedEvent represents the structure of the relevant parts of a
 // Clerk 'user.created' webhook payload. We only unmarshal the fields we need.
 type clerkUserCreatedEvent struct {
-	Data struct {
+	InstanceID string `json:"instance_id"` // Present in recent Clerk payloads
+	Data       struct {
 		ID                   string `json:"id"`
+		InstanceID           string `json:"instance_id"`
 		PrimaryEmailAddressID string `json:"primary_email_address_id"`
 		EmailAddresses       []struct {
 			EmailAddress string `json:"email_address"`
@@ -57,7 +66,8 @@ type clerkUserCreatedEvent struct {
  * @param c *gin.Context The Gin context for the request.
  *
  * @notes
- * - The Clerk Webhook Secret must be configured in the environment variables.
+ * - The Clerk webhook signing secrets must be configured in CLERK_WEBHOOK_SECRETS (or, as
+ *   a fallback, CLERK_SECRET_KEY).
  * - This endpoint should be registered in the Clerk Dashboard for the 'user.created' event.
  * - It relies on the Svix headers (svix-id, svix-timestamp, svix-signature)
  *   being present in the request for verification.
@@ -82,10 +92,23 @@ func (server *Server) handleCreateUserWebhook(c *gin.Context) {
 	// 3. Unmarshal the verified payload into our event struct.
 	var event clerkUserCreatedEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		server.logger.Error("failed to unmarshal clerk webhook payload", "error", err, "body", string(body))
+		server.logger.Error("failed to unmarshal clerk webhook payload", "error", err, "body", logsafe.Body(body))
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid webhook payload"})
 		return
 	}
+
+	// 4. In strict mode, reject events from other Clerk instances.
+	if err := server.checkClerkInstance(&event); err != nil {
+		server.logger.Warn("audit: clerk webhook rejected, unexpected instance",
+			"reason", err.Error(),
+			"event_type", event.Type,
+			"instance_id", event.instanceID(),
+			"user_id", event.Data.ID,
+			"svix_id", c.GetHeader("svix-id"),
+			"client_ip", c.ClientIP())
+		c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": "Webhook from unexpected Clerk instance"})
+		return
+	}
 	
 	server.logger.Debug("parsed webhook event", 
 		"type", event.Type,
@@ -158,7 +181,7 @@ func (server *Server) handleCreateUserWebhook(c *gin.Context) {
 	if err != nil {
 		// If user already exists, this is an idempotent operation - treat as success
 		// According to Clerk docs: Return 2xx status to acknowledge webhook and prevent retries
-		if err == services.ErrUserAlreadyExists {
+		if errors.Is(err, services.ErrUserAlreadyExists) {
 			// Try to fetch the existing user to return in the response
 			existingUser, findErr := server.userService.GetUserByClerkID(c.Request.Context(), event.Data.ID)
 			if findErr != nil {
@@ -185,11 +208,19 @@ func (server *Server) handleCreateUserWebhook(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
 				"status": "success", 
 				"message": "User already exists", 
-				"data": existingUser,
+				"data": newUserResponse(existingUser),
 			})
 			return
 		}
 		
+		// The email belongs to a different user: the user cannot be created until that is
+		// resolved, so report a conflict. Clerk retries it like other errors.
+		if errors.Is(err, services.ErrUserEmailTaken) {
+			server.logger.Error("cannot create user from webhook, email belongs to another user", "clerk_id", event.Data.ID, "email", primaryEmail)
+			c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+
 		// For other errors, return 500 to indicate server error
 		// Clerk will retry these errors according to their retry schedule
 		server.logger.Error("failed to create user from webhook", "error", err)
@@ -199,13 +230,62 @@ func (server *Server) handleCreateUserWebhook(c *gin.Context) {
 
 	// 8. Respond with success.
 	server.logger.Info("successfully created user from clerk webhook", "user_id", user.ID)
-	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": user})
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": newUserResponse(user)})
+}
+
+// instanceID returns the Clerk instance ID of the event, from the envelope or its data.
+func (event *clerkUserCreatedEvent) instanceID() string {
+	if event.InstanceID != "" {
+		return event.InstanceID
+	}
+	return event.Data.InstanceID
+}
+
+/**
+ * @description
+ * checkClerkInstance verifies, in strict mode, that a webhook event comes from our Clerk
+ * instance: its instance_id must be allowed when present, and its user ID must start with the
+ * configured prefix. Strict mode is off when neither is configured.
+ *
+ * @param event The verified webhook event.
+ * @returns An error describing the mismatch, or nil if the event is accepted.
+ */
+func (server *Server) checkClerkInstance(event *clerkUserCreatedEvent) error {
+	allowedInstances := server.config.ClerkWebhookInstanceIDs
+	userIDPrefix := server.config.ClerkWebhookUserIDPrefix
+	if len(allowedInstances) == 0 && userIDPrefix == "" {
+		return nil
+	}
+
+	instanceID := event.instanceID()
+	switch {
+	case instanceID != "" && len(allowedInstances) > 0:
+		allowed := false
+		for _, allowedInstance := range allowedInstances {
+			if instanceID == allowedInstance {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("instance %q is not allowed", instanceID)
+		}
+	case instanceID == "" && userIDPrefix == "":
+		// Nothing else identifies the instance, so the event cannot be attributed to ours.
+		return errors.New("event has no instance_id")
+	}
+
+	if userIDPrefix != "" && event.Data.ID != "" && !strings.HasPrefix(event.Data.ID, userIDPrefix) {
+		return fmt.Errorf("user ID %q does not have the expected prefix", event.Data.ID)
+	}
+	return nil
 }
 
 /**
  * @description
  * verifySvixSignature verifies the Svix signature of the webhook request.
  * Clerk uses Svix for webhook signing, which uses HMAC-SHA256 with the secret key.
+ * Every configured secret is tried, so that webhooks keep verifying while a secret is rotated.
  *
  * @param body The raw request body bytes.
  * @param headers The HTTP request headers.
@@ -233,33 +313,19 @@ func (server *Server) verifySvixSignature(body []byte, headers http.Header) bool
 		return false
 	}
 
-	// Get the secret key from config
-	secret := server.config.ClerkSecretKey
-	if secret == "" {
-		server.logger.Error("CLERK_SECRET_KEY is not configured")
-		return false
-	}
-
-	// Svix secret format: The secret starts with "whsec_" and the rest is base64 encoded
-	// According to Svix documentation, we need to use the secret as-is but decode only the part after "whsec_"
-	var secretBytes []byte
-	var err error
-	
-	if strings.HasPrefix(secret, "whsec_") {
-		// Extract the base64 part after "whsec_"
-		secretKey := secret[6:] // Remove "whsec_" prefix
-		secretBytes, err = base64.StdEncoding.DecodeString(secretKey)
-		if err != nil {
-			server.logger.Error("failed to decode secret key from whsec format", "error", err)
-			return false
-		}
-	} else {
-		// If no prefix, try to decode the entire secret as base64
-		secretBytes, err = base64.StdEncoding.DecodeString(secret)
+	// Get the secret keys from config; several are configured during a rotation window
+	var secretKeys [][]byte
+	for i, secret := range server.config.ClerkWebhookSecrets {
+		secretBytes, err := decodeSvixSecret(secret)
 		if err != nil {
-			server.logger.Error("failed to decode secret key", "error", err)
-			return false
+			server.logger.Error("failed to decode webhook secret", "error", err, "secret_index", i)
+			continue
 		}
+		secretKeys = append(secretKeys, secretBytes)
+	}
+	if len(secretKeys) == 0 {
+		server.logger.Error("CLERK_WEBHOOK_SECRETS is not configured or invalid")
+		return false
 	}
 
 	// Parse the signature header
@@ -295,11 +361,6 @@ func (server *Server) verifySvixSignature(body []byte, headers http.Header) bool
 		signedContent = append(signedContent, byte('.'))
 		signedContent = append(signedContent, body...)
 
-		// Compute HMAC-SHA256
-		mac := hmac.New(sha256.New, secretBytes)
-		mac.Write(signedContent)
-		expectedMAC := mac.Sum(nil)
-
 		// Decode the signature from base64
 		receivedMAC, err := base64.StdEncoding.DecodeString(signatureStr)
 		if err != nil {
@@ -307,10 +368,14 @@ func (server *Server) verifySvixSignature(body []byte, headers http.Header) bool
 			continue
 		}
 
-		// Compare using constant-time comparison
-		if hmac.Equal(expectedMAC, receivedMAC) {
-			server.logger.Debug("signature verification successful")
-			return true
+		// Compute HMAC-SHA256 with each secret and compare using constant-time comparison
+		for i, secretBytes := range secretKeys {
+			mac := hmac.New(sha256.New, secretBytes)
+			mac.Write(signedContent)
+			if hmac.Equal(mac.Sum(nil), receivedMAC) {
+				server.logger.Debug("signature verification successful", "secret_index", i)
+				return true
+			}
 		}
 	}
 
@@ -322,3 +387,7 @@ func (server *Server) verifySvixSignature(body []byte, headers http.Header) bool
 	return false
 }
 
+// decodeSvixSecret decodes a Svix signing secret: base64, optionally prefixed with "whsec_".
+func decodeSvixSecret(secret string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/poly-pro/backend/internal/config"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+// userStore is a db.Querier that creates every user it is asked to.
+type userStore struct {
+	db.Querier
+	created []string // Clerk IDs of the created users
+}
+
+func (s *userStore) CreateUser(_ context.Context, arg db.CreateUserParams) (db.User, error) {
+	s.created = append(s.created, arg.ClerkUserID)
+	return db.User{ID: fixtureUUID(0x01), ClerkUserID: arg.ClerkUserID, Email: arg.Email}, nil
+}
+
+// newWebhookServer creates a server handling Clerk webhooks with cfg, creating users in store.
+func newWebhookServer(cfg config.Config, store *userStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := &Server{
+		config:      cfg,
+		logger:      logger,
+		userService: services.NewUserService(store, logger),
+	}
+	router := gin.New()
+	router.POST("/webhooks/clerk", server.handleCreateUserWebhook)
+	return router
+}
+
+// testWebhookSecret builds a Svix signing secret from a seed.
+func testWebhookSecret(seed string) string {
+	return "whsec_" + base64.StdEncoding.EncodeToString([]byte(seed+"-signing-secret"))
+}
+
+// postWebhook sends a webhook body signed with secret and returns the response code.
+func postWebhook(t *testing.T, router *gin.Engine, body, secret string) int {
+	t.Helper()
+	const svixID, svixTimestamp = "msg_2x7Yz", "1760796615"
+	key, err := decodeSvixSecret(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(svixID + "." + svixTimestamp + "." + body))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/clerk", strings.NewReader(body))
+	req.Header.Set("svix-id", svixID)
+	req.Header.Set("svix-timestamp", svixTimestamp)
+	req.Header.Set("svix-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// TestClerkWebhookSecretRotation checks that during a rotation a signature made with any of
+// the configured secrets is accepted, and one made with another secret is not.
+func TestClerkWebhookSecretRotation(t *testing.T) {
+	oldSecret, newSecret := testWebhookSecret("old"), testWebhookSecret("new")
+	body := `{"type":"user.created","data":{"id":"user_2abc","email_addresses":[{"id":"idn_1","email_address":"trader@example.com"}]}}`
+
+	tests := []struct {
+		name    string
+		secrets []string
+		signer  string
+		want    int
+	}{
+		{"single secret", []string{newSecret}, newSecret, http.StatusCreated},
+		{"new secret while rotating", []string{oldSecret, newSecret}, newSecret, http.StatusCreated},
+		{"old secret while rotating", []string{oldSecret, newSecret}, oldSecret, http.StatusCreated},
+		{"old secret after rotation", []string{newSecret}, oldSecret, http.StatusUnauthorized},
+		// A secret that cannot be decoded is skipped rather than failing the others.
+		{"invalid secret configured", []string{"whsec_not base64!", newSecret}, newSecret, http.StatusCreated},
+		{"no valid secret configured", []string{"whsec_not base64!"}, newSecret, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &userStore{}
+			router := newWebhookServer(config.Config{ClerkWebhookSecrets: tt.secrets}, store)
+			if got := postWebhook(t, router, body, tt.signer); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+			if created := len(store.created) == 1; created != (tt.want == http.StatusCreated) {
+				t.Errorf("created users = %v", store.created)
+			}
+		})
+	}
+}
+
+func TestCheckClerkInstance(t *testing.T) {
+	tests := []struct {
+		name       string
+		instances  []string
+		prefix     string
+		event      string
+		wantReject bool
+	}{
+		{"strict mode off", nil, "", `{"instance_id":"ins_other","data":{"id":"user_1"}}`, false},
+		{"allowed instance", []string{"ins_ours", "ins_staging"}, "", `{"instance_id":"ins_staging","data":{"id":"user_1"}}`, false},
+		{"allowed instance in the data", []string{"ins_ours"}, "", `{"data":{"id":"user_1","instance_id":"ins_ours"}}`, false},
+		{"other instance", []string{"ins_ours"}, "", `{"instance_id":"ins_other","data":{"id":"user_1"}}`, true},
+		{"no instance to check", []string{"ins_ours"}, "", `{"data":{"id":"user_1"}}`, true},
+		{"expected prefix", nil, "user_2", `{"data":{"id":"user_2abc"}}`, false},
+		{"other prefix", nil, "user_2", `{"data":{"id":"user_9abc"}}`, true},
+		{"allowed instance with another prefix", []string{"ins_ours"}, "user_2", `{"instance_id":"ins_ours","data":{"id":"user_9abc"}}`, true},
+		{"prefix without an instance", []string{"ins_ours"}, "user_2", `{"data":{"id":"user_2abc"}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := testWebhookSecret("ours")
+			store := &userStore{}
+			router := newWebhookServer(config.Config{
+				ClerkWebhookSecrets:      []string{secret},
+				ClerkWebhookInstanceIDs:  tt.instances,
+				ClerkWebhookUserIDPrefix: tt.prefix,
+			}, store)
+
+			body := strings.Replace(tt.event, `{`, `{"type":"user.created",`, 1)
+			got := postWebhook(t, router, body, secret)
+			if tt.wantReject {
+				if got != http.StatusForbidden || len(store.created) != 0 {
+					t.Errorf("status = %d with users %v created, want 403 with none", got, store.created)
+				}
+				return
+			}
+			if got != http.StatusCreated {
+				t.Errorf("status = %d, want 201", got)
+			}
+		})
+	}
+}
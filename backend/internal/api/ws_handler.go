@@ -6,8 +6,17 @@
  * Key features:
  * - WebSocket Upgrade: Uses the `gorilla/websocket` library's `Upgrader` to handle
  *   the WebSocket handshake protocol.
+ * - Compression: When WS_COMPRESSION_ENABLED is set, permessage-deflate is negotiated with
+ *   clients that support it, and the bytes written before and after compression are counted.
  * - Client Instantiation: Once a connection is successfully upgraded, it creates a new
  *   `Client` instance to manage the connection.
+ * - Client Plans: A client presenting an API key (`api_key` query parameter or `X-API-Key`
+ *   header) is on the api_key plan, one presenting a Clerk session token (`token` query
+ *   parameter or `Authorization: Bearer` header) is authenticated, and any other client is
+ *   anonymous. Invalid credentials are rejected with 401 rather than downgraded.
+ * - Client Identity: The real client IP (resolved from X-Forwarded-For / X-Real-IP), the
+ *   raw X-Forwarded-For header, the user agent, the plan, and the Clerk user ID are logged
+ *   on connect, stored on the `Client`, and attached to every later log of the client.
  * - Hub Registration: The new client is registered with the central `Hub`, allowing it
  *   to receive broadcasted messages.
  * - Goroutine Management: Starts the `ReadPump` and `WritePump` for the new client in
@@ -23,34 +32,122 @@
 package api
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	gorillaWS "github.com/gorilla/websocket"
+	"github.com/poly-pro/backend/internal/config"
 	"github.com/poly-pro/backend/internal/websocket"
 )
 
-// upgrader configures the parameters for upgrading an HTTP connection to a WebSocket connection.
-var upgrader = gorillaWS.Upgrader{
-	// CheckOrigin allows us to control which origins are allowed to connect.
-	// For development, we allow all origins. In production, this should be
-	// configured to only allow requests from the frontend's domain.
-	CheckOrigin: func(r *http.Request) bool {
-		// TODO: In production, validate the origin against a list of allowed domains.
-		return true
-	},
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
+// maxLoggedUserAgentLength bounds the user agent stored on clients and logged.
+const maxLoggedUserAgentLength = 256
+
+// newUpgrader configures the parameters for upgrading an HTTP connection to a WebSocket connection.
+func newUpgrader(enableCompression bool) *gorillaWS.Upgrader {
+	return &gorillaWS.Upgrader{
+		// CheckOrigin allows us to control which origins are allowed to connect.
+		// For development, we allow all origins. In production, this should be
+		// configured to only allow requests from the frontend's domain.
+		CheckOrigin: func(r *http.Request) bool {
+			// TODO: In production, validate the origin against a list of allowed domains.
+			return true
+		},
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		EnableCompression: enableCompression,
+	}
+}
+
+// wsPlanLimits builds the per-plan client limits from the configuration.
+func wsPlanLimits(cfg config.Config) websocket.StaticLimits {
+	limits := make(websocket.StaticLimits, len(websocket.Plans))
+	for _, plan := range websocket.Plans {
+		limits[plan] = websocket.PlanLimits{
+			MaxSubscriptions: cfg.WSMaxSubscriptions[string(plan)],
+			MinThrottle:      cfg.WSMinThrottle[string(plan)],
+		}
+	}
+	return limits
+}
+
+/**
+ * @description
+ * identifyWSClient determines the plan of a connecting client from its credentials.
+ *
+ * @param c *gin.Context The Gin context of the upgrade request.
+ * @returns The plan and, for authenticated clients, the Clerk user ID.
+ * @returns An error if the client presented invalid credentials.
+ */
+func (server *Server) identifyWSClient(c *gin.Context) (websocket.Plan, string, error) {
+	apiKey := c.GetHeader("X-API-Key")
+	if apiKey == "" {
+		apiKey = c.Query("api_key")
+	}
+	if apiKey != "" {
+		for _, validKey := range server.config.WSAPIKeys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(validKey)) == 1 {
+				return websocket.PlanAPIKey, "", nil
+			}
+		}
+		return "", "", errors.New("Invalid API key")
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		return websocket.PlanAnonymous, "", nil
+	}
+	if server.verifier == nil {
+		return "", "", errors.New("Token authentication is not available")
+	}
+	identity, err := server.verifier.Verify(token)
+	if err != nil {
+		return "", "", err
+	}
+	return websocket.PlanAuthenticated, identity.ClerkUserID, nil
 }
 
 // serveWs handles websocket requests from the peer.
 func (server *Server) serveWs(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	plan, userID, err := server.identifyWSClient(c)
+	if err != nil {
+		server.logger.Warn("websocket client rejected, invalid credentials", "error", err, "client_ip", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	traffic := server.hub.NewClientTraffic()
+	conn, err := server.wsUpgrader.Upgrade(traffic.WrapResponseWriter(c.Writer), c.Request, nil)
 	if err != nil {
 		server.logger.Error("failed to upgrade connection to websocket", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to upgrade connection"})
 		return
 	}
+	// Writes are compressed whenever the client negotiated permessage-deflate.
+	if server.config.WSCompressionEnabled && server.config.WSCompressionLevel != 0 {
+		if err := conn.SetCompressionLevel(server.config.WSCompressionLevel); err != nil {
+			server.logger.Warn("failed to set websocket compression level", "error", err, "level", server.config.WSCompressionLevel)
+		}
+	}
+
+	// Identify the connection for later logs. Behind a proxy the remote address is the
+	// proxy's, so the real client IP is resolved from the forwarding headers.
+	clientIP := c.ClientIP()
+	forwardedFor := c.GetHeader("X-Forwarded-For")
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxLoggedUserAgentLength {
+		userAgent = userAgent[:maxLoggedUserAgentLength]
+	}
+	clientLogger := server.logger.With("client_ip", clientIP, "plan", plan)
+	if userID != "" {
+		clientLogger = clientLogger.With("user_id", userID)
+	}
 
 	// Create a new client for this connection.
 	client := &websocket.Client{
@@ -58,11 +155,20 @@ func (server *Server) serveWs(c *gin.Context) {
 		Conn:         conn,
 		Send:         make(chan []byte, 256),
 		Subscriptions: make(map[string]bool),
-		Logger:       server.logger,
+		Logger:       clientLogger,
+		Traffic:      traffic,
+		Plan:         plan,
+		UserID:       userID,
+		ClientIP:     clientIP,
+		ForwardedFor: forwardedFor,
+		UserAgent:    userAgent,
 	}
 
 	// Register the new client with the hub.
-	server.logger.Info("🔌 ws_handler: client created, registering with hub", "remote_addr", conn.RemoteAddr())
+	clientLogger.Info("🔌 ws_handler: client created, registering with hub",
+		"remote_addr", conn.RemoteAddr(),
+		"forwarded_for", forwardedFor,
+		"user_agent", userAgent)
 	server.hub.Register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -70,6 +176,6 @@ func (server *Server) serveWs(c *gin.Context) {
 	go client.WritePump()
 	go client.ReadPump()
 
-	server.logger.Info("✅ ws_handler: websocket client connected and pumps started", "remote_addr", conn.RemoteAddr())
+	clientLogger.Info("✅ ws_handler: websocket client connected and pumps started", "remote_addr", conn.RemoteAddr())
 }
 
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/poly-pro/backend/internal/auth"
+	"github.com/poly-pro/backend/internal/config"
+	"github.com/poly-pro/backend/internal/websocket"
+)
+
+// newTestVerifier serves a JWKS for a fresh RSA key and returns a verifier using it, with
+// a function signing tokens for the given issuer and subject.
+func newTestVerifier(t *testing.T) (*auth.Verifier, string, func(issuer, subject string) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"keys":[{"kty":"RSA","kid":"test","alg":"RS256","use":"sig","n":%q,"e":%q}]}`,
+			base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	}))
+	t.Cleanup(issuer.Close)
+
+	verifier, err := auth.NewVerifier(issuer.URL)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	sign := func(iss, subject string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss": iss,
+			"sub": subject,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+	return verifier, issuer.URL, sign
+}
+
+// TestIdentifyWSClient checks which plan each kind of credential puts a WebSocket client
+// on, and that invalid credentials are rejected rather than downgraded to anonymous.
+func TestIdentifyWSClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier, issuer, sign := newTestVerifier(t)
+	server := &Server{config: config.Config{WSAPIKeys: []string{"key-one", "key-two"}}, verifier: verifier}
+
+	tests := []struct {
+		name       string
+		query      string
+		header     http.Header
+		wantPlan   websocket.Plan
+		wantUserID string
+		wantErr    string
+	}{
+		{name: "no credentials", wantPlan: websocket.PlanAnonymous},
+		{name: "API key header", header: http.Header{"X-Api-Key": {"key-two"}}, wantPlan: websocket.PlanAPIKey},
+		{name: "API key query", query: "api_key=key-one", wantPlan: websocket.PlanAPIKey},
+		// An API key takes precedence over a token sent alongside it.
+		{name: "API key and token", query: "api_key=key-one&token=" + sign(issuer, "user_1"), wantPlan: websocket.PlanAPIKey},
+		{name: "invalid API key", header: http.Header{"X-Api-Key": {"key-three"}}, wantErr: "Invalid API key"},
+		{name: "token query", query: "token=" + sign(issuer, "user_1"), wantPlan: websocket.PlanAuthenticated, wantUserID: "user_1"},
+		{name: "bearer token", header: http.Header{"Authorization": {"Bearer " + sign(issuer, "user_2")}}, wantPlan: websocket.PlanAuthenticated, wantUserID: "user_2"},
+		{name: "token from another issuer", query: "token=" + sign("https://clerk.example.com", "user_1"), wantErr: "issuer"},
+		{name: "malformed token", query: "token=not-a-jwt", wantErr: "Invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/ws?"+tt.query, nil)
+			for name, values := range tt.header {
+				c.Request.Header[name] = values
+			}
+
+			plan, userID, err := server.identifyWSClient(c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || plan != tt.wantPlan || userID != tt.wantUserID {
+				t.Errorf("identifyWSClient = %q, %q, %v; want %q, %q", plan, userID, err, tt.wantPlan, tt.wantUserID)
+			}
+		})
+	}
+
+	t.Run("token without a verifier", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/ws?token="+sign(issuer, "user_1"), nil)
+		if _, _, err := (&Server{}).identifyWSClient(c); err == nil {
+			t.Error("a token was accepted without a verifier")
+		}
+	})
+}
+
+func TestWSPlanLimits(t *testing.T) {
+	cfg := config.Config{
+		WSMaxSubscriptions: map[string]int{"anonymous": 10, "authenticated": 50},
+		WSMinThrottle:      map[string]time.Duration{"anonymous": time.Second, "api_key": 50 * time.Millisecond},
+	}
+	want := websocket.StaticLimits{
+		websocket.PlanAnonymous:     {MaxSubscriptions: 10, MinThrottle: time.Second},
+		websocket.PlanAuthenticated: {MaxSubscriptions: 50},
+		websocket.PlanAPIKey:        {MinThrottle: 50 * time.Millisecond},
+	}
+	if got := wsPlanLimits(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("wsPlanLimits = %v, want %v", got, want)
+	}
+}
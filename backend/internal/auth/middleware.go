@@ -5,12 +5,15 @@
  * for protected API routes.
  *
  * Key features:
- * - JWT Validation: Verifies the signature and claims of the token.
+ * - JWT Validation: Verifies the signature and claims of the token. The `Verifier` is also
+ *   used outside the middleware, e.g. to identify WebSocket clients.
  * - JWKS Integration: Uses a JWKS (JSON Web Key Set) client to fetch Clerk's
  *   public keys for signature verification. The key set is cached to avoid
  *   excessive network requests.
  * - Context Injection: Upon successful validation, the user's Clerk ID (from the 'sub' claim)
- *   is injected into the Gin context for use by downstream handlers.
+ *   is injected into the Gin context for use by downstream handlers, along with the user's
+ *   role when the token carries one.
+ * - Role Checks: `RequireRole` restricts routes (e.g. admin operations) to users with a role.
  * - Error Handling: Returns a 401 Unauthorized status with a clear error message
  *   if authentication fails for any reason (e.g., missing token, invalid signature,
  *   expired token).
@@ -25,6 +28,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -40,22 +44,40 @@ type GinContextKey string
 const (
 	// ClerkUserIDKey is the key used to store the authenticated user's Clerk ID in the Gin context.
 	ClerkUserIDKey GinContextKey = "clerkUserID"
+	// ClerkRoleKey is the key used to store the authenticated user's role in the Gin context.
+	ClerkRoleKey GinContextKey = "clerkRole"
+
+	// RoleAdmin is the role allowed to run admin operations.
+	RoleAdmin = "admin"
 )
 
+// Verifier validates Clerk JWTs. It is shared by the HTTP middleware and the WebSocket
+// upgrade, which accepts an optional token to identify the client.
+type Verifier struct {
+	jwks   *keyfunc.JWKS
+	issuer string
+}
+
+// Identity is the authenticated user behind a valid token.
+type Identity struct {
+	ClerkUserID string
+	Role        string // "" if the token carries no role
+}
+
 /**
  * @description
- * NewAuthMiddleware creates a Gin middleware that validates Clerk JWTs.
+ * NewVerifier creates a Verifier for the tokens of a Clerk instance.
  *
  * @param clerkIssuerURL The URL of the Clerk instance (e.g., "https://clerk.your-domain.com").
  *        This is used to construct the JWKS URL.
- * @returns A gin.HandlerFunc that can be used as middleware.
+ * @returns A pointer to a new Verifier.
  * @returns An error if the JWKS key set cannot be initialized.
  *
  * @notes
  * - The function initializes a JWKS client which automatically handles fetching, caching,
  *   and refreshing Clerk's public keys.
  */
-func NewAuthMiddleware(clerkIssuerURL string) (gin.HandlerFunc, error) {
+func NewVerifier(clerkIssuerURL string) (*Verifier, error) {
 	// Construct the JWKS URL from the issuer URL provided by Clerk.
 	// This follows the OpenID Connect discovery standard.
 	jwksURL := strings.TrimSuffix(clerkIssuerURL, "/") + "/.well-known/jwks.json"
@@ -71,7 +93,59 @@ func NewAuthMiddleware(clerkIssuerURL string) (gin.HandlerFunc, error) {
 		return nil, err
 	}
 
-	// Return the middleware handler function.
+	return &Verifier{jwks: jwks, issuer: strings.TrimSuffix(clerkIssuerURL, "/")}, nil
+}
+
+/**
+ * @description
+ * Verify parses and validates a token and returns the identity it carries.
+ *
+ * @param tokenString The raw JWT.
+ * @returns The identity, or an error whose message can be returned to the client.
+ */
+func (v *Verifier) Verify(tokenString string) (Identity, error) {
+	// 1. Parse and validate the token.
+	// The keyfunc from the JWKS client is used to find the correct public key
+	// based on the 'kid' (Key ID) in the JWT header.
+	token, err := jwt.Parse(tokenString, v.jwks.Keyfunc)
+	if err != nil {
+		return Identity{}, errors.New("Invalid token: " + err.Error())
+	}
+
+	// 2. Check if the token is valid (signature and expiration verified).
+	if !token.Valid {
+		return Identity{}, errors.New("Token is invalid")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Identity{}, errors.New("Failed to parse token claims")
+	}
+
+	// 3. Verify the issuer claim matches our expected Clerk issuer URL.
+	// This ensures the token was issued by the correct Clerk instance.
+	issuer, ok := claims["iss"].(string)
+	if !ok || issuer != v.issuer {
+		return Identity{}, errors.New("Token issuer does not match expected issuer")
+	}
+
+	// 4. Extract the subject ('sub' claim), which is the Clerk User ID.
+	clerkUserID, ok := claims["sub"].(string)
+	if !ok || clerkUserID == "" {
+		return Identity{}, errors.New("Subject (sub) claim is missing or invalid in token")
+	}
+
+	return Identity{ClerkUserID: clerkUserID, Role: roleFromClaims(claims)}, nil
+}
+
+/**
+ * @description
+ * Middleware creates a Gin middleware that requires a valid Clerk JWT in the
+ * Authorization header.
+ *
+ * @returns A gin.HandlerFunc that can be used as middleware.
+ */
+func (v *Verifier) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Get the token from the Authorization header.
 		authHeader := c.GetHeader("Authorization")
@@ -86,50 +160,62 @@ func NewAuthMiddleware(clerkIssuerURL string) (gin.HandlerFunc, error) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Authorization header format must be Bearer {token}"})
 			return
 		}
-		tokenString := parts[1]
 
-		// 3. Parse and validate the token.
-		// The keyfunc from the JWKS client is used to find the correct public key
-		// based on the 'kid' (Key ID) in the JWT header.
-		token, err := jwt.Parse(tokenString, jwks.Keyfunc)
+		// 3. Validate the token.
+		identity, err := v.Verify(parts[1])
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Invalid token: " + err.Error()})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
 
-		// 4. Check if the token is valid (signature and expiration verified).
-		if !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Token is invalid"})
-			return
+		// 4. Set the Clerk User ID (and role, if any) in the Gin context for downstream handlers.
+		c.Set(string(ClerkUserIDKey), identity.ClerkUserID)
+		if identity.Role != "" {
+			c.Set(string(ClerkRoleKey), identity.Role)
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Failed to parse token claims"})
-			return
-		}
+		// 5. Proceed to the next handler in the chain.
+		c.Next()
+	}
+}
 
-		// 4. Verify the issuer claim matches our expected Clerk issuer URL.
-		// This ensures the token was issued by the correct Clerk instance.
-		expectedIssuer := strings.TrimSuffix(clerkIssuerURL, "/")
-		issuer, ok := claims["iss"].(string)
-		if !ok || issuer != expectedIssuer {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Token issuer does not match expected issuer"})
-			return
+/**
+ * @description
+ * roleFromClaims extracts the user's role from the token claims. Clerk session tokens carry
+ * it once customized to include the user's public metadata, either as a top-level `role`
+ * claim or as `role` inside a `metadata` or `public_metadata` claim.
+ *
+ * @param claims The validated token claims.
+ * @returns The role, or "" if the token carries none.
+ */
+func roleFromClaims(claims jwt.MapClaims) string {
+	if role, ok := claims["role"].(string); ok {
+		return role
+	}
+	for _, key := range []string{"metadata", "public_metadata"} {
+		if metadata, ok := claims[key].(map[string]interface{}); ok {
+			if role, ok := metadata["role"].(string); ok {
+				return role
+			}
 		}
+	}
+	return ""
+}
 
-		// 5. Extract the subject ('sub' claim), which is the Clerk User ID.
-		clerkUserID, ok := claims["sub"].(string)
-		if !ok || clerkUserID == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Subject (sub) claim is missing or invalid in token"})
+/**
+ * @description
+ * RequireRole creates a Gin middleware that only lets through users with the given role.
+ * It must run after the authentication middleware.
+ *
+ * @param role The required role (e.g. RoleAdmin).
+ * @returns A gin.HandlerFunc that responds 403 Forbidden to users without the role.
+ */
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(string(ClerkRoleKey)) != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "error", "message": "Insufficient role"})
 			return
 		}
-
-		// 6. Set the Clerk User ID in the Gin context for downstream handlers.
-		c.Set(string(ClerkUserIDKey), clerkUserID)
-
-		// 7. Proceed to the next handler in the chain.
 		c.Next()
-	}, nil
+	}
 }
-
@@ -0,0 +1,186 @@
+/**
+ * @description
+ * This package is the single source of truth for the Redis keys that backend caches write to
+ * the shared Redis instance: the key prefix and TTL of each cache purpose, whether the cache
+ * may currently be written, and how much memory each prefix uses.
+ *
+ * Key features:
+ * - Purposes: One `Purpose` per cache (e.g. `AnalyticsStats`, `TradingParams`), giving it a
+ *   fixed prefix and default TTL. Keys must be built with `Purpose.Key`.
+ * - Disable Flags: Caches can be disabled by configuration (CACHE_DISABLED) to relieve memory
+ *   pressure. Writers check `Purpose.Enabled` at write time; reads of existing keys still work
+ *   until they expire.
+ * - Memory Accounting: `MeasureUsage` estimates the memory used per prefix by SCANning the
+ *   prefix and sampling `MEMORY USAGE` on a subset of the keys.
+ *
+ * @notes
+ * - Purposes that the backend needs for correctness rather than speed (e.g. the OHLCV dedupe
+ *   ledger) are accounted for but cannot be disabled.
+ * - Disable must be called before the services are started.
+ */
+
+package cachekeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Purpose describes the keys written for one cache purpose.
+type Purpose struct {
+	Name        string        // Name used in configuration and reports
+	Prefix      string        // Prefix of every key of this purpose
+	TTL         time.Duration // Default TTL of the keys
+	Disableable bool          // Whether the purpose may be disabled by configuration
+}
+
+var (
+	// AnalyticsStats caches computed market statistics per (market, window).
+	AnalyticsStats = Purpose{Name: "analytics_stats", Prefix: "analytics:stats:", TTL: 5 * time.Minute, Disableable: true}
+	// TradingParams caches the tick size of tokens, used to validate order prices.
+	TradingParams = Purpose{Name: "trading_params", Prefix: "trading_params:", TTL: 10 * time.Minute, Disableable: true}
+	// LastPrice holds the latest mid-price per market, read before the stored bars.
+	LastPrice = Purpose{Name: "last_price", Prefix: "price:", TTL: 30 * time.Second, Disableable: true}
+	// MarketSnapshot holds the latest order book published per market, sent to new subscribers.
+	MarketSnapshot = Purpose{Name: "market_snapshot", Prefix: "market:snapshot:", TTL: 24 * time.Hour, Disableable: true}
+	// OHLCVLedger records stream messages already aggregated, to deduplicate ingesters.
+	OHLCVLedger = Purpose{Name: "ohlcv_ledger", Prefix: "ohlcv:ledger:", TTL: 2 * time.Minute}
+	// OHLCVCheckpoint holds the aggregator's in-progress bars, restored after a restart. Its
+	// TTL, refreshed on every checkpoint, outlasts a weekly bar.
+	OHLCVCheckpoint = Purpose{Name: "ohlcv_checkpoint", Prefix: "ohlcv:checkpoint:", TTL: 8 * 24 * time.Hour}
+	// OrderIdempotency maps the idempotency keys of placed orders to the orders, per user. Its
+	// TTL is the default window, replaced by ORDER_IDEMPOTENCY_TTL_MS.
+	OrderIdempotency = Purpose{Name: "order_idempotency", Prefix: "order:idempotency:", TTL: 24 * time.Hour}
+)
+
+// purposes lists every purpose, in report order.
+var purposes = []Purpose{AnalyticsStats, TradingParams, LastPrice, MarketSnapshot, OHLCVLedger, OHLCVCheckpoint, OrderIdempotency}
+
+// disabled holds the names of the disabled purposes.
+var disabled = map[string]bool{}
+
+// All returns every purpose.
+func All() []Purpose {
+	return append([]Purpose(nil), purposes...)
+}
+
+// Key builds a key of this purpose from its parts, joined by ":".
+func (p Purpose) Key(parts ...string) string {
+	return p.Prefix + strings.Join(parts, ":")
+}
+
+// Enabled reports whether keys of this purpose may be written.
+func (p Purpose) Enabled() bool {
+	return !disabled[p.Name]
+}
+
+/**
+ * @description
+ * Disable disables the named purposes, replacing any previous configuration.
+ *
+ * @param names The purpose names to disable.
+ * @returns An error naming the first unknown purpose or one that cannot be disabled.
+ */
+func Disable(names []string) error {
+	next := make(map[string]bool, len(names))
+	for _, name := range names {
+		purpose, ok := lookup(name)
+		if !ok {
+			return fmt.Errorf("unknown cache %q", name)
+		}
+		if !purpose.Disableable {
+			return fmt.Errorf("cache %q cannot be disabled", name)
+		}
+		next[name] = true
+	}
+	disabled = next
+	return nil
+}
+
+// lookup returns the purpose with the given name.
+func lookup(name string) (Purpose, bool) {
+	for _, purpose := range purposes {
+		if purpose.Name == name {
+			return purpose, true
+		}
+	}
+	return Purpose{}, false
+}
+
+// PrefixUsage is the estimated memory used by the keys of one purpose.
+type PrefixUsage struct {
+	Name           string `json:"name"`
+	Prefix         string `json:"prefix"`
+	TTL            string `json:"ttl"`
+	Enabled        bool   `json:"enabled"`
+	Keys           int    `json:"keys"`      // Keys found by the scan
+	Truncated      bool   `json:"truncated"` // The scan stopped at the key limit, so Keys is a lower bound
+	SampledKeys    int    `json:"sampled_keys"`
+	SampledBytes   int64  `json:"sampled_bytes"`
+	EstimatedBytes int64  `json:"estimated_bytes"`
+}
+
+/**
+ * @description
+ * MeasureUsage estimates the memory used by the keys of a purpose. The prefix is scanned for
+ * at most maxKeys keys, MEMORY USAGE is read for the first sampleSize of them, and the sampled
+ * average is extrapolated to every key found.
+ *
+ * @param ctx The context for the Redis calls.
+ * @param redisClient The Redis client.
+ * @param purpose The purpose to measure.
+ * @param sampleSize The number of keys whose memory usage is read.
+ * @param maxKeys The maximum number of keys scanned.
+ * @returns The usage estimate, or an error if a Redis call failed.
+ */
+func MeasureUsage(ctx context.Context, redisClient *redis.Client, purpose Purpose, sampleSize int, maxKeys int) (PrefixUsage, error) {
+	usage := PrefixUsage{
+		Name:    purpose.Name,
+		Prefix:  purpose.Prefix,
+		TTL:     purpose.TTL.String(),
+		Enabled: purpose.Enabled(),
+	}
+
+	iter := redisClient.Scan(ctx, 0, purpose.Prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		if usage.Keys >= maxKeys {
+			usage.Truncated = true
+			break
+		}
+		usage.Keys++
+		if usage.SampledKeys >= sampleSize {
+			continue
+		}
+		bytes, err := redisClient.MemoryUsage(ctx, iter.Val()).Result()
+		if errors.Is(err, redis.Nil) {
+			continue // Expired since it was scanned
+		}
+		if err != nil {
+			return usage, fmt.Errorf("failed to read memory usage: %w", err)
+		}
+		usage.SampledKeys++
+		usage.SampledBytes += bytes
+	}
+	if err := iter.Err(); err != nil {
+		return usage, fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	usage.EstimatedBytes = estimateBytes(usage.SampledBytes, usage.SampledKeys, usage.Keys)
+	return usage, nil
+}
+
+// estimateBytes extrapolates the memory of sampled keys to the total number of keys.
+func estimateBytes(sampledBytes int64, sampledKeys int, totalKeys int) int64 {
+	if sampledKeys == 0 {
+		return 0
+	}
+	if sampledKeys >= totalKeys {
+		return sampledBytes
+	}
+	return sampledBytes * int64(totalKeys) / int64(sampledKeys)
+}
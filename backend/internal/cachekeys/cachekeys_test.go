@@ -0,0 +1,291 @@
+package cachekeys
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestKey(t *testing.T) {
+	if got := AnalyticsStats.Key("0xmarket", "24h"); got != "analytics:stats:0xmarket:24h" {
+		t.Errorf("key = %q", got)
+	}
+	if got := TradingParams.Key("12345"); got != "trading_params:12345" {
+		t.Errorf("key = %q", got)
+	}
+}
+
+func TestPurposePrefixesDoNotOverlap(t *testing.T) {
+	for _, a := range purposes {
+		for _, b := range purposes {
+			if a.Name != b.Name && strings.HasPrefix(a.Prefix, b.Prefix) {
+				t.Errorf("prefix %q of %s starts with prefix %q of %s, so usage would be counted twice", a.Prefix, a.Name, b.Prefix, b.Name)
+			}
+		}
+	}
+}
+
+func TestDisable(t *testing.T) {
+	t.Cleanup(func() { Disable(nil) })
+	for _, purpose := range purposes {
+		if !purpose.Enabled() {
+			t.Fatalf("%s disabled by default", purpose.Name)
+		}
+	}
+
+	if err := Disable([]string{"analytics_stats", "market_snapshot"}); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if AnalyticsStats.Enabled() || MarketSnapshot.Enabled() || !TradingParams.Enabled() {
+		t.Errorf("enabled: analytics_stats %v, market_snapshot %v, trading_params %v; want false, false, true",
+			AnalyticsStats.Enabled(), MarketSnapshot.Enabled(), TradingParams.Enabled())
+	}
+
+	// An invalid configuration is rejected whole and leaves the previous one in place.
+	for _, names := range [][]string{{"trading_params", "ohlcv_ledger"}, {"trading_params", "snapshots"}} {
+		err := Disable(names)
+		if err == nil || !strings.Contains(err.Error(), names[1]) {
+			t.Errorf("Disable(%v) error = %v, want one naming %s", names, err, names[1])
+		}
+		if !TradingParams.Enabled() || AnalyticsStats.Enabled() {
+			t.Errorf("Disable(%v) changed the configuration", names)
+		}
+	}
+
+	// A new configuration replaces the previous one.
+	if err := Disable([]string{"trading_params"}); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if !AnalyticsStats.Enabled() || TradingParams.Enabled() {
+		t.Error("the previous configuration was merged rather than replaced")
+	}
+	if err := Disable(nil); err != nil || !TradingParams.Enabled() {
+		t.Errorf("Disable(nil) = %v, trading_params enabled %v; want every cache enabled", err, TradingParams.Enabled())
+	}
+}
+
+func TestEstimateBytes(t *testing.T) {
+	tests := []struct {
+		name         string
+		sampledBytes int64
+		sampledKeys  int
+		totalKeys    int
+		want         int64
+	}{
+		{"no keys", 0, 0, 0, 0},
+		// Every sampled key expired before its usage was read.
+		{"nothing sampled", 0, 0, 40, 0},
+		{"every key sampled", 1234, 10, 10, 1234},
+		{"sample extrapolated", 300, 2, 10, 1500},
+		{"uneven average", 1000, 3, 10, 3333},
+		{"large prefix", 64 * 1000, 1000, 2_000_000, 128_000_000},
+	}
+	for _, tt := range tests {
+		if got := estimateBytes(tt.sampledBytes, tt.sampledKeys, tt.totalKeys); got != tt.want {
+			t.Errorf("%s: estimateBytes(%d, %d, %d) = %d, want %d", tt.name, tt.sampledBytes, tt.sampledKeys, tt.totalKeys, got, tt.want)
+		}
+	}
+}
+
+// usageRedis is a Redis server that answers SCAN, in pages of pageSize keys, and MEMORY
+// USAGE for a fixed set of keys, and rejects every other command.
+type usageRedis struct {
+	listener   net.Listener
+	keys       []string         // Sorted, in scan order
+	usage      map[string]int64 // Memory usage by key; scanned keys missing here have expired
+	pageSize   int
+	failMemory atomic.Bool // Reject MEMORY USAGE
+}
+
+func newUsageRedis(t *testing.T, usage map[string]int64, scannedOnly ...string) *usageRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &usageRedis{listener: listener, usage: usage, pageSize: 3}
+	for key := range usage {
+		server.keys = append(server.keys, key)
+	}
+	server.keys = append(server.keys, scannedOnly...)
+	sort.Strings(server.keys)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+// client returns a client of the server, closed when the test ends.
+func (s *usageRedis) client(t *testing.T) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: s.listener.Addr().String(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (s *usageRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "SCAN":
+			s.scan(conn, args)
+		case "MEMORY":
+			bytes, ok := s.usage[args[2]]
+			switch {
+			case s.failMemory.Load():
+				io.WriteString(conn, "-ERR MEMORY is disabled\r\n")
+			case !ok:
+				io.WriteString(conn, "$-1\r\n")
+			default:
+				fmt.Fprintf(conn, ":%d\r\n", bytes)
+			}
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+// scan answers SCAN cursor MATCH prefix* COUNT n, where the cursor is an index into keys.
+func (s *usageRedis) scan(conn net.Conn, args []string) {
+	cursor, _ := strconv.Atoi(args[1])
+	prefix := strings.TrimSuffix(args[3], "*")
+	end := min(cursor+s.pageSize, len(s.keys))
+	var page []string
+	for _, key := range s.keys[cursor:end] {
+		if strings.HasPrefix(key, prefix) {
+			page = append(page, key)
+		}
+	}
+	next := strconv.Itoa(end)
+	if end == len(s.keys) {
+		next = "0"
+	}
+	fmt.Fprintf(conn, "*2\r\n$%d\r\n%s\r\n*%d\r\n", len(next), next, len(page))
+	for _, key := range page {
+		fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(key), key)
+	}
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(reader, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+// TestMeasureUsage measures a prefix of ten keys using 100 to 1000 bytes among keys of other
+// purposes, and checks the sampled and extrapolated usage.
+func TestMeasureUsage(t *testing.T) {
+	usage := map[string]int64{
+		TradingParams.Key("a"):             5000,
+		MarketSnapshot.Key("0xa"):          9000,
+		OrderIdempotency.Key("user", "k1"): 7000,
+	}
+	for i := 0; i < 10; i++ {
+		usage[AnalyticsStats.Key(fmt.Sprintf("0x%d", i), "24h")] = int64(100 * (i + 1))
+	}
+	server := newUsageRedis(t, usage)
+	client := server.client(t)
+
+	tests := []struct {
+		name       string
+		sampleSize int
+		maxKeys    int
+		want       PrefixUsage
+	}{
+		{"every key sampled", 100, 100, PrefixUsage{Keys: 10, SampledKeys: 10, SampledBytes: 5500, EstimatedBytes: 5500}},
+		// The first two keys in scan order use 100 and 200 bytes, 150 on average.
+		{"sampled", 2, 100, PrefixUsage{Keys: 10, SampledKeys: 2, SampledBytes: 300, EstimatedBytes: 1500}},
+		{"truncated", 2, 4, PrefixUsage{Keys: 4, Truncated: true, SampledKeys: 2, SampledBytes: 300, EstimatedBytes: 600}},
+		{"nothing sampled", 0, 100, PrefixUsage{Keys: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MeasureUsage(context.Background(), client, AnalyticsStats, tt.sampleSize, tt.maxKeys)
+			if err != nil {
+				t.Fatalf("MeasureUsage: %v", err)
+			}
+			want := tt.want
+			want.Name, want.Prefix, want.TTL, want.Enabled = "analytics_stats", "analytics:stats:", "5m0s", true
+			if got != want {
+				t.Errorf("usage = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestMeasureUsageExpiredKeys checks that keys expiring between the scan and MEMORY USAGE are
+// counted but not sampled, so they do not lower the average.
+func TestMeasureUsageExpiredKeys(t *testing.T) {
+	server := newUsageRedis(t, map[string]int64{
+		LastPrice.Key("0xb"): 80,
+		LastPrice.Key("0xd"): 120,
+	}, LastPrice.Key("0xa"), LastPrice.Key("0xc"))
+
+	got, err := MeasureUsage(context.Background(), server.client(t), LastPrice, 10, 100)
+	if err != nil {
+		t.Fatalf("MeasureUsage: %v", err)
+	}
+	if got.Keys != 4 || got.SampledKeys != 2 || got.EstimatedBytes != 400 {
+		t.Errorf("keys = %d, sampled = %d, estimated = %d; want 4, 2 and 400", got.Keys, got.SampledKeys, got.EstimatedBytes)
+	}
+}
+
+func TestMeasureUsageErrors(t *testing.T) {
+	server := newUsageRedis(t, map[string]int64{TradingParams.Key("a"): 100})
+	server.failMemory.Store(true)
+	if _, err := MeasureUsage(context.Background(), server.client(t), TradingParams, 10, 100); err == nil || !strings.Contains(err.Error(), "memory usage") {
+		t.Errorf("MeasureUsage error = %v, want a memory usage error", err)
+	}
+
+	// A disabled cache is still measured, and reported as disabled.
+	t.Cleanup(func() { Disable(nil) })
+	Disable([]string{"trading_params"})
+	server.failMemory.Store(false)
+	got, err := MeasureUsage(context.Background(), server.client(t), TradingParams, 10, 100)
+	if err != nil || got.Enabled || got.EstimatedBytes != 100 {
+		t.Errorf("MeasureUsage of a disabled cache = %+v, %v", got, err)
+	}
+
+	server.listener.Close()
+	unreachable := redis.NewClient(&redis.Options{Addr: server.listener.Addr().String(), MaxRetries: -1})
+	t.Cleanup(func() { unreachable.Close() })
+	if _, err := MeasureUsage(context.Background(), unreachable, TradingParams, 10, 100); err == nil || !strings.Contains(err.Error(), "scan") {
+		t.Errorf("MeasureUsage error = %v, want a scan error", err)
+	}
+}
@@ -0,0 +1,143 @@
+/**
+ * @description
+ * This package is the single source of truth for the names of the Redis Pub/Sub channels
+ * used to fan real-time data out between backend services and the WebSocket hub.
+ *
+ * Channel names were previously built by string concatenation in each publisher and
+ * subscriber, where a typo in a prefix silently broke fan-out. All channel names must now
+ * be built and parsed through this package.
+ *
+ * Key features:
+ * - Typed Constructors: One constructor per channel family (e.g. `MarketChannel`, `OrdersChannel`).
+ * - ID Validation: `IsConditionID` checks the shape of condition IDs.
+ * - Pattern Helpers: `Pattern` and `Parse` support PSUBSCRIBE-style listeners that need to
+ *   recover the kind and ID from a received channel name.
+ *
+ * @notes
+ * - Constructors do not reject malformed IDs, because some publishers (e.g. the market stream
+ *   when an asset→condition mapping is missing) intentionally fall back to other ID shapes.
+ *   Callers that require a condition ID should check it with `IsConditionID`.
+ */
+
+package channels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind identifies a family of Redis channels.
+type Kind string
+
+const (
+	// KindMarket carries order book updates for a market, keyed by condition ID.
+	KindMarket Kind = "market"
+	// KindOHLCV carries completed OHLCV bars of a single resolution for a market, keyed by
+	// condition ID and resolution, e.g. "ohlcv:<condition_id>:<resolution>".
+	KindOHLCV Kind = "ohlcv"
+	// KindUser carries CLOB user channel events, keyed by the owner of a CLOB API key, e.g.
+	// "user:<owner>:orders" and "user:<owner>:trades".
+	KindUser Kind = "user"
+	// KindOrders carries order_update events for a user, keyed by internal user UUID.
+	KindOrders Kind = "orders"
+)
+
+// separator separates the kind from the ID in a channel name.
+const separator = ":"
+
+// conditionIDPattern matches a Polymarket condition ID (0x-prefixed 32-byte hex).
+var conditionIDPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// knownKinds lists the kinds that Parse accepts.
+var knownKinds = map[Kind]bool{
+	KindMarket: true,
+	KindOHLCV:  true,
+	KindUser:   true,
+	KindOrders: true,
+}
+
+// MarketChannel returns the channel carrying order book updates for a market.
+func MarketChannel(conditionID string) string {
+	return build(KindMarket, conditionID)
+}
+
+// OHLCVChannel returns the channel carrying completed OHLCV bars of one resolution for a
+// market, e.g. "ohlcv:<condition_id>:<resolution>".
+func OHLCVChannel(conditionID, resolution string) string {
+	return build(KindOHLCV, conditionID+separator+resolution)
+}
+
+// OrdersChannel returns the channel carrying order_update events for a user.
+func OrdersChannel(userID string) string {
+	return build(KindOrders, userID)
+}
+
+// UserOrdersChannel returns the channel carrying CLOB user channel order events for the
+// owner of a CLOB API key, e.g. "user:<owner>:orders".
+func UserOrdersChannel(owner string) string {
+	return build(KindUser, owner+separator+"orders")
+}
+
+// UserTradesChannel returns the channel carrying CLOB user channel trade events for the
+// owner of a CLOB API key, e.g. "user:<owner>:trades".
+func UserTradesChannel(owner string) string {
+	return build(KindUser, owner+separator+"trades")
+}
+
+// Pattern returns the PSUBSCRIBE pattern matching every channel of the given kind.
+func Pattern(kind Kind) string {
+	return string(kind) + separator + "*"
+}
+
+/**
+ * @description
+ * Parse splits a channel name into its kind and ID. It is intended for pattern
+ * subscriptions, where the received channel name identifies the market or user.
+ *
+ * @param channel The full channel name, e.g. "market:0xabc...".
+ * @returns The channel kind and the ID portion of the name.
+ */
+func Parse(channel string) (Kind, string, error) {
+	prefix, id, found := strings.Cut(channel, separator)
+	if !found || id == "" {
+		return "", "", fmt.Errorf("malformed channel name %q", channel)
+	}
+	kind := Kind(prefix)
+	if !knownKinds[kind] {
+		return "", "", fmt.Errorf("unknown channel kind %q in %q", prefix, channel)
+	}
+	return kind, id, nil
+}
+
+// ParseMarketChannel returns the market ID of a market channel name.
+func ParseMarketChannel(channel string) (string, bool) {
+	kind, id, err := Parse(channel)
+	if err != nil || kind != KindMarket {
+		return "", false
+	}
+	return id, true
+}
+
+// ParseOHLCVChannel returns the market ID and resolution of an OHLCV channel name.
+func ParseOHLCVChannel(channel string) (string, string, bool) {
+	kind, id, err := Parse(channel)
+	if err != nil || kind != KindOHLCV {
+		return "", "", false
+	}
+	marketID, resolution, found := strings.Cut(id, separator)
+	if !found || marketID == "" || resolution == "" {
+		return "", "", false
+	}
+	return marketID, resolution, true
+}
+
+// IsConditionID reports whether id has the shape of a Polymarket condition ID.
+func IsConditionID(id string) bool {
+	return conditionIDPattern.MatchString(id)
+}
+
+// build joins a kind and an ID into a channel name.
+func build(kind Kind, id string) string {
+	return string(kind) + separator + id
+}
@@ -0,0 +1,129 @@
+package channels
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testConditionID = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		kind    Kind
+		id      string
+	}{
+		{"market", MarketChannel(testConditionID), KindMarket, testConditionID},
+		{"ohlcv", OHLCVChannel(testConditionID, "5"), KindOHLCV, testConditionID + ":5"},
+		{"orders", OrdersChannel("6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e6f"), KindOrders, "6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e6f"},
+		{"user orders", UserOrdersChannel("owner-key"), KindUser, "owner-key:orders"},
+		{"user trades", UserTradesChannel("owner-key"), KindUser, "owner-key:trades"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, id, err := Parse(tt.channel)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.channel, err)
+			}
+			if kind != tt.kind || id != tt.id {
+				t.Errorf("Parse(%q) = %s, %q, want %s, %q", tt.channel, kind, id, tt.kind, tt.id)
+			}
+			if matched, _ := path.Match(Pattern(tt.kind), tt.channel); !matched {
+				t.Errorf("Pattern(%s) = %q does not match %q", tt.kind, Pattern(tt.kind), tt.channel)
+			}
+		})
+	}
+
+	if id, ok := ParseMarketChannel(MarketChannel(testConditionID)); !ok || id != testConditionID {
+		t.Errorf("ParseMarketChannel = %q, %v, want %q", id, ok, testConditionID)
+	}
+	if id, resolution, ok := ParseOHLCVChannel(OHLCVChannel(testConditionID, "1D")); !ok || id != testConditionID || resolution != "1D" {
+		t.Errorf("ParseOHLCVChannel = %q, %q, %v, want %q, 1D", id, resolution, ok, testConditionID)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, channel := range []string{"", "market", "market:", "nosuchkind:id", ":id"} {
+		if kind, id, err := Parse(channel); err == nil {
+			t.Errorf("Parse(%q) = %s, %q, want an error", channel, kind, id)
+		}
+	}
+	if _, ok := ParseMarketChannel(OHLCVChannel(testConditionID, "5")); ok {
+		t.Error("ParseMarketChannel accepted an OHLCV channel")
+	}
+	for _, channel := range []string{MarketChannel(testConditionID), "ohlcv:" + testConditionID, "ohlcv::5", "ohlcv:" + testConditionID + ":"} {
+		if _, _, ok := ParseOHLCVChannel(channel); ok {
+			t.Errorf("ParseOHLCVChannel accepted %q", channel)
+		}
+	}
+}
+
+func TestIsConditionID(t *testing.T) {
+	for id, want := range map[string]bool{
+		testConditionID:                      true,
+		strings.ToUpper(testConditionID[2:]): false,
+		testConditionID[:65]:                 false,
+		testConditionID + "0":                false,
+		"will-it-rain-tomorrow":              false,
+	} {
+		if got := IsConditionID(id); got != want {
+			t.Errorf("IsConditionID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+// TestNoRawChannelNames fails on string literals outside this package that start like a
+// channel name, e.g. "market:" + id, so that channel names are only built here. Cache keys
+// share some of the prefixes and are built by the cachekeys package instead.
+func TestNoRawChannelNames(t *testing.T) {
+	allowed := map[string]bool{"internal/channels": true, "internal/cachekeys": true}
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, file)
+		if entry.IsDir() {
+			if allowed[filepath.ToSlash(rel)] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(file, ".go") {
+			return nil
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(parsed, func(node ast.Node) bool {
+			literal, ok := node.(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+			value, err := strconv.Unquote(literal.Value)
+			if err != nil {
+				return true
+			}
+			for kind := range knownKinds {
+				if strings.HasPrefix(value, string(kind)+separator) {
+					t.Errorf("%s: raw channel name %s; build it with the channels package", fset.Position(literal.Pos()), literal.Value)
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", root, err)
+	}
+}
@@ -17,19 +17,58 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Default Polymarket endpoints, used when the corresponding variables are not set.
+const (
+	DefaultGammaAPIURL = "https://gamma-api.polymarket.com"
+	DefaultCLOBAPIURL  = "https://clob.polymarket.com"
+	DefaultCLOBWSURL   = "wss://ws-subscriptions-clob.polymarket.com"
+)
+
+// defaultOHLCVIdleMarketTimeout is how long a market may go without updates before the
+// aggregator evicts it, when OHLCV_IDLE_MARKET_TIMEOUT_MS is not set.
+const defaultOHLCVIdleMarketTimeout = 2 * time.Hour
+
 // Config holds all configuration for the application.
 // Values are read from environment variables or a .env file.
 type Config struct {
 	Port                string
+	InternalPort        string // Port for the localhost-only internal listener (debug endpoints); disabled if empty
 	DatabaseURL         string
 	ClerkSecretKey      string
 	ClerkIssuerURL      string
 	RemoteSignerAddress string // Added for gRPC client
 	RedisURL            string // Added for Redis connection
+	// Clerk webhook verification; events from other Clerk instances are rejected when
+	// ClerkWebhookInstanceIDs or ClerkWebhookUserIDPrefix is set
+	ClerkWebhookSecrets      []string // Accepted signing secrets (comma-separated CLERK_WEBHOOK_SECRETS, for rotation)
+	ClerkWebhookInstanceIDs  []string // Allowed Clerk instance IDs, matched against the event's instance_id
+	ClerkWebhookUserIDPrefix string   // Required prefix of the user IDs in events
+	// SignerSimulationAllowed accepts simulated signatures from a remote signer running in
+	// simulate mode (load testing in staging only); they are rejected otherwise
+	SignerSimulationAllowed bool
+	// Redis retry configuration; zero values keep the go-redis defaults
+	RedisMaxRetries      int           // Retries per command; -1 disables retries
+	RedisMinRetryBackoff time.Duration // Minimum backoff between retries
+	RedisMaxRetryBackoff time.Duration // Maximum backoff between retries
+	RedisDialTimeout     time.Duration // Timeout for establishing new connections
+	// CacheDisabled names the Redis caches that must not be written (see the cachekeys package)
+	CacheDisabled []string
+	// LogBodyMaxBytes is the length request bodies and upstream payloads are truncated to when
+	// logged (see the logsafe package); zero uses the default
+	LogBodyMaxBytes int
+	// PublishBufferSize is the number of failed Redis publishes kept per channel for a retry;
+	// zero uses the default
+	PublishBufferSize int
+	// PublishBufferBookMaxAge is how long failed order book publishes stay worth retrying;
+	// zero uses the default
+	PublishBufferBookMaxAge time.Duration
 	// Polymarket API configuration
 	GammaAPIURL         string // Gamma API base URL (defaults to https://gamma-api.polymarket.com)
 	CLOBAPIURL          string // CLOB API base URL (defaults to https://clob.polymarket.com)
@@ -37,6 +76,56 @@ type Config struct {
 	CLOBAPIKey          string // CLOB API key (required for trading operations)
 	CLOBAPISecret       string // CLOB API secret (required for trading operations)
 	CLOBAPIPassphrase   string // CLOB API passphrase (required for trading operations)
+	MockFallbackEnabled bool   // Stream mock market data when the CLOB WebSocket is unavailable
+	// Market stream subscription configuration
+	FeaturedMarkets  []string      // Condition IDs always streamed and backfilled, regardless of Gamma's ordering
+	MaxStreamAssets  int           // Max assets subscribed on the CLOB WebSocket; 0 means unlimited
+	StreamCatalogTTL time.Duration // Age after which a reconnect re-fetches the markets from Gamma; zero uses the default
+	StreamShardSize  int           // Max assets subscribed per CLOB WebSocket connection; zero uses the default
+	// WebSocket configuration
+	WSAllowedMarkets     []string // Condition IDs clients may subscribe to; empty allows all markets
+	WSCompressionEnabled bool     // Negotiate permessage-deflate with clients that support it
+	WSCompressionLevel   int      // flate compression level (1-9); 0 uses the library default
+	WSMaxRedisListeners  int      // Max per-market Redis listeners of the hub, beyond which markets share pattern listeners; 0 means unlimited
+	// WebSocket client plans, keyed by plan name ("anonymous", "authenticated", "api_key");
+	// plans without an entry are unlimited
+	WSAPIKeys          []string                 // API keys identifying clients on the api_key plan
+	WSMaxSubscriptions map[string]int           // Max markets a client may be subscribed to at once
+	WSMinThrottle      map[string]time.Duration // Floor on the per-subscription throttle interval
+	// OHLCV aggregation configuration
+	OHLCVResolutions   []string      // Bar resolutions produced and served; empty uses the defaults
+	OHLCVMaxMarkets    int           // Max markets held in memory by the aggregator; 0 means unlimited
+	OHLCVMinMidPrice   float64       // Mid-prices below this are not aggregated; 0 disables the check
+	OHLCVMaxMidPrice   float64       // Mid-prices above this are not aggregated; 0 disables the check
+	OHLCVMaxSpread     float64       // Books with a wider bid/ask spread are not aggregated; 0 disables the check
+	OHLCVDedupeEnabled bool          // Skip book messages already aggregated by another ingester
+	OHLCVDedupeTTL     time.Duration // How long processed messages are remembered by the dedupe ledger
+	OHLCVStallAfter    time.Duration // How long bars may stall while markets are active before readiness degrades
+	// Idle market eviction from the aggregator's memory
+	OHLCVIdleMarketTimeout time.Duration // Markets without updates for this long are evicted (2h when unset); 0 keeps them
+	// Per-market bar watchdog
+	OHLCVBarWatchdogWindow  time.Duration // How recent the last 1m bar of a streamed market must be; zero uses the default
+	OHLCVBarWatchdogReflush bool          // Flush the in-memory bars of markets found missing bars again
+	// Bar persistence latency; zero values use the aggregator's defaults
+	OHLCVPersistLagThreshold time.Duration // p95 of bar end to database write above which a flush cycle is lagging
+	OHLCVPersistLagCycles    int           // Consecutive lagging flush cycles before persistence is reported degraded
+	// Flat bars for periods without updates; disabled unless OHLCVFillGaps is set
+	OHLCVFillGaps           bool // Save flat bars at the previous close for periods without updates
+	OHLCVFillGapsMaxPeriods int  // Longest gap filled, in periods; zero uses the aggregator's default
+	// Checkpoints of in-progress bars in Redis; disabled unless OHLCVCheckpointEnabled is set
+	OHLCVCheckpointEnabled  bool          // Checkpoint in-progress bars to Redis and restore them at startup
+	OHLCVCheckpointInterval time.Duration // How often changed bars are checkpointed; zero uses the default
+	// Verbose bar persistence diagnostics
+	OHLCVDebug bool // Read every saved bar back and log its timestamp conversion
+	// Order submission retries after transient CLOB failures; disabled when OrderRetryMaxAttempts is 0
+	OrderRetryMaxAttempts int           // Submission retries before a queued order is rejected
+	OrderRetryBackoff     time.Duration // Delay before the first retry, doubled for each further retry
+	// Idempotency keys of placed orders
+	OrderIdempotencyTTL time.Duration // How long an order's idempotency key is remembered; zero uses the default
+	// Concurrent order signings; unlimited when SignerMaxConcurrent is 0
+	SignerMaxConcurrent int           // Signing requests sent to the remote signer at once
+	SignerMaxQueued     int           // Orders waiting for a signing slot before new ones are rejected; zero uses SignerMaxConcurrent
+	SignerQueueTimeout  time.Duration // How long an order waits for a signing slot; zero uses the default
 }
 
 /**
@@ -75,26 +164,269 @@ func LoadConfig(path string) (config Config, err error) {
 		config.Port = "8080"
 	}
 
+	// The internal listener is optional and only started when a port is configured.
+	config.InternalPort = os.Getenv("INTERNAL_PORT")
+
 	config.DatabaseURL = os.Getenv("DATABASE_URL")
 	config.ClerkSecretKey = os.Getenv("CLERK_SECRET_KEY")
 	config.ClerkIssuerURL = os.Getenv("CLERK_ISSUER_URL")
+	// Webhook signing secrets, falling back to CLERK_SECRET_KEY (which held them before
+	// CLERK_WEBHOOK_SECRETS existed) only when the dedicated variable is unset
+	config.ClerkWebhookSecrets = splitList(os.Getenv("CLERK_WEBHOOK_SECRETS"))
+	if len(config.ClerkWebhookSecrets) == 0 {
+		config.ClerkWebhookSecrets = splitList(config.ClerkSecretKey)
+	}
+	config.ClerkWebhookInstanceIDs = splitList(os.Getenv("CLERK_WEBHOOK_INSTANCE_IDS"))
+	config.ClerkWebhookUserIDPrefix = strings.TrimSpace(os.Getenv("CLERK_WEBHOOK_USER_ID_PREFIX"))
 	config.RemoteSignerAddress = os.Getenv("REMOTE_SIGNER_ADDRESS")
 	config.RedisURL = os.Getenv("REDIS_URL")
+	config.SignerSimulationAllowed = os.Getenv("SIGNER_SIMULATION_ALLOWED") == "true"
 	
-	// Polymarket API configuration (optional - defaults provided in clients)
+	// Polymarket API configuration (optional - public endpoints by default)
 	config.GammaAPIURL = os.Getenv("GAMMA_API_URL")
+	if config.GammaAPIURL == "" {
+		config.GammaAPIURL = DefaultGammaAPIURL
+	}
 	config.CLOBAPIURL = os.Getenv("CLOB_API_URL")
+	if config.CLOBAPIURL == "" {
+		config.CLOBAPIURL = DefaultCLOBAPIURL
+	}
 	config.CLOBWSURL = os.Getenv("CLOB_WS_URL")
+	if config.CLOBWSURL == "" {
+		config.CLOBWSURL = DefaultCLOBWSURL
+	}
 	config.CLOBAPIKey = os.Getenv("CLOB_API_KEY")
 	config.CLOBAPISecret = os.Getenv("CLOB_API_SECRET")
 	config.CLOBAPIPassphrase = os.Getenv("CLOB_API_PASSPHRASE")
 
+	// Mock market data fallback (enabled unless explicitly set to "false")
+	config.MockFallbackEnabled = os.Getenv("MOCK_FALLBACK_ENABLED") != "false"
+
+	// Featured markets (optional, comma-separated condition IDs)
+	config.FeaturedMarkets = splitList(os.Getenv("FEATURED_MARKETS"))
+
+	// CLOB WebSocket subscription budget (optional, 0 or unset means unlimited)
+	if maxAssets := os.Getenv("MAX_STREAM_ASSETS"); maxAssets != "" {
+		config.MaxStreamAssets, err = strconv.Atoi(maxAssets)
+		if err != nil || config.MaxStreamAssets < 0 {
+			return Config{}, errors.New("MAX_STREAM_ASSETS must be a non-negative integer")
+		}
+	}
+	if config.StreamCatalogTTL, err = parseOptionalMillis("STREAM_CATALOG_TTL_MS"); err != nil {
+		return Config{}, err
+	}
+	if shardSize := os.Getenv("STREAM_SHARD_SIZE"); shardSize != "" {
+		config.StreamShardSize, err = strconv.Atoi(shardSize)
+		if err != nil || config.StreamShardSize < 0 {
+			return Config{}, errors.New("STREAM_SHARD_SIZE must be a non-negative integer")
+		}
+	}
+
+	// Disabled Redis caches (optional, comma-separated; validated when the services are created)
+	config.CacheDisabled = splitList(os.Getenv("CACHE_DISABLED"))
+
+	// Logged payload length (optional, unset uses the logsafe default)
+	if maxBytes := os.Getenv("LOG_BODY_MAX_BYTES"); maxBytes != "" {
+		config.LogBodyMaxBytes, err = strconv.Atoi(maxBytes)
+		if err != nil || config.LogBodyMaxBytes < 0 {
+			return Config{}, errors.New("LOG_BODY_MAX_BYTES must be a non-negative integer")
+		}
+	}
+
+	// Failed Redis publish buffer (optional, unset uses the defaults)
+	if size := os.Getenv("PUBLISH_BUFFER_SIZE"); size != "" {
+		config.PublishBufferSize, err = strconv.Atoi(size)
+		if err != nil || config.PublishBufferSize < 0 {
+			return Config{}, errors.New("PUBLISH_BUFFER_SIZE must be a non-negative integer")
+		}
+	}
+	if config.PublishBufferBookMaxAge, err = parseOptionalMillis("PUBLISH_BUFFER_BOOK_MAX_AGE_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// WebSocket subscription allow-list (optional, comma-separated condition IDs)
+	config.WSAllowedMarkets = splitList(os.Getenv("WS_ALLOWED_MARKETS"))
+
+	// WebSocket compression (optional, off by default)
+	config.WSCompressionEnabled = os.Getenv("WS_COMPRESSION_ENABLED") == "true"
+	if level := os.Getenv("WS_COMPRESSION_LEVEL"); level != "" {
+		config.WSCompressionLevel, err = strconv.Atoi(level)
+		if err != nil || config.WSCompressionLevel < 1 || config.WSCompressionLevel > 9 {
+			return Config{}, errors.New("WS_COMPRESSION_LEVEL must be an integer between 1 and 9")
+		}
+	}
+
+	// Per-market Redis listeners of the WebSocket hub (optional, unset means unlimited)
+	if maxListeners := os.Getenv("WS_MAX_REDIS_LISTENERS"); maxListeners != "" {
+		config.WSMaxRedisListeners, err = strconv.Atoi(maxListeners)
+		if err != nil || config.WSMaxRedisListeners < 0 {
+			return Config{}, errors.New("WS_MAX_REDIS_LISTENERS must be a non-negative integer")
+		}
+	}
+
+	// WebSocket client plan limits (optional, e.g. WS_MAX_SUBSCRIPTIONS_ANONYMOUS, WS_MIN_THROTTLE_MS_API_KEY)
+	config.WSAPIKeys = splitList(os.Getenv("WS_API_KEYS"))
+	config.WSMaxSubscriptions = make(map[string]int)
+	config.WSMinThrottle = make(map[string]time.Duration)
+	for _, plan := range wsPlans {
+		suffix := strings.ToUpper(plan)
+		if maxSubs := os.Getenv("WS_MAX_SUBSCRIPTIONS_" + suffix); maxSubs != "" {
+			value, err := strconv.Atoi(maxSubs)
+			if err != nil || value < 0 {
+				return Config{}, errors.New("WS_MAX_SUBSCRIPTIONS_" + suffix + " must be a non-negative integer")
+			}
+			config.WSMaxSubscriptions[plan] = value
+		}
+		minThrottle, err := parseOptionalMillis("WS_MIN_THROTTLE_MS_" + suffix)
+		if err != nil {
+			return Config{}, err
+		}
+		if minThrottle > time.Minute {
+			return Config{}, errors.New("WS_MIN_THROTTLE_MS_" + suffix + " must be at most 60000")
+		}
+		if minThrottle > 0 {
+			config.WSMinThrottle[plan] = minThrottle
+		}
+	}
+
+	// OHLCV resolutions (optional, comma-separated; validated when the services are created)
+	config.OHLCVResolutions = splitList(os.Getenv("OHLCV_RESOLUTIONS"))
+
+	// Aggregator memory bound (optional, 0 or unset means unlimited)
+	if maxMarkets := os.Getenv("OHLCV_MAX_MARKETS"); maxMarkets != "" {
+		config.OHLCVMaxMarkets, err = strconv.Atoi(maxMarkets)
+		if err != nil || config.OHLCVMaxMarkets < 0 {
+			return Config{}, errors.New("OHLCV_MAX_MARKETS must be a non-negative integer")
+		}
+	}
+
+	// Idle market eviction (optional, unset evicts markets idle for 2 hours, 0 keeps them)
+	config.OHLCVIdleMarketTimeout = defaultOHLCVIdleMarketTimeout
+	if os.Getenv("OHLCV_IDLE_MARKET_TIMEOUT_MS") != "" {
+		if config.OHLCVIdleMarketTimeout, err = parseOptionalMillis("OHLCV_IDLE_MARKET_TIMEOUT_MS"); err != nil {
+			return Config{}, err
+		}
+	}
+
+	// Redis retry/backoff (optional, unset keeps the go-redis defaults)
+	if maxRetries := os.Getenv("REDIS_MAX_RETRIES"); maxRetries != "" {
+		config.RedisMaxRetries, err = strconv.Atoi(maxRetries)
+		if err != nil || config.RedisMaxRetries < -1 {
+			return Config{}, errors.New("REDIS_MAX_RETRIES must be an integer of at least -1")
+		}
+	}
+	if config.RedisMinRetryBackoff, err = parseOptionalMillis("REDIS_MIN_RETRY_BACKOFF_MS"); err != nil {
+		return Config{}, err
+	}
+	if config.RedisMaxRetryBackoff, err = parseOptionalMillis("REDIS_MAX_RETRY_BACKOFF_MS"); err != nil {
+		return Config{}, err
+	}
+	if config.RedisMaxRetryBackoff > 0 && config.RedisMinRetryBackoff > config.RedisMaxRetryBackoff {
+		return Config{}, errors.New("REDIS_MIN_RETRY_BACKOFF_MS must not exceed REDIS_MAX_RETRY_BACKOFF_MS")
+	}
+	if config.RedisDialTimeout, err = parseOptionalMillis("REDIS_DIAL_TIMEOUT_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// Mid-price sanity filter (optional, unset disables each check)
+	if config.OHLCVMinMidPrice, err = parseOptionalPrice("OHLCV_MIN_MID_PRICE"); err != nil {
+		return Config{}, err
+	}
+	if config.OHLCVMaxMidPrice, err = parseOptionalPrice("OHLCV_MAX_MID_PRICE"); err != nil {
+		return Config{}, err
+	}
+	if config.OHLCVMaxSpread, err = parseOptionalPrice("OHLCV_MAX_SPREAD"); err != nil {
+		return Config{}, err
+	}
+	if config.OHLCVMaxMidPrice > 0 && config.OHLCVMinMidPrice > config.OHLCVMaxMidPrice {
+		return Config{}, errors.New("OHLCV_MIN_MID_PRICE must not exceed OHLCV_MAX_MID_PRICE")
+	}
+
+	// Dedupe ledger for overlapping ingesters (optional, disabled by default)
+	config.OHLCVDedupeEnabled = os.Getenv("OHLCV_DEDUPE_ENABLED") == "true"
+	if config.OHLCVDedupeTTL, err = parseOptionalMillis("OHLCV_DEDUPE_TTL_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// Pipeline stall threshold (optional, unset uses the monitor's default)
+	if config.OHLCVStallAfter, err = parseOptionalMillis("OHLCV_STALL_AFTER_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// Per-market bar watchdog (optional, unset uses the watchdog's default window)
+	if config.OHLCVBarWatchdogWindow, err = parseOptionalMillis("OHLCV_BAR_WATCHDOG_WINDOW_MS"); err != nil {
+		return Config{}, err
+	}
+	config.OHLCVBarWatchdogReflush = os.Getenv("OHLCV_BAR_WATCHDOG_REFLUSH") == "true"
+
+	// Bar persistence latency degradation (optional, unset uses the aggregator's defaults)
+	if config.OHLCVPersistLagThreshold, err = parseOptionalMillis("OHLCV_PERSIST_LAG_THRESHOLD_MS"); err != nil {
+		return Config{}, err
+	}
+	if cycles := os.Getenv("OHLCV_PERSIST_LAG_CYCLES"); cycles != "" {
+		config.OHLCVPersistLagCycles, err = strconv.Atoi(cycles)
+		if err != nil || config.OHLCVPersistLagCycles < 1 {
+			return Config{}, errors.New("OHLCV_PERSIST_LAG_CYCLES must be a positive integer")
+		}
+	}
+
+	// Gap filling (optional, off by default)
+	config.OHLCVFillGaps = os.Getenv("OHLCV_FILL_GAPS") == "true"
+	if maxPeriods := os.Getenv("OHLCV_FILL_GAPS_MAX_PERIODS"); maxPeriods != "" {
+		config.OHLCVFillGapsMaxPeriods, err = strconv.Atoi(maxPeriods)
+		if err != nil || config.OHLCVFillGapsMaxPeriods < 0 {
+			return Config{}, errors.New("OHLCV_FILL_GAPS_MAX_PERIODS must be a non-negative integer")
+		}
+	}
+
+	// Checkpoints of in-progress bars (optional, off by default)
+	config.OHLCVCheckpointEnabled = os.Getenv("OHLCV_CHECKPOINT_ENABLED") == "true"
+	if config.OHLCVCheckpointInterval, err = parseOptionalMillis("OHLCV_CHECKPOINT_INTERVAL_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// Verbose bar persistence diagnostics (optional, off by default)
+	config.OHLCVDebug = os.Getenv("OHLCV_DEBUG") == "true"
+
+	// Order submission retry queue (optional, unset disables retries)
+	if attempts := os.Getenv("ORDER_RETRY_MAX_ATTEMPTS"); attempts != "" {
+		config.OrderRetryMaxAttempts, err = strconv.Atoi(attempts)
+		if err != nil || config.OrderRetryMaxAttempts < 0 {
+			return Config{}, errors.New("ORDER_RETRY_MAX_ATTEMPTS must be a non-negative integer")
+		}
+	}
+	if config.OrderRetryBackoff, err = parseOptionalMillis("ORDER_RETRY_BACKOFF_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// Order idempotency key window (optional, unset uses the default of 24 hours)
+	if config.OrderIdempotencyTTL, err = parseOptionalMillis("ORDER_IDEMPOTENCY_TTL_MS"); err != nil {
+		return Config{}, err
+	}
+
+	// Order signing concurrency limit (optional, unset leaves signing unlimited)
+	if concurrent := os.Getenv("SIGNER_MAX_CONCURRENT"); concurrent != "" {
+		config.SignerMaxConcurrent, err = strconv.Atoi(concurrent)
+		if err != nil || config.SignerMaxConcurrent < 0 {
+			return Config{}, errors.New("SIGNER_MAX_CONCURRENT must be a non-negative integer")
+		}
+	}
+	if queued := os.Getenv("SIGNER_MAX_QUEUED"); queued != "" {
+		config.SignerMaxQueued, err = strconv.Atoi(queued)
+		if err != nil || config.SignerMaxQueued < 0 {
+			return Config{}, errors.New("SIGNER_MAX_QUEUED must be a non-negative integer")
+		}
+	}
+	if config.SignerQueueTimeout, err = parseOptionalMillis("SIGNER_QUEUE_TIMEOUT_MS"); err != nil {
+		return Config{}, err
+	}
+
 	// Validate that critical variables are not empty
 	if config.DatabaseURL == "" {
 		return Config{}, errors.New("DATABASE_URL is not set")
 	}
-	if config.ClerkSecretKey == "" {
-		return Config{}, errors.New("CLERK_SECRET_KEY is not set")
+	if len(config.ClerkWebhookSecrets) == 0 {
+		return Config{}, errors.New("CLERK_WEBHOOK_SECRETS is not set (nor CLERK_SECRET_KEY, its fallback)")
 	}
 	if config.ClerkIssuerURL == "" {
 		return Config{}, errors.New("CLERK_ISSUER_URL is not set")
@@ -107,8 +439,81 @@ func LoadConfig(path string) (config Config, err error) {
 	}
 	
 	// Note: CLOB API credentials are optional - only needed for trading operations
-	// Market data fetching via Gamma API does not require authentication
+	// Market data fetching via Gamma API does not require authentication.
+	// Partially set credentials are reported by MissingCLOBCredentials.
 
 	return
 }
 
+/**
+ * @description
+ * MissingCLOBCredentials reports CLOB credentials that are missing while others are set.
+ * Partial credentials disable trading and the live stream exactly like absent ones (the
+ * stream silently falls back to mock data), which is almost always a deployment mistake.
+ *
+ * @returns The names of the missing variables, or nil if all or none of them are set.
+ */
+func (c Config) MissingCLOBCredentials() []string {
+	credentials := []struct {
+		name  string
+		value string
+	}{
+		{"CLOB_API_KEY", c.CLOBAPIKey},
+		{"CLOB_API_SECRET", c.CLOBAPISecret},
+		{"CLOB_API_PASSPHRASE", c.CLOBAPIPassphrase},
+	}
+	var missing []string
+	for _, credential := range credentials {
+		if credential.value == "" {
+			missing = append(missing, credential.name)
+		}
+	}
+	if len(missing) == len(credentials) {
+		return nil
+	}
+	return missing
+}
+
+
+// wsPlans are the WebSocket client plans that limits can be configured for.
+var wsPlans = []string{"anonymous", "authenticated", "api_key"}
+
+// splitList parses a comma-separated environment value into a slice,
+// trimming whitespace and dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
+// parseOptionalPrice reads an optional price-like environment variable.
+// It returns 0 when the variable is unset, and an error unless the value is between 0 and 1.
+func parseOptionalPrice(name string) (float64, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 || price > 1 {
+		return 0, errors.New(name + " must be a number between 0 and 1")
+	}
+	return price, nil
+}
+
+// parseOptionalMillis reads an optional duration given in milliseconds.
+// It returns 0 when the variable is unset, and an error unless the value is a non-negative integer.
+func parseOptionalMillis(name string) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	millis, err := strconv.Atoi(value)
+	if err != nil || millis < 0 {
+		return 0, errors.New(name + " must be a non-negative integer (milliseconds)")
+	}
+	return time.Duration(millis) * time.Millisecond, nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClerkWebhookSecrets(t *testing.T) {
+	tests := []struct {
+		name           string
+		webhookSecrets string
+		secretKey      string
+		want           []string
+		wantErr        bool
+	}{
+		{"dedicated variable", "whsec_new, whsec_old", "sk_live_api", []string{"whsec_new", "whsec_old"}, false},
+		{"fallback when unset", "", "whsec_legacy", []string{"whsec_legacy"}, false},
+		{"fallback when only separators", " , ", "whsec_legacy", []string{"whsec_legacy"}, false},
+		{"neither set", "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DATABASE_URL", "postgres://localhost/polypro")
+			t.Setenv("CLERK_ISSUER_URL", "https://clerk.example.com")
+			t.Setenv("REMOTE_SIGNER_ADDRESS", "localhost:50051")
+			t.Setenv("REDIS_URL", "redis://localhost:6379")
+			t.Setenv("CLERK_WEBHOOK_SECRETS", tt.webhookSecrets)
+			t.Setenv("CLERK_SECRET_KEY", tt.secretKey)
+
+			config, err := LoadConfig(t.TempDir())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadConfig succeeded without a webhook secret")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if !reflect.DeepEqual(config.ClerkWebhookSecrets, tt.want) {
+				t.Errorf("webhook secrets = %q, want %q", config.ClerkWebhookSecrets, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// newTestQueries connects to the database of TEST_DATABASE_URL and loads schema.sql into a
+// scratch schema, which is dropped when the test ends. The test is skipped when the variable
+// is unset, so these tests only run against a disposable PostgreSQL database.
+func newTestQueries(t *testing.T) (*Queries, *pgx.Conn) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	schemaSQL, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		conn.Close(context.Background())
+	})
+	for _, statement := range []string{
+		"CREATE SCHEMA " + schema,
+		"SET search_path TO " + schema + ", public",
+		string(schemaSQL),
+	} {
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			t.Fatalf("set up schema: %v", err)
+		}
+	}
+	return New(conn), conn
+}
@@ -11,6 +11,84 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countMarketPriceHistoryRekeyCollisions = `-- name: CountMarketPriceHistoryRekeyCollisions :one
+SELECT COUNT(*)
+FROM market_price_history src
+JOIN market_price_history dst
+  ON dst.market_id = $1
+  AND dst.time = src.time
+  AND dst.resolution = src.resolution
+WHERE src.market_id = $2
+`
+
+type CountMarketPriceHistoryRekeyCollisionsParams struct {
+	TargetMarketID string `json:"target_market_id"`
+	SourceMarketID string `json:"source_market_id"`
+}
+
+// @description Counts bars under the source market ID that already have a bar with the same
+// time and resolution under the target market ID (i.e., bars that would be merged by a re-key).
+func (q *Queries) CountMarketPriceHistoryRekeyCollisions(ctx context.Context, arg CountMarketPriceHistoryRekeyCollisionsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countMarketPriceHistoryRekeyCollisions, arg.TargetMarketID, arg.SourceMarketID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countMarketPriceHistoryRows = `-- name: CountMarketPriceHistoryRows :one
+SELECT COUNT(*)
+FROM market_price_history
+WHERE market_id = $1
+`
+
+// @description Counts the OHLCV bars stored under a market ID across all resolutions.
+func (q *Queries) CountMarketPriceHistoryRows(ctx context.Context, marketID string) (int64, error) {
+	row := q.db.QueryRow(ctx, countMarketPriceHistoryRows, marketID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getLatestMarketPriceBar = `-- name: GetLatestMarketPriceBar :one
+SELECT
+  time,
+  market_id,
+  open,
+  high,
+  low,
+  close,
+  volume,
+  resolution
+FROM market_price_history
+WHERE market_id = $1
+  AND resolution = $2
+ORDER BY time DESC
+LIMIT 1
+`
+
+type GetLatestMarketPriceBarParams struct {
+	MarketID   string `json:"market_id"`
+	Resolution string `json:"resolution"`
+}
+
+// @description Retrieves the most recent OHLCV bar of a market at the given resolution.
+// Used as the fallback of the cached last price.
+func (q *Queries) GetLatestMarketPriceBar(ctx context.Context, arg GetLatestMarketPriceBarParams) (MarketPriceHistory, error) {
+	row := q.db.QueryRow(ctx, getLatestMarketPriceBar, arg.MarketID, arg.Resolution)
+	var i MarketPriceHistory
+	err := row.Scan(
+		&i.Time,
+		&i.MarketID,
+		&i.Open,
+		&i.High,
+		&i.Low,
+		&i.Close,
+		&i.Volume,
+		&i.Resolution,
+	)
+	return i, err
+}
+
 const getMarketPriceHistory = `-- name: GetMarketPriceHistory :many
 /**
  * @description
@@ -120,3 +198,132 @@ func (q *Queries) InsertMarketPriceHistory(ctx context.Context, arg InsertMarket
 	)
 	return err
 }
+
+const listDistinctMarketPriceHistoryMarketIDs = `-- name: ListDistinctMarketPriceHistoryMarketIDs :many
+SELECT DISTINCT market_id
+FROM market_price_history
+ORDER BY market_id
+`
+
+// @description Lists every distinct market_id that has stored OHLCV bars.
+// Used by the admin backfill to find bars stored under asset IDs instead of condition IDs.
+func (q *Queries) ListDistinctMarketPriceHistoryMarketIDs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listDistinctMarketPriceHistoryMarketIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var market_id string
+		if err := rows.Scan(&market_id); err != nil {
+			return nil, err
+		}
+		items = append(items, market_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMarketIDsWithBarsSince = `-- name: ListMarketIDsWithBarsSince :many
+SELECT DISTINCT market_id
+FROM market_price_history
+WHERE resolution = $1
+  AND time >= $2
+`
+
+type ListMarketIDsWithBarsSinceParams struct {
+	Resolution string             `json:"resolution"`
+	Since      pgtype.Timestamptz `json:"since"`
+}
+
+// @description Lists the distinct market IDs that have a bar of the given resolution starting
+// at or after the given time. Used by the bar watchdog to find markets whose bars stopped.
+func (q *Queries) ListMarketIDsWithBarsSince(ctx context.Context, arg ListMarketIDsWithBarsSinceParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listMarketIDsWithBarsSince, arg.Resolution, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var market_id string
+		if err := rows.Scan(&market_id); err != nil {
+			return nil, err
+		}
+		items = append(items, market_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rekeyMarketPriceHistory = `-- name: RekeyMarketPriceHistory :execrows
+WITH moved AS (
+  DELETE FROM market_price_history
+  WHERE market_id = $1
+  RETURNING time, open, high, low, close, volume, resolution
+)
+INSERT INTO market_price_history (time, market_id, open, high, low, close, volume, resolution)
+SELECT time, $2::varchar, open, high, low, close, volume, resolution
+FROM moved
+ON CONFLICT (market_id, time, resolution) DO UPDATE SET
+  open = EXCLUDED.open,
+  high = GREATEST(market_price_history.high, EXCLUDED.high),
+  low = LEAST(market_price_history.low, EXCLUDED.low),
+  volume = market_price_history.volume + EXCLUDED.volume
+`
+
+type RekeyMarketPriceHistoryParams struct {
+	SourceMarketID string `json:"source_market_id"`
+	TargetMarketID string `json:"target_market_id"`
+}
+
+// @description Moves all bars from the source market ID to the target market ID in one statement.
+// Colliding bars are merged like upsert_market_price_history() merges a re-saved bar: high and
+// low widen, the earlier bar's open and the later bar's close are kept, and volumes are added.
+// The moved bar is the earlier one, as bars were stored under the source ID before the mapping
+// fix, and its trades are not in the existing bar, so their volumes are disjoint.
+// Partitions already exist because the moved bars keep their original times.
+func (q *Queries) RekeyMarketPriceHistory(ctx context.Context, arg RekeyMarketPriceHistoryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rekeyMarketPriceHistory, arg.SourceMarketID, arg.TargetMarketID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertMarketPriceHistory = `-- name: UpsertMarketPriceHistory :exec
+SELECT upsert_market_price_history($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type UpsertMarketPriceHistoryParams struct {
+	PTime       pgtype.Timestamptz `json:"p_time"`
+	PMarketID   string             `json:"p_market_id"`
+	POpen       pgtype.Numeric     `json:"p_open"`
+	PHigh       pgtype.Numeric     `json:"p_high"`
+	PLow        pgtype.Numeric     `json:"p_low"`
+	PClose      pgtype.Numeric     `json:"p_close"`
+	PVolume     pgtype.Numeric     `json:"p_volume"`
+	PResolution string             `json:"p_resolution"`
+}
+
+// @description Saves an OHLCV bar, merging it with a stored bar of the same market, time, and
+// resolution: the stored open is kept, high and low widen, close is replaced, and volumes are
+// added. This uses the upsert_market_price_history() function, which creates partitions.
+func (q *Queries) UpsertMarketPriceHistory(ctx context.Context, arg UpsertMarketPriceHistoryParams) error {
+	_, err := q.db.Exec(ctx, upsertMarketPriceHistory,
+		arg.PTime,
+		arg.PMarketID,
+		arg.POpen,
+		arg.PHigh,
+		arg.PLow,
+		arg.PClose,
+		arg.PVolume,
+		arg.PResolution,
+	)
+	return err
+}
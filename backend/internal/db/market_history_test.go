@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// testBar is a bar's OHLCV values, as decimal strings.
+type testBar struct {
+	open, high, low, close, volume string
+}
+
+// saveTestBar saves a bar with UpsertMarketPriceHistory.
+func saveTestBar(t *testing.T, q *Queries, marketID string, start time.Time, bar testBar) {
+	t.Helper()
+	err := q.UpsertMarketPriceHistory(context.Background(), UpsertMarketPriceHistoryParams{
+		PTime:       pgtype.Timestamptz{Time: start, Valid: true},
+		PMarketID:   marketID,
+		POpen:       testNumeric(t, bar.open),
+		PHigh:       testNumeric(t, bar.high),
+		PLow:        testNumeric(t, bar.low),
+		PClose:      testNumeric(t, bar.close),
+		PVolume:     testNumeric(t, bar.volume),
+		PResolution: "1",
+	})
+	if err != nil {
+		t.Fatalf("save bar: %v", err)
+	}
+}
+
+// loadTestBar reads a stored bar, reporting whether it exists.
+func loadTestBar(t *testing.T, conn *pgx.Conn, marketID string, start time.Time) (testBar, bool) {
+	t.Helper()
+	var bar testBar
+	err := conn.QueryRow(context.Background(),
+		`SELECT open::text, high::text, low::text, close::text, volume::text
+		FROM market_price_history WHERE market_id = $1 AND time = $2 AND resolution = '1'`,
+		marketID, start,
+	).Scan(&bar.open, &bar.high, &bar.low, &bar.close, &bar.volume)
+	if err == pgx.ErrNoRows {
+		return testBar{}, false
+	}
+	if err != nil {
+		t.Fatalf("load bar: %v", err)
+	}
+	return bar, true
+}
+
+func testNumeric(t *testing.T, value string) pgtype.Numeric {
+	t.Helper()
+	var n pgtype.Numeric
+	if err := n.Scan(value); err != nil {
+		t.Fatalf("numeric %q: %v", value, err)
+	}
+	return n
+}
+
+func TestRekeyMarketPriceHistory(t *testing.T) {
+	q, conn := newTestQueries(t)
+	ctx := context.Background()
+	const source, target = "asset-123", "0xcondition"
+	first := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	third := second.Add(time.Minute)
+
+	// The source's bars predate the mapping fix; the target's second bar was saved after it.
+	saveTestBar(t, q, source, first, testBar{"0.40", "0.45", "0.38", "0.42", "10"})
+	saveTestBar(t, q, source, second, testBar{"0.42", "0.50", "0.41", "0.48", "5"})
+	saveTestBar(t, q, target, second, testBar{"0.47", "0.49", "0.35", "0.36", "7"})
+	saveTestBar(t, q, target, third, testBar{"0.36", "0.37", "0.30", "0.31", "3"})
+
+	collisions, err := q.CountMarketPriceHistoryRekeyCollisions(ctx, CountMarketPriceHistoryRekeyCollisionsParams{TargetMarketID: target, SourceMarketID: source})
+	if err != nil || collisions != 1 {
+		t.Fatalf("collisions = %d, %v, want 1", collisions, err)
+	}
+	rows, err := q.RekeyMarketPriceHistory(ctx, RekeyMarketPriceHistoryParams{SourceMarketID: source, TargetMarketID: target})
+	if err != nil || rows != 2 {
+		t.Fatalf("re-keyed rows = %d, %v, want 2", rows, err)
+	}
+
+	if remaining, err := q.CountMarketPriceHistoryRows(ctx, source); err != nil || remaining != 0 {
+		t.Errorf("bars left under the source = %d, %v, want 0", remaining, err)
+	}
+	tests := []struct {
+		name  string
+		start time.Time
+		want  testBar
+	}{
+		{"moved without collision", first, testBar{"0.40", "0.45", "0.38", "0.42", "10"}},
+		// Earlier (moved) open, later (existing) close, widest range, and both volumes.
+		{"merged collision", second, testBar{"0.42", "0.50", "0.35", "0.36", "12"}},
+		{"untouched target bar", third, testBar{"0.36", "0.37", "0.30", "0.31", "3"}},
+	}
+	for _, tt := range tests {
+		got, ok := loadTestBar(t, conn, target, tt.start)
+		if !ok {
+			t.Errorf("%s: no bar under the target", tt.name)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Re-keying again finds nothing to move.
+	if rows, err := q.RekeyMarketPriceHistory(ctx, RekeyMarketPriceHistoryParams{SourceMarketID: source, TargetMarketID: target}); err != nil || rows != 0 {
+		t.Errorf("second re-key = %d, %v, want 0", rows, err)
+	}
+}
+
+func TestUpsertMarketPriceHistory(t *testing.T) {
+	q, conn := newTestQueries(t)
+	const marketID = "0xcondition"
+	start := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	saveTestBar(t, q, marketID, start.Add(time.Minute), testBar{"0.42", "0.42", "0.42", "0.42", "1"})
+	// Each save carries the volume traded since the previous one.
+	saves := []struct {
+		name  string
+		saved testBar
+		want  testBar
+	}{
+		{"first save", testBar{"0.40", "0.45", "0.38", "0.42", "10"}, testBar{"0.40", "0.45", "0.38", "0.42", "10"}},
+		// Earlier open, later close, widest range, and summed volume.
+		{"higher high", testBar{"0.50", "0.52", "0.39", "0.44", "5"}, testBar{"0.40", "0.52", "0.38", "0.44", "15"}},
+		{"lower low", testBar{"0.30", "0.46", "0.20", "0.41", "2.5"}, testBar{"0.40", "0.52", "0.20", "0.41", "17.5"}},
+		{"no new trades", testBar{"0.41", "0.41", "0.41", "0.41", "0"}, testBar{"0.40", "0.52", "0.20", "0.41", "17.5"}},
+	}
+	for _, save := range saves {
+		saveTestBar(t, q, marketID, start, save.saved)
+		got, ok := loadTestBar(t, conn, marketID, start)
+		if !ok {
+			t.Fatalf("%s: no bar saved", save.name)
+		}
+		if got != save.want {
+			t.Errorf("%s: got %+v, want %+v", save.name, got, save.want)
+		}
+	}
+
+	if got, _ := loadTestBar(t, conn, marketID, start.Add(time.Minute)); got != (testBar{"0.42", "0.42", "0.42", "0.42", "1"}) {
+		t.Errorf("the next bar changed: %+v", got)
+	}
+}
@@ -0,0 +1,11 @@
+/**
+ * @description
+ * Rollback migration to remove wallet ownership verification.
+ */
+
+-- Drop wallet_challenges table and its indexes
+DROP INDEX IF EXISTS idx_wallet_challenges_wallet_id;
+DROP TABLE IF EXISTS wallet_challenges;
+
+-- Remove verified_at column from wallets table
+ALTER TABLE wallets DROP COLUMN IF EXISTS verified_at;
@@ -0,0 +1,31 @@
+/**
+ * @description
+ * Migration to add wallet ownership verification.
+ * This migration adds:
+ * - verified_at column on wallets, set once the user proves control of the funder address
+ * - wallet_challenges table holding single-use, expiring nonces for signature verification
+ *
+ * Existing active wallets are backfilled as verified, as they were registered and trading
+ * before verification existed and would otherwise lose trading until their owners complete
+ * the challenge flow. Wallets registered from now on must be verified before trading.
+ */
+
+-- Add verified_at column to wallets table
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
+
+-- Grandfather the wallets already in use
+UPDATE wallets SET verified_at = NOW() WHERE is_active = TRUE AND verified_at IS NULL;
+
+-- Table: wallet_challenges
+-- Single-use, expiring nonces that a user signs (EIP-191) to prove ownership of a wallet.
+CREATE TABLE IF NOT EXISTS wallet_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    nonce VARCHAR(64) UNIQUE NOT NULL,
+    message TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ, -- Set when the challenge is used; a consumed challenge can never be reused
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_challenges_wallet_id ON wallet_challenges(wallet_id);
@@ -0,0 +1,12 @@
+/**
+ * @description
+ * Rollback migration to remove the 'delayed' and 'expired' order statuses.
+ * Orders in those statuses are mapped back to the closest original status first.
+ */
+
+UPDATE orders SET status = 'open' WHERE status = 'delayed';
+UPDATE orders SET status = 'cancelled' WHERE status = 'expired';
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending', 'open', 'filled', 'cancelled', 'rejected'));
@@ -0,0 +1,12 @@
+/**
+ * @description
+ * Migration to support Polymarket's asynchronous order outcomes.
+ * This migration adds:
+ * - 'delayed' status for orders accepted during a market's matching delay window
+ *   (polled by the order sync service until they resolve)
+ * - 'expired' status for delayed resting orders that were never placed on the book
+ */
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected'));
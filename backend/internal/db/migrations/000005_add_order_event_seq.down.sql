@@ -0,0 +1,6 @@
+/**
+ * @description
+ * Rollback migration to remove the event_seq column from orders.
+ */
+
+ALTER TABLE orders DROP COLUMN IF EXISTS event_seq;
@@ -0,0 +1,9 @@
+/**
+ * @description
+ * Migration to order order_update events.
+ * This migration adds:
+ * - event_seq column on orders, incremented by every status update, so that events
+ *   published for an order can be ordered (and stale ones discarded) by clients
+ */
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS event_seq BIGINT NOT NULL DEFAULT 0;
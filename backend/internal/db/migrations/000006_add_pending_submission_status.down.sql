@@ -0,0 +1,11 @@
+/**
+ * @description
+ * Rollback migration to remove the 'pending_submission' order status.
+ * Orders still awaiting submission are rejected first.
+ */
+
+UPDATE orders SET status = 'rejected' WHERE status = 'pending_submission';
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected'));
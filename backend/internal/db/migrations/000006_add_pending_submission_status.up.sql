@@ -0,0 +1,11 @@
+/**
+ * @description
+ * Migration to support retrying order submissions while the CLOB is temporarily unavailable.
+ * This migration adds:
+ * - 'pending_submission' status for signed orders queued for another submission attempt
+ *   (rejected once the retries are exhausted)
+ */
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending', 'pending_submission', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected'));
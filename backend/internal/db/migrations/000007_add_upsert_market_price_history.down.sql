@@ -0,0 +1,6 @@
+/**
+ * @description
+ * Rollback migration to remove the merging OHLCV bar upsert.
+ */
+
+DROP FUNCTION IF EXISTS upsert_market_price_history(TIMESTAMPTZ, VARCHAR, DECIMAL, DECIMAL, DECIMAL, DECIMAL, DECIMAL, VARCHAR);
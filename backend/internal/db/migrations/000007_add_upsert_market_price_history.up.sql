@@ -0,0 +1,36 @@
+/**
+ * @description
+ * Migration to merge re-saved OHLCV bars instead of replacing them.
+ * This migration adds:
+ * - upsert_market_price_history(), a wrapper like insert_market_price_history() that merges
+ *   a bar saved again for the same (market_id, time, resolution): the stored open is kept,
+ *   high and low widen, close is replaced, and volumes are added. The aggregator saves only
+ *   the volume traded since a bar's previous save, so that the volumes are disjoint.
+ */
+
+CREATE OR REPLACE FUNCTION upsert_market_price_history(
+    p_time TIMESTAMPTZ,
+    p_market_id VARCHAR(255),
+    p_open DECIMAL,
+    p_high DECIMAL,
+    p_low DECIMAL,
+    p_close DECIMAL,
+    p_volume DECIMAL,
+    p_resolution VARCHAR(10) DEFAULT '15'
+)
+RETURNS VOID AS $$
+BEGIN
+    -- Ensure partition exists (function handles UTC normalization)
+    PERFORM ensure_market_price_history_partition(p_time);
+
+    INSERT INTO market_price_history (time, market_id, open, high, low, close, volume, resolution)
+    VALUES (p_time, p_market_id, p_open, p_high, p_low, p_close, p_volume, p_resolution)
+    ON CONFLICT (market_id, time, resolution) DO UPDATE SET
+        high = GREATEST(market_price_history.high, EXCLUDED.high),
+        low = LEAST(market_price_history.low, EXCLUDED.low),
+        close = EXCLUDED.close,
+        volume = market_price_history.volume + EXCLUDED.volume;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION upsert_market_price_history IS 'Wrapper function to insert into market_price_history with automatic partition creation, merging a bar saved again for the same market, time, and resolution.';
@@ -0,0 +1,6 @@
+/**
+ * @description
+ * Rollback migration to remove the taker column from orders.
+ */
+
+ALTER TABLE orders DROP COLUMN IF EXISTS taker;
@@ -0,0 +1,9 @@
+/**
+ * @description
+ * Migration to record the taker of directed orders.
+ * This migration adds:
+ * - taker column on orders, the counterparty an order is directed to, defaulting to the
+ *   zero address of public orders (which existing orders all are)
+ */
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS taker VARCHAR(42) NOT NULL DEFAULT '0x0000000000000000000000000000000000000000';
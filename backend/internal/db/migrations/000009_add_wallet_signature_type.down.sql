@@ -0,0 +1,6 @@
+/**
+ * @description
+ * Rollback migration to remove the signature_type column from wallets.
+ */
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS signature_type;
@@ -0,0 +1,11 @@
+/**
+ * @description
+ * Migration to sign orders according to the wallet type.
+ * This migration adds:
+ * - signature_type column on wallets: 0 for EOA wallets, 1 for Polymarket proxy wallets
+ *   (email accounts), 2 for Gnosis Safe wallets. Existing wallets keep 1, the type every
+ *   order was signed with so far.
+ */
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS signature_type SMALLINT NOT NULL DEFAULT 1
+    CHECK (signature_type IN (0, 1, 2));
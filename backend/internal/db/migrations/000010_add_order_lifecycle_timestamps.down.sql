@@ -0,0 +1,8 @@
+/**
+ * @description
+ * Rollback migration to remove the order lifecycle timestamps from orders.
+ */
+
+ALTER TABLE orders DROP COLUMN IF EXISTS first_status_change_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS acknowledged_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS signed_at;
@@ -0,0 +1,16 @@
+/**
+ * @description
+ * Migration to record the latency of each step of an order's submission.
+ * This migration adds:
+ * - signed_at column on orders, when the remote signer returned the order's signature
+ * - acknowledged_at column on orders, when the CLOB answered the submission it accepted or
+ *   rejected
+ * - first_status_change_at column on orders, when the status of the submitted order first
+ *   changed, e.g. when the order sync found it filled or cancelled
+ * submitted_at, which already exists, becomes the time that submission was sent to the CLOB.
+ * Existing orders keep NULL for the new columns.
+ */
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS signed_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS first_status_change_at TIMESTAMPTZ;
@@ -73,21 +73,26 @@ type NewsEvent struct {
 }
 
 type Order struct {
-	ID                pgtype.UUID        `json:"id"`
-	UserID            pgtype.UUID        `json:"user_id"`
-	MarketID          string             `json:"market_id"`
-	TokenID           string             `json:"token_id"`
-	PolymarketOrderID pgtype.Text        `json:"polymarket_order_id"`
-	Side              string             `json:"side"`
-	Size              pgtype.Numeric     `json:"size"`
-	Price             pgtype.Numeric     `json:"price"`
-	Status            string             `json:"status"`
-	SignedOrder       []byte             `json:"signed_order"`
-	SubmittedAt       pgtype.Timestamptz `json:"submitted_at"`
-	FilledAt          pgtype.Timestamptz `json:"filled_at"`
-	CancelledAt       pgtype.Timestamptz `json:"cancelled_at"`
-	CreatedAt         pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	ID                  pgtype.UUID        `json:"id"`
+	UserID              pgtype.UUID        `json:"user_id"`
+	MarketID            string             `json:"market_id"`
+	TokenID             string             `json:"token_id"`
+	PolymarketOrderID   pgtype.Text        `json:"polymarket_order_id"`
+	Side                string             `json:"side"`
+	Size                pgtype.Numeric     `json:"size"`
+	Price               pgtype.Numeric     `json:"price"`
+	Status              string             `json:"status"`
+	SignedOrder         []byte             `json:"signed_order"`
+	SubmittedAt         pgtype.Timestamptz `json:"submitted_at"`
+	FilledAt            pgtype.Timestamptz `json:"filled_at"`
+	CancelledAt         pgtype.Timestamptz `json:"cancelled_at"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	EventSeq            int64              `json:"event_seq"`
+	Taker               string             `json:"taker"`
+	SignedAt            pgtype.Timestamptz `json:"signed_at"`
+	AcknowledgedAt      pgtype.Timestamptz `json:"acknowledged_at"`
+	FirstStatusChangeAt pgtype.Timestamptz `json:"first_status_change_at"`
 }
 
 type Trade struct {
@@ -119,4 +124,16 @@ type Wallet struct {
 	IsActive                bool               `json:"is_active"`
 	CreatedAt               pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt               pgtype.Timestamptz `json:"updated_at"`
+	VerifiedAt              pgtype.Timestamptz `json:"verified_at"`
+	SignatureType           int16              `json:"signature_type"`
+}
+
+type WalletChallenge struct {
+	ID         pgtype.UUID        `json:"id"`
+	WalletID   pgtype.UUID        `json:"wallet_id"`
+	Nonce      string             `json:"nonce"`
+	Message    string             `json:"message"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	ConsumedAt pgtype.Timestamptz `json:"consumed_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
@@ -11,6 +11,27 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countOrdersByUserID = `-- name: CountOrdersByUserID :one
+SELECT COUNT(*) FROM orders
+WHERE user_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+  AND ($3::text IS NULL OR market_id = $3::text)
+`
+
+type CountOrdersByUserIDParams struct {
+	UserID   pgtype.UUID `json:"user_id"`
+	Status   pgtype.Text `json:"status"`
+	MarketID pgtype.Text `json:"market_id"`
+}
+
+// @description Counts a user's orders with the same filters as ListOrdersByUserID.
+func (q *Queries) CountOrdersByUserID(ctx context.Context, arg CountOrdersByUserIDParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrdersByUserID, arg.UserID, arg.Status, arg.MarketID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createOrder = `-- name: CreateOrder :one
 /**
  * @description
@@ -26,11 +47,12 @@ INSERT INTO orders (
   size,
   price,
   status,
-  signed_order
+  signed_order,
+  taker
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at
+RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at
 `
 
 type CreateOrderParams struct {
@@ -42,6 +64,7 @@ type CreateOrderParams struct {
 	Price       pgtype.Numeric `json:"price"`
 	Status      string         `json:"status"`
 	SignedOrder []byte         `json:"signed_order"`
+	Taker       string         `json:"taker"`
 }
 
 // @description Creates a new order in the database with status 'pending'.
@@ -56,6 +79,7 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		arg.Price,
 		arg.Status,
 		arg.SignedOrder,
+		arg.Taker,
 	)
 	var i Order
 	err := row.Scan(
@@ -74,12 +98,17 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.CancelledAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EventSeq,
+		&i.Taker,
+		&i.SignedAt,
+		&i.AcknowledgedAt,
+		&i.FirstStatusChangeAt,
 	)
 	return i, err
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at FROM orders
+SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at FROM orders
 WHERE id = $1
 LIMIT 1
 `
@@ -104,12 +133,17 @@ func (q *Queries) GetOrderByID(ctx context.Context, id pgtype.UUID) (Order, erro
 		&i.CancelledAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EventSeq,
+		&i.Taker,
+		&i.SignedAt,
+		&i.AcknowledgedAt,
+		&i.FirstStatusChangeAt,
 	)
 	return i, err
 }
 
 const getOrdersByMarketID = `-- name: GetOrdersByMarketID :many
-SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at FROM orders
+SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at FROM orders
 WHERE market_id = $1
 ORDER BY created_at DESC
 `
@@ -140,6 +174,11 @@ func (q *Queries) GetOrdersByMarketID(ctx context.Context, marketID string) ([]O
 			&i.CancelledAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EventSeq,
+			&i.Taker,
+			&i.SignedAt,
+			&i.AcknowledgedAt,
+			&i.FirstStatusChangeAt,
 		); err != nil {
 			return nil, err
 		}
@@ -152,7 +191,7 @@ func (q *Queries) GetOrdersByMarketID(ctx context.Context, marketID string) ([]O
 }
 
 const getOrdersByUserID = `-- name: GetOrdersByUserID :many
-SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at FROM orders
+SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at FROM orders
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -183,6 +222,11 @@ func (q *Queries) GetOrdersByUserID(ctx context.Context, userID pgtype.UUID) ([]
 			&i.CancelledAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EventSeq,
+			&i.Taker,
+			&i.SignedAt,
+			&i.AcknowledgedAt,
+			&i.FirstStatusChangeAt,
 		); err != nil {
 			return nil, err
 		}
@@ -195,7 +239,7 @@ func (q *Queries) GetOrdersByUserID(ctx context.Context, userID pgtype.UUID) ([]
 }
 
 const getOrdersByUserIDAndStatus = `-- name: GetOrdersByUserIDAndStatus :many
-SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at FROM orders
+SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at FROM orders
 WHERE user_id = $1 AND status = $2
 ORDER BY created_at DESC
 `
@@ -231,6 +275,11 @@ func (q *Queries) GetOrdersByUserIDAndStatus(ctx context.Context, arg GetOrdersB
 			&i.CancelledAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.EventSeq,
+			&i.Taker,
+			&i.SignedAt,
+			&i.AcknowledgedAt,
+			&i.FirstStatusChangeAt,
 		); err != nil {
 			return nil, err
 		}
@@ -242,13 +291,287 @@ func (q *Queries) GetOrdersByUserIDAndStatus(ctx context.Context, arg GetOrdersB
 	return items, nil
 }
 
+const listOrdersByUserID = `-- name: ListOrdersByUserID :many
+SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at FROM orders
+WHERE user_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+  AND ($3::text IS NULL OR market_id = $3::text)
+ORDER BY created_at DESC, id DESC
+LIMIT $4 OFFSET $5
+`
+
+type ListOrdersByUserIDParams struct {
+	UserID    pgtype.UUID `json:"user_id"`
+	Status    pgtype.Text `json:"status"`
+	MarketID  pgtype.Text `json:"market_id"`
+	RowLimit  int32       `json:"row_limit"`
+	RowOffset int32       `json:"row_offset"`
+}
+
+// @description Retrieves a page of a user's orders, newest first, optionally filtered by status
+// and market. A NULL filter matches every order.
+func (q *Queries) ListOrdersByUserID(ctx context.Context, arg ListOrdersByUserIDParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrdersByUserID,
+		arg.UserID,
+		arg.Status,
+		arg.MarketID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MarketID,
+			&i.TokenID,
+			&i.PolymarketOrderID,
+			&i.Side,
+			&i.Size,
+			&i.Price,
+			&i.Status,
+			&i.SignedOrder,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CancelledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EventSeq,
+			&i.Taker,
+			&i.SignedAt,
+			&i.AcknowledgedAt,
+			&i.FirstStatusChangeAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersForSync = `-- name: ListOrdersForSync :many
+SELECT o.id, o.user_id, o.market_id, o.token_id, o.polymarket_order_id, o.side, o.size, o.price, o.status, o.signed_order, o.submitted_at, o.filled_at, o.cancelled_at, o.created_at, o.updated_at, o.event_seq, o.taker, o.signed_at, o.acknowledged_at, o.first_status_change_at, w.polymarket_funder_address
+FROM orders o
+JOIN wallets w ON w.user_id = o.user_id AND w.is_active = TRUE AND w.verified_at IS NOT NULL
+WHERE o.status = $1 AND o.polymarket_order_id IS NOT NULL
+ORDER BY o.updated_at ASC
+LIMIT $2
+`
+
+type ListOrdersForSyncParams struct {
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+}
+
+type ListOrdersForSyncRow struct {
+	ID                      pgtype.UUID        `json:"id"`
+	UserID                  pgtype.UUID        `json:"user_id"`
+	MarketID                string             `json:"market_id"`
+	TokenID                 string             `json:"token_id"`
+	PolymarketOrderID       pgtype.Text        `json:"polymarket_order_id"`
+	Side                    string             `json:"side"`
+	Size                    pgtype.Numeric     `json:"size"`
+	Price                   pgtype.Numeric     `json:"price"`
+	Status                  string             `json:"status"`
+	SignedOrder             []byte             `json:"signed_order"`
+	SubmittedAt             pgtype.Timestamptz `json:"submitted_at"`
+	FilledAt                pgtype.Timestamptz `json:"filled_at"`
+	CancelledAt             pgtype.Timestamptz `json:"cancelled_at"`
+	CreatedAt               pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt               pgtype.Timestamptz `json:"updated_at"`
+	EventSeq                int64              `json:"event_seq"`
+	Taker                   string             `json:"taker"`
+	SignedAt                pgtype.Timestamptz `json:"signed_at"`
+	AcknowledgedAt          pgtype.Timestamptz `json:"acknowledged_at"`
+	FirstStatusChangeAt     pgtype.Timestamptz `json:"first_status_change_at"`
+	PolymarketFunderAddress string             `json:"polymarket_funder_address"`
+}
+
+// @description Retrieves submitted orders in a given status along with the maker (funder) address
+// needed to query them on the CLOB. Least recently updated orders are returned first.
+func (q *Queries) ListOrdersForSync(ctx context.Context, arg ListOrdersForSyncParams) ([]ListOrdersForSyncRow, error) {
+	rows, err := q.db.Query(ctx, listOrdersForSync, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrdersForSyncRow{}
+	for rows.Next() {
+		var i ListOrdersForSyncRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MarketID,
+			&i.TokenID,
+			&i.PolymarketOrderID,
+			&i.Side,
+			&i.Size,
+			&i.Price,
+			&i.Status,
+			&i.SignedOrder,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CancelledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EventSeq,
+			&i.Taker,
+			&i.SignedAt,
+			&i.AcknowledgedAt,
+			&i.FirstStatusChangeAt,
+			&i.PolymarketFunderAddress,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStaleOrdersByStatus = `-- name: ListStaleOrdersByStatus :many
+SELECT id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at FROM orders
+WHERE status = $1 AND updated_at < $2
+ORDER BY updated_at ASC
+LIMIT $3
+`
+
+type ListStaleOrdersByStatusParams struct {
+	Status    string             `json:"status"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	Limit     int32              `json:"limit"`
+}
+
+// @description Retrieves orders in a given status that have not been updated since the cutoff.
+// Least recently updated orders are returned first.
+func (q *Queries) ListStaleOrdersByStatus(ctx context.Context, arg ListStaleOrdersByStatusParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listStaleOrdersByStatus, arg.Status, arg.UpdatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.MarketID,
+			&i.TokenID,
+			&i.PolymarketOrderID,
+			&i.Side,
+			&i.Size,
+			&i.Price,
+			&i.Status,
+			&i.SignedOrder,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CancelledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EventSeq,
+			&i.Taker,
+			&i.SignedAt,
+			&i.AcknowledgedAt,
+			&i.FirstStatusChangeAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordOrderSubmission = `-- name: RecordOrderSubmission :one
+UPDATE orders
+SET 
+  status = $1,
+  polymarket_order_id = COALESCE($2, polymarket_order_id),
+  signed_order = COALESCE($3, signed_order),
+  signed_at = COALESCE(signed_at, $4),
+  updated_at = NOW(),
+  submitted_at = COALESCE(submitted_at, $5, CASE WHEN $1 IN ('open', 'delayed', 'filled') THEN NOW() END),
+  acknowledged_at = COALESCE(acknowledged_at, $6),
+  filled_at = CASE WHEN $1 = 'filled' AND filled_at IS NULL THEN NOW() ELSE filled_at END,
+  cancelled_at = CASE WHEN $1 IN ('cancelled', 'expired') AND cancelled_at IS NULL THEN NOW() ELSE cancelled_at END,
+  event_seq = event_seq + 1
+WHERE id = $7
+RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at
+`
+
+type RecordOrderSubmissionParams struct {
+	Status            string             `json:"status"`
+	PolymarketOrderID pgtype.Text        `json:"polymarket_order_id"`
+	SignedOrder       []byte             `json:"signed_order"`
+	SignedAt          pgtype.Timestamptz `json:"signed_at"`
+	SubmittedAt       pgtype.Timestamptz `json:"submitted_at"`
+	AcknowledgedAt    pgtype.Timestamptz `json:"acknowledged_at"`
+	ID                pgtype.UUID        `json:"id"`
+}
+
+// @description Records the outcome of submitting an order to Polymarket in one statement: the
+// status (with the same timestamps as UpdateOrderStatus), the Polymarket order ID, and the
+// signed order. A NULL order ID or signed order keeps the stored value.
+// The lifecycle timestamps (signed_at, submitted_at, acknowledged_at) are set once: a stored
+// value is kept, and a NULL argument leaves them unset (submitted_at then falls back to NOW()
+// like in UpdateOrderStatus).
+// The order's event_seq is incremented, so it reflects the commit order of status updates.
+func (q *Queries) RecordOrderSubmission(ctx context.Context, arg RecordOrderSubmissionParams) (Order, error) {
+	row := q.db.QueryRow(ctx, recordOrderSubmission,
+		arg.Status,
+		arg.PolymarketOrderID,
+		arg.SignedOrder,
+		arg.SignedAt,
+		arg.SubmittedAt,
+		arg.AcknowledgedAt,
+		arg.ID,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.MarketID,
+		&i.TokenID,
+		&i.PolymarketOrderID,
+		&i.Side,
+		&i.Size,
+		&i.Price,
+		&i.Status,
+		&i.SignedOrder,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CancelledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EventSeq,
+		&i.Taker,
+		&i.SignedAt,
+		&i.AcknowledgedAt,
+		&i.FirstStatusChangeAt,
+	)
+	return i, err
+}
+
 const updateOrderPolymarketID = `-- name: UpdateOrderPolymarketID :one
 UPDATE orders
 SET 
   polymarket_order_id = $2,
   updated_at = NOW()
 WHERE id = $1
-RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at
+RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at
 `
 
 type UpdateOrderPolymarketIDParams struct {
@@ -276,6 +599,11 @@ func (q *Queries) UpdateOrderPolymarketID(ctx context.Context, arg UpdateOrderPo
 		&i.CancelledAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EventSeq,
+		&i.Taker,
+		&i.SignedAt,
+		&i.AcknowledgedAt,
+		&i.FirstStatusChangeAt,
 	)
 	return i, err
 }
@@ -285,11 +613,13 @@ UPDATE orders
 SET 
   status = $2,
   updated_at = NOW(),
-  submitted_at = CASE WHEN $2 = 'open' AND submitted_at IS NULL THEN NOW() ELSE submitted_at END,
+  submitted_at = CASE WHEN $2 IN ('open', 'delayed', 'filled') AND submitted_at IS NULL THEN NOW() ELSE submitted_at END,
   filled_at = CASE WHEN $2 = 'filled' AND filled_at IS NULL THEN NOW() ELSE filled_at END,
-  cancelled_at = CASE WHEN $2 = 'cancelled' AND cancelled_at IS NULL THEN NOW() ELSE cancelled_at END
+  cancelled_at = CASE WHEN $2 IN ('cancelled', 'expired') AND cancelled_at IS NULL THEN NOW() ELSE cancelled_at END,
+  first_status_change_at = CASE WHEN status <> $2 AND submitted_at IS NOT NULL AND first_status_change_at IS NULL THEN NOW() ELSE first_status_change_at END,
+  event_seq = event_seq + 1
 WHERE id = $1
-RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at
+RETURNING id, user_id, market_id, token_id, polymarket_order_id, side, size, price, status, signed_order, submitted_at, filled_at, cancelled_at, created_at, updated_at, event_seq, taker, signed_at, acknowledged_at, first_status_change_at
 `
 
 type UpdateOrderStatusParams struct {
@@ -298,7 +628,9 @@ type UpdateOrderStatusParams struct {
 }
 
 // @description Updates the status of an order and sets the appropriate timestamp.
-// Status can be: 'pending', 'open', 'filled', 'cancelled', 'rejected'
+// Status can be: 'pending', 'pending_submission', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected'
+// first_status_change_at is set once, by the first status change of an order already submitted.
+// The order's event_seq is incremented, so it reflects the commit order of status updates.
 func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
 	row := q.db.QueryRow(ctx, updateOrderStatus, arg.ID, arg.Status)
 	var i Order
@@ -318,6 +650,11 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 		&i.CancelledAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EventSeq,
+		&i.Taker,
+		&i.SignedAt,
+		&i.AcknowledgedAt,
+		&i.FirstStatusChangeAt,
 	)
 	return i, err
 }
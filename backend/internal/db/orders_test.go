@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// createTestOrder creates a user and a pending order of theirs.
+func createTestOrder(t *testing.T, q *Queries) Order {
+	t.Helper()
+	ctx := context.Background()
+	user, err := q.CreateUser(ctx, CreateUserParams{ClerkUserID: "user_test", Email: "test@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	order, err := q.CreateOrder(ctx, CreateOrderParams{
+		UserID:   user.ID,
+		MarketID: "0xcondition",
+		TokenID:  "token",
+		Side:     "BUY",
+		Size:     testNumeric(t, "10"),
+		Price:    testNumeric(t, "0.5"),
+		Status:   "pending",
+		Taker:    "0x0000000000000000000000000000000000000000",
+	})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	return order
+}
+
+func TestOrderLifecycleTimestamps(t *testing.T) {
+	q, _ := newTestQueries(t)
+	ctx := context.Background()
+	order := createTestOrder(t, q)
+
+	// A status change before the submission is not the first change of a submitted order.
+	order, err := q.UpdateOrderStatus(ctx, UpdateOrderStatusParams{ID: order.ID, Status: "pending_submission"})
+	if err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if order.FirstStatusChangeAt.Valid {
+		t.Error("first_status_change_at set before the submission")
+	}
+
+	signedAt := time.Now().Add(-2 * time.Second).UTC().Truncate(time.Microsecond)
+	submittedAt := signedAt.Add(time.Second)
+	acknowledgedAt := submittedAt.Add(100 * time.Millisecond)
+	order, err = q.RecordOrderSubmission(ctx, RecordOrderSubmissionParams{
+		ID:                order.ID,
+		Status:            "open",
+		PolymarketOrderID: pgtype.Text{String: "0xorder", Valid: true},
+		SignedAt:          pgtype.Timestamptz{Time: signedAt, Valid: true},
+		SubmittedAt:       pgtype.Timestamptz{Time: submittedAt, Valid: true},
+		AcknowledgedAt:    pgtype.Timestamptz{Time: acknowledgedAt, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("record submission: %v", err)
+	}
+	if !order.SignedAt.Time.Equal(signedAt) || !order.SubmittedAt.Time.Equal(submittedAt) || !order.AcknowledgedAt.Time.Equal(acknowledgedAt) {
+		t.Errorf("timestamps = %v, %v, %v, want %v, %v, %v", order.SignedAt.Time, order.SubmittedAt.Time, order.AcknowledgedAt.Time, signedAt, submittedAt, acknowledgedAt)
+	}
+	if order.FirstStatusChangeAt.Valid {
+		t.Error("the submission set first_status_change_at")
+	}
+
+	// Setting the same status again is not a change.
+	order, err = q.UpdateOrderStatus(ctx, UpdateOrderStatusParams{ID: order.ID, Status: "open"})
+	if err != nil || order.FirstStatusChangeAt.Valid {
+		t.Fatalf("same status: first_status_change_at = %v, %v, want unset", order.FirstStatusChangeAt, err)
+	}
+
+	order, err = q.UpdateOrderStatus(ctx, UpdateOrderStatusParams{ID: order.ID, Status: "filled"})
+	if err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	first := order.FirstStatusChangeAt
+	if !first.Valid || first.Time.Before(order.AcknowledgedAt.Time) {
+		t.Fatalf("first_status_change_at = %v, want set after acknowledged_at %v", first, order.AcknowledgedAt.Time)
+	}
+
+	order, err = q.UpdateOrderStatus(ctx, UpdateOrderStatusParams{ID: order.ID, Status: "cancelled"})
+	if err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	if !order.FirstStatusChangeAt.Time.Equal(first.Time) {
+		t.Errorf("a later change moved first_status_change_at from %v to %v", first.Time, order.FirstStatusChangeAt.Time)
+	}
+}
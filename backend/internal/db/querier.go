@@ -11,17 +11,36 @@ import (
 )
 
 type Querier interface {
+	// @description Atomically marks an unexpired, unused challenge as consumed and returns it.
+	// Returns no rows if the challenge does not exist, has expired, or was already used.
+	ConsumeWalletChallenge(ctx context.Context, arg ConsumeWalletChallengeParams) (WalletChallenge, error)
+	// @description Counts bars under the source market ID that already have a bar with the same
+	// time and resolution under the target market ID (i.e., bars that would be merged by a re-key).
+	CountMarketPriceHistoryRekeyCollisions(ctx context.Context, arg CountMarketPriceHistoryRekeyCollisionsParams) (int64, error)
+	// @description Counts the OHLCV bars stored under a market ID across all resolutions.
+	CountMarketPriceHistoryRows(ctx context.Context, marketID string) (int64, error)
+	// @description Counts a user's orders with the same filters as ListOrdersByUserID.
+	CountOrdersByUserID(ctx context.Context, arg CountOrdersByUserIDParams) (int64, error)
 	// @description Creates a new order in the database with status 'pending'.
 	// This is called when an order is first placed, before it's submitted to Polymarket.
 	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	// @description Records a fill of one of a user's orders.
+	// Fills are keyed by their Polymarket trade ID, so recording the same fill again is a no-op.
+	CreateTrade(ctx context.Context, arg CreateTradeParams) error
 	// @description Creates a new user in the database.
 	// This is typically called after a 'user.created' webhook event from Clerk.
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	// @description Associates a new Polymarket funder address with a user.
 	CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error)
-	// @description Retrieves the active wallet for a given user.
+	// @description Stores a new single-use nonce challenge for a wallet.
+	CreateWalletChallenge(ctx context.Context, arg CreateWalletChallengeParams) (WalletChallenge, error)
+	// @description Retrieves the active, ownership-verified wallet for a given user.
 	// This is used to fetch the signer_secret_ref needed for transaction signing.
+	// Unverified wallets are never returned, so they cannot be used for trading.
 	GetActiveWalletByUserID(ctx context.Context, userID pgtype.UUID) (Wallet, error)
+	// @description Retrieves the most recent OHLCV bar of a market at the given resolution.
+	// Used as the fallback of the cached last price.
+	GetLatestMarketPriceBar(ctx context.Context, arg GetLatestMarketPriceBarParams) (MarketPriceHistory, error)
 	// @description Retrieves historical OHLCV data for a given market within a time range and resolution.
 	// The data is ordered by time ascending and filtered by resolution.
 	// @param market_id The market ID to fetch data for.
@@ -40,6 +59,8 @@ type Querier interface {
 	// @description Retrieves a single user from the database based on their unique Clerk User ID.
 	// This will be used frequently in authentication middleware to identify the requesting user.
 	GetUserByClerkID(ctx context.Context, clerkUserID string) (User, error)
+	// @description Retrieves a wallet by its ID, scoped to the owning user.
+	GetWalletByIDAndUserID(ctx context.Context, arg GetWalletByIDAndUserIDParams) (Wallet, error)
 	// @description Inserts a new OHLCV bar into the market_price_history table.
 	// This uses the insert_market_price_history() function which automatically creates partitions.
 	// @param time The timestamp for this bar.
@@ -51,11 +72,51 @@ type Querier interface {
 	// @param volume The trading volume.
 	// @param resolution The resolution/interval (e.g., '1', '5', '15', '60', 'D').
 	InsertMarketPriceHistory(ctx context.Context, arg InsertMarketPriceHistoryParams) error
+	// @description Lists every distinct market_id that has stored OHLCV bars.
+	// Used by the admin backfill to find bars stored under asset IDs instead of condition IDs.
+	ListDistinctMarketPriceHistoryMarketIDs(ctx context.Context) ([]string, error)
+	// @description Lists the distinct market IDs that have a bar of the given resolution starting
+	// at or after the given time. Used by the bar watchdog to find markets whose bars stopped.
+	ListMarketIDsWithBarsSince(ctx context.Context, arg ListMarketIDsWithBarsSinceParams) ([]string, error)
+	// @description Retrieves a page of a user's orders, newest first, optionally filtered by status
+	// and market. A NULL filter matches every order.
+	ListOrdersByUserID(ctx context.Context, arg ListOrdersByUserIDParams) ([]Order, error)
+	// @description Retrieves submitted orders in a given status along with the maker (funder) address
+	// needed to query them on the CLOB. Least recently updated orders are returned first.
+	ListOrdersForSync(ctx context.Context, arg ListOrdersForSyncParams) ([]ListOrdersForSyncRow, error)
+	// @description Retrieves orders in a given status that have not been updated since the cutoff.
+	// Least recently updated orders are returned first.
+	ListStaleOrdersByStatus(ctx context.Context, arg ListStaleOrdersByStatusParams) ([]Order, error)
+	// @description Marks a wallet as ownership-verified after a successful signature check.
+	MarkWalletVerified(ctx context.Context, id pgtype.UUID) (Wallet, error)
+	// @description Records the outcome of submitting an order to Polymarket in one statement: the
+	// status (with the same timestamps as UpdateOrderStatus), the Polymarket order ID, and the
+	// signed order. A NULL order ID or signed order keeps the stored value.
+	// The lifecycle timestamps (signed_at, submitted_at, acknowledged_at) are set once: a stored
+	// value is kept, and a NULL argument leaves them unset (submitted_at then falls back to NOW()
+	// like in UpdateOrderStatus).
+	// The order's event_seq is incremented, so it reflects the commit order of status updates.
+	RecordOrderSubmission(ctx context.Context, arg RecordOrderSubmissionParams) (Order, error)
+	// @description Moves all bars from the source market ID to the target market ID in one statement.
+	// Colliding bars are merged like upsert_market_price_history() merges a re-saved bar: high and
+	// low widen, the earlier bar's open and the later bar's close are kept, and volumes are added.
+	// The moved bar is the earlier one, as bars were stored under the source ID before the mapping
+	// fix, and its trades are not in the existing bar, so their volumes are disjoint.
+	// Partitions already exist because the moved bars keep their original times.
+	RekeyMarketPriceHistory(ctx context.Context, arg RekeyMarketPriceHistoryParams) (int64, error)
 	// @description Updates the Polymarket order ID after the order is submitted to Polymarket.
 	UpdateOrderPolymarketID(ctx context.Context, arg UpdateOrderPolymarketIDParams) (Order, error)
 	// @description Updates the status of an order and sets the appropriate timestamp.
-	// Status can be: 'pending', 'open', 'filled', 'cancelled', 'rejected'
+	// Status can be: 'pending', 'pending_submission', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected'
+	// first_status_change_at is set once, by the first status change of an order already submitted.
 	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error)
+	// @description Saves an OHLCV bar, merging it with a stored bar of the same market, time, and
+	// resolution: the stored open is kept, high and low widen, close is replaced, and volumes are
+	// added. This uses the upsert_market_price_history() function, which creates partitions.
+	UpsertMarketPriceHistory(ctx context.Context, arg UpsertMarketPriceHistoryParams) error
+	// @description Saves several OHLCV bars in one round trip, each like UpsertMarketPriceHistory.
+	// The batch runs in an implicit transaction, so if one bar fails, none of them are saved.
+	UpsertMarketPriceHistoryBars(ctx context.Context, arg []UpsertMarketPriceHistoryBarsParams) *UpsertMarketPriceHistoryBarsBatchResults
 }
 
 var _ Querier = (*Queries)(nil)
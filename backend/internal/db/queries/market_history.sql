@@ -27,6 +27,24 @@ WHERE market_id = $1
   AND resolution = $4
 ORDER BY time ASC;
 
+-- name: GetLatestMarketPriceBar :one
+-- @description Retrieves the most recent OHLCV bar of a market at the given resolution.
+-- Used as the fallback of the cached last price.
+SELECT
+  time,
+  market_id,
+  open,
+  high,
+  low,
+  close,
+  volume,
+  resolution
+FROM market_price_history
+WHERE market_id = sqlc.arg(market_id)
+  AND resolution = sqlc.arg(resolution)
+ORDER BY time DESC
+LIMIT 1;
+
 -- name: InsertMarketPriceHistory :exec
 -- @description Inserts a new OHLCV bar into the market_price_history table.
 -- This uses the insert_market_price_history() function which automatically creates partitions.
@@ -40,3 +58,69 @@ ORDER BY time ASC;
 -- @param resolution The resolution/interval (e.g., '1', '5', '15', '60', 'D').
 SELECT insert_market_price_history($1, $2, $3, $4, $5, $6, $7, $8);
 
+
+-- name: UpsertMarketPriceHistory :exec
+-- @description Saves an OHLCV bar, merging it with a stored bar of the same market, time, and
+-- resolution: the stored open is kept, high and low widen, close is replaced, and volumes are
+-- added. This uses the upsert_market_price_history() function, which creates partitions.
+SELECT upsert_market_price_history($1, $2, $3, $4, $5, $6, $7, $8);
+
+
+-- name: UpsertMarketPriceHistoryBars :batchexec
+-- @description Saves several OHLCV bars in one round trip, each like UpsertMarketPriceHistory.
+-- The batch runs in an implicit transaction, so if one bar fails, none of them are saved.
+SELECT upsert_market_price_history($1, $2, $3, $4, $5, $6, $7, $8);
+
+
+-- name: ListDistinctMarketPriceHistoryMarketIDs :many
+-- @description Lists every distinct market_id that has stored OHLCV bars.
+-- Used by the admin backfill to find bars stored under asset IDs instead of condition IDs.
+SELECT DISTINCT market_id
+FROM market_price_history
+ORDER BY market_id;
+
+-- name: ListMarketIDsWithBarsSince :many
+-- @description Lists the distinct market IDs that have a bar of the given resolution starting
+-- at or after the given time. Used by the bar watchdog to find markets whose bars stopped.
+SELECT DISTINCT market_id
+FROM market_price_history
+WHERE resolution = sqlc.arg(resolution)
+  AND time >= sqlc.arg(since);
+
+-- name: CountMarketPriceHistoryRows :one
+-- @description Counts the OHLCV bars stored under a market ID across all resolutions.
+SELECT COUNT(*)
+FROM market_price_history
+WHERE market_id = $1;
+
+-- name: CountMarketPriceHistoryRekeyCollisions :one
+-- @description Counts bars under the source market ID that already have a bar with the same
+-- time and resolution under the target market ID (i.e., bars that would be merged by a re-key).
+SELECT COUNT(*)
+FROM market_price_history src
+JOIN market_price_history dst
+  ON dst.market_id = sqlc.arg(target_market_id)
+  AND dst.time = src.time
+  AND dst.resolution = src.resolution
+WHERE src.market_id = sqlc.arg(source_market_id);
+
+-- name: RekeyMarketPriceHistory :execrows
+-- @description Moves all bars from the source market ID to the target market ID in one statement.
+-- Colliding bars are merged like upsert_market_price_history() merges a re-saved bar: high and
+-- low widen, the earlier bar's open and the later bar's close are kept, and volumes are added.
+-- The moved bar is the earlier one, as bars were stored under the source ID before the mapping
+-- fix, and its trades are not in the existing bar, so their volumes are disjoint.
+-- Partitions already exist because the moved bars keep their original times.
+WITH moved AS (
+  DELETE FROM market_price_history
+  WHERE market_id = sqlc.arg(source_market_id)
+  RETURNING time, open, high, low, close, volume, resolution
+)
+INSERT INTO market_price_history (time, market_id, open, high, low, close, volume, resolution)
+SELECT time, sqlc.arg(target_market_id)::varchar, open, high, low, close, volume, resolution
+FROM moved
+ON CONFLICT (market_id, time, resolution) DO UPDATE SET
+  open = EXCLUDED.open,
+  high = GREATEST(market_price_history.high, EXCLUDED.high),
+  low = LEAST(market_price_history.low, EXCLUDED.low),
+  volume = market_price_history.volume + EXCLUDED.volume;
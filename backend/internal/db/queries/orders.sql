@@ -15,22 +15,27 @@ INSERT INTO orders (
   size,
   price,
   status,
-  signed_order
+  signed_order,
+  taker
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
 RETURNING *;
 
 -- name: UpdateOrderStatus :one
 -- @description Updates the status of an order and sets the appropriate timestamp.
--- Status can be: 'pending', 'open', 'filled', 'cancelled', 'rejected'
+-- Status can be: 'pending', 'pending_submission', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected'
+-- first_status_change_at is set once, by the first status change of an order already submitted.
+-- The order's event_seq is incremented, so it reflects the commit order of status updates.
 UPDATE orders
 SET 
   status = $2,
   updated_at = NOW(),
-  submitted_at = CASE WHEN $2 = 'open' AND submitted_at IS NULL THEN NOW() ELSE submitted_at END,
+  submitted_at = CASE WHEN $2 IN ('open', 'delayed', 'filled') AND submitted_at IS NULL THEN NOW() ELSE submitted_at END,
   filled_at = CASE WHEN $2 = 'filled' AND filled_at IS NULL THEN NOW() ELSE filled_at END,
-  cancelled_at = CASE WHEN $2 = 'cancelled' AND cancelled_at IS NULL THEN NOW() ELSE cancelled_at END
+  cancelled_at = CASE WHEN $2 IN ('cancelled', 'expired') AND cancelled_at IS NULL THEN NOW() ELSE cancelled_at END,
+  first_status_change_at = CASE WHEN status <> $2 AND submitted_at IS NOT NULL AND first_status_change_at IS NULL THEN NOW() ELSE first_status_change_at END,
+  event_seq = event_seq + 1
 WHERE id = $1
 RETURNING *;
 
@@ -43,6 +48,29 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- name: RecordOrderSubmission :one
+-- @description Records the outcome of submitting an order to Polymarket in one statement: the
+-- status (with the same timestamps as UpdateOrderStatus), the Polymarket order ID, and the
+-- signed order. A NULL order ID or signed order keeps the stored value.
+-- The lifecycle timestamps (signed_at, submitted_at, acknowledged_at) are set once: a stored
+-- value is kept, and a NULL argument leaves them unset (submitted_at then falls back to NOW()
+-- like in UpdateOrderStatus).
+-- The order's event_seq is incremented, so it reflects the commit order of status updates.
+UPDATE orders
+SET 
+  status = sqlc.arg(status),
+  polymarket_order_id = COALESCE(sqlc.narg(polymarket_order_id), polymarket_order_id),
+  signed_order = COALESCE(sqlc.narg(signed_order), signed_order),
+  signed_at = COALESCE(signed_at, sqlc.narg(signed_at)),
+  updated_at = NOW(),
+  submitted_at = COALESCE(submitted_at, sqlc.narg(submitted_at), CASE WHEN sqlc.arg(status) IN ('open', 'delayed', 'filled') THEN NOW() END),
+  acknowledged_at = COALESCE(acknowledged_at, sqlc.narg(acknowledged_at)),
+  filled_at = CASE WHEN sqlc.arg(status) = 'filled' AND filled_at IS NULL THEN NOW() ELSE filled_at END,
+  cancelled_at = CASE WHEN sqlc.arg(status) IN ('cancelled', 'expired') AND cancelled_at IS NULL THEN NOW() ELSE cancelled_at END,
+  event_seq = event_seq + 1
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: GetOrderByID :one
 -- @description Retrieves a single order by its ID.
 SELECT * FROM orders
@@ -67,3 +95,38 @@ SELECT * FROM orders
 WHERE market_id = $1
 ORDER BY created_at DESC;
 
+
+-- name: ListOrdersForSync :many
+-- @description Retrieves submitted orders in a given status along with the maker (funder) address
+-- needed to query them on the CLOB. Least recently updated orders are returned first.
+SELECT o.*, w.polymarket_funder_address
+FROM orders o
+JOIN wallets w ON w.user_id = o.user_id AND w.is_active = TRUE AND w.verified_at IS NOT NULL
+WHERE o.status = $1 AND o.polymarket_order_id IS NOT NULL
+ORDER BY o.updated_at ASC
+LIMIT $2;
+
+-- name: ListStaleOrdersByStatus :many
+-- @description Retrieves orders in a given status that have not been updated since the cutoff.
+-- Least recently updated orders are returned first.
+SELECT * FROM orders
+WHERE status = $1 AND updated_at < $2
+ORDER BY updated_at ASC
+LIMIT $3;
+
+-- name: ListOrdersByUserID :many
+-- @description Retrieves a page of a user's orders, newest first, optionally filtered by status
+-- and market. A NULL filter matches every order.
+SELECT * FROM orders
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(market_id)::text IS NULL OR market_id = sqlc.narg(market_id)::text)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountOrdersByUserID :one
+-- @description Counts a user's orders with the same filters as ListOrdersByUserID.
+SELECT COUNT(*) FROM orders
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(market_id)::text IS NULL OR market_id = sqlc.narg(market_id)::text);
@@ -0,0 +1,22 @@
+/**
+ * @description
+ * This file contains all the SQL queries for interacting with the 'trades' table.
+ * These queries are used by sqlc to generate type-safe Go code for our database access layer.
+ */
+
+-- name: CreateTrade :exec
+-- @description Records a fill of one of a user's orders.
+-- Fills are keyed by their Polymarket trade ID, so recording the same fill again is a no-op.
+INSERT INTO trades (
+  user_id,
+  order_id,
+  market_id,
+  polymarket_trade_id,
+  side,
+  size,
+  price,
+  executed_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (polymarket_trade_id) DO NOTHING;
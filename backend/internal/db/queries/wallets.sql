@@ -9,16 +9,51 @@
 INSERT INTO wallets (
   user_id,
   polymarket_funder_address,
-  signer_secret_ref
+  signer_secret_ref,
+  signature_type
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
 RETURNING *;
 
 -- name: GetActiveWalletByUserID :one
--- @description Retrieves the active wallet for a given user.
+-- @description Retrieves the active, ownership-verified wallet for a given user.
 -- This is used to fetch the signer_secret_ref needed for transaction signing.
+-- Unverified wallets are never returned, so they cannot be used for trading.
 SELECT * FROM wallets
-WHERE user_id = $1 AND is_active = TRUE
+WHERE user_id = $1 AND is_active = TRUE AND verified_at IS NOT NULL
 LIMIT 1;
 
+
+-- name: GetWalletByIDAndUserID :one
+-- @description Retrieves a wallet by its ID, scoped to the owning user.
+SELECT * FROM wallets
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: MarkWalletVerified :one
+-- @description Marks a wallet as ownership-verified after a successful signature check.
+UPDATE wallets
+SET verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateWalletChallenge :one
+-- @description Stores a new single-use nonce challenge for a wallet.
+INSERT INTO wallet_challenges (
+  wallet_id,
+  nonce,
+  message,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ConsumeWalletChallenge :one
+-- @description Atomically marks an unexpired, unused challenge as consumed and returns it.
+-- Returns no rows if the challenge does not exist, has expired, or was already used.
+UPDATE wallet_challenges
+SET consumed_at = NOW()
+WHERE wallet_id = $1 AND nonce = $2 AND consumed_at IS NULL AND expires_at > NOW()
+RETURNING *;
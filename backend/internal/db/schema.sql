@@ -16,6 +16,7 @@
  * 
  * ✅ USE the wrapper functions instead (they auto-create partitions):
  *    - insert_market_price_history(...)
+ *    - upsert_market_price_history(...)
  *    - insert_market_sentiment_history(...)
  * 
  * See the wrapper function definitions below for usage examples.
@@ -23,6 +24,7 @@
  * Tables:
  * - users: Stores user profile information, linking back to their Clerk authentication ID.
  * - wallets: Manages user's Polymarket funder addresses and references to their secure signing keys.
+ * - wallet_challenges: Single-use nonces used to verify ownership of a wallet's funder address.
  * - trades: A log of all trades executed by users through the platform.
  * - market_price_history: A native PostgreSQL partitioned table for storing OHLCV (Open, High, Low, Close, Volume) data.
  * - market_sentiment_history: A native PostgreSQL partitioned table for storing aggregated sentiment scores and key drivers.
@@ -53,10 +55,28 @@ CREATE TABLE wallets (
     signer_secret_ref VARCHAR(255) NOT NULL,
     is_active BOOLEAN NOT NULL DEFAULT TRUE,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Set once the user proves control of the funder address by signing a challenge.
+    -- Only verified wallets may be used for trading.
+    verified_at TIMESTAMPTZ,
+    -- How orders of this wallet are signed: 0 = EOA, 1 = Polymarket proxy (email), 2 = Gnosis Safe.
+    signature_type SMALLINT NOT NULL DEFAULT 1 CHECK (signature_type IN (0, 1, 2))
 );
 CREATE INDEX idx_wallets_user_id ON wallets(user_id);
 
+-- Table: wallet_challenges
+-- Single-use, expiring nonces that a user signs (EIP-191) to prove ownership of a wallet.
+CREATE TABLE wallet_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    nonce VARCHAR(64) UNIQUE NOT NULL,
+    message TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ, -- Set when the challenge is used; a consumed challenge can never be reused
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_wallet_challenges_wallet_id ON wallet_challenges(wallet_id);
+
 -- Table: orders
 -- Records all placed orders (pending, filled, cancelled) for tracking and order management.
 CREATE TABLE orders (
@@ -68,13 +88,18 @@ CREATE TABLE orders (
     side VARCHAR(4) NOT NULL CHECK (side IN ('BUY', 'SELL')),
     size DECIMAL NOT NULL,
     price DECIMAL NOT NULL,
-    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'open', 'filled', 'cancelled', 'rejected')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'pending_submission', 'open', 'delayed', 'filled', 'cancelled', 'expired', 'rejected')),
     signed_order JSONB, -- Store the full signed order JSON for reference
-    submitted_at TIMESTAMPTZ, -- When order was submitted to Polymarket
+    submitted_at TIMESTAMPTZ, -- When the submission the CLOB answered was sent to Polymarket
     filled_at TIMESTAMPTZ, -- When order was filled (if applicable)
     cancelled_at TIMESTAMPTZ, -- When order was cancelled (if applicable)
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    event_seq BIGINT NOT NULL DEFAULT 0, -- Incremented on every status update; orders order_update events
+    taker VARCHAR(42) NOT NULL DEFAULT '0x0000000000000000000000000000000000000000', -- Counterparty of a directed order; the zero address for public orders
+    signed_at TIMESTAMPTZ, -- When the remote signer returned the order's signature
+    acknowledged_at TIMESTAMPTZ, -- When the CLOB answered the submission (accepted or rejected)
+    first_status_change_at TIMESTAMPTZ -- When the status first changed after the submission (e.g. the order sync found it filled)
 );
 CREATE INDEX idx_orders_user_id ON orders(user_id);
 CREATE INDEX idx_orders_market_id ON orders(market_id);
@@ -296,6 +321,41 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+/**
+ * @description
+ * Wrapper function to insert into market_price_history with automatic partition creation,
+ * merging a bar saved again for the same market_id, time, and resolution instead of
+ * replacing it: the stored open is kept, high and low widen, close is replaced, and volumes
+ * are added (saves carry the volume traded since the bar's previous save).
+ *
+ * Example:
+ *   SELECT upsert_market_price_history(NOW(), 'market-123', 1.0, 1.1, 0.9, 1.0, 100.0, '1');
+ */
+CREATE OR REPLACE FUNCTION upsert_market_price_history(
+    p_time TIMESTAMPTZ,
+    p_market_id VARCHAR(255),
+    p_open DECIMAL,
+    p_high DECIMAL,
+    p_low DECIMAL,
+    p_close DECIMAL,
+    p_volume DECIMAL,
+    p_resolution VARCHAR(10) DEFAULT '15'
+)
+RETURNS VOID AS $$
+BEGIN
+    -- Ensure partition exists
+    PERFORM ensure_market_price_history_partition(p_time);
+
+    INSERT INTO market_price_history (time, market_id, open, high, low, close, volume, resolution)
+    VALUES (p_time, p_market_id, p_open, p_high, p_low, p_close, p_volume, p_resolution)
+    ON CONFLICT (market_id, time, resolution) DO UPDATE SET
+        high = GREATEST(market_price_history.high, EXCLUDED.high),
+        low = LEAST(market_price_history.low, EXCLUDED.low),
+        close = EXCLUDED.close,
+        volume = market_price_history.volume + EXCLUDED.volume;
+END;
+$$ LANGUAGE plpgsql;
+
 /**
  * @description
  * Wrapper function to insert into market_sentiment_history with automatic
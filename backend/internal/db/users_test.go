@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TestCreateUserUniqueViolations checks the constraint named by each unique violation of
+// CreateUser; services.UserService.CreateUser tells them apart by these names.
+func TestCreateUserUniqueViolations(t *testing.T) {
+	q, _ := newTestQueries(t)
+	ctx := context.Background()
+	if _, err := q.CreateUser(ctx, CreateUserParams{ClerkUserID: "user_1", Email: "trader@example.com"}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		params         CreateUserParams
+		wantConstraint string
+	}{
+		{"same clerk ID", CreateUserParams{ClerkUserID: "user_1", Email: "other@example.com"}, "users_clerk_user_id_key"},
+		{"same email", CreateUserParams{ClerkUserID: "user_2", Email: "trader@example.com"}, "users_email_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := q.CreateUser(ctx, tt.params)
+			var pgErr *pgconn.PgError
+			if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+				t.Fatalf("CreateUser error = %v, want a unique violation", err)
+			}
+			if pgErr.ConstraintName != tt.wantConstraint {
+				t.Errorf("violated constraint = %s, want %s", pgErr.ConstraintName, tt.wantConstraint)
+			}
+		})
+	}
+}
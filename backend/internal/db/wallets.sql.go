@@ -11,6 +11,35 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const consumeWalletChallenge = `-- name: ConsumeWalletChallenge :one
+UPDATE wallet_challenges
+SET consumed_at = NOW()
+WHERE wallet_id = $1 AND nonce = $2 AND consumed_at IS NULL AND expires_at > NOW()
+RETURNING id, wallet_id, nonce, message, expires_at, consumed_at, created_at
+`
+
+type ConsumeWalletChallengeParams struct {
+	WalletID pgtype.UUID `json:"wallet_id"`
+	Nonce    string      `json:"nonce"`
+}
+
+// @description Atomically marks an unexpired, unused challenge as consumed and returns it.
+// Returns no rows if the challenge does not exist, has expired, or was already used.
+func (q *Queries) ConsumeWalletChallenge(ctx context.Context, arg ConsumeWalletChallengeParams) (WalletChallenge, error) {
+	row := q.db.QueryRow(ctx, consumeWalletChallenge, arg.WalletID, arg.Nonce)
+	var i WalletChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.WalletID,
+		&i.Nonce,
+		&i.Message,
+		&i.ExpiresAt,
+		&i.ConsumedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createWallet = `-- name: CreateWallet :one
 /**
  * @description
@@ -21,22 +50,29 @@ const createWallet = `-- name: CreateWallet :one
 INSERT INTO wallets (
   user_id,
   polymarket_funder_address,
-  signer_secret_ref
+  signer_secret_ref,
+  signature_type
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
-RETURNING id, user_id, polymarket_funder_address, signer_secret_ref, is_active, created_at, updated_at
+RETURNING id, user_id, polymarket_funder_address, signer_secret_ref, is_active, created_at, updated_at, verified_at, signature_type
 `
 
 type CreateWalletParams struct {
 	UserID                  pgtype.UUID `json:"user_id"`
 	PolymarketFunderAddress string      `json:"polymarket_funder_address"`
 	SignerSecretRef         string      `json:"signer_secret_ref"`
+	SignatureType           int16       `json:"signature_type"`
 }
 
 // @description Associates a new Polymarket funder address with a user.
 func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error) {
-	row := q.db.QueryRow(ctx, createWallet, arg.UserID, arg.PolymarketFunderAddress, arg.SignerSecretRef)
+	row := q.db.QueryRow(ctx, createWallet,
+		arg.UserID,
+		arg.PolymarketFunderAddress,
+		arg.SignerSecretRef,
+		arg.SignatureType,
+	)
 	var i Wallet
 	err := row.Scan(
 		&i.ID,
@@ -46,18 +82,61 @@ func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wal
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.SignatureType,
+	)
+	return i, err
+}
+
+const createWalletChallenge = `-- name: CreateWalletChallenge :one
+INSERT INTO wallet_challenges (
+  wallet_id,
+  nonce,
+  message,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, wallet_id, nonce, message, expires_at, consumed_at, created_at
+`
+
+type CreateWalletChallengeParams struct {
+	WalletID  pgtype.UUID        `json:"wallet_id"`
+	Nonce     string             `json:"nonce"`
+	Message   string             `json:"message"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+// @description Stores a new single-use nonce challenge for a wallet.
+func (q *Queries) CreateWalletChallenge(ctx context.Context, arg CreateWalletChallengeParams) (WalletChallenge, error) {
+	row := q.db.QueryRow(ctx, createWalletChallenge,
+		arg.WalletID,
+		arg.Nonce,
+		arg.Message,
+		arg.ExpiresAt,
+	)
+	var i WalletChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.WalletID,
+		&i.Nonce,
+		&i.Message,
+		&i.ExpiresAt,
+		&i.ConsumedAt,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getActiveWalletByUserID = `-- name: GetActiveWalletByUserID :one
-SELECT id, user_id, polymarket_funder_address, signer_secret_ref, is_active, created_at, updated_at FROM wallets
-WHERE user_id = $1 AND is_active = TRUE
+SELECT id, user_id, polymarket_funder_address, signer_secret_ref, is_active, created_at, updated_at, verified_at, signature_type FROM wallets
+WHERE user_id = $1 AND is_active = TRUE AND verified_at IS NOT NULL
 LIMIT 1
 `
 
-// @description Retrieves the active wallet for a given user.
+// @description Retrieves the active, ownership-verified wallet for a given user.
 // This is used to fetch the signer_secret_ref needed for transaction signing.
+// Unverified wallets are never returned, so they cannot be used for trading.
 func (q *Queries) GetActiveWalletByUserID(ctx context.Context, userID pgtype.UUID) (Wallet, error) {
 	row := q.db.QueryRow(ctx, getActiveWalletByUserID, userID)
 	var i Wallet
@@ -69,6 +148,62 @@ func (q *Queries) GetActiveWalletByUserID(ctx context.Context, userID pgtype.UUI
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.SignatureType,
+	)
+	return i, err
+}
+
+const getWalletByIDAndUserID = `-- name: GetWalletByIDAndUserID :one
+SELECT id, user_id, polymarket_funder_address, signer_secret_ref, is_active, created_at, updated_at, verified_at, signature_type FROM wallets
+WHERE id = $1 AND user_id = $2
+LIMIT 1
+`
+
+type GetWalletByIDAndUserIDParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// @description Retrieves a wallet by its ID, scoped to the owning user.
+func (q *Queries) GetWalletByIDAndUserID(ctx context.Context, arg GetWalletByIDAndUserIDParams) (Wallet, error) {
+	row := q.db.QueryRow(ctx, getWalletByIDAndUserID, arg.ID, arg.UserID)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PolymarketFunderAddress,
+		&i.SignerSecretRef,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.SignatureType,
+	)
+	return i, err
+}
+
+const markWalletVerified = `-- name: MarkWalletVerified :one
+UPDATE wallets
+SET verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, polymarket_funder_address, signer_secret_ref, is_active, created_at, updated_at, verified_at, signature_type
+`
+
+// @description Marks a wallet as ownership-verified after a successful signature check.
+func (q *Queries) MarkWalletVerified(ctx context.Context, id pgtype.UUID) (Wallet, error) {
+	row := q.db.QueryRow(ctx, markWalletVerified, id)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PolymarketFunderAddress,
+		&i.SignerSecretRef,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.SignatureType,
 	)
 	return i, err
 }
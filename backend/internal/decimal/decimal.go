@@ -0,0 +1,259 @@
+/**
+ * @description
+ * This package implements a minimal fixed-point decimal type for order math. Values are
+ * held as an int64 number of micro-units (6 decimals), the precision of both USDC and
+ * Polymarket's conditional tokens, so prices, sizes, and amounts convert to the integer
+ * amounts of signed orders without binary floating point rounding surprises.
+ *
+ * Key features:
+ * - Exact Parsing: `Parse` reads decimal strings exactly and rejects excess precision;
+ *   `FromFloat` goes through the shortest decimal representation of a float64, so 0.1
+ *   becomes exactly 0.1 rather than 0.1000000000000000055...
+ * - Explicit Rounding: Multiplication and conversions that can lose precision take a
+ *   `RoundingMode`.
+ * - Overflow Checks: Results that do not fit in an int64 are reported as errors.
+ *
+ * @notes
+ * - Products are computed exactly with math/big before rounding back to 6 decimals.
+ */
+
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places of a Decimal.
+const Scale = 6
+
+// unit is the number of micro-units in one.
+const unit = 1_000_000
+
+var (
+	// ErrSyntax is returned when a string is not a decimal number.
+	ErrSyntax = errors.New("invalid decimal")
+	// ErrPrecision is returned when a value has more than Scale decimal places.
+	ErrPrecision = errors.New("decimal has more than 6 decimal places")
+	// ErrOverflow is returned when a value does not fit in a Decimal.
+	ErrOverflow = errors.New("decimal overflow")
+)
+
+// RoundingMode selects how results are rounded to Scale decimal places.
+type RoundingMode int
+
+const (
+	// RoundDown rounds toward zero (truncation).
+	RoundDown RoundingMode = iota
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundHalfUp rounds to the nearest value, and ties away from zero.
+	RoundHalfUp
+)
+
+// Decimal is a fixed-point number with Scale decimal places.
+type Decimal struct {
+	micros int64
+}
+
+// Zero is the zero Decimal.
+var Zero = Decimal{}
+
+// FromMicros returns the Decimal of the given number of micro-units.
+func FromMicros(micros int64) Decimal {
+	return Decimal{micros: micros}
+}
+
+// FromInt returns the Decimal of an integer.
+func FromInt(n int64) (Decimal, error) {
+	if n > math.MaxInt64/unit || n < math.MinInt64/unit {
+		return Zero, ErrOverflow
+	}
+	return Decimal{micros: n * unit}, nil
+}
+
+/**
+ * @description
+ * Parse reads a decimal string such as "12", "-0.5", or "0.000001" exactly.
+ *
+ * @param s The decimal string; exponents are not accepted.
+ * @returns The Decimal, or ErrSyntax, ErrPrecision (more than 6 significant decimal places),
+ *   or ErrOverflow.
+ */
+func Parse(s string) (Decimal, error) {
+	return parse(s, nil)
+}
+
+/**
+ * @description
+ * FromFloat converts a float64 through its shortest decimal representation, rounding to
+ * Scale decimal places with the given mode.
+ *
+ * @param f The value to convert.
+ * @param mode How digits beyond Scale are rounded.
+ * @returns The Decimal, or an error for NaN, infinities, and values out of range.
+ */
+func FromFloat(f float64, mode RoundingMode) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Zero, fmt.Errorf("%w: %v", ErrSyntax, f)
+	}
+	return parse(strconv.FormatFloat(f, 'f', -1, 64), &mode)
+}
+
+// parse reads a decimal string, rounding excess decimal places when mode is set.
+func parse(s string, mode *RoundingMode) (Decimal, error) {
+	negative := false
+	body := s
+	if strings.HasPrefix(body, "-") || strings.HasPrefix(body, "+") {
+		negative = body[0] == '-'
+		body = body[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(body, ".")
+	if (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Zero, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+
+	var rest string
+	if len(fracPart) > Scale {
+		fracPart, rest = fracPart[:Scale], strings.TrimRight(fracPart[Scale:], "0")
+		if rest != "" && mode == nil {
+			return Zero, fmt.Errorf("%w: %q", ErrPrecision, s)
+		}
+	}
+	fracPart += strings.Repeat("0", Scale-len(fracPart))
+
+	micros, ok := new(big.Int).SetString(strings.TrimLeft(intPart, "0")+fracPart, 10)
+	if !ok {
+		return Zero, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+	if rest != "" {
+		// Round the truncated digits: up for RoundUp, and for RoundHalfUp when the first dropped digit is 5 or more.
+		if *mode == RoundUp || (*mode == RoundHalfUp && rest[0] >= '5') {
+			micros.Add(micros, big.NewInt(1))
+		}
+	}
+	if negative {
+		micros.Neg(micros)
+	}
+	return fromBig(micros)
+}
+
+// isDigits reports whether s consists of ASCII digits only (or is empty).
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// fromBig converts a number of micro-units to a Decimal, checking for overflow.
+func fromBig(micros *big.Int) (Decimal, error) {
+	if !micros.IsInt64() {
+		return Zero, ErrOverflow
+	}
+	return Decimal{micros: micros.Int64()}, nil
+}
+
+// Micros returns the value as an integer number of micro-units, i.e. the 6-decimal
+// integer amount Polymarket expects.
+func (d Decimal) Micros() int64 {
+	return d.micros
+}
+
+// Sign returns -1, 0, or 1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	switch {
+	case d.micros < 0:
+		return -1
+	case d.micros > 0:
+		return 1
+	}
+	return 0
+}
+
+// Cmp compares d and other, returning -1, 0, or 1.
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.micros < other.micros:
+		return -1
+	case d.micros > other.micros:
+		return 1
+	}
+	return 0
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) (Decimal, error) {
+	sum := d.micros + other.micros
+	if (other.micros > 0 && sum < d.micros) || (other.micros < 0 && sum > d.micros) {
+		return Zero, ErrOverflow
+	}
+	return Decimal{micros: sum}, nil
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) (Decimal, error) {
+	if other.micros == math.MinInt64 {
+		return Zero, ErrOverflow
+	}
+	return d.Add(Decimal{micros: -other.micros})
+}
+
+/**
+ * @description
+ * Mul returns d × other, computed exactly and rounded to Scale decimal places.
+ *
+ * @param other The multiplier.
+ * @param mode How the exact product is rounded.
+ * @returns The rounded product, or ErrOverflow.
+ */
+func (d Decimal) Mul(other Decimal, mode RoundingMode) (Decimal, error) {
+	product := new(big.Int).Mul(big.NewInt(d.micros), big.NewInt(other.micros))
+	return fromBig(divRound(product, big.NewInt(unit), mode))
+}
+
+// divRound returns n / m (m > 0) rounded with the given mode.
+func divRound(n, m *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(n, m, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	roundAway := false
+	switch mode {
+	case RoundUp:
+		roundAway = true
+	case RoundHalfUp:
+		doubled := new(big.Int).Abs(remainder)
+		doubled.Lsh(doubled, 1)
+		roundAway = doubled.Cmp(m) >= 0
+	}
+	if roundAway {
+		quotient.Add(quotient, big.NewInt(int64(n.Sign())))
+	}
+	return quotient
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d with exactly Scale decimal places, e.g. "0.500000".
+func (d Decimal) String() string {
+	magnitude := new(big.Int).Abs(big.NewInt(d.micros)).String()
+	if len(magnitude) <= Scale {
+		magnitude = strings.Repeat("0", Scale-len(magnitude)+1) + magnitude
+	}
+	sign := ""
+	if d.micros < 0 {
+		sign = "-"
+	}
+	return sign + magnitude[:len(magnitude)-Scale] + "." + magnitude[len(magnitude)-Scale:]
+}
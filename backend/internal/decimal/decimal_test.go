@@ -0,0 +1,225 @@
+package decimal
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// ticks are Polymarket's tick sizes.
+var ticks = []string{"0.1", "0.01", "0.001", "0.0001"}
+
+// ratMicros returns r in micro-units, rounded with the given mode, as the reference for Decimal.
+func ratMicros(r *big.Rat, mode RoundingMode) *big.Int {
+	scaled := new(big.Rat).Mul(r, big.NewRat(unit, 1))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	away := false
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundHalfUp:
+		fraction := new(big.Rat).SetFrac(new(big.Int).Abs(remainder), scaled.Denom())
+		away = fraction.Cmp(big.NewRat(1, 2)) >= 0
+	}
+	if away {
+		quotient.Add(quotient, big.NewInt(int64(scaled.Sign())))
+	}
+	return quotient
+}
+
+// ratOf returns the exact value of a decimal string.
+func ratOf(t *testing.T, s string) *big.Rat {
+	t.Helper()
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		t.Fatalf("big.Rat cannot parse %q", s)
+	}
+	return r
+}
+
+// randomPrice returns a price on a random tick, strictly between 0 and 1.
+func randomPrice(rng *rand.Rand) string {
+	tick := ticks[rng.Intn(len(ticks))]
+	steps := int64(math.Round(1 / mustParseFloat(tick)))
+	multiple := big.NewRat(1+rng.Int63n(steps-1), 1)
+	return new(big.Rat).Mul(multiple, mustRat(tick)).FloatString(len(tick) - 2)
+}
+
+// randomSize returns a size of up to a million shares with up to 6 decimal places.
+func randomSize(rng *rand.Rand) string {
+	micros := 1 + rng.Int63n(1_000_000*unit)
+	return new(big.Rat).SetFrac64(micros, unit).FloatString(rng.Intn(Scale + 1))
+}
+
+func mustParseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func mustRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic(s)
+	}
+	return r
+}
+
+func TestParseMatchesRat(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		for _, s := range []string{randomPrice(rng), randomSize(rng)} {
+			d, err := Parse(s)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", s, err)
+			}
+			if want := ratMicros(ratOf(t, s), RoundDown); d.Micros() != want.Int64() {
+				t.Fatalf("Parse(%q) = %d micros, want %s", s, d.Micros(), want)
+			}
+			if got := ratOf(t, d.String()); got.Cmp(ratOf(t, s)) != 0 {
+				t.Fatalf("Parse(%q).String() = %q, not the same value", s, d.String())
+			}
+		}
+	}
+}
+
+func TestMulMatchesRat(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 5000; i++ {
+		price, size := randomPrice(rng), randomSize(rng)
+		p, err := Parse(price)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", price, err)
+		}
+		s, err := Parse(size)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", size, err)
+		}
+		exact := new(big.Rat).Mul(ratOf(t, price), ratOf(t, size))
+		for _, mode := range []RoundingMode{RoundDown, RoundUp, RoundHalfUp} {
+			product, err := s.Mul(p, mode)
+			if err != nil {
+				t.Fatalf("%s × %s: %v", size, price, err)
+			}
+			if want := ratMicros(exact, mode); product.Micros() != want.Int64() {
+				t.Fatalf("%s × %s (mode %d) = %d micros, want %s", size, price, mode, product.Micros(), want)
+			}
+		}
+	}
+}
+
+func TestFromFloatMatchesRat(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 5000; i++ {
+		// Prices computed in floating point, as clients send them, e.g. 0.57 or 0.1 + 0.2.
+		f := mustParseFloat(randomPrice(rng))
+		if i%2 == 0 {
+			f *= mustParseFloat(randomSize(rng))
+		}
+		shortest := strconv.FormatFloat(f, 'f', -1, 64)
+		for _, mode := range []RoundingMode{RoundDown, RoundUp, RoundHalfUp} {
+			d, err := FromFloat(f, mode)
+			if err != nil {
+				t.Fatalf("FromFloat(%v): %v", f, err)
+			}
+			if want := ratMicros(ratOf(t, shortest), mode); d.Micros() != want.Int64() {
+				t.Fatalf("FromFloat(%v, %d) = %d micros, want %s", f, mode, d.Micros(), want)
+			}
+		}
+	}
+}
+
+func TestRoundingAtTickBoundaries(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		mode  RoundingMode
+		want  int64
+	}{
+		{"tick price is exact", 0.57, RoundDown, 570_000},
+		{"smallest tick is exact", 0.0001, RoundUp, 100},
+		{"float sum on a tick", 0.1 + 0.2, RoundHalfUp, 300_000},
+		{"size 0.3 is exact", 0.3, RoundDown, 300_000},
+		{"just below a micro rounds down", 0.0000019, RoundDown, 1},
+		{"just below a micro rounds up", 0.0000011, RoundUp, 2},
+		{"half micro rounds half up", 0.0000005, RoundHalfUp, 1},
+		{"below half micro rounds half up to zero", 0.0000004999, RoundHalfUp, 0},
+		{"negative half micro rounds away from zero", -0.0000005, RoundHalfUp, -1},
+		{"negative rounds down toward zero", -0.0000019, RoundDown, -1},
+		{"negative rounds up away from zero", -0.0000011, RoundUp, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := FromFloat(tt.value, tt.mode)
+			if err != nil {
+				t.Fatalf("FromFloat(%v): %v", tt.value, err)
+			}
+			if d.Micros() != tt.want {
+				t.Errorf("FromFloat(%v, %d) = %d micros, want %d", tt.value, tt.mode, d.Micros(), tt.want)
+			}
+		})
+	}
+
+	// A product exactly half a micro-unit away from a boundary: 0.5 × 0.000001 = 0.0000005.
+	half, one := FromMicros(500_000), FromMicros(1)
+	for mode, want := range map[RoundingMode]int64{RoundDown: 0, RoundUp: 1, RoundHalfUp: 1} {
+		product, err := half.Mul(one, mode)
+		if err != nil || product.Micros() != want {
+			t.Errorf("0.5 × 0.000001 (mode %d) = %d, %v, want %d", mode, product.Micros(), err, want)
+		}
+	}
+	if _, err := Parse("0.0000001"); !errors.Is(err, ErrPrecision) {
+		t.Errorf("Parse of 7 decimal places: %v, want %v", err, ErrPrecision)
+	}
+	if d, err := Parse("0.1000000"); err != nil || d.Micros() != 100_000 {
+		t.Errorf("Parse of trailing zeros = %d, %v, want 100000", d.Micros(), err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	max, min := FromMicros(math.MaxInt64), FromMicros(math.MinInt64)
+	tests := []struct {
+		name string
+		op   func() (Decimal, error)
+	}{
+		{"FromInt above range", func() (Decimal, error) { return FromInt(math.MaxInt64/unit + 1) }},
+		{"FromInt below range", func() (Decimal, error) { return FromInt(math.MinInt64/unit - 1) }},
+		{"Parse above range", func() (Decimal, error) { return Parse("9223372036854.775808") }},
+		{"Parse below range", func() (Decimal, error) { return Parse("-9223372036854.775809") }},
+		{"FromFloat above range", func() (Decimal, error) { return FromFloat(1e20, RoundDown) }},
+		{"Add", func() (Decimal, error) { return max.Add(FromMicros(1)) }},
+		{"Add negative", func() (Decimal, error) { return min.Add(FromMicros(-1)) }},
+		{"Sub", func() (Decimal, error) { return min.Sub(FromMicros(1)) }},
+		{"Sub of the minimum", func() (Decimal, error) { return Zero.Sub(min) }},
+		{"Mul", func() (Decimal, error) { return max.Mul(FromMicros(2*unit), RoundDown) }},
+		{"Mul rounding up past the maximum", func() (Decimal, error) {
+			return max.Mul(FromMicros(unit+1), RoundUp)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d, err := tt.op(); !errors.Is(err, ErrOverflow) {
+				t.Errorf("got %s, %v, want %v", d, err, ErrOverflow)
+			}
+		})
+	}
+
+	// The extremes themselves fit.
+	if d, err := Parse("9223372036854.775807"); err != nil || d != max {
+		t.Errorf("Parse of the maximum = %s, %v", d, err)
+	}
+	if d, err := Parse("-9223372036854.775808"); err != nil || d != min {
+		t.Errorf("Parse of the minimum = %s, %v", d, err)
+	}
+	if d, err := max.Mul(FromMicros(unit), RoundDown); err != nil || d != max {
+		t.Errorf("maximum × 1 = %s, %v", d, err)
+	}
+}
@@ -0,0 +1,152 @@
+/**
+ * @description
+ * This package makes request bodies and upstream payloads safe to log. Webhook bodies, client
+ * WebSocket messages, and CLOB payloads carry emails, wallet addresses, signatures, and
+ * credentials, and can be large, so they must never be logged raw; error paths log them
+ * through `Body` instead.
+ *
+ * Key features:
+ * - Redaction: In JSON payloads, emails are replaced by a short hash (so that log lines about
+ *   the same user can still be correlated), wallet addresses are partially masked, and the
+ *   values of secret fields (signatures, keys, passphrases, tokens) are removed. Emails and
+ *   addresses are also masked in payloads that are not valid JSON.
+ * - Truncation: The result is truncated to a configurable length (`LOG_BODY_MAX_BYTES`,
+ *   default `DefaultMaxLength`), with the original size appended.
+ *
+ * @notes
+ * - SetMaxLength must be called before the services are started.
+ */
+
+package logsafe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLength is the length logged payloads are truncated to when none is configured.
+const DefaultMaxLength = 256
+
+// redacted replaces the values of secret fields.
+const redacted = "[REDACTED]"
+
+var (
+	// maxLength is the configured truncation length.
+	maxLength = DefaultMaxLength
+
+	// emailPattern matches email addresses.
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// addressPattern matches Ethereum addresses, but not longer hex strings such as hashes.
+	addressPattern = regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`)
+)
+
+// secretFields lists the JSON fields whose values are removed, in lower case without
+// separators, so that e.g. "private_key", "privateKey", and "PrivateKey" all match.
+var secretFields = map[string]bool{
+	"signature":     true,
+	"privatekey":    true,
+	"secret":        true,
+	"apisecret":     true,
+	"apikey":        true,
+	"passphrase":    true,
+	"password":      true,
+	"token":         true,
+	"authorization": true,
+}
+
+// SetMaxLength sets the length logged payloads are truncated to; zero or less uses DefaultMaxLength.
+func SetMaxLength(length int) {
+	if length <= 0 {
+		length = DefaultMaxLength
+	}
+	maxLength = length
+}
+
+/**
+ * @description
+ * Body returns a redacted and truncated form of a payload, safe to log.
+ *
+ * @param body The raw payload, e.g. a request body or a WebSocket message.
+ * @returns The payload with its sensitive values redacted, truncated to the configured length.
+ */
+func Body(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err == nil {
+		if encoded, err := json.Marshal(redact(value, "")); err == nil {
+			return truncate(string(encoded), len(body))
+		}
+	}
+	return truncate(String(string(body)), len(body))
+}
+
+// String masks the emails and wallet addresses in free text, e.g. a payload that is not JSON.
+func String(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, HashEmail)
+	return addressPattern.ReplaceAllStringFunc(s, MaskAddress)
+}
+
+// MaskAddress keeps the first and last four hex digits of a wallet address, e.g. "0x1234…cdef".
+func MaskAddress(address string) string {
+	if len(address) < 10 {
+		return redacted
+	}
+	return address[:6] + "…" + address[len(address)-4:]
+}
+
+// HashEmail replaces an email with a short hash of its lower-cased form, e.g. "email:3f2a9c1b0d4e".
+func HashEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "email:" + hex.EncodeToString(sum[:6])
+}
+
+// redact returns a decoded JSON value with its sensitive values redacted. key is the name
+// of the field holding the value, if any.
+func redact(value any, key string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if isSecretField(key) {
+			// e.g. a token given as an object of its parts
+			return redacted
+		}
+		for k, field := range v {
+			v[k] = redact(field, k)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item, key)
+		}
+		return v
+	case string:
+		if isSecretField(key) {
+			return redacted
+		}
+		return String(v)
+	default:
+		return v
+	}
+}
+
+// isSecretField reports whether a JSON field holds a secret.
+func isSecretField(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	return secretFields[normalized]
+}
+
+// truncate shortens s to the configured length, noting the size of the original payload.
+func truncate(s string, size int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	// Do not cut a multi-byte character in half.
+	cut := maxLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…(truncated, %d bytes)", s[:cut], size)
+}
@@ -0,0 +1,115 @@
+package logsafe
+
+import (
+	"strings"
+	"testing"
+)
+
+// aliceHash is the hash of alice@example.com, the first 12 hex digits of its SHA-256.
+const aliceHash = "email:ff8d9819fc0e"
+
+func TestBodyRedaction(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			"webhook email",
+			`{"type":"user.created","data":{"id":"user_1","email_addresses":[{"email_address":"alice@example.com"}]}}`,
+			`{"data":{"email_addresses":[{"email_address":"` + aliceHash + `"}],"id":"user_1"},"type":"user.created"}`,
+		},
+		{
+			"signed order",
+			`{"order":{"maker":"0x1111222233334444555566667777888899990000","taker":"0x0000000000000000000000000000000000000000","signature":"0xdeadbeef","side":0},"owner":"key"}`,
+			`{"order":{"maker":"0x1111…0000","side":0,"signature":"[REDACTED]","taker":"0x0000…0000"},"owner":"key"}`,
+		},
+		// Secret fields match whatever their case and separators.
+		{
+			"secret fields",
+			`{"private_key":"a","privateKey":"b","Api-Key":"c","PASSPHRASE":"d","secret":"e","token":"f","Authorization":"Bearer g","name":"h"}`,
+			`{"Api-Key":"[REDACTED]","Authorization":"[REDACTED]","PASSPHRASE":"[REDACTED]","name":"h","privateKey":"[REDACTED]","private_key":"[REDACTED]","secret":"[REDACTED]","token":"[REDACTED]"}`,
+		},
+		{"secret list", `{"signature":["0xa","0xb"]}`, `{"signature":["[REDACTED]","[REDACTED]"]}`},
+		{"secret object", `{"token":{"value":"abc","owner":"alice@example.com"}}`, `{"token":"[REDACTED]"}`},
+		// Hashes are longer than addresses and are kept whole.
+		{
+			"transaction hash",
+			`{"hash":"0x1111222233334444555566667777888899990000aaaabbbbccccddddeeeeffff"}`,
+			`{"hash":"0x1111222233334444555566667777888899990000aaaabbbbccccddddeeeeffff"}`,
+		},
+		{"address in text", `{"message":"order by 0x1111222233334444555566667777888899990000 rejected"}`, `{"message":"order by 0x1111…0000 rejected"}`},
+		{"scalars", `[1,true,null,"x"]`, `[1,true,null,"x"]`},
+		{
+			"not JSON",
+			`subscribe from Alice@Example.com for 0xAbCd222233334444555566667777888899990000 {`,
+			`subscribe from ` + aliceHash + ` for 0xAbCd…0000 {`,
+		},
+		{"empty", ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Body([]byte(tt.body)); got != tt.want {
+				t.Errorf("Body(%s)\n got %s\nwant %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashEmail(t *testing.T) {
+	if got := HashEmail("alice@example.com"); got != aliceHash {
+		t.Errorf("HashEmail = %s, want %s", got, aliceHash)
+	}
+	// The same address hashes alike however it is written, so log lines can be correlated.
+	if got := HashEmail(" Alice@Example.COM "); got != aliceHash {
+		t.Errorf("HashEmail of the address in another case = %s, want %s", got, aliceHash)
+	}
+	if HashEmail("bob@example.com") == aliceHash {
+		t.Error("two addresses hash alike")
+	}
+}
+
+func TestMaskAddress(t *testing.T) {
+	tests := []struct{ address, want string }{
+		{"0x1111222233334444555566667777888899990000", "0x1111…0000"},
+		{"0x12345678", "0x1234…5678"},
+		{"0x1234", "[REDACTED]"},
+	}
+	for _, tt := range tests {
+		if got := MaskAddress(tt.address); got != tt.want {
+			t.Errorf("MaskAddress(%s) = %s, want %s", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestBodyTruncation(t *testing.T) {
+	t.Cleanup(func() { SetMaxLength(0) })
+	SetMaxLength(16)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"short", "0123456789", "0123456789"},
+		{"exactly the limit", "0123456789abcdef", "0123456789abcdef"},
+		{"long", strings.Repeat("x", 40), strings.Repeat("x", 16) + "…(truncated, 40 bytes)"},
+		// The size is the original payload's, not the redacted one's.
+		{"redacted JSON", `{"signature":"` + strings.Repeat("f", 100) + `"}`, `{"signature":"[R…(truncated, 116 bytes)`},
+		// A two-byte character straddling the limit is dropped whole.
+		{"multi-byte", "0123456789abcde" + "é" + "xyz", "0123456789abcde…(truncated, 20 bytes)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Body([]byte(tt.body)); got != tt.want {
+				t.Errorf("Body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	SetMaxLength(0)
+	long := strings.Repeat("x", DefaultMaxLength+1)
+	if got := Body([]byte(long)); !strings.HasPrefix(got, long[:DefaultMaxLength]+"…(truncated") {
+		t.Errorf("with no length configured, Body = %q, want it truncated to %d bytes", got, DefaultMaxLength)
+	}
+}
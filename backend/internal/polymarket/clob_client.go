@@ -5,6 +5,7 @@
  *
  * Key features:
  * - Order Placement: Submit signed orders to the CLOB
+ * - Order Cancellation: Cancel resting orders on the CLOB
  * - Order Book Retrieval: Fetch current order book state
  * - API Key Authentication: Uses L2 headers for authenticated requests
  * - Error Handling: Proper error handling for API responses
@@ -25,14 +26,33 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/poly-pro/backend/internal/logsafe"
 )
 
+// ErrOrderAlreadyExists is returned by PostOrder when the CLOB reports that the
+// submitted order (same hash/salt) has already been placed. Callers should treat
+// this as an idempotent success and look up the existing order.
+var ErrOrderAlreadyExists = errors.New("order already exists")
+
+// ErrCLOBUnavailable is returned when the CLOB could not be reached or reported a temporary
+// failure (HTTP 429 or 5xx), so the same request may succeed if retried.
+var ErrCLOBUnavailable = errors.New("CLOB API temporarily unavailable")
+
+// ErrOrderNotCanceled is returned by CancelOrder when the CLOB declines to cancel the order,
+// e.g. because it was already matched, cancelled, or is unknown to the CLOB.
+var ErrOrderNotCanceled = errors.New("order not canceled")
+
 // CLOBAPIClient handles interactions with Polymarket's CLOB API
 type CLOBAPIClient struct {
 	baseURL    string
@@ -94,6 +114,110 @@ type PostOrderResponse struct {
 	Status      string   `json:"status"` // "matched", "live", "delayed", "unmatched"
 }
 
+// Order statuses returned by the CLOB when an order is posted
+const (
+	// OrderStatusLive means the order was placed and is resting on the book
+	OrderStatusLive = "live"
+	// OrderStatusMatched means the order was placed and matched against resting orders
+	OrderStatusMatched = "matched"
+	// OrderStatusDelayed means the order is marketable but subject to the market's matching delay
+	OrderStatusDelayed = "delayed"
+	// OrderStatusUnmatched means the order was marketable but could not be matched after the delay
+	OrderStatusUnmatched = "unmatched"
+)
+
+// cancelOrderRequest is the body of the DELETE /order request
+type cancelOrderRequest struct {
+	OrderID string `json:"orderID"`
+}
+
+// CancelOrdersResponse represents the response from cancelling orders
+type CancelOrdersResponse struct {
+	Canceled    []string          `json:"canceled"`
+	NotCanceled map[string]string `json:"not_canceled"` // Order ID -> reason
+}
+
+// Trade represents a single public trade from the CLOB trades endpoint
+type Trade struct {
+	ID        string `json:"id"`
+	Market    string `json:"market"`
+	AssetID   string `json:"asset_id"`
+	Side      string `json:"side"`
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	Outcome   string `json:"outcome"`
+	MatchTime string `json:"match_time"` // Unix timestamp in seconds
+	Status    string `json:"status"`
+}
+
+// OpenOrder represents an order as returned by the CLOB order lookup endpoint
+type OpenOrder struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"` // "LIVE", "MATCHED", "CANCELED", ...
+	Market       string `json:"market"`
+	AssetID      string `json:"asset_id"`
+	Side         string `json:"side"`
+	OriginalSize string `json:"original_size"`
+	SizeMatched  string `json:"size_matched"`
+	Price        string `json:"price"`
+	OrderType    string `json:"order_type"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+// UserTrade represents a trade involving the authenticated user, as returned by /data/trades
+type UserTrade struct {
+	ID           string           `json:"id"`
+	TakerOrderID string           `json:"taker_order_id"`
+	Market       string           `json:"market"`
+	AssetID      string           `json:"asset_id"`
+	Side         string           `json:"side"`
+	Size         string           `json:"size"`
+	Price        string           `json:"price"`
+	Status       string           `json:"status"`
+	MatchTime    string           `json:"match_time"` // Unix timestamp (seconds) as a string
+	MakerOrders  []MakerOrderFill `json:"maker_orders"`
+}
+
+// MakerOrderFill is the portion of a trade filled against one resting (maker) order
+type MakerOrderFill struct {
+	OrderID       string `json:"order_id"`
+	MatchedAmount string `json:"matched_amount"`
+	Price         string `json:"price"`
+	Side          string `json:"side"`
+}
+
+// OrderFill is a single fill of a specific order, from either the taker or maker side of a trade
+type OrderFill struct {
+	TradeID   string
+	Side      string
+	Size      string
+	Price     string
+	MatchTime string
+}
+
+// userTradesPage is one page of the paginated /data/trades response
+type userTradesPage struct {
+	Data       []UserTrade `json:"data"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// endCursor is the cursor value the CLOB returns when there are no more pages
+const endCursor = "LTE="
+
+// maxTradePages bounds how many pages GetOrderFills will read for a single order
+const maxTradePages = 10
+
+// PricePoint is a single point of a token's price history
+type PricePoint struct {
+	Time  int64   `json:"t"` // Unix timestamp in seconds
+	Price float64 `json:"p"`
+}
+
+// pricesHistoryResponse is the response of the /prices-history endpoint
+type pricesHistoryResponse struct {
+	History []PricePoint `json:"history"`
+}
+
 // CLOBError represents an error response from the CLOB API
 type CLOBError struct {
 	Error string `json:"error"`
@@ -156,7 +280,7 @@ func (c *CLOBAPIClient) GetOrderBook(ctx context.Context, tokenID string) (*Orde
 		if err := json.Unmarshal(body, &clobErr); err == nil {
 			return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
 		}
-		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
 	}
 
 	var orderBook OrderBookSummary
@@ -167,6 +291,198 @@ func (c *CLOBAPIClient) GetOrderBook(ctx context.Context, tokenID string) (*Orde
 	return &orderBook, nil
 }
 
+// GetMarketTrades fetches the most recent public trades for a market (condition ID)
+// The CLOB returns trades newest first; limit caps the number of trades returned
+func (c *CLOBAPIClient) GetMarketTrades(ctx context.Context, conditionID string, limit int) ([]Trade, error) {
+	apiURL := fmt.Sprintf("%s/trades?market=%s&limit=%d", c.baseURL, url.QueryEscape(conditionID), limit)
+
+	c.logger.Info("fetching market trades from CLOB API", "market", conditionID, "limit", limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to fetch market trades from CLOB API", "error", err, "market", conditionID)
+		return nil, fmt.Errorf("failed to fetch trades: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil {
+			return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+		}
+		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var trades []Trade
+	if err := json.Unmarshal(body, &trades); err != nil {
+		return nil, fmt.Errorf("failed to parse trades response: %w", err)
+	}
+
+	// Defensively enforce the limit in case the upstream ignores it
+	if len(trades) > limit {
+		trades = trades[:limit]
+	}
+
+	return trades, nil
+}
+
+// GetPricesHistory fetches the price history of a token between startTs and endTs (Unix seconds)
+// fidelityMinutes sets the spacing of the returned points
+func (c *CLOBAPIClient) GetPricesHistory(ctx context.Context, tokenID string, startTs, endTs int64, fidelityMinutes int) ([]PricePoint, error) {
+	apiURL := fmt.Sprintf("%s/prices-history?market=%s&startTs=%d&endTs=%d&fidelity=%d",
+		c.baseURL, url.QueryEscape(tokenID), startTs, endTs, fidelityMinutes)
+
+	c.logger.Info("fetching prices history from CLOB API", "token_id", tokenID, "start_ts", startTs, "end_ts", endTs, "fidelity", fidelityMinutes)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to fetch prices history from CLOB API", "error", err, "token_id", tokenID)
+		return nil, fmt.Errorf("failed to fetch prices history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil {
+			return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+		}
+		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var history pricesHistoryResponse
+	if err := json.Unmarshal(body, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse prices history response: %w", err)
+	}
+
+	return history.History, nil
+}
+
+// tickSizeResponse is the response of the tick-size endpoint
+type tickSizeResponse struct {
+	MinimumTickSize float64 `json:"minimum_tick_size"`
+}
+
+// GetTickSize fetches the current minimum tick size (price increment) of a token
+func (c *CLOBAPIClient) GetTickSize(ctx context.Context, tokenID string) (float64, error) {
+	apiURL := fmt.Sprintf("%s/tick-size?token_id=%s", c.baseURL, url.QueryEscape(tokenID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to fetch tick size from CLOB API", "error", err, "token_id", tokenID)
+		return 0, fmt.Errorf("failed to fetch tick size: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil && clobErr.Error != "" {
+			return 0, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+		}
+		return 0, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var tickSize tickSizeResponse
+	if err := json.Unmarshal(body, &tickSize); err != nil {
+		return 0, fmt.Errorf("failed to parse tick size response: %w", err)
+	}
+	if tickSize.MinimumTickSize <= 0 || tickSize.MinimumTickSize >= 1 {
+		return 0, fmt.Errorf("invalid tick size %v for token %s", tickSize.MinimumTickSize, tokenID)
+	}
+
+	return tickSize.MinimumTickSize, nil
+}
+
+// CLOBMarket represents a market as returned by the CLOB markets endpoint
+type CLOBMarket struct {
+	ConditionID string            `json:"condition_id"`
+	Tokens      []CLOBMarketToken `json:"tokens"`
+}
+
+// CLOBMarketToken represents one outcome token of a CLOB market
+type CLOBMarketToken struct {
+	TokenID string `json:"token_id"`
+	Outcome string `json:"outcome"`
+}
+
+// GetMarket fetches a market, including its outcome tokens, by condition ID
+func (c *CLOBAPIClient) GetMarket(ctx context.Context, conditionID string) (*CLOBMarket, error) {
+	apiURL := fmt.Sprintf("%s/markets/%s", c.baseURL, url.PathEscape(conditionID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to fetch market from CLOB API", "error", err, "condition_id", conditionID)
+		return nil, fmt.Errorf("failed to fetch market: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil && clobErr.Error != "" {
+			return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+		}
+		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var market CLOBMarket
+	if err := json.Unmarshal(body, &market); err != nil {
+		return nil, fmt.Errorf("failed to parse market response: %w", err)
+	}
+
+	return &market, nil
+}
+
 // PostOrder submits a signed order to the CLOB API
 func (c *CLOBAPIClient) PostOrder(ctx context.Context, signedOrder *SignedOrder, orderType string) (*PostOrderResponse, error) {
 	if orderType == "" {
@@ -215,7 +531,10 @@ func (c *CLOBAPIClient) PostOrder(ctx context.Context, signedOrder *SignedOrder,
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		c.logger.Error("failed to submit order to CLOB API", "error", err)
-		return nil, fmt.Errorf("failed to submit order: %w", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to submit order: %w", err)
+		}
+		return nil, fmt.Errorf("failed to submit order: %w: %w", ErrCLOBUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -224,12 +543,29 @@ func (c *CLOBAPIClient) PostOrder(ctx context.Context, signedOrder *SignedOrder,
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		c.logger.Warn("CLOB API temporarily unavailable", "status_code", resp.StatusCode)
+		return nil, fmt.Errorf("%w: HTTP %d", ErrCLOBUnavailable, resp.StatusCode)
+	}
+
 	var orderResp PostOrderResponse
 	if err := json.Unmarshal(body, &orderResp); err != nil {
 		return nil, fmt.Errorf("failed to parse order response: %w", err)
 	}
 
+	if !orderResp.Success && orderResp.ErrorMsg == "" {
+		// Some failures come back in the generic {"error": "..."} shape
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil {
+			orderResp.ErrorMsg = clobErr.Error
+		}
+	}
+
 	if !orderResp.Success {
+		if isDuplicateOrderError(orderResp.ErrorMsg) {
+			c.logger.Info("order already exists on CLOB", "error_msg", orderResp.ErrorMsg, "order_id", orderResp.OrderID)
+			return &orderResp, fmt.Errorf("%w: %s", ErrOrderAlreadyExists, orderResp.ErrorMsg)
+		}
 		c.logger.Warn("order submission failed", "error_msg", orderResp.ErrorMsg, "status", orderResp.Status)
 		return &orderResp, fmt.Errorf("order submission failed: %s", orderResp.ErrorMsg)
 	}
@@ -238,3 +574,256 @@ func (c *CLOBAPIClient) PostOrder(ctx context.Context, signedOrder *SignedOrder,
 	return &orderResp, nil
 }
 
+
+// GetOrder fetches a single order by its CLOB order ID (order hash)
+// The address parameter should be the maker address (funder address) of the order
+func (c *CLOBAPIClient) GetOrder(ctx context.Context, orderID, address string) (*OpenOrder, error) {
+	path := "/data/order/" + orderID
+	apiURL := c.baseURL + path
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	authHeaders, err := c.createAuthHeaders("GET", path, "", address, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth headers: %w", err)
+	}
+	for k, v := range authHeaders {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to fetch order from CLOB API", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to fetch order: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil {
+			return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+		}
+		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var order OpenOrder
+	if err := json.Unmarshal(body, &order); err != nil {
+		return nil, fmt.Errorf("failed to parse order response: %w", err)
+	}
+
+	return &order, nil
+}
+
+/**
+ * @description
+ * CancelOrder cancels a single resting order on the CLOB.
+ *
+ * @param orderID The CLOB order ID (order hash).
+ * @param address The maker address (funder address) of the order.
+ * @returns The CLOB's response, or an error. An order the CLOB declines to cancel returns
+ *   the response with an error wrapping ErrOrderNotCanceled and the CLOB's reason; a network
+ *   error, HTTP 429, or 5xx wraps ErrCLOBUnavailable.
+ */
+func (c *CLOBAPIClient) CancelOrder(ctx context.Context, orderID, address string) (*CancelOrdersResponse, error) {
+	bodyBytes, err := json.Marshal(cancelOrderRequest{OrderID: orderID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cancel request: %w", err)
+	}
+
+	path := "/order"
+	apiURL := c.baseURL + path
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", apiURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	authHeaders, err := c.createAuthHeaders("DELETE", path, string(bodyBytes), address, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth headers: %w", err)
+	}
+	for k, v := range authHeaders {
+		req.Header.Set(k, v)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	c.logger.Info("cancelling order on CLOB API", "order_id", orderID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to cancel order on CLOB API", "error", err, "order_id", orderID)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to cancel order: %w", err)
+		}
+		return nil, fmt.Errorf("failed to cancel order: %w: %w", ErrCLOBUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		c.logger.Warn("CLOB API temporarily unavailable", "status_code", resp.StatusCode)
+		return nil, fmt.Errorf("%w: HTTP %d", ErrCLOBUnavailable, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var clobErr CLOBError
+		if err := json.Unmarshal(body, &clobErr); err == nil && clobErr.Error != "" {
+			return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+		}
+		return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var cancelResp CancelOrdersResponse
+	if err := json.Unmarshal(body, &cancelResp); err != nil {
+		return nil, fmt.Errorf("failed to parse cancel response: %w", err)
+	}
+
+	for _, canceled := range cancelResp.Canceled {
+		if canceled == orderID {
+			c.logger.Info("order successfully cancelled", "order_id", orderID)
+			return &cancelResp, nil
+		}
+	}
+	reason, ok := cancelResp.NotCanceled[orderID]
+	if !ok {
+		reason = "order not reported as canceled"
+	}
+	c.logger.Warn("CLOB declined to cancel order", "order_id", orderID, "reason", reason)
+	return &cancelResp, fmt.Errorf("%w: %s", ErrOrderNotCanceled, reason)
+}
+
+/**
+ * @description
+ * GetOrderFills fetches the fills of a single order from the user's trade history.
+ * An order can be filled as the taker of a trade or as one of its maker orders.
+ *
+ * @param orderID The CLOB order ID (order hash).
+ * @param assetID The token ID the order was placed on, used to narrow the trade query.
+ * @param address The maker address (funder address) of the order.
+ * @returns The fills for the order, or an error.
+ */
+func (c *CLOBAPIClient) GetOrderFills(ctx context.Context, orderID, assetID, address string) ([]OrderFill, error) {
+	// L2 signatures cover the path only; query parameters are not part of the signed message
+	path := "/data/trades"
+	query := url.Values{}
+	query.Set("maker_address", address)
+	query.Set("asset_id", assetID)
+
+	var fills []OrderFill
+	cursor := ""
+	for page := 0; page < maxTradePages; page++ {
+		if cursor != "" {
+			query.Set("next_cursor", cursor)
+		}
+		apiURL := c.baseURL + path + "?" + query.Encode()
+		timestamp := time.Now().Unix()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		authHeaders, err := c.createAuthHeaders("GET", path, "", address, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create auth headers: %w", err)
+		}
+		for k, v := range authHeaders {
+			req.Header.Set(k, v)
+		}
+
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			c.logger.Error("failed to fetch trades from CLOB API", "error", err, "order_id", orderID)
+			return nil, fmt.Errorf("failed to fetch trades: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			var clobErr CLOBError
+			if err := json.Unmarshal(body, &clobErr); err == nil {
+				return nil, fmt.Errorf("CLOB API error: %s", clobErr.Error)
+			}
+			return nil, fmt.Errorf("CLOB API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+		}
+
+		var tradesPage userTradesPage
+		if err := json.Unmarshal(body, &tradesPage); err != nil {
+			return nil, fmt.Errorf("failed to parse trades response: %w", err)
+		}
+
+		for _, trade := range tradesPage.Data {
+			if trade.TakerOrderID == orderID {
+				fills = append(fills, OrderFill{
+					TradeID:   trade.ID,
+					Side:      trade.Side,
+					Size:      trade.Size,
+					Price:     trade.Price,
+					MatchTime: trade.MatchTime,
+				})
+				continue
+			}
+			for _, makerOrder := range trade.MakerOrders {
+				if makerOrder.OrderID == orderID {
+					fills = append(fills, OrderFill{
+						TradeID:   trade.ID,
+						Side:      makerOrder.Side,
+						Size:      makerOrder.MatchedAmount,
+						Price:     makerOrder.Price,
+						MatchTime: trade.MatchTime,
+					})
+				}
+			}
+		}
+
+		cursor = tradesPage.NextCursor
+		if cursor == "" || cursor == endCursor {
+			break
+		}
+	}
+
+	return fills, nil
+}
+
+// The CLOB's INVALID_ORDER_DUPLICATED error: its code, its documented message, and the
+// message naming the order's hash.
+const (
+	duplicateOrderCode    = "INVALID_ORDER_DUPLICATED"
+	duplicateOrderMessage = "order is invalid. Duplicated. Same order has already been placed, can't be placed again"
+)
+
+var duplicateOrderHashMessage = regexp.MustCompile(`^order 0x[0-9a-fA-F]+ is invalid\. Duplicated\.$`)
+
+// isDuplicateOrderError reports whether a CLOB error message is the CLOB's duplicate order
+// error, i.e. the order has already been submitted (e.g. a retried request or a reused salt).
+// Other errors that merely mention duplicates (e.g. a duplicated field) do not match.
+func isDuplicateOrderError(errorMsg string) bool {
+	msg := strings.TrimSpace(errorMsg)
+	return msg == duplicateOrderCode || msg == duplicateOrderMessage || duplicateOrderHashMessage.MatchString(msg)
+}
@@ -0,0 +1,102 @@
+package polymarket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsDuplicateOrderError(t *testing.T) {
+	tests := []struct {
+		errorMsg string
+		want     bool
+	}{
+		{"INVALID_ORDER_DUPLICATED", true},
+		{"order is invalid. Duplicated. Same order has already been placed, can't be placed again", true},
+		{"order 0x3f6a9c2e is invalid. Duplicated.", true},
+		{" INVALID_ORDER_DUPLICATED\n", true},
+		// Errors that only mention duplicates or existing records are other rejections.
+		{"duplicate key value violates unique constraint", false},
+		{"invalid order: duplicated field 'salt'", false},
+		{"API key already exists", false},
+		{"order 0x3f6a9c2e is invalid. Duplicated. Also not enough balance", false},
+		{"invalid_order_duplicated", false},
+		{"INVALID_ORDER_NOT_ENOUGH_BALANCE", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isDuplicateOrderError(tt.errorMsg); got != tt.want {
+			t.Errorf("isDuplicateOrderError(%q) = %v, want %v", tt.errorMsg, got, tt.want)
+		}
+	}
+}
+
+func TestPostOrderDuplicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		duplicate bool
+	}{
+		{"duplicate order", `{"success":false,"errorMsg":"INVALID_ORDER_DUPLICATED","orderId":"0xorder"}`, true},
+		{"duplicate in the generic error shape", `{"error":"order 0xabc is invalid. Duplicated."}`, true},
+		{"other rejection mentioning a duplicate", `{"success":false,"errorMsg":"duplicate nonce"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(clob.Close)
+			client := NewCLOBAPIClient(clob.URL, "key", "secret", "passphrase", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			resp, err := client.PostOrder(context.Background(), &SignedOrder{}, "GTC")
+			if err == nil || resp == nil {
+				t.Fatalf("PostOrder = %+v, %v, want a response and an error", resp, err)
+			}
+			if got := errors.Is(err, ErrOrderAlreadyExists); got != tt.duplicate {
+				t.Errorf("error %q is ErrOrderAlreadyExists: %v, want %v", err, got, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestGetTickSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    float64
+		wantErr bool
+	}{
+		{"tick size", http.StatusOK, `{"minimum_tick_size":0.001}`, 0.001, false},
+		{"zero tick size", http.StatusOK, `{"minimum_tick_size":0}`, 0, true},
+		{"tick size of one", http.StatusOK, `{"minimum_tick_size":1}`, 0, true},
+		{"missing field", http.StatusOK, `{}`, 0, true},
+		{"unknown token", http.StatusNotFound, `{"error":"market not found"}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.RequestURI()
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(clob.Close)
+			client := NewCLOBAPIClient(clob.URL, "key", "secret", "passphrase", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			tickSize, err := client.GetTickSize(context.Background(), "12345")
+			if query != "/tick-size?token_id=12345" {
+				t.Errorf("requested %s", query)
+			}
+			if (err != nil) != tt.wantErr || tickSize != tt.want {
+				t.Errorf("GetTickSize = %v, %v; want %v (error: %v)", tickSize, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,9 +5,19 @@
  *
  * Key features:
  * - Market Channel: Subscribe to order book updates for specific tokens
- * - User Channel: Subscribe to user-specific order and trade updates (requires auth)
+ * - User Channel: Subscribe to user-specific order and trade updates (requires auth). A
+ *   client is connected to either the market or the user channel; SubscribeUser connects
+ *   it to the user channel, and `order` and `trade` events are passed to optional handlers
  * - Automatic Reconnection: Handles connection drops and reconnects
+ * - Single Writer: gorilla/websocket forbids concurrent writers, so every write (pings,
+ *   subscriptions, unsubscriptions) is posted to a buffered outbound channel drained by one
+ *   writer goroutine per connection. Writes have a deadline, and a failed write closes the
+ *   connection, which ends Listen so that the owner reconnects.
+ * - Persistent Subscriptions: Remembers every subscribed asset (including ones added
+ *   after the initial subscription) and resubscribes to exactly that set on reconnect
  * - Message Parsing: Parses incoming WebSocket messages
+ * - Tick Size Changes: `tick_size_change` events are passed to an optional handler
+ * - Trades: `last_trade_price` events are passed to an optional handler
  *
  * @dependencies
  * - github.com/gorilla/websocket: For WebSocket connections
@@ -18,15 +28,43 @@
 package polymarket
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
 	gorillaWS "github.com/gorilla/websocket"
+	"github.com/poly-pro/backend/internal/logsafe"
 )
 
+const (
+	// marketChannelPath and userChannelPath are the WebSocket endpoints of the channels.
+	marketChannelPath = "/ws/market"
+	userChannelPath   = "/ws/user"
+)
+
+const (
+	// wsWriteWait is the time allowed to write a message to the connection.
+	wsWriteWait = 10 * time.Second
+	// wsOutboundBufferSize is the number of messages queued for the writer goroutine.
+	wsOutboundBufferSize = 16
+)
+
+// errConnectionClosed is returned for writes to a connection that was closed or failed.
+var errConnectionClosed = errors.New("WebSocket connection closed")
+
+// outboundMessage is a message queued for the writer goroutine, with the channel its
+// write result is reported on.
+type outboundMessage struct {
+	data   []byte
+	result chan error
+}
+
 // CLOBWebSocketClient handles WebSocket connections to Polymarket's CLOB
 type CLOBWebSocketClient struct {
 	baseURL    string
@@ -37,6 +75,36 @@ type CLOBWebSocketClient struct {
 	apiKey     string
 	apiSecret  string
 	passphrase string
+
+	// connMu guards the current connection's fields below.
+	connMu sync.Mutex
+	// connDone is closed when the current connection is replaced or closed,
+	// stopping that connection's ping loop and writer.
+	connDone chan struct{}
+	// outbound feeds the current connection's writer goroutine.
+	outbound chan outboundMessage
+	// writerDone is closed when the current connection's writer goroutine exits.
+	writerDone chan struct{}
+	// channelPath is the endpoint Connect dials; the market channel unless SubscribeUser was called.
+	channelPath string
+
+	// subscribedMu guards subscribed and connSubscribed.
+	subscribedMu sync.Mutex
+	// subscribed is the set of asset IDs subscribed to across all connections.
+	subscribed map[string]struct{}
+	// connSubscribed reports whether the initial subscription was sent on the current connection.
+	connSubscribed bool
+	// userMarkets is the user channel subscription, resubscribed on reconnect.
+	userMarkets []string
+
+	// tickSizeHandler receives tick_size_change events; they are ignored when it is nil.
+	tickSizeHandler TickSizeChangeHandler
+	// tradeHandler receives last_trade_price events; they are ignored when it is nil.
+	tradeHandler LastTradePriceHandler
+	// userOrderHandler receives user channel order events; they are ignored when it is nil.
+	userOrderHandler UserOrderHandler
+	// userTradeHandler receives user channel trade events; they are ignored when it is nil.
+	userTradeHandler UserTradeHandler
 }
 
 // NewCLOBWebSocketClient creates a new CLOB WebSocket client
@@ -48,13 +116,15 @@ func NewCLOBWebSocketClient(baseURL string, apiKey, apiSecret, passphrase string
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &CLOBWebSocketClient{
-		baseURL:    baseURL,
-		logger:     logger,
-		ctx:        ctx,
-		cancel:     cancel,
-		apiKey:     apiKey,
-		apiSecret:  apiSecret,
-		passphrase: passphrase,
+		baseURL:     baseURL,
+		logger:      logger,
+		ctx:         ctx,
+		cancel:      cancel,
+		apiKey:      apiKey,
+		apiSecret:   apiSecret,
+		passphrase:  passphrase,
+		channelPath: marketChannelPath,
+		subscribed:  make(map[string]struct{}),
 	}
 }
 
@@ -86,12 +156,86 @@ type PriceChangeMessage struct {
 	Timestamp string `json:"timestamp"`
 }
 
+// TickSizeChangeMessage is sent when a market's minimum tick size changes,
+// e.g. as its price approaches 0 or 1
+type TickSizeChangeMessage struct {
+	EventType   string `json:"event_type"` // "tick_size_change"
+	AssetID     string `json:"asset_id"`
+	Market      string `json:"market"`
+	OldTickSize string `json:"old_tick_size"`
+	NewTickSize string `json:"new_tick_size"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// LastTradePriceMessage is sent when a trade is matched on one of the subscribed assets
+type LastTradePriceMessage struct {
+	EventType string `json:"event_type"` // "last_trade_price"
+	AssetID   string `json:"asset_id"`
+	Market    string `json:"market"`
+	Price     string `json:"price"`
+	Size      string `json:"size"`
+	Side      string `json:"side"` // Side of the taker: "BUY" or "SELL"
+	Timestamp string `json:"timestamp"`
+}
+
+// UserOrderMessage is sent on the user channel when one of the user's orders is placed,
+// updated (partially matched), or cancelled
+type UserOrderMessage struct {
+	EventType       string   `json:"event_type"` // "order"
+	Type            string   `json:"type"`       // "PLACEMENT", "UPDATE", or "CANCELLATION"
+	ID              string   `json:"id"`         // Order ID
+	Owner           string   `json:"owner"`      // API key of the order's owner
+	OrderOwner      string   `json:"order_owner"`
+	AssetID         string   `json:"asset_id"`
+	Market          string   `json:"market"`
+	Outcome         string   `json:"outcome"`
+	Side            string   `json:"side"`
+	Price           string   `json:"price"`
+	OriginalSize    string   `json:"original_size"`
+	SizeMatched     string   `json:"size_matched"`
+	AssociateTrades []string `json:"associate_trades"`
+	Timestamp       string   `json:"timestamp"`
+}
+
+// UserTradeMessage is sent on the user channel when a trade involving one of the user's
+// orders is matched, and again as its status progresses (e.g. MINED, CONFIRMED)
+type UserTradeMessage struct {
+	EventType    string            `json:"event_type"` // "trade"
+	Type         string            `json:"type"`       // "TRADE"
+	ID           string            `json:"id"`         // Trade ID
+	Owner        string            `json:"owner"`      // API key of the trade's owner
+	TradeOwner   string            `json:"trade_owner"`
+	AssetID      string            `json:"asset_id"`
+	Market       string            `json:"market"`
+	Outcome      string            `json:"outcome"`
+	Side         string            `json:"side"`
+	Price        string            `json:"price"`
+	Size         string            `json:"size"`
+	Status       string            `json:"status"` // "MATCHED", "MINED", "CONFIRMED", "RETRYING", or "FAILED"
+	TakerOrderID string            `json:"taker_order_id"`
+	MakerOrders  []TradeMakerOrder `json:"maker_orders"`
+	MatchTime    string            `json:"matchtime"`
+	LastUpdate   string            `json:"last_update"`
+	Timestamp    string            `json:"timestamp"`
+}
+
+// TradeMakerOrder is a maker order filled by a trade
+type TradeMakerOrder struct {
+	OrderID       string `json:"order_id"`
+	Owner         string `json:"owner"`
+	AssetID       string `json:"asset_id"`
+	Outcome       string `json:"outcome"`
+	Price         string `json:"price"`
+	MatchedAmount string `json:"matched_amount"`
+}
+
 // SubscriptionMessage represents a subscription request
 type SubscriptionMessage struct {
 	Type      string   `json:"type"`       // "MARKET" or "USER"
 	AssetsIDs []string `json:"assets_ids"` // For MARKET channel
 	Markets   []string `json:"markets"`     // For USER channel
 	Auth      *Auth    `json:"auth,omitempty"` // For USER channel
+	Operation string   `json:"operation,omitempty"` // "subscribe" or "unsubscribe" to change an existing subscription
 }
 
 // Auth represents authentication for USER channel
@@ -104,13 +248,51 @@ type Auth struct {
 // MessageHandler is a function that handles incoming WebSocket messages
 type MessageHandler func(message *BookMessage) error
 
+// TickSizeChangeHandler is a function that handles tick_size_change events
+type TickSizeChangeHandler func(message *TickSizeChangeMessage)
+
+// OnTickSizeChange sets the handler for tick_size_change events.
+// It must be called before Listen.
+func (c *CLOBWebSocketClient) OnTickSizeChange(handler TickSizeChangeHandler) {
+	c.tickSizeHandler = handler
+}
+
+// LastTradePriceHandler is a function that handles last_trade_price events
+type LastTradePriceHandler func(message *LastTradePriceMessage)
+
+// OnLastTradePrice sets the handler for last_trade_price events.
+// It must be called before Listen.
+func (c *CLOBWebSocketClient) OnLastTradePrice(handler LastTradePriceHandler) {
+	c.tradeHandler = handler
+}
+
+// UserOrderHandler is a function that handles user channel order events
+type UserOrderHandler func(message *UserOrderMessage)
+
+// OnUserOrder sets the handler for user channel order events.
+// It must be called before Listen.
+func (c *CLOBWebSocketClient) OnUserOrder(handler UserOrderHandler) {
+	c.userOrderHandler = handler
+}
+
+// UserTradeHandler is a function that handles user channel trade events
+type UserTradeHandler func(message *UserTradeMessage)
+
+// OnUserTrade sets the handler for user channel trade events.
+// It must be called before Listen.
+func (c *CLOBWebSocketClient) OnUserTrade(handler UserTradeHandler) {
+	c.userTradeHandler = handler
+}
+
 // Connect connects to the WebSocket server
 func (c *CLOBWebSocketClient) Connect() error {
 	dialer := gorillaWS.Dialer{
 		HandshakeTimeout: 10 * time.Second,
 	}
 
-	url := c.baseURL + "/ws/market"
+	c.connMu.Lock()
+	url := c.baseURL + c.channelPath
+	c.connMu.Unlock()
 	c.logger.Info("connecting to CLOB WebSocket", "url", url)
 
 	conn, _, err := dialer.Dial(url, nil)
@@ -118,44 +300,306 @@ func (c *CLOBWebSocketClient) Connect() error {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
 
+	c.connMu.Lock()
 	c.conn = conn
+	c.connDone = make(chan struct{})
+	c.outbound = make(chan outboundMessage, wsOutboundBufferSize)
+	c.writerDone = make(chan struct{})
+	go c.writer(conn, c.outbound, c.connDone, c.writerDone)
+	c.connMu.Unlock()
+
+	c.subscribedMu.Lock()
+	c.connSubscribed = false
+	c.subscribedMu.Unlock()
 	c.logger.Info("connected to CLOB WebSocket")
 	return nil
 }
 
-// Subscribe subscribes to order book updates for specific tokens
+/**
+ * @description
+ * Subscribe subscribes to order book updates for specific tokens and records them
+ * in the persistent subscription set. It may be called again at any time to add assets.
+ *
+ * @param assetIDs The token IDs to subscribe to.
+ * @returns An error if not connected or the subscription message could not be sent.
+ *
+ * @notes
+ * - The first subscription on a connection is sent as a plain MARKET subscription;
+ *   later ones use the "subscribe" operation so they add to it instead of replacing it.
+ */
 func (c *CLOBWebSocketClient) Subscribe(assetIDs []string) error {
-	if c.conn == nil {
-		return fmt.Errorf("not connected to WebSocket")
-	}
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
 
 	subMsg := SubscriptionMessage{
 		Type:      "MARKET",
 		AssetsIDs: assetIDs,
 	}
+	if c.connSubscribed {
+		subMsg.Operation = "subscribe"
+	}
 
 	message, err := json.Marshal(subMsg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal subscription message: %w", err)
 	}
 
-	c.logger.Info("subscribing to market channel", "asset_ids", assetIDs, "asset_count", len(assetIDs))
-	if err := c.conn.WriteMessage(gorillaWS.TextMessage, message); err != nil {
+	c.logger.Info("subscribing to market channel", "asset_ids", assetIDs, "asset_count", len(assetIDs), "operation", subMsg.Operation)
+	if err := c.writeMessage(message); err != nil {
 		return fmt.Errorf("failed to send subscription message: %w", err)
 	}
 
-	c.logger.Info("subscription message sent successfully", "asset_count", len(assetIDs))
+	c.connSubscribed = true
+	for _, assetID := range assetIDs {
+		c.subscribed[assetID] = struct{}{}
+	}
+
+	c.logger.Info("subscription message sent successfully", "asset_count", len(assetIDs), "total_subscribed", len(c.subscribed))
+	return nil
+}
+
+/**
+ * @description
+ * SubscribeUser connects the client to the user channel, if it is not connected yet, and
+ * subscribes to the order and trade events of the API key's user, authenticating with the
+ * client's API credentials. The subscription is resubscribed on reconnect.
+ *
+ * @param markets The condition IDs of the markets to receive events for; empty for all markets.
+ * @returns An error if the credentials are missing, the client is connected to the market
+ *   channel, or the connection or subscription failed.
+ *
+ * @notes
+ * - Each call replaces the user channel subscription.
+ */
+func (c *CLOBWebSocketClient) SubscribeUser(markets []string) error {
+	if c.apiKey == "" || c.apiSecret == "" || c.passphrase == "" {
+		return errors.New("the user channel requires API credentials")
+	}
+
+	c.connMu.Lock()
+	connected := c.outbound != nil
+	if connected && c.channelPath != userChannelPath {
+		c.connMu.Unlock()
+		return errors.New("client is connected to the market channel")
+	}
+	c.channelPath = userChannelPath
+	c.connMu.Unlock()
+
+	if !connected {
+		if err := c.Connect(); err != nil {
+			return err
+		}
+	}
+	return c.sendUserSubscription(markets)
+}
+
+// sendUserSubscription sends a user channel subscription on the current connection and
+// records it for reconnects.
+func (c *CLOBWebSocketClient) sendUserSubscription(markets []string) error {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+
+	if markets == nil {
+		markets = []string{}
+	}
+	message, err := json.Marshal(SubscriptionMessage{
+		Type:    "USER",
+		Markets: markets,
+		Auth: &Auth{
+			APIKey:     c.apiKey,
+			Secret:     c.apiSecret,
+			Passphrase: c.passphrase,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal user subscription message: %w", err)
+	}
+
+	c.logger.Info("subscribing to user channel", "market_count", len(markets))
+	if err := c.writeMessage(message); err != nil {
+		return fmt.Errorf("failed to send user subscription message: %w", err)
+	}
+
+	c.connSubscribed = true
+	c.userMarkets = markets
+	return nil
+}
+
+/**
+ * @description
+ * Unsubscribe stops order book updates for specific tokens and removes them from the
+ * persistent subscription set, so they are not resubscribed on reconnect.
+ *
+ * @param assetIDs The token IDs to unsubscribe from.
+ * @returns An error if not connected or the unsubscription message could not be sent.
+ *
+ * @notes
+ * - The assets are removed from the persistent set even if sending fails, as the next
+ *   reconnect drops them anyway.
+ */
+func (c *CLOBWebSocketClient) Unsubscribe(assetIDs []string) error {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+
+	for _, assetID := range assetIDs {
+		delete(c.subscribed, assetID)
+	}
+
+	message, err := json.Marshal(SubscriptionMessage{
+		Type:      "MARKET",
+		AssetsIDs: assetIDs,
+		Operation: "unsubscribe",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsubscription message: %w", err)
+	}
+
+	c.logger.Info("unsubscribing from market channel", "asset_count", len(assetIDs))
+	if err := c.writeMessage(message); err != nil {
+		return fmt.Errorf("failed to send unsubscription message: %w", err)
+	}
+
+	c.logger.Info("unsubscription message sent successfully", "asset_count", len(assetIDs), "total_subscribed", len(c.subscribed))
+	return nil
+}
+
+// SubscribedAssets returns the sorted set of asset IDs that will be resubscribed on reconnect.
+func (c *CLOBWebSocketClient) SubscribedAssets() []string {
+	c.subscribedMu.Lock()
+	defer c.subscribedMu.Unlock()
+
+	assetIDs := make([]string, 0, len(c.subscribed))
+	for assetID := range c.subscribed {
+		assetIDs = append(assetIDs, assetID)
+	}
+	sort.Strings(assetIDs)
+	return assetIDs
+}
+
+/**
+ * @description
+ * Reconnect replaces the current connection with a new one and resubscribes to
+ * exactly the persisted set of subscribed assets, or to the user channel subscription
+ * on the user channel.
+ *
+ * @returns An error if the connection or the resubscription failed.
+ */
+func (c *CLOBWebSocketClient) Reconnect() error {
+	c.closeConn()
+
+	if err := c.Connect(); err != nil {
+		return err
+	}
+
+	c.connMu.Lock()
+	userChannel := c.channelPath == userChannelPath
+	c.connMu.Unlock()
+	if userChannel {
+		c.subscribedMu.Lock()
+		markets := c.userMarkets
+		c.subscribedMu.Unlock()
+		c.logger.Info("resubscribing to user channel after reconnect", "market_count", len(markets))
+		return c.sendUserSubscription(markets)
+	}
+
+	assetIDs := c.SubscribedAssets()
+	if len(assetIDs) == 0 {
+		return nil
+	}
+	c.logger.Info("resubscribing after reconnect", "asset_count", len(assetIDs))
+	return c.Subscribe(assetIDs)
+}
+
+/**
+ * @description
+ * writeMessage queues a text message for the current connection's writer and waits for
+ * the result of the write.
+ *
+ * @param message The message to send.
+ * @returns An error if not connected, the connection was closed, the outbound queue stayed
+ *   full for longer than the write deadline, or the write failed.
+ */
+func (c *CLOBWebSocketClient) writeMessage(message []byte) error {
+	c.connMu.Lock()
+	outbound, writerDone := c.outbound, c.writerDone
+	c.connMu.Unlock()
+	if outbound == nil {
+		return fmt.Errorf("not connected to WebSocket")
+	}
+
+	queued := outboundMessage{data: message, result: make(chan error, 1)}
+	select {
+	case outbound <- queued:
+	case <-writerDone:
+		return errConnectionClosed
+	case <-time.After(wsWriteWait):
+		return errors.New("timed out queueing WebSocket message")
+	}
+
+	select {
+	case err := <-queued.result:
+		return err
+	case <-writerDone:
+		// The writer may have reported the result just before exiting.
+		select {
+		case err := <-queued.result:
+			return err
+		default:
+			return errConnectionClosed
+		}
+	}
+}
+
+// writer is the only goroutine writing to a connection. It writes queued messages until
+// the connection is closed or a write fails, in which case it closes the connection so
+// that Listen returns and the owner reconnects.
+func (c *CLOBWebSocketClient) writer(conn *gorillaWS.Conn, outbound <-chan outboundMessage, done <-chan struct{}, writerDone chan<- struct{}) {
+	defer close(writerDone)
+	for {
+		select {
+		case <-done:
+			return
+		case queued := <-outbound:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			err := conn.WriteMessage(gorillaWS.TextMessage, queued.data)
+			queued.result <- err
+			if err != nil {
+				c.logger.Error("WebSocket write failed, closing connection", "error", err)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// closeConn closes the current connection (if any) and stops its ping loop and writer.
+func (c *CLOBWebSocketClient) closeConn() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.connDone != nil {
+		close(c.connDone)
+		c.connDone = nil
+	}
+	c.outbound = nil
+	c.writerDone = nil
+	if c.conn != nil {
+		return c.conn.Close()
+	}
 	return nil
 }
 
 // Listen listens for incoming messages and calls the handler
 func (c *CLOBWebSocketClient) Listen(handler MessageHandler) error {
-	if c.conn == nil {
+	c.connMu.Lock()
+	conn, connDone := c.conn, c.connDone
+	c.connMu.Unlock()
+	if conn == nil || connDone == nil {
 		return fmt.Errorf("not connected to WebSocket")
 	}
 
-	// Start ping goroutine
-	go c.ping()
+	// Start ping goroutine for this connection
+	go c.ping(connDone)
 
 	messageCount := 0
 	for {
@@ -163,7 +607,7 @@ func (c *CLOBWebSocketClient) Listen(handler MessageHandler) error {
 		case <-c.ctx.Done():
 			return c.ctx.Err()
 		default:
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := conn.ReadMessage()
 			if err != nil {
 				if gorillaWS.IsUnexpectedCloseError(err, gorillaWS.CloseGoingAway, gorillaWS.CloseAbnormalClosure) {
 					c.logger.Error("WebSocket read error", "error", err)
@@ -179,6 +623,12 @@ func (c *CLOBWebSocketClient) Listen(handler MessageHandler) error {
 				continue
 			}
 
+			// Skip empty arrays ("[]") and empty objects ("{}"). The server sends these
+			// e.g. as an empty snapshot; they carry no data and are not parse failures.
+			if isEmptyJSONContainer(message) {
+				continue
+			}
+
 			// Try to parse as array of book messages first (initial snapshot)
 			var bookMessages []BookMessage
 			if err := json.Unmarshal(message, &bookMessages); err == nil && len(bookMessages) > 0 {
@@ -211,6 +661,29 @@ func (c *CLOBWebSocketClient) Listen(handler MessageHandler) error {
 				continue
 			}
 
+			// Try to parse as tick_size_change event
+			var tickSizeMsg TickSizeChangeMessage
+			if err := json.Unmarshal(message, &tickSizeMsg); err == nil && tickSizeMsg.EventType == "tick_size_change" {
+				c.handleTickSizeChange(&tickSizeMsg)
+				continue
+			}
+
+			// Try to parse as last_trade_price event
+			var tradeMsg LastTradePriceMessage
+			if err := json.Unmarshal(message, &tradeMsg); err == nil && tradeMsg.EventType == "last_trade_price" {
+				c.handleLastTradePrice(&tradeMsg)
+				continue
+			}
+
+			// Try to parse as user channel order and trade events
+			var userEvent struct {
+				EventType string `json:"event_type"`
+			}
+			if err := json.Unmarshal(message, &userEvent); err == nil && (userEvent.EventType == "order" || userEvent.EventType == "trade") {
+				c.handleUserEvent(userEvent.EventType, message)
+				continue
+			}
+
 			// Try to parse as price_change event
 			var priceChangeMsg PriceChangeMessage
 			if err := json.Unmarshal(message, &priceChangeMsg); err == nil && priceChangeMsg.EventType == "price_change" {
@@ -283,6 +756,22 @@ func (c *CLOBWebSocketClient) Listen(handler MessageHandler) error {
 						continue
 					}
 				}
+				// Check if it's a tick_size_change event in the wrapper
+				if wsMsg.EventType == "tick_size_change" {
+					var tickSizeMsg TickSizeChangeMessage
+					if err := json.Unmarshal(wsMsg.Data, &tickSizeMsg); err == nil {
+						c.handleTickSizeChange(&tickSizeMsg)
+						continue
+					}
+				}
+				// Check if it's a last_trade_price event in the wrapper
+				if wsMsg.EventType == "last_trade_price" {
+					var tradeMsg LastTradePriceMessage
+					if err := json.Unmarshal(wsMsg.Data, &tradeMsg); err == nil {
+						c.handleLastTradePrice(&tradeMsg)
+						continue
+					}
+				}
 				// Other message types (subscription confirmations, errors, etc.)
 				if wsMsg.Type == "subscribed" || wsMsg.Type == "subscription" {
 					c.logger.Info("✅ WebSocket: subscription confirmed", "type", wsMsg.Type)
@@ -295,18 +784,82 @@ func (c *CLOBWebSocketClient) Listen(handler MessageHandler) error {
 			// If we can't parse it at all, log the raw message for debugging
 			// This helps identify new message types from Polymarket
 			if messageCount <= 3 {
-				msgStr := string(message)
-				if len(msgStr) > 100 {
-					msgStr = msgStr[:100] + "..."
-				}
 				c.logger.Warn("⚠️  WebSocket: unparseable message", 
 					"message", messageCount,
-					"preview", msgStr)
+					"preview", logsafe.Body(message))
 			}
 		}
 	}
 }
 
+// handleTickSizeChange logs a tick_size_change event and passes it to the handler, if any.
+func (c *CLOBWebSocketClient) handleTickSizeChange(message *TickSizeChangeMessage) {
+	c.logger.Info("WebSocket: tick size changed",
+		"asset_id", message.AssetID,
+		"market", message.Market,
+		"old_tick_size", message.OldTickSize,
+		"new_tick_size", message.NewTickSize)
+	if c.tickSizeHandler != nil {
+		c.tickSizeHandler(message)
+	}
+}
+
+// handleLastTradePrice passes a last_trade_price event to the handler, if any.
+func (c *CLOBWebSocketClient) handleLastTradePrice(message *LastTradePriceMessage) {
+	if c.tradeHandler != nil {
+		c.tradeHandler(message)
+	}
+}
+
+// handleUserEvent parses a user channel order or trade event and passes it to its handler, if any.
+func (c *CLOBWebSocketClient) handleUserEvent(eventType string, message []byte) {
+	switch eventType {
+	case "order":
+		var orderMsg UserOrderMessage
+		if err := json.Unmarshal(message, &orderMsg); err != nil {
+			c.logger.Warn("WebSocket: failed to parse user order event", "error", err)
+			return
+		}
+		if c.userOrderHandler != nil {
+			c.userOrderHandler(&orderMsg)
+		}
+	case "trade":
+		var tradeMsg UserTradeMessage
+		if err := json.Unmarshal(message, &tradeMsg); err != nil {
+			c.logger.Warn("WebSocket: failed to parse user trade event", "error", err)
+			return
+		}
+		if c.userTradeHandler != nil {
+			c.userTradeHandler(&tradeMsg)
+		}
+	}
+}
+
+/**
+ * @description
+ * isEmptyJSONContainer reports whether a message is an empty JSON array or object.
+ *
+ * @param message The raw WebSocket message.
+ * @returns true for `[]` or `{}` (ignoring whitespace), false for anything else,
+ *          including invalid JSON.
+ */
+func isEmptyJSONContainer(message []byte) bool {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) == 0 {
+		return false
+	}
+	switch trimmed[0] {
+	case '[':
+		var elements []json.RawMessage
+		return json.Unmarshal(trimmed, &elements) == nil && len(elements) == 0
+	case '{':
+		var fields map[string]json.RawMessage
+		return json.Unmarshal(trimmed, &fields) == nil && len(fields) == 0
+	default:
+		return false
+	}
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
@@ -316,7 +869,8 @@ func min(a, b int) int {
 }
 
 // ping sends periodic PING messages to keep the connection alive
-func (c *CLOBWebSocketClient) ping() {
+// It stops when done is closed (the connection was replaced or closed) or the client shuts down
+func (c *CLOBWebSocketClient) ping(done <-chan struct{}) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
@@ -324,12 +878,12 @@ func (c *CLOBWebSocketClient) ping() {
 		select {
 		case <-c.ctx.Done():
 			return
+		case <-done:
+			return
 		case <-ticker.C:
-			if c.conn != nil {
-				if err := c.conn.WriteMessage(gorillaWS.TextMessage, []byte("PING")); err != nil {
-					c.logger.Error("failed to send ping", "error", err)
-					return
-				}
+			if err := c.writeMessage([]byte("PING")); err != nil {
+				c.logger.Error("failed to send ping", "error", err)
+				return
 			}
 		}
 	}
@@ -338,9 +892,5 @@ func (c *CLOBWebSocketClient) ping() {
 // Close closes the WebSocket connection
 func (c *CLOBWebSocketClient) Close() error {
 	c.cancel()
-	if c.conn != nil {
-		return c.conn.Close()
-	}
-	return nil
+	return c.closeConn()
 }
-
@@ -0,0 +1,298 @@
+/**
+ * @description
+ * This file implements a pool of CLOB WebSocket connections. The market channel has
+ * practical limits on how many assets a single connection can subscribe to, so large asset
+ * sets are split across several connections (shards), each a `CLOBWebSocketClient` with its
+ * own read loop, writer, and ping loop.
+ *
+ * Key features:
+ * - Sharding: Each shard holds at most `shardSize` assets. Subscriptions fill the lowest
+ *   shards with room first, and new shards are connected when every shard is full.
+ * - Independent Failures: Each shard is run by its own runner (see Run), which listens and
+ *   reconnects it on its own, so a dropped connection only interrupts that shard's assets.
+ *   A shard resubscribes to exactly its own assets when it reconnects.
+ * - Shared Handlers: Every shard feeds the same book, tick size, and trade handlers.
+ * - Drop-in: Subscribe, Unsubscribe, and SubscribedAssets work across all shards like on a
+ *   single client.
+ *
+ * @notes
+ * - Shards emptied by unsubscriptions stay connected and are filled again first, instead of
+ *   being closed and reopened as the allocation changes.
+ */
+
+package polymarket
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// DefaultShardSize is the number of assets per connection used when none is configured.
+const DefaultShardSize = 500
+
+// ShardRunner listens on a shard's connection and reconnects it until the pool is closed.
+// It is called in its own goroutine for every shard of a pool.
+type ShardRunner func(shard int, client *CLOBWebSocketClient)
+
+// poolShard is a connection of the pool and the number of assets assigned to it.
+type poolShard struct {
+	id     int
+	client *CLOBWebSocketClient
+	assets int
+}
+
+// CLOBWebSocketPool splits market channel subscriptions across several WebSocket connections.
+type CLOBWebSocketPool struct {
+	baseURL    string
+	apiKey     string
+	apiSecret  string
+	passphrase string
+	shardSize  int
+	logger     *slog.Logger
+
+	// Handlers set on every shard; set before Connect.
+	tickSizeHandler TickSizeChangeHandler
+	tradeHandler    LastTradePriceHandler
+
+	// mu guards the fields below.
+	mu         sync.Mutex
+	shards     []*poolShard
+	assetShard map[string]*poolShard // asset ID -> shard it is subscribed on
+	runner     ShardRunner           // nil until Run is called
+	runners    sync.WaitGroup
+	closed     bool
+	done       chan struct{} // closed by Close
+}
+
+// NewCLOBWebSocketPool creates a pool of CLOB WebSocket connections holding at most
+// shardSize assets each; a shardSize of 0 uses DefaultShardSize.
+func NewCLOBWebSocketPool(baseURL string, apiKey, apiSecret, passphrase string, shardSize int, logger *slog.Logger) *CLOBWebSocketPool {
+	if shardSize <= 0 {
+		shardSize = DefaultShardSize
+	}
+	return &CLOBWebSocketPool{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
+		passphrase: passphrase,
+		shardSize:  shardSize,
+		logger:     logger,
+		assetShard: make(map[string]*poolShard),
+		done:       make(chan struct{}),
+	}
+}
+
+// OnTickSizeChange sets the handler for tick_size_change events of every shard.
+// It must be called before Connect.
+func (p *CLOBWebSocketPool) OnTickSizeChange(handler TickSizeChangeHandler) {
+	p.tickSizeHandler = handler
+}
+
+// OnLastTradePrice sets the handler for last_trade_price events of every shard.
+// It must be called before Connect.
+func (p *CLOBWebSocketPool) OnLastTradePrice(handler LastTradePriceHandler) {
+	p.tradeHandler = handler
+}
+
+// Connect connects the pool's first shard, so that an unreachable WebSocket is reported
+// before anything is subscribed. Further shards are connected as subscriptions need them.
+func (p *CLOBWebSocketPool) Connect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.shards) > 0 {
+		return nil
+	}
+	_, err := p.addShard()
+	return err
+}
+
+// addShard connects a new shard and starts its runner if the pool is running. mu must be held.
+func (p *CLOBWebSocketPool) addShard() (*poolShard, error) {
+	if p.closed {
+		return nil, errConnectionClosed
+	}
+	id := len(p.shards)
+	client := NewCLOBWebSocketClient(p.baseURL, p.apiKey, p.apiSecret, p.passphrase, p.logger.With("shard", id))
+	client.OnTickSizeChange(p.tickSizeHandler)
+	client.OnLastTradePrice(p.tradeHandler)
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect shard %d: %w", id, err)
+	}
+
+	shard := &poolShard{id: id, client: client}
+	p.shards = append(p.shards, shard)
+	if p.runner != nil {
+		p.startRunner(shard)
+	}
+	p.logger.Info("CLOB WebSocket pool: shard connected", "shard", id, "shards", len(p.shards), "shard_size", p.shardSize)
+	return shard, nil
+}
+
+// startRunner runs the pool's runner for a shard in its own goroutine. mu must be held.
+func (p *CLOBWebSocketPool) startRunner(shard *poolShard) {
+	p.runners.Add(1)
+	go func() {
+		defer p.runners.Done()
+		p.runner(shard.id, shard.client)
+	}()
+}
+
+/**
+ * @description
+ * Run calls runner for every shard, including shards connected later, each in its own
+ * goroutine. It blocks until the pool is closed and every runner has returned.
+ *
+ * @param runner Listens on a shard and reconnects it; it must return once the shard's
+ *   client is closed.
+ */
+func (p *CLOBWebSocketPool) Run(runner ShardRunner) {
+	p.mu.Lock()
+	p.runner = runner
+	if !p.closed {
+		for _, shard := range p.shards {
+			p.startRunner(shard)
+		}
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	p.runners.Wait()
+}
+
+/**
+ * @description
+ * Subscribe subscribes to order book updates for specific tokens, placing them on the
+ * shards with room and connecting new shards as needed. Tokens already subscribed are
+ * skipped.
+ *
+ * @param assetIDs The token IDs to subscribe to.
+ * @returns An error if a subscription could not be sent on any shard; the tokens
+ *   subscribed before the failure stay subscribed.
+ *
+ * @notes
+ * - A shard whose subscription fails (e.g. while it is reconnecting) is skipped for the
+ *   rest of the call, and its tokens are placed on the next shard with room.
+ */
+func (p *CLOBWebSocketPool) Subscribe(assetIDs []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending := make([]string, 0, len(assetIDs))
+	seen := make(map[string]bool, len(assetIDs))
+	for _, assetID := range assetIDs {
+		if _, ok := p.assetShard[assetID]; ok || seen[assetID] {
+			continue
+		}
+		seen[assetID] = true
+		pending = append(pending, assetID)
+	}
+
+	failed := make(map[*poolShard]bool)
+	for len(pending) > 0 {
+		shard, err := p.shardWithRoom(failed)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe %d assets: %w", len(pending), err)
+		}
+		batch := pending[:min(p.shardSize-shard.assets, len(pending))]
+		if err := shard.client.Subscribe(batch); err != nil {
+			p.logger.Warn("CLOB WebSocket pool: subscription failed on shard, trying another", "shard", shard.id, "asset_count", len(batch), "error", err)
+			failed[shard] = true
+			continue
+		}
+		for _, assetID := range batch {
+			p.assetShard[assetID] = shard
+		}
+		shard.assets += len(batch)
+		pending = pending[len(batch):]
+	}
+	return nil
+}
+
+// shardWithRoom returns the first shard with room for more assets that is not in skip,
+// connecting a new shard if there is none. mu must be held.
+func (p *CLOBWebSocketPool) shardWithRoom(skip map[*poolShard]bool) (*poolShard, error) {
+	for _, shard := range p.shards {
+		if shard.assets < p.shardSize && !skip[shard] {
+			return shard, nil
+		}
+	}
+	return p.addShard()
+}
+
+/**
+ * @description
+ * Unsubscribe stops order book updates for specific tokens on the shards they are
+ * subscribed on. Like on a single client, the tokens are forgotten even if sending fails.
+ *
+ * @param assetIDs The token IDs to unsubscribe from.
+ * @returns The errors of the shards whose unsubscription could not be sent, joined.
+ */
+func (p *CLOBWebSocketPool) Unsubscribe(assetIDs []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	byShard := make(map[*poolShard][]string)
+	for _, assetID := range assetIDs {
+		if shard, ok := p.assetShard[assetID]; ok {
+			byShard[shard] = append(byShard[shard], assetID)
+			delete(p.assetShard, assetID)
+		}
+	}
+
+	var errs []error
+	for shard, shardAssets := range byShard {
+		shard.assets -= len(shardAssets)
+		if err := shard.client.Unsubscribe(shardAssets); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", shard.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SubscribedAssets returns the sorted set of asset IDs subscribed across all shards.
+func (p *CLOBWebSocketPool) SubscribedAssets() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assetIDs := make([]string, 0, len(p.assetShard))
+	for assetID := range p.assetShard {
+		assetIDs = append(assetIDs, assetID)
+	}
+	sort.Strings(assetIDs)
+	return assetIDs
+}
+
+// ShardAssets returns the number of assets subscribed on each shard, by shard.
+func (p *CLOBWebSocketPool) ShardAssets() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	counts := make([]int, len(p.shards))
+	for i, shard := range p.shards {
+		counts[i] = shard.assets
+	}
+	return counts
+}
+
+// Close closes every shard's connection, which ends their runners, and makes Run return.
+func (p *CLOBWebSocketPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+
+	var errs []error
+	for _, shard := range p.shards {
+		if err := shard.client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", shard.id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
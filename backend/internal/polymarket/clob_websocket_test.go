@@ -0,0 +1,370 @@
+package polymarket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorillaWS "github.com/gorilla/websocket"
+)
+
+// testWSServer is a WebSocket server that records the text messages it receives.
+type testWSServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []string
+	received chan struct{}
+}
+
+// newTestWSServer starts a server that accepts connections on any path. It never closes the
+// connections itself, so that a client connection only ends when the client closes it.
+func newTestWSServer(t *testing.T) *testWSServer {
+	t.Helper()
+	server := &testWSServer{received: make(chan struct{}, 1024)}
+	upgrader := gorillaWS.Upgrader{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.messages = append(server.messages, string(message))
+			server.mu.Unlock()
+			server.received <- struct{}{}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// waitMessages waits until the server has received n messages and returns them.
+func (s *testWSServer) waitMessages(t *testing.T, n int) []string {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.received:
+		case <-timeout:
+			t.Fatalf("received %d of %d messages", i, n)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// newTestWSClient connects a client to the server. It is closed when the test ends.
+func newTestWSClient(t *testing.T, server *testWSServer) *CLOBWebSocketClient {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewCLOBWebSocketClient("ws"+strings.TrimPrefix(server.URL, "http"), "", "", "", logger)
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// TestCLOBWebSocketConcurrentWrites writes pings, subscriptions and unsubscriptions from many
+// goroutines at once. gorilla/websocket panics on concurrent writers, and -race reports them.
+func TestCLOBWebSocketConcurrentWrites(t *testing.T) {
+	const (
+		goroutines = 8
+		writes     = 20
+	)
+	server := newTestWSServer(t)
+	client := newTestWSClient(t, server)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3*goroutines*writes)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				errs <- client.writeMessage([]byte("PING"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				errs <- client.Subscribe([]string{fmt.Sprintf("asset-%d-%d", g, i)})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				errs <- client.Unsubscribe([]string{fmt.Sprintf("asset-%d-%d", g, i)})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	messages := server.waitMessages(t, 3*goroutines*writes)
+	counts := make(map[string]int)
+	for _, message := range messages {
+		switch {
+		case message == "PING":
+			counts["ping"]++
+		case strings.Contains(message, `"unsubscribe"`):
+			counts["unsubscribe"]++
+		case strings.Contains(message, `"MARKET"`):
+			counts["subscribe"]++
+		default:
+			t.Errorf("unexpected message %q", message)
+		}
+	}
+	for _, kind := range []string{"ping", "subscribe", "unsubscribe"} {
+		if counts[kind] != goroutines*writes {
+			t.Errorf("%s messages = %d, want %d", kind, counts[kind], goroutines*writes)
+		}
+	}
+}
+
+// TestCLOBWebSocketFailedWriteClosesConnection checks that a failed write closes the
+// connection, ending Listen, and that later writes return errConnectionClosed.
+func TestCLOBWebSocketFailedWriteClosesConnection(t *testing.T) {
+	server := newTestWSServer(t)
+	client := newTestWSClient(t, server)
+	if err := client.writeMessage([]byte("PING")); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	server.waitMessages(t, 1)
+
+	listenDone := make(chan error, 1)
+	go func() { listenDone <- client.Listen(func(*BookMessage) error { return nil }) }()
+
+	// Shut down the sending side of the socket, so that the next write fails while reads
+	// would still block until the connection is closed.
+	client.connMu.Lock()
+	tcpConn := client.conn.NetConn().(*net.TCPConn)
+	client.connMu.Unlock()
+	if err := tcpConn.CloseWrite(); err != nil {
+		t.Fatalf("close write side: %v", err)
+	}
+
+	if err := client.writeMessage([]byte("PING")); err == nil {
+		t.Fatal("write after the socket was shut down succeeded")
+	}
+	select {
+	case err := <-listenDone:
+		if err == nil {
+			t.Error("Listen returned no error after the connection was closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after a failed write")
+	}
+
+	for _, write := range []func() error{
+		func() error { return client.writeMessage([]byte("PING")) },
+		func() error { return client.Subscribe([]string{"asset"}) },
+	} {
+		if err := write(); !errors.Is(err, errConnectionClosed) {
+			t.Errorf("write after failure = %v, want %v", err, errConnectionClosed)
+		}
+	}
+}
+
+// lockedBuffer is an io.Writer that can be read while a logger writes to it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newScriptedWSServer starts a server that sends messages to each connection and then
+// closes it normally.
+func newScriptedWSServer(t *testing.T, messages ...string) *httptest.Server {
+	t.Helper()
+	upgrader := gorillaWS.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		for _, message := range messages {
+			if err := conn.WriteMessage(gorillaWS.TextMessage, []byte(message)); err != nil {
+				t.Errorf("write %q: %v", message, err)
+				return
+			}
+		}
+		closing := gorillaWS.FormatCloseMessage(gorillaWS.CloseNormalClosure, "")
+		conn.WriteControl(gorillaWS.CloseMessage, closing, time.Now().Add(time.Second))
+		// Wait for the client to close its side.
+		conn.ReadMessage()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestCLOBWebSocketListenMessageShapes feeds each shape of message the CLOB sends through
+// Listen, and checks which handlers it reaches and whether it is logged as unparseable.
+func TestCLOBWebSocketListenMessageShapes(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		wantBooks   []string // Markets of the book messages passed to the handler
+		wantTrades  int
+		wantOrders  int
+		wantWarning bool
+	}{
+		{"empty array", `[]`, nil, 0, 0, false},
+		{"empty array with whitespace", " [ ]\n", nil, 0, 0, false},
+		{"empty object", `{}`, nil, 0, 0, false},
+		{"empty object with whitespace", "{ \n}", nil, 0, 0, false},
+		{"heartbeat", `PONG`, nil, 0, 0, false},
+		{"array of book events", `[{"event_type":"book","market":"0xa","bids":[],"asks":[]},{"event_type":"book","market":"0xb","bids":[],"asks":[]}]`,
+			[]string{"0xa", "0xb"}, 0, 0, false},
+		// Entries of a snapshot that are not book events are skipped.
+		{"array with another event", `[{"event_type":"book","market":"0xa"},{"event_type":"price_change","market":"0xb"}]`,
+			[]string{"0xa"}, 0, 0, false},
+		{"single book event", `{"event_type":"book","market":"0xa","bids":[{"price":"0.51","size":"10"}],"asks":[]}`,
+			[]string{"0xa"}, 0, 0, false},
+		{"single price change", `{"event_type":"price_change","market":"0xa","price":"0.52"}`,
+			[]string{"0xa"}, 0, 0, false},
+		{"single last trade price", `{"event_type":"last_trade_price","market":"0xa","price":"0.52","size":"5","side":"BUY"}`,
+			nil, 1, 0, false},
+		{"single user order", `{"event_type":"order","id":"0xorder","type":"PLACEMENT"}`,
+			nil, 0, 1, false},
+		{"subscription confirmation", `{"type":"subscribed"}`, nil, 0, 0, false},
+		{"not JSON", `hello`, nil, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedWSServer(t, tt.message)
+			logs := &lockedBuffer{}
+			logger := slog.New(slog.NewTextHandler(logs, nil))
+			client := NewCLOBWebSocketClient("ws"+strings.TrimPrefix(server.URL, "http"), "", "", "", logger)
+			var trades, orders int
+			client.OnLastTradePrice(func(*LastTradePriceMessage) { trades++ })
+			client.OnUserOrder(func(*UserOrderMessage) { orders++ })
+			if err := client.Connect(); err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			t.Cleanup(func() { client.Close() })
+
+			var books []string
+			listenDone := make(chan error, 1)
+			go func() {
+				listenDone <- client.Listen(func(message *BookMessage) error {
+					books = append(books, message.Market)
+					return nil
+				})
+			}()
+			select {
+			case <-listenDone:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Listen did not return after the server closed the connection")
+			}
+
+			if strings.Join(books, ",") != strings.Join(tt.wantBooks, ",") {
+				t.Errorf("book messages for %q, want %q", books, tt.wantBooks)
+			}
+			if trades != tt.wantTrades || orders != tt.wantOrders {
+				t.Errorf("trades = %d, orders = %d, want %d and %d", trades, orders, tt.wantTrades, tt.wantOrders)
+			}
+			if warned := strings.Contains(logs.String(), "unparseable message"); warned != tt.wantWarning {
+				t.Errorf("unparseable warning logged = %v, want %v", warned, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// TestCLOBWebSocketTickSizeChange checks that a tick_size_change event reaches the tick
+// size handler with its fields.
+func TestCLOBWebSocketTickSizeChange(t *testing.T) {
+	server := newScriptedWSServer(t, `{"event_type":"tick_size_change","asset_id":"12345","market":"0xa","old_tick_size":"0.01","new_tick_size":"0.001","timestamp":"1789000000000"}`)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewCLOBWebSocketClient("ws"+strings.TrimPrefix(server.URL, "http"), "", "", "", logger)
+	var changes []TickSizeChangeMessage
+	client.OnTickSizeChange(func(message *TickSizeChangeMessage) { changes = append(changes, *message) })
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	listenDone := make(chan error, 1)
+	go func() {
+		listenDone <- client.Listen(func(*BookMessage) error { return nil })
+	}()
+	select {
+	case <-listenDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after the server closed the connection")
+	}
+
+	want := TickSizeChangeMessage{EventType: "tick_size_change", AssetID: "12345", Market: "0xa", OldTickSize: "0.01", NewTickSize: "0.001", Timestamp: "1789000000000"}
+	if len(changes) != 1 || changes[0] != want {
+		t.Errorf("tick size changes = %+v, want %+v", changes, want)
+	}
+}
+
+// TestCLOBWebSocketReconnectResubscribes subscribes to assets one at a time, unsubscribes
+// from one, reconnects, and checks that the new connection subscribes to exactly the rest.
+func TestCLOBWebSocketReconnectResubscribes(t *testing.T) {
+	server := newTestWSServer(t)
+	client := newTestWSClient(t, server)
+	for _, write := range []func() error{
+		func() error { return client.Subscribe([]string{"asset-1"}) },
+		func() error { return client.Subscribe([]string{"asset-2", "asset-3"}) },
+		func() error { return client.Unsubscribe([]string{"asset-2"}) },
+	} {
+		if err := write(); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	server.waitMessages(t, 3)
+
+	if err := client.Reconnect(); err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	messages := server.waitMessages(t, 1)
+	if len(messages) != 4 {
+		t.Fatalf("received %q, want the resubscription after the 3 earlier messages", messages)
+	}
+	var resubscription SubscriptionMessage
+	if err := json.Unmarshal([]byte(messages[3]), &resubscription); err != nil {
+		t.Fatalf("decode %s: %v", messages[3], err)
+	}
+	// The first subscription on the new connection is a plain MARKET subscription.
+	if resubscription.Type != "MARKET" || resubscription.Operation != "" {
+		t.Errorf("resubscription = %s, want a plain MARKET subscription", messages[3])
+	}
+	if got := strings.Join(resubscription.AssetsIDs, ","); got != "asset-1,asset-3" {
+		t.Errorf("resubscribed to %s, want asset-1,asset-3", got)
+	}
+}
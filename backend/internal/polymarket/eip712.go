@@ -64,6 +64,23 @@ var PolymarketEIP712Types = apitypes.Types{
 	},
 }
 
+// Signature types of Polymarket orders, telling the exchange how the maker's signature is verified.
+const (
+	SignatureTypeEOA        = 0 // The maker is an externally owned account signing for itself
+	SignatureTypePolyProxy  = 1 // The maker is a Polymarket proxy wallet (email and Magic accounts)
+	SignatureTypeGnosisSafe = 2 // The maker is a Gnosis Safe (browser wallet accounts)
+)
+
+// ValidSignatureType reports whether signatureType is a signature type the exchange supports.
+func ValidSignatureType(signatureType int) bool {
+	switch signatureType {
+	case SignatureTypeEOA, SignatureTypePolyProxy, SignatureTypeGnosisSafe:
+		return true
+	default:
+		return false
+	}
+}
+
 // Order represents the EIP-712 message for a Polymarket CLOB order.
 // The fields are tagged with `json:"..."` to ensure correct serialization.
 type Order struct {
@@ -5,6 +5,8 @@
  *
  * Key features:
  * - Market Data Fetching: Retrieves market information by condition ID, slug, or ID
+ * - Market Search: Searches active markets by text through the public search endpoint
+ * - Event Fetching: Retrieves an event, with the markets of its outcomes, by slug
  * - Public API: No authentication required for market data endpoints
  * - Error Handling: Proper error handling for API responses
  * - Rate Limiting: Respects API rate limits
@@ -18,14 +20,19 @@
 package polymarket
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
+
+	"github.com/poly-pro/backend/internal/logsafe"
 )
 
 // GammaAPIClient handles interactions with Polymarket's Gamma API
@@ -71,10 +78,21 @@ type GammaMarket struct {
 	Icon             string    `json:"icon"`
 	Tokens           []Token   `json:"tokens"`
 	ClobTokenIds     string    `json:"clobTokenIds"` // Comma-separated or JSON array string of token IDs
+	Outcomes         string    `json:"outcomes"`     // JSON array string of outcome names, in ClobTokenIds order
+	GroupItemTitle   string    `json:"groupItemTitle"` // Outcome name within a multi-market event, e.g. a candidate
 	CreatedAt        string    `json:"createdAt"`
 	UpdatedAt        string    `json:"updatedAt"`
 }
 
+// GammaEvent represents an event from the Gamma API, which groups related markets, e.g. one
+// binary market per candidate of an election.
+type GammaEvent struct {
+	ID      string        `json:"id"`
+	Slug    string        `json:"slug"`
+	Title   string        `json:"title"`
+	Markets []GammaMarket `json:"markets"`
+}
+
 // Token represents a token in a market
 type Token struct {
 	TokenID string `json:"tokenId"`
@@ -82,12 +100,73 @@ type Token struct {
 	Price   string `json:"price"`
 }
 
+// YesTokenID returns the token ID of the market's "Yes" outcome.
+// If the outcome names are unavailable, the first token is assumed to be "Yes",
+// which is the order Polymarket lists binary outcomes in.
+func (m *GammaMarket) YesTokenID() (string, bool) {
+	tokenIDs := m.clobTokenIDs()
+	if len(tokenIDs) == 0 {
+		for _, token := range m.Tokens {
+			if strings.EqualFold(token.Outcome, "yes") && token.TokenID != "" {
+				return token.TokenID, true
+			}
+		}
+		if len(m.Tokens) > 0 && m.Tokens[0].TokenID != "" {
+			return m.Tokens[0].TokenID, true
+		}
+		return "", false
+	}
+
+	var outcomes []string
+	if err := json.Unmarshal([]byte(m.Outcomes), &outcomes); err == nil && len(outcomes) == len(tokenIDs) {
+		for i, outcome := range outcomes {
+			if strings.EqualFold(outcome, "yes") {
+				return tokenIDs[i], true
+			}
+		}
+	}
+	return tokenIDs[0], true
+}
+
+// TokenIDs returns the token IDs of all of the market's outcomes, taken from
+// clobTokenIds (a JSON array or comma-separated string), or from Tokens if that is empty.
+func (m *GammaMarket) TokenIDs() []string {
+	tokenIDs := m.clobTokenIDs()
+	if len(tokenIDs) == 0 {
+		for _, token := range m.Tokens {
+			if token.TokenID != "" {
+				tokenIDs = append(tokenIDs, token.TokenID)
+			}
+		}
+	}
+	return tokenIDs
+}
+
+// clobTokenIDs parses the clobTokenIds field as a JSON array or a comma-separated string.
+func (m *GammaMarket) clobTokenIDs() []string {
+	var tokenIDs []string
+	if err := json.Unmarshal([]byte(m.ClobTokenIds), &tokenIDs); err == nil {
+		return tokenIDs
+	}
+	tokenIDs = nil
+	for _, part := range strings.Split(m.ClobTokenIds, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			tokenIDs = append(tokenIDs, trimmed)
+		}
+	}
+	return tokenIDs
+}
+
 // GammaError represents an error response from the Gamma API
 type GammaError struct {
 	Message string `json:"message"`
 	Error   string `json:"error"`
 }
 
+// ErrMarketNotFound is returned when the Gamma API has no market for the requested slug or
+// condition ID.
+var ErrMarketNotFound = errors.New("market not found")
+
 // GetMarketByConditionID fetches a market by its condition ID
 func (c *GammaAPIClient) GetMarketByConditionID(ctx context.Context, conditionID string) (*GammaMarket, error) {
 	// Use the markets endpoint with conditionId query parameter
@@ -120,7 +199,7 @@ func (c *GammaAPIClient) GetMarketByConditionID(ctx context.Context, conditionID
 		if err := json.Unmarshal(body, &gammaErr); err == nil {
 			return nil, fmt.Errorf("Gamma API error: %s", gammaErr.Error)
 		}
-		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
 	}
 
 	// Gamma API returns an array of markets
@@ -130,7 +209,7 @@ func (c *GammaAPIClient) GetMarketByConditionID(ctx context.Context, conditionID
 	}
 
 	if len(markets) == 0 {
-		return nil, fmt.Errorf("market not found for condition ID: %s", conditionID)
+		return nil, fmt.Errorf("%w for condition ID: %s", ErrMarketNotFound, conditionID)
 	}
 
 	// Return the first market (should only be one for a specific condition ID)
@@ -163,22 +242,94 @@ func (c *GammaAPIClient) GetMarketBySlug(ctx context.Context, slug string) (*Gam
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w for slug: %s", ErrMarketNotFound, slug)
+	}
 	if resp.StatusCode != http.StatusOK {
 		var gammaErr GammaError
 		if err := json.Unmarshal(body, &gammaErr); err == nil {
 			return nil, fmt.Errorf("Gamma API error: %s", gammaErr.Error)
 		}
-		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	market, err := decodeSlugMarket(body)
+	if err != nil {
+		if errors.Is(err, ErrMarketNotFound) {
+			return nil, fmt.Errorf("%w for slug: %s", ErrMarketNotFound, slug)
+		}
+		return nil, err
+	}
+	return market, nil
+}
+
+// decodeSlugMarket decodes a market-by-slug response, which some Gamma deployments return as
+// a market object and others as an array of markets. An empty array or null is not found.
+func decodeSlugMarket(body []byte) (*GammaMarket, error) {
+	trimmed := bytes.TrimSpace(body)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil, ErrMarketNotFound
+	}
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var markets []GammaMarket
+		if err := json.Unmarshal(trimmed, &markets); err != nil {
+			return nil, fmt.Errorf("failed to parse market response: %w", err)
+		}
+		if len(markets) == 0 {
+			return nil, ErrMarketNotFound
+		}
+		return &markets[0], nil
 	}
 
 	var market GammaMarket
-	if err := json.Unmarshal(body, &market); err != nil {
+	if err := json.Unmarshal(trimmed, &market); err != nil {
 		return nil, fmt.Errorf("failed to parse market response: %w", err)
 	}
-
 	return &market, nil
 }
 
+// GetEventBySlug fetches an event and its markets by the event's slug
+func (c *GammaAPIClient) GetEventBySlug(ctx context.Context, slug string) (*GammaEvent, error) {
+	apiURL := fmt.Sprintf("%s/events/slug/%s", c.baseURL, url.PathEscape(slug))
+
+	c.logger.Info("fetching event from Gamma API by slug", "slug", slug)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to fetch event from Gamma API", "error", err, "slug", slug)
+		return nil, fmt.Errorf("failed to fetch event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var gammaErr GammaError
+		if err := json.Unmarshal(body, &gammaErr); err == nil {
+			return nil, fmt.Errorf("Gamma API error: %s", gammaErr.Error)
+		}
+		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var event GammaEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse event response: %w", err)
+	}
+
+	return &event, nil
+}
+
 // ListActiveMarkets fetches all active markets from the Gamma API
 // It supports pagination and filtering to get only active (non-closed) markets
 // By default, it orders by volume (descending) to get the top markets by volume
@@ -215,7 +366,7 @@ func (c *GammaAPIClient) ListActiveMarkets(ctx context.Context, limit int, offse
 		if err := json.Unmarshal(body, &gammaErr); err == nil {
 			return nil, fmt.Errorf("Gamma API error: %s", gammaErr.Error)
 		}
-		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
 	}
 
 	var markets []GammaMarket
@@ -227,6 +378,69 @@ func (c *GammaAPIClient) ListActiveMarkets(ctx context.Context, limit int, offse
 	return markets, nil
 }
 
+// gammaSearchResponse is the part of a Gamma public search response holding markets.
+type gammaSearchResponse struct {
+	Events []struct {
+		Markets []GammaMarket `json:"markets"`
+	} `json:"events"`
+}
+
+// SearchMarkets searches active markets by text with the Gamma public search.
+// Markets are returned in the order of their events' relevance, at most limit of them.
+func (c *GammaAPIClient) SearchMarkets(ctx context.Context, query string, limit int) ([]GammaMarket, error) {
+	apiURL := fmt.Sprintf("%s/public-search?q=%s&limit_per_type=%d&events_status=active",
+		c.baseURL, url.QueryEscape(query), limit)
+
+	c.logger.Info("searching markets with Gamma API", "query", query, "limit", limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "poly-pro-backend/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("failed to search markets with Gamma API", "error", err, "query", query)
+		return nil, fmt.Errorf("failed to search markets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var gammaErr GammaError
+		if err := json.Unmarshal(body, &gammaErr); err == nil {
+			return nil, fmt.Errorf("Gamma API error: %s", gammaErr.Error)
+		}
+		return nil, fmt.Errorf("Gamma API returned status %d: %s", resp.StatusCode, logsafe.Body(body))
+	}
+
+	var result gammaSearchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse search response: %w", err)
+	}
+
+	// An event groups several markets; a market can only appear under one event.
+	var markets []GammaMarket
+	for _, event := range result.Events {
+		for _, market := range event.Markets {
+			if len(markets) == limit {
+				return markets, nil
+			}
+			if market.ConditionID != "" {
+				markets = append(markets, market)
+			}
+		}
+	}
+	return markets, nil
+}
+
 // GetAllActiveMarkets fetches all active markets by paginating through the API
 // It continues fetching until no more markets are returned
 func (c *GammaAPIClient) GetAllActiveMarkets(ctx context.Context) ([]GammaMarket, error) {
@@ -0,0 +1,107 @@
+package polymarket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	fixtureSlug        = "fed-rate-cut-december-2026"
+	fixtureConditionID = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+)
+
+// readGammaFixture returns a recorded Gamma API response from testdata/gamma.
+func readGammaFixture(t *testing.T, name string) string {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "gamma", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return string(body)
+}
+
+func TestGetMarketBySlug(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		want     string // the condition ID of the market returned
+		notFound bool
+	}{
+		{"object response", http.StatusOK, readGammaFixture(t, "market_by_slug_object.json"), fixtureConditionID, false},
+		{"array response", http.StatusOK, readGammaFixture(t, "market_by_slug_array.json"), fixtureConditionID, false},
+		{"array of several markets", http.StatusOK, `[{"conditionId":"0xfirst"},{"conditionId":"0xsecond"}]`, "0xfirst", false},
+		{"not found status", http.StatusNotFound, `{"error":"market not found"}`, "", true},
+		{"empty array", http.StatusOK, "[]\n", "", true},
+		{"null", http.StatusOK, "null", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			gamma := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			t.Cleanup(gamma.Close)
+			client := NewGammaAPIClient(gamma.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			market, err := client.GetMarketBySlug(context.Background(), fixtureSlug)
+			if path != "/markets/slug/"+fixtureSlug {
+				t.Errorf("requested %s", path)
+			}
+			if tt.notFound {
+				if !errors.Is(err, ErrMarketNotFound) || market != nil {
+					t.Fatalf("GetMarketBySlug = %+v, %v; want %v", market, err, ErrMarketNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetMarketBySlug: %v", err)
+			}
+			if market.ConditionID != tt.want {
+				t.Errorf("condition ID = %q, want %q", market.ConditionID, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetMarketBySlugFixtureFields checks that the recorded response decodes completely,
+// whichever shape it came in.
+func TestGetMarketBySlugFixtureFields(t *testing.T) {
+	for _, fixture := range []string{"market_by_slug_object.json", "market_by_slug_array.json"} {
+		t.Run(fixture, func(t *testing.T) {
+			market, err := decodeSlugMarket([]byte(readGammaFixture(t, fixture)))
+			if err != nil {
+				t.Fatalf("decodeSlugMarket: %v", err)
+			}
+			if market.Slug != fixtureSlug || market.Question != "Will the Fed cut rates in December 2026?" {
+				t.Errorf("market = %q, %q", market.Slug, market.Question)
+			}
+			if yes, ok := market.YesTokenID(); !ok || yes != "71321045679252212594626385532706912750332728571942532289631379312455583992563" {
+				t.Errorf("YesTokenID = %q, %v", yes, ok)
+			}
+			if len(market.TokenIDs()) != 2 || market.VolumeNum == nil || *market.VolumeNum != 1284533.102391 {
+				t.Errorf("tokens = %v, volume = %v", market.TokenIDs(), market.VolumeNum)
+			}
+		})
+	}
+}
+
+// TestGetMarketBySlugMalformed checks that a response in neither shape is an error, and not
+// reported as a missing market.
+func TestGetMarketBySlugMalformed(t *testing.T) {
+	for _, body := range []string{`"fed-rate-cut"`, `[{"conditionId":1}]`, `{"conditionId":`, ``} {
+		if market, err := decodeSlugMarket([]byte(body)); err == nil || errors.Is(err, ErrMarketNotFound) {
+			t.Errorf("decodeSlugMarket(%q) = %+v, %v; want a parse error", body, market, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/**
+ * @description
+ * This file implements the parsing of the date fields of Gamma API markets, which are passed
+ * through as strings and come in several formats depending on the market's age and the field.
+ *
+ * Key features:
+ * - Observed Formats: RFC3339 with or without fractional seconds (`2024-11-05T12:00:00Z`,
+ *   `2024-01-04T22:57:23.336Z`), the PostgreSQL text form with a space and a short offset
+ *   (`2020-11-02 16:31:01+00`), ISO timestamps without a zone, and plain dates (`2024-11-05`).
+ * - UTC: Timestamps without a zone, and plain dates, are read as UTC.
+ */
+
+package polymarket
+
+import (
+	"strings"
+	"time"
+)
+
+// gammaDateLayouts are the layouts Gamma dates are parsed with, most common first.
+var gammaDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ParseGammaDate parses a Gamma API date in any of the observed formats.
+// It returns false for empty or unparseable values.
+func ParseGammaDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range gammaDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseGammaDatePtr parses an optional Gamma date, returning nil if it is missing or unparseable.
+func parseGammaDatePtr(value *string) *time.Time {
+	if value == nil {
+		return nil
+	}
+	parsed, ok := ParseGammaDate(*value)
+	if !ok {
+		return nil
+	}
+	return &parsed
+}
+
+// StartTime returns the market's parsed start date, or nil if it is missing or unparseable.
+func (m *GammaMarket) StartTime() *time.Time {
+	return parseGammaDatePtr(m.StartDate)
+}
+
+// EndTime returns the market's parsed end date, or nil if it is missing or unparseable.
+func (m *GammaMarket) EndTime() *time.Time {
+	return parseGammaDatePtr(m.EndDate)
+}
+
+// CreatedTime returns the market's parsed creation time, or nil if it is missing or unparseable.
+func (m *GammaMarket) CreatedTime() *time.Time {
+	return parseGammaDatePtr(&m.CreatedAt)
+}
+
+// UpdatedTime returns the market's parsed update time, or nil if it is missing or unparseable.
+func (m *GammaMarket) UpdatedTime() *time.Time {
+	return parseGammaDatePtr(&m.UpdatedAt)
+}
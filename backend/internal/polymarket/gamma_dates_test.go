@@ -0,0 +1,71 @@
+package polymarket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGammaDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string // RFC3339Nano in UTC; empty if the value must be rejected
+	}{
+		{"RFC3339", "2024-11-05T12:00:00Z", "2024-11-05T12:00:00Z"},
+		{"RFC3339 with milliseconds", "2024-01-04T22:57:23.336Z", "2024-01-04T22:57:23.336Z"},
+		{"RFC3339 with microseconds", "2024-01-04T22:57:23.336512Z", "2024-01-04T22:57:23.336512Z"},
+		{"RFC3339 with an offset", "2024-11-05T07:00:00-05:00", "2024-11-05T12:00:00Z"},
+		{"PostgreSQL text", "2020-11-02 16:31:01+00", "2020-11-02T16:31:01Z"},
+		{"PostgreSQL text with fractions", "2020-11-02 16:31:01.123456+00", "2020-11-02T16:31:01.123456Z"},
+		{"PostgreSQL text with a full offset", "2020-11-02 18:31:01+02:00", "2020-11-02T16:31:01Z"},
+		{"space and Z", "2020-11-02 16:31:01Z", "2020-11-02T16:31:01Z"},
+		{"short offset", "2020-11-02T16:31:01+00", "2020-11-02T16:31:01Z"},
+		// Timestamps without a zone, and plain dates, are UTC.
+		{"ISO without a zone", "2024-11-05T12:00:00", "2024-11-05T12:00:00Z"},
+		{"ISO without a zone with fractions", "2024-11-05T12:00:00.5", "2024-11-05T12:00:00.5Z"},
+		{"space without a zone", "2024-11-05 12:00:00", "2024-11-05T12:00:00Z"},
+		{"plain date", "2024-11-05", "2024-11-05T00:00:00Z"},
+		{"surrounding spaces", " 2024-11-05T12:00:00Z\n", "2024-11-05T12:00:00Z"},
+		{"empty", "", ""},
+		{"blank", "   ", ""},
+		{"US format", "11/05/2024", ""},
+		{"out of range", "2024-13-05", ""},
+		{"Unix seconds", "1730808000", ""},
+		{"text", "TBD", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseGammaDate(tt.value)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("ParseGammaDate(%q) = %s, want it rejected", tt.value, got)
+				}
+				return
+			}
+			if !ok || got.Format(time.RFC3339Nano) != tt.want || got.Location() != time.UTC {
+				t.Errorf("ParseGammaDate(%q) = %s, %v; want %s", tt.value, got.Format(time.RFC3339Nano), ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGammaMarketTimes(t *testing.T) {
+	start, end := "2024-01-04T22:57:23.336Z", "not a date"
+	market := GammaMarket{StartDate: &start, EndDate: &end, CreatedAt: "2024-01-04 22:57:23+00"}
+
+	if got := market.StartTime(); got == nil || !got.Equal(time.Date(2024, 1, 4, 22, 57, 23, 336_000_000, time.UTC)) {
+		t.Errorf("StartTime = %v", got)
+	}
+	if got := market.EndTime(); got != nil {
+		t.Errorf("EndTime of an unparseable date = %v, want nil", got)
+	}
+	if got := market.CreatedTime(); got == nil || !got.Equal(time.Date(2024, 1, 4, 22, 57, 23, 0, time.UTC)) {
+		t.Errorf("CreatedTime = %v", got)
+	}
+	if got := market.UpdatedTime(); got != nil {
+		t.Errorf("UpdatedTime of an empty date = %v, want nil", got)
+	}
+	if got := (&GammaMarket{}).EndTime(); got != nil {
+		t.Errorf("EndTime of a missing date = %v, want nil", got)
+	}
+}
@@ -0,0 +1,245 @@
+/**
+ * @description
+ * This service computes summary statistics over a market's stored daily bars, so that
+ * analytics views do not have to download every bar to the browser.
+ *
+ * Key features:
+ * - Realized Volatility: Standard deviation of daily changes in the log-odds of the close.
+ *   Prediction-market prices are probabilities bounded by 0 and 1, so log-odds are used in
+ *   place of log prices, with prices clamped away from the bounds.
+ * - Range Statistics: Average daily high-low range, and the largest rise from a trough
+ *   (drawup) and fall from a peak (drawdown) of the daily closes, in price units.
+ * - Coverage: The ratio of stored daily bars to days in the window.
+ * - Caching: Results are cached in Redis for a few minutes per (market, window).
+ *
+ * @notes
+ * - Statistics that need more bars than are available are returned as null rather than 0.
+ * - Volatility is per day and not annualized.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/poly-pro/backend/internal/cachekeys"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// priceBoundEpsilon keeps prices away from 0 and 1, where log-odds are infinite.
+	priceBoundEpsilon = 0.001
+)
+
+// statsWindows maps the supported window names to their length in days.
+var statsWindows = map[string]int{
+	"7d":  7,
+	"30d": 30,
+}
+
+// ErrInvalidStatsWindow is returned for windows other than those in statsWindows.
+var ErrInvalidStatsWindow = errors.New("window must be one of: 7d, 30d")
+
+// MarketStats holds the statistics of a market's daily bars over a window.
+type MarketStats struct {
+	MarketID           string    `json:"market_id"`
+	Window             string    `json:"window"`
+	From               time.Time `json:"from"`
+	To                 time.Time `json:"to"`
+	Bars               int       `json:"bars"`
+	ExpectedBars       int       `json:"expected_bars"`
+	CoverageRatio      float64   `json:"coverage_ratio"`
+	RealizedVolatility *float64  `json:"realized_volatility"` // Daily stddev of log-odds changes
+	AverageDailyRange  *float64  `json:"average_daily_range"`
+	MaxDrawup          *float64  `json:"max_drawup"`
+	MaxDrawdown        *float64  `json:"max_drawdown"`
+	GeneratedAt        time.Time `json:"generated_at"`
+}
+
+// dailyBar holds the prices of a stored daily bar used by the statistics.
+type dailyBar struct {
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// AnalyticsService computes market statistics from stored OHLCV bars.
+type AnalyticsService struct {
+	store       db.Querier
+	redisClient *redis.Client
+	logger      *slog.Logger
+}
+
+/**
+ * @description
+ * NewAnalyticsService creates a new instance of the AnalyticsService.
+ *
+ * @param store The database querier for reading stored bars.
+ * @param redisClient The Redis client used to cache results.
+ * @param logger A structured logger for logging service-level events.
+ * @returns A pointer to a new AnalyticsService instance.
+ */
+func NewAnalyticsService(store db.Querier, redisClient *redis.Client, logger *slog.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		store:       store,
+		redisClient: redisClient,
+		logger:      logger,
+	}
+}
+
+/**
+ * @description
+ * MarketStats returns the statistics of a market's daily bars over a window ending today.
+ *
+ * @param ctx The context for the operation.
+ * @param marketID The market's condition ID.
+ * @param window The window name ("7d" or "30d").
+ * @returns The statistics, or ErrInvalidStatsWindow / a database error.
+ */
+func (s *AnalyticsService) MarketStats(ctx context.Context, marketID string, window string) (*MarketStats, error) {
+	days, ok := statsWindows[window]
+	if !ok {
+		return nil, ErrInvalidStatsWindow
+	}
+
+	cacheKey := cachekeys.AnalyticsStats.Key(marketID, window)
+	if cached, err := s.redisClient.Get(ctx, cacheKey).Bytes(); err == nil {
+		var stats MarketStats
+		if err := json.Unmarshal(cached, &stats); err == nil {
+			return &stats, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		s.logger.Warn("failed to read market stats from cache", "error", err, "market_id", marketID)
+	}
+
+	// The window covers the current (partial) day and the days-1 full days before it.
+	now := time.Now().UTC()
+	from := BucketStart(now, 24*time.Hour).AddDate(0, 0, -(days - 1))
+	rows, err := s.store.GetMarketPriceHistory(ctx, db.GetMarketPriceHistoryParams{
+		MarketID:   marketID,
+		Time:       pgtype.Timestamptz{Time: from, Valid: true},
+		Time_2:     pgtype.Timestamptz{Time: now, Valid: true},
+		Resolution: "D",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily bars: %w", err)
+	}
+
+	stats := computeMarketStats(dailyBars(rows), days)
+	stats.MarketID = marketID
+	stats.Window = window
+	stats.From = from
+	stats.To = now
+	stats.GeneratedAt = now
+
+	if !cachekeys.AnalyticsStats.Enabled() {
+		return stats, nil
+	}
+	if encoded, err := json.Marshal(stats); err == nil {
+		if err := s.redisClient.Set(ctx, cacheKey, encoded, cachekeys.AnalyticsStats.TTL).Err(); err != nil {
+			s.logger.Warn("failed to cache market stats", "error", err, "market_id", marketID)
+		}
+	}
+	return stats, nil
+}
+
+// dailyBars converts stored bars, skipping bars with missing or non-finite prices.
+func dailyBars(rows []db.MarketPriceHistory) []dailyBar {
+	bars := make([]dailyBar, 0, len(rows))
+	for _, row := range rows {
+		high, okHigh := numericFloat(row.High)
+		low, okLow := numericFloat(row.Low)
+		closePrice, okClose := numericFloat(row.Close)
+		if !okHigh || !okLow || !okClose {
+			continue
+		}
+		bars = append(bars, dailyBar{High: high, Low: low, Close: closePrice})
+	}
+	return bars
+}
+
+// computeMarketStats computes the statistics of time-ordered daily bars over a window of
+// expectedBars days.
+func computeMarketStats(bars []dailyBar, expectedBars int) *MarketStats {
+	stats := &MarketStats{
+		Bars:         len(bars),
+		ExpectedBars: expectedBars,
+	}
+	if expectedBars > 0 {
+		stats.CoverageRatio = math.Min(float64(len(bars))/float64(expectedBars), 1)
+	}
+	if len(bars) == 0 {
+		return stats
+	}
+
+	// Average daily range.
+	rangeSum := 0.0
+	for _, bar := range bars {
+		rangeSum += bar.High - bar.Low
+	}
+	averageRange := rangeSum / float64(len(bars))
+	stats.AverageDailyRange = &averageRange
+
+	// Max drawup and drawdown of the closes.
+	peak, trough := bars[0].Close, bars[0].Close
+	drawup, drawdown := 0.0, 0.0
+	for _, bar := range bars[1:] {
+		drawup = math.Max(drawup, bar.Close-trough)
+		drawdown = math.Max(drawdown, peak-bar.Close)
+		peak = math.Max(peak, bar.Close)
+		trough = math.Min(trough, bar.Close)
+	}
+	stats.MaxDrawup = &drawup
+	stats.MaxDrawdown = &drawdown
+
+	// Realized volatility needs at least two changes for a sample standard deviation.
+	if len(bars) < 3 {
+		return stats
+	}
+	changes := make([]float64, 0, len(bars)-1)
+	for i := 1; i < len(bars); i++ {
+		changes = append(changes, logOdds(bars[i].Close)-logOdds(bars[i-1].Close))
+	}
+	volatility := sampleStdDev(changes)
+	stats.RealizedVolatility = &volatility
+	return stats
+}
+
+// logOdds returns log(p / (1 - p)), with p clamped to [priceBoundEpsilon, 1-priceBoundEpsilon].
+func logOdds(price float64) float64 {
+	p := math.Min(math.Max(price, priceBoundEpsilon), 1-priceBoundEpsilon)
+	return math.Log(p / (1 - p))
+}
+
+// sampleStdDev returns the sample standard deviation of values, which must have at least two.
+func sampleStdDev(values []float64) float64 {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	sumSquares := 0.0
+	for _, v := range values {
+		sumSquares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sumSquares / float64(len(values)-1))
+}
+
+// numericFloat converts a numeric column to float64, reporting false if it is null, NaN or
+// infinite.
+func numericFloat(n pgtype.Numeric) (float64, bool) {
+	value, err := n.Float64Value()
+	if err != nil || !value.Valid || math.IsNaN(value.Float64) || math.IsInf(value.Float64, 0) {
+		return 0, false
+	}
+	return value.Float64, true
+}
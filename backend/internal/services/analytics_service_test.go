@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/redis/go-redis/v9"
+)
+
+// approxEqual reports whether got is within 1e-9 of want.
+func approxEqual(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
+
+// checkStat compares an optional statistic with want, where nil means it must be null.
+func checkStat(t *testing.T, name string, got *float64, want *float64) {
+	t.Helper()
+	switch {
+	case want == nil && got != nil:
+		t.Errorf("%s = %v, want null", name, *got)
+	case want != nil && got == nil:
+		t.Errorf("%s = null, want %v", name, *want)
+	case want != nil && !approxEqual(*got, *want):
+		t.Errorf("%s = %v, want %v", name, *got, *want)
+	}
+}
+
+func stat(v float64) *float64 { return &v }
+
+// TestComputeMarketStats checks the statistics against values computed by hand.
+func TestComputeMarketStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		bars         []dailyBar
+		expectedBars int
+		coverage     float64
+		volatility   *float64
+		averageRange *float64
+		drawup       *float64
+		drawdown     *float64
+	}{
+		{
+			// Ranges 0.10 + 0.15 + 0.24 + 0.32 = 0.81 over 4 days. The closes rise from the
+			// 0.4 trough to 0.7, and fall from the 0.6 peak to 0.4. The log-odds of the
+			// closes are 0, ln 1.5, -ln 1.5 and ln(7/3), whose changes have a sample
+			// standard deviation of 1.0373331841699274.
+			name: "four days",
+			bars: []dailyBar{
+				{High: 0.55, Low: 0.45, Close: 0.5},
+				{High: 0.65, Low: 0.50, Close: 0.6},
+				{High: 0.62, Low: 0.38, Close: 0.4},
+				{High: 0.72, Low: 0.40, Close: 0.7},
+			},
+			expectedBars: 7,
+			coverage:     4.0 / 7,
+			volatility:   stat(1.0373331841699274),
+			averageRange: stat(0.2025),
+			drawup:       stat(0.3),
+			drawdown:     stat(0.2),
+		},
+		{
+			// Prices at the bounds are clamped to 0.001 and 0.999, whose log-odds are
+			// ∓ln 999, so the changes are ±2 ln 999 and their deviation is 2√2 ln 999.
+			name: "prices at the bounds",
+			bars: []dailyBar{
+				{High: 0.2, Low: 0, Close: 0},
+				{High: 1, Low: 0, Close: 1},
+				{High: 1, Low: 0, Close: 0},
+			},
+			expectedBars: 7,
+			coverage:     3.0 / 7,
+			volatility:   stat(2 * math.Sqrt2 * math.Log(999)),
+			averageRange: stat(2.2 / 3),
+			drawup:       stat(1),
+			drawdown:     stat(1),
+		},
+		{
+			// Constant prices have no volatility, range or drawdown, but none are null.
+			name:         "flat",
+			bars:         []dailyBar{{0.5, 0.5, 0.5}, {0.5, 0.5, 0.5}, {0.5, 0.5, 0.5}},
+			expectedBars: 3,
+			coverage:     1,
+			volatility:   stat(0),
+			averageRange: stat(0),
+			drawup:       stat(0),
+			drawdown:     stat(0),
+		},
+		{
+			// One change is not enough for a sample standard deviation.
+			name:         "two days",
+			bars:         []dailyBar{{0.5, 0.4, 0.45}, {0.6, 0.4, 0.55}},
+			expectedBars: 30,
+			coverage:     2.0 / 30,
+			averageRange: stat(0.15),
+			drawup:       stat(0.1),
+			drawdown:     stat(0),
+		},
+		{
+			name:         "one day",
+			bars:         []dailyBar{{0.5, 0.4, 0.45}},
+			expectedBars: 7,
+			coverage:     1.0 / 7,
+			averageRange: stat(0.1),
+			drawup:       stat(0),
+			drawdown:     stat(0),
+		},
+		{
+			name:         "no bars",
+			expectedBars: 7,
+		},
+		{
+			// More bars than days, e.g. the partial current day and a full window.
+			name:         "coverage is capped",
+			bars:         []dailyBar{{0.5, 0.5, 0.5}, {0.5, 0.5, 0.5}},
+			expectedBars: 1,
+			coverage:     1,
+			averageRange: stat(0),
+			drawup:       stat(0),
+			drawdown:     stat(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := computeMarketStats(tt.bars, tt.expectedBars)
+			if stats.Bars != len(tt.bars) || stats.ExpectedBars != tt.expectedBars {
+				t.Errorf("bars = %d of %d, want %d of %d", stats.Bars, stats.ExpectedBars, len(tt.bars), tt.expectedBars)
+			}
+			if !approxEqual(stats.CoverageRatio, tt.coverage) {
+				t.Errorf("coverage = %v, want %v", stats.CoverageRatio, tt.coverage)
+			}
+			checkStat(t, "realized volatility", stats.RealizedVolatility, tt.volatility)
+			checkStat(t, "average daily range", stats.AverageDailyRange, tt.averageRange)
+			checkStat(t, "max drawup", stats.MaxDrawup, tt.drawup)
+			checkStat(t, "max drawdown", stats.MaxDrawdown, tt.drawdown)
+		})
+	}
+}
+
+// dailyBarStore is a db.Querier that returns fixed daily bars and records the query.
+type dailyBarStore struct {
+	db.Querier
+	rows  []db.MarketPriceHistory
+	query db.GetMarketPriceHistoryParams
+}
+
+func (s *dailyBarStore) GetMarketPriceHistory(_ context.Context, arg db.GetMarketPriceHistoryParams) ([]db.MarketPriceHistory, error) {
+	s.query = arg
+	return s.rows, nil
+}
+
+// TestMarketStatsWindow checks the window and resolution queried, that stored bars with
+// missing or non-finite prices are skipped, and that an unreachable cache is not an error.
+func TestMarketStatsWindow(t *testing.T) {
+	price := func(value string) pgtype.Numeric {
+		var n pgtype.Numeric
+		if err := n.Scan(value); err != nil {
+			t.Fatalf("numeric %q: %v", value, err)
+		}
+		return n
+	}
+	bar := func(high, low, closePrice string) db.MarketPriceHistory {
+		return db.MarketPriceHistory{High: price(high), Low: price(low), Close: price(closePrice)}
+	}
+	store := &dailyBarStore{rows: []db.MarketPriceHistory{
+		bar("0.55", "0.45", "0.5"),
+		bar("0.65", "NaN", "0.6"),
+		{High: price("0.6"), Low: price("0.5")}, // No close
+		bar("0.72", "0.40", "0.7"),
+	}}
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { redisClient.Close() })
+	service := NewAnalyticsService(store, redisClient, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if _, err := service.MarketStats(context.Background(), "0xmarket", "90d"); !errors.Is(err, ErrInvalidStatsWindow) {
+		t.Errorf("90d window error = %v, want %v", err, ErrInvalidStatsWindow)
+	}
+
+	stats, err := service.MarketStats(context.Background(), "0xmarket", "30d")
+	if err != nil {
+		t.Fatalf("MarketStats: %v", err)
+	}
+	if store.query.MarketID != "0xmarket" || store.query.Resolution != "D" {
+		t.Errorf("queried %q bars of %q", store.query.Resolution, store.query.MarketID)
+	}
+	from := store.query.Time.Time
+	if from.Hour() != 0 || from.Minute() != 0 || from.Location() != time.UTC {
+		t.Errorf("window starts at %s, want a UTC midnight", from)
+	}
+	if days := store.query.Time_2.Time.Sub(from).Hours() / 24; days < 29 || days > 30 {
+		t.Errorf("window spans %.2f days, want the current day and the 29 before", days)
+	}
+	if stats.Window != "30d" || stats.Bars != 2 || stats.ExpectedBars != 30 || !stats.From.Equal(from) {
+		t.Errorf("stats = %+v", stats)
+	}
+	checkStat(t, "average daily range", stats.AverageDailyRange, stat(0.21))
+}
@@ -0,0 +1,121 @@
+/**
+ * @description
+ * This file defines the `BarEventPublisher`, through which the OHLCV aggregator publishes
+ * every completed bar to Redis, so that clients can update their charts live instead of
+ * polling the history endpoint.
+ *
+ * Key features:
+ * - Per-Resolution Channels: Bars are published to `ohlcv:<condition_id>:<resolution>`
+ *   (built by `channels.OHLCVChannel`), to which WebSocket clients subscribe through the hub.
+ * - Payload: Bars are `wire.Bar` payloads, with the t/o/h/l/c/v fields of the history
+ *   endpoint's bars plus the market and resolution.
+ * - Completed Bars Only: Bars are published when their period has ended, including flat bars
+ *   saved by gap filling. Bars saved while still in progress (e.g. by `FlushMarket` or on
+ *   eviction) are not published.
+ *
+ * @notes
+ * - Publishing is best-effort: failures are logged and never fail the save of the bar. Failed
+ *   publishes are retried by the publish buffer, when one is set.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/poly-pro/backend/internal/wire"
+	"github.com/redis/go-redis/v9"
+)
+
+// barEventType is the value of the "event_type" field of bar events.
+const barEventType = "bar"
+
+// BarEventPublisher publishes completed OHLCV bars to Redis.
+type BarEventPublisher struct {
+	redisClient *redis.Client
+	buffer      *PublishBuffer // Retries failed publishes; nil publishes directly
+	logger      *slog.Logger
+	published   atomic.Int64
+	failed      atomic.Int64
+}
+
+// BarEventStats counts the bar events published since startup.
+type BarEventStats struct {
+	Published int64 `json:"published"`
+	Failed    int64 `json:"failed"`
+}
+
+/**
+ * @description
+ * NewBarEventPublisher creates a new BarEventPublisher.
+ *
+ * @param redisClient The Redis client to publish with; if nil, bars are not published.
+ * @param logger A structured logger.
+ * @returns A pointer to a new BarEventPublisher instance.
+ */
+func NewBarEventPublisher(redisClient *redis.Client, logger *slog.Logger) *BarEventPublisher {
+	return &BarEventPublisher{
+		redisClient: redisClient,
+		logger:      logger,
+	}
+}
+
+/**
+ * @description
+ * Publish publishes a completed bar on the OHLCV channel of its market and resolution.
+ *
+ * @param ctx The context for the Redis call.
+ * @param bar The completed bar, which must not be modified concurrently.
+ */
+func (p *BarEventPublisher) Publish(ctx context.Context, bar *CurrentBar) {
+	if p == nil || p.redisClient == nil {
+		return
+	}
+
+	event := wire.Bar{
+		SchemaVersion: wire.SchemaVersion,
+		EventType:     barEventType,
+		Market:        bar.MarketID,
+		Resolution:    bar.Resolution,
+		Time:          bar.StartTime.Unix(),
+		Open:          bar.Open,
+		High:          bar.High,
+		Low:           bar.Low,
+		Close:         bar.Close,
+		Volume:        bar.Volume,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		p.logger.Error("failed to marshal bar event", "error", err, "market_id", bar.MarketID, "resolution", bar.Resolution)
+		p.failed.Add(1)
+		return
+	}
+
+	channel := channels.OHLCVChannel(bar.MarketID, bar.Resolution)
+	if err := publish(ctx, p.redisClient, p.buffer, channel, payload, PublishDurable); err != nil {
+		p.logger.Warn("failed to publish bar event", "error", err, "channel", channel)
+		p.failed.Add(1)
+		return
+	}
+	p.published.Add(1)
+}
+
+// SetPublishBuffer sets the buffer that retries failed publishes. It must be called before
+// bars are published.
+func (p *BarEventPublisher) SetPublishBuffer(buffer *PublishBuffer) {
+	if p != nil {
+		p.buffer = buffer
+	}
+}
+
+// Stats returns the number of bar events published and failed since startup.
+func (p *BarEventPublisher) Stats() BarEventStats {
+	if p == nil {
+		return BarEventStats{}
+	}
+	return BarEventStats{Published: p.published.Load(), Failed: p.failed.Load()}
+}
@@ -0,0 +1,244 @@
+/**
+ * @description
+ * This file implements the BarWatchdog, which detects bars silently failing to be written for
+ * a subset of markets: books keep flowing and being published, but some markets' bars never
+ * reach the database (e.g. because of numeric conversion errors), which the PipelineMonitor
+ * cannot see as long as other markets' bars are still saved.
+ *
+ * Key features:
+ * - Per-Market Check: Every `barWatchdogCheckInterval`, the markets with messages accepted for
+ *   aggregation (from the stream's activity) are compared with the markets that have a 1m bar
+ *   saved within the window.
+ * - Discrepancy Reporting: Markets missing bars are logged, counted, and listed in Status for
+ *   the diagnostics endpoint.
+ * - Re-flush: Optionally, the in-memory bars of the affected markets are flushed once more per
+ *   detection, so that a transient failure does not leave a gap until the next bar completes.
+ *
+ * @notes
+ * - A market is only expected to have bars for messages seen at the previous check, at least
+ *   one check interval ago, so that the bars of recent messages have had time to complete and
+ *   be flushed.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+const (
+	// defaultBarWatchdogWindow is used when no window is configured.
+	defaultBarWatchdogWindow = 10 * time.Minute
+	// barWatchdogCheckInterval is how often the watchdog compares activity with saved bars.
+	// It must exceed a 1m bar's duration plus the aggregator's flush period.
+	barWatchdogCheckInterval = 3 * time.Minute
+	// barWatchdogResolution is the resolution whose saved bars are checked.
+	barWatchdogResolution = "1"
+	// maxReportedMissingBars bounds the markets listed in BarWatchdogStatus.
+	maxReportedMissingBars = 100
+)
+
+// MissingBars describes a market with accepted messages but no recently saved bar.
+type MissingBars struct {
+	ConditionID   string    `json:"condition_id"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+// BarWatchdogStatus is a snapshot of the bar watchdog's findings.
+type BarWatchdogStatus struct {
+	Window          string        `json:"window"`
+	Reflush         bool          `json:"reflush"`
+	Checks          int64         `json:"checks"`
+	LastCheckAt     *time.Time    `json:"last_check_at,omitempty"`
+	LastError       string        `json:"last_error,omitempty"`
+	ExpectedMarkets int           `json:"expected_markets"` // Markets expected to have bars at the last check
+	Missing         []MissingBars `json:"missing"`          // Markets missing bars at the last check
+	Truncated       bool          `json:"truncated"`        // Missing was cut to maxReportedMissingBars
+	Discrepancies   int64         `json:"discrepancies"`    // Markets found missing bars, summed over all checks
+	Reflushes       int64         `json:"reflushes"`        // Re-flush attempts of affected markets
+}
+
+// BarWatchdog watches for streamed markets whose bars stop being saved.
+type BarWatchdog struct {
+	ctx     context.Context
+	logger  *slog.Logger
+	store   db.Querier
+	stream  *MarketStreamService
+	window  time.Duration
+	reflush bool
+
+	mu           sync.Mutex
+	lastActivity map[string]time.Time // Stream activity at the previous check
+	status       BarWatchdogStatus
+}
+
+/**
+ * @description
+ * NewBarWatchdog creates a new BarWatchdog.
+ *
+ * @param ctx The root context; Run returns when it is cancelled.
+ * @param logger A structured logger.
+ * @param store The database queried for saved bars.
+ * @param stream The market stream service whose activity and aggregator are watched.
+ * @param window How recent a market's last saved 1m bar must be; 0 uses the default, and
+ *   values below the check interval are raised to it.
+ * @param reflush Whether the in-memory bars of markets missing bars are flushed again.
+ * @returns A pointer to a new BarWatchdog instance.
+ */
+func NewBarWatchdog(ctx context.Context, logger *slog.Logger, store db.Querier, stream *MarketStreamService, window time.Duration, reflush bool) *BarWatchdog {
+	if window <= 0 {
+		window = defaultBarWatchdogWindow
+	}
+	if window < barWatchdogCheckInterval {
+		window = barWatchdogCheckInterval
+	}
+	return &BarWatchdog{
+		ctx:     ctx,
+		logger:  logger,
+		store:   store,
+		stream:  stream,
+		window:  window,
+		reflush: reflush,
+		status: BarWatchdogStatus{
+			Window:  window.String(),
+			Reflush: reflush,
+			Missing: []MissingBars{},
+		},
+	}
+}
+
+// Run checks the markets periodically until the context is cancelled.
+// It should be started as a goroutine.
+func (w *BarWatchdog) Run() {
+	ticker := time.NewTicker(barWatchdogCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(time.Now())
+		}
+	}
+}
+
+// check compares the markets active at the previous check with the markets that have
+// recently saved bars, and records the discrepancies.
+func (w *BarWatchdog) check(now time.Time) {
+	activity := w.stream.MarketActivity()
+
+	w.mu.Lock()
+	previous := w.lastActivity
+	w.lastActivity = activity
+	w.mu.Unlock()
+
+	// Messages at the previous check must have produced bars by now, as long as they are
+	// within the window. A bar starts at most one bar duration before its messages.
+	cutoff := now.Add(-w.window)
+	expected := make(map[string]time.Time)
+	for conditionID, at := range previous {
+		if !at.Before(cutoff) {
+			expected[conditionID] = at
+		}
+	}
+
+	var missing []MissingBars
+	var checkErr error
+	if len(expected) > 0 {
+		withBars, err := w.store.ListMarketIDsWithBarsSince(w.ctx, db.ListMarketIDsWithBarsSinceParams{
+			Resolution: barWatchdogResolution,
+			Since:      pgtype.Timestamptz{Time: cutoff.Add(-time.Minute), Valid: true},
+		})
+		if err != nil {
+			checkErr = fmt.Errorf("failed to list markets with recent bars: %w", err)
+		} else {
+			missing = findMissingBars(expected, withBars)
+		}
+	}
+
+	reflushes := 0
+	if checkErr == nil && len(missing) > 0 {
+		sample := make([]string, 0, 10)
+		for _, market := range missing {
+			if len(sample) < cap(sample) {
+				sample = append(sample, market.ConditionID)
+			}
+		}
+		w.logger.Error("OHLCV bars missing for streamed markets: messages are accepted but no 1m bar was saved",
+			"markets", len(missing),
+			"expected_markets", len(expected),
+			"window", w.window,
+			"sample", sample)
+
+		if w.reflush {
+			for _, market := range missing {
+				result := w.stream.Aggregator().FlushMarket(market.ConditionID)
+				reflushes++
+				w.logger.Info("re-flushed bars of market missing bars",
+					"condition_id", market.ConditionID,
+					"bars_written", result.BarsWritten,
+					"bars_failed", result.BarsFailed)
+			}
+		}
+	}
+	if checkErr != nil {
+		w.logger.Warn("bar watchdog check failed", "error", checkErr)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	checkedAt := now.UTC()
+	w.status.Checks++
+	w.status.LastCheckAt = &checkedAt
+	if checkErr != nil {
+		w.status.LastError = checkErr.Error()
+		return
+	}
+	w.status.LastError = ""
+	w.status.ExpectedMarkets = len(expected)
+	w.status.Discrepancies += int64(len(missing))
+	w.status.Reflushes += int64(reflushes)
+	w.status.Truncated = len(missing) > maxReportedMissingBars
+	if w.status.Truncated {
+		missing = missing[:maxReportedMissingBars]
+	}
+	w.status.Missing = append([]MissingBars{}, missing...)
+}
+
+// findMissingBars returns the expected markets without saved bars, most recently active first.
+func findMissingBars(expected map[string]time.Time, withBars []string) []MissingBars {
+	saved := make(map[string]bool, len(withBars))
+	for _, marketID := range withBars {
+		saved[marketID] = true
+	}
+	var missing []MissingBars
+	for conditionID, at := range expected {
+		if !saved[conditionID] {
+			missing = append(missing, MissingBars{ConditionID: conditionID, LastMessageAt: at.UTC()})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if !missing[i].LastMessageAt.Equal(missing[j].LastMessageAt) {
+			return missing[i].LastMessageAt.After(missing[j].LastMessageAt)
+		}
+		return missing[i].ConditionID < missing[j].ConditionID
+	})
+	return missing
+}
+
+// Status returns a snapshot of the watchdog's findings.
+func (w *BarWatchdog) Status() BarWatchdogStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := w.status
+	status.Missing = append([]MissingBars{}, w.status.Missing...)
+	return status
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/poly-pro/backend/internal/config"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// unlistableBarStore is a barStore whose markets with recent bars cannot be listed.
+type unlistableBarStore struct {
+	*barStore
+}
+
+func (unlistableBarStore) ListMarketIDsWithBarsSince(context.Context, db.ListMarketIDsWithBarsSinceParams) ([]string, error) {
+	return nil, errors.New("database unavailable")
+}
+
+// newTestBarWatchdog creates a watchdog over a stream service saving 1m bars to store.
+func newTestBarWatchdog(store db.Querier, reflush bool) (*BarWatchdog, *MarketStreamService) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	stream := NewMarketStreamService(context.Background(), logger, nil, config.Config{}, store, nil, nil)
+	stream.Aggregator().resolutions = []ResolutionDef{resolutionDef(barWatchdogResolution)}
+	return NewBarWatchdog(context.Background(), logger, store, stream, 10*time.Minute, reflush), stream
+}
+
+// addBar stores a 1m bar of a market starting at start.
+func (s *barStore) addBar(marketID string, start time.Time) {
+	key := checkpointField(marketID, barWatchdogResolution)
+	if s.bars[key] == nil {
+		s.bars[key] = make(map[time.Time]*storedBar)
+	}
+	s.bars[key][start] = &storedBar{0.5, 0.5, 0.5, 0.5, 1}
+}
+
+// TestBarWatchdogDetectsMissingBars records activity for markets with and without saved
+// bars, and checks that only the active markets without bars are reported, and only once
+// their messages are a check old.
+func TestBarWatchdogDetectsMissingBars(t *testing.T) {
+	store := newBarStore()
+	watchdog, stream := newTestBarWatchdog(store, false)
+	now := time.Now()
+	stream.recordMarketActivity("0xsaved", now.Add(-30*time.Second))
+	stream.recordMarketActivity("0xmissing", now.Add(-time.Minute))
+	// Active long before the window, so its bars are not expected to be recent.
+	stream.recordMarketActivity("0xidle", now.Add(-15*time.Minute))
+	store.addBar("0xsaved", BucketStart(now.Add(-30*time.Second), time.Minute))
+
+	// At the first check, no messages are old enough for their bars to be expected.
+	watchdog.check(now)
+	if status := watchdog.Status(); status.Checks != 1 || status.ExpectedMarkets != 0 || len(status.Missing) != 0 {
+		t.Fatalf("first check: %+v, want nothing expected", status)
+	}
+
+	// A market first active after the first check is not expected at the second.
+	stream.recordMarketActivity("0xnew", now.Add(time.Minute))
+	watchdog.check(now.Add(barWatchdogCheckInterval))
+	status := watchdog.Status()
+	want := []MissingBars{{ConditionID: "0xmissing", LastMessageAt: now.Add(-time.Minute).UTC()}}
+	if status.ExpectedMarkets != 2 || !reflect.DeepEqual(status.Missing, want) || status.Discrepancies != 1 {
+		t.Errorf("second check: expected %d, missing %+v, discrepancies %d; want 2, %+v, 1",
+			status.ExpectedMarkets, status.Missing, status.Discrepancies, want)
+	}
+	if status.Reflushes != 0 {
+		t.Errorf("%d re-flushes with re-flushing disabled", status.Reflushes)
+	}
+
+	// Once the market's bars are saved, it is no longer reported, but the count remains.
+	store.addBar("0xmissing", BucketStart(now, time.Minute))
+	store.addBar("0xnew", BucketStart(now.Add(time.Minute), time.Minute))
+	watchdog.check(now.Add(2 * barWatchdogCheckInterval))
+	if status := watchdog.Status(); len(status.Missing) != 0 || status.Discrepancies != 1 || status.Checks != 3 {
+		t.Errorf("third check: missing %+v, discrepancies %d, checks %d; want none, 1, 3", status.Missing, status.Discrepancies, status.Checks)
+	}
+}
+
+// TestBarWatchdogReflush checks that the in-memory bars of a market missing bars are flushed
+// again on every detection, until a flush succeeds and the market stops being reported.
+func TestBarWatchdogReflush(t *testing.T) {
+	store := newBarStore()
+	store.reject = "0xbroken"
+	watchdog, stream := newTestBarWatchdog(store, true)
+	now := time.Now()
+	tradeAt := now.Add(-30 * time.Second)
+	stream.recordMarketActivity("0xbroken", tradeAt)
+	if err := stream.Aggregator().UpdateTrade("0xbroken", 0.5, 10, tradeAt); err != nil {
+		t.Fatalf("update trade: %v", err)
+	}
+
+	watchdog.check(now)
+	watchdog.check(now.Add(barWatchdogCheckInterval))
+	if status := watchdog.Status(); len(status.Missing) != 1 || status.Reflushes != 1 {
+		t.Fatalf("while saves fail: missing %+v, re-flushes %d; want the market, 1", status.Missing, status.Reflushes)
+	}
+	if len(store.saved) != 0 {
+		t.Fatalf("saved %v while saves fail", store.saved)
+	}
+
+	store.reject = ""
+	watchdog.check(now.Add(2 * barWatchdogCheckInterval))
+	if status := watchdog.Status(); status.Reflushes != 2 || status.Discrepancies != 2 {
+		t.Errorf("after the fix: re-flushes %d, discrepancies %d; want 2, 2", status.Reflushes, status.Discrepancies)
+	}
+	if want := BucketStart(tradeAt, time.Minute); len(store.saved) != 1 || !store.saved[0].Equal(want) {
+		t.Errorf("re-flush saved %v, want the bar starting %s", store.saved, want)
+	}
+	watchdog.check(now.Add(3 * barWatchdogCheckInterval))
+	if status := watchdog.Status(); len(status.Missing) != 0 || status.Reflushes != 2 {
+		t.Errorf("after the re-flush: missing %+v, re-flushes %d; want none, 2", status.Missing, status.Reflushes)
+	}
+}
+
+// TestBarWatchdogCheckError checks that a failed query is reported without clearing the
+// previous findings.
+func TestBarWatchdogCheckError(t *testing.T) {
+	store := newBarStore()
+	watchdog, stream := newTestBarWatchdog(store, false)
+	now := time.Now()
+	stream.recordMarketActivity("0xmissing", now)
+	watchdog.check(now)
+	watchdog.check(now.Add(barWatchdogCheckInterval))
+
+	watchdog.store = unlistableBarStore{store}
+	watchdog.check(now.Add(2 * barWatchdogCheckInterval))
+	status := watchdog.Status()
+	if status.LastError == "" || status.Checks != 3 {
+		t.Errorf("last error %q after %d checks, want the failed query after 3", status.LastError, status.Checks)
+	}
+	if len(status.Missing) != 1 || status.Discrepancies != 1 {
+		t.Errorf("missing %+v, discrepancies %d; want the previous finding kept", status.Missing, status.Discrepancies)
+	}
+
+	watchdog.store = store
+	watchdog.check(now.Add(3 * barWatchdogCheckInterval))
+	if status := watchdog.Status(); status.LastError != "" {
+		t.Errorf("last error %q after a successful check", status.LastError)
+	}
+}
+
+func TestFindMissingBars(t *testing.T) {
+	at := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	expected := map[string]time.Time{
+		"0xa": at,
+		"0xb": at.Add(time.Minute),
+		"0xc": at,
+		"0xd": at.Add(2 * time.Minute),
+	}
+	got := findMissingBars(expected, []string{"0xd", "0xother"})
+	// Most recently active first, then by condition ID.
+	want := []MissingBars{
+		{ConditionID: "0xb", LastMessageAt: at.Add(time.Minute)},
+		{ConditionID: "0xa", LastMessageAt: at},
+		{ConditionID: "0xc", LastMessageAt: at},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findMissingBars = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewBarWatchdogWindow(t *testing.T) {
+	tests := []struct {
+		window, want time.Duration
+	}{
+		{0, defaultBarWatchdogWindow},
+		{-time.Minute, defaultBarWatchdogWindow},
+		// A window shorter than a check would expect bars before they can be saved.
+		{time.Minute, barWatchdogCheckInterval},
+		{time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		watchdog := NewBarWatchdog(context.Background(), nil, nil, nil, tt.window, false)
+		if watchdog.window != tt.want || watchdog.Status().Window != tt.want.String() {
+			t.Errorf("window %s: got %s, want %s", tt.window, watchdog.window, tt.want)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+/**
+ * @description
+ * This service compares the OHLCV bars we store for a market against Polymarket's own
+ * prices-history series, to support data-quality reviews of the charts.
+ *
+ * Key features:
+ * - Identical Bucketing: Upstream price points are bucketed with the same bar start logic
+ *   as the OHLCV aggregator; the last point in a bucket is its close.
+ * - Per-Bucket Report: Each bucket reports our close, the upstream close, and their delta,
+ *   along with buckets missing on either side.
+ * - Summary Statistics: Max and mean absolute close-price deviation over matched buckets.
+ * - Cached Jobs: Results are cached per (market, resolution, range). Large ranges are
+ *   computed asynchronously and polled for by repeating the same request.
+ *
+ * @notes
+ * - Only the market's YES token is compared, as that is the series our charts show.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+const (
+	// consistencyAsyncBuckets is the bucket count above which checks run asynchronously.
+	consistencyAsyncBuckets = 1000
+	// consistencyMaxBuckets caps the number of buckets a single check can span.
+	consistencyMaxBuckets = 50000
+	// consistencyCacheTTL is how long completed results are served from the cache.
+	consistencyCacheTTL = 15 * time.Minute
+	// consistencyFailureTTL is how long a failure is reported before the check is retried.
+	consistencyFailureTTL = time.Minute
+	// consistencyJobTimeout bounds how long a single check may run.
+	consistencyJobTimeout = 5 * time.Minute
+)
+
+// Consistency job states.
+const (
+	ConsistencyJobRunning   = "running"
+	ConsistencyJobCompleted = "completed"
+	ConsistencyJobFailed    = "failed"
+)
+
+var (
+	// ErrConsistencyRangeTooLarge is returned when a check spans too many buckets.
+	ErrConsistencyRangeTooLarge = errors.New("requested range spans too many buckets")
+	// ErrUnsupportedResolution is returned for resolutions the aggregator does not produce.
+	ErrUnsupportedResolution = errors.New("unsupported resolution")
+)
+
+// ConsistencyParams identifies a consistency check.
+type ConsistencyParams struct {
+	MarketID   string `json:"market_id"` // Condition ID
+	Resolution string `json:"resolution"`
+	From       int64  `json:"from"` // Unix seconds, inclusive
+	To         int64  `json:"to"`   // Unix seconds, inclusive
+}
+
+// ConsistencyBucket compares one bar's close with the upstream close for the same bucket.
+type ConsistencyBucket struct {
+	Time          int64    `json:"time"` // Bucket start, Unix seconds
+	LocalClose    *float64 `json:"local_close"`
+	UpstreamClose *float64 `json:"upstream_close"`
+	Delta         *float64 `json:"delta,omitempty"` // LocalClose - UpstreamClose, when both exist
+}
+
+// ConsistencySummary aggregates the per-bucket comparison.
+type ConsistencySummary struct {
+	Buckets          int     `json:"buckets"`
+	Matched          int     `json:"matched"`
+	MissingLocal     int     `json:"missing_local"`    // Upstream has a close, we have no bar
+	MissingUpstream  int     `json:"missing_upstream"` // We have a bar, upstream has no point
+	MaxDeviation     float64 `json:"max_deviation"`    // Max |delta| over matched buckets
+	MaxDeviationTime int64   `json:"max_deviation_time,omitempty"`
+	MeanDeviation    float64 `json:"mean_deviation"` // Mean |delta| over matched buckets
+}
+
+// ConsistencyReport is the result of a consistency check.
+type ConsistencyReport struct {
+	ConsistencyParams
+	TokenID     string              `json:"token_id"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Summary     ConsistencySummary  `json:"summary"`
+	Buckets     []ConsistencyBucket `json:"buckets"`
+}
+
+// ConsistencyJob tracks a (possibly asynchronous) consistency check.
+type ConsistencyJob struct {
+	Params      ConsistencyParams  `json:"params"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error,omitempty"`
+	StartedAt   time.Time          `json:"started_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	Report      *ConsistencyReport `json:"report,omitempty"`
+}
+
+// ChartConsistencyService compares stored bars against Polymarket's prices-history.
+type ChartConsistencyService struct {
+	ctx         context.Context
+	store       db.Querier
+	gammaClient *polymarket.GammaAPIClient
+	clobClient  *polymarket.CLOBAPIClient
+	logger      *slog.Logger
+
+	mu   sync.Mutex
+	jobs map[ConsistencyParams]*ConsistencyJob
+}
+
+/**
+ * @description
+ * NewChartConsistencyService creates a new instance of the ChartConsistencyService.
+ *
+ * @param ctx The root context; asynchronous checks are cancelled when it is.
+ * @param store The database querier for reading stored bars.
+ * @param gammaClient The Gamma API client, used to look up the market's YES token.
+ * @param clobClient The CLOB API client, used to fetch the upstream price history.
+ * @param logger A structured logger for logging service-level events.
+ * @returns A pointer to a new ChartConsistencyService instance.
+ */
+func NewChartConsistencyService(ctx context.Context, store db.Querier, gammaClient *polymarket.GammaAPIClient, clobClient *polymarket.CLOBAPIClient, logger *slog.Logger) *ChartConsistencyService {
+	return &ChartConsistencyService{
+		ctx:         ctx,
+		store:       store,
+		gammaClient: gammaClient,
+		clobClient:  clobClient,
+		logger:      logger,
+		jobs:        make(map[ConsistencyParams]*ConsistencyJob),
+	}
+}
+
+/**
+ * @description
+ * Check returns the consistency job for the given parameters. A cached job is returned if
+ * one is running or finished recently. Otherwise a new check is started: small ranges are
+ * computed before returning, large ranges in the background.
+ *
+ * @param ctx The request context, used for synchronous checks.
+ * @param params The market, resolution, and range to compare.
+ * @returns A snapshot of the job; its status is "running" while an asynchronous check is in progress.
+ */
+func (s *ChartConsistencyService) Check(ctx context.Context, params ConsistencyParams) (ConsistencyJob, error) {
+	barDuration, ok := ResolutionDuration(params.Resolution)
+	if !ok {
+		return ConsistencyJob{}, ErrUnsupportedResolution
+	}
+	buckets := (params.To-params.From)/int64(barDuration/time.Second) + 1
+	if buckets > consistencyMaxBuckets {
+		return ConsistencyJob{}, ErrConsistencyRangeTooLarge
+	}
+
+	s.mu.Lock()
+	s.evictExpiredLocked()
+	if job, ok := s.jobs[params]; ok {
+		snapshot := *job
+		s.mu.Unlock()
+		return snapshot, nil
+	}
+	job := &ConsistencyJob{Params: params, Status: ConsistencyJobRunning, StartedAt: time.Now().UTC()}
+	s.jobs[params] = job
+	s.mu.Unlock()
+
+	if buckets > consistencyAsyncBuckets {
+		go func() {
+			jobCtx, cancel := context.WithTimeout(s.ctx, consistencyJobTimeout)
+			defer cancel()
+			s.finish(jobCtx, job)
+		}()
+		return s.snapshot(job), nil
+	}
+
+	s.finish(ctx, job)
+	return s.snapshot(job), nil
+}
+
+// finish runs the comparison for a job and records its outcome.
+func (s *ChartConsistencyService) finish(ctx context.Context, job *ConsistencyJob) {
+	report, err := s.compare(ctx, job.Params)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	completedAt := time.Now().UTC()
+	job.CompletedAt = &completedAt
+	if err != nil {
+		s.logger.Warn("chart consistency check failed", "error", err, "market_id", job.Params.MarketID, "resolution", job.Params.Resolution)
+		job.Status = ConsistencyJobFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = ConsistencyJobCompleted
+	job.Report = report
+}
+
+// snapshot returns a copy of a job taken under the lock.
+func (s *ChartConsistencyService) snapshot(job *ConsistencyJob) ConsistencyJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *job
+}
+
+// evictExpiredLocked drops finished jobs whose cache TTL has passed. Must be called with mu held.
+func (s *ChartConsistencyService) evictExpiredLocked() {
+	for params, job := range s.jobs {
+		if job.CompletedAt == nil {
+			continue
+		}
+		ttl := consistencyCacheTTL
+		if job.Status == ConsistencyJobFailed {
+			ttl = consistencyFailureTTL
+		}
+		if time.Since(*job.CompletedAt) > ttl {
+			delete(s.jobs, params)
+		}
+	}
+}
+
+// compare fetches both series and builds the per-bucket report.
+func (s *ChartConsistencyService) compare(ctx context.Context, params ConsistencyParams) (*ConsistencyReport, error) {
+	market, err := s.gammaClient.GetMarketByConditionID(ctx, params.MarketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up market: %w", err)
+	}
+	tokenID, ok := market.YesTokenID()
+	if !ok {
+		return nil, fmt.Errorf("market %s has no YES token", params.MarketID)
+	}
+
+	barDuration, _ := ResolutionDuration(params.Resolution)
+	points, err := s.clobClient.GetPricesHistory(ctx, tokenID, params.From, params.To, int(barDuration/time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch upstream price history: %w", err)
+	}
+
+	bars, err := s.store.GetMarketPriceHistory(ctx, db.GetMarketPriceHistoryParams{
+		MarketID:   params.MarketID,
+		Time:       pgtype.Timestamptz{Time: time.Unix(params.From, 0).UTC(), Valid: true},
+		Time_2:     pgtype.Timestamptz{Time: time.Unix(params.To, 0).UTC(), Valid: true},
+		Resolution: params.Resolution,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored bars: %w", err)
+	}
+
+	report := &ConsistencyReport{
+		ConsistencyParams: params,
+		TokenID:           tokenID,
+		GeneratedAt:       time.Now().UTC(),
+	}
+	report.Buckets, report.Summary = compareCloses(bucketUpstreamCloses(points, params), localCloses(bars))
+	return report, nil
+}
+
+// bucketUpstreamCloses buckets upstream points by bar start; the latest point in a bucket is its close.
+func bucketUpstreamCloses(points []polymarket.PricePoint, params ConsistencyParams) map[int64]float64 {
+	closes := make(map[int64]float64)
+	latest := make(map[int64]int64)
+	for _, point := range points {
+		if point.Time < params.From || point.Time > params.To {
+			continue
+		}
+		bucket := barStartTime(time.Unix(point.Time, 0).UTC(), params.Resolution).Unix()
+		if seen, ok := latest[bucket]; !ok || point.Time >= seen {
+			latest[bucket] = point.Time
+			closes[bucket] = point.Price
+		}
+	}
+	return closes
+}
+
+// localCloses returns the close of each stored bar, keyed by bar start.
+func localCloses(bars []db.MarketPriceHistory) map[int64]float64 {
+	closes := make(map[int64]float64, len(bars))
+	for _, bar := range bars {
+		if !bar.Time.Valid || !bar.Close.Valid {
+			continue
+		}
+		value, err := bar.Close.Float64Value()
+		if err != nil || !value.Valid {
+			continue
+		}
+		closes[bar.Time.Time.Unix()] = value.Float64
+	}
+	return closes
+}
+
+// compareCloses joins the two series by bucket and computes the summary statistics.
+func compareCloses(upstream, local map[int64]float64) ([]ConsistencyBucket, ConsistencySummary) {
+	bucketTimes := make([]int64, 0, len(upstream)+len(local))
+	for t := range upstream {
+		bucketTimes = append(bucketTimes, t)
+	}
+	for t := range local {
+		if _, ok := upstream[t]; !ok {
+			bucketTimes = append(bucketTimes, t)
+		}
+	}
+	sort.Slice(bucketTimes, func(i, j int) bool { return bucketTimes[i] < bucketTimes[j] })
+
+	buckets := make([]ConsistencyBucket, 0, len(bucketTimes))
+	summary := ConsistencySummary{Buckets: len(bucketTimes)}
+	var totalDeviation float64
+	for _, t := range bucketTimes {
+		bucket := ConsistencyBucket{Time: t}
+		upstreamClose, hasUpstream := upstream[t]
+		localClose, hasLocal := local[t]
+		if hasUpstream {
+			bucket.UpstreamClose = &upstreamClose
+		}
+		if hasLocal {
+			bucket.LocalClose = &localClose
+		}
+
+		switch {
+		case hasUpstream && hasLocal:
+			delta := localClose - upstreamClose
+			bucket.Delta = &delta
+			summary.Matched++
+			deviation := math.Abs(delta)
+			totalDeviation += deviation
+			if deviation > summary.MaxDeviation {
+				summary.MaxDeviation = deviation
+				summary.MaxDeviationTime = t
+			}
+		case hasUpstream:
+			summary.MissingLocal++
+		default:
+			summary.MissingUpstream++
+		}
+		buckets = append(buckets, bucket)
+	}
+	if summary.Matched > 0 {
+		summary.MeanDeviation = totalDeviation / float64(summary.Matched)
+	}
+	return buckets, summary
+}
@@ -0,0 +1,275 @@
+/**
+ * @description
+ * This file implements featured markets: a configured set of headline markets that the
+ * market stream always subscribes to on startup and whose chart history is backfilled,
+ * so that they are live with history ready regardless of Gamma's active-market ordering.
+ *
+ * Key features:
+ * - Resolution: Featured markets missing from the active markets fetched from Gamma are
+ *   looked up individually by condition ID and added to the initial subscription.
+ * - Backfill: For each resolution, the YES token's CLOB prices-history over a lookback
+ *   window is bucketed into bars with the same bar start logic as the OHLCV aggregator,
+ *   and bars missing from the database are inserted.
+ * - Readiness: Each featured market logs whether it is ready (streaming with history) or
+ *   why it is not.
+ *
+ * @notes
+ * - Bars already stored are never overwritten, and the current (incomplete) bar is left to
+ *   the live aggregator.
+ * - Prices-history points carry no volume, so backfilled bars have a volume of 0.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// featuredBackfillSpec describes how much history is backfilled for a resolution.
+type featuredBackfillSpec struct {
+	Resolution      string
+	Lookback        time.Duration
+	FidelityMinutes int // Spacing of the prices-history points
+}
+
+// featuredBackfillSpecs lists the backfilled resolutions; those not enabled are skipped.
+// Daily bars are built from hourly points, as a single point per day would yield flat bars.
+var featuredBackfillSpecs = []featuredBackfillSpec{
+	{Resolution: "1", Lookback: 24 * time.Hour, FidelityMinutes: 1},
+	{Resolution: "5", Lookback: 3 * 24 * time.Hour, FidelityMinutes: 5},
+	{Resolution: "15", Lookback: 7 * 24 * time.Hour, FidelityMinutes: 15},
+	{Resolution: "60", Lookback: 30 * 24 * time.Hour, FidelityMinutes: 60},
+	{Resolution: "D", Lookback: 90 * 24 * time.Hour, FidelityMinutes: 60},
+}
+
+/**
+ * @description
+ * resolveFeaturedMarkets returns the configured featured markets. Markets present in the
+ * fetched active markets are taken from there; the others are fetched from Gamma.
+ * Markets that cannot be fetched are logged as not ready and skipped.
+ *
+ * @param fetched The active markets fetched from Gamma.
+ * @returns The featured markets, in configuration order.
+ */
+func (s *MarketStreamService) resolveFeaturedMarkets(fetched []polymarket.GammaMarket) []polymarket.GammaMarket {
+	if len(s.config.FeaturedMarkets) == 0 {
+		return nil
+	}
+
+	byConditionID := make(map[string]polymarket.GammaMarket, len(fetched))
+	for _, market := range fetched {
+		byConditionID[market.ConditionID] = market
+	}
+
+	featured := make([]polymarket.GammaMarket, 0, len(s.config.FeaturedMarkets))
+	for _, conditionID := range s.config.FeaturedMarkets {
+		if market, ok := byConditionID[conditionID]; ok {
+			featured = append(featured, market)
+			continue
+		}
+		market, err := s.gammaClient.GetMarketByConditionID(s.ctx, conditionID)
+		if err != nil {
+			s.logger.Warn("featured market not ready: failed to fetch market from Gamma API",
+				"condition_id", conditionID,
+				"error", err)
+			continue
+		}
+		featured = append(featured, *market)
+	}
+	return featured
+}
+
+/**
+ * @description
+ * prepareFeaturedMarkets backfills the history of each featured market and logs its
+ * readiness. It returns early if the service's context is cancelled.
+ *
+ * @param featured The featured markets, already subscribed to.
+ */
+func (s *MarketStreamService) prepareFeaturedMarkets(featured []polymarket.GammaMarket) {
+	ready := 0
+	for _, market := range featured {
+		if s.ctx.Err() != nil {
+			return
+		}
+		if s.prepareFeaturedMarket(market) {
+			ready++
+		}
+	}
+	s.logger.Info("featured markets prepared", "ready", ready, "configured", len(s.config.FeaturedMarkets))
+}
+
+// prepareFeaturedMarket backfills one featured market and logs its readiness.
+// It reports whether the market is ready.
+func (s *MarketStreamService) prepareFeaturedMarket(market polymarket.GammaMarket) bool {
+	conditionID := market.ConditionID
+	if len(market.TokenIDs()) == 0 {
+		s.logger.Warn("featured market not ready: market has no token IDs to stream",
+			"condition_id", conditionID,
+			"slug", market.Slug)
+		return false
+	}
+	tokenID, ok := market.YesTokenID()
+	if !ok {
+		s.logger.Warn("featured market not ready: market has no YES token to backfill",
+			"condition_id", conditionID,
+			"slug", market.Slug)
+		return false
+	}
+	if s.clobClient == nil {
+		s.logger.Warn("featured market not ready: CLOB client not available for backfill",
+			"condition_id", conditionID,
+			"slug", market.Slug)
+		return false
+	}
+
+	backfilled := make(map[string]int, len(featuredBackfillSpecs))
+	ready := true
+	for _, spec := range featuredBackfillSpecs {
+		if _, enabled := ResolutionDuration(spec.Resolution); !enabled {
+			continue
+		}
+		inserted, err := s.backfillFeaturedResolution(s.ctx, conditionID, tokenID, spec)
+		backfilled[spec.Resolution] = inserted
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return false
+			}
+			ready = false
+			s.logger.Warn("failed to backfill featured market",
+				"condition_id", conditionID,
+				"resolution", spec.Resolution,
+				"error", err)
+		}
+	}
+
+	if !ready {
+		s.logger.Warn("featured market not ready: history backfill incomplete",
+			"condition_id", conditionID,
+			"slug", market.Slug,
+			"bars_backfilled", backfilled)
+		return false
+	}
+	s.logger.Info("✅ featured market ready",
+		"condition_id", conditionID,
+		"slug", market.Slug,
+		"token_id", tokenID,
+		"bars_backfilled", backfilled)
+	return true
+}
+
+/**
+ * @description
+ * backfillFeaturedResolution inserts the completed bars of one resolution that are missing
+ * from the database, built from the token's prices-history over the spec's lookback.
+ *
+ * @param ctx The context for the upstream and database calls.
+ * @param conditionID The market's condition ID, under which bars are stored.
+ * @param tokenID The token whose prices-history is used.
+ * @param spec The resolution, lookback, and point spacing.
+ * @returns The number of bars inserted.
+ */
+func (s *MarketStreamService) backfillFeaturedResolution(ctx context.Context, conditionID string, tokenID string, spec featuredBackfillSpec) (int, error) {
+	now := time.Now().UTC()
+	from := barStartTime(now.Add(-spec.Lookback), spec.Resolution)
+	currentBarStart := barStartTime(now, spec.Resolution)
+
+	points, err := s.clobClient.GetPricesHistory(ctx, tokenID, from.Unix(), now.Unix(), spec.FidelityMinutes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch price history: %w", err)
+	}
+
+	stored, err := s.store.GetMarketPriceHistory(ctx, db.GetMarketPriceHistoryParams{
+		MarketID:   conditionID,
+		Time:       pgtype.Timestamptz{Time: from, Valid: true},
+		Time_2:     pgtype.Timestamptz{Time: now, Valid: true},
+		Resolution: spec.Resolution,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query stored bars: %w", err)
+	}
+	existing := make(map[int64]bool, len(stored))
+	for _, bar := range stored {
+		if bar.Time.Valid {
+			existing[bar.Time.Time.Unix()] = true
+		}
+	}
+
+	inserted := 0
+	for _, bar := range priceHistoryBars(points, conditionID, spec.Resolution) {
+		if !bar.StartTime.Before(currentBarStart) || existing[bar.StartTime.Unix()] {
+			continue
+		}
+		if err := s.insertBackfilledBar(ctx, bar); err != nil {
+			return inserted, err
+		}
+		inserted++
+	}
+	return inserted, nil
+}
+
+// priceHistoryBars buckets prices-history points into bars, in time order.
+func priceHistoryBars(points []polymarket.PricePoint, marketID string, resolution string) []*CurrentBar {
+	sorted := make([]polymarket.PricePoint, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time < sorted[j].Time })
+
+	var bars []*CurrentBar
+	var bar *CurrentBar
+	for _, point := range sorted {
+		start := barStartTime(time.Unix(point.Time, 0).UTC(), resolution)
+		if bar == nil || !bar.StartTime.Equal(start) {
+			bar = &CurrentBar{
+				MarketID:   marketID,
+				Resolution: resolution,
+				StartTime:  start,
+				Open:       point.Price,
+				High:       point.Price,
+				Low:        point.Price,
+			}
+			bars = append(bars, bar)
+		}
+		if point.Price > bar.High {
+			bar.High = point.Price
+		}
+		if point.Price < bar.Low {
+			bar.Low = point.Price
+		}
+		bar.Close = point.Price
+	}
+	return bars
+}
+
+// insertBackfilledBar stores a backfilled bar.
+func (s *MarketStreamService) insertBackfilledBar(ctx context.Context, bar *CurrentBar) error {
+	values := make([]pgtype.Numeric, 0, 5)
+	for _, value := range []float64{bar.Open, bar.High, bar.Low, bar.Close, bar.Volume} {
+		numeric, err := floatToNumeric(value)
+		if err != nil {
+			return err
+		}
+		values = append(values, numeric)
+	}
+
+	err := s.store.InsertMarketPriceHistory(ctx, db.InsertMarketPriceHistoryParams{
+		PTime:       pgtype.Timestamptz{Time: bar.StartTime, Valid: true},
+		PMarketID:   bar.MarketID,
+		POpen:       values[0],
+		PHigh:       values[1],
+		PLow:        values[2],
+		PClose:      values[3],
+		PVolume:     values[4],
+		PResolution: bar.Resolution,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert bar at %s: %w", bar.StartTime.Format(time.RFC3339), err)
+	}
+	return nil
+}
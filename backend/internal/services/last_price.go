@@ -0,0 +1,178 @@
+/**
+ * @description
+ * This file implements the last price of a market: the market stream writes the latest
+ * mid-price of each market to Redis, and the price endpoint reads it from there, so that
+ * reads of popular markets do not reach the database.
+ *
+ * Key features:
+ * - Write-Through: Every mid-price accepted for aggregation is stored under
+ *   `cachekeys.LastPrice` with a short TTL, so a market that stops streaming expires.
+ * - Fallback: Without a cached price, the close of the market's latest stored bar at the
+ *   finest enabled resolution is returned.
+ * - In-Memory Price: `OHLCVAggregator.LatestPrice` reads the close of the market's current bar
+ *   instead of the cache, which is never older than the cache, and falls back to the stored
+ *   bars the same way.
+ *
+ * @notes
+ * - Books of every outcome token of a market are aggregated under its condition ID, so the
+ *   cached price is the mid-price of the token whose book was received last; its asset ID is
+ *   returned with the price.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/poly-pro/backend/internal/cachekeys"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sources reported in MarketPrice.Source.
+const (
+	priceSourceCache    = "cache"
+	priceSourceDatabase = "database"
+	priceSourceMemory   = "memory"
+)
+
+// ErrPriceNotFound is returned when a market has neither a cached price nor a stored bar.
+var ErrPriceNotFound = errors.New("no price recorded for market")
+
+// MarketPrice is the latest known price of a market.
+type MarketPrice struct {
+	MarketID  string    `json:"market_id"`
+	AssetID   string    `json:"asset_id,omitempty"` // Token whose book gave the mid-price; empty for stored bars
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"` // Book time, or start of the stored bar
+	Source    string    `json:"source"`    // "cache", "memory", or "database"
+}
+
+// storeLastPrice caches the latest mid-price of a market. A failure only sends price reads
+// to the database until the next update.
+func (s *MarketStreamService) storeLastPrice(conditionID, assetID string, price float64, timestamp time.Time) {
+	if !cachekeys.LastPrice.Enabled() {
+		return
+	}
+	encoded, err := json.Marshal(MarketPrice{
+		MarketID:  conditionID,
+		AssetID:   assetID,
+		Price:     price,
+		Timestamp: timestamp.UTC(),
+		Source:    priceSourceCache,
+	})
+	if err != nil {
+		return
+	}
+	key := cachekeys.LastPrice.Key(conditionID)
+	if err := s.redisClient.Set(s.ctx, key, encoded, cachekeys.LastPrice.TTL).Err(); err != nil {
+		s.logger.Warn("failed to cache last price", "error", err, "key", key)
+	}
+}
+
+/**
+ * @description
+ * LastPrice returns the latest price of a market, from the cache written by the market
+ * stream or, failing that, from the close of its latest stored bar.
+ *
+ * @param ctx The context for the operation.
+ * @param marketID The market's condition ID.
+ * @returns The price, ErrPriceNotFound, or a database error.
+ */
+func (s *AnalyticsService) LastPrice(ctx context.Context, marketID string) (*MarketPrice, error) {
+	if cached, err := s.redisClient.Get(ctx, cachekeys.LastPrice.Key(marketID)).Bytes(); err == nil {
+		var price MarketPrice
+		if err := json.Unmarshal(cached, &price); err == nil {
+			return &price, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		s.logger.Warn("failed to read last price from cache", "error", err, "market_id", marketID)
+	}
+
+	return latestStoredPrice(ctx, s.store, marketID, finestResolution())
+}
+
+/**
+ * @description
+ * LatestPrice returns the latest price of a market, from the close of its current in-memory
+ * bar at the finest resolution or, failing that, from the close of its latest stored bar.
+ *
+ * @param ctx The context for the operation.
+ * @param marketID The market's condition ID.
+ * @returns The price, ErrPriceNotFound, or a database error.
+ */
+func (a *OHLCVAggregator) LatestPrice(ctx context.Context, marketID string) (*MarketPrice, error) {
+	resolution := a.finestResolution()
+
+	a.mu.RLock()
+	bar, ok := a.bars[marketID][resolution]
+	var price *MarketPrice
+	if ok {
+		timestamp := bar.LastUpdate
+		if timestamp.IsZero() {
+			timestamp = bar.StartTime
+		}
+		price = &MarketPrice{
+			MarketID:  marketID,
+			Price:     bar.Close,
+			Timestamp: timestamp.UTC(),
+			Source:    priceSourceMemory,
+		}
+	}
+	a.mu.RUnlock()
+	if price != nil {
+		return price, nil
+	}
+
+	return latestStoredPrice(ctx, a.store, marketID, resolution)
+}
+
+// latestStoredPrice returns the close of a market's latest stored bar at a resolution.
+func latestStoredPrice(ctx context.Context, store db.Querier, marketID, resolution string) (*MarketPrice, error) {
+	bar, err := store.GetLatestMarketPriceBar(ctx, db.GetLatestMarketPriceBarParams{
+		MarketID:   marketID,
+		Resolution: resolution,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPriceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest bar: %w", err)
+	}
+	closePrice, ok := numericFloat(bar.Close)
+	if !ok || !bar.Time.Valid {
+		return nil, ErrPriceNotFound
+	}
+	return &MarketPrice{
+		MarketID:  marketID,
+		Price:     closePrice,
+		Timestamp: bar.Time.Time.UTC(),
+		Source:    priceSourceDatabase,
+	}, nil
+}
+
+// finestResolution returns the enabled resolution with the shortest bars.
+func finestResolution() string {
+	return finestOf(enabledResolutions)
+}
+
+// finestResolution returns the aggregator's resolution with the shortest bars.
+func (a *OHLCVAggregator) finestResolution() string {
+	return finestOf(a.resolutions)
+}
+
+// finestOf returns the resolution with the shortest bars among defs.
+func finestOf(defs []ResolutionDef) string {
+	finest := defs[0]
+	for _, def := range defs[1:] {
+		if def.Duration < finest.Duration {
+			finest = def
+		}
+	}
+	return finest.Code
+}
@@ -0,0 +1,163 @@
+/**
+ * @description
+ * This file implements the MarketCatalog, an in-memory index of the markets known to the
+ * market stream, used to validate and canonicalize market identifiers sent by clients.
+ *
+ * Key features:
+ * - Condition IDs: Well-formed condition IDs are accepted as-is.
+ * - Slug Translation: Known market slugs are translated to their condition ID.
+ * - Suggestions: Unknown identifiers get a near-match suggestion among known slugs, by
+ *   prefix first and then by Levenshtein distance.
+ *
+ * @notes
+ * - The catalog is filled from the Gamma API markets fetched when the stream starts, and
+ *   from markets added later via AddMarketAssets.
+ */
+
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/poly-pro/backend/internal/channels"
+)
+
+// MarketCatalog resolves client-supplied market identifiers to condition IDs.
+type MarketCatalog struct {
+	mu           sync.RWMutex
+	conditionIDs map[string]bool   // Known condition IDs
+	slugs        map[string]string // Lower-cased slug -> condition ID
+}
+
+// NewMarketCatalog creates an empty MarketCatalog.
+func NewMarketCatalog() *MarketCatalog {
+	return &MarketCatalog{
+		conditionIDs: make(map[string]bool),
+		slugs:        make(map[string]string),
+	}
+}
+
+// Add records a market's condition ID and, if non-empty, its slug.
+func (m *MarketCatalog) Add(conditionID string, slug string) {
+	if conditionID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conditionIDs[conditionID] = true
+	if slug = strings.ToLower(strings.TrimSpace(slug)); slug != "" {
+		m.slugs[slug] = conditionID
+	}
+}
+
+// Size returns the number of known markets.
+func (m *MarketCatalog) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.conditionIDs)
+}
+
+/**
+ * @description
+ * ResolveMarketID maps a client-supplied identifier to a condition ID.
+ *
+ * @param id A condition ID or a market slug.
+ * @returns The condition ID, and false if the identifier is neither a well-formed
+ *          condition ID nor a known slug.
+ */
+func (m *MarketCatalog) ResolveMarketID(id string) (string, bool) {
+	if channels.IsConditionID(id) {
+		return id, true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conditionID, ok := m.slugs[strings.ToLower(id)]
+	return conditionID, ok
+}
+
+/**
+ * @description
+ * SuggestMarketID returns the known slug closest to an unresolved identifier.
+ *
+ * @param id The identifier that could not be resolved.
+ * @returns A slug sharing a prefix with id, otherwise the slug within a small edit
+ *          distance of id, or "" if there is no close match.
+ */
+func (m *MarketCatalog) SuggestMarketID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Prefer the shortest slug that extends the identifier (e.g. a truncated slug).
+	best := ""
+	for slug := range m.slugs {
+		if strings.HasPrefix(slug, id) && (best == "" || len(slug) < len(best) || (len(slug) == len(best) && slug < best)) {
+			best = slug
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	// Otherwise take the closest slug, allowing roughly one typo per four characters.
+	maxDistance := len(id)/4 + 1
+	bestDistance := maxDistance + 1
+	for slug := range m.slugs {
+		if abs(len(slug)-len(id)) > maxDistance {
+			continue
+		}
+		distance := levenshtein(id, slug)
+		if distance < bestDistance || (distance == bestDistance && slug < best) {
+			best, bestDistance = slug, distance
+		}
+	}
+	if bestDistance > maxDistance {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// minInt returns the smallest of its arguments.
+func minInt(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
+
+// abs returns the absolute value of v.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
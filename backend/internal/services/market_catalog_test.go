@@ -0,0 +1,92 @@
+package services
+
+import "testing"
+
+const (
+	fedConditionID      = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+	electionConditionID = "0x9b1e1f1a4f0c2d3e4b5a69788796a5b4c3d2e1f00112233445566778899aabbc"
+)
+
+// newTestCatalog creates a catalog of two markets with slugs.
+func newTestCatalog() *MarketCatalog {
+	catalog := NewMarketCatalog()
+	catalog.Add(fedConditionID, "fed-rate-cut-december")
+	catalog.Add(electionConditionID, " Presidential-Election-Winner-2028 ")
+	catalog.Add("", "orphan-slug") // Markets without a condition ID are not recorded
+	return catalog
+}
+
+func TestMarketCatalogResolveMarketID(t *testing.T) {
+	catalog := newTestCatalog()
+	tests := []struct {
+		id     string
+		want   string
+		wantOK bool
+	}{
+		{fedConditionID, fedConditionID, true},
+		// Well-formed condition IDs are accepted even when the catalog does not list them.
+		{"0x" + "ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12", "0x" + "ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12cd34ef56ab12", true},
+		{"fed-rate-cut-december", fedConditionID, true},
+		{"FED-Rate-Cut-December", fedConditionID, true},
+		{"presidential-election-winner-2028", electionConditionID, true},
+		{"fed-rate-cut", "", false},
+		{"orphan-slug", "", false},
+		{"0xabc", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := catalog.ResolveMarketID(tt.id)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ResolveMarketID(%q) = %q, %v; want %q, %v", tt.id, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if size := catalog.Size(); size != 2 {
+		t.Errorf("size = %d, want 2", size)
+	}
+}
+
+func TestMarketCatalogSuggestMarketID(t *testing.T) {
+	catalog := newTestCatalog()
+	catalog.Add("0x"+"11"+fedConditionID[4:], "fed-rate-cut-december-2026-extended")
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{"truncated slug takes the shortest extension", "fed-rate", "fed-rate-cut-december"},
+		{"prefix match is case-insensitive", "  PRESIDENTIAL-", "presidential-election-winner-2028"},
+		{"one typo", "fed-rate-cut-decmber", "fed-rate-cut-december"},
+		{"transposed letters", "fed-rate-cut-decebmer", "fed-rate-cut-december"},
+		{"too many edits", "fed-hike-in-march", ""},
+		{"unrelated", "bitcoin-above-100k", ""},
+		{"empty", "   ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catalog.SuggestMarketID(tt.id); got != tt.want {
+				t.Errorf("SuggestMarketID(%q) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"élection", "election", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/**
+ * @description
+ * This file implements an admin backfill that re-keys historical OHLCV bars stored
+ * under an asset (token) ID to their market's canonical condition ID.
+ *
+ * Before the asset→condition mapping was fixed, bars for unmapped assets were stored
+ * under the raw asset ID ("no mapping found, using as-is"), leaving gaps in the charts of
+ * markets whose data exists under another key.
+ *
+ * Key features:
+ * - Discovery: Scans the distinct market IDs in market_price_history and resolves each one
+ *   as an asset ID; IDs that resolve to a different condition ID are re-key candidates.
+ * - Dry Run: Reports the planned moves, row counts, and collisions without modifying data.
+ * - Merge: Moved bars are merged into bars already stored under the condition ID like
+ *   upsert_market_price_history() merges a re-saved bar: high and low widen, the moved
+ *   (earlier) bar's open and the existing (later) bar's close are kept, and volumes add up.
+ *
+ * @notes
+ * - Each market ID is re-keyed in a single statement, so a failure leaves that market's
+ *   bars either fully moved or untouched.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// MarketIDResolver resolves asset (token) IDs to their market's condition ID.
+type MarketIDResolver interface {
+	ConditionIDForAsset(assetID string) (string, bool)
+}
+
+// HistoryRekeyPlan describes the bars that will be (or were) moved for one mis-keyed market ID.
+type HistoryRekeyPlan struct {
+	SourceMarketID string `json:"source_market_id"` // The asset ID the bars are stored under
+	TargetMarketID string `json:"target_market_id"` // The canonical condition ID
+	Rows           int64  `json:"rows"`             // Bars stored under the source ID
+	Collisions     int64  `json:"collisions"`       // Bars that already exist under the target ID
+	RowsRekeyed    int64  `json:"rows_rekeyed"`     // Bars written to the target ID (0 on dry run)
+}
+
+// HistoryRekeyReport summarizes a backfill run.
+type HistoryRekeyReport struct {
+	DryRun           bool               `json:"dry_run"`
+	ScannedMarketIDs int                `json:"scanned_market_ids"`
+	Plans            []HistoryRekeyPlan `json:"plans"`
+	TotalRows        int64              `json:"total_rows"`
+	TotalCollisions  int64              `json:"total_collisions"`
+	TotalRekeyed     int64              `json:"total_rekeyed"`
+}
+
+// MarketHistoryBackfillService re-keys historical bars to canonical condition IDs.
+type MarketHistoryBackfillService struct {
+	store    db.Querier
+	resolver MarketIDResolver
+	logger   *slog.Logger
+}
+
+/**
+ * @description
+ * NewMarketHistoryBackfillService creates a new instance of the MarketHistoryBackfillService.
+ *
+ * @param store The database querier for database operations.
+ * @param resolver Resolves asset IDs to condition IDs.
+ * @param logger A structured logger for logging service-level events.
+ * @returns A pointer to a new MarketHistoryBackfillService instance.
+ */
+func NewMarketHistoryBackfillService(store db.Querier, resolver MarketIDResolver, logger *slog.Logger) *MarketHistoryBackfillService {
+	return &MarketHistoryBackfillService{
+		store:    store,
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+/**
+ * @description
+ * RekeyAssetIDBars finds bars stored under asset IDs and, unless dryRun is set,
+ * moves them to the corresponding condition ID.
+ *
+ * @param ctx The context for the database operations.
+ * @param dryRun When true, only the report is produced and no rows are modified.
+ * @returns A report of the planned (or applied) moves.
+ */
+func (s *MarketHistoryBackfillService) RekeyAssetIDBars(ctx context.Context, dryRun bool) (HistoryRekeyReport, error) {
+	report := HistoryRekeyReport{DryRun: dryRun, Plans: []HistoryRekeyPlan{}}
+
+	marketIDs, err := s.store.ListDistinctMarketPriceHistoryMarketIDs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to list market IDs: %w", err)
+	}
+	report.ScannedMarketIDs = len(marketIDs)
+
+	for _, marketID := range marketIDs {
+		conditionID, ok := s.resolver.ConditionIDForAsset(marketID)
+		if !ok || conditionID == "" || conditionID == marketID {
+			continue
+		}
+
+		plan := HistoryRekeyPlan{SourceMarketID: marketID, TargetMarketID: conditionID}
+		if plan.Rows, err = s.store.CountMarketPriceHistoryRows(ctx, marketID); err != nil {
+			return report, fmt.Errorf("failed to count bars for %s: %w", marketID, err)
+		}
+		plan.Collisions, err = s.store.CountMarketPriceHistoryRekeyCollisions(ctx, db.CountMarketPriceHistoryRekeyCollisionsParams{
+			TargetMarketID: conditionID,
+			SourceMarketID: marketID,
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to count collisions for %s: %w", marketID, err)
+		}
+
+		if !dryRun {
+			plan.RowsRekeyed, err = s.store.RekeyMarketPriceHistory(ctx, db.RekeyMarketPriceHistoryParams{
+				SourceMarketID: marketID,
+				TargetMarketID: conditionID,
+			})
+			if err != nil {
+				return report, fmt.Errorf("failed to re-key bars from %s to %s: %w", marketID, conditionID, err)
+			}
+			s.logger.Info("re-keyed historical bars",
+				"source_market_id", marketID,
+				"target_market_id", conditionID,
+				"rows", plan.RowsRekeyed,
+				"collisions", plan.Collisions)
+		}
+
+		report.Plans = append(report.Plans, plan)
+		report.TotalRows += plan.Rows
+		report.TotalCollisions += plan.Collisions
+		report.TotalRekeyed += plan.RowsRekeyed
+	}
+
+	s.logger.Info("market history re-key backfill finished",
+		"dry_run", dryRun,
+		"scanned_market_ids", report.ScannedMarketIDs,
+		"markets_to_rekey", len(report.Plans),
+		"total_rows", report.TotalRows,
+		"total_collisions", report.TotalCollisions,
+		"total_rekeyed", report.TotalRekeyed)
+	return report, nil
+}
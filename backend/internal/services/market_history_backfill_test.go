@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"testing"
+
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// historyStore holds bar counts per market ID, and the collisions of each source market ID.
+type historyStore struct {
+	db.Querier
+	rows       map[string]int64
+	collisions map[string]int64
+	rekeyed    []db.RekeyMarketPriceHistoryParams
+}
+
+func (s *historyStore) ListDistinctMarketPriceHistoryMarketIDs(context.Context) ([]string, error) {
+	marketIDs := make([]string, 0, len(s.rows))
+	for marketID := range s.rows {
+		marketIDs = append(marketIDs, marketID)
+	}
+	sort.Strings(marketIDs)
+	return marketIDs, nil
+}
+
+func (s *historyStore) CountMarketPriceHistoryRows(_ context.Context, marketID string) (int64, error) {
+	return s.rows[marketID], nil
+}
+
+func (s *historyStore) CountMarketPriceHistoryRekeyCollisions(_ context.Context, arg db.CountMarketPriceHistoryRekeyCollisionsParams) (int64, error) {
+	return s.collisions[arg.SourceMarketID], nil
+}
+
+func (s *historyStore) RekeyMarketPriceHistory(_ context.Context, arg db.RekeyMarketPriceHistoryParams) (int64, error) {
+	s.rekeyed = append(s.rekeyed, arg)
+	moved := s.rows[arg.SourceMarketID]
+	s.rows[arg.TargetMarketID] += moved - s.collisions[arg.SourceMarketID]
+	delete(s.rows, arg.SourceMarketID)
+	return moved, nil
+}
+
+// assetResolver maps asset IDs to condition IDs; condition IDs resolve to themselves.
+type assetResolver map[string]string
+
+func (r assetResolver) ConditionIDForAsset(assetID string) (string, bool) {
+	if conditionID, ok := r[assetID]; ok {
+		return conditionID, true
+	}
+	for _, conditionID := range r {
+		if conditionID == assetID {
+			return conditionID, true
+		}
+	}
+	return "", false
+}
+
+func newHistoryBackfill() (*MarketHistoryBackfillService, *historyStore) {
+	store := &historyStore{
+		// asset-a collides with 2 bars already under its condition ID, asset-b with none.
+		rows:       map[string]int64{"0xcondition-a": 5, "asset-a": 3, "asset-b": 4, "asset-unknown": 1},
+		collisions: map[string]int64{"asset-a": 2},
+	}
+	resolver := assetResolver{"asset-a": "0xcondition-a", "asset-b": "0xcondition-b"}
+	return NewMarketHistoryBackfillService(store, resolver, slog.New(slog.NewTextHandler(io.Discard, nil))), store
+}
+
+func TestRekeyAssetIDBars(t *testing.T) {
+	wantPlans := func(rekeyedA, rekeyedB int64) []HistoryRekeyPlan {
+		return []HistoryRekeyPlan{
+			{SourceMarketID: "asset-a", TargetMarketID: "0xcondition-a", Rows: 3, Collisions: 2, RowsRekeyed: rekeyedA},
+			{SourceMarketID: "asset-b", TargetMarketID: "0xcondition-b", Rows: 4, Collisions: 0, RowsRekeyed: rekeyedB},
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		service, store := newHistoryBackfill()
+		report, err := service.RekeyAssetIDBars(context.Background(), true)
+		if err != nil {
+			t.Fatalf("RekeyAssetIDBars: %v", err)
+		}
+		if len(store.rekeyed) != 0 {
+			t.Errorf("dry run re-keyed %v", store.rekeyed)
+		}
+		if !reflect.DeepEqual(report.Plans, wantPlans(0, 0)) {
+			t.Errorf("plans = %+v, want %+v", report.Plans, wantPlans(0, 0))
+		}
+		if report.ScannedMarketIDs != 4 || report.TotalRows != 7 || report.TotalCollisions != 2 || report.TotalRekeyed != 0 {
+			t.Errorf("report = %+v", report)
+		}
+	})
+
+	t.Run("with and without collisions", func(t *testing.T) {
+		service, store := newHistoryBackfill()
+		report, err := service.RekeyAssetIDBars(context.Background(), false)
+		if err != nil {
+			t.Fatalf("RekeyAssetIDBars: %v", err)
+		}
+		if !reflect.DeepEqual(report.Plans, wantPlans(3, 4)) {
+			t.Errorf("plans = %+v, want %+v", report.Plans, wantPlans(3, 4))
+		}
+		if report.TotalRekeyed != 7 || report.TotalCollisions != 2 {
+			t.Errorf("report = %+v", report)
+		}
+		// Colliding bars merge into the existing ones instead of adding rows.
+		want := map[string]int64{"0xcondition-a": 6, "0xcondition-b": 4, "asset-unknown": 1}
+		if !reflect.DeepEqual(store.rows, want) {
+			t.Errorf("rows after re-key = %v, want %v", store.rows, want)
+		}
+
+		// A second run finds nothing left to move.
+		report, err = service.RekeyAssetIDBars(context.Background(), false)
+		if err != nil || len(report.Plans) != 0 {
+			t.Errorf("second run = %+v, %v, want no plans", report.Plans, err)
+		}
+	})
+}
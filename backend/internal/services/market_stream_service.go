@@ -11,7 +11,9 @@
  *   broadcast to clients.
  * - Redis Publishing: Publishes processed data to specific Redis channels, allowing
  *   the WebSocket hub to fan it out to many clients efficiently.
- * - Real-time Connection: Connects to Polymarket's CLOB WebSocket for live order book data.
+ * - Real-time Connection: Connects to Polymarket's CLOB WebSocket for live order book data,
+ *   spreading the subscribed assets over as many connections as `STREAM_SHARD_SIZE` requires.
+ * - User Channel: Publishes the CLOB user channel's order and trade events (see user_stream.go).
  *
  * @dependencies
  * - github.com/redis/go-redis/v9: The Redis client library.
@@ -23,27 +25,103 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"strconv"
-	"strings"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/poly-pro/backend/internal/cachekeys"
+	"github.com/poly-pro/backend/internal/channels"
 	"github.com/poly-pro/backend/internal/config"
 	db "github.com/poly-pro/backend/internal/db"
 	"github.com/poly-pro/backend/internal/polymarket"
+	"github.com/poly-pro/backend/internal/wire"
 	"github.com/redis/go-redis/v9"
 )
 
+// marketActivityRetention is how long the last accepted message of a market is remembered.
+const marketActivityRetention = time.Hour
+
+const (
+	// minReconnectBackoff is the initial delay before reconnecting a dropped CLOB WebSocket.
+	minReconnectBackoff = time.Second
+	// maxReconnectBackoff caps the delay between reconnection attempts.
+	maxReconnectBackoff = time.Minute
+	// stableConnectionAfter is how long a connection must last to reset the backoff.
+	stableConnectionAfter = time.Minute
+)
+
+// ErrStreamNotConfigured is reported when the CLOB WebSocket credentials are not set.
+var ErrStreamNotConfigured = errors.New("CLOB WebSocket credentials are not configured")
+
 // MarketStreamService is responsible for streaming market data and publishing it.
 type MarketStreamService struct {
 	redisClient     *redis.Client
 	logger          *slog.Logger
 	ctx             context.Context
-	wsClient        *polymarket.CLOBWebSocketClient
+	wsClient        *polymarket.CLOBWebSocketPool
+	userClient      *polymarket.CLOBWebSocketClient // User channel connection; nil without CLOB credentials
 	config          config.Config
 	ohlcvAggregator *OHLCVAggregator
 	gammaClient     *polymarket.GammaAPIClient
+	clobClient      *polymarket.CLOBAPIClient // Used to backfill featured markets and recover missing token IDs
+	store           db.Querier
+	ledger          *MessageLedger // nil unless OHLCV dedupe is enabled
+	tradingParams   *TradingParamsCache // Invalidated when a token's tick size changes
+	publishBuffer   *PublishBuffer      // Retries failed publishes; nil publishes directly
+	bookTops        *bookTopTracker     // Top of book history, used to infer trade aggressors
+
+	// State exposed through Stats() for diagnostics.
+	mode                 atomic.Value // "websocket", "mock", or "failed"
+	failure              atomic.Value // Reason the stream stopped, set when mode is "failed"
+	messagesProcessed    atomic.Int64
+	lastMessageAt        atomic.Int64 // Unix nanoseconds, 0 if no message processed yet
+	assetMu              sync.RWMutex
+	assetIDToConditionID map[string]string
+	catalog              *MarketCatalog
+	allocation           atomic.Value // *StreamAllocation, set by rebalance
+	catalogFetchedAt     atomic.Int64 // Unix nanoseconds of the last successful catalog fetch
+	reconnectAttempts    atomic.Int64 // CLOB WebSocket reconnection attempts
+	reconnects           atomic.Int64 // Successful CLOB WebSocket reconnections
+	userEventsPublished  atomic.Int64 // CLOB user channel events published to Redis
+	activityMu           sync.Mutex
+	marketActivity       map[string]time.Time // conditionID -> last message accepted for aggregation
+
+	// Subscription budget state, guarded by allocMu.
+	allocMu         sync.Mutex
+	streamMarkets   []streamMarket           // Markets that can be subscribed to
+	featuredMarkets []polymarket.GammaMarket // Pinned featured markets
+	addedMarkets    map[string][]string      // Pinned markets added via AddMarketAssets: conditionID -> assetIDs
+	marketDemand    func() []string          // Condition IDs clients are subscribed to; may be nil
+
+	// Tokens recovered from the CLOB for Gamma markets without token IDs: conditionID -> tokens.
+	// Only used by the stream's catalog fetches, which are serialized by catalogFetchMu.
+	catalogFetchMu  sync.Mutex
+	recoveredTokens map[string][]polymarket.Token
+}
+
+// StreamStats is a point-in-time snapshot of the market stream service's state.
+type StreamStats struct {
+	Mode               string            `json:"mode"`
+	Error              string            `json:"error,omitempty"`
+	MessagesProcessed  int64             `json:"messages_processed"`
+	LastMessageAt      *time.Time        `json:"last_message_at,omitempty"`
+	AssetMappingSize   int               `json:"asset_mapping_size"`
+	AssetMappingSample map[string]string `json:"asset_mapping_sample"` // assetID -> conditionID
+	Truncated          bool              `json:"truncated"`
+	Aggregator         AggregatorStats   `json:"aggregator"`
+	Dedupe             *LedgerStats      `json:"dedupe,omitempty"`
+	Allocation         *StreamAllocation `json:"allocation,omitempty"` // Subscription budget allocation
+	ReconnectAttempts  int64             `json:"reconnect_attempts"`
+	Reconnects         int64             `json:"reconnects"`
+	CatalogFetchedAt   *time.Time        `json:"catalog_fetched_at,omitempty"`
+	ShardAssets        []int             `json:"shard_assets,omitempty"` // Assets subscribed on each CLOB WebSocket connection
+	UserEvents         int64             `json:"user_events_published"`   // CLOB user channel events published to Redis
 }
 
 // OrderBookLevel represents a single price level in the order book.
@@ -65,25 +143,200 @@ type MockOrderBookData struct {
 }
 
 // NewMarketStreamService creates a new MarketStreamService.
-func NewMarketStreamService(ctx context.Context, logger *slog.Logger, redisClient *redis.Client, cfg config.Config, store db.Querier, gammaClient *polymarket.GammaAPIClient) *MarketStreamService {
+func NewMarketStreamService(ctx context.Context, logger *slog.Logger, redisClient *redis.Client, cfg config.Config, store db.Querier, gammaClient *polymarket.GammaAPIClient, clobClient *polymarket.CLOBAPIClient) *MarketStreamService {
 	// Initialize WebSocket client if credentials are provided
-	var wsClient *polymarket.CLOBWebSocketClient
+	var wsClient *polymarket.CLOBWebSocketPool
+	var userClient *polymarket.CLOBWebSocketClient
 	if cfg.CLOBAPIKey != "" && cfg.CLOBAPISecret != "" && cfg.CLOBAPIPassphrase != "" {
-		wsClient = polymarket.NewCLOBWebSocketClient(cfg.CLOBWSURL, cfg.CLOBAPIKey, cfg.CLOBAPISecret, cfg.CLOBAPIPassphrase, logger)
+		wsClient = polymarket.NewCLOBWebSocketPool(cfg.CLOBWSURL, cfg.CLOBAPIKey, cfg.CLOBAPISecret, cfg.CLOBAPIPassphrase, cfg.StreamShardSize, logger)
+		userClient = polymarket.NewCLOBWebSocketClient(cfg.CLOBWSURL, cfg.CLOBAPIKey, cfg.CLOBAPISecret, cfg.CLOBAPIPassphrase, logger.With("channel", "user"))
+	}
+
+	// In-progress bars are checkpointed to Redis only when enabled
+	var checkpoints *BarCheckpointer
+	if cfg.OHLCVCheckpointEnabled {
+		checkpoints = NewBarCheckpointer(logger, redisClient, cfg.OHLCVCheckpointInterval)
 	}
 
 	// Initialize OHLCV aggregator
-	ohlcvAggregator := NewOHLCVAggregator(ctx, logger, store)
+	ohlcvAggregator := NewOHLCVAggregator(ctx, logger, store, cfg.OHLCVMaxMarkets, MidPriceFilter{
+		MinMidPrice: cfg.OHLCVMinMidPrice,
+		MaxMidPrice: cfg.OHLCVMaxMidPrice,
+		MaxSpread:   cfg.OHLCVMaxSpread,
+	}, PersistLagPolicy{
+		Threshold: cfg.OHLCVPersistLagThreshold,
+		Cycles:    cfg.OHLCVPersistLagCycles,
+	}, GapFillPolicy{
+		FillGaps:   cfg.OHLCVFillGaps,
+		MaxPeriods: cfg.OHLCVFillGapsMaxPeriods,
+	}, checkpoints, cfg.OHLCVDebug)
+	ohlcvAggregator.SetBarEventPublisher(NewBarEventPublisher(redisClient, logger))
+	ohlcvAggregator.SetIdleTimeout(cfg.OHLCVIdleMarketTimeout)
+
+	// The dedupe ledger is only needed when more than one ingester may run at once
+	var ledger *MessageLedger
+	if cfg.OHLCVDedupeEnabled {
+		ledger = NewMessageLedger(logger, redisClient, cfg.OHLCVDedupeTTL)
+	}
 
 	return &MarketStreamService{
-		redisClient:     redisClient,
-		logger:          logger,
-		ctx:             ctx,
-		wsClient:        wsClient,
-		config:          cfg,
-		ohlcvAggregator: ohlcvAggregator,
-		gammaClient:     gammaClient,
+		redisClient:          redisClient,
+		logger:               logger,
+		ctx:                  ctx,
+		wsClient:             wsClient,
+		userClient:           userClient,
+		config:               cfg,
+		ohlcvAggregator:      ohlcvAggregator,
+		gammaClient:          gammaClient,
+		clobClient:           clobClient,
+		store:                store,
+		ledger:               ledger,
+		tradingParams:        NewTradingParamsCache(redisClient, clobClient, logger),
+		bookTops:             newBookTopTracker(),
+		assetIDToConditionID: make(map[string]string),
+		catalog:              NewMarketCatalog(),
+		marketActivity:       make(map[string]time.Time),
+		addedMarkets:         make(map[string][]string),
+		recoveredTokens:      make(map[string][]polymarket.Token),
+	}
+}
+
+/**
+ * @description
+ * Stats returns a snapshot of the stream service's state, including the
+ * assetID→conditionID mapping and the OHLCV aggregator's bar counts.
+ *
+ * @param sampleLimit The maximum number of mapping entries to include (0 for all).
+ */
+func (s *MarketStreamService) Stats(sampleLimit int) StreamStats {
+	mode, _ := s.mode.Load().(string)
+	stats := StreamStats{
+		Mode:               mode,
+		Error:              s.Failure(),
+		MessagesProcessed:  s.messagesProcessed.Load(),
+		AssetMappingSample: make(map[string]string),
+		Aggregator:         s.ohlcvAggregator.Stats(),
+		ReconnectAttempts:  s.reconnectAttempts.Load(),
+		Reconnects:         s.reconnects.Load(),
+		UserEvents:         s.userEventsPublished.Load(),
+	}
+	if last := s.lastMessageAt.Load(); last > 0 {
+		lastMessageAt := time.Unix(0, last).UTC()
+		stats.LastMessageAt = &lastMessageAt
+	}
+	if fetched := s.catalogFetchedAt.Load(); fetched > 0 {
+		catalogFetchedAt := time.Unix(0, fetched).UTC()
+		stats.CatalogFetchedAt = &catalogFetchedAt
+	}
+	if s.ledger != nil {
+		ledgerStats := s.ledger.Stats()
+		stats.Dedupe = &ledgerStats
+	}
+	stats.Allocation, stats.Truncated = s.allocationSnapshot(sampleLimit)
+	if s.wsClient != nil {
+		stats.ShardAssets = s.wsClient.ShardAssets()
+	}
+
+	s.assetMu.RLock()
+	defer s.assetMu.RUnlock()
+
+	stats.AssetMappingSize = len(s.assetIDToConditionID)
+	assetIDs := make([]string, 0, len(s.assetIDToConditionID))
+	for assetID := range s.assetIDToConditionID {
+		assetIDs = append(assetIDs, assetID)
+	}
+	sort.Strings(assetIDs)
+	for i, assetID := range assetIDs {
+		if sampleLimit > 0 && i >= sampleLimit {
+			stats.Truncated = true
+			break
+		}
+		stats.AssetMappingSample[assetID] = s.assetIDToConditionID[assetID]
+	}
+	return stats
+}
+
+// ConditionIDForAsset resolves an asset (token) ID to its market's condition ID
+// using the mapping built from the Gamma API when the stream started.
+func (s *MarketStreamService) ConditionIDForAsset(assetID string) (string, bool) {
+	s.assetMu.RLock()
+	defer s.assetMu.RUnlock()
+	conditionID, ok := s.assetIDToConditionID[assetID]
+	return conditionID, ok
+}
+
+// Catalog returns the catalog of markets known to the stream, used to validate
+// market identifiers sent by WebSocket clients.
+func (s *MarketStreamService) Catalog() *MarketCatalog {
+	return s.catalog
+}
+
+// MessagesProcessed returns the number of order book messages processed since startup.
+func (s *MarketStreamService) MessagesProcessed() int64 {
+	return s.messagesProcessed.Load()
+}
+
+// recordMarketActivity records that a message of a market was accepted for aggregation into
+// the bars at the given time.
+func (s *MarketStreamService) recordMarketActivity(conditionID string, at time.Time) {
+	s.activityMu.Lock()
+	defer s.activityMu.Unlock()
+	s.marketActivity[conditionID] = at
+}
+
+// MarketActivity returns the bar time of the last message accepted for aggregation per market,
+// for markets active within the last marketActivityRetention. Older entries are dropped.
+func (s *MarketStreamService) MarketActivity() map[string]time.Time {
+	s.activityMu.Lock()
+	defer s.activityMu.Unlock()
+
+	cutoff := time.Now().Add(-marketActivityRetention)
+	activity := make(map[string]time.Time, len(s.marketActivity))
+	for conditionID, at := range s.marketActivity {
+		if at.Before(cutoff) {
+			delete(s.marketActivity, conditionID)
+			continue
+		}
+		activity[conditionID] = at
+	}
+	return activity
+}
+
+// SetPublishBuffer sets the buffer that retries the stream's failed publishes, including the
+// aggregator's bar events. It must be called before the stream starts.
+func (s *MarketStreamService) SetPublishBuffer(buffer *PublishBuffer) {
+	s.publishBuffer = buffer
+	s.ohlcvAggregator.barEvents.SetPublishBuffer(buffer)
+}
+
+/**
+ * @description
+ * FlushOnShutdown saves all current OHLCV bars, including bars still in progress, and then
+ * publishes the messages left in the publish buffer, so that neither is lost on shutdown.
+ * It should be called after the background tasks have stopped, and before the database pool
+ * and the Redis client are closed.
+ *
+ * @param ctx Bounds the database writes and Redis calls; it must not be the cancelled root context.
+ * @returns The outcome of the bar flush, and the number of messages still buffered.
+ */
+func (s *MarketStreamService) FlushOnShutdown(ctx context.Context) (FlushResult, int) {
+	result := s.ohlcvAggregator.FlushAllContext(ctx)
+	if s.publishBuffer == nil {
+		return result, 0
 	}
+	return result, s.publishBuffer.Flush(ctx)
+}
+
+// Aggregator returns the OHLCV aggregator fed by the stream.
+func (s *MarketStreamService) Aggregator() *OHLCVAggregator {
+	return s.ohlcvAggregator
+}
+
+// AssetMappingSize returns the number of asset IDs the service can currently resolve.
+func (s *MarketStreamService) AssetMappingSize() int {
+	s.assetMu.RLock()
+	defer s.assetMu.RUnlock()
+	return len(s.assetIDToConditionID)
 }
 
 /**
@@ -95,136 +348,95 @@ func NewMarketStreamService(ctx context.Context, logger *slog.Logger, redisClien
  * - This function connects to Polymarket's real WebSocket feed.
  * - It runs in an infinite loop and should be started as a goroutine.
  * - It gracefully handles shutdown via the context.
- * - If WebSocket client is not configured, it falls back to a mock stream.
+ * - If the WebSocket client is not configured or cannot connect, it falls back to a mock
+ *   stream, unless the mock fallback is disabled, in which case the stream is marked failed.
  */
 func (s *MarketStreamService) RunStream() {
 	if s.wsClient == nil {
-		s.logger.Warn("CLOB WebSocket client not configured, falling back to mock stream")
-		s.RunMockStream()
+		s.fallBackToMock("CLOB WebSocket client not configured", ErrStreamNotConfigured)
 		return
 	}
 
 	s.logger.Info("starting Polymarket CLOB WebSocket stream service...")
+	s.mode.Store("websocket")
+	s.wsClient.OnTickSizeChange(s.handleTickSizeChange)
+	s.wsClient.OnLastTradePrice(s.handleLastTradePrice)
 
 	// Connect to WebSocket
 	if err := s.wsClient.Connect(); err != nil {
-		s.logger.Error("failed to connect to CLOB WebSocket", "error", err)
-		// Fall back to mock stream on connection failure
-		s.RunMockStream()
+		s.fallBackToMock("failed to connect to CLOB WebSocket", err)
 		return
 	}
 	defer s.wsClient.Close()
 
 	// Fetch active markets from Gamma API and extract token IDs
 	// Also create a mapping from asset/token ID to condition ID for publishing to correct Redis channels
-	var assetIDs []string
-	assetIDToConditionID := make(map[string]string) // Map asset ID -> condition ID
-	
-	if s.gammaClient != nil {
-		s.logger.Info("fetching active markets from Gamma API to subscribe to WebSocket...")
-		
-		// Fetch active markets (limit to first 100 for initial implementation)
-		// You can increase this or use GetAllActiveMarkets() for all markets
-		markets, err := s.gammaClient.ListActiveMarkets(s.ctx, 100, 0)
-		if err != nil {
-			s.logger.Error("failed to fetch markets from Gamma API", "error", err)
-			return // No fallback, as per user request
-		}
-		
-		// Log first market structure for debugging (only if needed)
-		if len(markets) > 0 {
-			firstMarket := markets[0]
-			s.logger.Info("sample market from Gamma API", 
-				"market_id", firstMarket.ConditionID,
-				"has_clobTokenIds", firstMarket.ClobTokenIds != "",
-				"tokens_count", len(firstMarket.Tokens))
-		}
-		
-		// Extract token IDs from markets and create mapping
-		// Try multiple methods to get token IDs:
-		// 1. Use clobTokenIds field (preferred - comma-separated or JSON array)
-		// 2. Fall back to Tokens array if clobTokenIds is empty
-		marketsWithTokens := 0
-		marketsWithoutTokens := 0
-		for i, market := range markets {
-			var tokenIDs []string
-			
-			// Method 1: Try clobTokenIds field (most reliable)
-			if market.ClobTokenIds != "" {
-				// Try parsing as JSON array first
-				var jsonArray []string
-				if err := json.Unmarshal([]byte(market.ClobTokenIds), &jsonArray); err == nil {
-					// Successfully parsed as JSON array
-					tokenIDs = jsonArray
-				} else {
-					// Try parsing as comma-separated string
-					parts := strings.Split(market.ClobTokenIds, ",")
-					for _, part := range parts {
-						trimmed := strings.TrimSpace(part)
-						if trimmed != "" {
-							tokenIDs = append(tokenIDs, trimmed)
-						}
-					}
-				}
-			}
-			
-			// Method 2: Fall back to Tokens array if clobTokenIds didn't work
-			if len(tokenIDs) == 0 && len(market.Tokens) > 0 {
-				for _, token := range market.Tokens {
-					if token.TokenID != "" {
-						tokenIDs = append(tokenIDs, token.TokenID)
-					}
-				}
-			}
-			
-			// Track statistics
-			if len(tokenIDs) == 0 {
-				marketsWithoutTokens++
-				// Only log warning for first few markets without tokens to avoid spam
-				if marketsWithoutTokens <= 3 {
-					s.logger.Warn("no token IDs found for market",
-						"market_id", market.ConditionID,
-						"market_index", i)
-				}
-			} else {
-				marketsWithTokens++
-				// Create mapping from token ID to condition ID
-				// This allows us to publish to Redis channels using condition ID when messages arrive
-				for _, tokenID := range tokenIDs {
-					assetIDToConditionID[tokenID] = market.ConditionID
-				}
-				// Add all token IDs found for this market
-				assetIDs = append(assetIDs, tokenIDs...)
-			}
-		}
-	s.logger.Info("✅ extracted token IDs from Gamma API markets", 
-		"market_count", len(markets),
-		"markets_with_tokens", marketsWithTokens,
-		"markets_without_tokens", marketsWithoutTokens,
-		"total_token_ids", len(assetIDs),
-		"mapping_size", len(assetIDToConditionID))
-	} else {
-		s.logger.Error("Gamma client not available - cannot fetch markets")
+	if s.gammaClient == nil {
+		s.fail(errors.New("Gamma client not available - cannot fetch markets"))
 		return
 	}
+	s.logger.Info("fetching active markets from Gamma API to subscribe to WebSocket...")
+
+	// Fetch active markets (limited to the first streamCatalogMarketLimit)
+	markets, err := s.gammaClient.ListActiveMarkets(s.ctx, streamCatalogMarketLimit, 0)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to fetch markets from Gamma API: %w", err))
+		return // No fallback, as per user request
+	}
+
+	// Log first market structure for debugging (only if needed)
+	if len(markets) > 0 {
+		firstMarket := markets[0]
+		s.logger.Info("sample market from Gamma API", 
+			"market_id", firstMarket.ConditionID,
+			"has_clobTokenIds", firstMarket.ClobTokenIds != "",
+			"tokens_count", len(firstMarket.Tokens))
+	}
+
+	// Markets Gamma returned without token IDs are looked up on the CLOB.
+	s.recoverMarketTokens(markets)
+	s.catalogFetchedAt.Store(time.Now().UnixNano())
+
+	// Featured markets are pinned, even if they are not among the fetched markets.
+	featured := s.resolveFeaturedMarkets(markets)
+	s.allocMu.Lock()
+	s.featuredMarkets = featured
+	s.allocMu.Unlock()
 
-	if len(assetIDs) == 0 {
-		s.logger.Error("no asset IDs to subscribe to - markets fetched but no token IDs extracted")
+	if s.setCatalogMarkets(markets) == 0 {
+		s.fail(errors.New("no asset IDs to subscribe to - markets fetched but no token IDs extracted"))
 		return
 	}
 
-	s.logger.Info("📡 proceeding to WebSocket subscription", "asset_count", len(assetIDs), "unique_markets", len(assetIDToConditionID))
-	s.logger.Info("📡 subscribing to WebSocket channels", "asset_count", len(assetIDs))
-	if err := s.wsClient.Subscribe(assetIDs); err != nil {
-		s.logger.Error("❌ failed to subscribe to WebSocket channels", "error", err)
+	s.logger.Info("📡 subscribing to WebSocket channels", "budget", s.config.MaxStreamAssets)
+	if err := s.rebalance(); err != nil {
+		s.fail(fmt.Errorf("failed to subscribe to WebSocket channels: %w", err))
 		return
 	}
-	s.logger.Info("✅ WebSocket subscription request sent", "asset_count", len(assetIDs))
+	s.logger.Info("✅ WebSocket subscription request sent", "asset_count", len(s.wsClient.SubscribedAssets()))
+
+	// Run background work tied to the stream: the featured market backfill, which runs in
+	// the background so that streaming starts immediately, and the subscription refresh.
+	// RunStream waits for both before returning.
+	var background sync.WaitGroup
+	defer background.Wait()
+	if len(featured) > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			s.prepareFeaturedMarkets(featured)
+		}()
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		s.runSubscriptionRefresh()
+	}()
 
-	// Listen for incoming messages
-	messageCount := 0
+	// Listen for incoming messages. Every shard of the pool calls the handler concurrently.
 	handler := func(bookMsg *polymarket.BookMessage) error {
-		messageCount++
+		messageCount := s.messagesProcessed.Add(1)
+		s.lastMessageAt.Store(time.Now().UnixNano())
 		if messageCount == 1 {
 			s.logger.Info("✅ WebSocket: first message received, subscription confirmed", 
 				"market", bookMsg.Market,
@@ -274,15 +486,24 @@ func (s *MarketStreamService) RunStream() {
 			}
 		}
 
+		// Record the top of book for trade aggressor inference
+		bookTime := time.Now().UTC()
+		if timestampMs, err := strconv.ParseInt(bookMsg.Timestamp, 10, 64); err == nil {
+			bookTime = time.UnixMilli(timestampMs).UTC()
+		}
+		bestBid, hasBid, bestAsk, hasAsk := extractBestPrices(bids, asks)
+		s.bookTops.record(bookMsg.AssetID, TopOfBook{BestBid: bestBid, HasBid: hasBid, BestAsk: bestAsk, HasAsk: hasAsk, At: bookTime})
+
 		// Map asset ID to condition ID FIRST (before OHLCV aggregation)
 		// The frontend subscribes using condition IDs, and we need to use condition IDs for OHLCV storage too
 		conditionID := bookMsg.Market // Default to bookMsg.Market (might already be condition ID)
 		
-		// Try to map asset ID to condition ID
-		if mappedConditionID, ok := assetIDToConditionID[bookMsg.AssetID]; ok {
+		// Try to map asset ID to condition ID.
+		// The shared mapping is used so that assets added dynamically are resolved too.
+		if mappedConditionID, ok := s.ConditionIDForAsset(bookMsg.AssetID); ok {
 			conditionID = mappedConditionID
 			s.logger.Debug("mapped asset ID to condition ID", "asset_id", bookMsg.AssetID, "condition_id", conditionID)
-		} else if mappedConditionID, ok := assetIDToConditionID[bookMsg.Market]; ok {
+		} else if mappedConditionID, ok := s.ConditionIDForAsset(bookMsg.Market); ok {
 			// bookMsg.Market might also be an asset ID
 			conditionID = mappedConditionID
 			s.logger.Debug("mapped market field to condition ID", "market", bookMsg.Market, "condition_id", conditionID)
@@ -294,9 +515,13 @@ func (s *MarketStreamService) RunStream() {
 				"using_as_condition_id", conditionID)
 		}
 
-		// Extract mid-price and aggregate OHLCV using condition ID
-		midPrice := ExtractMidPrice(bids, asks)
-		if midPrice > 0 {
+		// Extract mid-price and aggregate OHLCV using condition ID.
+		// Messages already aggregated by another ingester are skipped, but still published below.
+		midPrice, accepted := 0.0, false
+		if s.ledger.Claim(s.ctx, bookMsg.AssetID, bookMsg.Timestamp, bookMsg.Hash) {
+			midPrice, accepted = s.ohlcvAggregator.MidPriceFromBook(bids, asks)
+		}
+		if accepted {
 			// Parse timestamp (assuming it's in milliseconds)
 			timestampMs, err := strconv.ParseInt(bookMsg.Timestamp, 10, 64)
 			if err == nil {
@@ -344,41 +569,38 @@ func (s *MarketStreamService) RunStream() {
 				}
 				
 				// Use conditionID for OHLCV aggregation to ensure bars are stored under the correct market ID
+				s.recordMarketActivity(conditionID, timestamp)
 				if err := s.ohlcvAggregator.UpdatePrice(conditionID, midPrice, timestamp); err != nil {
 					s.logger.Error("failed to update OHLCV", "error", err, "condition_id", conditionID, "asset_id", bookMsg.AssetID)
 				}
+				s.storeLastPrice(conditionID, bookMsg.AssetID, midPrice, timestamp)
 			} else {
 				s.logger.Warn("failed to parse timestamp", "timestamp", bookMsg.Timestamp, "error", err)
 			}
 		} else {
-			s.logger.Debug("mid-price is 0, skipping OHLCV update", "condition_id", conditionID)
+			s.logger.Debug("mid-price is missing, filtered, or already aggregated, skipping OHLCV update", "condition_id", conditionID, "mid_price", midPrice)
 		}
 
 		// Convert valid bids/asks to the format expected by frontend
-		frontendBids := make([]map[string]interface{}, len(validBids))
+		frontendBids := make([]wire.BookLevel, len(validBids))
 		for i, bid := range validBids {
-			frontendBids[i] = map[string]interface{}{
-				"price": bid.Price,
-				"size":  bid.Size,
-			}
+			frontendBids[i] = wire.BookLevel{Price: bid.Price, Size: bid.Size}
 		}
-		frontendAsks := make([]map[string]interface{}, len(validAsks))
+		frontendAsks := make([]wire.BookLevel, len(validAsks))
 		for i, ask := range validAsks {
-			frontendAsks[i] = map[string]interface{}{
-				"price": ask.Price,
-				"size":  ask.Size,
-			}
+			frontendAsks[i] = wire.BookLevel{Price: ask.Price, Size: ask.Size}
 		}
 
 		// Convert to our format (using filtered bids/asks)
-		data := map[string]interface{}{
-			"event_type": bookMsg.EventType,
-			"asset_id":   bookMsg.AssetID,
-			"market":     conditionID, // Use condition ID for the market field
-			"bids":       frontendBids,
-			"asks":       frontendAsks,
-			"timestamp":  bookMsg.Timestamp,
-			"hash":       bookMsg.Hash,
+		data := wire.Book{
+			SchemaVersion: wire.SchemaVersion,
+			EventType:     bookMsg.EventType,
+			AssetID:       bookMsg.AssetID,
+			Market:        conditionID, // Use condition ID for the market field
+			Bids:          frontendBids,
+			Asks:          frontendAsks,
+			Timestamp:     bookMsg.Timestamp,
+			Hash:          bookMsg.Hash,
 		}
 
 		payload, err := json.Marshal(data)
@@ -388,11 +610,12 @@ func (s *MarketStreamService) RunStream() {
 		}
 		
 		// Publish to Redis channel using condition ID
-		channel := "market:" + conditionID
-		if err := s.redisClient.Publish(s.ctx, channel, payload).Err(); err != nil {
+		channel := channels.MarketChannel(conditionID)
+		if err := publish(s.ctx, s.redisClient, s.publishBuffer, channel, payload, PublishEphemeral); err != nil {
 			s.logger.Error("failed to publish data to redis", "error", err, "channel", channel)
 			return err
 		}
+		s.storeSnapshot(conditionID, payload)
 
 		// Only log first few publishes to avoid spam
 		if messageCount <= 3 {
@@ -406,13 +629,186 @@ func (s *MarketStreamService) RunStream() {
 		return nil
 	}
 
-	// Start listening (this blocks until connection closes)
-	if err := s.wsClient.Listen(handler); err != nil {
-		s.logger.Error("WebSocket listen error", "error", err)
-		// Attempt to reconnect after a delay
-		time.Sleep(5 * time.Second)
-		s.RunStream() // Recursive call to reconnect
+	// Close the pool on shutdown so that every shard's Listen, and Run below, return.
+	go func() {
+		<-s.ctx.Done()
+		s.wsClient.Close()
+	}()
+
+	// Run every shard of the pool until shutdown, each listening and reconnecting on its own.
+	s.wsClient.Run(func(shard int, client *polymarket.CLOBWebSocketClient) {
+		s.runShard(shard, client, handler)
+	})
+}
+
+/**
+ * @description
+ * runShard listens on one connection of the pool until shutdown. On a dropped connection,
+ * it reconnects and resubscribes to the shard's persisted asset set, which includes assets
+ * added dynamically, instead of re-deriving the set from Gamma; other shards keep streaming
+ * meanwhile. After a reconnect the subscriptions are rebalanced, re-fetching the catalog
+ * first if it is older than the configured TTL, so that assets whose subscription failed
+ * while the shard was down are subscribed again.
+ *
+ * @param shard The shard's index in the pool, for logging.
+ * @param client The shard's connection.
+ * @param handler The book message handler shared by all shards.
+ *
+ * @notes
+ * - Reconnection attempts back off exponentially; a connection that stayed up for
+ *   stableConnectionAfter starts the next outage from the minimum delay again.
+ */
+func (s *MarketStreamService) runShard(shard int, client *polymarket.CLOBWebSocketClient, handler polymarket.MessageHandler) {
+	backoff := minReconnectBackoff
+	for {
+		connectedAt := time.Now()
+		err := client.Listen(handler)
+		if err == nil || s.ctx.Err() != nil {
+			return
+		}
+		if time.Since(connectedAt) >= stableConnectionAfter {
+			backoff = minReconnectBackoff
+		}
+		s.logger.Error("WebSocket listen error", "error", err, "shard", shard)
+
+		for {
+			// Attempt to reconnect after a delay
+			delay := jitterBackoff(backoff)
+			s.logger.Info("reconnecting to CLOB WebSocket", "delay", delay, "shard", shard)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			if backoff *= 2; backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+
+			s.reconnectAttempts.Add(1)
+			if err := client.Reconnect(); err != nil {
+				s.logger.Error("failed to reconnect to CLOB WebSocket", "error", err, "shard", shard, "attempts", s.reconnectAttempts.Load())
+				continue
+			}
+			s.reconnects.Add(1)
+			s.logger.Info("reconnected to CLOB WebSocket", "shard", shard, "asset_count", len(client.SubscribedAssets()), "reconnects", s.reconnects.Load())
+			break
+		}
+
+		if s.catalogStale() {
+			if err := s.refreshCatalog(); err != nil {
+				s.logger.Warn("failed to refresh stale markets from Gamma API after reconnect, keeping the current catalog", "error", err)
+			}
+		}
+		if err := s.rebalance(); err != nil {
+			s.logger.Warn("failed to rebalance WebSocket subscriptions after reconnect", "error", err, "shard", shard)
+		}
+	}
+}
+
+// jitterBackoff returns a random delay between half of the backoff and the full backoff,
+// so that instances disconnected together do not reconnect in lockstep.
+func jitterBackoff(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	return half + rand.N(backoff-half+1)
+}
+
+/**
+ * @description
+ * handleLastTradePrice aggregates a trade into the market's OHLCV bars, contributing its
+ * size to the bars' volume. Like book timestamps, trade timestamps more than 5 minutes old
+ * or more than 1 minute in the future are replaced with the current time.
+ *
+ * @param message The last_trade_price event.
+ */
+func (s *MarketStreamService) handleLastTradePrice(message *polymarket.LastTradePriceMessage) {
+	price, err := strconv.ParseFloat(message.Price, 64)
+	if err != nil || price <= 0 || price >= 1 {
+		s.logger.Debug("ignoring trade with invalid price", "asset_id", message.AssetID, "price", message.Price)
+		return
+	}
+	size, err := strconv.ParseFloat(message.Size, 64)
+	if err != nil || size < 0 {
+		s.logger.Debug("ignoring trade with invalid size", "asset_id", message.AssetID, "size", message.Size)
+		return
+	}
+	// Trades carry no hash; price, size, and side tell trades with the same timestamp apart.
+	if !s.ledger.Claim(s.ctx, message.AssetID, message.Timestamp, "trade:"+message.Price+":"+message.Size+":"+message.Side) {
+		return
+	}
+
+	conditionID := message.Market
+	if mappedConditionID, ok := s.ConditionIDForAsset(message.AssetID); ok {
+		conditionID = mappedConditionID
+	}
+
+	now := time.Now().UTC()
+	timestamp := now
+	if timestampMs, err := strconv.ParseInt(message.Timestamp, 10, 64); err == nil {
+		tradeTime := time.UnixMilli(timestampMs).UTC()
+		if age := now.Sub(tradeTime); age <= 5*time.Minute && age >= -time.Minute {
+			timestamp = tradeTime
+		}
+	}
+
+	s.recordMarketActivity(conditionID, timestamp)
+	if err := s.ohlcvAggregator.UpdateTrade(conditionID, price, size, timestamp); err != nil {
+		s.logger.Error("failed to update OHLCV with trade", "error", err, "condition_id", conditionID, "asset_id", message.AssetID)
+	}
+}
+
+/**
+ * @description
+ * AddMarketAssets subscribes the live stream to additional assets for a market.
+ * The market is pinned in the subscription budget, and its assets are added to the
+ * asset→condition mapping and to the WebSocket client's persistent subscription set,
+ * so they survive reconnects.
+ *
+ * @param conditionID The market's condition ID.
+ * @param assetIDs The market's token IDs.
+ * @returns An error if the stream is not running over WebSocket or the subscription failed.
+ */
+func (s *MarketStreamService) AddMarketAssets(conditionID string, assetIDs []string) error {
+	if s.wsClient == nil {
+		return errors.New("CLOB WebSocket client not configured")
+	}
+
+	s.assetMu.Lock()
+	for _, assetID := range assetIDs {
+		s.assetIDToConditionID[assetID] = conditionID
+	}
+	s.assetMu.Unlock()
+	s.catalog.Add(conditionID, "")
+
+	s.allocMu.Lock()
+	s.addedMarkets[conditionID] = assetIDs
+	s.streamMarkets = append(s.streamMarkets, streamMarket{ConditionID: conditionID, TokenIDs: assetIDs})
+	s.allocMu.Unlock()
+
+	return s.rebalance()
+}
+
+// fallBackToMock runs the mock stream after the real stream could not start, or marks the
+// stream failed if the mock fallback is disabled.
+func (s *MarketStreamService) fallBackToMock(reason string, err error) {
+	if !s.config.MockFallbackEnabled {
+		s.fail(fmt.Errorf("%s and mock fallback is disabled: %w", reason, err))
+		return
 	}
+	s.logger.Warn(reason+", falling back to mock stream", "error", err)
+	s.RunMockStream()
+}
+
+// fail records that the stream has stopped, so that it is reported as unhealthy.
+func (s *MarketStreamService) fail(err error) {
+	s.mode.Store("failed")
+	s.failure.Store(err.Error())
+	s.logger.Error("market stream stopped", "error", err)
+}
+
+// Failure returns why the stream stopped, or "" if it has not failed.
+func (s *MarketStreamService) Failure() string {
+	failure, _ := s.failure.Load().(string)
+	return failure
 }
 
 /**
@@ -428,6 +824,7 @@ func (s *MarketStreamService) RunStream() {
  */
 func (s *MarketStreamService) RunMockStream() {
 	s.logger.Info("starting mock market data stream service...")
+	s.mode.Store("mock")
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
@@ -445,6 +842,10 @@ func (s *MarketStreamService) RunMockStream() {
 		},
 	}
 
+	for _, market := range mockMarkets {
+		s.catalog.Add(market.Market, "")
+	}
+
 	for {
 		select {
 		case <-s.ctx.Done():
@@ -453,16 +854,18 @@ func (s *MarketStreamService) RunMockStream() {
 		case <-ticker.C:
 			for _, market := range mockMarkets {
 				data := s.generateMockOrderBook(market.Market, market.AssetID)
+				s.messagesProcessed.Add(1)
+				s.lastMessageAt.Store(time.Now().UnixNano())
 				
 				// Extract mid-price and aggregate OHLCV
 				bids := data["bids"].([]interface{})
 				asks := data["asks"].([]interface{})
-				midPrice := ExtractMidPrice(bids, asks)
-				if midPrice > 0 {
+				if midPrice, accepted := s.ohlcvAggregator.MidPriceFromBook(bids, asks); accepted {
 					timestamp := time.Now()
 					if err := s.ohlcvAggregator.UpdatePrice(market.Market, midPrice, timestamp); err != nil {
 						s.logger.Error("failed to update OHLCV", "error", err, "market", market.Market)
 					}
+					s.storeLastPrice(market.Market, market.AssetID, midPrice, timestamp)
 				}
 
 				payload, err := json.Marshal(data)
@@ -471,26 +874,40 @@ func (s *MarketStreamService) RunMockStream() {
 					continue
 				}
 
-				channel := "market:" + market.Market
-				if err := s.redisClient.Publish(s.ctx, channel, payload).Err(); err != nil {
+				channel := channels.MarketChannel(market.Market)
+				if err := publish(s.ctx, s.redisClient, s.publishBuffer, channel, payload, PublishEphemeral); err != nil {
 					s.logger.Error("failed to publish data to redis", "error", err, "channel", channel)
 				}
+				s.storeSnapshot(market.Market, payload)
 			}
 		}
 	}
 }
 
+// storeSnapshot keeps the latest order book published for a market, which the hub sends to
+// clients as soon as they subscribe. A failure only delays new subscribers' first update.
+func (s *MarketStreamService) storeSnapshot(conditionID string, payload []byte) {
+	if !cachekeys.MarketSnapshot.Enabled() {
+		return
+	}
+	key := cachekeys.MarketSnapshot.Key(conditionID)
+	if err := s.redisClient.Set(s.ctx, key, payload, cachekeys.MarketSnapshot.TTL).Err(); err != nil {
+		s.logger.Warn("failed to store order book snapshot", "error", err, "key", key)
+	}
+}
+
 // generateMockOrderBook creates a randomized order book for a given market and asset ID.
 func (s *MarketStreamService) generateMockOrderBook(market string, assetID string) map[string]interface{} {
 	// This is a simplified mock - in production you'd use real data
 	return map[string]interface{}{
-		"event_type": "book",
-		"asset_id":   assetID,
-		"market":     market,
-		"bids":       []interface{}{},
-		"asks":       []interface{}{},
-		"timestamp":  fmt.Sprintf("%d", time.Now().UnixMilli()),
-		"hash":       fmt.Sprintf("0x%x", time.Now().UnixNano()%1000000000000),
+		"schema_version": wire.SchemaVersion,
+		"event_type":     "book",
+		"asset_id":       assetID,
+		"market":         market,
+		"bids":           []interface{}{},
+		"asks":           []interface{}{},
+		"timestamp":      fmt.Sprintf("%d", time.Now().UnixMilli()),
+		"hash":           fmt.Sprintf("0x%x", time.Now().UnixNano()%1000000000000),
 	}
 }
 
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/poly-pro/backend/internal/config"
+)
+
+// TestFlushOnShutdown cancels the stream's root context, as the shutdown path does before
+// flushing, and checks that the open bars are saved and the buffered messages published.
+func TestFlushOnShutdown(t *testing.T) {
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := newBarStore()
+	service := NewMarketStreamService(rootCtx, logger, nil, config.Config{}, store, nil, nil)
+	service.Aggregator().resolutions = []ResolutionDef{resolutionDef("M")}
+
+	publisher := &fakePublisher{down: true}
+	now := time.Now()
+	buffer := newTestPublishBuffer(publisher, PublishBufferPolicy{}, &now)
+	buffer.ctx = rootCtx
+	service.SetPublishBuffer(buffer)
+
+	start := barStartTime(now.UTC(), "M")
+	if err := service.Aggregator().UpdateTrade("0xmarket", 0.5, 4, now); err != nil {
+		t.Fatalf("update trade: %v", err)
+	}
+	buffer.Publish(rootCtx, ordersChannel, []byte("order-1"), PublishDurable)
+	buffer.Publish(rootCtx, barChannel, []byte("bar-1"), PublishDurable)
+
+	// Redis recovers just as the process is asked to stop.
+	cancelRoot()
+	publisher.down = false
+	buffer.replay(rootCtx)
+	if len(publisher.published) != 0 {
+		t.Fatalf("published %v with the cancelled root context", publisher.published)
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second)
+	defer cancelShutdown()
+	result, unpublished := service.FlushOnShutdown(shutdownCtx)
+	if result.BarsWritten != 1 || result.BarsFailed != 0 {
+		t.Errorf("flush = %+v, want 1 bar written", result)
+	}
+	if got := storedVolume(t, store, "0xmarket", start); got != 4 {
+		t.Errorf("stored volume = %v, want 4", got)
+	}
+	if unpublished != 0 || len(publisher.published) != 2 {
+		t.Errorf("published %v with %d left, want both messages", publisher.published, unpublished)
+	}
+}
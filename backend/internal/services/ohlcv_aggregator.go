@@ -5,9 +5,41 @@
  *
  * Key features:
  * - OHLCV Aggregation: Converts order book updates (bids/asks) into OHLCV bars.
+ * - Trade Volume: Trades (`UpdateTrade`) update the bars like prices do, add their size
+ *   to the bars' volume, and are counted in the bars' trade count; book mid-prices
+ *   (`UpdatePrice`) contribute no volume and no trades.
  * - Time-based Bucketing: Groups price updates into time buckets (1m, 5m, 15m, 1h, 1d, etc.).
+ *   The resolutions are the enabled ones (see resolutions.go) at construction; bar start and
+ *   end times are derived from each resolution's definition.
  * - In-memory State: Maintains current bar state for each market/resolution combination.
- * - Database Storage: Stores completed bars in the database.
+ * - Database Storage: Stores completed bars in the database. The periodic flush saves all
+ *   the bars completed since the previous tick in a single batch, one round trip instead of
+ *   one per bar, falling back to per-bar saves when the batch fails.
+ * - Bounded Memory: Optionally caps the number of markets held in memory, flushing and
+ *   evicting the least recently updated market when the cap is reached. Optionally, markets
+ *   without updates for an idle timeout are flushed and evicted by the periodic flush too.
+ * - Noise Filtering: Mid-prices outside a configurable band, or taken from books with an
+ *   implausibly wide spread, are skipped before aggregation and counted by reason.
+ * - Debug Mode: Saves are logged at Debug level; with the debug flag (`OHLCV_DEBUG`), every
+ *   saved bar is also read back and its timestamp conversion logged, at a query per bar.
+ * - Gap Filling: Optionally, periods without updates between two bars are saved as flat
+ *   bars at the previous close, up to a bounded number of periods (see ohlcv_gap_fill.go).
+ * - Suspension Catch-up: After the process was suspended, stale bars are closed and fresh bars
+ *   started for the current period, instead of being flushed late (see ohlcv_catch_up.go).
+ * - Bar Events: Completed bars are published to Redis as they are saved, when a publisher is
+ *   set (see bar_events.go); bars saved while still in progress are not published.
+ * - Startup Recovery: Bars of the current period already saved by a previous run are loaded
+ *   back into memory (`RecoverBars`), so that updates after a restart continue them.
+ * - Checkpoints: Optionally, in-progress bars are also checkpointed to Redis and restored at
+ *   startup, so that bars never saved before a crash survive it (see ohlcv_checkpoint.go).
+ *
+ * @notes
+ * - A bar may be saved several times (e.g. by `FlushMarket` and again once it completes), so
+ *   bars are saved with an upsert on (market_id, time, resolution) that merges them with the
+ *   stored bar: the stored open is kept, high and low widen, close is replaced, and volumes
+ *   are added. Each save therefore carries only the volume traded since the bar's previous
+ *   save (see `CurrentBar.SavedVolume`). A bar recovered after a restart starts from the
+ *   stored values, with all of its volume already saved.
  *
  * @dependencies
  * - github.com/poly-pro/backend/internal/db: For database access.
@@ -17,31 +49,92 @@
 package services
 
 import (
+	"container/list"
 	"context"
 	"fmt"
 	"log/slog"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/poly-pro/backend/internal/db"
 )
 
+// barRecoveryTimeout bounds the database queries of RecoverBars at startup.
+const barRecoveryTimeout = 30 * time.Second
+
 // OHLCVAggregator aggregates order book data into OHLCV bars.
 type OHLCVAggregator struct {
 	store  db.Querier
 	logger *slog.Logger
 	ctx    context.Context
 
+	// Resolutions bars are produced for, captured from the enabled set at construction.
+	resolutions []ResolutionDef
+
 	// In-memory state: market_id -> resolution -> current bar
 	bars map[string]map[string]*CurrentBar
 	mu   sync.RWMutex
+
+	// LRU tracking of markets in bars (front = most recently updated), guarded by mu.
+	// When maxMarkets > 0, the least recently updated market is evicted to make room, and
+	// when idleTimeout > 0, markets not updated for that long are evicted by the flush loop.
+	maxMarkets         int
+	marketLRU          *list.List // Of *marketRecency
+	marketElements     map[string]*list.Element
+	evictedMarkets     int64
+	idleTimeout        time.Duration
+	idleEvictedMarkets int64
+
+	// Mid-price sanity filter applied by MidPriceFromBook; counters are updated atomically.
+	priceFilter         MidPriceFilter
+	skippedBelowMin     atomic.Int64
+	skippedAboveMax     atomic.Int64
+	skippedWideSpread   atomic.Int64
+	skippedOneSidedBook atomic.Int64
 	
 	// Track statistics
-	totalUpdates   int64
-	totalBarsSaved int64
+	totalUpdates   atomic.Int64 // Updated atomically, as the market stream's shards update concurrently
 	lastStatusLog  time.Time
+
+	// Persistence health, guarded by saveMu rather than mu, as bars are saved both with and
+	// without mu held. saveMu is taken after mu, never before it.
+	saveMu         sync.Mutex
+	totalBarsSaved int64
+	lastSavedBars  map[string]time.Time // resolution -> start of the latest saved bar
+	saveFailures   int64
+	lastSaveFailed bool
+	persistLag     *persistLagTracker // Ingest-to-persist latency, safe for concurrent use
+	saveCounters   *saveCounters      // Per-resolution saves, updated atomically
+	clock          func() time.Time   // Time source of the flush loop and the per-resolution daily counters
+
+	// Gap filling (see ohlcv_gap_fill.go); the policy is read-only after construction, the rest is guarded by mu.
+	gapFill       GapFillPolicy
+	closedBars    map[string]map[string]closedBar // market_id -> resolution -> last completed bar that left memory
+	gapBarsFilled int64
+	gapsSkipped   int64
+
+	// Flush loop catch-ups after a suspension (see ohlcv_catch_up.go), guarded by mu.
+	suspensionCatchUps int64
+
+	// Publishes completed bars; nil disables publishing. Set before the flush loop starts.
+	barEvents *BarEventPublisher
+
+	// Checkpoints in-progress bars to Redis (see ohlcv_checkpoint.go); nil disables checkpointing.
+	// checkpointed is owned by the checkpoint loop, after construction.
+	checkpoints  *BarCheckpointer
+	checkpointed map[string]checkpointVersion // market_id:resolution -> last checkpointed state
+
+	// Diagnostics: log timestamp conversions and read every saved bar back; read-only after construction.
+	debug bool
+}
+
+// marketRecency is a market's entry in the LRU list.
+type marketRecency struct {
+	marketID  string
+	updatedAt time.Time // Time of the market's latest update, on the aggregator's clock
 }
 
 // CurrentBar represents a bar that is currently being aggregated.
@@ -54,17 +147,83 @@ type CurrentBar struct {
 	Low         float64
 	Close       float64
 	Volume      float64
+	SavedVolume float64 // Part of Volume already saved to the database, which the next save leaves out
 	Count       int64 // Number of updates in this bar
+	Trades      int64 // Number of trades in this bar, a subset of Count
+	Filled      bool  // Synthesized by gap filling rather than aggregated from updates
+	LastUpdate  time.Time // Time of the latest update in this bar; zero for filled and recovered bars
+}
+
+// MidPriceFilter bounds the mid-prices accepted for aggregation.
+// A zero value for any field disables that check.
+type MidPriceFilter struct {
+	MinMidPrice float64 `json:"min_mid_price"` // Mid-prices below this are skipped
+	MaxMidPrice float64 `json:"max_mid_price"` // Mid-prices above this are skipped
+	MaxSpread   float64 `json:"max_spread"`    // Books whose best ask minus best bid exceeds this are skipped
+}
+
+// Reasons reported in AggregatorStats.SkippedMidPrices.
+const (
+	skipReasonBelowMin     = "below_min"
+	skipReasonAboveMax     = "above_max"
+	skipReasonWideSpread   = "wide_spread"
+	skipReasonOneSidedBook = "one_sided_book"
+)
+
+// AggregatorStats is a point-in-time snapshot of the aggregator's in-memory state.
+type AggregatorStats struct {
+	TotalUpdates     int64                `json:"total_updates"`
+	TotalBarsSaved   int64                `json:"total_bars_saved"`
+	Markets          int                  `json:"markets"`
+	ActiveBars       int                  `json:"active_bars"`
+	BarsByResolution map[string]int       `json:"bars_by_resolution"`
+	MaxMarkets       int                  `json:"max_markets"` // 0 means unlimited
+	EvictedMarkets   int64                `json:"evicted_markets"`
+	IdleTimeout      string               `json:"idle_timeout,omitempty"` // Empty when idle markets are kept
+	IdleEvicted      int64                `json:"idle_evicted_markets"`
+	PriceFilter      MidPriceFilter       `json:"price_filter"`
+	SkippedMidPrices map[string]int64     `json:"skipped_mid_prices"` // By reason
+	LastSavedBars    map[string]time.Time `json:"last_saved_bars"`    // Resolution -> latest saved bar start
+	SaveFailures     int64                `json:"save_failures"`
+	LastSaveFailed   bool                 `json:"last_save_failed"`
+	PersistLag       PersistLagStats      `json:"persist_lag"` // Bar end to database write
+	// Resolution -> bars saved and failed since UTC midnight, and latest save time
+	SavesByResolution map[string]ResolutionSaveStats `json:"saves_by_resolution"`
+	GapFill           GapFillPolicy                  `json:"gap_fill"`
+	GapBarsFilled     int64                          `json:"gap_bars_filled"`      // Flat bars saved for quiet periods
+	GapsSkipped       int64                          `json:"gaps_skipped"`         // Gaps longer than the fill limit
+	CatchUps          int64                          `json:"suspension_catch_ups"` // Flushes that caught up after a suspension
+	BarEvents         BarEventStats                  `json:"bar_events"`           // Completed bars published to Redis
+	Checkpoints       BarCheckpointStats             `json:"checkpoints"`          // In-progress bars checkpointed to Redis
 }
 
 // NewOHLCVAggregator creates a new OHLCV aggregator.
-func NewOHLCVAggregator(ctx context.Context, logger *slog.Logger, store db.Querier) *OHLCVAggregator {
+// maxMarkets bounds the number of markets held in memory; 0 means unlimited.
+// priceFilter bounds the mid-prices accepted by MidPriceFromBook.
+// lagPolicy configures when the latency of persisting bars is reported as lagging.
+// gapFill configures whether periods without updates are saved as flat bars.
+// checkpoints stores in-progress bars in Redis, restored here; nil disables checkpointing.
+func NewOHLCVAggregator(ctx context.Context, logger *slog.Logger, store db.Querier, maxMarkets int, priceFilter MidPriceFilter, lagPolicy PersistLagPolicy, gapFill GapFillPolicy, checkpoints *BarCheckpointer, debug bool) *OHLCVAggregator {
 	agg := &OHLCVAggregator{
-		store:        store,
-		logger:       logger,
-		ctx:          ctx,
-		bars:         make(map[string]map[string]*CurrentBar),
-		lastStatusLog: time.Now(),
+		store:          store,
+		logger:         logger,
+		ctx:            ctx,
+		resolutions:    append([]ResolutionDef(nil), enabledResolutions...),
+		bars:           make(map[string]map[string]*CurrentBar),
+		maxMarkets:     maxMarkets,
+		marketLRU:      list.New(),
+		marketElements: make(map[string]*list.Element),
+		priceFilter:    priceFilter,
+		lastStatusLog:  time.Now(),
+		lastSavedBars:  make(map[string]time.Time),
+		persistLag:     newPersistLagTracker(lagPolicy),
+		saveCounters:   newSaveCounters(),
+		clock:          time.Now,
+		gapFill:        gapFill,
+		closedBars:     make(map[string]map[string]closedBar),
+		checkpoints:    checkpoints,
+		checkpointed:   make(map[string]checkpointVersion),
+		debug:          debug,
 	}
 	
 	// Test database connection by running a simple query
@@ -89,34 +248,155 @@ func NewOHLCVAggregator(ctx context.Context, logger *slog.Logger, store db.Queri
 		logger.Info("✅ OHLCV aggregator: database connection verified")
 	}
 	
-	// Start periodic status logging
-	go agg.periodicStatusLog()
-	
-	// Start periodic flush of completed bars
-	go agg.periodicFlush()
-	
+	// Checkpoints are restored first: they are at least as recent as the saved bars, which
+	// RecoverBars then only loads for the bars not in memory.
+	agg.restoreCheckpoints(ctx)
+	agg.RecoverBars(ctx)
+
+	// The periodic status log, flush, and checkpoint loops are started by the owner via
+	// RunStatusLog, RunPeriodicFlush, and RunCheckpoints, so their shutdown can be tracked.
 	return agg
 }
 
+/**
+ * @description
+ * RecoverBars loads the bars of the current period that are already saved in the database
+ * into memory, for every enabled resolution, so that the next updates continue them instead
+ * of starting fresh bars that would overwrite them. Only bars whose period has not yet ended
+ * are recovered, markets beyond the in-memory cap are skipped, and bars already in memory
+ * are left unchanged. Failures are logged and leave the affected bars unrecovered.
+ *
+ * @param ctx The context for the database queries.
+ * @returns The number of bars recovered.
+ */
+func (a *OHLCVAggregator) RecoverBars(ctx context.Context) int {
+	ctx, cancel := context.WithTimeout(ctx, barRecoveryTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	recovered := 0
+	for _, def := range a.resolutions {
+		resolution := def.Code
+		startTime := barStartTime(now, resolution)
+		if !barEndTime(startTime, resolution).After(now) {
+			continue
+		}
+
+		marketIDs, err := a.store.ListMarketIDsWithBarsSince(ctx, db.ListMarketIDsWithBarsSinceParams{
+			Resolution: resolution,
+			Since:      pgtype.Timestamptz{Time: startTime, Valid: true},
+		})
+		if err != nil {
+			a.logger.Warn("failed to list markets with current bars for recovery", "resolution", resolution, "error", err)
+			continue
+		}
+
+		for _, marketID := range marketIDs {
+			rows, err := a.store.GetMarketPriceHistory(ctx, db.GetMarketPriceHistoryParams{
+				MarketID:   marketID,
+				Time:       pgtype.Timestamptz{Time: startTime, Valid: true},
+				Time_2:     pgtype.Timestamptz{Time: startTime, Valid: true},
+				Resolution: resolution,
+			})
+			if err != nil {
+				a.logger.Warn("failed to load current bar for recovery", "market_id", marketID, "resolution", resolution, "error", err)
+				continue
+			}
+			if len(rows) == 0 {
+				continue
+			}
+			bar, ok := recoveredBar(rows[len(rows)-1])
+			if !ok {
+				a.logger.Warn("skipping current bar with null values", "market_id", marketID, "resolution", resolution)
+				continue
+			}
+			if a.restoreBar(bar) {
+				recovered++
+			}
+		}
+	}
+
+	if recovered > 0 {
+		a.logger.Info("recovered current OHLCV bars from the database", "bars", recovered)
+	}
+	return recovered
+}
+
+// recoveredBar converts a saved bar to an in-memory bar, reporting false if a price is null.
+func recoveredBar(row db.MarketPriceHistory) (*CurrentBar, bool) {
+	open, okOpen := numericFloat(row.Open)
+	high, okHigh := numericFloat(row.High)
+	low, okLow := numericFloat(row.Low)
+	closePrice, okClose := numericFloat(row.Close)
+	if !row.Time.Valid || !okOpen || !okHigh || !okLow || !okClose {
+		return nil, false
+	}
+	volume, _ := numericFloat(row.Volume)
+	return &CurrentBar{
+		MarketID:    row.MarketID,
+		Resolution:  row.Resolution,
+		StartTime:   row.Time.Time.UTC(),
+		Open:        open,
+		High:        high,
+		Low:         low,
+		Close:       closePrice,
+		Volume:      volume,
+		SavedVolume: volume,
+	}, true
+}
+
+// restoreBar puts a recovered bar in memory unless the market already has a bar of its
+// resolution or a new market would exceed the cap. It reports whether the bar was restored.
+func (a *OHLCVAggregator) restoreBar(bar *CurrentBar) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.bars[bar.MarketID] == nil {
+		if a.maxMarkets > 0 && len(a.bars) >= a.maxMarkets {
+			return false
+		}
+		a.bars[bar.MarketID] = make(map[string]*CurrentBar)
+	}
+	if _, exists := a.bars[bar.MarketID][bar.Resolution]; exists {
+		return false
+	}
+	a.bars[bar.MarketID][bar.Resolution] = bar
+	a.touchMarket(bar.MarketID)
+	return true
+}
+
 // UpdatePrice processes a price update for a market and updates the current bar.
 // It extracts the mid-price from the order book (average of best bid and ask).
 func (a *OHLCVAggregator) UpdatePrice(marketID string, price float64, timestamp time.Time) error {
-	a.totalUpdates++
+	update := a.totalUpdates.Add(1)
 	
 	// Log first few updates to confirm function is being called
-	if a.totalUpdates <= 3 {
+	if update <= 3 {
 		a.logger.Info("OHLCV aggregator: processing price update", 
-			"update", a.totalUpdates,
+			"update", update,
 			"market_id", marketID,
 			"price", price)
 	}
 	
-	// Update all resolutions for this market
-	resolutions := []string{"1", "5", "15", "60", "D"}
+	// Update all enabled resolutions for this market
+	for _, def := range a.resolutions {
+		if err := a.updateBarForResolution(marketID, def.Code, price, 0, 0, timestamp); err != nil {
+			a.logger.Error("failed to update bar", "market_id", marketID, "resolution", def.Code, "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateTrade processes a trade for a market: the trade price updates the current bars like
+// UpdatePrice does, and the trade size is added to their volume.
+func (a *OHLCVAggregator) UpdateTrade(marketID string, price, size float64, timestamp time.Time) error {
+	a.totalUpdates.Add(1)
 
-	for _, resolution := range resolutions {
-		if err := a.updateBarForResolution(marketID, resolution, price, timestamp); err != nil {
-			a.logger.Error("failed to update bar", "market_id", marketID, "resolution", resolution, "error", err)
+	for _, def := range a.resolutions {
+		if err := a.updateBarForResolution(marketID, def.Code, price, size, 1, timestamp); err != nil {
+			a.logger.Error("failed to update bar with trade", "market_id", marketID, "resolution", def.Code, "error", err)
 			return err
 		}
 	}
@@ -124,24 +404,34 @@ func (a *OHLCVAggregator) UpdatePrice(marketID string, price float64, timestamp
 	return nil
 }
 
-// updateBarForResolution updates the bar for a specific market and resolution.
-func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution string, price float64, timestamp time.Time) error {
+// updateBarForResolution updates the bar for a specific market and resolution with a price,
+// adding volume and trades (both 0 for book mid-prices) to the bar's volume and trade count.
+func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution string, price float64, volume float64, trades int64, timestamp time.Time) error {
+	// Deferred before the unlock so that it runs after it: the bars of an evicted market are
+	// saved without holding mu.
+	var evicted []*CurrentBar
+	defer func() { a.saveEvictedBars(evicted) }()
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Get or create the bar map for this market
+	// Get or create the bar map for this market, evicting the least recently
+	// updated market first if the aggregator is at capacity.
 	if a.bars[marketID] == nil {
+		if a.maxMarkets > 0 && len(a.bars) >= a.maxMarkets {
+			evicted = a.evictLeastRecentMarket()
+		}
 		a.bars[marketID] = make(map[string]*CurrentBar)
 	}
+	a.touchMarket(marketID)
 
 	// Calculate the start time for this bar based on resolution
 	barStartTime := a.getBarStartTime(timestamp, resolution)
 
 	// Log timestamp details for first few updates to debug date issues
 	now := time.Now().UTC()
-	if a.totalUpdates <= 10 {
+	if a.totalUpdates.Load() <= 10 {
 		a.logger.Info("🔍 timestamp flow in aggregator",
-			"update", a.totalUpdates,
+			"update", a.totalUpdates.Load(),
 			"market_id", marketID,
 			"resolution", resolution,
 			"input_timestamp", timestamp.Format(time.RFC3339),
@@ -159,7 +449,7 @@ func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution str
 	// Additional validation: if the bar start time is more than 1 day old, log a warning
 	// This helps catch cases where stale timestamps are creating bars with old dates
 	barDateDiff := now.Sub(barStartTime)
-	if barDateDiff > 24*time.Hour && a.totalUpdates <= 20 {
+	if barDateDiff > 24*time.Hour && a.totalUpdates.Load() <= 20 {
 		a.logger.Warn("⚠️  bar start time is more than 1 day old",
 			"bar_start_time", barStartTime.Format(time.RFC3339),
 			"bar_start_time_date", barStartTime.Format("2006-01-02"),
@@ -174,11 +464,15 @@ func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution str
 	bar, exists := a.bars[marketID][resolution]
 	if !exists || bar.StartTime.Before(barStartTime) {
 		// If the bar doesn't exist or we've moved to a new time period, save the old bar and create a new one
+		var previous *CurrentBar
 		if exists {
-			if err := a.saveBar(bar); err != nil {
+			if err := a.closeBar(bar); err != nil {
 				return err
 			}
+			previous = bar
 		}
+		// Periods without updates since the previous bar are saved as flat bars, if enabled
+		a.fillGap(marketID, resolution, barStartTime, previous)
 
 		// Create a new bar
 		bar = &CurrentBar{
@@ -189,12 +483,12 @@ func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution str
 			High:       price,
 			Low:        price,
 			Close:      price,
-			Volume:     0, // Volume would need to come from trade data
+			Volume:     0, // Accumulated from trades below
 			Count:      0,
 		}
 		a.bars[marketID][resolution] = bar
 		barEndTime := a.getBarEndTime(barStartTime, resolution)
-		a.logger.Info("🆕 created new OHLCV bar", 
+		a.logger.Debug("created new OHLCV bar",
 			"market_id", marketID, 
 			"resolution", resolution,
 			"start_time", barStartTime,
@@ -212,7 +506,12 @@ func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution str
 	if price < bar.Low {
 		bar.Low = price
 	}
+	bar.Volume += volume
+	bar.Trades += trades
 	bar.Count++
+	if timestamp.After(bar.LastUpdate) {
+		bar.LastUpdate = timestamp
+	}
 
 	// No need to log every update - too verbose
 
@@ -221,84 +520,184 @@ func (a *OHLCVAggregator) updateBarForResolution(marketID string, resolution str
 
 // getBarStartTime calculates the start time of the bar for a given timestamp and resolution.
 func (a *OHLCVAggregator) getBarStartTime(timestamp time.Time, resolution string) time.Time {
-	switch resolution {
-	case "1": // 1 minute
-		return timestamp.Truncate(time.Minute)
-	case "5": // 5 minutes
-		return timestamp.Truncate(5 * time.Minute)
-	case "15": // 15 minutes
-		return timestamp.Truncate(15 * time.Minute)
-	case "60": // 1 hour
-		return timestamp.Truncate(time.Hour)
-	case "D": // 1 day
-		// Ensure we use UTC for daily bars to avoid timezone issues
-		utcTimestamp := timestamp.UTC()
-		return time.Date(utcTimestamp.Year(), utcTimestamp.Month(), utcTimestamp.Day(), 0, 0, 0, 0, time.UTC)
-	default:
-		return timestamp.Truncate(time.Hour)
+	return barStartTime(timestamp, resolution)
+}
+
+// barStartTime returns the start of the bar of the given resolution containing timestamp.
+func barStartTime(timestamp time.Time, resolution string) time.Time {
+	return resolutionDef(resolution).Bucket(timestamp)
+}
+
+/**
+ * @description
+ * BucketStart returns the start of the interval-sized bucket containing timestamp.
+ * Buckets are aligned to the Unix epoch in UTC, so every bucket boundary is a whole
+ * multiple of interval since 1970-01-01T00:00:00Z (e.g. 4h buckets start at 00:00,
+ * 04:00, ... UTC, and daily buckets start at UTC midnight).
+ *
+ * @param timestamp The time to bucket.
+ * @param interval The bucket length; timestamp is returned unchanged if it is not positive.
+ * @returns The bucket start, in UTC.
+ */
+func BucketStart(timestamp time.Time, interval time.Duration) time.Time {
+	if interval <= 0 {
+		return timestamp.UTC()
+	}
+	// Split into seconds and nanoseconds so that dates far from the epoch cannot overflow.
+	secs, nanos := timestamp.Unix(), int64(timestamp.Nanosecond())
+	if interval%time.Second == 0 {
+		step := int64(interval / time.Second)
+		offset := secs % step
+		if offset < 0 {
+			offset += step // floor towards earlier time for pre-epoch timestamps
+		}
+		return time.Unix(secs-offset, 0).UTC()
+	}
+	unixNanos := secs*int64(time.Second) + nanos
+	offset := unixNanos % int64(interval)
+	if offset < 0 {
+		offset += int64(interval)
+	}
+	return time.Unix(0, unixNanos-offset).UTC()
+}
+
+// SetBarEventPublisher sets the publisher of completed bars. It must be called before the
+// aggregator receives updates.
+func (a *OHLCVAggregator) SetBarEventPublisher(publisher *BarEventPublisher) {
+	a.barEvents = publisher
+}
+
+// closeBar saves a bar whose period has ended and publishes it once saved.
+func (a *OHLCVAggregator) closeBar(bar *CurrentBar) error {
+	if err := a.saveBar(a.ctx, bar); err != nil {
+		return err
 	}
+	a.barEvents.Publish(a.ctx, bar)
+	return nil
 }
 
 // saveBar saves a completed bar to the database.
-func (a *OHLCVAggregator) saveBar(bar *CurrentBar) error {
+// Its outcome is counted in the per-resolution save counters.
+func (a *OHLCVAggregator) saveBar(ctx context.Context, bar *CurrentBar) (err error) {
+	defer func() { a.saveCounters.record(bar.Resolution, err == nil, a.clock()) }()
+
+	// Save into the database, merging with a bar already saved for the same period
+	arg, err := barParams(bar)
+	if err != nil {
+		return err
+	}
+	if err := a.store.UpsertMarketPriceHistory(ctx, arg); err != nil {
+		a.recordSaveFailure(bar, arg.PTime, err)
+		return fmt.Errorf("database insert failed: %w", err)
+	}
+	a.recordSavedBar(bar, arg.PTime)
+	return nil
+}
+
+/**
+ * @description
+ * saveBars saves completed bars to the database in a single batch, i.e. one round trip
+ * instead of one per bar. A bar that cannot be converted to its row is logged and left out,
+ * and the others are still batched. The batch runs in an implicit transaction, so when any
+ * bar fails none are saved, and the bars are saved one at a time instead so that the failing
+ * ones are reported individually and the others still saved. Saved bars are published, in
+ * their order.
+ *
+ * @param bars The bars to save.
+ */
+func (a *OHLCVAggregator) saveBars(bars []*CurrentBar) {
+	args := make([]db.UpsertMarketPriceHistoryBarsParams, 0, len(bars))
+	convertible := make([]*CurrentBar, 0, len(bars))
+	for _, bar := range bars {
+		arg, err := barParams(bar)
+		if err != nil {
+			a.saveCounters.record(bar.Resolution, false, a.clock())
+			a.logFlushFailure(bar, err)
+			continue
+		}
+		args = append(args, db.UpsertMarketPriceHistoryBarsParams(arg))
+		convertible = append(convertible, bar)
+	}
+	bars = convertible
+	if len(bars) <= 1 {
+		a.saveBarsIndividually(bars)
+		return
+	}
+
+	var batchErr error
+	a.store.UpsertMarketPriceHistoryBars(a.ctx, args).Exec(func(i int, err error) {
+		if err != nil && batchErr == nil {
+			batchErr = fmt.Errorf("bar %d (market %s, resolution %s): %w", i, bars[i].MarketID, bars[i].Resolution, err)
+		}
+	})
+	if batchErr != nil {
+		a.logger.Warn("batched save of completed bars failed, saving bars one at a time", "error", batchErr, "count", len(bars))
+		a.saveBarsIndividually(bars)
+		return
+	}
+
+	now := a.clock()
+	for i, bar := range bars {
+		a.recordSavedBar(bar, args[i].PTime)
+		a.saveCounters.record(bar.Resolution, true, now)
+		a.barEvents.Publish(a.ctx, bar)
+	}
+}
+
+// saveBarsIndividually saves and publishes completed bars one at a time, logging each failure.
+func (a *OHLCVAggregator) saveBarsIndividually(bars []*CurrentBar) {
+	for _, bar := range bars {
+		if err := a.closeBar(bar); err != nil {
+			a.logFlushFailure(bar, err)
+		}
+	}
+}
+
+// logFlushFailure logs a completed bar that the periodic flush could not save.
+func (a *OHLCVAggregator) logFlushFailure(bar *CurrentBar, err error) {
+	a.logger.Error("failed to flush completed bar",
+		"error", err,
+		"market_id", bar.MarketID,
+		"resolution", bar.Resolution,
+		"start_time", bar.StartTime,
+		"end_time", a.getBarEndTime(bar.StartTime, bar.Resolution))
+}
+
+// barParams converts a bar to the parameters of its upsert.
+func barParams(bar *CurrentBar) (db.UpsertMarketPriceHistoryParams, error) {
 	// Ensure the timestamp is in UTC before storing
 	// This prevents timezone-related issues when storing timestamps
 	utcTime := bar.StartTime.UTC()
-	
+
 	// Convert to database types
 	var timeVal pgtype.Timestamptz
 	if err := timeVal.Scan(utcTime); err != nil {
-		return err
-	}
-	
-	// Log the exact pgtype.Timestamptz value being sent to the database
-	// This helps debug timezone issues
-	a.logger.Info("🔍 timestamp conversion details",
-		"original_time", bar.StartTime.Format(time.RFC3339),
-		"utc_time", utcTime.Format(time.RFC3339),
-		"utc_time_unix", utcTime.Unix(),
-		"pgtype_valid", timeVal.Valid,
-		"pgtype_time", timeVal.Time.Format(time.RFC3339),
-		"pgtype_time_unix", timeVal.Time.Unix(),
-		"pgtype_infinity", timeVal.InfinityModifier,
-		"pgtype_time_utc", timeVal.Time.UTC().Format(time.RFC3339))
-
-	// Helper function to convert float64 to pgtype.Numeric
-	// pgtype.Numeric.Scan() doesn't accept float64 directly, so we convert to string first
-	convertToNumeric := func(val float64) (pgtype.Numeric, error) {
-		var num pgtype.Numeric
-		// Convert float64 to string with sufficient precision (10 decimal places)
-		// Use 'g' format to avoid trailing zeros and handle large/small numbers
-		valStr := strconv.FormatFloat(val, 'g', -1, 64)
-		if err := num.Scan(valStr); err != nil {
-			return num, fmt.Errorf("failed to scan %f as numeric: %w", val, err)
-		}
-		return num, nil
+		return db.UpsertMarketPriceHistoryParams{}, err
 	}
 
-	openVal, err := convertToNumeric(bar.Open)
+	openVal, err := floatToNumeric(bar.Open)
 	if err != nil {
-		return fmt.Errorf("failed to convert open: %w", err)
+		return db.UpsertMarketPriceHistoryParams{}, fmt.Errorf("failed to convert open: %w", err)
 	}
-	highVal, err := convertToNumeric(bar.High)
+	highVal, err := floatToNumeric(bar.High)
 	if err != nil {
-		return fmt.Errorf("failed to convert high: %w", err)
+		return db.UpsertMarketPriceHistoryParams{}, fmt.Errorf("failed to convert high: %w", err)
 	}
-	lowVal, err := convertToNumeric(bar.Low)
+	lowVal, err := floatToNumeric(bar.Low)
 	if err != nil {
-		return fmt.Errorf("failed to convert low: %w", err)
+		return db.UpsertMarketPriceHistoryParams{}, fmt.Errorf("failed to convert low: %w", err)
 	}
-	closeVal, err := convertToNumeric(bar.Close)
+	closeVal, err := floatToNumeric(bar.Close)
 	if err != nil {
-		return fmt.Errorf("failed to convert close: %w", err)
+		return db.UpsertMarketPriceHistoryParams{}, fmt.Errorf("failed to convert close: %w", err)
 	}
-	volumeVal, err := convertToNumeric(bar.Volume)
+	// The stored bar already counts the saved volume, and the upsert adds the rest to it
+	volumeVal, err := floatToNumeric(bar.Volume - bar.SavedVolume)
 	if err != nil {
-		return fmt.Errorf("failed to convert volume: %w", err)
+		return db.UpsertMarketPriceHistoryParams{}, fmt.Errorf("failed to convert volume: %w", err)
 	}
 
-	// Insert into database
-	arg := db.InsertMarketPriceHistoryParams{
+	return db.UpsertMarketPriceHistoryParams{
 		PTime:       timeVal,
 		PMarketID:   bar.MarketID,
 		POpen:       openVal,
@@ -307,42 +706,87 @@ func (a *OHLCVAggregator) saveBar(bar *CurrentBar) error {
 		PClose:      closeVal,
 		PVolume:     volumeVal,
 		PResolution: bar.Resolution,
-	}
+	}, nil
+}
 
-	// Log the insert attempt with full details, including UTC timestamp
-	a.logger.Debug("attempting to insert OHLCV bar",
+// recordSaveFailure logs a failed save of a bar and counts it.
+func (a *OHLCVAggregator) recordSaveFailure(bar *CurrentBar, timeVal pgtype.Timestamptz, err error) {
+	utcTime := bar.StartTime.UTC()
+	// Log detailed error information
+	a.logger.Error("❌ failed to insert market price history",
+		"error", err,
+		"error_type", fmt.Sprintf("%T", err),
 		"market_id", bar.MarketID,
 		"resolution", bar.Resolution,
 		"start_time_original", bar.StartTime,
 		"start_time_utc", utcTime,
 		"start_time_rfc3339", utcTime.Format(time.RFC3339),
-		"start_time_unix", utcTime.Unix(),
 		"time_valid", timeVal.Valid,
 		"open", bar.Open,
 		"high", bar.High,
 		"low", bar.Low,
 		"close", bar.Close)
+	a.saveMu.Lock()
+	a.saveFailures++
+	a.lastSaveFailed = true
+	a.saveMu.Unlock()
+}
 
-	if err := a.store.InsertMarketPriceHistory(a.ctx, arg); err != nil {
-		// Log detailed error information
-		a.logger.Error("❌ failed to insert market price history",
-			"error", err,
-			"error_type", fmt.Sprintf("%T", err),
-			"market_id", bar.MarketID,
-			"resolution", bar.Resolution,
-			"start_time_original", bar.StartTime,
-			"start_time_utc", utcTime,
-			"start_time_rfc3339", utcTime.Format(time.RFC3339),
-			"time_valid", timeVal.Valid,
-			"open", bar.Open,
-			"high", bar.High,
-			"low", bar.Low,
-			"close", bar.Close)
-		return fmt.Errorf("database insert failed: %w", err)
+// recordSavedBar records a successful save of a bar: it marks the bar's volume saved, updates
+// the statistics and the persistence latency, and verifies the bar when debugging.
+func (a *OHLCVAggregator) recordSavedBar(bar *CurrentBar, timeVal pgtype.Timestamptz) {
+	utcTime := bar.StartTime.UTC()
+
+	// Verify the insert by reading it back, only when diagnosing persistence issues
+	if a.debug {
+		a.verifySavedBar(bar, timeVal, utcTime)
+	}
+
+	bar.SavedVolume = bar.Volume
+	if !bar.Filled {
+		a.persistLag.Observe(a.clock().Sub(barEndTime(bar.StartTime, bar.Resolution)))
 	}
+	a.saveMu.Lock()
+	a.totalBarsSaved++
+	a.lastSaveFailed = false
+	if utcTime.After(a.lastSavedBars[bar.Resolution]) {
+		a.lastSavedBars[bar.Resolution] = utcTime
+	}
+	a.saveMu.Unlock()
+
+	a.logger.Debug("OHLCV bar saved",
+		"market_id", bar.MarketID,
+		"resolution", bar.Resolution,
+		"start_time", utcTime.Format(time.RFC3339),
+		"close", bar.Close,
+		"updates", bar.Count,
+		"trades", bar.Trades,
+		"volume", bar.Volume)
+}
+
+/**
+ * @description
+ * verifySavedBar logs the details of a bar's timestamp conversion and reads the bar back
+ * from the database, logging whether it was stored and under which date. It costs a query
+ * per saved bar, so it only runs when the aggregator's debug flag is set.
+ *
+ * @param bar The bar just saved.
+ * @param timeVal The timestamp sent to the database.
+ * @param utcTime The bar's start time in UTC.
+ */
+func (a *OHLCVAggregator) verifySavedBar(bar *CurrentBar, timeVal pgtype.Timestamptz, utcTime time.Time) {
+	a.logger.Info("🔍 timestamp conversion details",
+		"market_id", bar.MarketID,
+		"resolution", bar.Resolution,
+		"original_time", bar.StartTime.Format(time.RFC3339),
+		"utc_time", utcTime.Format(time.RFC3339),
+		"utc_time_unix", utcTime.Unix(),
+		"pgtype_valid", timeVal.Valid,
+		"pgtype_time", timeVal.Time.Format(time.RFC3339),
+		"pgtype_time_unix", timeVal.Time.Unix(),
+		"pgtype_infinity", timeVal.InfinityModifier,
+		"pgtype_time_utc", timeVal.Time.UTC().Format(time.RFC3339))
 
-	// Verify the insert by querying the database
-	// This helps catch cases where the insert appears to succeed but data isn't actually saved
 	verifyCtx, cancel := context.WithTimeout(a.ctx, 5*time.Second)
 	defer cancel()
 	
@@ -408,68 +852,134 @@ func (a *OHLCVAggregator) saveBar(bar *CurrentBar) error {
 				"resolution", bar.Resolution)
 		}
 	}
+}
 
-	a.totalBarsSaved++
-	
-	// Log the date being saved to help debug timestamp issues
-	currentDate := time.Now().UTC().Format("2006-01-02")
-	savedDate := utcTime.Format("2006-01-02")
-	dateMatches := savedDate == currentDate
-	
-	a.logger.Info("✅ OHLCV bar saved to database", 
-		"market_id", bar.MarketID, 
-		"resolution", bar.Resolution,
-		"start_time_utc", utcTime,
-		"start_time_rfc3339", utcTime.Format(time.RFC3339),
-		"start_time_date", savedDate,
-		"start_time_unix", utcTime.Unix(),
-		"current_date", currentDate,
-		"date_matches_current", dateMatches,
-		"open", bar.Open,
-		"high", bar.High,
-		"low", bar.Low,
-		"close", bar.Close,
-		"updates", bar.Count,
-		"total_saved", a.totalBarsSaved,
-		"verified", len(verifyResults) > 0)
-	
-	// Warn if the date doesn't match current date
-	if !dateMatches {
-		a.logger.Warn("⚠️  saved bar date does not match current date",
-			"saved_date", savedDate,
-			"current_date", currentDate,
-			"market_id", bar.MarketID,
-			"resolution", bar.Resolution,
-			"start_time_utc", utcTime.Format(time.RFC3339))
+// floatToNumeric converts a float64 to pgtype.Numeric.
+// pgtype.Numeric.Scan() doesn't accept float64 directly, so we convert to string first
+func floatToNumeric(val float64) (pgtype.Numeric, error) {
+	var num pgtype.Numeric
+	// Use 'g' format to avoid trailing zeros and handle large/small numbers
+	valStr := strconv.FormatFloat(val, 'g', -1, 64)
+	if err := num.Scan(valStr); err != nil {
+		return num, fmt.Errorf("failed to scan %f as numeric: %w", val, err)
 	}
-	
-	return nil
+	return num, nil
 }
 
-// FlushAll flushes all current bars to the database.
-// This should be called periodically or on shutdown.
-func (a *OHLCVAggregator) FlushAll() error {
+// FlushResult reports the outcome of FlushAll.
+type FlushResult struct {
+	BarsWritten int      `json:"bars_written"`
+	BarsFailed  int      `json:"bars_failed"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// FlushAll flushes all current bars to the database, including bars still in progress.
+// A failed bar does not stop the flush; its error is reported in the result.
+// This should be called on demand; see FlushAllContext for shutdown.
+func (a *OHLCVAggregator) FlushAll() FlushResult {
+	return a.FlushAllContext(a.ctx)
+}
+
+// FlushAllContext is FlushAll with its own context for the database writes, so that bars can
+// be flushed on shutdown after the aggregator's context has been cancelled.
+func (a *OHLCVAggregator) FlushAllContext(ctx context.Context) FlushResult {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	var result FlushResult
 	for marketID, resolutions := range a.bars {
 		for resolution, bar := range resolutions {
-			if err := a.saveBar(bar); err != nil {
+			if err := a.saveBar(ctx, bar); err != nil {
 				a.logger.Error("failed to flush bar", "market_id", marketID, "resolution", resolution, "error", err)
-				return err
+				result.BarsFailed++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s/%s: %v", marketID, resolution, err))
+				continue
 			}
+			result.BarsWritten++
 		}
 	}
 
-	return nil
+	return result
+}
+
+// FlushMarket flushes a market's current bars to the database, including bars still in
+// progress. A failed bar does not stop the flush; its error is reported in the result.
+func (a *OHLCVAggregator) FlushMarket(marketID string) FlushResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var result FlushResult
+	for resolution, bar := range a.bars[marketID] {
+		if err := a.saveBar(a.ctx, bar); err != nil {
+			a.logger.Error("failed to flush bar", "market_id", marketID, "resolution", resolution, "error", err)
+			result.BarsFailed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s/%s: %v", marketID, resolution, err))
+			continue
+		}
+		result.BarsWritten++
+	}
+
+	return result
 }
 
 // ExtractMidPrice extracts the mid-price from order book data (bids and asks).
 // Returns the average of the best bid and best ask, or 0 if no data is available.
 func ExtractMidPrice(bids []interface{}, asks []interface{}) float64 {
-	var bestBid, bestAsk float64
-	var hasBid, hasAsk bool
+	bestBid, hasBid, bestAsk, hasAsk := extractBestPrices(bids, asks)
 
+	// Calculate mid-price
+	if hasBid && hasAsk {
+		return (bestBid + bestAsk) / 2.0
+	} else if hasBid {
+		return bestBid
+	} else if hasAsk {
+		return bestAsk
+	}
+
+	return 0
+}
+
+/**
+ * @description
+ * MidPriceFromBook extracts the mid-price from order book data and applies the
+ * aggregator's mid-price filter. Skipped prices are counted by reason.
+ *
+ * @param bids The bid levels, best first.
+ * @param asks The ask levels, best first.
+ * @returns The mid-price, and whether it should be passed to UpdatePrice.
+ */
+func (a *OHLCVAggregator) MidPriceFromBook(bids []interface{}, asks []interface{}) (float64, bool) {
+	midPrice := ExtractMidPrice(bids, asks)
+	if midPrice <= 0 {
+		return 0, false
+	}
+
+	filter := a.priceFilter
+	if filter.MaxSpread > 0 {
+		bestBid, hasBid, bestAsk, hasAsk := extractBestPrices(bids, asks)
+		if !hasBid || !hasAsk {
+			a.skippedOneSidedBook.Add(1)
+			return midPrice, false
+		}
+		if bestAsk-bestBid > filter.MaxSpread {
+			a.skippedWideSpread.Add(1)
+			return midPrice, false
+		}
+	}
+	if filter.MinMidPrice > 0 && midPrice < filter.MinMidPrice {
+		a.skippedBelowMin.Add(1)
+		return midPrice, false
+	}
+	if filter.MaxMidPrice > 0 && midPrice > filter.MaxMidPrice {
+		a.skippedAboveMax.Add(1)
+		return midPrice, false
+	}
+
+	return midPrice, true
+}
+
+// extractBestPrices returns the best bid and best ask of an order book, if present.
+func extractBestPrices(bids []interface{}, asks []interface{}) (bestBid float64, hasBid bool, bestAsk float64, hasAsk bool) {
 	// Extract best bid (highest price)
 	if len(bids) > 0 {
 		if bidMap, ok := bids[0].(map[string]interface{}); ok {
@@ -494,16 +1004,7 @@ func ExtractMidPrice(bids []interface{}, asks []interface{}) float64 {
 		}
 	}
 
-	// Calculate mid-price
-	if hasBid && hasAsk {
-		return (bestBid + bestAsk) / 2.0
-	} else if hasBid {
-		return bestBid
-	} else if hasAsk {
-		return bestAsk
-	}
-
-	return 0
+	return bestBid, hasBid, bestAsk, hasAsk
 }
 
 // parseFloat is a helper to parse string to float64.
@@ -513,24 +1014,12 @@ func parseFloat(s string) (float64, error) {
 
 // getNextSaveTime calculates when the next bar for this resolution will be saved.
 func (a *OHLCVAggregator) getNextSaveTime(barStartTime time.Time, resolution string) time.Time {
-	switch resolution {
-	case "1": // 1 minute
-		return barStartTime.Add(1 * time.Minute)
-	case "5": // 5 minutes
-		return barStartTime.Add(5 * time.Minute)
-	case "15": // 15 minutes
-		return barStartTime.Add(15 * time.Minute)
-	case "60": // 1 hour
-		return barStartTime.Add(1 * time.Hour)
-	case "D": // 1 day
-		return barStartTime.Add(24 * time.Hour)
-	default:
-		return barStartTime.Add(1 * time.Hour)
-	}
-}
-
-// periodicStatusLog logs the current state of all bars periodically.
-func (a *OHLCVAggregator) periodicStatusLog() {
+	return barEndTime(barStartTime, resolution)
+}
+
+// RunStatusLog logs the current state of all bars periodically until the context is cancelled.
+// It should be started as a goroutine.
+func (a *OHLCVAggregator) RunStatusLog() {
 	ticker := time.NewTicker(30 * time.Second) // Log every 30 seconds
 	defer ticker.Stop()
 
@@ -548,11 +1037,12 @@ func (a *OHLCVAggregator) periodicStatusLog() {
 func (a *OHLCVAggregator) logStatus() {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
+	totalBarsSaved := a.TotalBarsSaved()
 
 	if len(a.bars) == 0 {
 		a.logger.Warn("⚠️  OHLCV aggregator: no bars in memory",
-			"total_updates", a.totalUpdates,
-			"total_bars_saved", a.totalBarsSaved)
+			"total_updates", a.totalUpdates.Load(),
+			"total_bars_saved", totalBarsSaved)
 		return
 	}
 
@@ -567,41 +1057,257 @@ func (a *OHLCVAggregator) logStatus() {
 		}
 	}
 
+	// Report the bars saved and failed today by resolution, so that a single failing
+	// resolution shows up even while the others keep the totals growing
+	savedToday := make(map[string]int64)
+	failedToday := make(map[string]int64)
+	for resolution, saves := range a.saveCounters.snapshot(a.clock()) {
+		savedToday[resolution] = saves.SavedToday
+		failedToday[resolution] = saves.FailedToday
+	}
+
 	a.logger.Info("📊 OHLCV aggregator status",
-		"updates", a.totalUpdates,
-		"bars_saved", a.totalBarsSaved,
+		"updates", a.totalUpdates.Load(),
+		"bars_saved", totalBarsSaved,
 		"markets", len(a.bars),
 		"active_bars", totalBars,
-		"by_resolution", barCounts)
+		"by_resolution", barCounts,
+		"saved_today", savedToday,
+		"failed_today", failedToday)
+}
+
+// Stats returns a snapshot of the aggregator's counters and in-memory bar counts.
+func (a *OHLCVAggregator) Stats() AggregatorStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
+
+	stats := AggregatorStats{
+		TotalUpdates:     a.totalUpdates.Load(),
+		TotalBarsSaved:   a.totalBarsSaved,
+		Markets:          len(a.bars),
+		BarsByResolution: make(map[string]int),
+		MaxMarkets:       a.maxMarkets,
+		EvictedMarkets:   a.evictedMarkets,
+		IdleEvicted:      a.idleEvictedMarkets,
+		PriceFilter:      a.priceFilter,
+		SkippedMidPrices: map[string]int64{
+			skipReasonBelowMin:     a.skippedBelowMin.Load(),
+			skipReasonAboveMax:     a.skippedAboveMax.Load(),
+			skipReasonWideSpread:   a.skippedWideSpread.Load(),
+			skipReasonOneSidedBook: a.skippedOneSidedBook.Load(),
+		},
+		LastSavedBars:  make(map[string]time.Time, len(a.lastSavedBars)),
+		SaveFailures:   a.saveFailures,
+		LastSaveFailed: a.lastSaveFailed,
+		PersistLag:     a.persistLag.Stats(),
+
+		SavesByResolution: a.saveCounters.snapshot(a.clock()),
+		GapFill:           a.gapFill,
+		GapBarsFilled:     a.gapBarsFilled,
+		GapsSkipped:       a.gapsSkipped,
+		CatchUps:          a.suspensionCatchUps,
+		BarEvents:         a.barEvents.Stats(),
+		Checkpoints:       a.checkpoints.Stats(),
+	}
+	if a.idleTimeout > 0 {
+		stats.IdleTimeout = a.idleTimeout.String()
+	}
+	for resolution, startTime := range a.lastSavedBars {
+		stats.LastSavedBars[resolution] = startTime
+	}
+	for _, resolutions := range a.bars {
+		for resolution := range resolutions {
+			stats.BarsByResolution[resolution]++
+			stats.ActiveBars++
+		}
+	}
+	return stats
+}
+
+// TotalBarsSaved returns the number of bars saved since startup.
+func (a *OHLCVAggregator) TotalBarsSaved() int64 {
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
+	return a.totalBarsSaved
+}
+
+// touchMarket marks a market as the most recently updated. Must be called with mu held.
+func (a *OHLCVAggregator) touchMarket(marketID string) {
+	now := a.clock()
+	if element, ok := a.marketElements[marketID]; ok {
+		element.Value.(*marketRecency).updatedAt = now
+		a.marketLRU.MoveToFront(element)
+		return
+	}
+	a.marketElements[marketID] = a.marketLRU.PushFront(&marketRecency{marketID: marketID, updatedAt: now})
+}
+
+// forgetMarket removes a market from LRU tracking. Must be called with mu held.
+func (a *OHLCVAggregator) forgetMarket(marketID string) {
+	if element, ok := a.marketElements[marketID]; ok {
+		a.marketLRU.Remove(element)
+		delete(a.marketElements, marketID)
+	}
 }
 
-// periodicFlush periodically checks for completed bars and saves them to the database.
+// SetIdleTimeout sets how long a market may go without updates before the flush loop evicts
+// it; zero or less keeps idle markets. It must be called before the flush loop starts.
+func (a *OHLCVAggregator) SetIdleTimeout(timeout time.Duration) {
+	a.idleTimeout = max(timeout, 0)
+}
+
+/**
+ * @description
+ * evictLeastRecentMarket removes the least recently updated market from memory. Must be
+ * called with mu held.
+ *
+ * @returns The market's in-progress bars, to be saved with saveEvictedBars once mu is released.
+ */
+func (a *OHLCVAggregator) evictLeastRecentMarket() []*CurrentBar {
+	oldest := a.marketLRU.Back()
+	if oldest == nil {
+		return nil
+	}
+	marketID := oldest.Value.(*marketRecency).marketID
+
+	evicted := a.evictMarket(marketID)
+	a.evictedMarkets++
+
+	a.logger.Info("OHLCV aggregator: evicted least recently updated market",
+		"market_id", marketID,
+		"bars_evicted", len(evicted),
+		"max_markets", a.maxMarkets,
+		"total_evicted", a.evictedMarkets)
+	return evicted
+}
+
+/**
+ * @description
+ * evictIdleMarkets removes the markets without updates for the idle timeout from memory and
+ * then saves their in-progress bars, logging a summary if any was evicted.
+ */
+func (a *OHLCVAggregator) evictIdleMarkets() {
+	if a.idleTimeout <= 0 {
+		return
+	}
+	cutoff := a.clock().Add(-a.idleTimeout)
+
+	a.mu.Lock()
+	evicted := 0
+	var bars []*CurrentBar
+	// The LRU list is ordered by update time, so idle markets are at its back.
+	for oldest := a.marketLRU.Back(); oldest != nil; oldest = a.marketLRU.Back() {
+		recency := oldest.Value.(*marketRecency)
+		if !recency.updatedAt.Before(cutoff) {
+			break
+		}
+		bars = append(bars, a.evictMarket(recency.marketID)...)
+		evicted++
+	}
+	a.idleEvictedMarkets += int64(evicted)
+	totalIdleEvicted, marketsRemaining := a.idleEvictedMarkets, len(a.bars)
+	a.mu.Unlock()
+	if evicted == 0 {
+		return
+	}
+
+	// Saved outside the lock, so that updates of the other markets do not wait on the database
+	flushed := a.saveEvictedBars(bars)
+
+	a.logger.Info("OHLCV aggregator: evicted idle markets",
+		"markets_evicted", evicted,
+		"bars_flushed", flushed,
+		"idle_timeout", a.idleTimeout,
+		"markets_remaining", marketsRemaining,
+		"total_idle_evicted", totalIdleEvicted)
+}
+
+/**
+ * @description
+ * evictMarket removes a market from memory. Must be called with mu held.
+ *
+ * @param marketID The market to evict.
+ * @returns The market's in-progress bars, to be saved with saveEvictedBars once mu is released.
+ */
+func (a *OHLCVAggregator) evictMarket(marketID string) []*CurrentBar {
+	evicted := make([]*CurrentBar, 0, len(a.bars[marketID]))
+	for _, bar := range a.bars[marketID] {
+		a.rememberClosedBar(bar)
+		evicted = append(evicted, bar)
+	}
+
+	delete(a.bars, marketID)
+	a.forgetMarket(marketID)
+	return evicted
+}
+
+/**
+ * @description
+ * saveEvictedBars saves the in-progress bars of evicted markets to the database. Must be
+ * called without mu held, so that a slow database does not block updates.
+ *
+ * @param bars The evicted bars, no longer referenced by the aggregator's maps.
+ * @returns The number of bars saved.
+ *
+ * @notes
+ * - Bars that fail to save are logged and dropped; eviction still proceeds so that
+ *   memory stays bounded even when the database is unavailable.
+ */
+func (a *OHLCVAggregator) saveEvictedBars(bars []*CurrentBar) int {
+	flushed := 0
+	for _, bar := range bars {
+		if err := a.saveBar(a.ctx, bar); err != nil {
+			a.logger.Error("failed to flush bar for evicted market", "market_id", bar.MarketID, "resolution", bar.Resolution, "error", err)
+			continue
+		}
+		flushed++
+	}
+	return flushed
+}
+
+// RunPeriodicFlush periodically checks for completed bars and saves them to the database.
 // This ensures bars are saved even if no new price updates arrive after a time period ends.
-func (a *OHLCVAggregator) periodicFlush() {
-	ticker := time.NewTicker(15 * time.Second) // Check every 15 seconds
+// A tick arriving late after a suspension first catches up the stale bars (see ohlcv_catch_up.go).
+// After the flush, markets idle for longer than the idle timeout are evicted.
+// It runs until the context is cancelled and should be started as a goroutine.
+func (a *OHLCVAggregator) RunPeriodicFlush() {
+	ticker := time.NewTicker(flushInterval)
 	defer ticker.Stop()
 
+	lastTick := a.clock()
 	for {
 		select {
 		case <-a.ctx.Done():
 			return
 		case <-ticker.C:
-			a.flushCompletedBars()
+			lastTick = a.flushTick(lastTick)
 		}
 	}
 }
 
+// flushTick runs one tick of the flush loop, catching up first if the tick arrived late
+// after the previous one at lastTick. It returns the time of this tick.
+func (a *OHLCVAggregator) flushTick(lastTick time.Time) time.Time {
+	now := a.clock()
+	if gap := now.Sub(lastTick); gap > suspendedFlushGap {
+		a.catchUpSuspendedBars(now, gap)
+	}
+	a.flushCompletedBars()
+	a.evictIdleMarkets()
+	return now
+}
+
 // flushCompletedBars checks all bars in memory and saves any that have completed their time period.
 func (a *OHLCVAggregator) flushCompletedBars() {
-	now := time.Now()
+	now := a.clock()
 	var barsToSave []*CurrentBar
-	var barsToRemove []struct {
-		marketID  string
-		resolution string
-	}
 
 	a.mu.Lock()
-	// First pass: identify completed bars
+	a.pruneClosedBars(now)
+	// First pass: take completed bars out of memory, so that an update cannot close and save
+	// a bar again while it is being saved (its volume would be counted twice)
 	totalBarsChecked := 0
 	for marketID, resolutions := range a.bars {
 		for resolution, bar := range resolutions {
@@ -613,12 +1319,15 @@ func (a *OHLCVAggregator) flushCompletedBars() {
 			// If the current time is past the bar's end time (with tolerance), it's completed
 			if now.After(barEndTime.Add(-time.Second)) {
 				barsToSave = append(barsToSave, bar)
-				barsToRemove = append(barsToRemove, struct {
-					marketID   string
-					resolution string
-				}{marketID: marketID, resolution: resolution})
+				a.rememberClosedBar(bar)
+				delete(resolutions, resolution)
 			}
 		}
+		// If no more bars for this market, remove the market entry
+		if len(resolutions) == 0 {
+			delete(a.bars, marketID)
+			a.forgetMarket(marketID)
+		}
 	}
 	a.mu.Unlock()
 
@@ -633,56 +1342,31 @@ func (a *OHLCVAggregator) flushCompletedBars() {
 	// Second pass: save completed bars (outside the lock to avoid holding it during DB operations)
 	if len(barsToSave) > 0 {
 		a.logger.Info("💾 flushing completed bars", "count", len(barsToSave))
-		for _, bar := range barsToSave {
-			barEndTime := a.getBarEndTime(bar.StartTime, bar.Resolution)
-			if err := a.saveBar(bar); err != nil {
-				a.logger.Error("failed to flush completed bar", 
-					"error", err, 
-					"market_id", bar.MarketID, 
-					"resolution", bar.Resolution,
-					"start_time", bar.StartTime,
-					"end_time", barEndTime,
-					"now", now)
-			} else {
-				a.logger.Info("✅ flushed completed bar",
-					"market_id", bar.MarketID,
-					"resolution", bar.Resolution,
-					"start_time", bar.StartTime,
-					"end_time", barEndTime,
-					"updates_in_bar", bar.Count)
-			}
-		}
+		// All completed bars are saved in one batched round trip (see saveBars)
+		a.saveBars(barsToSave)
+	}
 
-		// Third pass: remove saved bars from memory
-		a.mu.Lock()
-		for _, toRemove := range barsToRemove {
-			if resolutions, ok := a.bars[toRemove.marketID]; ok {
-				delete(resolutions, toRemove.resolution)
-				// If no more bars for this market, remove the market entry
-				if len(resolutions) == 0 {
-					delete(a.bars, toRemove.marketID)
-				}
-			}
+	// Evaluate the persistence latency of the bars saved during this cycle
+	if a.persistLag.EndCycle() {
+		stats := a.persistLag.Stats()
+		if stats.Lagging {
+			a.logger.Error("OHLCV bar persistence is lagging",
+				"p95", stats.LastCycleP95,
+				"threshold", stats.Threshold,
+				"consecutive_cycles", stats.ConsecutiveBreaches)
+		} else {
+			a.logger.Info("OHLCV bar persistence recovered", "p95", stats.LastCycleP95)
 		}
-		a.mu.Unlock()
 	}
 }
 
 // getBarEndTime calculates when a bar's time period ends based on its start time and resolution.
 func (a *OHLCVAggregator) getBarEndTime(startTime time.Time, resolution string) time.Time {
-	switch resolution {
-	case "1": // 1 minute
-		return startTime.Add(1 * time.Minute)
-	case "5": // 5 minutes
-		return startTime.Add(5 * time.Minute)
-	case "15": // 15 minutes
-		return startTime.Add(15 * time.Minute)
-	case "60": // 1 hour
-		return startTime.Add(1 * time.Hour)
-	case "D": // 1 day
-		return startTime.Add(24 * time.Hour)
-	default:
-		return startTime.Add(1 * time.Hour)
-	}
+	return barEndTime(startTime, resolution)
+}
+
+// barEndTime returns the end of the bar of the given resolution starting at startTime.
+func barEndTime(startTime time.Time, resolution string) time.Time {
+	return resolutionDef(resolution).End(startTime)
 }
 
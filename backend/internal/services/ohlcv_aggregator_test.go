@@ -0,0 +1,532 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+func TestBucketStart(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	kolkata := time.FixedZone("IST", 5*3600+30*60)
+	utc := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t.Fatalf("parse %q: %v", value, err)
+		}
+		return parsed.UTC()
+	}
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		interval  time.Duration
+		want      time.Time
+	}{
+		{"1m inside", utc("2024-03-05T10:17:42.5Z"), time.Minute, utc("2024-03-05T10:17:00Z")},
+		{"1m on boundary", utc("2024-03-05T10:17:00Z"), time.Minute, utc("2024-03-05T10:17:00Z")},
+		{"1m last nanosecond", utc("2024-03-05T10:17:59.999999999Z"), time.Minute, utc("2024-03-05T10:17:00Z")},
+		{"5m inside", utc("2024-03-05T10:17:42Z"), 5 * time.Minute, utc("2024-03-05T10:15:00Z")},
+		{"5m on boundary", utc("2024-03-05T10:20:00Z"), 5 * time.Minute, utc("2024-03-05T10:20:00Z")},
+		{"15m inside", utc("2024-03-05T10:44:59Z"), 15 * time.Minute, utc("2024-03-05T10:30:00Z")},
+		{"15m on boundary", utc("2024-03-05T10:45:00Z"), 15 * time.Minute, utc("2024-03-05T10:45:00Z")},
+		{"1h inside", utc("2024-03-05T10:59:59Z"), time.Hour, utc("2024-03-05T10:00:00Z")},
+		{"1h on boundary", utc("2024-03-05T11:00:00Z"), time.Hour, utc("2024-03-05T11:00:00Z")},
+		{"4h inside", utc("2024-03-05T10:17:42Z"), 4 * time.Hour, utc("2024-03-05T08:00:00Z")},
+		{"4h on boundary", utc("2024-03-05T20:00:00Z"), 4 * time.Hour, utc("2024-03-05T20:00:00Z")},
+		{"4h before midnight", utc("2024-03-05T23:59:59Z"), 4 * time.Hour, utc("2024-03-05T20:00:00Z")},
+		{"1d inside", utc("2024-03-05T10:17:42Z"), 24 * time.Hour, utc("2024-03-05T00:00:00Z")},
+		{"1d on boundary", utc("2024-03-06T00:00:00Z"), 24 * time.Hour, utc("2024-03-06T00:00:00Z")},
+		{"1d leap day", utc("2024-02-29T23:59:59Z"), 24 * time.Hour, utc("2024-02-29T00:00:00Z")},
+		{"250ms interval", utc("2024-03-05T10:17:42.123456789Z"), 250 * time.Millisecond, utc("2024-03-05T10:17:42Z")},
+		{"1h pre-epoch", utc("1969-12-31T23:30:00Z"), time.Hour, utc("1969-12-31T23:00:00Z")},
+		{"1d pre-epoch", utc("1969-12-31T12:00:00Z"), 24 * time.Hour, utc("1969-12-31T00:00:00Z")},
+		// Non-UTC inputs are bucketed by their instant, in UTC, not by their local wall clock.
+		{"1h New York", time.Date(2024, 3, 5, 5, 17, 0, 0, newYork), time.Hour, utc("2024-03-05T10:00:00Z")},
+		{"4h New York", time.Date(2024, 3, 5, 5, 17, 0, 0, newYork), 4 * time.Hour, utc("2024-03-05T08:00:00Z")},
+		{"1d New York evening", time.Date(2024, 3, 5, 21, 0, 0, 0, newYork), 24 * time.Hour, utc("2024-03-06T00:00:00Z")},
+		{"1d across DST change", time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), 24 * time.Hour, utc("2024-03-10T00:00:00Z")},
+		{"15m half-hour offset", time.Date(2024, 3, 5, 16, 10, 0, 0, kolkata), 15 * time.Minute, utc("2024-03-05T10:30:00Z")},
+		{"1d half-hour offset", time.Date(2024, 3, 6, 2, 0, 0, 0, kolkata), 24 * time.Hour, utc("2024-03-05T00:00:00Z")},
+		{"zero interval", time.Date(2024, 3, 5, 5, 17, 42, 0, newYork), 0, utc("2024-03-05T10:17:42Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BucketStart(tt.timestamp, tt.interval)
+			if !got.Equal(tt.want) {
+				t.Errorf("BucketStart(%s, %s) = %s, want %s", tt.timestamp, tt.interval, got, tt.want)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("BucketStart(%s, %s) location = %s, want UTC", tt.timestamp, tt.interval, got.Location())
+			}
+		})
+	}
+}
+
+// storedBar is a bar saved to a barStore.
+type storedBar struct {
+	open, high, low, close, volume float64
+}
+
+// barStore is a db.Querier that keeps saved bars in memory and merges a re-saved bar like
+// upsert_market_price_history() does.
+type barStore struct {
+	db.Querier
+	bars             map[string]map[time.Time]*storedBar // By market ID and resolution, then start time
+	fail             bool                                // Fail every save
+	reject           string                              // Fail the saves of this market's bars
+	rejectResolution string                              // Fail the saves of this resolution's bars
+	batches          int                                 // Number of batched saves
+	saved            []time.Time                         // Start times of the saved bars, in save order
+	onSave           func()                              // Called by every save
+}
+
+func newBarStore() *barStore {
+	return &barStore{bars: make(map[string]map[time.Time]*storedBar)}
+}
+
+func (s *barStore) UpsertMarketPriceHistory(_ context.Context, arg db.UpsertMarketPriceHistoryParams) error {
+	if s.onSave != nil {
+		s.onSave()
+	}
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	if arg.PMarketID == s.reject || arg.PResolution == s.rejectResolution {
+		return errors.New("value out of range")
+	}
+	s.saved = append(s.saved, arg.PTime.Time)
+	key := checkpointField(arg.PMarketID, arg.PResolution)
+	if s.bars[key] == nil {
+		s.bars[key] = make(map[time.Time]*storedBar)
+	}
+	open, _ := numericFloat(arg.POpen)
+	high, _ := numericFloat(arg.PHigh)
+	low, _ := numericFloat(arg.PLow)
+	closePrice, _ := numericFloat(arg.PClose)
+	volume, _ := numericFloat(arg.PVolume)
+	stored, ok := s.bars[key][arg.PTime.Time]
+	if !ok {
+		s.bars[key][arg.PTime.Time] = &storedBar{open, high, low, closePrice, volume}
+		return nil
+	}
+	stored.high = max(stored.high, high)
+	stored.low = min(stored.low, low)
+	stored.close = closePrice
+	stored.volume += volume
+	return nil
+}
+
+func (s *barStore) UpsertMarketPriceHistoryBars(ctx context.Context, arg []db.UpsertMarketPriceHistoryBarsParams) *db.UpsertMarketPriceHistoryBarsBatchResults {
+	s.batches++
+	return db.New(batchConn{store: s}).UpsertMarketPriceHistoryBars(ctx, arg)
+}
+
+// batchConn runs the batched saves of a barStore in an implicit transaction, like Postgres:
+// once a save fails, the later ones are aborted, and none are kept.
+type batchConn struct {
+	db.DBTX
+	store *barStore
+}
+
+func (c batchConn) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	results := &batchResults{}
+	var saves []db.UpsertMarketPriceHistoryParams
+	failed := false
+	for _, query := range batch.QueuedQueries {
+		args := query.Arguments
+		arg := db.UpsertMarketPriceHistoryParams{
+			PTime:       args[0].(pgtype.Timestamptz),
+			PMarketID:   args[1].(string),
+			POpen:       args[2].(pgtype.Numeric),
+			PHigh:       args[3].(pgtype.Numeric),
+			PLow:        args[4].(pgtype.Numeric),
+			PClose:      args[5].(pgtype.Numeric),
+			PVolume:     args[6].(pgtype.Numeric),
+			PResolution: args[7].(string),
+		}
+		var err error
+		switch {
+		case failed:
+			err = errors.New("current transaction is aborted")
+		case c.store.fail || arg.PMarketID == c.store.reject || arg.PResolution == c.store.rejectResolution:
+			err = errors.New("value out of range")
+			failed = true
+		}
+		results.errs = append(results.errs, err)
+		saves = append(saves, arg)
+	}
+	if !failed {
+		for _, arg := range saves {
+			_ = c.store.UpsertMarketPriceHistory(ctx, arg)
+		}
+	}
+	return results
+}
+
+// batchResults returns the outcome of each batched save in turn.
+type batchResults struct {
+	pgx.BatchResults
+	errs []error
+}
+
+func (r *batchResults) Exec() (pgconn.CommandTag, error) {
+	err := r.errs[0]
+	r.errs = r.errs[1:]
+	return pgconn.CommandTag{}, err
+}
+
+func (r *batchResults) Close() error {
+	return nil
+}
+
+func (s *barStore) GetMarketPriceHistory(_ context.Context, arg db.GetMarketPriceHistoryParams) ([]db.MarketPriceHistory, error) {
+	var rows []db.MarketPriceHistory
+	for start, bar := range s.bars[checkpointField(arg.MarketID, arg.Resolution)] {
+		if start.Before(arg.Time.Time) || start.After(arg.Time_2.Time) {
+			continue
+		}
+		row := db.MarketPriceHistory{Time: pgtype.Timestamptz{Time: start, Valid: true}, MarketID: arg.MarketID, Resolution: arg.Resolution}
+		row.Open, _ = floatToNumeric(bar.open)
+		row.High, _ = floatToNumeric(bar.high)
+		row.Low, _ = floatToNumeric(bar.low)
+		row.Close, _ = floatToNumeric(bar.close)
+		row.Volume, _ = floatToNumeric(bar.volume)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *barStore) ListMarketIDsWithBarsSince(_ context.Context, arg db.ListMarketIDsWithBarsSinceParams) ([]string, error) {
+	var marketIDs []string
+	for key, bars := range s.bars {
+		for start := range bars {
+			separator := strings.LastIndex(key, ":")
+			if key[separator+1:] == arg.Resolution && !start.Before(arg.Since.Time) {
+				marketIDs = append(marketIDs, key[:separator])
+				break
+			}
+		}
+	}
+	return marketIDs, nil
+}
+
+// newMonthlyAggregator creates an aggregator of monthly bars only, so that a test's updates
+// stay in one bar, saving to store. The current bars already in store are recovered.
+func newMonthlyAggregator(t *testing.T, store db.Querier) *OHLCVAggregator {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	agg := NewOHLCVAggregator(context.Background(), logger, store, 0, MidPriceFilter{}, PersistLagPolicy{}, GapFillPolicy{}, nil, false)
+	agg.resolutions = []ResolutionDef{resolutionDef("M")}
+	agg.RecoverBars(context.Background())
+	return agg
+}
+
+// storedVolume returns the volume of a market's stored monthly bar starting at start.
+func storedVolume(t *testing.T, store *barStore, marketID string, start time.Time) float64 {
+	t.Helper()
+	bar, ok := store.bars[checkpointField(marketID, "M")][start]
+	if !ok {
+		t.Fatalf("no stored bar for %s at %s", marketID, start)
+	}
+	return bar.volume
+}
+
+func TestSavedVolumeIsNotCountedTwice(t *testing.T) {
+	const marketID = "0xmarket"
+	store := newBarStore()
+	agg := newMonthlyAggregator(t, store)
+	now := time.Now().UTC()
+	start := barStartTime(now, "M")
+
+	trade := func(price, size float64) {
+		t.Helper()
+		if err := agg.UpdateTrade(marketID, price, size, now); err != nil {
+			t.Fatalf("update trade: %v", err)
+		}
+	}
+	flush := func() FlushResult {
+		t.Helper()
+		return agg.FlushMarket(marketID)
+	}
+
+	trade(0.5, 1.5)
+	trade(0.6, 2.25)
+	if result := flush(); result.BarsWritten != 1 {
+		t.Fatalf("flush = %+v", result)
+	}
+	if got := storedVolume(t, store, marketID, start); got != 3.75 {
+		t.Fatalf("stored volume after the first save = %v, want 3.75", got)
+	}
+
+	// Saving again without new trades adds nothing.
+	flush()
+	if got := storedVolume(t, store, marketID, start); got != 3.75 {
+		t.Fatalf("stored volume after an unchanged save = %v, want 3.75", got)
+	}
+
+	// A failed save leaves its volume to the next save.
+	trade(0.4, 4)
+	store.fail = true
+	if result := flush(); result.BarsFailed != 1 {
+		t.Fatalf("failing flush = %+v", result)
+	}
+	store.fail = false
+	trade(0.45, 0.25)
+	flush()
+	if got := storedVolume(t, store, marketID, start); got != 8 {
+		t.Fatalf("stored volume after a failed save = %v, want 8", got)
+	}
+
+	stored := store.bars[checkpointField(marketID, "M")][start]
+	if *stored != (storedBar{open: 0.5, high: 0.6, low: 0.4, close: 0.45, volume: 8}) {
+		t.Errorf("stored bar = %+v", *stored)
+	}
+
+	// A restarted aggregator recovers the bar with its volume saved, and adds only new trades.
+	agg = newMonthlyAggregator(t, store)
+	if bar := agg.bars[marketID]["M"]; bar == nil || bar.Volume != 8 || bar.SavedVolume != 8 {
+		t.Fatalf("recovered bar = %+v, want volume 8, all saved", bar)
+	}
+	trade(0.5, 2)
+	flush()
+	if got := storedVolume(t, store, marketID, start); got != 10 {
+		t.Errorf("stored volume after a restart = %v, want 10", got)
+	}
+}
+
+func TestReloadSavedVolume(t *testing.T) {
+	const marketID = "0xmarket"
+	store := newBarStore()
+	agg := newMonthlyAggregator(t, store)
+	start := barStartTime(time.Now().UTC(), "M")
+
+	tests := []struct {
+		name                        string
+		stored                      *storedBar
+		volume, savedVolume         float64
+		wantVolume, wantSavedVolume float64
+	}{
+		// The bar was saved after its checkpoint, with trades the checkpoint missed.
+		{"saved after the checkpoint", &storedBar{volume: 10}, 8, 5, 10, 10},
+		// The bar was not saved since its checkpoint.
+		{"checkpointed after the save", &storedBar{volume: 5}, 8, 5, 8, 5},
+		{"never saved", nil, 8, 5, 8, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.bars = make(map[string]map[time.Time]*storedBar)
+			if tt.stored != nil {
+				store.bars[checkpointField(marketID, "M")] = map[time.Time]*storedBar{start: tt.stored}
+			}
+			bar := &CurrentBar{MarketID: marketID, Resolution: "M", StartTime: start, Volume: tt.volume, SavedVolume: tt.savedVolume}
+			if err := agg.reloadSavedVolume(context.Background(), bar); err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			if bar.Volume != tt.wantVolume || bar.SavedVolume != tt.wantSavedVolume {
+				t.Errorf("volume, saved volume = %v, %v, want %v, %v", bar.Volume, bar.SavedVolume, tt.wantVolume, tt.wantSavedVolume)
+			}
+		})
+	}
+}
+
+func TestFlushCompletedBarsSavesOnce(t *testing.T) {
+	const marketID = "0xmarket"
+	store := newBarStore()
+	agg := newMonthlyAggregator(t, store)
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	if err := agg.UpdateTrade(marketID, 0.5, 3, start.Add(time.Hour)); err != nil {
+		t.Fatalf("update trade: %v", err)
+	}
+
+	// The flush takes the completed bar out of memory before saving it, so a late update
+	// starts a new bar instead of closing and saving the completed one again.
+	agg.clock = func() time.Time { return start.AddDate(0, 1, 0) }
+	agg.flushCompletedBars()
+	if _, ok := agg.bars[marketID]; ok {
+		t.Errorf("completed bar still in memory")
+	}
+	if got := storedVolume(t, store, marketID, start); got != 3 {
+		t.Errorf("stored volume = %v, want 3", got)
+	}
+}
+
+func TestFlushCompletedBarsLogsBadBars(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		reject  bool    // The database rejects the bad bar
+		volume  float64 // The bad bar's volume
+		batches int     // Batched saves tried
+		saved   bool    // The bad bar is not bad after all
+	}{
+		{"all saved in the batch", false, 1, 1, true},
+		{"rejected by the database", true, 1, 1, false},
+		{"not convertible", false, math.Inf(1), 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newBarStore()
+			if tt.reject {
+				store.reject = "0xbad"
+			}
+			agg := newMonthlyAggregator(t, store)
+			var logs bytes.Buffer
+			agg.logger = slog.New(slog.NewTextHandler(&logs, nil))
+			for marketID, volume := range map[string]float64{"0xfirst": 2, "0xbad": tt.volume, "0xlast": 3} {
+				if err := agg.UpdateTrade(marketID, 0.5, volume, start.Add(time.Hour)); err != nil {
+					t.Fatalf("update trade: %v", err)
+				}
+			}
+
+			agg.clock = func() time.Time { return start.AddDate(0, 1, 0) }
+			agg.flushCompletedBars()
+			if store.batches != tt.batches {
+				t.Errorf("batched saves = %d, want %d", store.batches, tt.batches)
+			}
+			for marketID, want := range map[string]float64{"0xfirst": 2, "0xlast": 3} {
+				if got := storedVolume(t, store, marketID, start); got != want {
+					t.Errorf("%s: stored volume = %v, want %v", marketID, got, want)
+				}
+			}
+			failures := strings.Count(logs.String(), `msg="failed to flush completed bar"`)
+			if tt.saved {
+				if got := storedVolume(t, store, "0xbad", start); got != 1 || failures != 0 {
+					t.Errorf("stored volume = %v with %d failures logged, want 1 with none", got, failures)
+				}
+				return
+			}
+			// The other bars are still saved, and the bad bar is logged and dropped.
+			if _, ok := store.bars[checkpointField("0xbad", "M")]; ok {
+				t.Error("the bad bar was saved")
+			}
+			if failures != 1 || !strings.Contains(logs.String(), "market_id=0xbad") {
+				t.Errorf("logs do not report the bad bar once:\n%s", logs.String())
+			}
+			if len(agg.bars) != 0 {
+				t.Errorf("%d markets still in memory", len(agg.bars))
+			}
+		})
+	}
+}
+
+func TestEvictedBarsAreSavedWithoutTheLock(t *testing.T) {
+	store := newBarStore()
+	agg := newMonthlyAggregator(t, store)
+	agg.maxMarkets = 1
+	agg.SetIdleTimeout(time.Hour)
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	agg.clock = func() time.Time { return start.Add(time.Hour) }
+	store.onSave = func() {
+		if !agg.mu.TryLock() {
+			t.Error("bar saved while holding the aggregator's lock")
+			return
+		}
+		agg.mu.Unlock()
+	}
+
+	// Making room for a market evicts the least recently updated one.
+	if err := agg.UpdateTrade("0xfirst", 0.5, 2, start.Add(time.Hour)); err != nil {
+		t.Fatalf("update trade: %v", err)
+	}
+	if err := agg.UpdateTrade("0xsecond", 0.5, 3, start.Add(time.Hour)); err != nil {
+		t.Fatalf("update trade: %v", err)
+	}
+	if got := storedVolume(t, store, "0xfirst", start); got != 2 {
+		t.Errorf("stored volume of the evicted market = %v, want 2", got)
+	}
+
+	agg.clock = func() time.Time { return start.Add(3 * time.Hour) }
+	agg.evictIdleMarkets()
+	if got := storedVolume(t, store, "0xsecond", start); got != 3 {
+		t.Errorf("stored volume of the idle market = %v, want 3", got)
+	}
+	if len(agg.bars) != 0 {
+		t.Errorf("markets in memory = %d, want 0", len(agg.bars))
+	}
+}
+
+// TestFlushCatchesUpAfterSuspension suspends the flush loop for ten minutes with a fake
+// clock, and checks the 1-minute bars saved for the stale bar and the missed periods.
+func TestFlushCatchesUpAfterSuspension(t *testing.T) {
+	const marketID = "0xmarket"
+	start := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	minute := func(n int) time.Time { return start.Add(time.Duration(n) * time.Minute) }
+
+	tests := []struct {
+		name      string
+		fillGaps  bool
+		wantSaved []time.Time
+	}{
+		{"carry-forward bars", true, []time.Time{minute(0), minute(1), minute(2), minute(3), minute(4), minute(5), minute(6), minute(7), minute(8), minute(9)}},
+		{"no gap filling", false, []time.Time{minute(0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newBarStore()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			agg := NewOHLCVAggregator(context.Background(), logger, store, 0, MidPriceFilter{}, PersistLagPolicy{}, GapFillPolicy{FillGaps: tt.fillGaps}, nil, false)
+			agg.resolutions = []ResolutionDef{resolutionDef("1")}
+			now := start.Add(20 * time.Second)
+			agg.clock = func() time.Time { return now }
+
+			for _, trade := range []struct{ price, size float64 }{{0.5, 2}, {0.55, 1}} {
+				if err := agg.UpdateTrade(marketID, trade.price, trade.size, now); err != nil {
+					t.Fatalf("update trade: %v", err)
+				}
+			}
+			lastTick := now
+			now = now.Add(flushInterval)
+			lastTick = agg.flushTick(lastTick)
+			if len(store.saved) != 0 || agg.Stats().CatchUps != 0 {
+				t.Fatalf("on-time tick saved %v, caught up %d times", store.saved, agg.Stats().CatchUps)
+			}
+
+			// The next tick arrives ten minutes late.
+			now = now.Add(10 * time.Minute)
+			agg.flushTick(lastTick)
+			if agg.Stats().CatchUps != 1 {
+				t.Errorf("catch-ups = %d, want 1", agg.Stats().CatchUps)
+			}
+			if len(store.saved) != len(tt.wantSaved) {
+				t.Fatalf("saved bars starting at %v, want %v", store.saved, tt.wantSaved)
+			}
+			for i, want := range tt.wantSaved {
+				if !store.saved[i].Equal(want) {
+					t.Errorf("saved bar %d starts at %s, want %s", i, store.saved[i], want)
+				}
+			}
+			bars := store.bars[checkpointField(marketID, "1")]
+			if stale := bars[minute(0)]; *stale != (storedBar{open: 0.5, high: 0.55, low: 0.5, close: 0.55, volume: 3}) {
+				t.Errorf("stale bar = %+v", *stale)
+			}
+			for _, missed := range tt.wantSaved[1:] {
+				if bar := bars[missed]; *bar != (storedBar{open: 0.55, high: 0.55, low: 0.55, close: 0.55}) {
+					t.Errorf("carry-forward bar at %s = %+v", missed, *bar)
+				}
+			}
+
+			// A fresh bar for the current period opens at the stale bar's close.
+			current := agg.bars[marketID]["1"]
+			if current == nil || !current.StartTime.Equal(minute(10)) || current.Open != 0.55 || current.Volume != 0 {
+				t.Errorf("current bar = %+v, want a bar at %s opening at 0.55", current, minute(10))
+			}
+		})
+	}
+}
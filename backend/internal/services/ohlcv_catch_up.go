@@ -0,0 +1,84 @@
+/**
+ * @description
+ * This file implements the periodic flush's catch-up after the process was suspended. When
+ * the container is paused or starved of CPU, the flush ticker misses many intervals, and on
+ * resume the bars in memory may be several periods old. Flushing them as usual saves each
+ * under its original start time and leaves nothing for the periods that elapsed meanwhile.
+ *
+ * Key features:
+ * - Resume Detection: A flush tick arriving more than twice the flush interval after the
+ *   previous one means the process was suspended.
+ * - Catch-up: Every bar older than the current period is closed (saved), the fully missed
+ *   periods in between are saved as flat carry-forward bars when gap filling is enabled
+ *   (`OHLCV_FILL_GAPS`, bounded by its maximum), and a fresh bar for the current period is
+ *   started at the stale bar's close.
+ * - Summary: Each catch-up is logged with the suspension length and the bars it wrote.
+ *
+ * @notes
+ * - Missed periods are filled by the same code as gaps between updates (see ohlcv_gap_fill.go),
+ *   so they are counted in `gap_bars_filled` and `gaps_skipped`.
+ */
+
+package services
+
+import "time"
+
+const (
+	// flushInterval is the period of the flush loop (see RunPeriodicFlush).
+	flushInterval = 15 * time.Second
+	// suspendedFlushGap is the gap between flush ticks above which the process is considered
+	// to have been suspended.
+	suspendedFlushGap = 2 * flushInterval
+)
+
+/**
+ * @description
+ * catchUpSuspendedBars closes the bars left behind by a suspension and starts fresh bars for
+ * the current period. Bars of the current period are left unchanged.
+ *
+ * @param now The time of the flush tick that detected the suspension.
+ * @param suspended The time elapsed since the previous flush tick.
+ */
+func (a *OHLCVAggregator) catchUpSuspendedBars(now time.Time, suspended time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	filledBefore, skippedBefore := a.gapBarsFilled, a.gapsSkipped
+	closed, failed := 0, 0
+	for marketID, resolutions := range a.bars {
+		for resolution, bar := range resolutions {
+			currentStart := a.getBarStartTime(now, resolution)
+			if !bar.StartTime.Before(currentStart) {
+				continue
+			}
+
+			if err := a.closeBar(bar); err != nil {
+				a.logger.Error("failed to close stale bar after suspension", "error", err, "market_id", marketID, "resolution", resolution, "start_time", bar.StartTime)
+				failed++
+			} else {
+				closed++
+			}
+			a.fillGap(marketID, resolution, currentStart, bar)
+
+			resolutions[resolution] = &CurrentBar{
+				MarketID:   marketID,
+				Resolution: resolution,
+				StartTime:  currentStart,
+				Open:       bar.Close,
+				High:       bar.Close,
+				Low:        bar.Close,
+				Close:      bar.Close,
+			}
+		}
+	}
+	a.suspensionCatchUps++
+
+	a.logger.Warn("OHLCV flush resumed after suspension, caught up stale bars",
+		"suspended", suspended,
+		"bars_closed", closed,
+		"bars_failed", failed,
+		"bars_started", closed+failed,
+		"carry_forward_bars", a.gapBarsFilled-filledBefore,
+		"gaps_skipped", a.gapsSkipped-skippedBefore,
+		"fill_gaps", a.gapFill.FillGaps)
+}
@@ -0,0 +1,179 @@
+/**
+ * @description
+ * This file implements the checkpointing of the OHLCV aggregator's in-progress bars to Redis
+ * (see bar_checkpoints.go), and their restoration at startup, so that restarting the backend
+ * in the middle of a period continues the current bars instead of resetting them.
+ *
+ * Key features:
+ * - Throttled Checkpoints: `RunCheckpoints` writes the bars that changed since the previous
+ *   checkpoint once per checkpoint interval, so a busy bar costs at most one write per
+ *   interval, and removes the bars that left memory.
+ * - Startup Restore: `restoreCheckpoints` puts the checkpointed bars of the current period
+ *   back in memory before `RecoverBars` runs. Checkpointed bars whose period has ended are
+ *   saved to the database instead, since their updates are over.
+ *
+ * @notes
+ * - A restore loses at most the updates of the last checkpoint interval. A checkpointed bar's
+ *   saved volume is reloaded from the database, as the bar may have been saved since (e.g. by
+ *   `FlushMarket`), so that the next save adds only the volume not yet stored.
+ * - Checkpointing is disabled when the aggregator has no checkpointer.
+ */
+
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// checkpointVersion identifies the state of a bar when it was last checkpointed.
+type checkpointVersion struct {
+	startTime time.Time
+	count     int64
+}
+
+// RunCheckpoints periodically checkpoints the in-progress bars that changed to Redis.
+// It runs until the context is cancelled and should be started as a goroutine; it returns
+// immediately when checkpointing is disabled.
+func (a *OHLCVAggregator) RunCheckpoints() {
+	if a.checkpoints == nil {
+		return
+	}
+	ticker := time.NewTicker(a.checkpoints.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkpointBars()
+		}
+	}
+}
+
+// checkpointBars writes the bars that changed since the previous checkpoint and removes the
+// bars no longer in memory. It must only be called from the checkpoint loop, which owns
+// a.checkpointed.
+func (a *OHLCVAggregator) checkpointBars() {
+	var changed []CurrentBar
+	inMemory := make(map[string]bool)
+
+	a.mu.RLock()
+	for marketID, resolutions := range a.bars {
+		for resolution, bar := range resolutions {
+			field := checkpointField(marketID, resolution)
+			inMemory[field] = true
+			version := checkpointVersion{startTime: bar.StartTime, count: bar.Count}
+			if a.checkpointed[field] != version {
+				changed = append(changed, *bar)
+			}
+		}
+	}
+	a.mu.RUnlock()
+
+	var removed []string
+	for field := range a.checkpointed {
+		if !inMemory[field] {
+			removed = append(removed, field)
+		}
+	}
+
+	if err := a.checkpoints.Save(a.ctx, changed, removed); err != nil {
+		// The same bars are retried on the next tick, as a.checkpointed is unchanged.
+		a.logger.Warn("failed to checkpoint OHLCV bars", "error", err, "bars", len(changed), "removed", len(removed))
+		return
+	}
+	for _, bar := range changed {
+		a.checkpointed[checkpointField(bar.MarketID, bar.Resolution)] = checkpointVersion{startTime: bar.StartTime, count: bar.Count}
+	}
+	for _, field := range removed {
+		delete(a.checkpointed, field)
+	}
+}
+
+/**
+ * @description
+ * restoreCheckpoints puts the checkpointed bars of the current period back in memory, and
+ * saves the checkpointed bars whose period has ended to the database. Bars of markets beyond
+ * the in-memory cap are left out. Failures are logged and leave the affected bars unrestored.
+ *
+ * @param ctx The context for the Redis call.
+ * @returns The number of bars restored.
+ */
+func (a *OHLCVAggregator) restoreCheckpoints(ctx context.Context) int {
+	if a.checkpoints == nil {
+		return 0
+	}
+	bars, err := a.checkpoints.Load(ctx)
+	if err != nil {
+		a.logger.Warn("failed to load OHLCV bar checkpoints", "error", err)
+		return 0
+	}
+
+	now := time.Now().UTC()
+	restored, saved := 0, 0
+	for _, bar := range bars {
+		field := checkpointField(bar.MarketID, bar.Resolution)
+		// Bars not restored are recorded with a version no bar has, so that the first
+		// checkpoint removes them, unless their market has a bar of that resolution by then.
+		stale := checkpointVersion{}
+		if _, ok := ResolutionDuration(bar.Resolution); !ok {
+			a.checkpointed[field] = stale
+			continue
+		}
+		if err := a.reloadSavedVolume(ctx, bar); err != nil {
+			a.logger.Warn("failed to load the saved volume of a checkpointed bar, keeping its checkpointed one", "error", err, "market_id", bar.MarketID, "resolution", bar.Resolution)
+		}
+		if !barEndTime(bar.StartTime, bar.Resolution).After(now) {
+			a.mu.Lock()
+			err := a.saveBar(ctx, bar)
+			a.mu.Unlock()
+			if err != nil {
+				// Kept in Redis, so that the next startup tries again.
+				a.logger.Warn("failed to save checkpointed bar of an ended period", "error", err, "market_id", bar.MarketID, "resolution", bar.Resolution)
+				continue
+			}
+			a.checkpointed[field] = stale
+			saved++
+			continue
+		}
+		if !a.restoreBar(bar) {
+			a.checkpointed[field] = stale
+			continue
+		}
+		a.checkpointed[field] = checkpointVersion{startTime: bar.StartTime, count: bar.Count}
+		restored++
+	}
+
+	if restored > 0 || saved > 0 {
+		a.logger.Info("restored OHLCV bars from Redis checkpoints", "bars_restored", restored, "ended_bars_saved", saved)
+	}
+	return restored
+}
+
+// reloadSavedVolume sets a checkpointed bar's saved volume to the volume of its stored bar, or
+// to 0 if it has none. Both volumes count the bar's updates from its start, so the bar keeps
+// the larger one.
+func (a *OHLCVAggregator) reloadSavedVolume(ctx context.Context, bar *CurrentBar) error {
+	start := pgtype.Timestamptz{Time: bar.StartTime, Valid: true}
+	rows, err := a.store.GetMarketPriceHistory(ctx, db.GetMarketPriceHistoryParams{
+		MarketID:   bar.MarketID,
+		Time:       start,
+		Time_2:     start,
+		Resolution: bar.Resolution,
+	})
+	if err != nil {
+		return err
+	}
+	bar.SavedVolume = 0
+	if len(rows) > 0 {
+		bar.SavedVolume, _ = numericFloat(rows[len(rows)-1].Volume)
+	}
+	bar.Volume = math.Max(bar.Volume, bar.SavedVolume)
+	return nil
+}
@@ -0,0 +1,140 @@
+/**
+ * @description
+ * This file implements gap filling in the OHLCV aggregator. Bars are only created by price
+ * updates, so a market without updates for a whole period has no bar for it, and its chart
+ * shows a hole. With gap filling enabled, the periods missing between a market's previous
+ * bar and a new one are saved as flat bars at the previous bar's close.
+ *
+ * Key features:
+ * - Flat Bars: A filled bar has the previous close as open, high, low, and close, and no
+ *   volume. Its periods follow the resolution's bar boundaries (see getBarStartTime).
+ * - Bounded: Gaps of up to `MaxPeriods` periods are filled. Longer gaps (e.g. a stream
+ *   outage) are left as they are, rather than drawn as a flat market.
+ * - Closed Bars: The close of each market's last completed bar is remembered after the bar
+ *   leaves memory, so that a quiet market's gap can be filled when it trades again.
+ *
+ * @notes
+ * - Gap filling is off by default (`OHLCV_FILL_GAPS`).
+ * - Closed bars are only kept in memory, so gaps spanning a restart are not filled.
+ * - Filled bars are not observed for the persistence latency, as they are saved long after
+ *   their period ended by design.
+ */
+
+package services
+
+import "time"
+
+// defaultMaxGapFillPeriods is the longest gap filled when no limit is configured.
+const defaultMaxGapFillPeriods = 60
+
+// GapFillPolicy configures gap filling. Gaps are only filled when FillGaps is set.
+type GapFillPolicy struct {
+	FillGaps   bool `json:"fill_gaps"`   // Save flat bars for the periods missing between two bars
+	MaxPeriods int  `json:"max_periods"` // Longest gap filled, in periods; zero uses the default
+}
+
+// closedBar is the last completed bar of a market and resolution that left memory.
+type closedBar struct {
+	startTime  time.Time
+	closePrice float64
+}
+
+// rememberClosedBar records a completed bar leaving memory as the start of a possible gap.
+// Must be called with mu held.
+func (a *OHLCVAggregator) rememberClosedBar(bar *CurrentBar) {
+	if !a.gapFill.FillGaps {
+		return
+	}
+	if a.closedBars[bar.MarketID] == nil {
+		a.closedBars[bar.MarketID] = make(map[string]closedBar)
+	}
+	a.closedBars[bar.MarketID][bar.Resolution] = closedBar{startTime: bar.StartTime, closePrice: bar.Close}
+}
+
+/**
+ * @description
+ * fillGap saves flat bars for the periods between a market's previous bar and a new bar
+ * starting at startTime. The previous bar is the bar being replaced, or else the market's
+ * last closed bar. Must be called with mu held.
+ *
+ * @param marketID The market's condition ID.
+ * @param resolution The resolution of the bars.
+ * @param startTime The start of the new bar.
+ * @param previous The bar being replaced by the new bar; nil if there is none in memory.
+ */
+func (a *OHLCVAggregator) fillGap(marketID, resolution string, startTime time.Time, previous *CurrentBar) {
+	if !a.gapFill.FillGaps {
+		return
+	}
+	last, ok := a.closedBars[marketID][resolution]
+	delete(a.closedBars[marketID], resolution)
+	if len(a.closedBars[marketID]) == 0 {
+		delete(a.closedBars, marketID)
+	}
+	if previous != nil {
+		last, ok = closedBar{startTime: previous.StartTime, closePrice: previous.Close}, true
+	}
+	if !ok {
+		return
+	}
+
+	// Periods are counted one by one, as monthly bars vary in length
+	missing := 0
+	for periodStart := a.getBarEndTime(last.startTime, resolution); periodStart.Before(startTime) && missing <= a.maxGapFillPeriods(); periodStart = a.getBarEndTime(periodStart, resolution) {
+		missing++
+	}
+	if missing <= 0 {
+		return
+	}
+	if missing > a.maxGapFillPeriods() {
+		a.gapsSkipped++
+		a.logger.Debug("OHLCV gap too long to fill",
+			"market_id", marketID,
+			"resolution", resolution,
+			"missing_periods", missing,
+			"max_periods", a.maxGapFillPeriods())
+		return
+	}
+
+	for periodStart := a.getBarEndTime(last.startTime, resolution); periodStart.Before(startTime); periodStart = a.getBarEndTime(periodStart, resolution) {
+		bar := &CurrentBar{
+			MarketID:   marketID,
+			Resolution: resolution,
+			StartTime:  periodStart,
+			Open:       last.closePrice,
+			High:       last.closePrice,
+			Low:        last.closePrice,
+			Close:      last.closePrice,
+			Filled:     true,
+		}
+		if err := a.closeBar(bar); err != nil {
+			a.logger.Error("failed to save gap-filling bar", "error", err, "market_id", marketID, "resolution", resolution, "start_time", periodStart)
+			return
+		}
+		a.gapBarsFilled++
+	}
+}
+
+// pruneClosedBars forgets the closed bars whose gap to now is already too long to fill.
+// Must be called with mu held.
+func (a *OHLCVAggregator) pruneClosedBars(now time.Time) {
+	maxPeriods := time.Duration(a.maxGapFillPeriods())
+	for marketID, resolutions := range a.closedBars {
+		for resolution, last := range resolutions {
+			if now.Sub(a.getBarEndTime(last.startTime, resolution)) > (maxPeriods+1)*resolutionInterval(resolution) {
+				delete(resolutions, resolution)
+			}
+		}
+		if len(resolutions) == 0 {
+			delete(a.closedBars, marketID)
+		}
+	}
+}
+
+// maxGapFillPeriods returns the longest gap filled, in periods.
+func (a *OHLCVAggregator) maxGapFillPeriods() int {
+	if a.gapFill.MaxPeriods > 0 {
+		return a.gapFill.MaxPeriods
+	}
+	return defaultMaxGapFillPeriods
+}
@@ -0,0 +1,114 @@
+/**
+ * @description
+ * This file implements the per-resolution save counters of the OHLCV aggregator. The
+ * aggregate totals cannot show a single resolution failing (e.g. only daily bars), so the
+ * bars saved and failed are also counted for each resolution.
+ *
+ * Key features:
+ * - Daily Counters: Bars saved and failed since the last UTC midnight, reset lazily on the
+ *   first save or read after midnight, as told by the aggregator's clock.
+ * - Last Save: The time of each resolution's latest successful save.
+ *
+ * @notes
+ * - Counters are updated atomically, as bars are saved both with and without the
+ *   aggregator's lock held.
+ */
+
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResolutionSaveStats reports the bars saved for one resolution.
+type ResolutionSaveStats struct {
+	SavedToday  int64      `json:"saved_today"`  // Bars saved since the last UTC midnight
+	FailedToday int64      `json:"failed_today"` // Bars failing to save since the last UTC midnight
+	LastSaveAt  *time.Time `json:"last_save_at"` // Latest successful save; null if none
+}
+
+// resolutionSaveCounters counts one resolution's saved and failed bars.
+type resolutionSaveCounters struct {
+	day         atomic.Int64 // UTC day, in days since the Unix epoch, the daily counters belong to
+	savedToday  atomic.Int64
+	failedToday atomic.Int64
+	lastSaveAt  atomic.Int64 // Unix nanoseconds; 0 if no bar was saved
+}
+
+// saveCounters holds the save counters of each resolution.
+type saveCounters struct {
+	mu           sync.Mutex
+	byResolution map[string]*resolutionSaveCounters
+}
+
+func newSaveCounters() *saveCounters {
+	return &saveCounters{byResolution: make(map[string]*resolutionSaveCounters)}
+}
+
+// utcDay returns the number of whole UTC days between the Unix epoch and t.
+func utcDay(t time.Time) int64 {
+	return BucketStart(t, 24*time.Hour).Unix() / int64(24*time.Hour/time.Second)
+}
+
+// counters returns the counters of a resolution, creating them if needed.
+func (c *saveCounters) counters(resolution string) *resolutionSaveCounters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counters, ok := c.byResolution[resolution]
+	if !ok {
+		counters = &resolutionSaveCounters{}
+		c.byResolution[resolution] = counters
+	}
+	return counters
+}
+
+// record counts a bar save of a resolution at now.
+func (c *saveCounters) record(resolution string, saved bool, now time.Time) {
+	counters := c.counters(resolution)
+	counters.rollover(utcDay(now))
+	if saved {
+		counters.savedToday.Add(1)
+		counters.lastSaveAt.Store(now.UnixNano())
+	} else {
+		counters.failedToday.Add(1)
+	}
+}
+
+// snapshot returns the counters of every resolution with a recorded save at now.
+func (c *saveCounters) snapshot(now time.Time) map[string]ResolutionSaveStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	day := utcDay(now)
+	stats := make(map[string]ResolutionSaveStats, len(c.byResolution))
+	for resolution, counters := range c.byResolution {
+		counters.rollover(day)
+		resolutionStats := ResolutionSaveStats{
+			SavedToday:  counters.savedToday.Load(),
+			FailedToday: counters.failedToday.Load(),
+		}
+		if nanos := counters.lastSaveAt.Load(); nanos != 0 {
+			lastSaveAt := time.Unix(0, nanos).UTC()
+			resolutionStats.LastSaveAt = &lastSaveAt
+		}
+		stats[resolution] = resolutionStats
+	}
+	return stats
+}
+
+// rollover resets the daily counters when day is past the day they belong to.
+func (c *resolutionSaveCounters) rollover(day int64) {
+	for {
+		current := c.day.Load()
+		if current >= day {
+			return
+		}
+		if c.day.CompareAndSwap(current, day) {
+			c.savedToday.Store(0)
+			c.failedToday.Store(0)
+			return
+		}
+	}
+}
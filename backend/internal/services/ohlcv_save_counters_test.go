@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// TestSaveCountersByResolution saves minute bars while daily bars fail, and checks that the
+// counters tell the resolutions apart and reset at UTC midnight on the aggregator's clock.
+func TestSaveCountersByResolution(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := newBarStore()
+	store.rejectResolution = "D"
+	agg := NewOHLCVAggregator(context.Background(), logger, store, 0, MidPriceFilter{}, PersistLagPolicy{}, GapFillPolicy{}, nil, false)
+	agg.resolutions = []ResolutionDef{resolutionDef("1"), resolutionDef("D")}
+	now := time.Date(2026, 9, 1, 23, 58, 10, 0, time.UTC)
+	agg.clock = func() time.Time { return now }
+
+	type counts struct {
+		saved, failed int64
+		lastSave      time.Time // Zero if none
+	}
+	check := func(when string, want map[string]counts) {
+		t.Helper()
+		stats := agg.Stats().SavesByResolution
+		for resolution, want := range want {
+			got, ok := stats[resolution]
+			if !ok {
+				t.Errorf("%s: no counters for resolution %s", when, resolution)
+				continue
+			}
+			var lastSave time.Time
+			if got.LastSaveAt != nil {
+				lastSave = *got.LastSaveAt
+			}
+			if got.SavedToday != want.saved || got.FailedToday != want.failed || !lastSave.Equal(want.lastSave) {
+				t.Errorf("%s: resolution %s saved %d, failed %d, last save %v; want %d, %d, %v",
+					when, resolution, got.SavedToday, got.FailedToday, lastSave, want.saved, want.failed, want.lastSave)
+			}
+		}
+	}
+	trade := func(marketID string) {
+		t.Helper()
+		if err := agg.UpdateTrade(marketID, 0.5, 1, now); err != nil {
+			t.Fatalf("update trade: %v", err)
+		}
+	}
+
+	if stats := agg.Stats().SavesByResolution; len(stats) != 0 {
+		t.Fatalf("counters before any save: %+v", stats)
+	}
+
+	// The two completed minute bars are saved in one batch; the daily bars are in progress.
+	trade("0xa")
+	trade("0xb")
+	now = time.Date(2026, 9, 1, 23, 59, 5, 0, time.UTC)
+	agg.flushCompletedBars()
+	if store.batches != 1 {
+		t.Errorf("%d batched saves, want 1", store.batches)
+	}
+	firstSave := now
+	check("before midnight", map[string]counts{"1": {2, 0, firstSave}})
+
+	trade("0xa")
+	now = time.Date(2026, 9, 1, 23, 59, 59, 999_000_000, time.UTC)
+	check("a millisecond before midnight", map[string]counts{"1": {2, 0, firstSave}})
+
+	// Just after midnight, the last minute bar is saved but both daily bars fail. The
+	// counters only show saves from the new day.
+	now = time.Date(2026, 9, 2, 0, 0, 0, 500_000_000, time.UTC)
+	agg.flushCompletedBars()
+	check("after midnight", map[string]counts{
+		"1": {1, 0, now},
+		"D": {0, 2, time.Time{}},
+	})
+	midnightSave := now
+
+	// A day without saves reads as zero, while the last save times remain.
+	store.rejectResolution = ""
+	now = time.Date(2026, 9, 3, 0, 0, 1, 0, time.UTC)
+	check("the next day", map[string]counts{
+		"1": {0, 0, midnightSave},
+		"D": {0, 0, time.Time{}},
+	})
+}
+
+func TestResolutionSaveCountersRollover(t *testing.T) {
+	counters := newSaveCounters()
+	day := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	counters.record("60", true, day)
+	counters.record("60", false, day)
+
+	// A save timestamped on an earlier day, e.g. by a clock stepping back, counts towards
+	// the current day rather than resetting it.
+	counters.record("60", true, day.Add(-24*time.Hour))
+	if got := counters.snapshot(day)["60"]; got.SavedToday != 2 || got.FailedToday != 1 {
+		t.Errorf("after an earlier save: saved %d, failed %d; want 2, 1", got.SavedToday, got.FailedToday)
+	}
+
+	// Reading on a later day resets the counters.
+	if got := counters.snapshot(day.Add(24 * time.Hour))["60"]; got.SavedToday != 0 || got.FailedToday != 0 {
+		t.Errorf("the next day: saved %d, failed %d; want 0, 0", got.SavedToday, got.FailedToday)
+	}
+}
+
+func TestUTCDay(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		want int64
+	}{
+		{time.Unix(0, 0), 0},
+		{time.Date(1970, 1, 1, 23, 59, 59, 0, time.UTC), 0},
+		{time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC), -1},
+		// Days are UTC days, whatever the time's location.
+		{time.Date(1970, 1, 1, 20, 0, 0, 0, time.FixedZone("EST", -5*3600)), 1},
+	}
+	for _, tt := range tests {
+		if got := utcDay(tt.at); got != tt.want {
+			t.Errorf("utcDay(%s) = %d, want %d", tt.at, got, tt.want)
+		}
+	}
+}
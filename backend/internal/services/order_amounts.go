@@ -0,0 +1,68 @@
+/**
+ * @description
+ * This file converts order prices and sizes to the 6-decimal integer amounts of Polymarket
+ * orders, using exact fixed-point arithmetic (see the decimal package) instead of binary
+ * floating point, which produced off-by-one amounts (e.g. a size of 0.3 becoming 299999
+ * micro-units).
+ *
+ * Key features:
+ * - Amounts: For BUY orders the taker amount is the size and the maker amount (USDC paid)
+ *   is size × price; for SELL orders the maker amount is the size and the taker amount
+ *   (USDC received) is size × price. Products are rounded down to 6 decimals.
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/poly-pro/backend/internal/decimal"
+)
+
+// ErrInvalidOrderSize is returned when an order's size cannot be represented as a token amount.
+var ErrInvalidOrderSize = errors.New("invalid order size")
+
+/**
+ * @description
+ * orderDecimals converts an order's price and size to decimals. The price is rounded to
+ * the nearest micro-unit (tick sizes are coarser, so valid prices are exact) and the size
+ * is rounded down to whole micro-units.
+ *
+ * @param price The order price (0 to 1).
+ * @param size The order size in shares.
+ * @returns The price and size, or an error wrapping ErrInvalidOrderPrice or ErrInvalidOrderSize.
+ */
+func orderDecimals(price float64, size float64) (decimal.Decimal, decimal.Decimal, error) {
+	priceDecimal, err := decimal.FromFloat(price, decimal.RoundHalfUp)
+	if err != nil || priceDecimal.Sign() <= 0 {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("%w: price %v", ErrInvalidOrderPrice, price)
+	}
+	sizeDecimal, err := decimal.FromFloat(size, decimal.RoundDown)
+	if err != nil || sizeDecimal.Sign() <= 0 {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("%w: size %v must be at least 0.000001 and representable", ErrInvalidOrderSize, size)
+	}
+	return priceDecimal, sizeDecimal, nil
+}
+
+/**
+ * @description
+ * orderAmounts computes the maker and taker amounts of an order.
+ *
+ * @param side "BUY" or "SELL".
+ * @param price The order price.
+ * @param size The order size in shares.
+ * @returns The maker and taker amounts, or an error wrapping ErrInvalidOrderSize on overflow.
+ */
+func orderAmounts(side string, price decimal.Decimal, size decimal.Decimal) (makerAmount decimal.Decimal, takerAmount decimal.Decimal, err error) {
+	notional, err := size.Mul(price, decimal.RoundDown)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("%w: %v", ErrInvalidOrderSize, err)
+	}
+	if side == "BUY" {
+		// The maker pays size × price USDC for size shares.
+		return notional, size, nil
+	}
+	// The maker sells size shares for size × price USDC.
+	return size, notional, nil
+}
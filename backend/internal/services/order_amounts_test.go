@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/poly-pro/backend/internal/decimal"
+)
+
+// ratFloorMicros returns r rounded down to whole micro-units.
+func ratFloorMicros(r *big.Rat) int64 {
+	scaled := new(big.Rat).Mul(r, big.NewRat(1_000_000, 1))
+	return new(big.Int).Quo(scaled.Num(), scaled.Denom()).Int64()
+}
+
+// TestOrderAmountsMatchRat checks the amounts of orders at realistic prices and sizes,
+// given as the float64s clients send, against exact big.Rat arithmetic.
+func TestOrderAmountsMatchRat(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tickDecimals := []int{1, 2, 3, 4}
+	for i := 0; i < 5000; i++ {
+		places := tickDecimals[rng.Intn(len(tickDecimals))]
+		steps := int64(math.Pow10(places))
+		priceText := new(big.Rat).SetFrac64(1+rng.Int63n(steps-1), steps).FloatString(places)
+		sizeText := new(big.Rat).SetFrac64(1+rng.Int63n(100_000*100), 100).FloatString(rng.Intn(3))
+		price, _ := strconv.ParseFloat(priceText, 64)
+		size, _ := strconv.ParseFloat(sizeText, 64)
+
+		priceDecimal, sizeDecimal, err := orderDecimals(price, size)
+		if err != nil {
+			t.Fatalf("orderDecimals(%v, %v): %v", price, size, err)
+		}
+		exactPrice, _ := new(big.Rat).SetString(priceText)
+		exactSize, _ := new(big.Rat).SetString(sizeText)
+		if got, want := priceDecimal.Micros(), ratFloorMicros(exactPrice); got != want {
+			t.Fatalf("price %v = %d micros, want %d", price, got, want)
+		}
+		if got, want := sizeDecimal.Micros(), ratFloorMicros(exactSize); got != want {
+			t.Fatalf("size %v = %d micros, want %d", size, got, want)
+		}
+
+		notional := ratFloorMicros(new(big.Rat).Mul(exactPrice, exactSize))
+		for _, side := range []string{"BUY", "SELL"} {
+			maker, taker, err := orderAmounts(side, priceDecimal, sizeDecimal)
+			if err != nil {
+				t.Fatalf("orderAmounts(%s, %v, %v): %v", side, price, size, err)
+			}
+			wantMaker, wantTaker := notional, sizeDecimal.Micros()
+			if side == "SELL" {
+				wantMaker, wantTaker = wantTaker, wantMaker
+			}
+			if maker.Micros() != wantMaker || taker.Micros() != wantTaker {
+				t.Fatalf("%s %v @ %v = maker %d, taker %d, want %d and %d", side, size, price, maker.Micros(), taker.Micros(), wantMaker, wantTaker)
+			}
+		}
+	}
+}
+
+func TestOrderAmountsRounding(t *testing.T) {
+	tests := []struct {
+		name        string
+		price, size float64
+		wantMaker   int64 // BUY: USDC paid
+	}{
+		{"float size is exact", 0.5, 0.3, 150_000},
+		{"notional rounds down", 0.333, 0.000003, 0},
+		{"notional just below a micro", 0.9999, 0.000001, 0},
+		{"notional on a micro", 0.5, 0.000002, 1},
+		{"size beyond 6 decimals rounds down", 0.5, 1.0000019, 500_000},
+		{"price between ticks rounds to the nearest micro", 0.1234565, 1, 123_457},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, size, err := orderDecimals(tt.price, tt.size)
+			if err != nil {
+				t.Fatalf("orderDecimals: %v", err)
+			}
+			maker, _, err := orderAmounts("BUY", price, size)
+			if err != nil {
+				t.Fatalf("orderAmounts: %v", err)
+			}
+			if maker.Micros() != tt.wantMaker {
+				t.Errorf("maker amount = %d, want %d", maker.Micros(), tt.wantMaker)
+			}
+		})
+	}
+}
+
+func TestOrderAmountsInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		price, size float64
+		want        error
+	}{
+		{"zero price", 0, 10, ErrInvalidOrderPrice},
+		{"NaN price", math.NaN(), 10, ErrInvalidOrderPrice},
+		{"size below a micro-unit", 0.5, 0.0000009, ErrInvalidOrderSize},
+		{"negative size", 0.5, -1, ErrInvalidOrderSize},
+		{"size out of range", 0.5, 1e20, ErrInvalidOrderSize},
+		{"infinite size", 0.5, math.Inf(1), ErrInvalidOrderSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := orderDecimals(tt.price, tt.size); !errors.Is(err, tt.want) {
+				t.Errorf("orderDecimals(%v, %v) = %v, want %v", tt.price, tt.size, err, tt.want)
+			}
+		})
+	}
+
+	// A notional that overflows is reported as an invalid size.
+	size := decimal.FromMicros(math.MaxInt64)
+	price, _ := decimal.FromInt(2)
+	if _, _, err := orderAmounts("BUY", price, size); !errors.Is(err, ErrInvalidOrderSize) {
+		t.Errorf("overflowing notional: %v, want %v", err, ErrInvalidOrderSize)
+	}
+}
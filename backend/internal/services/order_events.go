@@ -0,0 +1,166 @@
+/**
+ * @description
+ * This file defines the `order_update` event published whenever an order's local
+ * status changes, so that the progression of an order (e.g. pending → delayed → filled)
+ * can be observed in real time, and the `OrderEventPublisher` through which every such
+ * event is published.
+ *
+ * Key features:
+ * - Per-User Channels: Events are published to the Redis channel `orders:<user_id>`
+ *   (built by `channels.OrdersChannel`), where user_id is the internal user UUID.
+ * - Payload: Events are `wire.OrderUpdate` payloads (see the wire package).
+ * - Transition Details: Each event carries both the previous and the new status.
+ * - Ordering: Each event carries the order's `event_seq`, which the database increments on
+ *   every status update, so it reflects the commit order of the transitions. Events for the
+ *   same order are published one at a time, and an event older than one already published
+ *   is dropped. Clients should also ignore events whose `event_seq` is not greater than the
+ *   last one they have seen for that order.
+ *
+ * @notes
+ * - Publishing is best-effort: failures are logged and never fail the status update itself.
+ *   Failed publishes are retried by the publish buffer, when one is set.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/poly-pro/backend/internal/channels"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/wire"
+	"github.com/redis/go-redis/v9"
+)
+
+// orderUpdateEventType is the value of the "type" field of order status events.
+const orderUpdateEventType = "order_update"
+
+// maxTrackedOrderEvents bounds the per-order publishing state kept in memory. When it is
+// exceeded, the state of orders with no publish in progress is dropped.
+const maxTrackedOrderEvents = 10000
+
+// orderEventState serializes publishing for a single order.
+type orderEventState struct {
+	mu      sync.Mutex
+	lastSeq int64 // event_seq of the last published event, guarded by mu
+	refs    int   // publishes in progress or waiting, guarded by OrderEventPublisher.mu
+}
+
+// OrderEventPublisher publishes order_update events in order for each order.
+type OrderEventPublisher struct {
+	redisClient *redis.Client
+	buffer      *PublishBuffer // Retries failed publishes; nil publishes directly
+	logger      *slog.Logger
+
+	mu     sync.Mutex
+	orders map[string]*orderEventState
+}
+
+/**
+ * @description
+ * NewOrderEventPublisher creates a new OrderEventPublisher.
+ *
+ * @param redisClient The Redis client to publish with; if nil, events are not published.
+ * @param logger A structured logger.
+ * @returns A pointer to a new OrderEventPublisher instance.
+ */
+func NewOrderEventPublisher(redisClient *redis.Client, logger *slog.Logger) *OrderEventPublisher {
+	return &OrderEventPublisher{
+		redisClient: redisClient,
+		logger:      logger,
+		orders:      make(map[string]*orderEventState),
+	}
+}
+
+/**
+ * @description
+ * Publish publishes an order_update event for a status transition.
+ *
+ * @param ctx The context for the Redis call.
+ * @param order The order record returned by the status update, carrying its new event_seq.
+ * @param previousStatus The status before the transition.
+ */
+func (p *OrderEventPublisher) Publish(ctx context.Context, order db.Order, previousStatus string) {
+	if p.redisClient == nil {
+		return
+	}
+
+	orderID := order.ID.String()
+	state := p.acquire(orderID)
+	defer p.release(state)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if order.EventSeq <= state.lastSeq {
+		p.logger.Info("dropping stale order_update event",
+			"order_id", orderID,
+			"event_seq", order.EventSeq,
+			"last_published_seq", state.lastSeq,
+			"status", order.Status)
+		return
+	}
+
+	event := wire.OrderUpdate{
+		SchemaVersion:     wire.SchemaVersion,
+		Type:              orderUpdateEventType,
+		OrderID:           orderID,
+		PolymarketOrderID: order.PolymarketOrderID.String,
+		MarketID:          order.MarketID,
+		PreviousStatus:    previousStatus,
+		Status:            order.Status,
+		EventSeq:          order.EventSeq,
+		Timestamp:         time.Now().UnixMilli(),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		p.logger.Error("failed to marshal order_update event", "error", err, "order_id", orderID)
+		return
+	}
+
+	// The sequence number is consumed even if publishing fails, so that an older event
+	// can never be published after a newer one was attempted.
+	state.lastSeq = order.EventSeq
+	channel := channels.OrdersChannel(order.UserID.String())
+	if err := publish(ctx, p.redisClient, p.buffer, channel, payload, PublishDurable); err != nil {
+		p.logger.Warn("failed to publish order_update event", "error", err, "order_id", orderID, "channel", channel)
+	}
+}
+
+// SetPublishBuffer sets the buffer that retries failed publishes. It must be called before
+// events are published.
+func (p *OrderEventPublisher) SetPublishBuffer(buffer *PublishBuffer) {
+	p.buffer = buffer
+}
+
+// acquire returns the publishing state of an order, creating it if needed.
+func (p *OrderEventPublisher) acquire(orderID string) *orderEventState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.orders[orderID]
+	if !ok {
+		if len(p.orders) >= maxTrackedOrderEvents {
+			for id, idle := range p.orders {
+				if idle.refs == 0 {
+					delete(p.orders, id)
+				}
+			}
+		}
+		state = &orderEventState{}
+		p.orders[orderID] = state
+	}
+	state.refs++
+	return state
+}
+
+// release marks a publish for an order as finished.
+func (p *OrderEventPublisher) release(state *orderEventState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state.refs--
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/wire"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestOrderEventPublisher creates a publisher whose events are recorded by the returned
+// fake publisher.
+func newTestOrderEventPublisher(t *testing.T) (*OrderEventPublisher, *fakePublisher) {
+	t.Helper()
+	// The client is only checked for nil; the buffer publishes with the fake.
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	t.Cleanup(func() { redisClient.Close() })
+	publisher := &fakePublisher{}
+	now := time.Now()
+	events := NewOrderEventPublisher(redisClient, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	events.SetPublishBuffer(newTestPublishBuffer(publisher, PublishBufferPolicy{}, &now))
+	return events, publisher
+}
+
+// publishedOrderUpdates decodes the order_update events recorded by publisher, by order ID.
+func publishedOrderUpdates(t *testing.T, publisher *fakePublisher) map[string][]wire.OrderUpdate {
+	t.Helper()
+	updates := make(map[string][]wire.OrderUpdate)
+	for _, message := range publisher.published {
+		_, payload, _ := strings.Cut(message, " ")
+		var update wire.OrderUpdate
+		if err := json.Unmarshal([]byte(payload), &update); err != nil {
+			t.Fatalf("decode %s: %v", payload, err)
+		}
+		updates[update.OrderID] = append(updates[update.OrderID], update)
+	}
+	return updates
+}
+
+func testOrderUUID(n byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{15: n}, Valid: true}
+}
+
+// TestOrderEventPublisherDropsStaleEvents checks that an event with an event_seq not above
+// the last one published for its order is dropped, and that orders are sequenced separately.
+func TestOrderEventPublisherDropsStaleEvents(t *testing.T) {
+	events, publisher := newTestOrderEventPublisher(t)
+	ctx := context.Background()
+	first := db.Order{ID: testOrderUUID(1), UserID: testOrderUUID(9), Status: OrderStatusFilled, EventSeq: 3}
+	second := db.Order{ID: testOrderUUID(2), UserID: testOrderUUID(9), Status: OrderStatusOpen, EventSeq: 1}
+
+	events.Publish(ctx, first, OrderStatusOpen)
+	first.Status, first.EventSeq = OrderStatusOpen, 2 // Committed before the fill, published after it
+	events.Publish(ctx, first, OrderStatusPending)
+	first.EventSeq = 3 // A repeat of the fill
+	events.Publish(ctx, first, OrderStatusOpen)
+	events.Publish(ctx, second, OrderStatusPending)
+	first.Status, first.EventSeq = OrderStatusCancelled, 4
+	events.Publish(ctx, first, OrderStatusFilled)
+
+	updates := publishedOrderUpdates(t, publisher)
+	var got []string
+	for _, update := range updates[first.ID.String()] {
+		got = append(got, fmt.Sprintf("%d:%s->%s", update.EventSeq, update.PreviousStatus, update.Status))
+	}
+	if want := "3:open->filled 4:filled->cancelled"; strings.Join(got, " ") != want {
+		t.Errorf("first order's events = %s, want %s", strings.Join(got, " "), want)
+	}
+	if len(updates[second.ID.String()]) != 1 {
+		t.Errorf("second order's events = %+v, want its first event", updates[second.ID.String()])
+	}
+	if channel, _, _ := strings.Cut(publisher.published[0], " "); !strings.HasSuffix(channel, testOrderUUID(9).String()) {
+		t.Errorf("published on %s, want the user's orders channel", channel)
+	}
+}
+
+// sequencedOrderStore is a db.Querier whose status updates increment the order's event_seq
+// under a lock, like the database's row lock, recording the status committed at each seq.
+type sequencedOrderStore struct {
+	db.Querier
+	mu        sync.Mutex
+	orders    map[pgtype.UUID]db.Order
+	committed map[pgtype.UUID]map[int64]string // Order -> event_seq -> status
+}
+
+func (s *sequencedOrderStore) UpdateOrderStatus(_ context.Context, arg db.UpdateOrderStatusParams) (db.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order := s.orders[arg.ID]
+	order.Status = arg.Status
+	order.EventSeq++
+	s.orders[arg.ID] = order
+	s.committed[arg.ID][order.EventSeq] = arg.Status
+	return order, nil
+}
+
+// TestConcurrentTransitionsPublishInCommitOrder fires concurrent status transitions at
+// several orders and checks that each order's published events follow the commit order:
+// their event_seq increases, each carries the status committed at that seq, and the last
+// transition is always published.
+func TestConcurrentTransitionsPublishInCommitOrder(t *testing.T) {
+	const (
+		orders      = 4
+		transitions = 50
+	)
+	events, publisher := newTestOrderEventPublisher(t)
+	store := &sequencedOrderStore{orders: make(map[pgtype.UUID]db.Order), committed: make(map[pgtype.UUID]map[int64]string)}
+	for i := 0; i < orders; i++ {
+		id := testOrderUUID(byte(i + 1))
+		store.orders[id] = db.Order{ID: id, UserID: testOrderUUID(100), Status: OrderStatusPending}
+		store.committed[id] = make(map[int64]string)
+	}
+	service := &PolymarketService{
+		store:       store,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		orderEvents: events,
+		latency:     newOrderLatencyTracker(),
+	}
+
+	statuses := []string{OrderStatusOpen, OrderStatusDelayed, OrderStatusCancelled}
+	var wg sync.WaitGroup
+	for i := 0; i < orders; i++ {
+		id := testOrderUUID(byte(i + 1))
+		for j := 0; j < transitions; j++ {
+			wg.Add(1)
+			go func(status string) {
+				defer wg.Done()
+				// The handler, the sync worker and the sweeper each start from the status
+				// they read, which differs from the one being set.
+				service.transitionOrder(context.Background(), db.Order{ID: id, Status: OrderStatusPending}, status, "")
+			}(statuses[j%len(statuses)])
+		}
+	}
+	wg.Wait()
+
+	updates := publishedOrderUpdates(t, publisher)
+	for id, committed := range store.committed {
+		published := updates[id.String()]
+		if len(published) == 0 {
+			t.Errorf("order %s: no events published", id)
+			continue
+		}
+		var lastSeq int64
+		for _, update := range published {
+			if update.EventSeq <= lastSeq {
+				t.Errorf("order %s: event_seq %d published after %d", id, update.EventSeq, lastSeq)
+			}
+			if update.Status != committed[update.EventSeq] {
+				t.Errorf("order %s: event_seq %d published as %s, committed as %s", id, update.EventSeq, update.Status, committed[update.EventSeq])
+			}
+			lastSeq = update.EventSeq
+		}
+		if lastSeq != transitions {
+			t.Errorf("order %s: last published event_seq = %d, want the last committed %d", id, lastSeq, transitions)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/**
+ * @description
+ * This file implements idempotency keys for order placement: a client may send an
+ * `Idempotency-Key` with an order, and a retry carrying the same key returns the order placed
+ * by the first request instead of placing a second one.
+ *
+ * Key features:
+ * - Storage: Keys are kept in Redis under `cachekeys.OrderIdempotency`, per user, and hold
+ *   the ID of the order placed with them.
+ * - Lifecycle: Every key expires after the configured window (ORDER_IDEMPOTENCY_TTL_MS), so
+ *   the store does not grow with the number of orders and needs no cleanup task.
+ * - Concurrency: A key is claimed before its order is placed. A request arriving while the
+ *   first one is still being processed is rejected with ErrIdempotencyKeyInProgress.
+ *
+ * @notes
+ * - A claim whose order was never saved is released, so that the key can be retried.
+ * - When Redis is unavailable, orders are placed without the key's protection.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/poly-pro/backend/internal/cachekeys"
+	"github.com/redis/go-redis/v9"
+)
+
+// idempotencyClaimed is the value of a key claimed by an order that is not saved yet.
+const idempotencyClaimed = "claimed"
+
+// maxIdempotencyKeyLength bounds the length of the keys sent by clients.
+const maxIdempotencyKeyLength = 255
+
+// ErrIdempotencyKeyInProgress is returned when an order with the same idempotency key is still being placed.
+var ErrIdempotencyKeyInProgress = errors.New("an order with this idempotency key is already being processed")
+
+// ErrInvalidIdempotencyKey is returned when an idempotency key is too long.
+var ErrInvalidIdempotencyKey = errors.New("idempotency key must be at most 255 characters")
+
+// orderIdempotency stores the idempotency keys of placed orders in Redis.
+type orderIdempotency struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	logger      *slog.Logger
+}
+
+// newOrderIdempotency creates the key store. Keys expire after ttl, or after the purpose's
+// default TTL when ttl is zero.
+func newOrderIdempotency(redisClient *redis.Client, ttl time.Duration, logger *slog.Logger) *orderIdempotency {
+	if ttl <= 0 {
+		ttl = cachekeys.OrderIdempotency.TTL
+	}
+	return &orderIdempotency{redisClient: redisClient, ttl: ttl, logger: logger}
+}
+
+/**
+ * @description
+ * claim reserves a user's idempotency key for a new order.
+ *
+ * @param ctx The context for the Redis calls.
+ * @param userID The internal ID of the user placing the order.
+ * @param key The idempotency key sent by the client.
+ * @returns Whether the key was claimed, and otherwise the ID of the order already placed with it.
+ * @returns ErrIdempotencyKeyInProgress if the key is claimed by an order not saved yet.
+ */
+func (s *orderIdempotency) claim(ctx context.Context, userID pgtype.UUID, key string) (bool, pgtype.UUID, error) {
+	redisKey := cachekeys.OrderIdempotency.Key(userID.String(), key)
+	claimed, err := s.redisClient.SetNX(ctx, redisKey, idempotencyClaimed, s.ttl).Result()
+	if err != nil {
+		s.logger.Warn("failed to claim order idempotency key, placing the order without it", "error", err, "user_id", userID)
+		return true, pgtype.UUID{}, nil
+	}
+	if claimed {
+		return true, pgtype.UUID{}, nil
+	}
+
+	value, err := s.redisClient.Get(ctx, redisKey).Result()
+	if errors.Is(err, redis.Nil) {
+		// The key expired between the two calls.
+		return s.claim(ctx, userID, key)
+	}
+	if err != nil {
+		return false, pgtype.UUID{}, err
+	}
+	if value == idempotencyClaimed {
+		return false, pgtype.UUID{}, ErrIdempotencyKeyInProgress
+	}
+	var orderID pgtype.UUID
+	if err := orderID.Scan(value); err != nil {
+		return false, pgtype.UUID{}, err
+	}
+	return false, orderID, nil
+}
+
+// record stores the ID of the order placed with a claimed key, restarting its window.
+func (s *orderIdempotency) record(ctx context.Context, userID pgtype.UUID, key string, orderID pgtype.UUID) {
+	if err := s.redisClient.Set(ctx, cachekeys.OrderIdempotency.Key(userID.String(), key), orderID.String(), s.ttl).Err(); err != nil {
+		s.logger.Warn("failed to record order idempotency key", "error", err, "user_id", userID, "order_id", orderID)
+	}
+}
+
+// release frees a claimed key whose order was not saved, so that the request can be retried.
+func (s *orderIdempotency) release(ctx context.Context, userID pgtype.UUID, key string) {
+	if err := s.redisClient.Del(ctx, cachekeys.OrderIdempotency.Key(userID.String(), key)).Err(); err != nil {
+		s.logger.Warn("failed to release order idempotency key", "error", err, "user_id", userID)
+	}
+}
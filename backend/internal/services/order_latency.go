@@ -0,0 +1,149 @@
+/**
+ * @description
+ * This file implements the tracking of order lifecycle latencies, so that the signer and the
+ * CLOB submission path can be held to latency objectives.
+ *
+ * Key features:
+ * - Lifecycle Steps: Three steps are measured from the order's stored timestamps: created to
+ *   signed (the remote signer), signed to submitted (including any submission retries), and
+ *   submitted to the first status change (e.g. the order sync finding the order filled), which
+ *   is how long the order took to progress on the CLOB.
+ * - Histograms: Each step's latency is counted in fixed cumulative buckets, exposed by
+ *   OrderLatencyStats on the diagnostics endpoint.
+ *
+ * @notes
+ * - A step is observed once, by the update that sets its end timestamp; orders whose start
+ *   timestamp is missing (e.g. placed before the timestamps existed) are skipped.
+ * - created_at and first_status_change_at are set by the database while the other timestamps
+ *   are taken by the backend, so clock skew between them can distort created to signed and
+ *   submitted to the first status change; negative latencies count as zero.
+ */
+
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// orderLatencyBuckets are the upper bounds of the order latency histogram buckets.
+var orderLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+}
+
+// LatencyHistogram is a snapshot of one lifecycle step's latencies.
+type LatencyHistogram struct {
+	Histogram  []LagBucket `json:"histogram"`
+	Count      int64       `json:"count"`
+	SumSeconds float64     `json:"sum_seconds"`
+}
+
+// OrderLatencyStats is a snapshot of the order lifecycle latencies.
+type OrderLatencyStats struct {
+	CreatedToSigned         LatencyHistogram `json:"created_to_signed"`
+	SignedToSubmitted       LatencyHistogram `json:"signed_to_submitted"`
+	SubmittedToStatusChange LatencyHistogram `json:"submitted_to_first_status_change"`
+}
+
+// latencyHistogram counts latencies in the order latency buckets.
+type latencyHistogram struct {
+	counts []int64 // Per bucket, with a final +Inf bucket
+	count  int64
+	sum    time.Duration
+}
+
+// observe records a latency. The caller must hold the tracker's lock.
+func (h *latencyHistogram) observe(latency time.Duration) {
+	if latency < 0 {
+		latency = 0
+	}
+	if h.counts == nil {
+		h.counts = make([]int64, len(orderLatencyBuckets)+1)
+	}
+	bucket := sort.Search(len(orderLatencyBuckets), func(i int) bool { return latency <= orderLatencyBuckets[i] })
+	h.counts[bucket]++
+	h.count++
+	h.sum += latency
+}
+
+// stats returns the histogram with cumulative bucket counts. The caller must hold the tracker's lock.
+func (h *latencyHistogram) stats() LatencyHistogram {
+	stats := LatencyHistogram{
+		Histogram:  make([]LagBucket, 0, len(orderLatencyBuckets)+1),
+		Count:      h.count,
+		SumSeconds: h.sum.Seconds(),
+	}
+	var cumulative int64
+	for i := 0; i <= len(orderLatencyBuckets); i++ {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		le := "+Inf"
+		if i < len(orderLatencyBuckets) {
+			le = orderLatencyBuckets[i].String()
+		}
+		stats.Histogram = append(stats.Histogram, LagBucket{LE: le, Count: cumulative})
+	}
+	return stats
+}
+
+// orderLatencyTracker records the latencies of the order lifecycle steps.
+type orderLatencyTracker struct {
+	mu                      sync.Mutex
+	createdToSigned         latencyHistogram
+	signedToSubmitted       latencyHistogram
+	submittedToStatusChange latencyHistogram
+}
+
+// newOrderLatencyTracker creates an empty tracker.
+func newOrderLatencyTracker() *orderLatencyTracker {
+	return &orderLatencyTracker{}
+}
+
+// Observe records the lifecycle steps completed by an update of an order, given the order
+// before and after the update.
+func (t *orderLatencyTracker) Observe(before, after db.Order) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if timestampSet(before.SignedAt, after.SignedAt) && after.CreatedAt.Valid {
+		t.createdToSigned.observe(after.SignedAt.Time.Sub(after.CreatedAt.Time))
+	}
+	if timestampSet(before.SubmittedAt, after.SubmittedAt) && after.SignedAt.Valid {
+		t.signedToSubmitted.observe(after.SubmittedAt.Time.Sub(after.SignedAt.Time))
+	}
+	if timestampSet(before.FirstStatusChangeAt, after.FirstStatusChangeAt) && after.SubmittedAt.Valid {
+		t.submittedToStatusChange.observe(after.FirstStatusChangeAt.Time.Sub(after.SubmittedAt.Time))
+	}
+}
+
+// timestampSet reports whether a timestamp was set by an update.
+func timestampSet(before, after pgtype.Timestamptz) bool {
+	return !before.Valid && after.Valid
+}
+
+// Stats returns a snapshot of the latency histograms.
+func (t *orderLatencyTracker) Stats() OrderLatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return OrderLatencyStats{
+		CreatedToSigned:         t.createdToSigned.stats(),
+		SignedToSubmitted:       t.signedToSubmitted.stats(),
+		SubmittedToStatusChange: t.submittedToStatusChange.stats(),
+	}
+}
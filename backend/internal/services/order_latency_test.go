@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	for _, latency := range []time.Duration{
+		-time.Second, // Clock skew counts as zero
+		0,
+		50 * time.Millisecond, // On a bucket's upper bound
+		51 * time.Millisecond,
+		2 * time.Second,
+		10 * time.Minute, // Beyond the last bound
+	} {
+		h.observe(latency)
+	}
+
+	stats := h.stats()
+	if stats.Count != 6 {
+		t.Errorf("count = %d, want 6", stats.Count)
+	}
+	if want := (101*time.Millisecond + 2*time.Second + 10*time.Minute).Seconds(); stats.SumSeconds != want {
+		t.Errorf("sum = %v, want %v", stats.SumSeconds, want)
+	}
+	want := map[string]int64{"50ms": 3, "100ms": 4, "1s": 4, "2.5s": 5, "5m0s": 5, "+Inf": 6}
+	for _, bucket := range stats.Histogram {
+		if count, ok := want[bucket.LE]; ok && bucket.Count != count {
+			t.Errorf("bucket le=%s = %d, want %d", bucket.LE, bucket.Count, count)
+		}
+	}
+	if n := len(stats.Histogram); n != len(orderLatencyBuckets)+1 || stats.Histogram[n-1].LE != "+Inf" {
+		t.Errorf("histogram has %d buckets ending at %s, want %d ending at +Inf", n, stats.Histogram[n-1].LE, len(orderLatencyBuckets)+1)
+	}
+
+	var empty latencyHistogram
+	if stats := empty.stats(); stats.Count != 0 || len(stats.Histogram) != len(orderLatencyBuckets)+1 {
+		t.Errorf("empty histogram = %+v", stats)
+	}
+}
+
+// at returns a timestamp offset from base.
+func at(base time.Time, offset time.Duration) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: base.Add(offset), Valid: true}
+}
+
+func TestOrderLatencyObserve(t *testing.T) {
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	created := db.Order{CreatedAt: at(base, 0)}
+	signed := created
+	signed.SignedAt = at(base, 100*time.Millisecond)
+	submitted := signed
+	submitted.SubmittedAt = at(base, 300*time.Millisecond)
+	submitted.AcknowledgedAt = at(base, 400*time.Millisecond)
+	changed := submitted
+	changed.FirstStatusChangeAt = at(base, 3*time.Second)
+	legacy := changed // Placed before the lifecycle timestamps existed
+	legacy.SignedAt = pgtype.Timestamptz{}
+	legacy.SubmittedAt = pgtype.Timestamptz{}
+
+	tests := []struct {
+		name          string
+		before, after db.Order
+		want          [3]int64 // created to signed, signed to submitted, submitted to status change
+	}{
+		{"signing", created, signed, [3]int64{1, 0, 0}},
+		{"submission", signed, submitted, [3]int64{0, 1, 0}},
+		{"signing and submission in one update", created, submitted, [3]int64{1, 1, 0}},
+		{"first status change", submitted, changed, [3]int64{0, 0, 1}},
+		{"later status change", changed, changed, [3]int64{0, 0, 0}},
+		{"no timestamp set", submitted, submitted, [3]int64{0, 0, 0}},
+		{"start timestamp missing", db.Order{}, legacy, [3]int64{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newOrderLatencyTracker()
+			tracker.Observe(tt.before, tt.after)
+			stats := tracker.Stats()
+			got := [3]int64{stats.CreatedToSigned.Count, stats.SignedToSubmitted.Count, stats.SubmittedToStatusChange.Count}
+			if got != tt.want {
+				t.Errorf("observed counts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	tracker := newOrderLatencyTracker()
+	tracker.Observe(submitted, changed)
+	if got := tracker.Stats().SubmittedToStatusChange.SumSeconds; got != 2.7 {
+		t.Errorf("submitted to first status change = %vs, want 2.7s", got)
+	}
+}
+
+// orderStore keeps one order and applies the timestamp rules of RecordOrderSubmission and
+// UpdateOrderStatus, with the database clock as time.Now.
+type orderStore struct {
+	db.Querier
+	order db.Order
+}
+
+func (s *orderStore) RecordOrderSubmission(_ context.Context, arg db.RecordOrderSubmissionParams) (db.Order, error) {
+	o := &s.order
+	o.Status = arg.Status
+	if arg.PolymarketOrderID.Valid {
+		o.PolymarketOrderID = arg.PolymarketOrderID
+	}
+	o.SignedOrder = arg.SignedOrder
+	o.SignedAt = firstTimestamp(o.SignedAt, arg.SignedAt)
+	o.SubmittedAt = firstTimestamp(o.SubmittedAt, arg.SubmittedAt)
+	o.AcknowledgedAt = firstTimestamp(o.AcknowledgedAt, arg.AcknowledgedAt)
+	o.EventSeq++
+	return *o, nil
+}
+
+func (s *orderStore) UpdateOrderStatus(_ context.Context, arg db.UpdateOrderStatusParams) (db.Order, error) {
+	o := &s.order
+	if o.Status != arg.Status && o.SubmittedAt.Valid && !o.FirstStatusChangeAt.Valid {
+		o.FirstStatusChangeAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	o.Status = arg.Status
+	o.EventSeq++
+	return *o, nil
+}
+
+// firstTimestamp returns the stored timestamp if set, and the argument otherwise.
+func firstTimestamp(stored, arg pgtype.Timestamptz) pgtype.Timestamptz {
+	if stored.Valid {
+		return stored
+	}
+	return arg
+}
+
+// TestOrderLifecycleTimestamps submits an order to a test CLOB and lets the order sync find it
+// cancelled, then checks that the lifecycle timestamps are in order and each step is observed once.
+func TestOrderLifecycleTimestamps(t *testing.T) {
+	clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/order":
+			io.WriteString(w, `{"success":true,"orderId":"0xorder","status":"live"}`)
+		case "/data/order/0xorder":
+			io.WriteString(w, `{"id":"0xorder","status":"CANCELED","order_type":"GTC"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(clob.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := &orderStore{order: db.Order{
+		Status:    "pending",
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}}
+	service := &PolymarketService{
+		store:       store,
+		logger:      logger,
+		clobClient:  polymarket.NewCLOBAPIClient(clob.URL, "key", "secret", "passphrase", logger),
+		orderEvents: NewOrderEventPublisher(nil, logger),
+		latency:     newOrderLatencyTracker(),
+	}
+	ctx := context.Background()
+
+	signedAt := time.Now()
+	submitted, warning, err := service.submitOrder(ctx, store.order, &polymarket.SignedOrder{}, "0xmaker", signedAt)
+	if err != nil || warning != "" {
+		t.Fatalf("submitOrder: %v, warning %q", err, warning)
+	}
+	if submitted.Status != "open" {
+		t.Fatalf("status after submission = %s, want open", submitted.Status)
+	}
+	if submitted.FirstStatusChangeAt.Valid {
+		t.Error("the submission itself was recorded as a status change")
+	}
+
+	refreshed, err := service.RefreshOrderStatus(ctx, submitted, "0xmaker")
+	if err != nil {
+		t.Fatalf("RefreshOrderStatus: %v", err)
+	}
+	if refreshed.Status != "cancelled" {
+		t.Fatalf("status after sync = %s, want cancelled", refreshed.Status)
+	}
+	// A later change is not the first one.
+	service.transitionOrder(ctx, refreshed, "expired", "0xmaker")
+
+	order := store.order
+	steps := []struct {
+		name string
+		ts   pgtype.Timestamptz
+	}{
+		{"created_at", order.CreatedAt},
+		{"signed_at", order.SignedAt},
+		{"submitted_at", order.SubmittedAt},
+		{"acknowledged_at", order.AcknowledgedAt},
+		{"first_status_change_at", order.FirstStatusChangeAt},
+	}
+	for i, step := range steps {
+		if !step.ts.Valid {
+			t.Fatalf("%s is not set", step.name)
+		}
+		if i > 0 && step.ts.Time.Before(steps[i-1].ts.Time) {
+			t.Errorf("%s (%v) is before %s (%v)", step.name, step.ts.Time, steps[i-1].name, steps[i-1].ts.Time)
+		}
+	}
+	if !order.SignedAt.Time.Equal(signedAt.UTC()) {
+		t.Errorf("signed_at = %v, want %v", order.SignedAt.Time, signedAt)
+	}
+	if order.FirstStatusChangeAt != refreshed.FirstStatusChangeAt {
+		t.Error("a later status change moved first_status_change_at")
+	}
+
+	stats := service.OrderLatencyStats()
+	for name, histogram := range map[string]LatencyHistogram{
+		"created_to_signed":                stats.CreatedToSigned,
+		"signed_to_submitted":              stats.SignedToSubmitted,
+		"submitted_to_first_status_change": stats.SubmittedToStatusChange,
+	} {
+		if histogram.Count != 1 {
+			t.Errorf("%s observed %d latencies, want 1", name, histogram.Count)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+/**
+ * @description
+ * This service retries the submission of signed orders that failed because the CLOB was
+ * temporarily unavailable, instead of rejecting them immediately.
+ *
+ * Key features:
+ * - Retry Queue: An order whose submission fails with a transient error (network error,
+ *   HTTP 429 or 5xx) is moved to the 'pending_submission' status and queued in memory
+ *   with its signed payload.
+ * - Backoff: Queued orders are resubmitted after an exponential backoff, and rejected
+ *   once the configured number of retries is exhausted. A rejection by the CLOB itself
+ *   is final and is not retried.
+ * - Orphan Sweep: The queue does not survive a restart, so orders left in
+ *   'pending_submission' for longer than a full retry schedule are rejected.
+ * - Metrics: Queue depth and outcome counters are exposed through `OrderRetryStats`.
+ *
+ * @notes
+ * - Retries are disabled unless ORDER_RETRY_MAX_ATTEMPTS is set and CLOB API credentials
+ *   are configured.
+ * - Resubmitting the same signed order is safe: a duplicate is reconciled as the existing
+ *   CLOB order rather than placed twice.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+const (
+	// defaultOrderRetryBackoff is the delay before the first retry when none is configured.
+	defaultOrderRetryBackoff = 2 * time.Second
+	// maxOrderRetryBackoff caps the delay between retries.
+	maxOrderRetryBackoff = 30 * time.Second
+	// maxQueuedOrders bounds the queue; transient failures are rejected when it is full.
+	maxQueuedOrders = 1000
+	// orderRetryPollInterval is how often the queue is checked for orders due for a retry.
+	orderRetryPollInterval = time.Second
+	// orderRetrySweepInterval is how often orphaned pending_submission orders are swept.
+	orderRetrySweepInterval = time.Minute
+	// orderRetrySweepGrace is added to the retry schedule before an order counts as orphaned.
+	orderRetrySweepGrace = 5 * time.Minute
+)
+
+// queuedOrder is a signed order waiting for another submission attempt.
+type queuedOrder struct {
+	order        db.Order
+	signedOrder  *polymarket.SignedOrder
+	makerAddress string
+	retries      int // Retries made so far
+	nextAttempt  time.Time
+}
+
+// OrderRetryStats is a snapshot of the order submission retry queue.
+type OrderRetryStats struct {
+	Enabled     bool  `json:"enabled"`
+	Depth       int   `json:"depth"` // Orders currently waiting for a retry
+	MaxAttempts int   `json:"max_attempts"`
+	Queued      int64 `json:"queued"`     // Orders queued since startup
+	Submitted   int64 `json:"submitted"`  // Queued orders accepted by the CLOB on a retry
+	Rejected    int64 `json:"rejected"`   // Queued orders rejected by the CLOB or after exhausting retries
+	QueueFull   int64 `json:"queue_full"` // Transient failures rejected because the queue was full
+}
+
+// orderRetryQueue holds the orders waiting for a retry, keyed by order ID.
+type orderRetryQueue struct {
+	maxAttempts int
+	backoff     time.Duration
+
+	mu        sync.Mutex
+	orders    map[string]*queuedOrder
+	queued    int64
+	submitted int64
+	rejected  int64
+	queueFull int64
+}
+
+// newOrderRetryQueue creates a queue allowing maxAttempts retries per order.
+func newOrderRetryQueue(maxAttempts int, backoff time.Duration) *orderRetryQueue {
+	if backoff <= 0 {
+		backoff = defaultOrderRetryBackoff
+	}
+	return &orderRetryQueue{
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		orders:      make(map[string]*queuedOrder),
+	}
+}
+
+// delay returns the backoff before the retry following the given number of retries.
+func (q *orderRetryQueue) delay(retries int) time.Duration {
+	delay := q.backoff
+	for i := 0; i < retries && delay < maxOrderRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOrderRetryBackoff)
+}
+
+// schedule returns the time a full retry schedule takes.
+func (q *orderRetryQueue) schedule() time.Duration {
+	var total time.Duration
+	for retries := 0; retries < q.maxAttempts; retries++ {
+		total += q.delay(retries)
+	}
+	return total
+}
+
+// push schedules an order's next retry. It reports false if a new order does not fit.
+func (q *orderRetryQueue) push(item *queuedOrder) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if item.retries == 0 {
+		if len(q.orders) >= maxQueuedOrders {
+			q.queueFull++
+			return false
+		}
+		q.queued++
+	}
+	item.nextAttempt = time.Now().Add(q.delay(item.retries))
+	q.orders[item.order.ID.String()] = item
+	return true
+}
+
+// popDue removes and returns the orders due for a retry.
+func (q *orderRetryQueue) popDue(now time.Time) []*queuedOrder {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []*queuedOrder
+	for id, item := range q.orders {
+		if !item.nextAttempt.After(now) {
+			due = append(due, item)
+			delete(q.orders, id)
+		}
+	}
+	return due
+}
+
+// contains reports whether an order is waiting in the queue.
+func (q *orderRetryQueue) contains(orderID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.orders[orderID]
+	return ok
+}
+
+// recordOutcome counts a queued order that left the queue.
+func (q *orderRetryQueue) recordOutcome(submitted bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if submitted {
+		q.submitted++
+	} else {
+		q.rejected++
+	}
+}
+
+// stats returns a snapshot of the queue.
+func (q *orderRetryQueue) stats() OrderRetryStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return OrderRetryStats{
+		Enabled:     true,
+		Depth:       len(q.orders),
+		MaxAttempts: q.maxAttempts,
+		Queued:      q.queued,
+		Submitted:   q.submitted,
+		Rejected:    q.rejected,
+		QueueFull:   q.queueFull,
+	}
+}
+
+// OrderRetriesEnabled reports whether transient submission failures are retried.
+func (s *PolymarketService) OrderRetriesEnabled() bool {
+	return s.retryQueue != nil
+}
+
+// OrderRetryStats returns a snapshot of the order submission retry queue.
+func (s *PolymarketService) OrderRetryStats() OrderRetryStats {
+	if s.retryQueue == nil {
+		return OrderRetryStats{}
+	}
+	return s.retryQueue.stats()
+}
+
+/**
+ * @description
+ * queueSubmission moves an order whose submission failed transiently to the
+ * 'pending_submission' status and queues it for a retry.
+ *
+ * @param ctx The context for the status update.
+ * @param dbOrder The local order record.
+ * @param signedOrder The signed order to resubmit.
+ * @param makerAddress The funder address of the order.
+ * @param signedAt When the order was signed.
+ * @returns The updated order record, a warning if its status could not be recorded (see
+ *   recordSubmission), and false if the queue is full and the order was not queued (the
+ *   caller then rejects it).
+ */
+func (s *PolymarketService) queueSubmission(ctx context.Context, dbOrder db.Order, signedOrder *polymarket.SignedOrder, makerAddress string, signedAt time.Time) (db.Order, string, bool) {
+	dbOrder, warning := s.recordSubmission(ctx, dbOrder, OrderStatusPendingSubmission, "", signedOrder, makerAddress, orderTimestamps{signedAt: signedAt})
+	item := &queuedOrder{
+		order:        dbOrder,
+		signedOrder:  signedOrder,
+		makerAddress: makerAddress,
+	}
+	if !s.retryQueue.push(item) {
+		s.logger.Warn("order retry queue is full, not queueing order", "order_id", dbOrder.ID, "max_queued", maxQueuedOrders)
+		return dbOrder, warning, false
+	}
+
+	s.logger.Info("order queued for resubmission", "order_id", dbOrder.ID, "retry_in", s.retryQueue.delay(0))
+	return dbOrder, warning, true
+}
+
+/**
+ * @description
+ * retrySubmission makes another submission attempt for a queued order. A transient
+ * failure requeues the order until its retries are exhausted, after which it is rejected.
+ *
+ * @param ctx The context for the submission.
+ * @param item The queued order.
+ */
+func (s *PolymarketService) retrySubmission(ctx context.Context, item *queuedOrder) {
+	item.retries++
+	// The signing time is already stored with the order, which was queued after signing.
+	dbOrder, _, err := s.submitOrder(ctx, item.order, item.signedOrder, item.makerAddress, time.Time{})
+	item.order = dbOrder
+
+	switch {
+	case errors.Is(err, polymarket.ErrCLOBUnavailable) && item.retries < s.retryQueue.maxAttempts:
+		s.retryQueue.push(item)
+		s.logger.Warn("order resubmission failed, will retry",
+			"error", err,
+			"order_id", dbOrder.ID,
+			"retries", item.retries,
+			"max_attempts", s.retryQueue.maxAttempts,
+			"retry_in", s.retryQueue.delay(item.retries))
+	case errors.Is(err, polymarket.ErrCLOBUnavailable):
+		s.logger.Error("order resubmission retries exhausted, rejecting order",
+			"error", err,
+			"order_id", dbOrder.ID,
+			"retries", item.retries)
+		s.recordSubmission(ctx, dbOrder, OrderStatusRejected, "", item.signedOrder, item.makerAddress, orderTimestamps{})
+		s.retryQueue.recordOutcome(false)
+	case err != nil:
+		s.logger.Warn("order rejected on resubmission", "error", err, "order_id", dbOrder.ID, "retries", item.retries)
+		s.retryQueue.recordOutcome(false)
+	default:
+		s.logger.Info("order resubmitted successfully", "order_id", dbOrder.ID, "status", dbOrder.Status, "retries", item.retries)
+		s.retryQueue.recordOutcome(true)
+	}
+}
+
+// OrderRetryService resubmits queued orders and rejects orphaned ones.
+type OrderRetryService struct {
+	ctx               context.Context
+	store             db.Querier
+	polymarketService *PolymarketService
+	logger            *slog.Logger
+}
+
+/**
+ * @description
+ * NewOrderRetryService creates a new instance of the OrderRetryService.
+ *
+ * @param ctx The root context; the service stops when it is cancelled.
+ * @param store The database querier for database operations.
+ * @param polymarketService The service owning the retry queue and submitting the orders.
+ * @param logger A structured logger for logging service-level events.
+ * @returns A pointer to a new OrderRetryService instance.
+ */
+func NewOrderRetryService(ctx context.Context, store db.Querier, polymarketService *PolymarketService, logger *slog.Logger) *OrderRetryService {
+	return &OrderRetryService{
+		ctx:               ctx,
+		store:             store,
+		polymarketService: polymarketService,
+		logger:            logger,
+	}
+}
+
+// Run resubmits queued orders as they become due until the context is cancelled.
+// It should be started as a goroutine.
+func (s *OrderRetryService) Run() {
+	if !s.polymarketService.OrderRetriesEnabled() {
+		s.logger.Info("order retry service disabled: retries or CLOB API credentials are not configured")
+		return
+	}
+
+	pollTicker := time.NewTicker(orderRetryPollInterval)
+	defer pollTicker.Stop()
+	sweepTicker := time.NewTicker(orderRetrySweepInterval)
+	defer sweepTicker.Stop()
+
+	s.logger.Info("order retry service started",
+		"max_attempts", s.polymarketService.retryQueue.maxAttempts,
+		"backoff", s.polymarketService.retryQueue.backoff)
+	s.sweepOrphanedOrders()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.logger.Info("order retry service stopped", "queued_orders", s.polymarketService.OrderRetryStats().Depth)
+			return
+		case now := <-pollTicker.C:
+			for _, item := range s.polymarketService.retryQueue.popDue(now) {
+				if s.ctx.Err() != nil {
+					break
+				}
+				s.polymarketService.retrySubmission(s.ctx, item)
+			}
+		case <-sweepTicker.C:
+			s.sweepOrphanedOrders()
+		}
+	}
+}
+
+// sweepOrphanedOrders rejects pending_submission orders that are not queued and have been
+// waiting longer than a full retry schedule, e.g. because the server restarted.
+func (s *OrderRetryService) sweepOrphanedOrders() {
+	queue := s.polymarketService.retryQueue
+	cutoff := time.Now().Add(-(queue.schedule() + orderRetrySweepGrace))
+
+	orders, err := s.store.ListStaleOrdersByStatus(s.ctx, db.ListStaleOrdersByStatusParams{
+		Status:    OrderStatusPendingSubmission,
+		UpdatedAt: pgtype.Timestamptz{Time: cutoff, Valid: true},
+		Limit:     orderSyncBatchSize,
+	})
+	if err != nil {
+		s.logger.Error("failed to list orphaned pending_submission orders", "error", err)
+		return
+	}
+
+	for _, order := range orders {
+		if queue.contains(order.ID.String()) {
+			continue
+		}
+		s.logger.Warn("rejecting orphaned pending_submission order", "order_id", order.ID, "updated_at", order.UpdatedAt.Time)
+		s.polymarketService.transitionOrder(s.ctx, order, OrderStatusRejected, "")
+	}
+}
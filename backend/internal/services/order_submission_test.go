@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// unrecordableStore is a placingStore whose orders cannot be updated after they are created.
+type unrecordableStore struct {
+	*placingStore
+}
+
+func (unrecordableStore) RecordOrderSubmission(context.Context, db.RecordOrderSubmissionParams) (db.Order, error) {
+	return db.Order{}, errors.New("connection reset")
+}
+
+// TestCreateAndSignOrderReturnsSubmissionOutcome places orders the CLOB answers in each way,
+// and checks that the returned order is the row written with the outcome, or the row as
+// created with a warning when the outcome could not be written.
+func TestCreateAndSignOrderReturnsSubmissionOutcome(t *testing.T) {
+	tests := []struct {
+		name        string
+		reply       string
+		unrecorded  bool // The outcome cannot be written
+		wantStatus  string
+		wantOrderID string
+		wantErr     bool
+		wantWarning string
+	}{
+		{name: "live", reply: `{"success":true,"orderId":"0xorder","status":"live"}`, wantStatus: OrderStatusOpen, wantOrderID: "0xorder"},
+		{name: "matched", reply: `{"success":true,"orderId":"0xorder","status":"matched"}`, wantStatus: OrderStatusFilled, wantOrderID: "0xorder"},
+		{name: "delayed", reply: `{"success":true,"orderId":"0xorder","status":"delayed"}`, wantStatus: OrderStatusDelayed, wantOrderID: "0xorder"},
+		{name: "rejected", reply: `{"success":false,"errorMsg":"not enough balance / allowance","orderId":"","status":""}`, wantStatus: OrderStatusRejected, wantErr: true},
+		{name: "accepted but not recorded", reply: `{"success":true,"orderId":"0xorder","status":"live"}`, unrecorded: true,
+			wantStatus: OrderStatusPending, wantWarning: submissionRecordWarning},
+		{name: "rejected and not recorded", reply: `{"success":false,"errorMsg":"not enough balance / allowance","orderId":"","status":""}`, unrecorded: true,
+			wantStatus: OrderStatusPending, wantErr: true, wantWarning: submissionRecordWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/order" {
+					http.NotFound(w, r)
+					return
+				}
+				io.WriteString(w, tt.reply)
+			}))
+			defer clob.Close()
+			placing := &placingStore{}
+			var store db.Querier = placing
+			if tt.unrecorded {
+				store = unrecordableStore{placing}
+			}
+			service := newTestPolymarketService(clob, store)
+			service.signerClient = &recordingSigner{}
+
+			placed, err := service.CreateAndSignOrder(context.Background(), PlaceOrderParams{
+				UserID:  "user_1",
+				TokenID: big.NewInt(12345),
+				Price:   0.55,
+				Size:    10,
+				Side:    "BUY",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateAndSignOrder error = %v, want error %v", err, tt.wantErr)
+			}
+			order := placed.Order
+			if order.Status != tt.wantStatus || order.PolymarketOrderID.String != tt.wantOrderID || placed.Warning != tt.wantWarning {
+				t.Errorf("returned status %q, Polymarket order ID %q, warning %q; want %q, %q, %q",
+					order.Status, order.PolymarketOrderID.String, placed.Warning, tt.wantStatus, tt.wantOrderID, tt.wantWarning)
+			}
+			if tt.unrecorded {
+				if order.SignedOrder != nil || order.EventSeq != 0 {
+					t.Errorf("returned a row with a signed order or event %d, want the row as created", order.EventSeq)
+				}
+				return
+			}
+
+			// The returned row is the stored one, written once with the signed order.
+			if order.EventSeq != 1 || order.Status != placing.order.Status {
+				t.Errorf("returned event %d with status %q, stored %q; want the single stored update", order.EventSeq, order.Status, placing.order.Status)
+			}
+			var signed polymarket.SignedOrder
+			if err := json.Unmarshal(order.SignedOrder, &signed); err != nil || signed.Signature != "0xsignature" {
+				t.Errorf("stored signed order %s (%v), want the signed order", order.SignedOrder, err)
+			}
+			if !order.SignedAt.Valid || !order.SubmittedAt.Valid || !order.AcknowledgedAt.Valid {
+				t.Errorf("timestamps signed %v, submitted %v, acknowledged %v; want all recorded", order.SignedAt.Valid, order.SubmittedAt.Valid, order.AcknowledgedAt.Valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/**
+ * @description
+ * This service keeps the local status of submitted orders in sync with the CLOB.
+ * It periodically looks up orders that have not reached a final state and applies
+ * any status change reported by Polymarket.
+ *
+ * Key features:
+ * - Tiered Polling: Orders in the 'delayed' state (inside a market's matching delay window)
+ *   are polled every few seconds until they resolve; resting 'open' orders are polled
+ *   much less often. Orders left 'pending' with a Polymarket order ID (a duplicate
+ *   submission whose state the CLOB did not confirm) are polled with the delayed ones.
+ * - Status Progression: Changes are applied through the PolymarketService, which records
+ *   fills and publishes order_update events.
+ *
+ * @notes
+ * - The service does nothing when CLOB API credentials are not configured.
+ */
+
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+const (
+	// delayedOrderPollInterval is how often orders in a matching delay are polled.
+	delayedOrderPollInterval = 2 * time.Second
+	// openOrderPollInterval is how often resting orders are polled.
+	openOrderPollInterval = 30 * time.Second
+	// orderSyncBatchSize caps the number of orders refreshed per status per tick.
+	orderSyncBatchSize = 100
+)
+
+// OrderSyncService polls the CLOB for status changes of non-final orders.
+type OrderSyncService struct {
+	ctx               context.Context
+	store             db.Querier
+	polymarketService *PolymarketService
+	logger            *slog.Logger
+}
+
+/**
+ * @description
+ * NewOrderSyncService creates a new instance of the OrderSyncService.
+ *
+ * @param ctx The root context; the service stops when it is cancelled.
+ * @param store The database querier for database operations.
+ * @param polymarketService The service used to fetch and apply order status changes.
+ * @param logger A structured logger for logging service-level events.
+ * @returns A pointer to a new OrderSyncService instance.
+ */
+func NewOrderSyncService(ctx context.Context, store db.Querier, polymarketService *PolymarketService, logger *slog.Logger) *OrderSyncService {
+	return &OrderSyncService{
+		ctx:               ctx,
+		store:             store,
+		polymarketService: polymarketService,
+		logger:            logger,
+	}
+}
+
+// Run polls delayed, unconfirmed pending and open orders until the context is cancelled.
+// It should be started as a goroutine.
+func (s *OrderSyncService) Run() {
+	if !s.polymarketService.TradingEnabled() {
+		s.logger.Info("order sync service disabled: CLOB API credentials are not configured")
+		return
+	}
+
+	delayedTicker := time.NewTicker(delayedOrderPollInterval)
+	defer delayedTicker.Stop()
+	openTicker := time.NewTicker(openOrderPollInterval)
+	defer openTicker.Stop()
+
+	s.logger.Info("order sync service started",
+		"delayed_poll_interval", delayedOrderPollInterval,
+		"open_poll_interval", openOrderPollInterval)
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.logger.Info("order sync service stopped")
+			return
+		case <-delayedTicker.C:
+			s.syncOrders(OrderStatusDelayed)
+			s.syncOrders(OrderStatusPending)
+		case <-openTicker.C:
+			s.syncOrders(OrderStatusOpen)
+		}
+	}
+}
+
+// syncOrders refreshes a batch of orders in the given local status.
+func (s *OrderSyncService) syncOrders(status string) {
+	orders, err := s.store.ListOrdersForSync(s.ctx, db.ListOrdersForSyncParams{
+		Status: status,
+		Limit:  orderSyncBatchSize,
+	})
+	if err != nil {
+		s.logger.Error("failed to list orders for sync", "error", err, "status", status)
+		return
+	}
+
+	for _, row := range orders {
+		order := db.Order{
+			ID:                  row.ID,
+			UserID:              row.UserID,
+			MarketID:            row.MarketID,
+			TokenID:             row.TokenID,
+			PolymarketOrderID:   row.PolymarketOrderID,
+			Side:                row.Side,
+			Size:                row.Size,
+			Price:               row.Price,
+			Status:              row.Status,
+			SignedOrder:         row.SignedOrder,
+			SubmittedAt:         row.SubmittedAt,
+			FilledAt:            row.FilledAt,
+			CancelledAt:         row.CancelledAt,
+			CreatedAt:           row.CreatedAt,
+			UpdatedAt:           row.UpdatedAt,
+			EventSeq:            row.EventSeq,
+			Taker:               row.Taker,
+			SignedAt:            row.SignedAt,
+			AcknowledgedAt:      row.AcknowledgedAt,
+			FirstStatusChangeAt: row.FirstStatusChangeAt,
+		}
+		if _, err := s.polymarketService.RefreshOrderStatus(s.ctx, order, row.PolymarketFunderAddress); err != nil {
+			s.logger.Warn("failed to refresh order status", "error", err, "order_id", order.ID, "status", status)
+		}
+	}
+}
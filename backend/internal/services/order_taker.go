@@ -0,0 +1,38 @@
+/**
+ * @description
+ * This file resolves the taker of an order. Orders are public by default, with the zero
+ * address as taker so that anyone can fill them; a directed order names the only
+ * counterparty allowed to fill it (e.g. a market maker's address).
+ *
+ * @notes
+ * - A taker must be given in its EIP-55 checksummed form, so that a mistyped address is
+ *   rejected instead of directing the order to an address no one controls.
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// publicOrderTaker is the taker of orders that anyone can fill.
+const publicOrderTaker = "0x0000000000000000000000000000000000000000"
+
+// ErrInvalidOrderTaker is returned when an order's taker is not a checksummed address.
+var ErrInvalidOrderTaker = errors.New("invalid order taker")
+
+// orderTaker returns the taker of an order: the given address, or the zero address of public
+// orders when none is given. It returns an error wrapping ErrInvalidOrderTaker when the
+// address is not in its checksummed form.
+func orderTaker(taker string) (string, error) {
+	if taker == "" {
+		return publicOrderTaker, nil
+	}
+	if !common.IsHexAddress(taker) || common.HexToAddress(taker).Hex() != taker {
+		return "", fmt.Errorf("%w: %q is not a checksummed address", ErrInvalidOrderTaker, taker)
+	}
+	return taker, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// directedTaker is a checksummed address directed orders are sent to.
+const directedTaker = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+func TestOrderTaker(t *testing.T) {
+	tests := []struct {
+		name  string
+		taker string
+		want  string // Empty if the taker is invalid
+	}{
+		{"public", "", publicOrderTaker},
+		{"checksummed", directedTaker, directedTaker},
+		{"zero address", publicOrderTaker, publicOrderTaker},
+		// A mistyped case would still be a valid address, just not the intended one.
+		{"lower case", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ""},
+		{"wrong checksum", "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{"no prefix", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{"too short", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", ""},
+		{"not hex", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAzz", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orderTaker(tt.taker)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidOrderTaker) {
+					t.Errorf("orderTaker(%q) = %q, %v; want %v", tt.taker, got, err, ErrInvalidOrderTaker)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("orderTaker(%q) = %q, %v; want %q", tt.taker, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// placingStore is an orderStore for a user with a verified proxy wallet, which records the
+// order it is asked to create.
+type placingStore struct {
+	orderStore
+	created *db.CreateOrderParams
+}
+
+func (s *placingStore) GetUserByClerkID(_ context.Context, clerkUserID string) (db.User, error) {
+	return db.User{ClerkUserID: clerkUserID}, nil
+}
+
+func (s *placingStore) GetActiveWalletByUserID(context.Context, pgtype.UUID) (db.Wallet, error) {
+	return db.Wallet{PolymarketFunderAddress: "0x1111111111111111111111111111111111111111", SignatureType: int16(polymarket.SignatureTypePolyProxy)}, nil
+}
+
+func (s *placingStore) CreateOrder(_ context.Context, arg db.CreateOrderParams) (db.Order, error) {
+	s.created = &arg
+	s.order = db.Order{TokenID: arg.TokenID, Side: arg.Side, Status: arg.Status, Taker: arg.Taker}
+	return s.order, nil
+}
+
+// recordingSigner is a SignerClient that signs everything and records the typed data.
+type recordingSigner struct {
+	SignerClient
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (s *recordingSigner) SignTransaction(_ context.Context, _, payloadJSON string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = append(s.payloads, payloadJSON)
+	return "0xsignature", nil
+}
+
+// TestCreateAndSignOrderTaker places a public, a directed and an invalidly directed order,
+// and checks the taker signed, submitted to the CLOB and recorded.
+func TestCreateAndSignOrderTaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		taker     string
+		wantTaker string // Empty if the order must be rejected
+	}{
+		{"public", "", publicOrderTaker},
+		{"directed", directedTaker, directedTaker},
+		{"invalid", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted []string // Takers of the orders posted to the CLOB
+			clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Order polymarket.SignedOrder `json:"order"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("decode posted order: %v", err)
+				}
+				posted = append(posted, body.Order.Taker)
+				io.WriteString(w, `{"success":true,"orderId":"0xorder","status":"live"}`)
+			}))
+			defer clob.Close()
+			store := &placingStore{}
+			signer := &recordingSigner{}
+			service := newTestPolymarketService(clob, store)
+			service.signerClient = signer
+
+			placed, err := service.CreateAndSignOrder(context.Background(), PlaceOrderParams{
+				UserID:   "user_1",
+				MarketID: "0xmarket",
+				TokenID:  big.NewInt(12345),
+				Price:    0.55,
+				Size:     10,
+				Side:     "BUY",
+				Taker:    tt.taker,
+			})
+			if tt.wantTaker == "" {
+				if !errors.Is(err, ErrInvalidOrderTaker) {
+					t.Fatalf("CreateAndSignOrder error = %v, want %v", err, ErrInvalidOrderTaker)
+				}
+				if store.created != nil || len(signer.payloads) != 0 || len(posted) != 0 {
+					t.Errorf("rejected order was saved (%v), signed %d times or posted %d times", store.created != nil, len(signer.payloads), len(posted))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAndSignOrder: %v", err)
+			}
+
+			if len(signer.payloads) != 1 {
+				t.Fatalf("signed %d times, want once", len(signer.payloads))
+			}
+			var typedData struct {
+				Message map[string]interface{} `json:"message"`
+			}
+			if err := json.Unmarshal([]byte(signer.payloads[0]), &typedData); err != nil {
+				t.Fatalf("decode typed data: %v", err)
+			}
+			if got := typedData.Message["taker"]; got != tt.wantTaker {
+				t.Errorf("signed taker = %v, want %s", got, tt.wantTaker)
+			}
+			if len(posted) != 1 || posted[0] != tt.wantTaker {
+				t.Errorf("posted takers = %v, want [%s]", posted, tt.wantTaker)
+			}
+			if placed.Signed.Taker != tt.wantTaker || store.created.Taker != tt.wantTaker || placed.Order.Taker != tt.wantTaker {
+				t.Errorf("taker returned %q, saved %q, recorded %q; want %s", placed.Signed.Taker, store.created.Taker, placed.Order.Taker, tt.wantTaker)
+			}
+		})
+	}
+}
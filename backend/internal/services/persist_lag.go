@@ -0,0 +1,166 @@
+/**
+ * @description
+ * This file implements the tracking of ingest-to-persist latency for OHLCV bars: how long
+ * after a bar's end time it was written to the database. A growing latency means the
+ * flush worker is falling behind and bars reach the charts late.
+ *
+ * Key features:
+ * - Histogram: Every saved bar's latency is counted in fixed cumulative buckets.
+ * - Cycle p95: The 95th percentile of the latencies observed during each flush cycle is
+ *   compared against a threshold.
+ * - Degradation Flag: The aggregator is lagging once the p95 has exceeded the threshold
+ *   for a number of consecutive cycles, and recovers on the first cycle within it.
+ *
+ * @notes
+ * - Flush cycles in which no bar was saved leave the flag unchanged.
+ */
+
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPersistLagThreshold is used when no latency threshold is configured.
+	defaultPersistLagThreshold = time.Minute
+	// defaultPersistLagCycles is used when no consecutive-cycle count is configured.
+	defaultPersistLagCycles = 3
+	// maxPersistLagSamples bounds the latencies kept for a single cycle's p95.
+	maxPersistLagSamples = 10000
+)
+
+// persistLagBuckets are the upper bounds of the latency histogram buckets.
+var persistLagBuckets = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	15 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+}
+
+// PersistLagPolicy configures when persistence latency degrades the aggregator.
+// Zero values use the defaults.
+type PersistLagPolicy struct {
+	Threshold time.Duration // Cycle p95 above which a cycle counts as lagging
+	Cycles    int           // Consecutive lagging cycles before the flag is raised
+}
+
+// LagBucket is a cumulative histogram bucket: the number of bars persisted within LE.
+type LagBucket struct {
+	LE    string `json:"le"` // Upper bound, or "+Inf"
+	Count int64  `json:"count"`
+}
+
+// PersistLagStats is a snapshot of the persistence latency.
+type PersistLagStats struct {
+	Histogram           []LagBucket `json:"histogram"`
+	Count               int64       `json:"count"`
+	SumSeconds          float64     `json:"sum_seconds"`
+	LastCycleP95        string      `json:"last_cycle_p95"`
+	Threshold           string      `json:"threshold"`
+	Cycles              int         `json:"cycles"`
+	ConsecutiveBreaches int         `json:"consecutive_breaches"`
+	Lagging             bool        `json:"lagging"`
+}
+
+// persistLagTracker records persistence latencies and evaluates them per flush cycle.
+type persistLagTracker struct {
+	policy PersistLagPolicy
+
+	mu           sync.Mutex
+	counts       []int64 // Per bucket, with a final +Inf bucket
+	count        int64
+	sum          time.Duration
+	cycleSamples []time.Duration
+	lastP95      time.Duration
+	breaches     int
+	lagging      bool
+}
+
+// newPersistLagTracker creates a tracker, applying the defaults to a zero policy.
+func newPersistLagTracker(policy PersistLagPolicy) *persistLagTracker {
+	if policy.Threshold <= 0 {
+		policy.Threshold = defaultPersistLagThreshold
+	}
+	if policy.Cycles <= 0 {
+		policy.Cycles = defaultPersistLagCycles
+	}
+	return &persistLagTracker{
+		policy: policy,
+		counts: make([]int64, len(persistLagBuckets)+1),
+	}
+}
+
+// Observe records the latency of a persisted bar.
+func (t *persistLagTracker) Observe(lag time.Duration) {
+	if lag < 0 {
+		lag = 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket := sort.Search(len(persistLagBuckets), func(i int) bool { return lag <= persistLagBuckets[i] })
+	t.counts[bucket]++
+	t.count++
+	t.sum += lag
+	if len(t.cycleSamples) < maxPersistLagSamples {
+		t.cycleSamples = append(t.cycleSamples, lag)
+	}
+}
+
+// EndCycle evaluates the latencies observed since the previous cycle and updates the
+// lagging flag. It returns whether the flag changed.
+func (t *persistLagTracker) EndCycle() (changed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.cycleSamples) == 0 {
+		return false
+	}
+	sort.Slice(t.cycleSamples, func(i, j int) bool { return t.cycleSamples[i] < t.cycleSamples[j] })
+	t.lastP95 = t.cycleSamples[(len(t.cycleSamples)*95+99)/100-1]
+	t.cycleSamples = t.cycleSamples[:0]
+
+	previous := t.lagging
+	if t.lastP95 > t.policy.Threshold {
+		t.breaches++
+		t.lagging = t.breaches >= t.policy.Cycles
+	} else {
+		t.breaches = 0
+		t.lagging = false
+	}
+	return t.lagging != previous
+}
+
+// Stats returns a snapshot of the latency histogram and the lagging state.
+func (t *persistLagTracker) Stats() PersistLagStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := PersistLagStats{
+		Histogram:           make([]LagBucket, 0, len(t.counts)),
+		Count:               t.count,
+		SumSeconds:          t.sum.Seconds(),
+		LastCycleP95:        t.lastP95.String(),
+		Threshold:           t.policy.Threshold.String(),
+		Cycles:              t.policy.Cycles,
+		ConsecutiveBreaches: t.breaches,
+		Lagging:             t.lagging,
+	}
+	var cumulative int64
+	for i, count := range t.counts {
+		cumulative += count
+		le := "+Inf"
+		if i < len(persistLagBuckets) {
+			le = persistLagBuckets[i].String()
+		}
+		stats.Histogram = append(stats.Histogram, LagBucket{LE: le, Count: cumulative})
+	}
+	return stats
+}
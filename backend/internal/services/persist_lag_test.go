@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestPersistLagHistogram(t *testing.T) {
+	tracker := newPersistLagTracker(PersistLagPolicy{})
+	for _, lag := range []time.Duration{-time.Second, 0, time.Second, 3 * time.Second, 45 * time.Second, time.Hour} {
+		tracker.Observe(lag)
+	}
+
+	stats := tracker.Stats()
+	// Buckets are cumulative; a negative lag counts as none, and an hour only fits +Inf.
+	want := map[string]int64{"1s": 3, "5s": 4, "15s": 4, "30s": 4, "1m0s": 5, "2m0s": 5, "5m0s": 5, "10m0s": 5, "+Inf": 6}
+	if len(stats.Histogram) != len(want) {
+		t.Fatalf("histogram has %d buckets, want %d", len(stats.Histogram), len(want))
+	}
+	for _, bucket := range stats.Histogram {
+		if bucket.Count != want[bucket.LE] {
+			t.Errorf("bucket %s = %d, want %d", bucket.LE, bucket.Count, want[bucket.LE])
+		}
+	}
+	if stats.Count != 6 || stats.SumSeconds != 3649 {
+		t.Errorf("count, sum = %d, %v; want 6, 3649", stats.Count, stats.SumSeconds)
+	}
+	if stats.Threshold != defaultPersistLagThreshold.String() || stats.Cycles != defaultPersistLagCycles {
+		t.Errorf("policy = %s over %d cycles, want the defaults", stats.Threshold, stats.Cycles)
+	}
+}
+
+// TestPersistLagCycleP95 checks that a cycle is judged by its 95th percentile, so that a
+// few slow saves among many fast ones do not count as lag.
+func TestPersistLagCycleP95(t *testing.T) {
+	tracker := newPersistLagTracker(PersistLagPolicy{Threshold: 10 * time.Second, Cycles: 1})
+	observe := func(fast, slow int) {
+		for i := 0; i < fast; i++ {
+			tracker.Observe(time.Second)
+		}
+		for i := 0; i < slow; i++ {
+			tracker.Observe(time.Minute)
+		}
+	}
+
+	observe(95, 5)
+	tracker.EndCycle()
+	if stats := tracker.Stats(); stats.LastCycleP95 != "1s" || stats.Lagging {
+		t.Errorf("5%% slow saves: p95 = %s, lagging = %v; want 1s, false", stats.LastCycleP95, stats.Lagging)
+	}
+	observe(94, 6)
+	tracker.EndCycle()
+	if stats := tracker.Stats(); stats.LastCycleP95 != "1m0s" || !stats.Lagging {
+		t.Errorf("6%% slow saves: p95 = %s, lagging = %v; want 1m0s, true", stats.LastCycleP95, stats.Lagging)
+	}
+}
+
+// TestPersistLagFlagToggles saves minute bars late on the aggregator's fake clock, and
+// checks that the flag is raised only after the configured number of lagging flush
+// cycles, survives an empty cycle, and clears on the first timely one.
+func TestPersistLagFlagToggles(t *testing.T) {
+	const marketID = "0xmarket"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	agg := NewOHLCVAggregator(context.Background(), logger, newBarStore(), 0, MidPriceFilter{}, PersistLagPolicy{Threshold: 30 * time.Second, Cycles: 2}, GapFillPolicy{}, nil, false)
+	agg.resolutions = []ResolutionDef{resolutionDef("1")}
+	start := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	agg.clock = func() time.Time { return now }
+
+	// flushAfter trades in the next minute bar, and flushes lag after the bar ends.
+	minute := 0
+	flushAfter := func(lag time.Duration) PersistLagStats {
+		t.Helper()
+		barStart := start.Add(time.Duration(minute) * time.Minute)
+		minute += 10
+		now = barStart.Add(10 * time.Second)
+		if err := agg.UpdateTrade(marketID, 0.5, 1, now); err != nil {
+			t.Fatalf("update trade: %v", err)
+		}
+		now = barStart.Add(time.Minute + lag)
+		agg.flushCompletedBars()
+		return agg.Stats().PersistLag
+	}
+
+	if stats := flushAfter(2 * time.Minute); stats.Lagging || stats.ConsecutiveBreaches != 1 {
+		t.Fatalf("after one late cycle: lagging = %v, breaches = %d; want false, 1", stats.Lagging, stats.ConsecutiveBreaches)
+	}
+	if stats := flushAfter(90 * time.Second); !stats.Lagging || stats.LastCycleP95 != "1m30s" {
+		t.Fatalf("after two late cycles: lagging = %v, p95 = %s; want true, 1m30s", stats.Lagging, stats.LastCycleP95)
+	}
+
+	// A cycle that saves nothing says nothing about the lag.
+	now = now.Add(time.Minute)
+	agg.flushCompletedBars()
+	if stats := agg.Stats().PersistLag; !stats.Lagging {
+		t.Fatal("an empty flush cycle cleared the flag")
+	}
+
+	if stats := flushAfter(2 * time.Second); stats.Lagging || stats.ConsecutiveBreaches != 0 {
+		t.Fatalf("after a timely cycle: lagging = %v, breaches = %d; want false, 0", stats.Lagging, stats.ConsecutiveBreaches)
+	}
+	if stats := flushAfter(time.Minute); stats.Lagging {
+		t.Error("a single late cycle after recovering raised the flag")
+	}
+	if stats := agg.Stats().PersistLag; stats.Count != 4 {
+		t.Errorf("histogram counts %d bars, want 4", stats.Count)
+	}
+}
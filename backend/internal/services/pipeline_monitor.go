@@ -0,0 +1,162 @@
+/**
+ * @description
+ * This file implements the PipelineMonitor, which detects the OHLCV pipeline silently
+ * stalling: the stream keeps receiving messages for subscribed markets, but no bars are
+ * saved (e.g. because every mid-price is filtered out).
+ *
+ * Key features:
+ * - Progress Tracking: Periodically samples the aggregator's saved-bar count and the
+ *   stream's processed-message count.
+ * - Stall Detection: The pipeline is degraded when clients are subscribed and messages keep
+ *   arriving, yet no bar has been saved for longer than the stall threshold.
+ * - Health Snapshot: `Health` reports the state for the readiness endpoint and diagnostics,
+ *   including a counter of detected stalls.
+ *
+ * @notes
+ * - A quiet market (no messages) or no subscribers is reported as idle, not degraded.
+ */
+
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPipelineStallThreshold is used when no stall threshold is configured.
+	defaultPipelineStallThreshold = 5 * time.Minute
+	// pipelineCheckInterval is how often the monitor samples the pipeline.
+	pipelineCheckInterval = 30 * time.Second
+)
+
+// Pipeline health states.
+const (
+	PipelineOK       = "ok"
+	PipelineIdle     = "idle"
+	PipelineDegraded = "degraded"
+)
+
+// PipelineHealth is a snapshot of the OHLCV pipeline's health.
+type PipelineHealth struct {
+	Status         string     `json:"status"`
+	BarsSaved      int64      `json:"bars_saved"`
+	LastBarSavedAt *time.Time `json:"last_bar_saved_at,omitempty"` // Last time the saved-bar count increased
+	StallThreshold string     `json:"stall_threshold"`
+	Stalls         int64      `json:"stalls"` // Number of times the pipeline became degraded
+}
+
+// PipelineMonitor watches the OHLCV pipeline for stalls.
+type PipelineMonitor struct {
+	ctx                 context.Context
+	logger              *slog.Logger
+	stream              *MarketStreamService
+	activeSubscriptions func() int
+	stallThreshold      time.Duration
+
+	mu             sync.Mutex
+	status         string
+	lastBarsSaved  int64
+	lastMessages   int64
+	lastProgressAt time.Time // Start of the current stall clock
+	lastBarSavedAt time.Time
+	stalls         int64
+}
+
+/**
+ * @description
+ * NewPipelineMonitor creates a new PipelineMonitor.
+ *
+ * @param ctx The root context; Run returns when it is cancelled.
+ * @param logger A structured logger.
+ * @param stream The market stream service whose aggregator is monitored.
+ * @param activeSubscriptions Returns the number of markets clients are subscribed to.
+ * @param stallThreshold How long bars may stall before the pipeline is degraded; 0 uses the default.
+ * @returns A pointer to a new PipelineMonitor instance.
+ */
+func NewPipelineMonitor(ctx context.Context, logger *slog.Logger, stream *MarketStreamService, activeSubscriptions func() int, stallThreshold time.Duration) *PipelineMonitor {
+	if stallThreshold <= 0 {
+		stallThreshold = defaultPipelineStallThreshold
+	}
+	return &PipelineMonitor{
+		ctx:                 ctx,
+		logger:              logger,
+		stream:              stream,
+		activeSubscriptions: activeSubscriptions,
+		stallThreshold:      stallThreshold,
+		status:              PipelineIdle,
+		lastProgressAt:      time.Now(),
+	}
+}
+
+// Run samples the pipeline periodically until the context is cancelled.
+// It should be started as a goroutine.
+func (m *PipelineMonitor) Run() {
+	ticker := time.NewTicker(pipelineCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(time.Now())
+		}
+	}
+}
+
+// check samples the pipeline counters and updates the health state.
+func (m *PipelineMonitor) check(now time.Time) {
+	barsSaved := m.stream.Aggregator().TotalBarsSaved()
+	messages := m.stream.MessagesProcessed()
+	subscriptions := m.activeSubscriptions()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messagesArriving := messages > m.lastMessages
+	m.lastMessages = messages
+
+	previous := m.status
+	switch {
+	case barsSaved > m.lastBarsSaved:
+		m.lastBarsSaved = barsSaved
+		m.lastProgressAt = now
+		m.lastBarSavedAt = now
+		m.status = PipelineOK
+	case subscriptions == 0 || !messagesArriving:
+		// Nothing is expected to be aggregated; restart the stall clock.
+		m.lastProgressAt = now
+		m.status = PipelineIdle
+	case now.Sub(m.lastProgressAt) > m.stallThreshold:
+		m.status = PipelineDegraded
+	}
+
+	if m.status == PipelineDegraded && previous != PipelineDegraded {
+		m.stalls++
+		m.logger.Error("OHLCV pipeline stalled: messages are arriving but no bars are being saved",
+			"stalled_for", now.Sub(m.lastProgressAt),
+			"subscribed_markets", subscriptions,
+			"bars_saved", barsSaved)
+	} else if previous == PipelineDegraded && m.status != PipelineDegraded {
+		m.logger.Info("OHLCV pipeline recovered", "status", m.status, "bars_saved", barsSaved)
+	}
+}
+
+// Health returns a snapshot of the pipeline's health.
+func (m *PipelineMonitor) Health() PipelineHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health := PipelineHealth{
+		Status:         m.status,
+		BarsSaved:      m.lastBarsSaved,
+		StallThreshold: m.stallThreshold.String(),
+		Stalls:         m.stalls,
+	}
+	if !m.lastBarSavedAt.IsZero() {
+		lastBarSavedAt := m.lastBarSavedAt.UTC()
+		health.LastBarSavedAt = &lastBarSavedAt
+	}
+	return health
+}
@@ -9,6 +9,8 @@
  *   typed data structure required by Polymarket's smart contracts.
  * - Secure Signing Flow: Coordinates with the `SignerClient` to get a valid signature
  *   for the constructed order from the isolated remote-signer service.
+ * - Order Cancellation: Cancels a user's resting orders on the CLOB and records the
+ *   cancellation locally.
  * - Abstraction: Hides the complexity of EIP-712 payload creation and the signing
  *   process from the API handlers.
  * - API Interaction (Future): This service will be expanded to include methods for
@@ -30,6 +32,9 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -38,45 +43,154 @@ import (
 	"github.com/poly-pro/backend/internal/config"
 	"github.com/poly-pro/backend/internal/polymarket"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/redis/go-redis/v9"
 )
 
+// defaultOrderType is the time-in-force used when submitting orders to the CLOB.
+const defaultOrderType = "GTC" // Good-Till-Cancelled
+
+// Local order statuses, stored in orders.status
+const (
+	// OrderStatusPending means the order was created and is being signed and submitted
+	OrderStatusPending = "pending"
+	// OrderStatusPendingSubmission means the signed order is queued for resubmission
+	OrderStatusPendingSubmission = "pending_submission"
+	// OrderStatusOpen means the order is resting on the book
+	OrderStatusOpen = "open"
+	// OrderStatusDelayed means the order is waiting out the market's matching delay
+	OrderStatusDelayed = "delayed"
+	// OrderStatusFilled means the order was matched
+	OrderStatusFilled = "filled"
+	// OrderStatusCancelled means the order was cancelled, or killed unmatched
+	OrderStatusCancelled = "cancelled"
+	// OrderStatusExpired means the order lapsed without being placed
+	OrderStatusExpired = "expired"
+	// OrderStatusRejected means the CLOB rejected the order, or its retries were exhausted
+	OrderStatusRejected = "rejected"
+)
+
+// ErrTradingNotConfigured is returned when an operation requires the authenticated CLOB client.
+var ErrTradingNotConfigured = errors.New("CLOB API credentials are not configured")
+
+// ErrUnsupportedSignatureType is returned when a wallet's signature type is not one the exchange supports.
+var ErrUnsupportedSignatureType = errors.New("unsupported wallet signature type")
+
+// ErrOrderNotFound is returned when an order does not exist or belongs to another user.
+var ErrOrderNotFound = errors.New("order not found")
+
+// ErrOrderNotCancellable is returned when an order is not resting on the CLOB, e.g. because
+// it was filled, has expired, or was never submitted.
+var ErrOrderNotCancellable = errors.New("order cannot be cancelled")
+
 // PlaceOrderParams defines the parameters for placing a new order.
 type PlaceOrderParams struct {
-	UserID        string
-	MarketID      string
-	TokenID       *big.Int
-	Price         float64 // The price of the order (0 to 1)
-	Size          float64 // The size/quantity of the order
-	Side          string  // "BUY" or "SELL"
-	SignatureType int
+	UserID   string
+	MarketID string
+	TokenID  *big.Int
+	Price    float64 // The price of the order (0 to 1)
+	Size     float64 // The size/quantity of the order
+	Side     string  // "BUY" or "SELL"
+	Taker    string  // Counterparty of a directed order; empty for a public order
+	// Optional client key; a retry with the same key returns the order already placed
+	IdempotencyKey string
+}
+
+// submissionRecordWarning is reported with an order whose record could not be updated with
+// the outcome of its submission, so that the returned record may be out of date.
+const submissionRecordWarning = "the order record could not be updated with the submission outcome and may be out of date"
+
+// cancellationRecordWarning is reported with an order cancelled on the CLOB whose record could
+// not be updated; the order sync moves it to 'cancelled' later.
+const cancellationRecordWarning = "the order was cancelled but its record could not be updated and may be out of date"
+
+// PlacedOrder is the outcome of CreateAndSignOrder.
+type PlacedOrder struct {
+	Signed  *polymarket.SignedOrder
+	Order   db.Order // The order record as stored after the submission
+	Warning string   // Set when the submission outcome could not be recorded
+	// The order was placed by an earlier request with the same idempotency key; Signed is nil
+	Replayed bool
 }
 
 // PolymarketService provides methods for interacting with Polymarket.
 type PolymarketService struct {
-	store        db.Querier
-	logger       *slog.Logger
-	signerClient SignerClient
-	clobClient   *polymarket.CLOBAPIClient
-	config       config.Config
+	store          db.Querier
+	logger         *slog.Logger
+	signerClient   SignerClient
+	clobClient     *polymarket.CLOBAPIClient
+	orderEvents    *OrderEventPublisher
+	retryQueue     *orderRetryQueue    // nil when submission retries are disabled
+	tradeParams    *TradingParamsCache // nil when the CLOB client is not configured
+	signingLimiter *signingLimiter     // nil when concurrent signings are unlimited
+	idempotency    *orderIdempotency   // nil without Redis
+	latency        *orderLatencyTracker
+	config         config.Config
+}
+
+// orderTimestamps are the lifecycle timestamps recorded with a submission outcome.
+// Zero values are not recorded.
+type orderTimestamps struct {
+	signedAt       time.Time // When the remote signer returned the signature
+	submittedAt    time.Time // When the submission was sent to the CLOB
+	acknowledgedAt time.Time // When the CLOB answered the submission
 }
 
 // NewPolymarketService creates a new instance of the PolymarketService.
-func NewPolymarketService(store db.Querier, logger *slog.Logger, signerClient SignerClient, cfg config.Config) *PolymarketService {
+// Order status changes are published as order_update events through redisClient.
+// Transient submission failures are queued for a retry when retries are configured.
+func NewPolymarketService(store db.Querier, logger *slog.Logger, signerClient SignerClient, redisClient *redis.Client, cfg config.Config) *PolymarketService {
 	// Initialize CLOB API client if credentials are provided
 	var clobClient *polymarket.CLOBAPIClient
+	var retryQueue *orderRetryQueue
+	var tradeParams *TradingParamsCache
 	if cfg.CLOBAPIKey != "" && cfg.CLOBAPISecret != "" && cfg.CLOBAPIPassphrase != "" {
 		clobClient = polymarket.NewCLOBAPIClient(cfg.CLOBAPIURL, cfg.CLOBAPIKey, cfg.CLOBAPISecret, cfg.CLOBAPIPassphrase, logger)
+		tradeParams = NewTradingParamsCache(redisClient, clobClient, logger)
+		if cfg.OrderRetryMaxAttempts > 0 {
+			retryQueue = newOrderRetryQueue(cfg.OrderRetryMaxAttempts, cfg.OrderRetryBackoff)
+		}
+	}
+	var limiter *signingLimiter
+	if cfg.SignerMaxConcurrent > 0 {
+		limiter = newSigningLimiter(cfg.SignerMaxConcurrent, cfg.SignerMaxQueued, cfg.SignerQueueTimeout)
+	}
+
+	var idempotency *orderIdempotency
+	if redisClient != nil {
+		idempotency = newOrderIdempotency(redisClient, cfg.OrderIdempotencyTTL, logger)
 	}
 
 	return &PolymarketService{
-		store:        store,
-		logger:       logger,
-		signerClient: signerClient,
-		clobClient:   clobClient,
-		config:       cfg,
+		store:          store,
+		logger:         logger,
+		signerClient:   signerClient,
+		clobClient:     clobClient,
+		orderEvents:    NewOrderEventPublisher(redisClient, logger),
+		retryQueue:     retryQueue,
+		tradeParams:    tradeParams,
+		signingLimiter: limiter,
+		idempotency:    idempotency,
+		latency:        newOrderLatencyTracker(),
+		config:         cfg,
 	}
 }
 
+// SetPublishBuffer sets the buffer that retries failed order_update publishes. It must be
+// called before orders are placed.
+func (s *PolymarketService) SetPublishBuffer(buffer *PublishBuffer) {
+	s.orderEvents.SetPublishBuffer(buffer)
+}
+
+// OrderLatencyStats returns a snapshot of the order lifecycle latency histograms.
+func (s *PolymarketService) OrderLatencyStats() OrderLatencyStats {
+	return s.latency.Stats()
+}
+
+// TradingEnabled reports whether the authenticated CLOB client is configured.
+func (s *PolymarketService) TradingEnabled() bool {
+	return s.clobClient != nil
+}
+
 /**
  * @description
  * CreateAndSignOrder constructs an EIP-712 compliant order, saves it to the database,
@@ -84,62 +198,96 @@ func NewPolymarketService(store db.Querier, logger *slog.Logger, signerClient Si
  *
  * @param ctx The context for the operation.
  * @param params The parameters for the order to be created.
- * @returns A pointer to the signed order.
- * @returns The database order record.
+ * @returns The signed order and its record as stored after the submission, with a warning
+ *   if the submission outcome could not be recorded.
  * @returns An error if any part of the process fails.
  */
-func (s *PolymarketService) CreateAndSignOrder(ctx context.Context, params PlaceOrderParams) (*polymarket.SignedOrder, db.Order, error) {
+func (s *PolymarketService) CreateAndSignOrder(ctx context.Context, params PlaceOrderParams) (PlacedOrder, error) {
 	s.logger.Info("creating and signing Polymarket order", "user_id", params.UserID, "side", params.Side)
+	if len(params.IdempotencyKey) > maxIdempotencyKeyLength {
+		return PlacedOrder{}, ErrInvalidIdempotencyKey
+	}
 
 	// 1. Fetch the user from the database using the Clerk ID to get the internal user ID.
 	user, err := s.store.GetUserByClerkID(ctx, params.UserID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			s.logger.Error("user not found in database", "clerk_id", params.UserID)
-			return nil, db.Order{}, errors.New("user not found")
+			return PlacedOrder{}, errors.New("user not found")
 		}
 		s.logger.Error("failed to get user from database", "error", err, "clerk_id", params.UserID)
-		return nil, db.Order{}, err
+		return PlacedOrder{}, err
+	}
+
+	// Return the order already placed with the same idempotency key, if any. Otherwise the
+	// key is claimed until the order is saved, and released if it never is.
+	var orderSaved bool
+	if params.IdempotencyKey != "" && s.idempotency != nil {
+		claimed, existingID, err := s.idempotency.claim(ctx, user.ID, params.IdempotencyKey)
+		if err != nil {
+			return PlacedOrder{}, err
+		}
+		if !claimed {
+			existing, err := s.store.GetOrderByID(ctx, existingID)
+			if err != nil {
+				s.logger.Error("failed to get order of idempotency key", "error", err, "order_id", existingID)
+				return PlacedOrder{}, err
+			}
+			s.logger.Info("order already placed with this idempotency key", "order_id", existing.ID, "user_id", user.ID)
+			return PlacedOrder{Order: existing, Replayed: true}, nil
+		}
+		defer func() {
+			if !orderSaved {
+				s.idempotency.release(context.WithoutCancel(ctx), user.ID, params.IdempotencyKey)
+			}
+		}()
 	}
 
 	// 2. Fetch the active wallet for the user to get the Polymarket funder address.
+	// Only wallets whose ownership has been verified are returned.
 	wallet, err := s.store.GetActiveWalletByUserID(ctx, user.ID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Error("active wallet not found for user", "user_id", user.ID)
-			return nil, db.Order{}, errors.New("user wallet not found")
+			s.logger.Error("active verified wallet not found for user", "user_id", user.ID)
+			return PlacedOrder{}, errors.New("user wallet not found")
 		}
 		s.logger.Error("failed to get wallet from database", "error", err, "user_id", user.ID)
-		return nil, db.Order{}, err
+		return PlacedOrder{}, err
 	}
 
 	makerAddress := wallet.PolymarketFunderAddress
 
+	// Orders are signed according to the wallet type (EOA, Polymarket proxy, or Gnosis Safe).
+	signatureType := int(wallet.SignatureType)
+	if !polymarket.ValidSignatureType(signatureType) {
+		s.logger.Error("wallet has an unsupported signature type", "wallet_id", wallet.ID, "signature_type", signatureType)
+		return PlacedOrder{}, fmt.Errorf("%w: %d", ErrUnsupportedSignatureType, signatureType)
+	}
+
+	taker, err := orderTaker(params.Taker)
+	if err != nil {
+		return PlacedOrder{}, err
+	}
+
+	// Reject prices that do not conform to the token's current tick size before signing.
+	if err := s.validatePrice(ctx, params.TokenID.String(), params.Price); err != nil {
+		return PlacedOrder{}, err
+	}
+
 	// 3. Convert price and size to their integer representations based on contract decimals.
 	// Polymarket uses 6 decimals for both USDC (makerAmount) and conditional tokens (takerAmount).
-	priceBI := new(big.Float).SetFloat64(params.Price)
-	sizeBI := new(big.Float).SetFloat64(params.Size)
-	decimals := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(6), nil))
-
-	var makerAmount, takerAmount *big.Int
-	var sideInt int
-
-	if params.Side == "BUY" {
-		sideInt = 0 // BUY side is 0 in the contract
-		// For BUY: takerAmount = size * 10^6, makerAmount = takerAmount * price
-		takerAmountFloat := new(big.Float).Mul(sizeBI, decimals)
-		makerAmountFloat := new(big.Float).Mul(takerAmountFloat, priceBI)
-
-		takerAmount, _ = takerAmountFloat.Int(nil)
-		makerAmount, _ = makerAmountFloat.Int(nil)
-	} else { // SELL
-		sideInt = 1 // SELL side is 1 in the contract
-		// For SELL: makerAmount = size * 10^6, takerAmount = makerAmount * price
-		makerAmountFloat := new(big.Float).Mul(sizeBI, decimals)
-		takerAmountFloat := new(big.Float).Mul(makerAmountFloat, priceBI)
+	price, size, err := orderDecimals(params.Price, params.Size)
+	if err != nil {
+		return PlacedOrder{}, err
+	}
+	makerAmount, takerAmount, err := orderAmounts(params.Side, price, size)
+	if err != nil {
+		return PlacedOrder{}, err
+	}
 
-		makerAmount, _ = makerAmountFloat.Int(nil)
-		takerAmount, _ = takerAmountFloat.Int(nil)
+	sideInt := 0 // BUY side is 0 in the contract
+	if params.Side != "BUY" {
+		sideInt = 1 // SELL side is 1 in the contract
 	}
 
 	// 4. Construct the order message for EIP-712 signing.
@@ -147,15 +295,15 @@ func (s *PolymarketService) CreateAndSignOrder(ctx context.Context, params Place
 		Salt:          big.NewInt(time.Now().UnixMilli()).String(),
 		Maker:         makerAddress,
 		Signer:        makerAddress, // In Polymarket's system, maker and signer are the same.
-		Taker:         "0x0000000000000000000000000000000000000000", // Public order
+		Taker:         taker,
 		TokenId:       params.TokenID.String(),
-		MakerAmount:   makerAmount.String(),
-		TakerAmount:   takerAmount.String(),
+		MakerAmount:   strconv.FormatInt(makerAmount.Micros(), 10),
+		TakerAmount:   strconv.FormatInt(takerAmount.Micros(), 10),
 		Expiration:    "0", // No expiration for GTC orders
 		Nonce:         "0", // Nonce for on-chain cancellation, can be managed later.
 		FeeRateBps:    "0", // Fee rate in basis points.
 		Side:          sideInt,
-		SignatureType: params.SignatureType,
+		SignatureType: signatureType,
 	}
 
 	// 5. Create the full EIP-712 typed data payload.
@@ -166,20 +314,32 @@ func (s *PolymarketService) CreateAndSignOrder(ctx context.Context, params Place
 		Message:     order.ToMessage(),
 	}
 
+	// Take a signing slot before the order is saved, so that an order rejected because the
+	// signer is busy leaves no record behind. The slot is held until the signature returns.
+	releaseSigningSlot := func() {}
+	if s.signingLimiter != nil {
+		if err := s.signingLimiter.acquire(ctx); err != nil {
+			s.logger.Warn("no signing slot available for order", "error", err, "user_id", user.ID)
+			return PlacedOrder{}, err
+		}
+		releaseSigningSlot = sync.OnceFunc(s.signingLimiter.release)
+	}
+	defer releaseSigningSlot()
+
 	// 6. Save the order to the database with status 'pending' before signing.
 	// We'll update it with the signed order JSON after signing.
 	
-	// Convert float64 to pgtype.Numeric for Size and Price
+	// Convert the decimals to pgtype.Numeric for Size and Price
 	sizeNumeric := pgtype.Numeric{}
-	if err := sizeNumeric.Scan(fmt.Sprintf("%.6f", params.Size)); err != nil {
+	if err := sizeNumeric.Scan(size.String()); err != nil {
 		s.logger.Error("failed to convert size to numeric", "error", err)
-		return nil, db.Order{}, fmt.Errorf("failed to convert size: %w", err)
+		return PlacedOrder{}, fmt.Errorf("failed to convert size: %w", err)
 	}
 	
 	priceNumeric := pgtype.Numeric{}
-	if err := priceNumeric.Scan(fmt.Sprintf("%.6f", params.Price)); err != nil {
+	if err := priceNumeric.Scan(price.String()); err != nil {
 		s.logger.Error("failed to convert price to numeric", "error", err)
-		return nil, db.Order{}, fmt.Errorf("failed to convert price: %w", err)
+		return PlacedOrder{}, fmt.Errorf("failed to convert price: %w", err)
 	}
 	
 	createOrderParams := db.CreateOrderParams{
@@ -189,32 +349,39 @@ func (s *PolymarketService) CreateAndSignOrder(ctx context.Context, params Place
 		Side:        params.Side,
 		Size:        sizeNumeric,
 		Price:       priceNumeric,
-		Status:      "pending",
+		Status:      OrderStatusPending,
 		SignedOrder: nil, // Will be updated after signing
+		Taker:       taker,
 	}
 	
 	dbOrder, err := s.store.CreateOrder(ctx, createOrderParams)
 	if err != nil {
 		s.logger.Error("failed to create order in database", "error", err, "user_id", user.ID)
-		return nil, db.Order{}, fmt.Errorf("failed to save order: %w", err)
+		return PlacedOrder{}, fmt.Errorf("failed to save order: %w", err)
 	}
 	s.logger.Info("order created in database", "order_id", dbOrder.ID, "user_id", user.ID)
+	if params.IdempotencyKey != "" && s.idempotency != nil {
+		orderSaved = true
+		s.idempotency.record(ctx, user.ID, params.IdempotencyKey, dbOrder.ID)
+	}
 
 	// 7. Marshal the typed data to a JSON string to send to the remote signer.
 	payloadJSON, err := json.Marshal(typedData)
 	if err != nil {
 		s.logger.Error("failed to marshal EIP-712 typed data", "error", err)
-		return nil, dbOrder, err
+		return PlacedOrder{Order: dbOrder}, err
 	}
 
 	// 8. Request the signature from the remote signer service.
 	// Use the internal user ID (UUID as string) for the signer service.
 	internalUserID := user.ID.String()
 	signature, err := s.signerClient.SignTransaction(ctx, internalUserID, string(payloadJSON))
+	releaseSigningSlot()
 	if err != nil {
 		s.logger.Error("failed to get signature from remote signer", "error", err)
-		return nil, dbOrder, err
+		return PlacedOrder{Order: dbOrder}, err
 	}
+	signedAt := time.Now()
 
 	// 9. Assemble the final signed order.
 	signedOrder := &polymarket.SignedOrder{
@@ -222,74 +389,444 @@ func (s *PolymarketService) CreateAndSignOrder(ctx context.Context, params Place
 		Signature: signature,
 	}
 
-	// 10. Marshal the signed order to JSONB and update the database record.
+	s.logger.Info("order successfully signed", "user_id", params.UserID, "order_id", dbOrder.ID, "signature", signedOrder.Signature)
+
+	// 10. Submit the order to Polymarket's CLOB API if CLOB client is configured. The
+	// submission outcome and the signed order are recorded in one update, and the returned
+	// record is the updated row.
+	placed := PlacedOrder{Signed: signedOrder, Order: dbOrder}
+	if s.clobClient != nil {
+		placed.Order, placed.Warning, err = s.submitOrder(ctx, dbOrder, signedOrder, makerAddress, signedAt)
+		if errors.Is(err, polymarket.ErrCLOBUnavailable) {
+			// The CLOB is temporarily unavailable: queue the signed order for a retry when
+			// retries are enabled, and reject it otherwise.
+			if s.retryQueue != nil {
+				var queued bool
+				if placed.Order, placed.Warning, queued = s.queueSubmission(ctx, placed.Order, signedOrder, makerAddress, signedAt); queued {
+					return placed, nil
+				}
+			}
+			placed.Order, placed.Warning = s.recordSubmission(ctx, placed.Order, OrderStatusRejected, "", signedOrder, makerAddress, orderTimestamps{signedAt: signedAt})
+		}
+		if err != nil {
+			s.logger.Error("failed to submit order to CLOB API", "error", err, "user_id", params.UserID, "order_id", placed.Order.ID)
+			return PlacedOrder{Order: placed.Order, Warning: placed.Warning}, err
+		}
+	}
+
+	return placed, nil
+}
+
+/**
+ * @description
+ * submitOrder posts a signed order to the CLOB and records the outcome on the local order.
+ * An order the CLOB rejects is moved to 'rejected'.
+ *
+ * @param ctx The context for the operation.
+ * @param dbOrder The local order record.
+ * @param signedOrder The signed order to submit.
+ * @param makerAddress The funder address of the order.
+ * @param signedAt When the order was signed; zero keeps the stored signed_at.
+ * @returns The updated order record.
+ * @returns A warning if the outcome could not be recorded (see recordSubmission).
+ * @returns An error if the submission failed. If it wraps polymarket.ErrCLOBUnavailable, the
+ *   failure is transient and the order is left unchanged for the caller to decide.
+ */
+func (s *PolymarketService) submitOrder(ctx context.Context, dbOrder db.Order, signedOrder *polymarket.SignedOrder, makerAddress string, signedAt time.Time) (db.Order, string, error) {
+	timestamps := orderTimestamps{signedAt: signedAt, submittedAt: time.Now()}
+	orderResp, err := s.clobClient.PostOrder(ctx, signedOrder, defaultOrderType)
+	if orderResp != nil {
+		// The CLOB answered, whether it accepted the order or not.
+		timestamps.acknowledgedAt = time.Now()
+	}
+	if errors.Is(err, polymarket.ErrOrderAlreadyExists) {
+		// The CLOB already has this order (e.g. a retried submission), so this is
+		// an idempotent success rather than a rejection.
+		dbOrder, warning := s.reconcileExistingOrder(ctx, dbOrder, orderResp, signedOrder, makerAddress, timestamps)
+		return dbOrder, warning, nil
+	}
+	if errors.Is(err, polymarket.ErrCLOBUnavailable) {
+		return dbOrder, "", err
+	}
+	if s.tradeParams != nil && orderResp != nil && !orderResp.Success && strings.Contains(strings.ToLower(orderResp.ErrorMsg), "tick size") {
+		// The tick size may have changed since it was cached; refresh it so that the
+		// next order is validated against the current one.
+		if _, err := s.tradeParams.Refresh(ctx, dbOrder.TokenID); err != nil {
+			s.logger.Warn("failed to refresh trading params after tick size rejection", "error", err, "token_id", dbOrder.TokenID)
+		}
+	}
+	if err != nil {
+		// Update order status to rejected if submission fails
+		dbOrder, warning := s.recordSubmission(ctx, dbOrder, OrderStatusRejected, "", signedOrder, makerAddress, timestamps)
+		return dbOrder, warning, fmt.Errorf("failed to submit order: %w", err)
+	}
+
+	if !orderResp.Success {
+		s.logger.Warn("order submission failed", "error_msg", orderResp.ErrorMsg, "status", orderResp.Status, "order_id", dbOrder.ID)
+		// Update order status to rejected
+		dbOrder, warning := s.recordSubmission(ctx, dbOrder, OrderStatusRejected, "", signedOrder, makerAddress, timestamps)
+		return dbOrder, warning, fmt.Errorf("order submission failed: %s", orderResp.ErrorMsg)
+	}
+
+	s.logger.Info("order successfully submitted to CLOB API", "polymarket_order_id", orderResp.OrderID, "status", orderResp.Status, "db_order_id", dbOrder.ID)
+
+	// Map the CLOB's placement status (live, matched, delayed, unmatched) to the local status.
+	// Delayed orders are resolved later by the OrderSyncService.
+	status := localOrderStatus(orderResp.Status, defaultOrderType)
+	dbOrder, warning := s.recordSubmission(ctx, dbOrder, status, orderResp.OrderID, signedOrder, makerAddress, timestamps)
+	return dbOrder, warning, nil
+}
+
+/**
+ * @description
+ * recordSubmission records the outcome of submitting an order in a single update: its local
+ * status, its Polymarket order ID, and the signed order. The updated row is returned, so the
+ * record reflects exactly what was stored. When the status changes, fills are recorded for
+ * filled orders and an order_update event is published, like in transitionOrder. The lifecycle
+ * steps completed by the update are observed in the latency histograms.
+ *
+ * @param ctx The context for the operation.
+ * @param dbOrder The local order record.
+ * @param status The new local status.
+ * @param polymarketOrderID The order ID assigned by the CLOB; empty keeps the stored one.
+ * @param signedOrder The submitted signed order.
+ * @param makerAddress The funder address used to fetch fills.
+ * @param timestamps The lifecycle timestamps to record; timestamps already stored are kept.
+ * @returns The updated order record.
+ * @returns A warning if the update failed, in which case the original record is returned.
+ */
+func (s *PolymarketService) recordSubmission(ctx context.Context, dbOrder db.Order, status string, polymarketOrderID string, signedOrder *polymarket.SignedOrder, makerAddress string, timestamps orderTimestamps) (db.Order, string) {
 	signedOrderJSON, err := json.Marshal(signedOrder)
 	if err != nil {
-		s.logger.Error("failed to marshal signed order", "error", err)
-		return nil, dbOrder, err
+		s.logger.Warn("failed to marshal signed order", "error", err, "order_id", dbOrder.ID)
+		signedOrderJSON = nil
 	}
 
-	// Store signed order JSON in database (we'll add an update query for this later)
-	// For now, the order is saved and we'll update it when we add the update query
-	s.logger.Info("signed order JSON prepared", "order_id", dbOrder.ID, "json_size", len(signedOrderJSON))
+	previousStatus := dbOrder.Status
+	updated, err := s.store.RecordOrderSubmission(ctx, db.RecordOrderSubmissionParams{
+		Status:            status,
+		PolymarketOrderID: pgtype.Text{String: polymarketOrderID, Valid: polymarketOrderID != ""},
+		SignedOrder:       signedOrderJSON,
+		SignedAt:          optionalTimestamp(timestamps.signedAt),
+		SubmittedAt:       optionalTimestamp(timestamps.submittedAt),
+		AcknowledgedAt:    optionalTimestamp(timestamps.acknowledgedAt),
+		ID:                dbOrder.ID,
+	})
+	if err != nil {
+		s.logger.Warn("failed to record order submission", "error", err, "order_id", dbOrder.ID, "status", status, "polymarket_order_id", polymarketOrderID)
+		return dbOrder, submissionRecordWarning
+	}
+	s.latency.Observe(dbOrder, updated)
 
-	s.logger.Info("order successfully signed", "user_id", params.UserID, "order_id", dbOrder.ID, "signature", signedOrder.Signature)
+	if previousStatus == status {
+		return updated, ""
+	}
+	s.logger.Info("order status changed", "order_id", updated.ID, "previous_status", previousStatus, "status", status)
 
-	// 11. Submit the order to Polymarket's CLOB API if CLOB client is configured
-	if s.clobClient != nil {
-		orderResp, err := s.clobClient.PostOrder(ctx, signedOrder, "GTC") // Default to Good-Till-Cancelled
+	if status == OrderStatusFilled {
+		s.recordFills(ctx, updated, makerAddress)
+	}
+	s.orderEvents.Publish(ctx, updated, previousStatus)
+	return updated, ""
+}
+
+// optionalTimestamp converts a time to a timestamp argument, NULL for the zero time.
+func optionalTimestamp(t time.Time) pgtype.Timestamptz {
+	if t.IsZero() {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: t.UTC(), Valid: true}
+}
+
+// validatePrice checks an order price against the token's cached tick size.
+// Validation is skipped when the tick size is unavailable; the CLOB then validates the price.
+func (s *PolymarketService) validatePrice(ctx context.Context, tokenID string, price float64) error {
+	if s.tradeParams == nil {
+		return nil
+	}
+	tradingParams, err := s.tradeParams.Get(ctx, tokenID)
+	if err != nil {
+		s.logger.Warn("failed to get trading params, skipping tick size validation", "error", err, "token_id", tokenID)
+		return nil
+	}
+	return validateOrderPrice(price, tradingParams.TickSize)
+}
+
+/**
+ * @description
+ * reconcileExistingOrder handles a PostOrder response indicating that the order
+ * already exists on the CLOB. It looks up the existing order and records its
+ * Polymarket order ID and a local status matching the CLOB's view. If the lookup fails,
+ * the order is recorded as 'pending' for the OrderSyncService to resolve.
+ *
+ * @param ctx The context for the operation.
+ * @param dbOrder The local order record.
+ * @param orderResp The PostOrder response (may carry the existing order ID).
+ * @param signedOrder The submitted signed order.
+ * @param makerAddress The funder address used to authenticate the lookup.
+ * @param timestamps The lifecycle timestamps of the submission.
+ * @returns The refreshed database order record.
+ * @returns A warning if the outcome could not be recorded (see recordSubmission).
+ */
+func (s *PolymarketService) reconcileExistingOrder(ctx context.Context, dbOrder db.Order, orderResp *polymarket.PostOrderResponse, signedOrder *polymarket.SignedOrder, makerAddress string, timestamps orderTimestamps) (db.Order, string) {
+	polymarketOrderID := ""
+	if orderResp != nil {
+		polymarketOrderID = orderResp.OrderID
+		if polymarketOrderID == "" && len(orderResp.OrderHashes) > 0 {
+			polymarketOrderID = orderResp.OrderHashes[0]
+		}
+	}
+
+	// The order stays 'pending' until the CLOB confirms its state: it is not assumed to be
+	// open, as it may since have been filled or cancelled. The OrderSyncService resolves
+	// pending orders that have a Polymarket order ID.
+	status := OrderStatusPending
+	if polymarketOrderID != "" {
+		existing, err := s.clobClient.GetOrder(ctx, polymarketOrderID, makerAddress)
 		if err != nil {
-			s.logger.Error("failed to submit order to CLOB API", "error", err, "user_id", params.UserID, "order_id", dbOrder.ID)
-			// Update order status to rejected if submission fails
-			s.store.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
-				ID:     dbOrder.ID,
-				Status: "rejected",
-			})
-			return nil, dbOrder, fmt.Errorf("failed to submit order: %w", err)
+			s.logger.Warn("failed to fetch existing order from CLOB API, leaving it pending", "error", err, "polymarket_order_id", polymarketOrderID, "order_id", dbOrder.ID)
+		} else {
+			status = localOrderStatus(existing.Status, existing.OrderType)
 		}
+	} else {
+		s.logger.Warn("duplicate order response did not include an order ID", "order_id", dbOrder.ID)
+	}
+
+	s.logger.Info("order already exists on CLOB, treating as idempotent success",
+		"polymarket_order_id", polymarketOrderID,
+		"status", status,
+		"db_order_id", dbOrder.ID)
+
+	return s.recordSubmission(ctx, dbOrder, status, polymarketOrderID, signedOrder, makerAddress, timestamps)
+}
+
+/**
+ * @description
+ * RefreshOrderStatus fetches an order's current state from the CLOB and applies
+ * any resulting status change locally. It is used by the OrderSyncService.
+ *
+ * @param ctx The context for the operation.
+ * @param dbOrder The local order record; it must have a Polymarket order ID.
+ * @param makerAddress The funder address used to authenticate the lookup.
+ * @returns The (possibly updated) order record, or an error if the CLOB lookup failed.
+ */
+func (s *PolymarketService) RefreshOrderStatus(ctx context.Context, dbOrder db.Order, makerAddress string) (db.Order, error) {
+	if s.clobClient == nil {
+		return dbOrder, ErrTradingNotConfigured
+	}
+	if !dbOrder.PolymarketOrderID.Valid {
+		return dbOrder, fmt.Errorf("order %s has no Polymarket order ID", dbOrder.ID.String())
+	}
+
+	clobOrder, err := s.clobClient.GetOrder(ctx, dbOrder.PolymarketOrderID.String, makerAddress)
+	if err != nil {
+		return dbOrder, fmt.Errorf("failed to fetch order from CLOB: %w", err)
+	}
+
+	return s.transitionOrder(ctx, dbOrder, localOrderStatus(clobOrder.Status, clobOrder.OrderType), makerAddress), nil
+}
+
+/**
+ * @description
+ * CancelOrder cancels a user's resting order on the CLOB and moves it to 'cancelled'.
+ * Cancelling an order that is already cancelled returns it unchanged.
+ *
+ * @param ctx The context for the operation.
+ * @param clerkUserID The Clerk ID of the user cancelling the order.
+ * @param orderID The local order ID.
+ * @returns The order record as stored after the cancellation.
+ * @returns A warning if the order was cancelled but its record could not be updated.
+ * @returns ErrOrderNotFound if the order does not exist or belongs to another user,
+ *   ErrOrderNotCancellable if it is not resting on the CLOB (including when the CLOB declines
+ *   the cancellation), or an error wrapping polymarket.ErrCLOBUnavailable if the CLOB could
+ *   not be reached.
+ */
+func (s *PolymarketService) CancelOrder(ctx context.Context, clerkUserID string, orderID pgtype.UUID) (db.Order, string, error) {
+	if s.clobClient == nil {
+		return db.Order{}, "", ErrTradingNotConfigured
+	}
 
-		if !orderResp.Success {
-			s.logger.Warn("order submission failed", "error_msg", orderResp.ErrorMsg, "status", orderResp.Status, "order_id", dbOrder.ID)
-			// Update order status to rejected
-			s.store.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
-				ID:     dbOrder.ID,
-				Status: "rejected",
-			})
-			return nil, dbOrder, fmt.Errorf("order submission failed: %s", orderResp.ErrorMsg)
+	user, err := s.store.GetUserByClerkID(ctx, clerkUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Order{}, "", ErrOrderNotFound
 		}
+		return db.Order{}, "", fmt.Errorf("failed to get user: %w", err)
+	}
 
-		s.logger.Info("order successfully submitted to CLOB API", "polymarket_order_id", orderResp.OrderID, "status", orderResp.Status, "db_order_id", dbOrder.ID)
-		
-		// Update the order with Polymarket order ID and status
-		polymarketOrderID := pgtype.Text{}
-		if err := polymarketOrderID.Scan(orderResp.OrderID); err != nil {
-			s.logger.Warn("failed to convert Polymarket order ID", "error", err, "order_id", dbOrder.ID)
-		} else {
-			_, err = s.store.UpdateOrderPolymarketID(ctx, db.UpdateOrderPolymarketIDParams{
-				ID:                dbOrder.ID,
-				PolymarketOrderID: polymarketOrderID,
-			})
-			if err != nil {
-				s.logger.Warn("failed to update order with Polymarket ID", "error", err, "order_id", dbOrder.ID)
-			}
+	dbOrder, err := s.store.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Order{}, "", ErrOrderNotFound
 		}
+		return db.Order{}, "", fmt.Errorf("failed to get order: %w", err)
+	}
+	// Another user's order is reported as missing, so that order IDs cannot be probed.
+	if dbOrder.UserID != user.ID {
+		s.logger.Warn("user attempted to cancel another user's order", "user_id", user.ID, "order_id", dbOrder.ID)
+		return db.Order{}, "", ErrOrderNotFound
+	}
 
-		// Update status to 'open' since it's been submitted
-		_, err = s.store.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
-			ID:     dbOrder.ID,
-			Status: "open",
-		})
-		if err != nil {
-			s.logger.Warn("failed to update order status to open", "error", err, "order_id", dbOrder.ID)
+	if dbOrder.Status == OrderStatusCancelled {
+		return dbOrder, "", nil
+	}
+	if (dbOrder.Status != OrderStatusOpen && dbOrder.Status != OrderStatusDelayed) || !dbOrder.PolymarketOrderID.Valid {
+		return dbOrder, "", fmt.Errorf("%w: order is %s", ErrOrderNotCancellable, dbOrder.Status)
+	}
+
+	// The order is cancelled with the same wallet the order sync looks it up with.
+	wallet, err := s.store.GetActiveWalletByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("active verified wallet not found for user", "user_id", user.ID)
+			return dbOrder, "", errors.New("user wallet not found")
 		}
+		return dbOrder, "", fmt.Errorf("failed to get wallet: %w", err)
+	}
+	makerAddress := wallet.PolymarketFunderAddress
 
-		// Refresh the order from database to get updated fields
-		dbOrder, err = s.store.GetOrderByID(ctx, dbOrder.ID)
-		if err != nil {
-			s.logger.Warn("failed to refresh order from database", "error", err, "order_id", dbOrder.ID)
+	_, err = s.clobClient.CancelOrder(ctx, dbOrder.PolymarketOrderID.String, makerAddress)
+	if errors.Is(err, polymarket.ErrOrderNotCanceled) {
+		// The order most likely left the book in the meantime; apply its current status.
+		refreshed, refreshErr := s.RefreshOrderStatus(ctx, dbOrder, makerAddress)
+		if refreshErr != nil {
+			s.logger.Warn("failed to refresh order after a declined cancellation", "error", refreshErr, "order_id", dbOrder.ID)
 		}
+		if refreshed.Status == OrderStatusCancelled {
+			return refreshed, "", nil
+		}
+		return refreshed, "", fmt.Errorf("%w: %w", ErrOrderNotCancellable, err)
+	}
+	if err != nil {
+		return dbOrder, "", fmt.Errorf("failed to cancel order on CLOB: %w", err)
+	}
+
+	updated := s.transitionOrder(ctx, dbOrder, OrderStatusCancelled, makerAddress)
+	if updated.Status != OrderStatusCancelled {
+		return updated, cancellationRecordWarning, nil
+	}
+	return updated, "", nil
+}
+
+/**
+ * @description
+ * transitionOrder moves an order to a new local status. When the status changes it
+ * records fills for filled orders and publishes an order_update event. The first status change
+ * of a submitted order is observed in the latency histograms.
+ *
+ * @param ctx The context for the operation.
+ * @param dbOrder The local order record.
+ * @param status The new local status.
+ * @param makerAddress The funder address used to fetch fills.
+ * @returns The updated order record, or the original record if the update failed.
+ */
+func (s *PolymarketService) transitionOrder(ctx context.Context, dbOrder db.Order, status string, makerAddress string) db.Order {
+	previousStatus := dbOrder.Status
+	if previousStatus == status {
+		return dbOrder
+	}
+
+	updated, err := s.store.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
+		ID:     dbOrder.ID,
+		Status: status,
+	})
+	if err != nil {
+		s.logger.Warn("failed to update order status", "error", err, "order_id", dbOrder.ID, "status", status)
+		return dbOrder
 	}
 
-	return signedOrder, dbOrder, nil
+	s.logger.Info("order status changed", "order_id", updated.ID, "previous_status", previousStatus, "status", status)
+	s.latency.Observe(dbOrder, updated)
+
+	if status == OrderStatusFilled {
+		s.recordFills(ctx, updated, makerAddress)
+	}
+	s.orderEvents.Publish(ctx, updated, previousStatus)
+	return updated
 }
 
+// recordFills fetches a filled order's fills from the CLOB and stores them as trades.
+// Failures are logged; fills are idempotent and can be recorded again by a later sync.
+func (s *PolymarketService) recordFills(ctx context.Context, dbOrder db.Order, makerAddress string) {
+	if s.clobClient == nil || !dbOrder.PolymarketOrderID.Valid {
+		return
+	}
+
+	fills, err := s.clobClient.GetOrderFills(ctx, dbOrder.PolymarketOrderID.String, dbOrder.TokenID, makerAddress)
+	if err != nil {
+		s.logger.Warn("failed to fetch order fills from CLOB API", "error", err, "order_id", dbOrder.ID)
+		return
+	}
+
+	for _, fill := range fills {
+		var size, price pgtype.Numeric
+		if err := size.Scan(fill.Size); err != nil {
+			s.logger.Warn("failed to parse fill size", "error", err, "trade_id", fill.TradeID)
+			continue
+		}
+		if err := price.Scan(fill.Price); err != nil {
+			s.logger.Warn("failed to parse fill price", "error", err, "trade_id", fill.TradeID)
+			continue
+		}
+		executedAt := time.Now()
+		if matchTime, err := strconv.ParseInt(fill.MatchTime, 10, 64); err == nil {
+			executedAt = time.Unix(matchTime, 0)
+		}
+		side := strings.ToUpper(fill.Side)
+		if side != "BUY" && side != "SELL" {
+			side = dbOrder.Side
+		}
+
+		if err := s.store.CreateTrade(ctx, db.CreateTradeParams{
+			UserID:            dbOrder.UserID,
+			OrderID:           dbOrder.ID,
+			MarketID:          dbOrder.MarketID,
+			PolymarketTradeID: pgtype.Text{String: fill.TradeID, Valid: true},
+			Side:              side,
+			Size:              size,
+			Price:             price,
+			ExecutedAt:        pgtype.Timestamptz{Time: executedAt.UTC(), Valid: true},
+		}); err != nil {
+			s.logger.Warn("failed to record order fill", "error", err, "order_id", dbOrder.ID, "trade_id", fill.TradeID)
+		}
+	}
+
+	s.logger.Info("recorded order fills", "order_id", dbOrder.ID, "fills", len(fills))
+}
+
+/**
+ * @description
+ * localOrderStatus maps a CLOB order status to the local orders.status value.
+ * It accepts both PostOrder placement statuses ("live", "matched", "delayed", "unmatched")
+ * and order lookup statuses ("LIVE", "MATCHED", "CANCELED", ...).
+ *
+ * @param clobStatus The status reported by the CLOB (case-insensitive).
+ * @param orderType The order's time-in-force (GTC, GTD, FOK, FAK).
+ * @returns The local status.
+ *
+ * @notes
+ * - "unmatched" means a marketable order could not be matched after the matching delay.
+ *   Fill-or-kill style orders (FOK/FAK) are killed and become 'cancelled'; resting orders
+ *   (GTC/GTD) lapse without being placed and become 'expired'.
+ */
+func localOrderStatus(clobStatus string, orderType string) string {
+	switch strings.ToLower(clobStatus) {
+	case polymarket.OrderStatusLive:
+		return OrderStatusOpen
+	case polymarket.OrderStatusMatched:
+		return OrderStatusFilled
+	case polymarket.OrderStatusDelayed:
+		return OrderStatusDelayed
+	case polymarket.OrderStatusUnmatched:
+		switch strings.ToUpper(orderType) {
+		case "FOK", "FAK":
+			return OrderStatusCancelled
+		default:
+			return OrderStatusExpired
+		}
+	case "canceled", "cancelled":
+		return OrderStatusCancelled
+	default:
+		return OrderStatusOpen
+	}
+}
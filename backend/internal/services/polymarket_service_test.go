@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// newTestPolymarketService creates a service whose CLOB client talks to clob and whose
+// orders are kept by store.
+func newTestPolymarketService(clob *httptest.Server, store db.Querier) *PolymarketService {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &PolymarketService{
+		store:       store,
+		logger:      logger,
+		clobClient:  polymarket.NewCLOBAPIClient(clob.URL, "key", "secret", "passphrase", logger),
+		orderEvents: NewOrderEventPublisher(nil, logger),
+		latency:     newOrderLatencyTracker(),
+	}
+}
+
+// TestReconcileExistingOrder submits an order the CLOB already has, and checks the status
+// recorded for each outcome of the lookup of the existing order.
+func TestReconcileExistingOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		postBody   string // Reply to the submission
+		lookupCode int    // Status of the lookup of the existing order; 0 if it must not happen
+		lookupBody string
+		wantStatus string
+		wantID     string
+	}{
+		{"existing order live", `{"success":false,"errorMsg":"INVALID_ORDER_DUPLICATED","orderId":"0xorder"}`,
+			http.StatusOK, `{"id":"0xorder","status":"LIVE","order_type":"GTC"}`, OrderStatusOpen, "0xorder"},
+		{"existing order cancelled", `{"success":false,"errorMsg":"INVALID_ORDER_DUPLICATED","orderHashes":["0xorder"]}`,
+			http.StatusOK, `{"id":"0xorder","status":"CANCELED","order_type":"GTC"}`, OrderStatusCancelled, "0xorder"},
+		// The order is not assumed to be open when the CLOB cannot confirm its state.
+		{"lookup fails", `{"success":false,"errorMsg":"INVALID_ORDER_DUPLICATED","orderId":"0xorder"}`,
+			http.StatusNotFound, `{"error":"not found"}`, OrderStatusPending, "0xorder"},
+		{"no order ID", `{"success":false,"errorMsg":"INVALID_ORDER_DUPLICATED"}`,
+			0, "", OrderStatusPending, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/order":
+					w.WriteHeader(http.StatusBadRequest)
+					io.WriteString(w, tt.postBody)
+				case "/data/order/0xorder":
+					if tt.lookupCode == 0 {
+						t.Error("existing order looked up without an order ID")
+					}
+					w.WriteHeader(tt.lookupCode)
+					io.WriteString(w, tt.lookupBody)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(clob.Close)
+			store := &orderStore{order: db.Order{Status: OrderStatusPending}}
+			service := newTestPolymarketService(clob, store)
+
+			order, warning, err := service.submitOrder(context.Background(), store.order, &polymarket.SignedOrder{}, "0xmaker", time.Now())
+			if err != nil || warning != "" {
+				t.Fatalf("submitOrder: %v, warning %q", err, warning)
+			}
+			if order.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", order.Status, tt.wantStatus)
+			}
+			if order.PolymarketOrderID.String != tt.wantID {
+				t.Errorf("polymarket order ID = %q, want %q", order.PolymarketOrderID.String, tt.wantID)
+			}
+		})
+	}
+}
+
+// TestPendingDuplicateIsResolvedBySync checks that a duplicate order left pending is moved to
+// the CLOB's status once the order sync can look it up.
+func TestPendingDuplicateIsResolvedBySync(t *testing.T) {
+	var available atomic.Bool
+	clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/order":
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"success":false,"errorMsg":"INVALID_ORDER_DUPLICATED","orderId":"0xorder"}`)
+		case r.URL.Path == "/data/order/0xorder" && available.Load():
+			io.WriteString(w, `{"id":"0xorder","status":"LIVE","order_type":"GTC"}`)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(clob.Close)
+	store := &orderStore{order: db.Order{Status: OrderStatusPendingSubmission}}
+	service := newTestPolymarketService(clob, store)
+	ctx := context.Background()
+
+	order, _, err := service.submitOrder(ctx, store.order, &polymarket.SignedOrder{}, "0xmaker", time.Now())
+	if err != nil {
+		t.Fatalf("submitOrder: %v", err)
+	}
+	if order.Status != OrderStatusPending || !order.PolymarketOrderID.Valid {
+		t.Fatalf("order = %s with ID %v, want pending with its Polymarket order ID", order.Status, order.PolymarketOrderID)
+	}
+
+	available.Store(true)
+	refreshed, err := service.RefreshOrderStatus(ctx, order, "0xmaker")
+	if err != nil {
+		t.Fatalf("RefreshOrderStatus: %v", err)
+	}
+	if refreshed.Status != OrderStatusOpen {
+		t.Errorf("status after sync = %s, want open", refreshed.Status)
+	}
+}
+
+func TestLocalOrderStatus(t *testing.T) {
+	tests := []struct {
+		clobStatus string
+		orderType  string
+		want       string
+	}{
+		// Placement statuses returned by PostOrder.
+		{polymarket.OrderStatusLive, "GTC", OrderStatusOpen},
+		{polymarket.OrderStatusMatched, "GTC", OrderStatusFilled},
+		{polymarket.OrderStatusDelayed, "GTC", OrderStatusDelayed},
+		{polymarket.OrderStatusDelayed, "FOK", OrderStatusDelayed},
+		{polymarket.OrderStatusUnmatched, "GTC", OrderStatusExpired},
+		{polymarket.OrderStatusUnmatched, "gtd", OrderStatusExpired},
+		{polymarket.OrderStatusUnmatched, "FOK", OrderStatusCancelled},
+		{polymarket.OrderStatusUnmatched, "fak", OrderStatusCancelled},
+		// Statuses returned by order lookups, in upper case.
+		{"LIVE", "GTC", OrderStatusOpen},
+		{"MATCHED", "GTC", OrderStatusFilled},
+		{"DELAYED", "GTC", OrderStatusDelayed},
+		{"UNMATCHED", "GTC", OrderStatusExpired},
+		{"CANCELED", "GTC", OrderStatusCancelled},
+		{"cancelled", "GTC", OrderStatusCancelled},
+		// An unknown status keeps the order open for the sync to resolve.
+		{"", "GTC", OrderStatusOpen},
+		{"PARTIALLY_FILLED", "GTC", OrderStatusOpen},
+	}
+	for _, tt := range tests {
+		if got := localOrderStatus(tt.clobStatus, tt.orderType); got != tt.want {
+			t.Errorf("localOrderStatus(%q, %q) = %s, want %s", tt.clobStatus, tt.orderType, got, tt.want)
+		}
+	}
+}
+
+// TestSubmitOrderPlacementStatus submits orders to a test CLOB replying with each placement
+// status, and checks the local status recorded and that only matched orders fetch fills.
+func TestSubmitOrderPlacementStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		postBody    string
+		wantStatus  string
+		wantFetched bool
+	}{
+		{"live", `{"success":true,"errorMsg":"","orderId":"0xorder","orderHashes":[],"status":"live"}`, OrderStatusOpen, false},
+		{"matched", `{"success":true,"errorMsg":"","orderId":"0xorder","orderHashes":["0xtx"],"status":"matched"}`, OrderStatusFilled, true},
+		{"delayed", `{"success":true,"errorMsg":"","orderId":"0xorder","orderHashes":[],"status":"delayed"}`, OrderStatusDelayed, false},
+		// Orders are submitted as GTC, so an unmatched order lapses rather than being killed.
+		{"unmatched", `{"success":true,"errorMsg":"","orderId":"0xorder","orderHashes":[],"status":"unmatched"}`, OrderStatusExpired, false},
+		{"rejected", `{"success":false,"errorMsg":"INVALID_ORDER_NOT_ENOUGH_BALANCE","orderId":"","status":""}`, OrderStatusRejected, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetchedFills atomic.Bool
+			clob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/order":
+					io.WriteString(w, tt.postBody)
+				case "/data/trades":
+					fetchedFills.Store(true)
+					io.WriteString(w, `{"data":[],"next_cursor":"LTE="}`)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(clob.Close)
+			store := &orderStore{order: db.Order{Status: OrderStatusPendingSubmission}}
+			service := newTestPolymarketService(clob, store)
+
+			order, _, err := service.submitOrder(context.Background(), store.order, &polymarket.SignedOrder{}, "0xmaker", time.Now())
+			if (err != nil) != (tt.wantStatus == OrderStatusRejected) {
+				t.Fatalf("submitOrder error = %v", err)
+			}
+			if order.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", order.Status, tt.wantStatus)
+			}
+			if fetchedFills.Load() != tt.wantFetched {
+				t.Errorf("fills fetched = %v, want %v", fetchedFills.Load(), tt.wantFetched)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+/**
+ * @description
+ * This file implements the PublishBuffer, which keeps the Redis Pub/Sub messages that failed
+ * to publish (e.g. while Redis is briefly unavailable) and publishes them again once Redis
+ * recovers, so that live viewers do not silently lose bar, order, and user events.
+ *
+ * Key features:
+ * - Bounded Rings: Failed messages are kept in a ring per channel, dropping the oldest when a
+ *   ring is full, and only for a bounded number of channels.
+ * - Message Classes: Ephemeral messages (order books, superseded by the next one) are only
+ *   replayed while younger than the ephemeral max age; durable messages (bars, order and
+ *   user events, market_meta) are replayed however old.
+ * - Replay Order: Channels whose latest failure is the most recent are replayed first, and
+ *   each channel's messages in their publish order. A replay pass stops at the first failure,
+ *   keeping the remaining messages for the next pass.
+ * - Metrics: Buffered, recovered, and dropped messages (by reason) are counted, and the
+ *   buffer's depth is reported per channel.
+ *
+ * @notes
+ * - Publish always tries Redis first, so a message published while older ones of its channel
+ *   are still buffered may overtake them. Durable payloads that must be applied in order
+ *   carry a sequence number (e.g. order_update's event_seq).
+ * - The buffer lives in memory: it survives a Redis outage, not a restart.
+ */
+
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// defaultPublishBufferSize is the number of messages kept per channel when none is configured.
+	defaultPublishBufferSize = 100
+	// defaultEphemeralMaxAge is how long ephemeral messages stay worth replaying when not configured.
+	defaultEphemeralMaxAge = 5 * time.Second
+	// maxPublishBufferChannels bounds the number of channels with buffered messages.
+	maxPublishBufferChannels = 10000
+	// publishRetryInterval is how often buffered messages are retried.
+	publishRetryInterval = time.Second
+)
+
+// PublishClass tells how a message is replayed after a failed publish.
+type PublishClass int
+
+const (
+	// PublishEphemeral messages are superseded by the next one and replayed only while fresh.
+	PublishEphemeral PublishClass = iota
+	// PublishDurable messages are events that are replayed however old.
+	PublishDurable
+)
+
+// Reasons reported in PublishBufferStats.Dropped.
+const (
+	dropReasonOverflow = "overflow"
+	dropReasonStale    = "stale"
+)
+
+// PublishBufferPolicy bounds the buffer.
+type PublishBufferPolicy struct {
+	Size            int           `json:"size"`              // Messages kept per channel; zero uses the default
+	EphemeralMaxAge time.Duration `json:"ephemeral_max_age"` // Age beyond which ephemeral messages are dropped; zero uses the default
+}
+
+// PublishBufferStats is a snapshot of the buffer's state and counters.
+type PublishBufferStats struct {
+	Policy    PublishBufferPolicy `json:"policy"`
+	Depth     int                 `json:"depth"` // Messages buffered
+	Channels  int                 `json:"channels"`
+	ByChannel map[string]int      `json:"by_channel"` // Channel -> messages buffered, largest first up to the sample limit
+	Truncated bool                `json:"truncated"`
+	Buffered  int64               `json:"buffered"`  // Failed publishes buffered since startup
+	Recovered int64               `json:"recovered"` // Buffered messages published since startup
+	Dropped   map[string]int64    `json:"dropped"`   // By reason
+}
+
+// redisPublisher publishes Pub/Sub messages; *redis.Client implements it.
+type redisPublisher interface {
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+}
+
+// bufferedMessage is a message that failed to publish.
+type bufferedMessage struct {
+	payload  []byte
+	class    PublishClass
+	failedAt time.Time
+}
+
+// PublishBuffer publishes to Redis, buffering failed publishes for a later retry.
+type PublishBuffer struct {
+	redisClient redisPublisher
+	logger      *slog.Logger
+	ctx         context.Context
+	policy      PublishBufferPolicy
+	clock       func() time.Time // Current time; replaced in tests
+
+	mu       sync.Mutex
+	channels map[string][]bufferedMessage // Oldest first
+	depth    int
+
+	buffered        atomic.Int64
+	recovered       atomic.Int64
+	droppedOverflow atomic.Int64
+	droppedStale    atomic.Int64
+}
+
+/**
+ * @description
+ * NewPublishBuffer creates a new PublishBuffer. Its retry loop must be started with Run.
+ *
+ * @param ctx The context that stops the retry loop.
+ * @param logger A structured logger.
+ * @param redisClient The Redis client to publish with.
+ * @param policy The buffer's bounds.
+ * @returns A pointer to a new PublishBuffer instance.
+ */
+func NewPublishBuffer(ctx context.Context, logger *slog.Logger, redisClient *redis.Client, policy PublishBufferPolicy) *PublishBuffer {
+	if policy.Size <= 0 {
+		policy.Size = defaultPublishBufferSize
+	}
+	if policy.EphemeralMaxAge <= 0 {
+		policy.EphemeralMaxAge = defaultEphemeralMaxAge
+	}
+	return &PublishBuffer{
+		redisClient: redisClient,
+		logger:      logger,
+		ctx:         ctx,
+		policy:      policy,
+		clock:       time.Now,
+		channels:    make(map[string][]bufferedMessage),
+	}
+}
+
+// publish publishes a message through a buffer, or directly when there is none.
+func publish(ctx context.Context, redisClient *redis.Client, buffer *PublishBuffer, channel string, payload []byte, class PublishClass) error {
+	if buffer != nil {
+		return buffer.Publish(ctx, channel, payload, class)
+	}
+	return redisClient.Publish(ctx, channel, payload).Err()
+}
+
+/**
+ * @description
+ * Publish publishes a message, buffering it for a retry if the publish fails.
+ *
+ * @param ctx The context for the Redis call; a message whose context is done is not buffered.
+ * @param channel The channel to publish on.
+ * @param payload The message.
+ * @param class How the message is replayed.
+ * @returns The error of the failed publish, after the message was buffered.
+ */
+func (b *PublishBuffer) Publish(ctx context.Context, channel string, payload []byte, class PublishClass) error {
+	err := b.redisClient.Publish(ctx, channel, payload).Err()
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	b.add(channel, bufferedMessage{payload: payload, class: class, failedAt: b.clock()})
+	return err
+}
+
+// add buffers a failed message, dropping the channel's oldest message if its ring is full.
+func (b *PublishBuffer) add(channel string, message bufferedMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	queue, ok := b.channels[channel]
+	if !ok && len(b.channels) >= maxPublishBufferChannels {
+		b.droppedOverflow.Add(1)
+		return
+	}
+	if len(queue) >= b.policy.Size {
+		queue = queue[1:]
+		b.depth--
+		b.droppedOverflow.Add(1)
+	}
+	b.channels[channel] = append(queue, message)
+	b.depth++
+	if b.buffered.Add(1)%100 == 1 {
+		b.logger.Warn("buffering failed redis publishes for a retry", "channel", channel, "depth", b.depth, "buffered_total", b.buffered.Load())
+	}
+}
+
+// Run retries the buffered messages periodically until the context is cancelled.
+// It should be started as a goroutine.
+func (b *PublishBuffer) Run() {
+	ticker := time.NewTicker(publishRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.replay(b.ctx)
+		}
+	}
+}
+
+/**
+ * @description
+ * Flush publishes the buffered messages once, e.g. on shutdown after the buffer's context
+ * was cancelled and its retry loop stopped.
+ *
+ * @param ctx Bounds the Redis calls; it must not be the cancelled root context.
+ * @returns The number of messages still buffered.
+ */
+func (b *PublishBuffer) Flush(ctx context.Context) int {
+	b.replay(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.depth
+}
+
+/**
+ * @description
+ * replay publishes the buffered messages, the channels with the most recent failure first,
+ * dropping the stale ephemeral ones. It stops at the first failure, putting the unpublished
+ * messages back ahead of the ones buffered in the meantime.
+ *
+ * @param ctx The context for the Redis calls.
+ */
+func (b *PublishBuffer) replay(ctx context.Context) {
+	b.mu.Lock()
+	if b.depth == 0 {
+		b.mu.Unlock()
+		return
+	}
+	pending := b.channels
+	b.channels = make(map[string][]bufferedMessage)
+	b.depth = 0
+	b.mu.Unlock()
+
+	order := make([]string, 0, len(pending))
+	for channel := range pending {
+		order = append(order, channel)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		last := func(channel string) time.Time { queue := pending[channel]; return queue[len(queue)-1].failedAt }
+		return last(order[i]).After(last(order[j]))
+	})
+
+	now := b.clock()
+	recovered, stale := 0, 0
+	var failure error
+	for _, channel := range order {
+		queue := pending[channel]
+		for len(queue) > 0 && failure == nil {
+			message := queue[0]
+			if message.class == PublishEphemeral && now.Sub(message.failedAt) > b.policy.EphemeralMaxAge {
+				stale++
+				queue = queue[1:]
+				continue
+			}
+			if err := b.redisClient.Publish(ctx, channel, message.payload).Err(); err != nil {
+				failure = err
+				break
+			}
+			recovered++
+			queue = queue[1:]
+		}
+		pending[channel] = queue
+	}
+
+	b.droppedStale.Add(int64(stale))
+	b.recovered.Add(int64(recovered))
+	remaining := b.restore(pending)
+
+	if recovered > 0 || stale > 0 {
+		b.logger.Info("replayed buffered redis publishes", "recovered", recovered, "stale_dropped", stale, "remaining", remaining)
+	}
+	if failure != nil && ctx.Err() == nil {
+		b.logger.Debug("redis still unavailable for buffered publishes", "error", failure, "remaining", remaining)
+	}
+}
+
+// restore puts unpublished messages back ahead of the ones buffered during the replay,
+// trimming each channel to the ring size. It returns the buffer's depth.
+func (b *PublishBuffer) restore(pending map[string][]bufferedMessage) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for channel, queue := range pending {
+		if len(queue) == 0 {
+			continue
+		}
+		merged := append(queue, b.channels[channel]...)
+		if excess := len(merged) - b.policy.Size; excess > 0 {
+			merged = merged[excess:]
+			b.droppedOverflow.Add(int64(excess))
+		}
+		b.depth += len(merged) - len(b.channels[channel])
+		b.channels[channel] = merged
+	}
+	return b.depth
+}
+
+/**
+ * @description
+ * Stats returns a snapshot of the buffer's state and counters.
+ *
+ * @param sampleLimit The maximum number of channels listed, those with the most messages;
+ * zero or less lists them all.
+ * @returns The buffer's stats.
+ */
+func (b *PublishBuffer) Stats(sampleLimit int) PublishBufferStats {
+	b.mu.Lock()
+	depths := make(map[string]int, len(b.channels))
+	for channel, queue := range b.channels {
+		depths[channel] = len(queue)
+	}
+	stats := PublishBufferStats{
+		Policy:    b.policy,
+		Depth:     b.depth,
+		Channels:  len(b.channels),
+		ByChannel: make(map[string]int),
+		Buffered:  b.buffered.Load(),
+		Recovered: b.recovered.Load(),
+		Dropped: map[string]int64{
+			dropReasonOverflow: b.droppedOverflow.Load(),
+			dropReasonStale:    b.droppedStale.Load(),
+		},
+	}
+	b.mu.Unlock()
+
+	channels := make([]string, 0, len(depths))
+	for channel := range depths {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if depths[channels[i]] != depths[channels[j]] {
+			return depths[channels[i]] > depths[channels[j]]
+		}
+		return channels[i] < channels[j]
+	})
+	for i, channel := range channels {
+		if sampleLimit > 0 && i >= sampleLimit {
+			stats.Truncated = true
+			break
+		}
+		stats.ByChannel[channel] = depths[channel]
+	}
+	return stats
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/redis/go-redis/v9"
+)
+
+// Channels of the messages published in the tests.
+var (
+	barChannel    = channels.OHLCVChannel("0xa", "1")
+	bookChannel   = channels.MarketChannel("0xb")
+	ordersChannel = channels.OrdersChannel("user_1")
+)
+
+// fakePublisher is a redisPublisher that records what it publishes, and fails like a Redis
+// client when the context is done, while down, or once it has published failAfter messages
+// (when positive). It may be published to concurrently.
+type fakePublisher struct {
+	mu        sync.Mutex
+	down      bool
+	failAfter int
+	published []string // "channel payload"
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cmd := redis.NewIntCmd(ctx)
+	if err := ctx.Err(); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if p.down || (p.failAfter > 0 && len(p.published) >= p.failAfter) {
+		cmd.SetErr(errors.New("dial tcp 127.0.0.1:6379: connect: connection refused"))
+		return cmd
+	}
+	p.published = append(p.published, fmt.Sprintf("%s %s", channel, message))
+	cmd.SetVal(1)
+	return cmd
+}
+
+// newTestPublishBuffer creates a buffer publishing with publisher, whose clock is at *now.
+func newTestPublishBuffer(publisher *fakePublisher, policy PublishBufferPolicy, now *time.Time) *PublishBuffer {
+	buffer := NewPublishBuffer(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), nil, policy)
+	buffer.redisClient = publisher
+	buffer.clock = func() time.Time { return *now }
+	return buffer
+}
+
+func TestPublishBufferBuffersDuringOutage(t *testing.T) {
+	publisher := &fakePublisher{down: true}
+	now := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	buffer := newTestPublishBuffer(publisher, PublishBufferPolicy{}, &now)
+
+	for i := 0; i < 3; i++ {
+		if err := buffer.Publish(context.Background(), barChannel, []byte(fmt.Sprintf("bar-%d", i)), PublishDurable); err == nil {
+			t.Fatal("publish during the outage reported no error")
+		}
+	}
+	if err := buffer.Publish(context.Background(), bookChannel, []byte("book"), PublishEphemeral); err == nil {
+		t.Fatal("publish during the outage reported no error")
+	}
+	// A publish whose context is done is abandoned rather than buffered.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	buffer.Publish(cancelled, bookChannel, []byte("abandoned"), PublishEphemeral)
+
+	// Retrying while Redis is still down keeps every message.
+	buffer.replay(context.Background())
+	stats := buffer.Stats(0)
+	if stats.Depth != 4 || stats.Buffered != 4 || stats.Recovered != 0 {
+		t.Errorf("depth, buffered, recovered = %d, %d, %d, want 4, 4, 0", stats.Depth, stats.Buffered, stats.Recovered)
+	}
+	if want := map[string]int{barChannel: 3, bookChannel: 1}; !reflect.DeepEqual(stats.ByChannel, want) {
+		t.Errorf("depth by channel = %v, want %v", stats.ByChannel, want)
+	}
+	if len(publisher.published) != 0 {
+		t.Errorf("published %v while Redis was down", publisher.published)
+	}
+}
+
+func TestPublishBufferDropsOldestWhenFull(t *testing.T) {
+	publisher := &fakePublisher{down: true}
+	now := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	buffer := newTestPublishBuffer(publisher, PublishBufferPolicy{Size: 3}, &now)
+
+	for i := 0; i < 5; i++ {
+		buffer.Publish(context.Background(), ordersChannel, []byte(fmt.Sprintf("event-%d", i)), PublishDurable)
+	}
+	stats := buffer.Stats(0)
+	if stats.Depth != 3 || stats.Dropped[dropReasonOverflow] != 2 {
+		t.Errorf("depth = %d, dropped = %v; want 3 and 2 overflows", stats.Depth, stats.Dropped)
+	}
+
+	publisher.down = false
+	buffer.replay(context.Background())
+	want := []string{ordersChannel + " event-2", ordersChannel + " event-3", ordersChannel + " event-4"}
+	if !reflect.DeepEqual(publisher.published, want) {
+		t.Errorf("replayed %v, want the 3 newest events %v", publisher.published, want)
+	}
+}
+
+func TestPublishBufferReplaysInOrderOnRecovery(t *testing.T) {
+	publisher := &fakePublisher{down: true}
+	now := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	buffer := newTestPublishBuffer(publisher, PublishBufferPolicy{EphemeralMaxAge: 5 * time.Second}, &now)
+	publishAt := func(offset time.Duration, channel, payload string, class PublishClass) {
+		now = time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC).Add(offset)
+		buffer.Publish(context.Background(), channel, []byte(payload), class)
+	}
+
+	publishAt(0, barChannel, "bar-1", PublishDurable)
+	publishAt(time.Second, bookChannel, "old-book", PublishEphemeral)
+	publishAt(2*time.Second, barChannel, "bar-2", PublishDurable)
+	publishAt(8*time.Second, bookChannel, "new-book", PublishEphemeral)
+	publishAt(9*time.Second, ordersChannel, "order-1", PublishDurable)
+
+	// Redis recovers 10s into the outage, but fails again after two publishes.
+	now = now.Add(time.Second)
+	publisher.down, publisher.failAfter = false, 2
+	buffer.replay(context.Background())
+	// The channel of the latest failure is replayed first. The old book is stale, the
+	// durable bars are not, however old.
+	want := []string{ordersChannel + " order-1", bookChannel + " new-book"}
+	if !reflect.DeepEqual(publisher.published, want) {
+		t.Fatalf("first replay published %v, want %v", publisher.published, want)
+	}
+	stats := buffer.Stats(0)
+	if stats.Recovered != 2 || stats.Dropped[dropReasonStale] != 1 || stats.Depth != 2 {
+		t.Errorf("recovered = %d, dropped = %v, depth = %d; want 2, 1 stale, 2", stats.Recovered, stats.Dropped, stats.Depth)
+	}
+
+	// A bar buffered between the passes is replayed after the ones still pending.
+	publisher.down = true
+	publishAt(11*time.Second, barChannel, "bar-3", PublishDurable)
+	publisher.down, publisher.failAfter = false, 0
+	buffer.replay(context.Background())
+	want = append(want, barChannel+" bar-1", barChannel+" bar-2", barChannel+" bar-3")
+	if !reflect.DeepEqual(publisher.published, want) {
+		t.Errorf("published %v, want %v", publisher.published, want)
+	}
+	if stats := buffer.Stats(0); stats.Depth != 0 || stats.Recovered != 5 {
+		t.Errorf("depth = %d, recovered = %d; want 0 and 5", stats.Depth, stats.Recovered)
+	}
+}
@@ -0,0 +1,168 @@
+/**
+ * @description
+ * This file is the single source of truth for the OHLCV bar resolutions: the aggregator
+ * produces bars for exactly the enabled resolutions, and the history, export, and admin
+ * endpoints accept exactly the same set, so that the two sides cannot drift.
+ *
+ * Key features:
+ * - Configuration: The enabled resolutions come from OHLCV_RESOLUTIONS and default to
+ *   `DefaultResolutions`.
+ * - Resolution Format: TradingView-style names, i.e. a number of minutes ("1", "240"),
+ *   "D" for one day, "W" for one week, or "M" for one calendar month. Minute resolutions
+ *   must divide a day, so bars align to UTC midnight.
+ * - Definitions: Each enabled resolution is a `ResolutionDef` holding its bar length and the
+ *   alignment of its bar boundaries. Its `Bucket` and `End` methods are the only bar-boundary
+ *   math, so a new resolution needs no changes to the bar-boundary helpers.
+ *
+ * @notes
+ * - ConfigureResolutions must be called before the services are started; the enabled set is
+ *   not changed afterwards.
+ * - Bar boundaries are whole multiples of the bar length since the Unix epoch in UTC, except
+ *   for weekly bars, which start on Monday 00:00 UTC (the epoch was a Thursday), and monthly
+ *   bars, which start on the 1st at 00:00 UTC and last as long as their month.
+ */
+
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DefaultResolutions are the resolutions produced when none are configured.
+var DefaultResolutions = []string{"1", "5", "15", "60", "D"}
+
+// ResolutionDef defines the bars of a resolution.
+type ResolutionDef struct {
+	Code     string        // TradingView-style name, e.g. "1", "240", "D", "W", or "M"
+	Duration time.Duration // Bar length; nominal (30 days) for monthly bars, whose length varies
+	Offset   time.Duration // Offset of the bar boundaries from the Unix epoch
+	Months   int           // Calendar months per bar; when set, Duration and Offset are not used for boundaries
+}
+
+// monthDuration is the nominal length of a monthly bar, used for range limits and ordering.
+const monthDuration = 30 * 24 * time.Hour
+
+// weekOffset aligns weekly bars to Monday: the Unix epoch was a Thursday.
+const weekOffset = 4 * 24 * time.Hour
+
+// enabledResolutions is the configured set, in configuration order.
+var enabledResolutions = mustResolutionDefs(DefaultResolutions)
+
+/**
+ * @description
+ * ConfigureResolutions sets the resolutions produced by the aggregator and accepted by the
+ * history endpoints.
+ *
+ * @param resolutions The resolutions to enable; empty keeps DefaultResolutions.
+ * @returns An error naming the first invalid or duplicate resolution.
+ */
+func ConfigureResolutions(resolutions []string) error {
+	if len(resolutions) == 0 {
+		enabledResolutions = mustResolutionDefs(DefaultResolutions)
+		return nil
+	}
+
+	defs := make([]ResolutionDef, 0, len(resolutions))
+	seen := make(map[string]bool, len(resolutions))
+	for _, resolution := range resolutions {
+		def, ok := parseResolution(resolution)
+		if !ok {
+			return fmt.Errorf("invalid resolution %q: must be a number of minutes dividing a day, D, W, or M", resolution)
+		}
+		if seen[resolution] {
+			return fmt.Errorf("duplicate resolution %q", resolution)
+		}
+		seen[resolution] = true
+		defs = append(defs, def)
+	}
+	enabledResolutions = defs
+	return nil
+}
+
+// mustResolutionDefs returns the definitions of valid resolution names.
+func mustResolutionDefs(resolutions []string) []ResolutionDef {
+	defs := make([]ResolutionDef, len(resolutions))
+	for i, resolution := range resolutions {
+		def, ok := parseResolution(resolution)
+		if !ok {
+			panic(fmt.Sprintf("invalid resolution %q", resolution))
+		}
+		defs[i] = def
+	}
+	return defs
+}
+
+// Resolutions returns the enabled resolutions.
+func Resolutions() []string {
+	codes := make([]string, len(enabledResolutions))
+	for i, def := range enabledResolutions {
+		codes[i] = def.Code
+	}
+	return codes
+}
+
+// ResolutionDuration returns the length of a bar of the given resolution,
+// and false if the resolution is not enabled.
+func ResolutionDuration(resolution string) (time.Duration, bool) {
+	for _, def := range enabledResolutions {
+		if def.Code == resolution {
+			return def.Duration, true
+		}
+	}
+	return 0, false
+}
+
+// BarStart returns the start of the bar of the given resolution containing timestamp, in UTC.
+func BarStart(timestamp time.Time, resolution string) time.Time {
+	return barStartTime(timestamp, resolution)
+}
+
+// parseResolution returns the definition of a resolution name, and false if it is invalid.
+func parseResolution(resolution string) (ResolutionDef, bool) {
+	switch resolution {
+	case "D":
+		return ResolutionDef{Code: resolution, Duration: 24 * time.Hour}, true
+	case "W":
+		return ResolutionDef{Code: resolution, Duration: 7 * 24 * time.Hour, Offset: weekOffset}, true
+	case "M":
+		return ResolutionDef{Code: resolution, Duration: monthDuration, Months: 1}, true
+	}
+	minutes, err := strconv.Atoi(resolution)
+	if err != nil || minutes <= 0 || (24*60)%minutes != 0 || strconv.Itoa(minutes) != resolution {
+		return ResolutionDef{}, false
+	}
+	return ResolutionDef{Code: resolution, Duration: time.Duration(minutes) * time.Minute}, true
+}
+
+// Bucket returns the start of the bar containing timestamp, in UTC.
+func (d ResolutionDef) Bucket(timestamp time.Time) time.Time {
+	if d.Months > 0 {
+		t := timestamp.UTC()
+		month := (int(t.Month()) - 1) / d.Months * d.Months
+		return time.Date(t.Year(), time.Month(month+1), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return BucketStart(timestamp.Add(-d.Offset), d.Duration).Add(d.Offset)
+}
+
+// End returns the end of the bar starting at startTime, i.e. the start of the next bar.
+func (d ResolutionDef) End(startTime time.Time) time.Time {
+	if d.Months > 0 {
+		return startTime.AddDate(0, d.Months, 0)
+	}
+	return startTime.Add(d.Duration)
+}
+
+// resolutionDef returns the definition of a resolution, defaulting to one-hour bars.
+func resolutionDef(resolution string) ResolutionDef {
+	if def, ok := parseResolution(resolution); ok {
+		return def
+	}
+	return ResolutionDef{Code: resolution, Duration: time.Hour}
+}
+
+// resolutionInterval returns the bar length of a resolution, defaulting to one hour.
+func resolutionInterval(resolution string) time.Duration {
+	return resolutionDef(resolution).Duration
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolutionBuckets(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	kolkata := time.FixedZone("IST", 5*3600+30*60)
+	utc := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t.Fatalf("parse %q: %v", value, err)
+		}
+		return parsed.UTC()
+	}
+
+	tests := []struct {
+		name       string
+		resolution string
+		timestamp  time.Time
+		wantStart  time.Time
+		wantEnd    time.Time
+	}{
+		// Weekly bars start on Monday 00:00 UTC.
+		{"week on Tuesday", "W", utc("2024-03-05T10:17:42Z"), utc("2024-03-04T00:00:00Z"), utc("2024-03-11T00:00:00Z")},
+		{"week on its Monday start", "W", utc("2024-03-04T00:00:00Z"), utc("2024-03-04T00:00:00Z"), utc("2024-03-11T00:00:00Z")},
+		{"week last nanosecond of Sunday", "W", utc("2024-03-03T23:59:59.999999999Z"), utc("2024-02-26T00:00:00Z"), utc("2024-03-04T00:00:00Z")},
+		{"week across a year", "W", utc("2025-01-01T12:00:00Z"), utc("2024-12-30T00:00:00Z"), utc("2025-01-06T00:00:00Z")},
+		{"week across a leap day", "W", utc("2024-02-29T12:00:00Z"), utc("2024-02-26T00:00:00Z"), utc("2024-03-04T00:00:00Z")},
+		{"week of the epoch", "W", utc("1970-01-01T00:00:00Z"), utc("1969-12-29T00:00:00Z"), utc("1970-01-05T00:00:00Z")},
+		{"week Monday in Kolkata, Sunday in UTC", "W", time.Date(2024, 3, 4, 0, 30, 0, 0, kolkata), utc("2024-02-26T00:00:00Z"), utc("2024-03-04T00:00:00Z")},
+		{"week Sunday in New York, Monday in UTC", "W", time.Date(2024, 3, 3, 20, 0, 0, 0, newYork), utc("2024-03-04T00:00:00Z"), utc("2024-03-11T00:00:00Z")},
+
+		// Monthly bars start on the 1st at 00:00 UTC and last as long as their month.
+		{"month inside", "M", utc("2024-03-15T10:00:00Z"), utc("2024-03-01T00:00:00Z"), utc("2024-04-01T00:00:00Z")},
+		{"month on its start", "M", utc("2024-03-01T00:00:00Z"), utc("2024-03-01T00:00:00Z"), utc("2024-04-01T00:00:00Z")},
+		{"month last nanosecond", "M", utc("2024-03-31T23:59:59.999999999Z"), utc("2024-03-01T00:00:00Z"), utc("2024-04-01T00:00:00Z")},
+		{"month of 30 days", "M", utc("2024-04-30T23:00:00Z"), utc("2024-04-01T00:00:00Z"), utc("2024-05-01T00:00:00Z")},
+		{"month across a year", "M", utc("2024-12-31T23:59:59Z"), utc("2024-12-01T00:00:00Z"), utc("2025-01-01T00:00:00Z")},
+		{"February of a leap year", "M", utc("2024-02-29T23:59:59Z"), utc("2024-02-01T00:00:00Z"), utc("2024-03-01T00:00:00Z")},
+		{"February of a common year", "M", utc("2023-02-28T23:59:59Z"), utc("2023-02-01T00:00:00Z"), utc("2023-03-01T00:00:00Z")},
+		{"February of a leap century", "M", utc("2000-02-29T12:00:00Z"), utc("2000-02-01T00:00:00Z"), utc("2000-03-01T00:00:00Z")},
+		{"February of a common century", "M", utc("2100-02-28T12:00:00Z"), utc("2100-02-01T00:00:00Z"), utc("2100-03-01T00:00:00Z")},
+		{"month 1st in Kolkata, last day in UTC", "M", time.Date(2024, 3, 1, 1, 0, 0, 0, kolkata), utc("2024-02-01T00:00:00Z"), utc("2024-03-01T00:00:00Z")},
+		{"month last day in New York, 1st in UTC", "M", time.Date(2024, 1, 31, 20, 0, 0, 0, newYork), utc("2024-02-01T00:00:00Z"), utc("2024-03-01T00:00:00Z")},
+
+		// Daily bars start at 00:00 UTC, whatever the local day of the timestamp.
+		{"day inside", "D", utc("2024-03-05T10:17:42Z"), utc("2024-03-05T00:00:00Z"), utc("2024-03-06T00:00:00Z")},
+		{"day on its midnight start", "D", utc("2024-03-05T00:00:00Z"), utc("2024-03-05T00:00:00Z"), utc("2024-03-06T00:00:00Z")},
+		{"day last nanosecond before midnight", "D", utc("2024-03-05T23:59:59.999999999Z"), utc("2024-03-05T00:00:00Z"), utc("2024-03-06T00:00:00Z")},
+		{"day across a year", "D", utc("2024-12-31T23:59:59Z"), utc("2024-12-31T00:00:00Z"), utc("2025-01-01T00:00:00Z")},
+		{"day before the epoch", "D", utc("1969-12-31T12:00:00Z"), utc("1969-12-31T00:00:00Z"), utc("1970-01-01T00:00:00Z")},
+		{"day next in Kolkata, previous in UTC", "D", time.Date(2024, 3, 6, 2, 0, 0, 0, kolkata), utc("2024-03-05T00:00:00Z"), utc("2024-03-06T00:00:00Z")},
+		{"day previous in New York, next in UTC", "D", time.Date(2024, 3, 5, 21, 0, 0, 0, newYork), utc("2024-03-06T00:00:00Z"), utc("2024-03-07T00:00:00Z")},
+		{"day of a DST change in New York", "D", time.Date(2024, 3, 10, 3, 30, 0, 0, newYork), utc("2024-03-10T00:00:00Z"), utc("2024-03-11T00:00:00Z")},
+
+		// Daily and minute bars align to the epoch, whatever the month.
+		{"day of a leap day", "D", utc("2024-02-29T23:59:59Z"), utc("2024-02-29T00:00:00Z"), utc("2024-03-01T00:00:00Z")},
+		{"4 hours across a month", "240", utc("2024-01-31T23:00:00Z"), utc("2024-01-31T20:00:00Z"), utc("2024-02-01T00:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, ok := parseResolution(tt.resolution)
+			if !ok {
+				t.Fatalf("parseResolution(%q) failed", tt.resolution)
+			}
+			start := def.Bucket(tt.timestamp)
+			if !start.Equal(tt.wantStart) || start.Location() != time.UTC {
+				t.Errorf("Bucket(%s) = %s, want %s", tt.timestamp, start, tt.wantStart)
+			}
+			if end := def.End(start); !end.Equal(tt.wantEnd) {
+				t.Errorf("End(%s) = %s, want %s", start, end, tt.wantEnd)
+			}
+			if tt.resolution == "W" && start.Weekday() != time.Monday {
+				t.Errorf("weekly bar starts on %s", start.Weekday())
+			}
+			if tt.resolution == "D" && (start.Hour() != 0 || start.Minute() != 0 || start.Second() != 0 || start.Nanosecond() != 0) {
+				t.Errorf("daily bar starts at %s, not UTC midnight", start.Format("15:04:05.999999999"))
+			}
+		})
+	}
+}
+
+func TestParseResolution(t *testing.T) {
+	for resolution, want := range map[string]bool{
+		"1": true, "5": true, "240": true, "1440": true, "D": true, "W": true, "M": true,
+		"0": false, "-5": false, "7": false, "05": false, "2880": false, "1D": false, "m": false, "": false,
+	} {
+		if _, ok := parseResolution(resolution); ok != want {
+			t.Errorf("parseResolution(%q) = %v, want %v", resolution, ok, want)
+		}
+	}
+}
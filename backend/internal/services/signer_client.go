@@ -23,13 +23,26 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"github.com/poly-pro/backend/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
+const (
+	// signerModeHeader is the response header the remote signer sets on simulated signatures.
+	signerModeHeader = "x-signer-mode"
+	// signerModeSimulate is the header value marking a simulated signature.
+	signerModeSimulate = "simulate"
+)
+
+// ErrSimulatedSignature is returned when the remote signer returns a simulated signature
+// and simulated signatures are not allowed.
+var ErrSimulatedSignature = errors.New("remote signer returned a simulated signature")
+
 // SignerClient provides an interface for communicating with the remote signer service.
 type SignerClient interface {
 	SignTransaction(ctx context.Context, userID, payloadJSON string) (string, error)
@@ -41,6 +54,8 @@ type grpcSignerClient struct {
 	conn   *grpc.ClientConn
 	client proto.SignerClient
 	logger *slog.Logger
+	// allowSimulated accepts simulated signatures from a signer in simulate mode
+	allowSimulated bool
 }
 
 /**
@@ -49,14 +64,18 @@ type grpcSignerClient struct {
  *
  * @param address The network address of the remote-signer service (e.g., "localhost:8081").
  * @param logger A structured logger.
+ * @param allowSimulated Whether simulated signatures are accepted (staging load tests only).
  * @returns A SignerClient interface and an error if the connection fails.
  *
  * @notes
  * - For local development, it uses an insecure connection. In production, this
  *   MUST be configured with TLS credentials.
  */
-func NewSignerClient(address string, logger *slog.Logger) (SignerClient, error) {
+func NewSignerClient(address string, logger *slog.Logger, allowSimulated bool) (SignerClient, error) {
 	logger.Info("connecting to remote signer service", "address", address)
+	if allowSimulated {
+		logger.Warn("simulated signatures from the remote signer are allowed; not for production use")
+	}
 
 	// In a production environment, you would use grpc.WithTransportCredentials()
 	// to establish a secure TLS connection.
@@ -68,9 +87,10 @@ func NewSignerClient(address string, logger *slog.Logger) (SignerClient, error)
 
 	client := proto.NewSignerClient(conn)
 	return &grpcSignerClient{
-		conn:   conn,
-		client: client,
-		logger: logger,
+		conn:           conn,
+		client:         client,
+		logger:         logger,
+		allowSimulated: allowSimulated,
 	}, nil
 }
 
@@ -83,7 +103,8 @@ func NewSignerClient(address string, logger *slog.Logger) (SignerClient, error)
  * @param userID The ID of the user for whom the transaction is being signed.
  * @param payloadJSON The EIP-712 payload as a JSON string.
  * @returns The signature as a hexadecimal string.
- * @returns An error if the RPC call fails.
+ * @returns An error if the RPC call fails, or ErrSimulatedSignature if the signer is in
+ *   simulate mode and simulated signatures are not allowed.
  */
 func (c *grpcSignerClient) SignTransaction(ctx context.Context, userID, payloadJSON string) (string, error) {
 	req := &proto.SignRequest{
@@ -92,12 +113,23 @@ func (c *grpcSignerClient) SignTransaction(ctx context.Context, userID, payloadJ
 	}
 
 	c.logger.Info("sending sign request to remote signer", "user_id", userID)
-	resp, err := c.client.SignTransaction(ctx, req)
+	var header metadata.MD
+	resp, err := c.client.SignTransaction(ctx, req, grpc.Header(&header))
 	if err != nil {
 		c.logger.Error("remote signer returned an error", "error", err, "user_id", userID)
 		return "", err
 	}
 
+	// A signer in simulate mode flags its fake signatures, which must never reach the CLOB
+	// unless simulation is explicitly allowed.
+	if mode := header.Get(signerModeHeader); len(mode) > 0 && mode[0] == signerModeSimulate {
+		if !c.allowSimulated {
+			c.logger.Error("rejecting simulated signature from remote signer", "user_id", userID)
+			return "", ErrSimulatedSignature
+		}
+		c.logger.Warn("using simulated signature from remote signer", "user_id", userID)
+	}
+
 	return resp.Signature, nil
 }
 
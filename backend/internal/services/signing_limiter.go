@@ -0,0 +1,145 @@
+/**
+ * @description
+ * This file implements the concurrency limit on order signing. Every order is signed by the
+ * remote signer, and a burst of orders would otherwise send all of them to it at once.
+ *
+ * Key features:
+ * - Semaphore: At most `SignerMaxConcurrent` orders hold a signing slot at the same time.
+ * - Short Queue: Orders beyond the limit wait up to `SignerQueueTimeout` for a slot, with at
+ *   most `SignerMaxQueued` orders waiting; other orders are rejected with ErrSignerBusy, which
+ *   the API reports as 429 Too Many Requests.
+ * - Metrics: The in-flight and waiting signings and the rejections are exposed by SigningStats
+ *   on the diagnostics endpoint.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultSignerQueueTimeout is used when no queue timeout is configured.
+const defaultSignerQueueTimeout = 2 * time.Second
+
+// ErrSignerBusy is returned when an order cannot get a signing slot in time.
+var ErrSignerBusy = errors.New("too many orders are being signed, please retry shortly")
+
+// SigningStats is a snapshot of the order signing concurrency limit.
+type SigningStats struct {
+	Enabled       bool  `json:"enabled"`
+	MaxConcurrent int   `json:"max_concurrent"`
+	MaxQueued     int   `json:"max_queued"`
+	InFlight      int   `json:"in_flight"` // Orders holding a signing slot
+	Waiting       int   `json:"waiting"`   // Orders waiting for a signing slot
+	Signed        int64 `json:"signed"`    // Orders granted a signing slot
+	Rejected      int64 `json:"rejected"`  // Orders rejected because no slot became free
+}
+
+// signingLimiter bounds the number of concurrent order signings.
+type signingLimiter struct {
+	slots        chan struct{}
+	maxQueued    int
+	queueTimeout time.Duration
+
+	mu       sync.Mutex
+	waiting  int
+	signed   int64
+	rejected int64
+}
+
+// newSigningLimiter creates a limiter allowing maxConcurrent signings, with up to maxQueued
+// orders waiting at most queueTimeout for a slot.
+func newSigningLimiter(maxConcurrent, maxQueued int, queueTimeout time.Duration) *signingLimiter {
+	if maxQueued <= 0 {
+		maxQueued = maxConcurrent
+	}
+	if queueTimeout <= 0 {
+		queueTimeout = defaultSignerQueueTimeout
+	}
+	return &signingLimiter{
+		slots:        make(chan struct{}, maxConcurrent),
+		maxQueued:    maxQueued,
+		queueTimeout: queueTimeout,
+	}
+}
+
+// acquire takes a signing slot, waiting briefly when none is free. It returns ErrSignerBusy
+// when the queue is full or the wait times out. Every successful acquire must be released.
+func (l *signingLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		l.recordSigned()
+		return nil
+	default:
+	}
+
+	l.mu.Lock()
+	if l.waiting >= l.maxQueued {
+		l.rejected++
+		l.mu.Unlock()
+		return ErrSignerBusy
+	}
+	l.waiting++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case l.slots <- struct{}{}:
+	case <-timer.C:
+		err = ErrSignerBusy
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting--
+	switch {
+	case err == nil:
+		l.signed++
+	case errors.Is(err, ErrSignerBusy):
+		l.rejected++
+	}
+	return err
+}
+
+// recordSigned counts an order granted a slot without waiting.
+func (l *signingLimiter) recordSigned() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.signed++
+}
+
+// release frees a slot taken by acquire.
+func (l *signingLimiter) release() {
+	<-l.slots
+}
+
+// stats returns a snapshot of the limiter.
+func (l *signingLimiter) stats() SigningStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return SigningStats{
+		Enabled:       true,
+		MaxConcurrent: cap(l.slots),
+		MaxQueued:     l.maxQueued,
+		InFlight:      len(l.slots),
+		Waiting:       l.waiting,
+		Signed:        l.signed,
+		Rejected:      l.rejected,
+	}
+}
+
+// SigningStats returns a snapshot of the order signing concurrency limit.
+func (s *PolymarketService) SigningStats() SigningStats {
+	if s.signingLimiter == nil {
+		return SigningStats{}
+	}
+	return s.signingLimiter.stats()
+}
@@ -0,0 +1,447 @@
+/**
+ * @description
+ * This file implements the subscription budget of the market stream: which markets' assets
+ * are subscribed on the CLOB WebSocket when not every market fits on one connection.
+ *
+ * Key features:
+ * - Budget: At most `MAX_STREAM_ASSETS` assets (outcome tokens) are subscribed; 0 means
+ *   unlimited. A market is allocated all of its assets or none.
+ * - Priority Tiers: Markets are allocated in tier order: pinned (featured markets and
+ *   markets added via AddMarketAssets), then markets clients are subscribed to through the
+ *   hub, then markets with known liquidity (highest first), then the rest in Gamma's order.
+ * - Incremental Diffs: A rebalance unsubscribes and subscribes only the assets whose
+ *   allocation changed, instead of resubscribing everything.
+ * - Refresh: The allocation is recomputed periodically, so that hub demand is picked up,
+ *   and the market catalog is re-fetched from Gamma less often.
+ * - Token Recovery: Gamma markets without token IDs are looked up on the CLOB by condition
+ *   ID (at most `streamTokenRecoveryLimit` lookups per catalog fetch), instead of being
+ *   skipped.
+ * - Diagnostics: The current allocation, including evicted markets and their tiers, is
+ *   exposed through Stats() for the debug endpoint.
+ */
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+const (
+	// streamRebalanceInterval is how often the allocation is recomputed.
+	streamRebalanceInterval = 30 * time.Second
+	// streamCatalogRefreshInterval is how often the active markets are re-fetched from Gamma.
+	streamCatalogRefreshInterval = 5 * time.Minute
+	// defaultStreamCatalogTTL is the catalog age after which a reconnect re-fetches it, used
+	// when none is configured.
+	defaultStreamCatalogTTL = 15 * time.Minute
+	// streamCatalogMarketLimit is the number of active markets fetched from Gamma.
+	streamCatalogMarketLimit = 100
+	// streamTokenRecoveryLimit is the maximum number of CLOB lookups per catalog fetch for
+	// markets that Gamma returned without token IDs.
+	streamTokenRecoveryLimit = 20
+)
+
+// Stream allocation tiers, in priority order.
+const (
+	StreamTierPinned    = "pinned"
+	StreamTierDemanded  = "demanded"
+	StreamTierLiquidity = "liquidity"
+	StreamTierRest      = "rest"
+)
+
+// streamTierRank orders the tiers; lower ranks are allocated first.
+var streamTierRank = map[string]int{
+	StreamTierPinned:    0,
+	StreamTierDemanded:  1,
+	StreamTierLiquidity: 2,
+	StreamTierRest:      3,
+}
+
+// streamMarket is a market the stream can subscribe to.
+type streamMarket struct {
+	ConditionID string
+	TokenIDs    []string
+	Liquidity   float64 // 0 if unknown
+}
+
+// MarketAllocation describes a market's place in the subscription budget.
+type MarketAllocation struct {
+	ConditionID string  `json:"condition_id"`
+	Tier        string  `json:"tier"`
+	Assets      int     `json:"assets"`
+	Liquidity   float64 `json:"liquidity"`
+}
+
+// StreamAllocation is the result of allocating the subscription budget.
+type StreamAllocation struct {
+	Budget           int                `json:"budget"` // 0 means unlimited
+	AssetsAllocated  int                `json:"assets_allocated"`
+	Tiers            map[string]int     `json:"tiers"` // Tier -> allocated markets
+	Allocated        []MarketAllocation `json:"allocated"`
+	Evicted          []MarketAllocation `json:"evicted"`           // Markets that did not fit in the budget
+	LastSubscribed   int                `json:"last_subscribed"`   // Assets subscribed by the last rebalance
+	LastUnsubscribed int                `json:"last_unsubscribed"` // Assets unsubscribed by the last rebalance
+	ComputedAt       time.Time          `json:"computed_at"`
+}
+
+/**
+ * @description
+ * allocateStreamAssets allocates the subscription budget to markets in tier order.
+ * Within a tier, markets with more liquidity come first, and ties keep the input order.
+ *
+ * @param markets The markets that can be subscribed to.
+ * @param pinned Condition IDs of pinned markets.
+ * @param demanded Condition IDs of markets clients are subscribed to.
+ * @param budget The maximum number of assets; 0 means unlimited.
+ * @returns The allocation, and the asset IDs to subscribe to.
+ */
+func allocateStreamAssets(markets []streamMarket, pinned, demanded map[string]bool, budget int) (StreamAllocation, []string) {
+	candidates := make([]MarketAllocation, 0, len(markets))
+	tokenIDs := make(map[string][]string, len(markets))
+	for _, market := range markets {
+		if len(market.TokenIDs) == 0 {
+			continue
+		}
+		if _, seen := tokenIDs[market.ConditionID]; seen {
+			continue
+		}
+		tokenIDs[market.ConditionID] = market.TokenIDs
+
+		tier := StreamTierRest
+		switch {
+		case pinned[market.ConditionID]:
+			tier = StreamTierPinned
+		case demanded[market.ConditionID]:
+			tier = StreamTierDemanded
+		case market.Liquidity > 0:
+			tier = StreamTierLiquidity
+		}
+		candidates = append(candidates, MarketAllocation{
+			ConditionID: market.ConditionID,
+			Tier:        tier,
+			Assets:      len(market.TokenIDs),
+			Liquidity:   market.Liquidity,
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if streamTierRank[candidates[i].Tier] != streamTierRank[candidates[j].Tier] {
+			return streamTierRank[candidates[i].Tier] < streamTierRank[candidates[j].Tier]
+		}
+		return candidates[i].Liquidity > candidates[j].Liquidity
+	})
+
+	allocation := StreamAllocation{
+		Budget:    budget,
+		Tiers:     make(map[string]int),
+		Allocated: []MarketAllocation{},
+		Evicted:   []MarketAllocation{},
+	}
+	var assetIDs []string
+	for _, candidate := range candidates {
+		if budget > 0 && allocation.AssetsAllocated+candidate.Assets > budget {
+			allocation.Evicted = append(allocation.Evicted, candidate)
+			continue
+		}
+		allocation.Allocated = append(allocation.Allocated, candidate)
+		allocation.AssetsAllocated += candidate.Assets
+		allocation.Tiers[candidate.Tier]++
+		assetIDs = append(assetIDs, tokenIDs[candidate.ConditionID]...)
+	}
+	return allocation, assetIDs
+}
+
+// diffAssets returns the assets in desired but not in current (to subscribe), and those in
+// current but not in desired (to unsubscribe), each sorted.
+func diffAssets(current, desired []string) (subscribe, unsubscribe []string) {
+	currentSet := make(map[string]bool, len(current))
+	for _, assetID := range current {
+		currentSet[assetID] = true
+	}
+	desiredSet := make(map[string]bool, len(desired))
+	for _, assetID := range desired {
+		if !desiredSet[assetID] && !currentSet[assetID] {
+			subscribe = append(subscribe, assetID)
+		}
+		desiredSet[assetID] = true
+	}
+	for _, assetID := range current {
+		if !desiredSet[assetID] {
+			unsubscribe = append(unsubscribe, assetID)
+		}
+	}
+	sort.Strings(subscribe)
+	sort.Strings(unsubscribe)
+	return subscribe, unsubscribe
+}
+
+// toStreamMarket converts a Gamma market into a subscription candidate.
+func toStreamMarket(market polymarket.GammaMarket) streamMarket {
+	liquidity, err := strconv.ParseFloat(strings.TrimSpace(market.Liquidity), 64)
+	if err != nil || liquidity < 0 {
+		liquidity = 0
+	}
+	return streamMarket{
+		ConditionID: market.ConditionID,
+		TokenIDs:    market.TokenIDs(),
+		Liquidity:   liquidity,
+	}
+}
+
+// SetMarketDemand sets the function reporting the condition IDs clients are subscribed to.
+// It must be called before the stream is started.
+func (s *MarketStreamService) SetMarketDemand(demand func() []string) {
+	s.marketDemand = demand
+}
+
+/**
+ * @description
+ * setCatalogMarkets replaces the markets the stream can subscribe to with the given active
+ * markets, the featured markets, and the markets added via AddMarketAssets. The markets'
+ * assets are added to the asset→condition mapping and the market catalog.
+ *
+ * @param markets The active markets fetched from Gamma.
+ * @returns The total number of assets of the markets.
+ */
+func (s *MarketStreamService) setCatalogMarkets(markets []polymarket.GammaMarket) int {
+	s.allocMu.Lock()
+	defer s.allocMu.Unlock()
+
+	candidates := make([]streamMarket, 0, len(markets)+len(s.featuredMarkets)+len(s.addedMarkets))
+	for _, market := range s.featuredMarkets {
+		candidates = append(candidates, toStreamMarket(market))
+		s.catalog.Add(market.ConditionID, market.Slug)
+	}
+	addedIDs := make([]string, 0, len(s.addedMarkets))
+	for conditionID := range s.addedMarkets {
+		addedIDs = append(addedIDs, conditionID)
+	}
+	sort.Strings(addedIDs)
+	for _, conditionID := range addedIDs {
+		candidates = append(candidates, streamMarket{ConditionID: conditionID, TokenIDs: s.addedMarkets[conditionID]})
+	}
+
+	marketsWithoutTokens := 0
+	for i, market := range markets {
+		candidate := toStreamMarket(market)
+		if len(candidate.TokenIDs) == 0 {
+			marketsWithoutTokens++
+			// Only log warning for first few markets without tokens to avoid spam
+			if marketsWithoutTokens <= 3 {
+				s.logger.Warn("no token IDs found for market",
+					"market_id", market.ConditionID,
+					"market_index", i)
+			}
+			continue
+		}
+		candidates = append(candidates, candidate)
+		s.catalog.Add(market.ConditionID, market.Slug)
+	}
+
+	// Merge rather than replace, so assets of markets that drop out of the catalog still
+	// resolve while they are being unsubscribed.
+	totalAssets := 0
+	s.assetMu.Lock()
+	for _, candidate := range candidates {
+		for _, assetID := range candidate.TokenIDs {
+			s.assetIDToConditionID[assetID] = candidate.ConditionID
+		}
+		totalAssets += len(candidate.TokenIDs)
+	}
+	s.assetMu.Unlock()
+
+	s.streamMarkets = candidates
+	s.logger.Info("✅ extracted token IDs from Gamma API markets",
+		"market_count", len(markets),
+		"featured_markets", len(s.featuredMarkets),
+		"markets_without_tokens", marketsWithoutTokens,
+		"total_token_ids", totalAssets)
+	return totalAssets
+}
+
+/**
+ * @description
+ * recoverMarketTokens fills in the tokens of markets that Gamma returned with neither
+ * clobTokenIds nor tokens, by looking them up on the CLOB by condition ID. Recovered tokens
+ * are remembered, so each market is looked up once; markets whose lookup failed are
+ * retried on the next catalog fetch. The number of lookups per call is bounded by
+ * streamTokenRecoveryLimit.
+ *
+ * @param markets The active markets fetched from Gamma, updated in place.
+ */
+func (s *MarketStreamService) recoverMarketTokens(markets []polymarket.GammaMarket) {
+	missing, attempted, recovered := 0, 0, 0
+	for i := range markets {
+		market := &markets[i]
+		if market.ConditionID == "" || len(market.TokenIDs()) > 0 {
+			continue
+		}
+		missing++
+
+		tokens, ok := s.recoveredTokens[market.ConditionID]
+		if !ok {
+			if s.clobClient == nil || attempted >= streamTokenRecoveryLimit {
+				continue
+			}
+			attempted++
+			clobMarket, err := s.clobClient.GetMarket(s.ctx, market.ConditionID)
+			if err != nil {
+				s.logger.Debug("failed to recover token IDs from CLOB", "error", err, "condition_id", market.ConditionID)
+				continue
+			}
+			for _, token := range clobMarket.Tokens {
+				if token.TokenID != "" {
+					tokens = append(tokens, polymarket.Token{TokenID: token.TokenID, Outcome: token.Outcome})
+				}
+			}
+			if len(tokens) == 0 {
+				continue
+			}
+			s.recoveredTokens[market.ConditionID] = tokens
+		}
+
+		market.Tokens = tokens
+		recovered++
+	}
+
+	if missing == 0 {
+		return
+	}
+	s.logger.Info("recovered token IDs of Gamma markets from CLOB",
+		"markets_without_tokens", missing,
+		"lookups", attempted,
+		"recovered", recovered,
+		"recovery_rate", float64(recovered)/float64(missing))
+}
+
+/**
+ * @description
+ * rebalance recomputes the allocation of the subscription budget and applies the
+ * difference to the WebSocket subscription: assets that lost their allocation are
+ * unsubscribed first, then newly allocated assets are subscribed.
+ *
+ * @returns An error if the unsubscription or subscription could not be sent.
+ */
+func (s *MarketStreamService) rebalance() error {
+	s.allocMu.Lock()
+	defer s.allocMu.Unlock()
+
+	pinned := make(map[string]bool, len(s.featuredMarkets)+len(s.addedMarkets))
+	for _, market := range s.featuredMarkets {
+		pinned[market.ConditionID] = true
+	}
+	for conditionID := range s.addedMarkets {
+		pinned[conditionID] = true
+	}
+	demanded := make(map[string]bool)
+	if s.marketDemand != nil {
+		for _, conditionID := range s.marketDemand() {
+			demanded[conditionID] = true
+		}
+	}
+
+	allocation, assetIDs := allocateStreamAssets(s.streamMarkets, pinned, demanded, s.config.MaxStreamAssets)
+	subscribe, unsubscribe := diffAssets(s.wsClient.SubscribedAssets(), assetIDs)
+	allocation.LastSubscribed = len(subscribe)
+	allocation.LastUnsubscribed = len(unsubscribe)
+	allocation.ComputedAt = time.Now().UTC()
+	s.allocation.Store(&allocation)
+
+	if len(subscribe) == 0 && len(unsubscribe) == 0 {
+		return nil
+	}
+	s.logger.Info("📡 rebalancing WebSocket subscriptions",
+		"budget", allocation.Budget,
+		"assets_allocated", allocation.AssetsAllocated,
+		"markets_allocated", len(allocation.Allocated),
+		"markets_evicted", len(allocation.Evicted),
+		"subscribe", len(subscribe),
+		"unsubscribe", len(unsubscribe))
+
+	if len(unsubscribe) > 0 {
+		if err := s.wsClient.Unsubscribe(unsubscribe); err != nil {
+			return fmt.Errorf("failed to unsubscribe %d assets: %w", len(unsubscribe), err)
+		}
+	}
+	if len(subscribe) > 0 {
+		if err := s.wsClient.Subscribe(subscribe); err != nil {
+			return fmt.Errorf("failed to subscribe %d assets: %w", len(subscribe), err)
+		}
+	}
+	return nil
+}
+
+// runSubscriptionRefresh periodically recomputes the allocation and re-fetches the market
+// catalog until the context is cancelled.
+func (s *MarketStreamService) runSubscriptionRefresh() {
+	rebalanceTicker := time.NewTicker(streamRebalanceInterval)
+	defer rebalanceTicker.Stop()
+	refreshTicker := time.NewTicker(streamCatalogRefreshInterval)
+	defer refreshTicker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-refreshTicker.C:
+			if err := s.refreshCatalog(); err != nil {
+				s.logger.Warn("failed to refresh markets from Gamma API, keeping the current catalog", "error", err)
+				continue
+			}
+		case <-rebalanceTicker.C:
+		}
+		if err := s.rebalance(); err != nil {
+			s.logger.Warn("failed to rebalance WebSocket subscriptions", "error", err)
+		}
+	}
+}
+
+// refreshCatalog re-fetches the active markets from Gamma and replaces the stream's catalog.
+// Catalog fetches are serialized by catalogFetchMu.
+func (s *MarketStreamService) refreshCatalog() error {
+	s.catalogFetchMu.Lock()
+	defer s.catalogFetchMu.Unlock()
+
+	markets, err := s.gammaClient.ListActiveMarkets(s.ctx, streamCatalogMarketLimit, 0)
+	if err != nil {
+		return err
+	}
+	s.recoverMarketTokens(markets)
+	s.setCatalogMarkets(markets)
+	s.catalogFetchedAt.Store(time.Now().UnixNano())
+	return nil
+}
+
+// catalogStale reports whether the catalog is older than the configured TTL.
+func (s *MarketStreamService) catalogStale() bool {
+	ttl := s.config.StreamCatalogTTL
+	if ttl <= 0 {
+		ttl = defaultStreamCatalogTTL
+	}
+	return time.Since(time.Unix(0, s.catalogFetchedAt.Load())) > ttl
+}
+
+// allocationSnapshot returns a copy of the current allocation with at most sampleLimit
+// allocated and evicted markets each (0 for all), and whether it was truncated.
+func (s *MarketStreamService) allocationSnapshot(sampleLimit int) (*StreamAllocation, bool) {
+	current, _ := s.allocation.Load().(*StreamAllocation)
+	if current == nil {
+		return nil, false
+	}
+	snapshot := *current
+	truncated := false
+	if sampleLimit > 0 && len(snapshot.Allocated) > sampleLimit {
+		snapshot.Allocated = snapshot.Allocated[:sampleLimit]
+		truncated = true
+	}
+	if sampleLimit > 0 && len(snapshot.Evicted) > sampleLimit {
+		snapshot.Evicted = snapshot.Evicted[:sampleLimit]
+		truncated = true
+	}
+	return &snapshot, truncated
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorillaWS "github.com/gorilla/websocket"
+	"github.com/poly-pro/backend/internal/config"
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// allocatedIDs returns the condition IDs of markets, in order.
+func allocatedIDs(markets []MarketAllocation) []string {
+	ids := make([]string, 0, len(markets))
+	for _, market := range markets {
+		ids = append(ids, market.ConditionID)
+	}
+	return ids
+}
+
+// TestAllocateStreamAssets allocates a synthetic catalog of two-asset markets and checks
+// the tier order, the liquidity order within a tier, and which markets are evicted.
+func TestAllocateStreamAssets(t *testing.T) {
+	catalog := []streamMarket{
+		{ConditionID: "0xrest1", TokenIDs: []string{"r1-yes", "r1-no"}},
+		{ConditionID: "0xliquid-low", TokenIDs: []string{"ll-yes", "ll-no"}, Liquidity: 100},
+		{ConditionID: "0xdemanded-low", TokenIDs: []string{"dl-yes", "dl-no"}, Liquidity: 10},
+		{ConditionID: "0xpinned", TokenIDs: []string{"p-yes", "p-no"}},
+		{ConditionID: "0xliquid-high", TokenIDs: []string{"lh-yes", "lh-no"}, Liquidity: 5000},
+		{ConditionID: "0xdemanded-high", TokenIDs: []string{"dh-yes", "dh-no"}, Liquidity: 900},
+		{ConditionID: "0xrest2", TokenIDs: []string{"r2-yes", "r2-no"}},
+	}
+	pinned := map[string]bool{"0xpinned": true}
+	demanded := map[string]bool{"0xdemanded-low": true, "0xdemanded-high": true, "0xpinned": true}
+
+	tests := []struct {
+		name        string
+		budget      int
+		wantAlloc   []string
+		wantEvicted []string
+		wantTiers   map[string]int
+	}{
+		{
+			name:        "unlimited",
+			budget:      0,
+			wantAlloc:   []string{"0xpinned", "0xdemanded-high", "0xdemanded-low", "0xliquid-high", "0xliquid-low", "0xrest1", "0xrest2"},
+			wantEvicted: []string{},
+			wantTiers:   map[string]int{StreamTierPinned: 1, StreamTierDemanded: 2, StreamTierLiquidity: 2, StreamTierRest: 2},
+		},
+		{
+			name:        "budget reaches into the liquidity tier",
+			budget:      8,
+			wantAlloc:   []string{"0xpinned", "0xdemanded-high", "0xdemanded-low", "0xliquid-high"},
+			wantEvicted: []string{"0xliquid-low", "0xrest1", "0xrest2"},
+			wantTiers:   map[string]int{StreamTierPinned: 1, StreamTierDemanded: 2, StreamTierLiquidity: 1},
+		},
+		{
+			// A budget with room for half a market leaves that room unused.
+			name:        "odd budget",
+			budget:      5,
+			wantAlloc:   []string{"0xpinned", "0xdemanded-high"},
+			wantEvicted: []string{"0xdemanded-low", "0xliquid-high", "0xliquid-low", "0xrest1", "0xrest2"},
+			wantTiers:   map[string]int{StreamTierPinned: 1, StreamTierDemanded: 1},
+		},
+		{
+			name:        "budget smaller than any market",
+			budget:      1,
+			wantAlloc:   []string{},
+			wantEvicted: []string{"0xpinned", "0xdemanded-high", "0xdemanded-low", "0xliquid-high", "0xliquid-low", "0xrest1", "0xrest2"},
+			wantTiers:   map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocation, assetIDs := allocateStreamAssets(catalog, pinned, demanded, tt.budget)
+			if got := allocatedIDs(allocation.Allocated); !reflect.DeepEqual(got, tt.wantAlloc) {
+				t.Errorf("allocated %v, want %v", got, tt.wantAlloc)
+			}
+			if got := allocatedIDs(allocation.Evicted); !reflect.DeepEqual(got, tt.wantEvicted) {
+				t.Errorf("evicted %v, want %v", got, tt.wantEvicted)
+			}
+			if !reflect.DeepEqual(allocation.Tiers, tt.wantTiers) {
+				t.Errorf("tiers = %v, want %v", allocation.Tiers, tt.wantTiers)
+			}
+			if allocation.AssetsAllocated != 2*len(tt.wantAlloc) || len(assetIDs) != allocation.AssetsAllocated {
+				t.Errorf("assets allocated = %d with %d asset IDs, want %d", allocation.AssetsAllocated, len(assetIDs), 2*len(tt.wantAlloc))
+			}
+			if tt.budget > 0 && allocation.AssetsAllocated > tt.budget {
+				t.Errorf("allocated %d assets over a budget of %d", allocation.AssetsAllocated, tt.budget)
+			}
+		})
+	}
+}
+
+// TestAllocateStreamAssetsAllOrNothing checks that a market too large for the remaining
+// budget is evicted whole, and that a smaller market after it still gets the room.
+func TestAllocateStreamAssetsAllOrNothing(t *testing.T) {
+	catalog := []streamMarket{
+		{ConditionID: "0xbinary", TokenIDs: []string{"b-yes", "b-no"}, Liquidity: 300},
+		{ConditionID: "0xmulti", TokenIDs: []string{"m-1", "m-2", "m-3", "m-4"}, Liquidity: 200},
+		{ConditionID: "0xsmall", TokenIDs: []string{"s-yes", "s-no"}, Liquidity: 100},
+	}
+	allocation, assetIDs := allocateStreamAssets(catalog, nil, nil, 5)
+
+	if want := []string{"b-yes", "b-no", "s-yes", "s-no"}; !reflect.DeepEqual(assetIDs, want) {
+		t.Errorf("asset IDs = %v, want %v", assetIDs, want)
+	}
+	if got := allocatedIDs(allocation.Evicted); !reflect.DeepEqual(got, []string{"0xmulti"}) {
+		t.Errorf("evicted %v, want only 0xmulti", got)
+	}
+	if allocation.Evicted[0].Assets != 4 || allocation.Evicted[0].Tier != StreamTierLiquidity {
+		t.Errorf("evicted market = %+v, want 4 assets in the liquidity tier", allocation.Evicted[0])
+	}
+}
+
+// TestAllocateStreamAssetsSkipsUnusableMarkets checks that markets without tokens are left
+// out, and that only the first of two markets with the same condition ID is allocated.
+func TestAllocateStreamAssetsSkipsUnusableMarkets(t *testing.T) {
+	catalog := []streamMarket{
+		{ConditionID: "0xfeatured", TokenIDs: []string{"f-yes", "f-no"}},
+		{ConditionID: "0xtokenless", Liquidity: 1000},
+		{ConditionID: "0xfeatured", TokenIDs: []string{"stale-yes", "stale-no"}, Liquidity: 50},
+	}
+	allocation, assetIDs := allocateStreamAssets(catalog, map[string]bool{"0xfeatured": true}, nil, 0)
+
+	if want := []string{"f-yes", "f-no"}; !reflect.DeepEqual(assetIDs, want) {
+		t.Errorf("asset IDs = %v, want %v", assetIDs, want)
+	}
+	if len(allocation.Allocated) != 1 || len(allocation.Evicted) != 0 {
+		t.Errorf("allocated %+v and evicted %+v, want only the first 0xfeatured", allocation.Allocated, allocation.Evicted)
+	}
+}
+
+func TestDiffAssets(t *testing.T) {
+	tests := []struct {
+		name            string
+		current         []string
+		desired         []string
+		wantSubscribe   []string
+		wantUnsubscribe []string
+	}{
+		{"nothing subscribed", nil, []string{"b", "a"}, []string{"a", "b"}, nil},
+		{"unchanged", []string{"a", "b"}, []string{"b", "a"}, nil, nil},
+		{"everything dropped", []string{"a", "b"}, nil, nil, []string{"a", "b"}},
+		{"swap", []string{"a", "b", "c"}, []string{"c", "d", "a"}, []string{"d"}, []string{"b"}},
+		{"duplicates in desired", []string{"a"}, []string{"b", "b", "a"}, []string{"b"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscribe, unsubscribe := diffAssets(tt.current, tt.desired)
+			if !reflect.DeepEqual(subscribe, tt.wantSubscribe) || !reflect.DeepEqual(unsubscribe, tt.wantUnsubscribe) {
+				t.Errorf("diffAssets = %v, %v; want %v, %v", subscribe, unsubscribe, tt.wantSubscribe, tt.wantUnsubscribe)
+			}
+		})
+	}
+}
+
+func TestToStreamMarketLiquidity(t *testing.T) {
+	tests := []struct {
+		liquidity string
+		want      float64
+	}{
+		{"1234.5", 1234.5},
+		{" 42 ", 42},
+		{"", 0},
+		{"n/a", 0},
+		{"-10", 0},
+	}
+	for _, tt := range tests {
+		market := toStreamMarket(polymarket.GammaMarket{ConditionID: "0xm", ClobTokenIds: `["yes","no"]`, Liquidity: tt.liquidity})
+		if market.Liquidity != tt.want {
+			t.Errorf("liquidity %q = %v, want %v", tt.liquidity, market.Liquidity, tt.want)
+		}
+	}
+}
+
+// subscriptionRecorder is a CLOB WebSocket server that records the subscription messages
+// it receives, on any shard.
+type subscriptionRecorder struct {
+	*httptest.Server
+	messages chan polymarket.SubscriptionMessage
+}
+
+func newSubscriptionRecorder(t *testing.T) *subscriptionRecorder {
+	t.Helper()
+	recorder := &subscriptionRecorder{messages: make(chan polymarket.SubscriptionMessage, 64)}
+	upgrader := gorillaWS.Upgrader{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var message polymarket.SubscriptionMessage
+			if json.Unmarshal(data, &message) == nil && message.Type == "MARKET" {
+				recorder.messages <- message
+			}
+		}
+	}))
+	t.Cleanup(recorder.Close)
+	return recorder
+}
+
+// next returns the next subscription message, failing after a timeout.
+func (r *subscriptionRecorder) next(t *testing.T) polymarket.SubscriptionMessage {
+	t.Helper()
+	select {
+	case message := <-r.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no subscription message received")
+		return polymarket.SubscriptionMessage{}
+	}
+}
+
+// TestRebalanceAppliesDiffs rebalances a stream whose hub demand changes, and checks that
+// only the assets whose allocation changed are unsubscribed and then subscribed.
+func TestRebalanceAppliesDiffs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recorder := newSubscriptionRecorder(t)
+	service := NewMarketStreamService(context.Background(), logger, nil, config.Config{MaxStreamAssets: 4}, newBarStore(), nil, nil)
+	service.wsClient = polymarket.NewCLOBWebSocketPool("ws"+strings.TrimPrefix(recorder.URL, "http"), "", "", "", 100, logger)
+	if err := service.wsClient.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { service.wsClient.Close() })
+
+	var demandMu sync.Mutex
+	var demand []string
+	service.SetMarketDemand(func() []string {
+		demandMu.Lock()
+		defer demandMu.Unlock()
+		return demand
+	})
+	service.setCatalogMarkets([]polymarket.GammaMarket{
+		{ConditionID: "0xa", ClobTokenIds: `["a-yes","a-no"]`, Liquidity: "500"},
+		{ConditionID: "0xb", ClobTokenIds: `["b-yes","b-no"]`, Liquidity: "100"},
+		{ConditionID: "0xc", ClobTokenIds: `["c-yes","c-no"]`},
+	})
+
+	// The budget holds the two liquid markets.
+	if err := service.rebalance(); err != nil {
+		t.Fatalf("first rebalance: %v", err)
+	}
+	first := recorder.next(t)
+	if want := []string{"a-no", "a-yes", "b-no", "b-yes"}; first.Operation != "" || !reflect.DeepEqual(first.AssetsIDs, want) {
+		t.Fatalf("first message = %+v, want an initial subscription to %v", first, want)
+	}
+
+	// A client subscribes to 0xc, which displaces the less liquid 0xb.
+	demandMu.Lock()
+	demand = []string{"0xc"}
+	demandMu.Unlock()
+	if err := service.rebalance(); err != nil {
+		t.Fatalf("second rebalance: %v", err)
+	}
+	unsubscribe, subscribe := recorder.next(t), recorder.next(t)
+	if unsubscribe.Operation != "unsubscribe" || !reflect.DeepEqual(unsubscribe.AssetsIDs, []string{"b-no", "b-yes"}) {
+		t.Errorf("first diff message = %+v, want 0xb's assets unsubscribed", unsubscribe)
+	}
+	if subscribe.Operation != "subscribe" || !reflect.DeepEqual(subscribe.AssetsIDs, []string{"c-no", "c-yes"}) {
+		t.Errorf("second diff message = %+v, want 0xc's assets subscribed", subscribe)
+	}
+	allocation, _ := service.allocationSnapshot(0)
+	if allocation.LastSubscribed != 2 || allocation.LastUnsubscribed != 2 {
+		t.Errorf("last subscribed, unsubscribed = %d, %d; want 2, 2", allocation.LastSubscribed, allocation.LastUnsubscribed)
+	}
+	if got := allocatedIDs(allocation.Evicted); !reflect.DeepEqual(got, []string{"0xb"}) {
+		t.Errorf("evicted %v, want 0xb", got)
+	}
+
+	// Nothing changed, so nothing is sent.
+	if err := service.rebalance(); err != nil {
+		t.Fatalf("third rebalance: %v", err)
+	}
+	allocation, _ = service.allocationSnapshot(0)
+	if allocation.LastSubscribed != 0 || allocation.LastUnsubscribed != 0 {
+		t.Errorf("unchanged rebalance subscribed %d and unsubscribed %d assets", allocation.LastSubscribed, allocation.LastUnsubscribed)
+	}
+	if want := []string{"a-no", "a-yes", "c-no", "c-yes"}; !reflect.DeepEqual(service.wsClient.SubscribedAssets(), want) {
+		t.Errorf("subscribed assets = %v, want %v", service.wsClient.SubscribedAssets(), want)
+	}
+}
+
+func TestAllocationSnapshotTruncates(t *testing.T) {
+	service := NewMarketStreamService(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), nil, config.Config{}, newBarStore(), nil, nil)
+	if allocation, truncated := service.allocationSnapshot(2); allocation != nil || truncated {
+		t.Fatalf("snapshot before the first rebalance = %+v, %v; want none", allocation, truncated)
+	}
+
+	markets := make([]streamMarket, 5)
+	for i := range markets {
+		markets[i] = streamMarket{ConditionID: string(rune('a' + i)), TokenIDs: []string{string(rune('a' + i))}}
+	}
+	allocation, _ := allocateStreamAssets(markets, nil, nil, 3)
+	service.allocation.Store(&allocation)
+
+	snapshot, truncated := service.allocationSnapshot(2)
+	if !truncated || len(snapshot.Allocated) != 2 || len(snapshot.Evicted) != 2 {
+		t.Errorf("snapshot = %d allocated, %d evicted, truncated %v; want 2, 2, true", len(snapshot.Allocated), len(snapshot.Evicted), truncated)
+	}
+	if len(allocation.Allocated) != 3 {
+		t.Error("truncating the snapshot changed the stored allocation")
+	}
+	if snapshot, truncated := service.allocationSnapshot(0); truncated || len(snapshot.Allocated) != 3 || len(snapshot.Evicted) != 2 {
+		t.Errorf("full snapshot truncated to %d allocated and %d evicted", len(snapshot.Allocated), len(snapshot.Evicted))
+	}
+}
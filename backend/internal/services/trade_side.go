@@ -0,0 +1,147 @@
+/**
+ * @description
+ * This file implements aggressor inference for the trade tape. Public trades do not always
+ * label which side crossed the spread, so the side is inferred by comparing the trade price
+ * with the best bid and ask that prevailed when the trade happened.
+ *
+ * Key features:
+ * - Top of Book History: The market stream records the best bid and ask of every book
+ *   message per asset, keeping a few minutes of history, so that a trade is compared with
+ *   the book at its own time rather than the current one.
+ * - Inference: A price at or above the best ask is a buy aggressor, at or below the best
+ *   bid a sell aggressor, and anything in between (or without a book) is unknown.
+ * - Confidence: "high" when the book snapshot is at most `bookTopFreshness` older than the
+ *   trade, "low" when it is older, and "none" when the side is unknown.
+ *
+ * @notes
+ * - Inferred sides are computed when the tape is served and are not persisted.
+ */
+
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// AggressorBuy, AggressorSell, and AggressorUnknown are the inferred aggressor sides.
+	AggressorBuy     = "buy"
+	AggressorSell    = "sell"
+	AggressorUnknown = "unknown"
+
+	// AggressorConfidenceHigh, AggressorConfidenceLow, and AggressorConfidenceNone qualify an inference.
+	AggressorConfidenceHigh = "high"
+	AggressorConfidenceLow  = "low"
+	AggressorConfidenceNone = "none"
+
+	// bookTopRetention is how long top of book snapshots are kept per asset.
+	bookTopRetention = 10 * time.Minute
+	// maxBookTopSnapshots bounds the snapshots kept per asset.
+	maxBookTopSnapshots = 2000
+	// bookTopFreshness is the maximum age of a snapshot, relative to the trade, for a high confidence inference.
+	bookTopFreshness = 5 * time.Second
+	// priceTolerance absorbs floating point error when comparing prices with quotes.
+	priceTolerance = 1e-9
+)
+
+// TopOfBook is the best bid and ask of an asset's order book at a point in time.
+type TopOfBook struct {
+	BestBid float64
+	HasBid  bool
+	BestAsk float64
+	HasAsk  bool
+	At      time.Time
+}
+
+// bookTopTracker keeps a short history of each asset's top of book.
+type bookTopTracker struct {
+	mu        sync.RWMutex
+	snapshots map[string][]TopOfBook // assetID -> snapshots in time order
+}
+
+// newBookTopTracker creates an empty bookTopTracker.
+func newBookTopTracker() *bookTopTracker {
+	return &bookTopTracker{snapshots: make(map[string][]TopOfBook)}
+}
+
+// record appends a snapshot of an asset's top of book and drops expired ones.
+func (t *bookTopTracker) record(assetID string, top TopOfBook) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshots := t.snapshots[assetID]
+	if n := len(snapshots); n > 0 && top.At.Before(snapshots[n-1].At) {
+		// Out of order messages would break the binary search in at.
+		top.At = snapshots[n-1].At
+	}
+	snapshots = append(snapshots, top)
+
+	cutoff := top.At.Add(-bookTopRetention)
+	drop := sort.Search(len(snapshots), func(i int) bool { return !snapshots[i].At.Before(cutoff) })
+	if excess := len(snapshots) - maxBookTopSnapshots; excess > drop {
+		drop = excess
+	}
+	if drop > 0 {
+		snapshots = append(snapshots[:0:0], snapshots[drop:]...)
+	}
+	t.snapshots[assetID] = snapshots
+}
+
+// at returns the latest snapshot of an asset's top of book taken at or before the given time.
+func (t *bookTopTracker) at(assetID string, at time.Time) (TopOfBook, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	snapshots := t.snapshots[assetID]
+	i := sort.Search(len(snapshots), func(i int) bool { return snapshots[i].At.After(at) })
+	if i == 0 {
+		return TopOfBook{}, false
+	}
+	return snapshots[i-1], true
+}
+
+/**
+ * @description
+ * InferAggressor infers the aggressor side of a trade from the top of book that prevailed
+ * when it happened.
+ *
+ * @param price The trade price.
+ * @param tradeTime When the trade happened.
+ * @param top The prevailing top of book.
+ * @param hasBook Whether a top of book was known at the trade's time.
+ * @returns The aggressor side and the confidence of the inference.
+ */
+func InferAggressor(price float64, tradeTime time.Time, top TopOfBook, hasBook bool) (string, string) {
+	if !hasBook {
+		return AggressorUnknown, AggressorConfidenceNone
+	}
+
+	buy := top.HasAsk && price >= top.BestAsk-priceTolerance
+	sell := top.HasBid && price <= top.BestBid+priceTolerance
+	side := AggressorUnknown
+	switch {
+	case buy && sell:
+		// Locked or crossed book: the price is at both quotes.
+		return AggressorUnknown, AggressorConfidenceNone
+	case buy:
+		side = AggressorBuy
+	case sell:
+		side = AggressorSell
+	default:
+		return AggressorUnknown, AggressorConfidenceNone
+	}
+
+	if tradeTime.Sub(top.At) > bookTopFreshness {
+		return side, AggressorConfidenceLow
+	}
+	return side, AggressorConfidenceHigh
+}
+
+// InferTradeAggressor infers the aggressor side of a trade on an asset from the stream's
+// top of book history.
+func (s *MarketStreamService) InferTradeAggressor(assetID string, price float64, tradeTime time.Time) (string, string) {
+	top, ok := s.bookTops.at(assetID, tradeTime)
+	return InferAggressor(price, tradeTime, top, ok)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/poly-pro/backend/internal/config"
+)
+
+func TestInferAggressor(t *testing.T) {
+	tradeTime := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	book := TopOfBook{BestBid: 0.48, HasBid: true, BestAsk: 0.52, HasAsk: true, At: tradeTime.Add(-time.Second)}
+	tests := []struct {
+		name           string
+		price          float64
+		top            TopOfBook
+		hasBook        bool
+		wantSide       string
+		wantConfidence string
+	}{
+		{"at the ask", 0.52, book, true, AggressorBuy, AggressorConfidenceHigh},
+		{"above the ask", 0.55, book, true, AggressorBuy, AggressorConfidenceHigh},
+		// A price parsed from a decimal string may be a hair off the quote.
+		{"at the ask with float error", 0.32 + 0.2, book, true, AggressorBuy, AggressorConfidenceHigh},
+		{"just below the ask", 0.5199, book, true, AggressorUnknown, AggressorConfidenceNone},
+		{"at the bid", 0.48, book, true, AggressorSell, AggressorConfidenceHigh},
+		{"below the bid", 0.40, book, true, AggressorSell, AggressorConfidenceHigh},
+		{"just above the bid", 0.4801, book, true, AggressorUnknown, AggressorConfidenceNone},
+		{"inside the spread", 0.50, book, true, AggressorUnknown, AggressorConfidenceNone},
+		{"no book", 0.52, TopOfBook{}, false, AggressorUnknown, AggressorConfidenceNone},
+		{"empty book", 0.52, TopOfBook{At: book.At}, true, AggressorUnknown, AggressorConfidenceNone},
+		// With one side of the book missing, only the other side can be inferred.
+		{"asks only, at the ask", 0.52, TopOfBook{BestAsk: 0.52, HasAsk: true, At: book.At}, true, AggressorBuy, AggressorConfidenceHigh},
+		{"asks only, below the ask", 0.10, TopOfBook{BestAsk: 0.52, HasAsk: true, At: book.At}, true, AggressorUnknown, AggressorConfidenceNone},
+		{"bids only, at the bid", 0.48, TopOfBook{BestBid: 0.48, HasBid: true, At: book.At}, true, AggressorSell, AggressorConfidenceHigh},
+		{"bids only, above the bid", 0.90, TopOfBook{BestBid: 0.48, HasBid: true, At: book.At}, true, AggressorUnknown, AggressorConfidenceNone},
+		{"locked book", 0.50, TopOfBook{BestBid: 0.50, HasBid: true, BestAsk: 0.50, HasAsk: true, At: book.At}, true, AggressorUnknown, AggressorConfidenceNone},
+		{"crossed book", 0.50, TopOfBook{BestBid: 0.51, HasBid: true, BestAsk: 0.49, HasAsk: true, At: book.At}, true, AggressorUnknown, AggressorConfidenceNone},
+		{"book exactly as old as the freshness limit", 0.52, TopOfBook{BestBid: 0.48, HasBid: true, BestAsk: 0.52, HasAsk: true, At: tradeTime.Add(-bookTopFreshness)}, true, AggressorBuy, AggressorConfidenceHigh},
+		{"stale book", 0.48, TopOfBook{BestBid: 0.48, HasBid: true, BestAsk: 0.52, HasAsk: true, At: tradeTime.Add(-bookTopFreshness - time.Millisecond)}, true, AggressorSell, AggressorConfidenceLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			side, confidence := InferAggressor(tt.price, tradeTime, tt.top, tt.hasBook)
+			if side != tt.wantSide || confidence != tt.wantConfidence {
+				t.Errorf("InferAggressor(%v) = %s, %s; want %s, %s", tt.price, side, confidence, tt.wantSide, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+// TestInferTradeAggressorUsesBookAtTradeTime records a book that moves, and checks that each
+// trade is compared with the book that prevailed when it happened, not the latest one.
+func TestInferTradeAggressorUsesBookAtTradeTime(t *testing.T) {
+	const assetID = "12345"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := NewMarketStreamService(context.Background(), logger, nil, config.Config{}, newBarStore(), nil, nil)
+	start := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	service.bookTops.record(assetID, TopOfBook{BestBid: 0.40, HasBid: true, BestAsk: 0.42, HasAsk: true, At: start})
+	service.bookTops.record(assetID, TopOfBook{BestBid: 0.60, HasBid: true, BestAsk: 0.62, HasAsk: true, At: start.Add(time.Minute)})
+
+	tests := []struct {
+		name           string
+		assetID        string
+		price          float64
+		at             time.Time
+		wantSide       string
+		wantConfidence string
+	}{
+		{"before the first book", assetID, 0.42, start.Add(-time.Second), AggressorUnknown, AggressorConfidenceNone},
+		{"on the first book", assetID, 0.42, start.Add(2 * time.Second), AggressorBuy, AggressorConfidenceHigh},
+		// 0.60 is above the first book's ask, but the second book's bid.
+		{"on the first book, long after it", assetID, 0.60, start.Add(59 * time.Second), AggressorBuy, AggressorConfidenceLow},
+		{"at the second book", assetID, 0.60, start.Add(time.Minute), AggressorSell, AggressorConfidenceHigh},
+		{"unknown asset", "67890", 0.42, start.Add(2 * time.Second), AggressorUnknown, AggressorConfidenceNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			side, confidence := service.InferTradeAggressor(tt.assetID, tt.price, tt.at)
+			if side != tt.wantSide || confidence != tt.wantConfidence {
+				t.Errorf("InferTradeAggressor = %s, %s; want %s, %s", side, confidence, tt.wantSide, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestBookTopTrackerRetention(t *testing.T) {
+	const assetID = "12345"
+	tracker := newBookTopTracker()
+	start := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	tracker.record(assetID, TopOfBook{BestBid: 0.40, HasBid: true, At: start})
+	tracker.record(assetID, TopOfBook{BestBid: 0.45, HasBid: true, At: start.Add(time.Minute)})
+
+	// A book timestamped before the previous one is kept in order, at the previous time.
+	tracker.record(assetID, TopOfBook{BestBid: 0.50, HasBid: true, At: start.Add(30 * time.Second)})
+	if top, ok := tracker.at(assetID, start.Add(time.Minute)); !ok || top.BestBid != 0.50 {
+		t.Errorf("book at the out of order message = %+v, %v; want the 0.50 bid", top, ok)
+	}
+	if top, ok := tracker.at(assetID, start.Add(30*time.Second)); !ok || top.BestBid != 0.40 {
+		t.Errorf("book 30s in = %+v, %v; want the first book", top, ok)
+	}
+
+	// Books older than the retention are dropped, so trades from then have no book.
+	tracker.record(assetID, TopOfBook{BestBid: 0.55, HasBid: true, At: start.Add(time.Minute + bookTopRetention)})
+	if top, ok := tracker.at(assetID, start.Add(30*time.Second)); ok {
+		t.Errorf("book 30s in = %+v, want it expired", top)
+	}
+	if top, ok := tracker.at(assetID, start.Add(2*time.Minute)); !ok || top.BestBid != 0.50 {
+		t.Errorf("book 2m in = %+v, %v; want the 0.50 bid, retained for exactly the retention", top, ok)
+	}
+
+	// The number of books per asset is bounded.
+	for i := 0; i < maxBookTopSnapshots+10; i++ {
+		tracker.record("busy", TopOfBook{At: start.Add(time.Duration(i) * time.Millisecond)})
+	}
+	tracker.mu.RLock()
+	kept := len(tracker.snapshots["busy"])
+	tracker.mu.RUnlock()
+	if kept != maxBookTopSnapshots {
+		t.Errorf("kept %d books, want %d", kept, maxBookTopSnapshots)
+	}
+	if _, ok := tracker.at("busy", start.Add(5*time.Millisecond)); ok {
+		t.Error("the oldest books were not dropped")
+	}
+}
@@ -0,0 +1,191 @@
+/**
+ * @description
+ * This file implements the trading parameters cache: the order constraints of each token
+ * (currently its minimum tick size), cached in Redis so that orders can be validated before
+ * they are signed and submitted.
+ *
+ * Key features:
+ * - Caching: Parameters are fetched from the CLOB on a cache miss and kept in Redis under
+ *   `cachekeys.TradingParams`, shared by every backend instance.
+ * - Tick Size Changes: Polymarket changes a market's tick size as its price approaches
+ *   0 or 1. The market stream consumes the `tick_size_change` WebSocket event, invalidates
+ *   the token's cached parameters, and publishes a `market_meta` update on the market's
+ *   channel.
+ * - Validation: Order prices must be a multiple of the tick size, between one tick and
+ *   one minus one tick.
+ *
+ * @notes
+ * - When the parameters cannot be fetched, validation is skipped and the CLOB remains the
+ *   final judge of the price.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/poly-pro/backend/internal/cachekeys"
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/poly-pro/backend/internal/polymarket"
+	"github.com/poly-pro/backend/internal/wire"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// tickSizeTolerance absorbs floating point error when checking prices against the tick.
+	tickSizeTolerance = 1e-9
+	// marketMetaEventType is the value of the "event_type" field of market metadata updates.
+	marketMetaEventType = "market_meta"
+)
+
+// ErrInvalidOrderPrice is returned when an order's price does not conform to the token's tick size.
+var ErrInvalidOrderPrice = errors.New("invalid order price")
+
+// TradingParams holds the order constraints of a token.
+type TradingParams struct {
+	TokenID   string    `json:"token_id"`
+	TickSize  float64   `json:"tick_size"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// tradingParamsRedis reads, writes and deletes cached trading parameters; *redis.Client
+// implements it.
+type tradingParamsRedis interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// TradingParamsCache caches the trading parameters of tokens in Redis.
+type TradingParamsCache struct {
+	redisClient tradingParamsRedis
+	clobClient  *polymarket.CLOBAPIClient
+	logger      *slog.Logger
+}
+
+/**
+ * @description
+ * NewTradingParamsCache creates a new TradingParamsCache.
+ *
+ * @param redisClient The Redis client holding the cache.
+ * @param clobClient The CLOB client the parameters are fetched from on a cache miss.
+ * @param logger A structured logger.
+ * @returns A pointer to a new TradingParamsCache instance.
+ */
+func NewTradingParamsCache(redisClient *redis.Client, clobClient *polymarket.CLOBAPIClient, logger *slog.Logger) *TradingParamsCache {
+	return &TradingParamsCache{
+		redisClient: redisClient,
+		clobClient:  clobClient,
+		logger:      logger,
+	}
+}
+
+// Get returns a token's trading parameters, fetching them from the CLOB on a cache miss.
+func (c *TradingParamsCache) Get(ctx context.Context, tokenID string) (*TradingParams, error) {
+	if cached, err := c.redisClient.Get(ctx, cachekeys.TradingParams.Key(tokenID)).Bytes(); err == nil {
+		var params TradingParams
+		if err := json.Unmarshal(cached, &params); err == nil {
+			return &params, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		c.logger.Warn("failed to read trading params from cache", "error", err, "token_id", tokenID)
+	}
+	return c.Refresh(ctx, tokenID)
+}
+
+// Refresh fetches a token's trading parameters from the CLOB and caches them.
+func (c *TradingParamsCache) Refresh(ctx context.Context, tokenID string) (*TradingParams, error) {
+	tickSize, err := c.clobClient.GetTickSize(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	params := &TradingParams{
+		TokenID:   tokenID,
+		TickSize:  tickSize,
+		FetchedAt: time.Now().UTC(),
+	}
+
+	if !cachekeys.TradingParams.Enabled() {
+		return params, nil
+	}
+	if encoded, err := json.Marshal(params); err == nil {
+		if err := c.redisClient.Set(ctx, cachekeys.TradingParams.Key(tokenID), encoded, cachekeys.TradingParams.TTL).Err(); err != nil {
+			c.logger.Warn("failed to cache trading params", "error", err, "token_id", tokenID)
+		}
+	}
+	return params, nil
+}
+
+// Invalidate drops a token's cached trading parameters, so the next Get fetches them again.
+func (c *TradingParamsCache) Invalidate(ctx context.Context, tokenID string) {
+	if err := c.redisClient.Del(ctx, cachekeys.TradingParams.Key(tokenID)).Err(); err != nil {
+		c.logger.Warn("failed to invalidate trading params", "error", err, "token_id", tokenID)
+	}
+}
+
+/**
+ * @description
+ * validateOrderPrice checks that a price is a multiple of the tick size and lies between one
+ * tick and one minus one tick.
+ *
+ * @param price The order price (0 to 1).
+ * @param tickSize The token's minimum tick size.
+ * @returns An error wrapping ErrInvalidOrderPrice if the price does not conform.
+ */
+func validateOrderPrice(price float64, tickSize float64) error {
+	if price < tickSize-tickSizeTolerance || price > 1-tickSize+tickSizeTolerance {
+		return fmt.Errorf("%w: price %v must be between %v and %v", ErrInvalidOrderPrice, price, tickSize, 1-tickSize)
+	}
+	ticks := price / tickSize
+	if math.Abs(ticks-math.Round(ticks)) > tickSizeTolerance*ticks+tickSizeTolerance {
+		return fmt.Errorf("%w: price %v is not a multiple of the tick size %v", ErrInvalidOrderPrice, price, tickSize)
+	}
+	return nil
+}
+
+/**
+ * @description
+ * handleTickSizeChange invalidates the cached trading parameters of a token whose tick size
+ * changed and publishes a market_meta update on the market's channel, so that clients can
+ * adjust their price inputs.
+ *
+ * @param message The tick_size_change event.
+ */
+func (s *MarketStreamService) handleTickSizeChange(message *polymarket.TickSizeChangeMessage) {
+	s.tradingParams.Invalidate(s.ctx, message.AssetID)
+
+	conditionID := message.Market
+	if mappedConditionID, ok := s.ConditionIDForAsset(message.AssetID); ok {
+		conditionID = mappedConditionID
+	}
+
+	data := wire.MarketMeta{
+		SchemaVersion: wire.SchemaVersion,
+		EventType:     marketMetaEventType,
+		AssetID:       message.AssetID,
+		Market:        conditionID,
+		TickSize:      message.NewTickSize,
+		OldTickSize:   message.OldTickSize,
+		Timestamp:     message.Timestamp,
+	}
+	if message.Timestamp == "" {
+		data.Timestamp = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		s.logger.Error("failed to marshal market_meta update", "error", err)
+		return
+	}
+	channel := channels.MarketChannel(conditionID)
+	if err := publish(s.ctx, s.redisClient, s.publishBuffer, channel, payload, PublishDurable); err != nil {
+		s.logger.Error("failed to publish market_meta update", "error", err, "channel", channel)
+	}
+}
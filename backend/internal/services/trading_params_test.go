@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/poly-pro/backend/internal/cachekeys"
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/poly-pro/backend/internal/config"
+	db "github.com/poly-pro/backend/internal/db"
+	"github.com/poly-pro/backend/internal/polymarket"
+	"github.com/poly-pro/backend/internal/wire"
+	"github.com/redis/go-redis/v9"
+)
+
+const tickTestToken = "71321045679252212594626385532706912750332728571942532289631379312455583992563"
+
+// memoryRedis is a tradingParamsRedis that keeps values in memory, without expiry.
+type memoryRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{values: make(map[string]string)}
+}
+
+func (r *memoryRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cmd := redis.NewStringCmd(ctx)
+	if value, ok := r.values[key]; ok {
+		cmd.SetVal(value)
+	} else {
+		cmd.SetErr(redis.Nil)
+	}
+	return cmd
+}
+
+func (r *memoryRedis) Set(ctx context.Context, key string, value interface{}, _ time.Duration) *redis.StatusCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = fmt.Sprintf("%s", value)
+	cmd := redis.NewStatusCmd(ctx)
+	cmd.SetVal("OK")
+	return cmd
+}
+
+func (r *memoryRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := r.values[key]; ok {
+			delete(r.values, key)
+			deleted++
+		}
+	}
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetVal(int64(deleted))
+	return cmd
+}
+
+// cachedTickSize returns the tick size cached for tickTestToken, or 0 if none is.
+func (r *memoryRedis) cachedTickSize(t *testing.T) float64 {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cached, ok := r.values[cachekeys.TradingParams.Key(tickTestToken)]
+	if !ok {
+		return 0
+	}
+	var params TradingParams
+	if err := json.Unmarshal([]byte(cached), &params); err != nil {
+		t.Fatalf("cached trading params %q: %v", cached, err)
+	}
+	return params.TickSize
+}
+
+// tickSizeCLOB is a CLOB API whose tick size for every token can be changed, and which
+// rejects every order for breaking the tick size rule.
+type tickSizeCLOB struct {
+	*httptest.Server
+	tickSize atomic.Value // string
+	lookups  atomic.Int32
+}
+
+func newTickSizeCLOB(t *testing.T, tickSize string) *tickSizeCLOB {
+	t.Helper()
+	clob := &tickSizeCLOB{}
+	clob.tickSize.Store(tickSize)
+	clob.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tick-size":
+			clob.lookups.Add(1)
+			fmt.Fprintf(w, `{"minimum_tick_size":%s}`, clob.tickSize.Load())
+		case "/order":
+			fmt.Fprintf(w, `{"success":false,"errorMsg":"INVALID_ORDER_MIN_TICK_SIZE: order 0xorder is invalid. Price (0.555), breaks minimum tick size rule: %s","orderId":"","status":""}`, clob.tickSize.Load())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(clob.Close)
+	return clob
+}
+
+func TestValidateOrderPrice(t *testing.T) {
+	tests := []struct {
+		price    float64
+		tickSize float64
+		valid    bool
+	}{
+		{0.55, 0.01, true},
+		{0.01, 0.01, true},
+		{0.99, 0.01, true},
+		// Float error in a price typed as a decimal is tolerated.
+		{0.1 + 0.2, 0.01, true},
+		{0.555, 0.01, false},
+		{0.555, 0.001, true},
+		{0.005, 0.01, false},
+		{0.995, 0.01, false},
+		{0.999, 0.001, true},
+		{0.9995, 0.001, false},
+		{0, 0.01, false},
+		{1, 0.01, false},
+	}
+	for _, tt := range tests {
+		err := validateOrderPrice(tt.price, tt.tickSize)
+		if tt.valid && err != nil {
+			t.Errorf("price %v with tick %v: %v", tt.price, tt.tickSize, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidOrderPrice) {
+			t.Errorf("price %v with tick %v: error = %v, want %v", tt.price, tt.tickSize, err, ErrInvalidOrderPrice)
+		}
+	}
+}
+
+// TestTickSizeChangeInvalidatesTradingParams validates prices against a cached tick size,
+// simulates a tick_size_change event, and checks that the cache is dropped, a market_meta
+// update is published, and the next validation fetches and uses the new tick.
+func TestTickSizeChangeInvalidatesTradingParams(t *testing.T) {
+	const conditionID = "0xmarket"
+	clob := newTickSizeCLOB(t, "0.01")
+	cache := newMemoryRedis()
+	service := newTestPolymarketService(clob.Server, nil)
+	service.tradeParams = NewTradingParamsCache(nil, service.clobClient, service.logger)
+	service.tradeParams.redisClient = cache
+
+	if err := service.validatePrice(context.Background(), tickTestToken, 0.55); err != nil {
+		t.Fatalf("price on the 0.01 tick rejected: %v", err)
+	}
+	// The CLOB changes the tick; until the event arrives, the cached tick is used.
+	clob.tickSize.Store("0.001")
+	if err := service.validatePrice(context.Background(), tickTestToken, 0.555); !errors.Is(err, ErrInvalidOrderPrice) {
+		t.Fatalf("price off the cached 0.01 tick: error = %v, want %v", err, ErrInvalidOrderPrice)
+	}
+	if got := clob.lookups.Load(); got != 1 || cache.cachedTickSize(t) != 0.01 {
+		t.Fatalf("%d tick size lookups, cached tick %v; want 1 lookup cached as 0.01", got, cache.cachedTickSize(t))
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	stream := NewMarketStreamService(context.Background(), logger, nil, config.Config{}, newBarStore(), nil, nil)
+	stream.tradingParams = service.tradeParams
+	publisher := &fakePublisher{}
+	now := time.Now()
+	stream.SetPublishBuffer(newTestPublishBuffer(publisher, PublishBufferPolicy{}, &now))
+	stream.handleTickSizeChange(&polymarket.TickSizeChangeMessage{
+		EventType:   "tick_size_change",
+		AssetID:     tickTestToken,
+		Market:      conditionID,
+		OldTickSize: "0.01",
+		NewTickSize: "0.001",
+		Timestamp:   "1789000000000",
+	})
+
+	if tick := cache.cachedTickSize(t); tick != 0 {
+		t.Errorf("tick %v still cached after the event", tick)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("published %v, want one market_meta update", publisher.published)
+	}
+	channel, payload, _ := strings.Cut(publisher.published[0], " ")
+	var meta wire.MarketMeta
+	if err := json.Unmarshal([]byte(payload), &meta); err != nil {
+		t.Fatalf("decode market_meta %q: %v", payload, err)
+	}
+	wantMeta := wire.MarketMeta{SchemaVersion: wire.SchemaVersion, EventType: "market_meta", AssetID: tickTestToken, Market: conditionID, TickSize: "0.001", OldTickSize: "0.01", Timestamp: "1789000000000"}
+	if channel != channels.MarketChannel(conditionID) || meta != wantMeta {
+		t.Errorf("published %+v on %s, want %+v on the market's channel", meta, channel, wantMeta)
+	}
+
+	if err := service.validatePrice(context.Background(), tickTestToken, 0.555); err != nil {
+		t.Errorf("price on the new 0.001 tick rejected: %v", err)
+	}
+	if err := service.validatePrice(context.Background(), tickTestToken, 0.9995); !errors.Is(err, ErrInvalidOrderPrice) {
+		t.Errorf("price off the new tick: error = %v, want %v", err, ErrInvalidOrderPrice)
+	}
+	if got := clob.lookups.Load(); got != 2 || cache.cachedTickSize(t) != 0.001 {
+		t.Errorf("%d tick size lookups, cached tick %v; want a second lookup cached as 0.001", got, cache.cachedTickSize(t))
+	}
+}
+
+// TestTickSizeRejectionRefreshesTradingParams checks that an order the CLOB rejects for its
+// tick size refreshes the cached tick, for when the tick_size_change event was missed.
+func TestTickSizeRejectionRefreshesTradingParams(t *testing.T) {
+	clob := newTickSizeCLOB(t, "0.01")
+	cache := newMemoryRedis()
+	store := &orderStore{order: db.Order{TokenID: tickTestToken, Status: OrderStatusPendingSubmission}}
+	service := newTestPolymarketService(clob.Server, store)
+	service.tradeParams = NewTradingParamsCache(nil, service.clobClient, service.logger)
+	service.tradeParams.redisClient = cache
+	if err := service.validatePrice(context.Background(), tickTestToken, 0.55); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	clob.tickSize.Store("0.1")
+	order, _, err := service.submitOrder(context.Background(), store.order, &polymarket.SignedOrder{}, "0xmaker", time.Now())
+	if err == nil || order.Status != OrderStatusRejected {
+		t.Fatalf("submitOrder = %s, %v; want a rejection", order.Status, err)
+	}
+	if tick := cache.cachedTickSize(t); tick != 0.1 {
+		t.Errorf("cached tick = %v after the rejection, want the refreshed 0.1", tick)
+	}
+	if err := service.validatePrice(context.Background(), tickTestToken, 0.55); !errors.Is(err, ErrInvalidOrderPrice) {
+		t.Errorf("price off the refreshed tick: error = %v, want %v", err, ErrInvalidOrderPrice)
+	}
+}
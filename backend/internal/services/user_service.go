@@ -16,16 +16,25 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
-	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	db "github.com/poly-pro/backend/internal/db"
 )
 
 // Pre-defined errors for the user service to ensure consistent error handling.
 var (
-	ErrUserAlreadyExists = errors.New("a user with this clerk_id or email already exists")
+	ErrUserAlreadyExists = errors.New("a user with this clerk_id already exists")
+	ErrUserEmailTaken    = errors.New("another user already has this email")
+)
+
+// Unique constraints of the users table, as named by PostgreSQL for its UNIQUE columns.
+const (
+	uniqueViolationCode    = "23505" // PostgreSQL unique_violation
+	usersClerkIDConstraint = "users_clerk_user_id_key"
+	usersEmailConstraint   = "users_email_key"
 )
 
 // UserService provides methods for user-related business logic.
@@ -59,9 +68,10 @@ func NewUserService(store db.Querier, logger *slog.Logger) *UserService {
  * @returns The newly created user record or an error.
  *
  * @notes
- * - It handles the specific database error for unique constraint violations
- *   (e.g., if a webhook is received twice) and returns a domain-specific error,
- *   `ErrUserAlreadyExists`.
+ * - Unique constraint violations are told apart by the violated constraint:
+ *   - clerk_id: the user already exists (e.g. a webhook received twice). The existing
+ *     user is returned, if it can be fetched, with `ErrUserAlreadyExists`.
+ *   - email: a different user already has the email. `ErrUserEmailTaken` is returned.
  */
 func (s *UserService) CreateUser(ctx context.Context, clerkUserID string, email string) (db.User, error) {
 	s.logger.Info("attempting to create new user", "clerk_id", clerkUserID, "email", email)
@@ -72,29 +82,32 @@ func (s *UserService) CreateUser(ctx context.Context, clerkUserID string, email
 	}
 
 	user, err := s.store.CreateUser(ctx, params)
-	if err != nil {
-		// Check for a unique constraint violation error. This is important for idempotency,
-		// as Clerk might retry sending a webhook.
-		if strings.Contains(err.Error(), "unique constraint") {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		switch pgErr.ConstraintName {
+		case usersClerkIDConstraint:
+			// The user already exists. This is important for idempotency, as Clerk might
+			// retry sending a webhook.
 			s.logger.Warn("attempted to create a user that already exists", "clerk_id", clerkUserID)
-			// Try to fetch the existing user - this might be a duplicate webhook
 			existingUser, findErr := s.store.GetUserByClerkID(ctx, clerkUserID)
 			if findErr != nil {
-				// If we can't find the user (shouldn't happen with unique constraint), 
-				// it might be a race condition or the constraint was on email instead of clerk_id
-				// Still return ErrUserAlreadyExists to indicate this is an idempotent operation
-				if errors.Is(findErr, pgx.ErrNoRows) {
-					s.logger.Warn("unique constraint violation but user not found by clerk_id - may be email conflict", "clerk_id", clerkUserID)
-					return db.User{}, ErrUserAlreadyExists
+				// The user may have been deleted since; still report it as existing, as the
+				// creation was a duplicate.
+				if !errors.Is(findErr, pgx.ErrNoRows) {
+					s.logger.Error("failed to fetch existing user after clerk_id conflict", "error", findErr, "clerk_id", clerkUserID)
 				}
-				// For other database errors, log but still treat as idempotent
-				s.logger.Error("failed to check for existing user after unique constraint violation", "error", findErr)
 				return db.User{}, ErrUserAlreadyExists
 			}
-			// User exists - return it with ErrUserAlreadyExists to indicate idempotent operation
 			return existingUser, ErrUserAlreadyExists
+		case usersEmailConstraint:
+			s.logger.Warn("cannot create user, email belongs to another user", "clerk_id", clerkUserID, "email", email)
+			return db.User{}, ErrUserEmailTaken
+		default:
+			s.logger.Error("unexpected unique constraint violation creating user", "constraint", pgErr.ConstraintName, "clerk_id", clerkUserID)
+			return db.User{}, fmt.Errorf("failed to create user: %w", err)
 		}
-
+	}
+	if err != nil {
 		s.logger.Error("failed to create user in database", "error", err)
 		return db.User{}, err
 	}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// conflictingUserStore is a db.Querier whose CreateUser fails with createErr, and which
+// holds existing, if it is set.
+type conflictingUserStore struct {
+	db.Querier
+	createErr error
+	existing  *db.User
+}
+
+func (s *conflictingUserStore) CreateUser(context.Context, db.CreateUserParams) (db.User, error) {
+	return db.User{}, s.createErr
+}
+
+func (s *conflictingUserStore) GetUserByClerkID(_ context.Context, clerkUserID string) (db.User, error) {
+	if s.existing == nil || s.existing.ClerkUserID != clerkUserID {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return *s.existing, nil
+}
+
+func TestCreateUserUniqueViolations(t *testing.T) {
+	existing := &db.User{ClerkUserID: "user_1", Email: "trader@example.com"}
+	violation := func(constraint string) error {
+		return &pgconn.PgError{Code: uniqueViolationCode, ConstraintName: constraint}
+	}
+	tests := []struct {
+		name      string
+		store     *conflictingUserStore
+		wantErr   error
+		wantUser  bool // The existing user is returned with the error
+		wantOther bool // The error is neither ErrUserAlreadyExists nor ErrUserEmailTaken
+	}{
+		{"clerk ID taken", &conflictingUserStore{createErr: violation(usersClerkIDConstraint), existing: existing},
+			ErrUserAlreadyExists, true, false},
+		// The user was deleted after the conflict; the creation is still a duplicate.
+		{"clerk ID taken by a deleted user", &conflictingUserStore{createErr: violation(usersClerkIDConstraint)},
+			ErrUserAlreadyExists, false, false},
+		{"email taken", &conflictingUserStore{createErr: violation(usersEmailConstraint), existing: existing},
+			ErrUserEmailTaken, false, false},
+		{"other constraint", &conflictingUserStore{createErr: violation("users_pkey")},
+			nil, false, true},
+		{"not a unique violation", &conflictingUserStore{createErr: &pgconn.PgError{Code: "23502", ConstraintName: usersEmailConstraint}},
+			nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewUserService(tt.store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			user, err := service.CreateUser(context.Background(), "user_1", "trader@example.com")
+			if tt.wantOther {
+				if err == nil || errors.Is(err, ErrUserAlreadyExists) || errors.Is(err, ErrUserEmailTaken) {
+					t.Fatalf("CreateUser error = %v, want another error", err)
+				}
+				if !errors.Is(err, tt.store.createErr) {
+					t.Errorf("CreateUser error %v does not wrap the database error", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateUser error = %v, want %v", err, tt.wantErr)
+			}
+			if got := user.ClerkUserID == existing.ClerkUserID; got != tt.wantUser {
+				t.Errorf("returned user %+v, want the existing user: %v", user, tt.wantUser)
+			}
+		})
+	}
+}
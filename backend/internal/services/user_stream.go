@@ -0,0 +1,125 @@
+/**
+ * @description
+ * This file implements the market stream's user channel stream. The CLOB WebSocket's user
+ * channel reports the order and trade events of the user owning the configured API key
+ * (placements, partial matches, cancellations, and fills), which are published to Redis so
+ * that the WebSocket hub can fan them out, e.g. as live fill notifications.
+ *
+ * Key features:
+ * - Per-Owner Channels: Order events are published to `user:<owner>:orders` and trade events
+ *   to `user:<owner>:trades` (built by `channels.UserOrdersChannel` and
+ *   `channels.UserTradesChannel`), where owner is the event's `owner` field, i.e. the CLOB
+ *   API key of the order's or trade's owner.
+ * - Pass-Through Payloads: Events are published as received, as the typed
+ *   `polymarket.UserOrderMessage` and `polymarket.UserTradeMessage` with a `schema_version`
+ *   added (`wire.UserOrder` and `wire.UserTrade`).
+ * - Reconnection: A dropped connection is reconnected with the same backoff as the market
+ *   channel, and the user subscription is sent again.
+ *
+ * @notes
+ * - The user channel runs on its own connection, next to the market channel's pool.
+ * - Publishing is best-effort: failures are logged and the event is dropped.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/poly-pro/backend/internal/polymarket"
+	"github.com/poly-pro/backend/internal/wire"
+)
+
+/**
+ * @description
+ * RunUserStream subscribes to the CLOB user channel for all markets and publishes its order
+ * and trade events to Redis until shutdown. It returns immediately when the CLOB
+ * credentials are not configured.
+ *
+ * @notes
+ * - It runs until the context is cancelled and should be started as a goroutine.
+ * - A failed initial connection is retried like a dropped one.
+ */
+func (s *MarketStreamService) RunUserStream() {
+	if s.userClient == nil {
+		s.logger.Info("CLOB WebSocket credentials not configured, user channel stream disabled")
+		return
+	}
+
+	s.userClient.OnUserOrder(s.handleUserOrder)
+	s.userClient.OnUserTrade(s.handleUserTrade)
+	defer s.userClient.Close()
+
+	// Close the client on shutdown so that Listen returns.
+	go func() {
+		<-s.ctx.Done()
+		s.userClient.Close()
+	}()
+
+	s.logger.Info("starting CLOB user channel stream...")
+	err := s.userClient.SubscribeUser(nil)
+	backoff := minReconnectBackoff
+	for {
+		if err == nil {
+			connectedAt := time.Now()
+			// The user channel carries no book messages.
+			err = s.userClient.Listen(func(*polymarket.BookMessage) error { return nil })
+			if err == nil || s.ctx.Err() != nil {
+				return
+			}
+			if time.Since(connectedAt) >= stableConnectionAfter {
+				backoff = minReconnectBackoff
+			}
+		}
+		s.logger.Error("CLOB user channel error", "error", err)
+
+		delay := jitterBackoff(backoff)
+		s.logger.Info("reconnecting to CLOB user channel", "delay", delay)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if backoff *= 2; backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+
+		if err = s.userClient.Reconnect(); err == nil {
+			s.logger.Info("reconnected to CLOB user channel")
+		}
+	}
+}
+
+// handleUserOrder publishes a user channel order event to its owner's orders channel.
+func (s *MarketStreamService) handleUserOrder(message *polymarket.UserOrderMessage) {
+	event := wire.UserOrder{SchemaVersion: wire.SchemaVersion, UserOrderMessage: *message}
+	s.publishUserEvent(message.Owner, channels.UserOrdersChannel(message.Owner), event)
+}
+
+// handleUserTrade publishes a user channel trade event to its owner's trades channel.
+func (s *MarketStreamService) handleUserTrade(message *polymarket.UserTradeMessage) {
+	event := wire.UserTrade{SchemaVersion: wire.SchemaVersion, UserTradeMessage: *message}
+	s.publishUserEvent(message.Owner, channels.UserTradesChannel(message.Owner), event)
+}
+
+// publishUserEvent publishes a user channel event to a channel of its owner.
+// Events without an owner cannot be routed and are dropped.
+func (s *MarketStreamService) publishUserEvent(owner string, channel string, event any) {
+	if owner == "" {
+		s.logger.Warn("dropping CLOB user channel event without an owner", "channel", channel)
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to marshal CLOB user channel event", "error", err, "channel", channel)
+		return
+	}
+	if err := publish(s.ctx, s.redisClient, s.publishBuffer, channel, payload, PublishDurable); err != nil {
+		s.logger.Error("failed to publish CLOB user channel event to redis", "error", err, "channel", channel)
+		return
+	}
+	s.userEventsPublished.Add(1)
+}
@@ -0,0 +1,224 @@
+/**
+ * @description
+ * This file contains the business logic for verifying that a user controls the
+ * Polymarket funder address registered on one of their wallets.
+ *
+ * Key features:
+ * - Signed Challenges: Issues a random, single-use nonce message that the user signs
+ *   with the wallet's key (e.g., `personal_sign` in the browser).
+ * - EIP-191 Recovery: Recovers the signing address from the signature and compares it to
+ *   the wallet's funder address.
+ * - Replay Protection: Challenges expire after a short window and are consumed atomically,
+ *   so a signature can only ever be used once.
+ *
+ * @notes
+ * - Only verified wallets are returned by `GetActiveWalletByUserID`, so an unverified wallet
+ *   can never be used as the maker of an order. Wallets that were active before verification
+ *   was introduced are marked verified by migration 000003.
+ * - A challenge is consumed before the signature is checked, so a failed attempt requires
+ *   requesting a new challenge. This prevents repeated guessing against the same nonce.
+ */
+
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// walletChallengeTTL is how long a wallet verification challenge remains valid.
+const walletChallengeTTL = 10 * time.Minute
+
+// Pre-defined errors for the wallet service to ensure consistent error handling.
+var (
+	ErrWalletNotFound           = errors.New("wallet not found")
+	ErrWalletAlreadyVerified    = errors.New("wallet is already verified")
+	ErrChallengeInvalid         = errors.New("challenge is invalid, expired, or already used")
+	ErrInvalidSignature         = errors.New("signature is malformed")
+	ErrSignatureAddressMismatch = errors.New("signature was not produced by the wallet's address")
+)
+
+// WalletService provides methods for wallet-related business logic.
+type WalletService struct {
+	store  db.Querier
+	logger *slog.Logger
+}
+
+/**
+ * @description
+ * NewWalletService creates a new instance of the WalletService.
+ *
+ * @param store The database querier for database operations.
+ * @param logger A structured logger for logging service-level events.
+ * @returns A pointer to a new WalletService instance.
+ */
+func NewWalletService(store db.Querier, logger *slog.Logger) *WalletService {
+	return &WalletService{
+		store:  store,
+		logger: logger,
+	}
+}
+
+/**
+ * @description
+ * CreateChallenge issues a new verification challenge for one of the user's wallets.
+ *
+ * @param ctx The context for the database operations.
+ * @param clerkUserID The authenticated user's Clerk ID.
+ * @param walletID The ID of the wallet to verify.
+ * @returns The stored challenge, whose message must be signed by the wallet's key.
+ */
+func (s *WalletService) CreateChallenge(ctx context.Context, clerkUserID string, walletID pgtype.UUID) (db.WalletChallenge, error) {
+	wallet, err := s.getUserWallet(ctx, clerkUserID, walletID)
+	if err != nil {
+		return db.WalletChallenge{}, err
+	}
+	if wallet.VerifiedAt.Valid {
+		return db.WalletChallenge{}, ErrWalletAlreadyVerified
+	}
+
+	nonceBytes := make([]byte, 32)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return db.WalletChallenge{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	expiresAt := time.Now().UTC().Add(walletChallengeTTL)
+
+	challenge, err := s.store.CreateWalletChallenge(ctx, db.CreateWalletChallengeParams{
+		WalletID:  wallet.ID,
+		Nonce:     nonce,
+		Message:   walletChallengeMessage(wallet.PolymarketFunderAddress, nonce, expiresAt),
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		s.logger.Error("failed to create wallet challenge", "error", err, "wallet_id", wallet.ID)
+		return db.WalletChallenge{}, err
+	}
+
+	s.logger.Info("wallet challenge issued", "wallet_id", wallet.ID, "expires_at", expiresAt)
+	return challenge, nil
+}
+
+/**
+ * @description
+ * VerifyChallenge checks a signed challenge and marks the wallet as verified on success.
+ *
+ * @param ctx The context for the database operations.
+ * @param clerkUserID The authenticated user's Clerk ID.
+ * @param walletID The ID of the wallet being verified.
+ * @param nonce The nonce of the challenge that was signed.
+ * @param signature The 65-byte EIP-191 signature, hex-encoded with a 0x prefix.
+ * @returns The updated wallet record or an error.
+ */
+func (s *WalletService) VerifyChallenge(ctx context.Context, clerkUserID string, walletID pgtype.UUID, nonce string, signature string) (db.Wallet, error) {
+	wallet, err := s.getUserWallet(ctx, clerkUserID, walletID)
+	if err != nil {
+		return db.Wallet{}, err
+	}
+	if wallet.VerifiedAt.Valid {
+		return db.Wallet{}, ErrWalletAlreadyVerified
+	}
+
+	challenge, err := s.store.ConsumeWalletChallenge(ctx, db.ConsumeWalletChallengeParams{
+		WalletID: wallet.ID,
+		Nonce:    nonce,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Wallet{}, ErrChallengeInvalid
+		}
+		s.logger.Error("failed to consume wallet challenge", "error", err, "wallet_id", wallet.ID)
+		return db.Wallet{}, err
+	}
+
+	recovered, err := recoverPersonalSignAddress(challenge.Message, signature)
+	if err != nil {
+		return db.Wallet{}, err
+	}
+	if recovered != common.HexToAddress(wallet.PolymarketFunderAddress) {
+		s.logger.Warn("wallet verification signature mismatch", "wallet_id", wallet.ID, "recovered_address", recovered.Hex())
+		return db.Wallet{}, ErrSignatureAddressMismatch
+	}
+
+	verified, err := s.store.MarkWalletVerified(ctx, wallet.ID)
+	if err != nil {
+		s.logger.Error("failed to mark wallet verified", "error", err, "wallet_id", wallet.ID)
+		return db.Wallet{}, err
+	}
+
+	s.logger.Info("wallet ownership verified", "wallet_id", wallet.ID, "address", wallet.PolymarketFunderAddress)
+	return verified, nil
+}
+
+// getUserWallet fetches a wallet, ensuring it belongs to the given Clerk user.
+func (s *WalletService) getUserWallet(ctx context.Context, clerkUserID string, walletID pgtype.UUID) (db.Wallet, error) {
+	user, err := s.store.GetUserByClerkID(ctx, clerkUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Wallet{}, ErrWalletNotFound
+		}
+		return db.Wallet{}, err
+	}
+
+	wallet, err := s.store.GetWalletByIDAndUserID(ctx, db.GetWalletByIDAndUserIDParams{
+		ID:     walletID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Wallet{}, ErrWalletNotFound
+		}
+		return db.Wallet{}, err
+	}
+	return wallet, nil
+}
+
+// walletChallengeMessage builds the human-readable message the user is asked to sign.
+func walletChallengeMessage(address string, nonce string, expiresAt time.Time) string {
+	return fmt.Sprintf(
+		"Poly-Pro wants you to verify ownership of this wallet.\n\nAddress: %s\nNonce: %s\nExpires: %s",
+		address, nonce, expiresAt.Format(time.RFC3339),
+	)
+}
+
+/**
+ * @description
+ * recoverPersonalSignAddress recovers the address that produced an EIP-191
+ * (`personal_sign`) signature over the given message.
+ *
+ * @param message The plain-text message that was signed.
+ * @param signatureHex The 65-byte signature, hex-encoded with a 0x prefix.
+ * @returns The recovered address or ErrInvalidSignature.
+ *
+ * @notes
+ * - Wallets produce a recovery id (v) of 27/28, while go-ethereum expects 0/1, so it is normalized.
+ */
+func recoverPersonalSignAddress(message string, signatureHex string) (common.Address, error) {
+	signature, err := hexutil.Decode(strings.TrimSpace(signatureHex))
+	if err != nil || len(signature) != crypto.SignatureLength {
+		return common.Address{}, ErrInvalidSignature
+	}
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), signature)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/poly-pro/backend/internal/db"
+)
+
+// walletStore is an in-memory db.Querier holding one user and their wallets, with the
+// challenge semantics of the wallet queries.
+type walletStore struct {
+	db.Querier
+	user       db.User
+	wallets    map[pgtype.UUID]db.Wallet
+	challenges []db.WalletChallenge
+}
+
+func (s *walletStore) GetUserByClerkID(_ context.Context, clerkUserID string) (db.User, error) {
+	if clerkUserID != s.user.ClerkUserID {
+		return db.User{}, pgx.ErrNoRows
+	}
+	return s.user, nil
+}
+
+func (s *walletStore) GetWalletByIDAndUserID(_ context.Context, arg db.GetWalletByIDAndUserIDParams) (db.Wallet, error) {
+	wallet, ok := s.wallets[arg.ID]
+	if !ok || wallet.UserID != arg.UserID {
+		return db.Wallet{}, pgx.ErrNoRows
+	}
+	return wallet, nil
+}
+
+func (s *walletStore) CreateWalletChallenge(_ context.Context, arg db.CreateWalletChallengeParams) (db.WalletChallenge, error) {
+	challenge := db.WalletChallenge{WalletID: arg.WalletID, Nonce: arg.Nonce, Message: arg.Message, ExpiresAt: arg.ExpiresAt}
+	s.challenges = append(s.challenges, challenge)
+	return challenge, nil
+}
+
+func (s *walletStore) ConsumeWalletChallenge(_ context.Context, arg db.ConsumeWalletChallengeParams) (db.WalletChallenge, error) {
+	for i, challenge := range s.challenges {
+		if challenge.WalletID == arg.WalletID && challenge.Nonce == arg.Nonce && !challenge.ConsumedAt.Valid && challenge.ExpiresAt.Time.After(time.Now()) {
+			s.challenges[i].ConsumedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+			return s.challenges[i], nil
+		}
+	}
+	return db.WalletChallenge{}, pgx.ErrNoRows
+}
+
+func (s *walletStore) MarkWalletVerified(_ context.Context, id pgtype.UUID) (db.Wallet, error) {
+	wallet := s.wallets[id]
+	wallet.VerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	s.wallets[id] = wallet
+	return wallet, nil
+}
+
+// walletFixture is a wallet service with one user owning two unverified wallets.
+type walletFixture struct {
+	service        *WalletService
+	store          *walletStore
+	key, otherKey  *ecdsa.PrivateKey
+	wallet, second pgtype.UUID
+}
+
+const testClerkUserID = "user_test"
+
+func newWalletFixture(t *testing.T) *walletFixture {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	userID := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	walletID := pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+	secondID := pgtype.UUID{Bytes: [16]byte{3}, Valid: true}
+	store := &walletStore{
+		user: db.User{ID: userID, ClerkUserID: testClerkUserID},
+		wallets: map[pgtype.UUID]db.Wallet{
+			walletID: {ID: walletID, UserID: userID, PolymarketFunderAddress: crypto.PubkeyToAddress(key.PublicKey).Hex(), IsActive: true},
+			secondID: {ID: secondID, UserID: userID, PolymarketFunderAddress: crypto.PubkeyToAddress(otherKey.PublicKey).Hex()},
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &walletFixture{
+		service:  NewWalletService(store, logger),
+		store:    store,
+		key:      key,
+		otherKey: otherKey,
+		wallet:   walletID,
+		second:   secondID,
+	}
+}
+
+// sign returns the personal_sign signature of message, with a recovery id of 27/28 like wallets produce.
+func sign(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	t.Helper()
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("valid signature verifies the wallet", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, err := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		if err != nil {
+			t.Fatalf("CreateChallenge: %v", err)
+		}
+		wallet, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, sign(t, f.key, challenge.Message))
+		if err != nil {
+			t.Fatalf("VerifyChallenge: %v", err)
+		}
+		if !wallet.VerifiedAt.Valid {
+			t.Error("wallet not marked verified")
+		}
+		if _, err := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet); !errors.Is(err, ErrWalletAlreadyVerified) {
+			t.Errorf("CreateChallenge after verification: %v, want %v", err, ErrWalletAlreadyVerified)
+		}
+	})
+
+	t.Run("signature of another key is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		_, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, sign(t, f.otherKey, challenge.Message))
+		if !errors.Is(err, ErrSignatureAddressMismatch) {
+			t.Errorf("VerifyChallenge: %v, want %v", err, ErrSignatureAddressMismatch)
+		}
+		if f.store.wallets[f.wallet].VerifiedAt.Valid {
+			t.Error("wallet marked verified")
+		}
+	})
+
+	t.Run("signature of another message is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		_, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, sign(t, f.key, challenge.Message+"\n"))
+		if !errors.Is(err, ErrSignatureAddressMismatch) {
+			t.Errorf("VerifyChallenge: %v, want %v", err, ErrSignatureAddressMismatch)
+		}
+	})
+
+	t.Run("malformed signature is rejected", func(t *testing.T) {
+		for _, signature := range []string{"", "0x1234", "not hex", "0x00"} {
+			f := newWalletFixture(t)
+			challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+			if _, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, signature); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifyChallenge(%q): %v, want %v", signature, err, ErrInvalidSignature)
+			}
+		}
+	})
+
+	t.Run("replayed signature is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		signature := sign(t, f.key, challenge.Message)
+
+		// A failed attempt consumes the challenge, so the valid signature cannot be used after it.
+		if _, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, "0x00"); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("first attempt: %v, want %v", err, ErrInvalidSignature)
+		}
+		if _, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, signature); !errors.Is(err, ErrChallengeInvalid) {
+			t.Errorf("replay: %v, want %v", err, ErrChallengeInvalid)
+		}
+	})
+
+	t.Run("signature replayed after verification is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		signature := sign(t, f.key, challenge.Message)
+		if _, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, signature); err != nil {
+			t.Fatalf("VerifyChallenge: %v", err)
+		}
+
+		// Even with the verification undone, the consumed challenge cannot be used again.
+		wallet := f.store.wallets[f.wallet]
+		wallet.VerifiedAt = pgtype.Timestamptz{}
+		f.store.wallets[f.wallet] = wallet
+		if _, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, signature); !errors.Is(err, ErrChallengeInvalid) {
+			t.Errorf("replay: %v, want %v", err, ErrChallengeInvalid)
+		}
+	})
+
+	t.Run("challenge of another wallet is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.second)
+		_, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, sign(t, f.key, challenge.Message))
+		if !errors.Is(err, ErrChallengeInvalid) {
+			t.Errorf("VerifyChallenge: %v, want %v", err, ErrChallengeInvalid)
+		}
+	})
+
+	t.Run("expired challenge is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		f.store.challenges[0].ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true}
+		_, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, challenge.Nonce, sign(t, f.key, challenge.Message))
+		if !errors.Is(err, ErrChallengeInvalid) {
+			t.Errorf("VerifyChallenge: %v, want %v", err, ErrChallengeInvalid)
+		}
+	})
+
+	t.Run("unknown nonce is rejected", func(t *testing.T) {
+		f := newWalletFixture(t)
+		challenge, _ := f.service.CreateChallenge(ctx, testClerkUserID, f.wallet)
+		_, err := f.service.VerifyChallenge(ctx, testClerkUserID, f.wallet, "unknown", sign(t, f.key, challenge.Message))
+		if !errors.Is(err, ErrChallengeInvalid) {
+			t.Errorf("VerifyChallenge: %v, want %v", err, ErrChallengeInvalid)
+		}
+	})
+
+	t.Run("wallet of another user is not found", func(t *testing.T) {
+		f := newWalletFixture(t)
+		if _, err := f.service.CreateChallenge(ctx, "user_other", f.wallet); !errors.Is(err, ErrWalletNotFound) {
+			t.Errorf("CreateChallenge: %v, want %v", err, ErrWalletNotFound)
+		}
+	})
+}
+
+func TestCreateChallengeExpiry(t *testing.T) {
+	f := newWalletFixture(t)
+	before := time.Now()
+	challenge, err := f.service.CreateChallenge(context.Background(), testClerkUserID, f.wallet)
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	expiresAt := challenge.ExpiresAt.Time
+	if expiresAt.Before(before.Add(walletChallengeTTL).Add(-time.Second)) || expiresAt.After(time.Now().Add(walletChallengeTTL)) {
+		t.Errorf("expires at %s, want %s after issuing", expiresAt, walletChallengeTTL)
+	}
+	if want := walletChallengeMessage(f.store.wallets[f.wallet].PolymarketFunderAddress, challenge.Nonce, expiresAt); challenge.Message != want {
+		t.Errorf("message = %q, want %q", challenge.Message, want)
+	}
+	if len(challenge.Nonce) != 64 {
+		t.Errorf("nonce %q is not 32 hex-encoded bytes", challenge.Nonce)
+	}
+}
@@ -0,0 +1,121 @@
+/**
+ * @description
+ * This package provides a small registry for long-running background goroutines, so that
+ * the process can verify that all of them have stopped before it exits.
+ *
+ * Key features:
+ * - Tracked Goroutines: `Go` starts a named task and tracks it with a WaitGroup.
+ * - Shared Cancellation: All tasks observe the manager's context, which is cancelled by
+ *   `Shutdown` (or when the parent context is cancelled).
+ * - Bounded Teardown: `Shutdown` waits for every task to return, or until its context
+ *   expires, and reports the tasks that are still running.
+ *
+ * @notes
+ * - Tasks must return promptly once the manager's context is cancelled.
+ */
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Manager tracks background tasks and stops them on shutdown.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *slog.Logger
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]int // task name -> number of running instances
+}
+
+/**
+ * @description
+ * NewManager creates a new task Manager.
+ *
+ * @param parent The parent context; cancelling it also stops all tasks.
+ * @param logger A structured logger for task lifecycle events.
+ * @returns A pointer to a new Manager instance.
+ */
+func NewManager(parent context.Context, logger *slog.Logger) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logger,
+		running: make(map[string]int),
+	}
+}
+
+// Context returns the context observed by all tasks. It is cancelled on shutdown.
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs fn in a new tracked goroutine. The name is used in logs and shutdown reports.
+func (m *Manager) Go(name string, fn func()) {
+	m.mu.Lock()
+	m.running[name]++
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+			m.logger.Info("background task stopped", "task", name)
+		}()
+		m.logger.Info("background task started", "task", name)
+		fn()
+	}()
+}
+
+/**
+ * @description
+ * Shutdown cancels the tasks' context and waits for all tasks to return.
+ *
+ * @param ctx Bounds how long to wait for the tasks.
+ * @returns An error naming the tasks still running if ctx expires first.
+ */
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	start := time.Now()
+	select {
+	case <-done:
+		m.logger.Info("all background tasks stopped", "duration", time.Since(start))
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks did not stop in time: %s", strings.Join(m.Running(), ", "))
+	}
+}
+
+// Running returns the names of the tasks that have not returned yet, sorted.
+func (m *Manager) Running() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -8,6 +8,26 @@
  * - Connection Management: Wraps a `gorilla/websocket` connection.
  * - Concurrency: Uses channels and goroutines for non-blocking read and write operations.
  * - Subscription Handling: Maintains a set of market IDs that the client is subscribed to.
+ *   Identifiers are validated by the hub; slugs are translated to condition IDs and
+ *   acknowledged with a `subscribed` message, unknown identifiers get an error frame.
+ * - Plans: Each client carries the plan (identity class) it connected with. Subscriptions
+ *   beyond the plan's cap are rejected with a `subscription_limit` error frame, and
+ *   requested throttles are raised to the plan's floor.
+ * - Resubscribe Prompt: A client that pings or sends a message other than `subscribe` before
+ *   subscribing to anything on this connection is sent `{"type":"resubscribe_required"}`
+ *   once. After a backend restart behind a proxy that kept the client's connection open, the
+ *   new hub has no record of its subscriptions, and the prompt makes the client re-send them.
+ * - Subscription Listing: `{"type":"list_subscriptions"}` is answered with a `subscriptions`
+ *   message listing the condition IDs the client is subscribed to, so that a client can
+ *   reconcile its own state with the server's.
+ * - Message Size: A message may list at most `maxMarketIDsPerMessage` market IDs; larger
+ *   ones are rejected whole with a `too_many_market_ids` error frame. The read limit is sized
+ *   to fit such a message, and the hub paces the Redis listeners of the resulting burst.
+ * - Bar Subscriptions: `{"type":"subscribe_bars","market_ids":[...],"resolution":"5"}`
+ *   subscribes to the completed OHLCV bars of markets at one of the enabled resolutions,
+ *   acknowledged with a `bars_subscribed` message; `unsubscribe_bars` ends them. Bars are
+ *   sent as they complete, in the t/o/h/l/c/v shape of the history endpoint, and count
+ *   towards the plan's subscription cap like book subscriptions.
  * - Graceful Shutdown: The read and write pumps are designed to clean up and unregister
  *   the client when the connection is closed.
  *
@@ -26,11 +46,17 @@ package websocket
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/poly-pro/backend/internal/logsafe"
 )
 
 const (
@@ -43,8 +69,16 @@ const (
 	// Send pings to peer with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	// Maximum message size allowed from peer. It fits a subscribe message with
+	// maxMarketIDsPerMessage identifiers of up to 150 bytes, well above a condition ID's 66.
+	maxMessageSize = 32 * 1024
+
+	// Maximum number of market identifiers in one message. It stays below the Send buffer of
+	// a connection, so that the acknowledgements of one message are never dropped.
+	maxMarketIDsPerMessage = 200
+
+	// Maximum per-subscription throttle interval a client may request.
+	maxThrottle = time.Minute
 )
 
 // Client is a middleman between the websocket connection and the hub.
@@ -52,14 +86,75 @@ type Client struct {
 	Hub          *Hub
 	Conn         *websocket.Conn
 	Send         chan []byte
-	Subscriptions map[string]bool
+	Subscriptions map[string]bool // Market IDs, and OHLCV channel names for bar subscriptions
 	Logger       *slog.Logger
+	Traffic      *TrafficCounter // Counts the bytes written to the connection; may be nil
+	Plan         Plan            // Identity class, set at upgrade; empty means anonymous
+	UserID       string          // Clerk user ID of authenticated clients
+
+	// Connection metadata captured at upgrade, for diagnosing connection issues.
+	ClientIP     string // Real client IP, resolved from X-Forwarded-For / X-Real-IP behind a proxy
+	ForwardedFor string // Raw X-Forwarded-For header, empty when not behind a proxy
+	UserAgent    string
+
+	// Per-market throttle state; throttleMu also guards closing Send.
+	throttleMu sync.Mutex
+	throttles  map[string]*conflator
+	sendClosed bool
+
+	// Resubscribe prompt state, only accessed from the read pump.
+	hasSubscribed       bool // The client subscribed at least once on this connection
+	resubscribePrompted bool // resubscribe_required has already been sent
 }
 
 // subscriptionMessage defines the structure for incoming subscription requests from the client.
 type subscriptionMessage struct {
-	Type      string   `json:"type"` // e.g., "subscribe", "unsubscribe"
-	MarketIDs []string `json:"market_ids"`
+	Type       string   `json:"type"` // e.g., "subscribe", "unsubscribe", "subscribe_bars", "list_subscriptions"
+	MarketIDs  []string `json:"market_ids"`
+	ThrottleMs int      `json:"throttle_ms,omitempty"` // Optional: deliver at most one update per interval per market
+	Resolution string   `json:"resolution,omitempty"`  // Bar resolution of subscribe_bars and unsubscribe_bars
+}
+
+// errorMessage is sent to the client when one of its requests is rejected.
+type errorMessage struct {
+	Type       string `json:"type"` // always "error"
+	Code       string `json:"code"`
+	MarketID   string `json:"market_id,omitempty"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"` // A known identifier close to MarketID
+}
+
+// subscribedMessage acknowledges a subscription with the canonical market ID.
+type subscribedMessage struct {
+	Type        string `json:"type"`                   // always "subscribed"
+	MarketID    string `json:"market_id"`              // The condition ID the client is subscribed to
+	RequestedID string `json:"requested_id,omitempty"` // The identifier sent by the client, if it was translated
+}
+
+// barsSubscribedMessage acknowledges a bar subscription with the canonical market ID.
+type barsSubscribedMessage struct {
+	Type        string `json:"type"`                   // always "bars_subscribed"
+	MarketID    string `json:"market_id"`              // The condition ID the client is subscribed to
+	Resolution  string `json:"resolution"`
+	RequestedID string `json:"requested_id,omitempty"` // The identifier sent by the client, if it was translated
+}
+
+// barSubscription identifies a bar subscription in a subscriptions message.
+type barSubscription struct {
+	MarketID   string `json:"market_id"`
+	Resolution string `json:"resolution"`
+}
+
+// subscriptionsMessage answers list_subscriptions with the client's current subscriptions.
+type subscriptionsMessage struct {
+	Type      string            `json:"type"`       // always "subscriptions"
+	MarketIDs []string          `json:"market_ids"` // Subscribed condition IDs, sorted
+	Bars      []barSubscription `json:"bars"`       // Bar subscriptions, sorted by market ID and resolution
+}
+
+// resubscribeRequiredMessage asks the client to re-send its subscriptions.
+type resubscribeRequiredMessage struct {
+	Type string `json:"type"` // always "resubscribe_required"
 }
 
 // ReadPump pumps messages from the websocket connection to the hub.
@@ -75,6 +170,17 @@ func (c *Client) ReadPump() {
 	c.Conn.SetReadLimit(maxMessageSize)
 	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.Conn.SetPongHandler(func(string) error { c.Conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	c.Conn.SetPingHandler(func(appData string) error {
+		c.promptResubscribe()
+		// Reply like the default ping handler.
+		err := c.Conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(writeWait))
+		if err == websocket.ErrCloseSent {
+			return nil
+		} else if e, ok := err.(net.Error); ok && e.Temporary() {
+			return nil
+		}
+		return err
+	})
 
 	messageCount := 0
 	for {
@@ -87,26 +193,19 @@ func (c *Client) ReadPump() {
 		}
 		messageCount++
 		if messageCount == 1 {
-			c.Logger.Info("✅ client: received first message", "remote_addr", c.Conn.RemoteAddr(), "message_size", len(message), "message_preview", string(message[:min(len(message), 200)]))
+			c.Logger.Info("✅ client: received first message", "remote_addr", c.Conn.RemoteAddr(), "message_size", len(message), "message_preview", logsafe.Body(message))
 		}
 		c.handleMessage(message)
 	}
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 // handleMessage processes incoming messages from the client, such as subscription requests.
 func (c *Client) handleMessage(message []byte) {
-	c.Logger.Info("🔍 client: processing message", "remote_addr", c.Conn.RemoteAddr(), "message_size", len(message), "raw_message", string(message))
+	c.Logger.Info("🔍 client: processing message", "remote_addr", c.Conn.RemoteAddr(), "message_size", len(message), "message", logsafe.Body(message))
 	
 	var msg subscriptionMessage
 	if err := json.Unmarshal(message, &msg); err != nil {
-		c.Logger.Warn("❌ client: failed to unmarshal message", "error", err, "message", string(message), "remote_addr", c.Conn.RemoteAddr())
+		c.Logger.Warn("❌ client: failed to unmarshal message", "error", err, "message", logsafe.Body(message), "remote_addr", c.Conn.RemoteAddr())
 		return
 	}
 
@@ -116,8 +215,32 @@ func (c *Client) handleMessage(message []byte) {
 		"markets_count", len(msg.MarketIDs), 
 		"client_addr", c.Conn.RemoteAddr())
 
+	if msg.Type != "subscribe" {
+		c.promptResubscribe()
+	}
+
+	if len(msg.MarketIDs) > maxMarketIDsPerMessage {
+		c.Logger.Warn("client: message rejected, too many market IDs", "type", msg.Type, "markets_count", len(msg.MarketIDs), "limit", maxMarketIDsPerMessage, "client_addr", c.Conn.RemoteAddr())
+		c.sendError(errorMessage{
+			Code:    "too_many_market_ids",
+			Message: fmt.Sprintf("a message may list at most %d market IDs; split larger requests", maxMarketIDsPerMessage),
+		})
+		return
+	}
+
 	switch msg.Type {
 	case "subscribe":
+		c.hasSubscribed = true
+		limits := c.Hub.LimitsFor(c.plan())
+		throttle := time.Duration(msg.ThrottleMs) * time.Millisecond
+		if throttle < 0 {
+			throttle = 0
+		} else if throttle > maxThrottle {
+			throttle = maxThrottle
+		}
+		if throttle < limits.MinThrottle {
+			throttle = limits.MinThrottle
+		}
 		for _, marketID := range msg.MarketIDs {
 			// Normalize market ID (trim whitespace)
 			normalizedMarketID := strings.TrimSpace(marketID)
@@ -127,6 +250,20 @@ func (c *Client) handleMessage(message []byte) {
 					"normalized", normalizedMarketID)
 			}
 			
+			conditionID, ok := c.admitMarket(normalizedMarketID, limits, func(conditionID string) string { return conditionID })
+			if !ok {
+				continue
+			}
+			ack := subscribedMessage{Type: "subscribed", MarketID: conditionID}
+			if conditionID != normalizedMarketID {
+				c.Logger.Info("client: translated market identifier to condition ID", "requested_id", normalizedMarketID, "condition_id", conditionID)
+				ack.RequestedID = normalizedMarketID
+				normalizedMarketID = conditionID
+			}
+
+			// Apply (or clear) the throttle before subscribing so the first broadcast honours it.
+			c.setThrottle(normalizedMarketID, throttle)
+
 			if !c.Subscriptions[normalizedMarketID] {
 				c.Subscriptions[normalizedMarketID] = true
 				c.Logger.Info("📥 client: sending subscription to hub", 
@@ -138,21 +275,181 @@ func (c *Client) handleMessage(message []byte) {
 			} else {
 				c.Logger.Debug("client already subscribed to market", "market_id", normalizedMarketID)
 			}
+			c.sendControl(ack, "subscribed")
 		}
 	case "unsubscribe":
 		for _, marketID := range msg.MarketIDs {
-			// Normalize market ID (trim whitespace)
+			// Normalize market ID (trim whitespace) and translate slugs like subscribe does
 			normalizedMarketID := strings.TrimSpace(marketID)
+			if conditionID, _, ok := c.Hub.ResolveMarketID(normalizedMarketID); ok {
+				normalizedMarketID = conditionID
+			}
 			if c.Subscriptions[normalizedMarketID] {
 				delete(c.Subscriptions, normalizedMarketID)
+				c.setThrottle(normalizedMarketID, 0)
 				c.Hub.Unsubscribe <- subscription{client: c, marketID: normalizedMarketID}
 			}
 		}
+	case "subscribe_bars":
+		c.hasSubscribed = true
+		if !c.Hub.IsBarResolution(msg.Resolution) {
+			c.sendError(errorMessage{
+				Code:    "unknown_resolution",
+				Message: fmt.Sprintf("bars are not available at resolution %q", msg.Resolution),
+			})
+			return
+		}
+		limits := c.Hub.LimitsFor(c.plan())
+		barsKey := func(conditionID string) string { return channels.OHLCVChannel(conditionID, msg.Resolution) }
+		for _, marketID := range msg.MarketIDs {
+			requestedID := strings.TrimSpace(marketID)
+			conditionID, ok := c.admitMarket(requestedID, limits, barsKey)
+			if !ok {
+				continue
+			}
+			ack := barsSubscribedMessage{Type: "bars_subscribed", MarketID: conditionID, Resolution: msg.Resolution}
+			if conditionID != requestedID {
+				ack.RequestedID = requestedID
+			}
+
+			key := barsKey(conditionID)
+			if !c.Subscriptions[key] {
+				c.Subscriptions[key] = true
+				c.Logger.Info("📥 client: sending bar subscription to hub", "market_id", conditionID, "resolution", msg.Resolution, "client_addr", c.Conn.RemoteAddr())
+				c.Hub.Subscribe <- subscription{client: c, marketID: key}
+			}
+			c.sendControl(ack, "bars_subscribed")
+		}
+	case "unsubscribe_bars":
+		for _, marketID := range msg.MarketIDs {
+			conditionID := strings.TrimSpace(marketID)
+			if resolved, _, ok := c.Hub.ResolveMarketID(conditionID); ok {
+				conditionID = resolved
+			}
+			key := channels.OHLCVChannel(conditionID, msg.Resolution)
+			if c.Subscriptions[key] {
+				delete(c.Subscriptions, key)
+				c.Hub.Unsubscribe <- subscription{client: c, marketID: key}
+			}
+		}
+	case "list_subscriptions":
+		marketIDs := make([]string, 0, len(c.Subscriptions))
+		bars := make([]barSubscription, 0)
+		for key := range c.Subscriptions {
+			if marketID, resolution, ok := channels.ParseOHLCVChannel(key); ok {
+				bars = append(bars, barSubscription{MarketID: marketID, Resolution: resolution})
+				continue
+			}
+			marketIDs = append(marketIDs, key)
+		}
+		sort.Strings(marketIDs)
+		sort.Slice(bars, func(i, j int) bool {
+			if bars[i].MarketID != bars[j].MarketID {
+				return bars[i].MarketID < bars[j].MarketID
+			}
+			return bars[i].Resolution < bars[j].Resolution
+		})
+		c.sendControl(subscriptionsMessage{Type: "subscriptions", MarketIDs: marketIDs, Bars: bars}, "subscriptions")
+	case "ping":
+		// Application-level keepalive; the resubscribe prompt above is its only effect.
 	default:
 		c.Logger.Warn("received unknown message type from client", "type", msg.Type)
 	}
 }
 
+/**
+ * @description
+ * admitMarket validates a market identifier a client subscribes with: it must resolve to a
+ * known condition ID, be allowed, and fit within the plan's subscription cap. Rejections are
+ * sent to the client as error frames.
+ *
+ * @param requestedID The identifier sent by the client, with whitespace trimmed.
+ * @param limits The limits of the client's plan.
+ * @param keyFor Maps the condition ID to the key of the subscription in Subscriptions.
+ * @returns The condition ID, and whether the subscription may proceed.
+ */
+func (c *Client) admitMarket(requestedID string, limits PlanLimits, keyFor func(conditionID string) string) (string, bool) {
+	conditionID, suggestion, ok := c.Hub.ResolveMarketID(requestedID)
+	if !ok {
+		c.Logger.Warn("client: subscription rejected, unknown market identifier", "market_id", requestedID, "suggestion", suggestion, "client_addr", c.Conn.RemoteAddr())
+		c.sendError(errorMessage{
+			Code:       "unknown_market",
+			MarketID:   requestedID,
+			Message:    "market identifier is not a condition ID or a known market slug",
+			Suggestion: suggestion,
+		})
+		return "", false
+	}
+
+	if !c.Hub.IsMarketAllowed(conditionID) {
+		c.Logger.Warn("client: subscription rejected, market not in allow-list", "market_id", conditionID, "client_addr", c.Conn.RemoteAddr())
+		c.sendError(errorMessage{
+			Code:     "market_not_allowed",
+			MarketID: conditionID,
+			Message:  "subscriptions to this market are not allowed",
+		})
+		return "", false
+	}
+
+	if !c.Subscriptions[keyFor(conditionID)] && limits.MaxSubscriptions > 0 && len(c.Subscriptions) >= limits.MaxSubscriptions {
+		c.Logger.Warn("client: subscription rejected, plan limit reached", "market_id", conditionID, "plan", c.plan(), "limit", limits.MaxSubscriptions, "client_addr", c.Conn.RemoteAddr())
+		c.sendError(errorMessage{
+			Code:     "subscription_limit",
+			MarketID: conditionID,
+			Message:  fmt.Sprintf("the %s plan allows at most %d subscriptions", c.plan(), limits.MaxSubscriptions),
+		})
+		return "", false
+	}
+	return conditionID, true
+}
+
+// plan returns the client's plan, defaulting to anonymous.
+func (c *Client) plan() Plan {
+	if c.Plan == "" {
+		return PlanAnonymous
+	}
+	return c.Plan
+}
+
+// promptResubscribe sends resubscribe_required, once per connection, to a client that has
+// not subscribed to anything on this connection.
+func (c *Client) promptResubscribe() {
+	if c.hasSubscribed || c.resubscribePrompted {
+		return
+	}
+	c.resubscribePrompted = true
+	c.Logger.Info("client: no subscriptions on this connection, requesting resubscribe", "client_addr", c.Conn.RemoteAddr())
+	c.sendControl(resubscribeRequiredMessage{Type: "resubscribe_required"}, "resubscribe_required")
+}
+
+// sendError queues an error message for the client without blocking.
+// It is a no-op if the client's Send channel has already been closed.
+func (c *Client) sendError(msg errorMessage) {
+	msg.Type = "error"
+	c.sendControl(msg, msg.Code)
+}
+
+// sendControl queues a control message (error, ack) for the client without blocking.
+// It is a no-op if the client's Send channel has already been closed.
+func (c *Client) sendControl(msg interface{}, code string) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		c.Logger.Error("failed to marshal control message", "error", err, "code", code)
+		return
+	}
+
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	if c.sendClosed {
+		return
+	}
+	select {
+	case c.Send <- payload:
+	default:
+		c.Logger.Warn("client send buffer full, dropping control message", "code", code, "client_addr", c.Conn.RemoteAddr())
+	}
+}
+
 // WritePump pumps messages from the hub to the websocket connection.
 // A goroutine running WritePump is started for each connection. The
 // application ensures that there is at most one writer to a connection by
@@ -162,6 +459,14 @@ func (c *Client) WritePump() {
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
+		if c.Traffic != nil {
+			traffic := c.Traffic.Stats()
+			c.Logger.Info("client: connection traffic",
+				"remote_addr", c.Conn.RemoteAddr(),
+				"payload_bytes", traffic.PayloadBytes,
+				"wire_bytes", traffic.WireBytes,
+				"savings_ratio", traffic.SavingsRatio)
+		}
 	}()
 
 	messageCount := 0
@@ -199,12 +504,15 @@ func (c *Client) WritePump() {
 				return
 			}
 			w.Write(message)
+			c.Traffic.addPayload(len(message))
 
 			// Add queued messages to the current websocket message.
 			n := len(c.Send)
 			for i := 0; i < n; i++ {
+				queued := <-c.Send
 				w.Write([]byte{'\n'})
-				w.Write(<-c.Send)
+				w.Write(queued)
+				c.Traffic.addPayload(1 + len(queued))
 			}
 
 			if err := w.Close(); err != nil {
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/poly-pro/backend/internal/services"
+)
+
+// subscribeMessage returns a subscribe message for count market IDs of the given length.
+func subscribeMessage(t *testing.T, count, idLength int) []byte {
+	t.Helper()
+	marketIDs := make([]string, count)
+	for i := range marketIDs {
+		id := fmt.Sprintf("0x%064x", i)
+		marketIDs[i] = id + strings.Repeat("f", max(idLength-len(id), 0))
+	}
+	message, err := json.Marshal(subscriptionMessage{Type: "subscribe", MarketIDs: marketIDs})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return message
+}
+
+// TestSubscribeBurst subscribes to as many markets as one message allows and checks that
+// the message fits the read limit, every market is acknowledged, and the hub keeps serving
+// other clients while the burst's listeners are started.
+func TestSubscribeBurst(t *testing.T) {
+	if size := len(subscribeMessage(t, maxMarketIDsPerMessage, 150)); size > maxMessageSize {
+		t.Fatalf("a subscribe message of %d long identifiers is %d bytes, above the read limit of %d", maxMarketIDsPerMessage, size, maxMessageSize)
+	}
+
+	hub := newTestHub(t)
+	client, peer := newTestClientPeer(t, hub, 256)
+	other := newTestClient(t, hub, 16)
+	hub.Register <- client
+	hub.Register <- other
+	hub.Subscribe <- subscription{client: other, marketID: "other-market"}
+	go client.ReadPump()
+
+	if err := peer.WriteMessage(websocket.TextMessage, subscribeMessage(t, maxMarketIDsPerMessage, 0)); err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+
+	// Broadcasts to another market and stats requests are served throughout the burst.
+	deadline := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		hub.broadcast <- marketMessage{marketID: "other-market", payload: []byte(fmt.Sprintf("update-%d", i))}
+		select {
+		case got := <-other.Send:
+			if want := fmt.Sprintf("update-%d", i); string(got) != want {
+				t.Fatalf("other client received %q, want %q", got, want)
+			}
+		case <-deadline:
+			t.Fatal("broadcast to another market not delivered during the burst")
+		}
+		stats := hub.Stats(0)
+		if len(stats.Subscriptions) == maxMarketIDsPerMessage+1 && stats.PendingListeners == 0 {
+			if stats.RedisListenerCount != maxMarketIDsPerMessage+1 {
+				t.Errorf("listeners = %d, want %d", stats.RedisListenerCount, maxMarketIDsPerMessage+1)
+			}
+			break
+		}
+	}
+
+	for i := 0; i < maxMarketIDsPerMessage; i++ {
+		select {
+		case got := <-client.Send:
+			var ack subscribedMessage
+			if err := json.Unmarshal(got, &ack); err != nil || ack.Type != "subscribed" {
+				t.Fatalf("acknowledgement %d = %s, want subscribed", i, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d acknowledgements", i, maxMarketIDsPerMessage)
+		}
+	}
+}
+
+// TestSubscribeTooManyMarketIDs checks that a message listing more than
+// maxMarketIDsPerMessage market IDs is rejected whole with an error frame.
+func TestSubscribeTooManyMarketIDs(t *testing.T) {
+	hub := newTestHub(t)
+	client, peer := newTestClientPeer(t, hub, 16)
+	hub.Register <- client
+	go client.ReadPump()
+
+	if err := peer.WriteMessage(websocket.TextMessage, subscribeMessage(t, maxMarketIDsPerMessage+1, 0)); err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+	select {
+	case got := <-client.Send:
+		var frame errorMessage
+		if err := json.Unmarshal(got, &frame); err != nil || frame.Type != "error" || frame.Code != "too_many_market_ids" {
+			t.Errorf("reply = %s, want a too_many_market_ids error", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reply to an oversized subscribe message")
+	}
+	if stats := hub.Stats(0); len(stats.Subscriptions) != 0 {
+		t.Errorf("subscriptions = %d after a rejected message, want 0", len(stats.Subscriptions))
+	}
+}
+
+// TestSubscribeResolvesMarketIdentifiers subscribes with a condition ID, a known slug and an
+// unknown identifier, and checks the acknowledgements, the error frame's suggestion, the
+// markets subscribed to and the translated and rejected counts.
+func TestSubscribeResolvesMarketIdentifiers(t *testing.T) {
+	const (
+		fedConditionID      = "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1"
+		electionConditionID = "0x9b1e1f1a4f0c2d3e4b5a69788796a5b4c3d2e1f0011223344556677889900aa"
+	)
+	catalog := services.NewMarketCatalog()
+	catalog.Add(fedConditionID, "fed-rate-cut-december")
+	catalog.Add(electionConditionID, "presidential-election-winner-2028")
+	hub := newTestHub(t, func(h *Hub) { h.resolver = catalog })
+	client, peer := newTestClientPeer(t, hub, 16)
+	hub.Register <- client
+	go client.ReadPump()
+
+	message := `{"type":"subscribe","market_ids":["` + fedConditionID + `"," Presidential-Election-Winner-2028 ","fed-rate-cut-decmber"]}`
+	if err := peer.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+	next := func(v interface{}) {
+		t.Helper()
+		select {
+		case got := <-client.Send:
+			if err := json.Unmarshal(got, v); err != nil {
+				t.Fatalf("decode %s: %v", got, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reply to the subscribe message")
+		}
+	}
+
+	// A condition ID is subscribed to as-is.
+	var ack subscribedMessage
+	next(&ack)
+	if ack != (subscribedMessage{Type: "subscribed", MarketID: fedConditionID}) {
+		t.Errorf("condition ID acknowledged as %+v", ack)
+	}
+	// A known slug is translated, and the ack reports both identifiers.
+	ack = subscribedMessage{}
+	next(&ack)
+	if ack != (subscribedMessage{Type: "subscribed", MarketID: electionConditionID, RequestedID: "Presidential-Election-Winner-2028"}) {
+		t.Errorf("slug acknowledged as %+v", ack)
+	}
+	// An unknown identifier is rejected with the closest slug.
+	var frame errorMessage
+	next(&frame)
+	if frame.Type != "error" || frame.Code != "unknown_market" || frame.MarketID != "fed-rate-cut-decmber" || frame.Suggestion != "fed-rate-cut-december" {
+		t.Errorf("unknown identifier rejected with %+v", frame)
+	}
+
+	stats := hub.Stats(0)
+	if len(stats.Subscriptions) != 2 || stats.Subscriptions[fedConditionID] != 1 || stats.Subscriptions[electionConditionID] != 1 {
+		t.Errorf("subscriptions = %v, want the two condition IDs", stats.Subscriptions)
+	}
+	if stats.TranslatedSubs != 1 || stats.RejectedSubs != 1 {
+		t.Errorf("translated, rejected = %d, %d; want 1, 1", stats.TranslatedSubs, stats.RejectedSubs)
+	}
+}
@@ -12,7 +12,24 @@
  * - Redis Pub/Sub Integration: Subscribes to Redis channels to receive market data updates
  *   from backend services (like the `MarketStreamService`).
  * - Fan-Out Broadcasting: Efficiently broadcasts incoming data from Redis to all relevant
- *   subscribed clients.
+ *   subscribed clients. Redis listeners hand their messages to the `Run` loop, which owns
+ *   the client and subscription maps, so no locking is needed.
+ * - Subscribe Snapshots: A new subscriber is sent the market's latest order book, cached in
+ *   Redis by the `MarketStreamService`, marked with `"snapshot": true`, instead of waiting
+ *   for the next live update. The snapshot is skipped if none is cached or a live update
+ *   arrived first.
+ * - Bar Subscriptions: Clients may also subscribe to the completed OHLCV bars of a market at
+ *   one resolution. These subscriptions are keyed by their OHLCV channel name
+ *   (`ohlcv:<condition_id>:<resolution>`) next to the market IDs of book subscriptions, and
+ *   are relayed the same way, without snapshots.
+ * - Paced Listener Startup: Redis listeners of new subscriptions are queued and started at
+ *   most `listenerStartBatch` per `Run` loop iteration, so that a client subscribing to
+ *   hundreds of markets at once does not hold up the other events of the loop.
+ * - Listener Cap: Beyond `SetMaxListeners` per-market listeners, new subscriptions share one
+ *   pattern listener per channel kind instead (see shared_listeners.go), so that thousands of
+ *   markets do not need thousands of Redis connections.
+ * - Idle Listeners: A market's listener is stopped when its last client leaves, so that
+ *   listeners count against the cap only while in use. A later subscription starts a new one.
  *
  * @dependencies
  * - github.com/redis/go-redis/v9: The Redis client library.
@@ -31,20 +48,69 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/poly-pro/backend/internal/cachekeys"
+	"github.com/poly-pro/backend/internal/channels"
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	// minListenerBackoff is the initial delay before a Redis listener resubscribes.
+	minListenerBackoff = time.Second
+	// maxListenerBackoff caps the delay between Redis listener resubscription attempts.
+	maxListenerBackoff = 30 * time.Second
+	// broadcastBufferSize is the number of Redis messages queued for the Run loop.
+	broadcastBufferSize = 256
+	// snapshotFetchTimeout bounds the Redis read of a market's snapshot on subscribe.
+	snapshotFetchTimeout = 2 * time.Second
+	// listenerStartBatch is the number of queued Redis listeners started per Run loop iteration.
+	listenerStartBatch = 16
+)
+
+// listenersPending is always ready to receive from; Run selects on it while listeners are queued.
+var listenersPending = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
 // subscription represents a client's subscription to a specific market.
 type subscription struct {
 	client   *Client
 	marketID string
 }
 
+// marketMessage is a message received from a market's Redis channel, to be broadcast.
+type marketMessage struct {
+	marketID string
+	payload  []byte
+}
+
+// clientSnapshot is a market's cached order book, to be sent to a client that subscribed.
+type clientSnapshot struct {
+	client       *Client
+	marketID     string
+	subscribedAt time.Time
+	payload      []byte
+}
+
+// MarketResolver validates and canonicalizes the market identifiers clients subscribe with.
+type MarketResolver interface {
+	// ResolveMarketID maps an identifier (condition ID or slug) to a condition ID.
+	ResolveMarketID(id string) (string, bool)
+	// SuggestMarketID returns a close known identifier for an unresolved one, or "".
+	SuggestMarketID(id string) string
+}
+
 // Hub maintains the set of active clients and broadcasts messages to them.
 type Hub struct {
 	// Registered clients.
@@ -57,23 +123,130 @@ type Hub struct {
 	Subscribe chan subscription
 	// Unsubscription requests from clients.
 	Unsubscribe chan subscription
-	// Map of marketID to a set of subscribed clients.
+	// Messages from the Redis listeners, broadcast from the Run loop.
+	broadcast chan marketMessage
+	// Cached order books fetched for new subscribers, delivered from the Run loop.
+	snapshots chan clientSnapshot
+	// Map of marketID (or OHLCV channel name, for bar subscriptions) to a set of subscribed clients.
 	subscriptions map[string]map[*Client]bool
+	// Markets clients may subscribe to; nil allows all markets. Read-only after construction.
+	allowedMarkets map[string]bool
+	// Resolves subscription identifiers to condition IDs; nil accepts identifiers as-is.
+	resolver MarketResolver
+	// Per-plan client limits; nil leaves every plan unlimited. Set before Run.
+	limits LimitsProvider
+	// Resolutions clients may subscribe to bars of; nil rejects all. Set before Run.
+	barResolutions map[string]bool
+	// Subscriptions translated from a slug, and rejected as unknown identifiers.
+	translatedSubscriptions atomic.Int64
+	rejectedSubscriptions   atomic.Int64
+	// Redis listeners keyed by marketID, one per market with at least one subscriber.
+	listeners map[string]*redisListener
+	// Market IDs whose listeners are registered but not started yet, in subscription order.
+	// A listener stopped before it started is removed from the queue.
+	pendingListeners []string
+	// Max per-market Redis listeners; 0 is unlimited. Set before Run.
+	maxListeners int
+	// Subscription keys served by the shared pattern listeners, once maxListeners is reached.
+	// Written by the Run loop, which reads it without locking; read by the pattern listeners.
+	sharedMu   sync.RWMutex
+	sharedKeys map[string]*sharedSubscription
+	// Shared pattern listeners keyed by pattern, started with the first key of their kind.
+	patternListeners map[string]*redisListener
+	// Tracks running Redis listener goroutines, so Run can wait for them on shutdown.
+	listenerWG sync.WaitGroup
+	// Number of throttled updates superseded by a newer payload before delivery.
+	conflatedMessages atomic.Int64
+	// Number of times a Redis listener had to resubscribe after losing its connection.
+	redisReconnects atomic.Int64
+	// Number of cached order books sent to new subscribers.
+	snapshotsSent atomic.Int64
+	// Bytes written to all clients, before and after compression.
+	traffic TrafficCounter
+	// Snapshot requests, served from the Run loop so no extra locking is needed.
+	statsRequests chan statsRequest
 	// Redis client for Pub/Sub.
 	redisClient *redis.Client
 	logger      *slog.Logger
 	ctx         context.Context
 }
 
+// redisListener tracks the state of a single Redis channel listener.
+// Counters are updated atomically by the listener goroutine. startedAt is zero while the
+// listener is queued. ctx is canceled when the listener is stopped or the hub shuts down.
+type redisListener struct {
+	channel       string
+	ctx           context.Context
+	cancel        context.CancelFunc
+	startedAt     time.Time
+	messages      atomic.Int64
+	lastMessageAt atomic.Int64 // Unix nanoseconds, 0 if no message received yet
+	reconnects    atomic.Int64
+}
+
+// statsRequest asks the Run loop for a snapshot of the hub's state.
+type statsRequest struct {
+	sampleLimit int
+	reply       chan HubStats
+}
+
+// HubStats is a point-in-time snapshot of the hub's state.
+type HubStats struct {
+	Clients            int                      `json:"clients"`
+	ClientsByPlan      map[Plan]int             `json:"clients_by_plan"`
+	SubscribedMarkets  int                      `json:"subscribed_markets"`
+	Subscriptions      map[string]int           `json:"subscriptions"` // marketID -> subscribed client count
+	RedisListenerCount int                      `json:"redis_listener_count"`
+	PendingListeners   int                      `json:"pending_listeners"` // Queued, not started yet
+	MaxRedisListeners  int                      `json:"max_redis_listeners"`  // Per-market listeners; 0 is unlimited
+	SharedSubs         int                      `json:"shared_subscriptions"` // Served by the shared pattern listeners
+	RedisListeners     map[string]ListenerStats `json:"redis_listeners"`
+	ConflatedMessages  int64                    `json:"conflated_messages"`
+	RedisReconnects    int64                    `json:"redis_reconnects"`
+	SnapshotsSent      int64                    `json:"snapshots_sent"`
+	TranslatedSubs     int64                    `json:"translated_subscriptions"`
+	RejectedSubs       int64                    `json:"rejected_subscriptions"`
+	Traffic            TrafficStats             `json:"traffic"` // Bytes written to clients since startup
+	Truncated          bool                     `json:"truncated"`
+}
+
+// ListenerStats describes the state of a single Redis channel listener.
+type ListenerStats struct {
+	Channel       string     `json:"channel"`
+	StartedAt     time.Time  `json:"started_at"`
+	Messages      int64      `json:"messages"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+	Reconnects    int64      `json:"reconnects"`
+}
+
 // NewHub creates a new Hub instance.
-func NewHub(ctx context.Context, logger *slog.Logger, redisClient *redis.Client) *Hub {
+// If allowedMarkets is non-empty, only those market IDs may be subscribed to.
+// If resolver is non-nil, subscription identifiers are validated and translated with it.
+func NewHub(ctx context.Context, logger *slog.Logger, redisClient *redis.Client, allowedMarkets []string, resolver MarketResolver) *Hub {
+	var allowed map[string]bool
+	if len(allowedMarkets) > 0 {
+		allowed = make(map[string]bool, len(allowedMarkets))
+		for _, marketID := range allowedMarkets {
+			allowed[strings.TrimSpace(marketID)] = true
+		}
+		logger.Info("hub: market subscription allow-list enabled", "allowed_markets", len(allowed))
+	}
+
 	return &Hub{
 		clients:       make(map[*Client]bool),
 		Register:      make(chan *Client),
 		Unregister:    make(chan *Client),
 		Subscribe:     make(chan subscription),
 		Unsubscribe:   make(chan subscription),
+		broadcast:     make(chan marketMessage, broadcastBufferSize),
+		snapshots:     make(chan clientSnapshot),
 		subscriptions: make(map[string]map[*Client]bool),
+		allowedMarkets: allowed,
+		resolver:      resolver,
+		listeners:     make(map[string]*redisListener),
+		sharedKeys:    make(map[string]*sharedSubscription),
+		patternListeners: make(map[string]*redisListener),
+		statsRequests: make(chan statsRequest),
 		redisClient:   redisClient,
 		logger:        logger,
 		ctx:           ctx,
@@ -81,38 +254,39 @@ func NewHub(ctx context.Context, logger *slog.Logger, redisClient *redis.Client)
 }
 
 // Run starts the hub's event loop. It should be run in a goroutine.
+// On shutdown it returns only after all Redis listeners have stopped.
 func (h *Hub) Run() {
 	for {
+		// While listeners are queued, starting a batch competes fairly with the other ready
+		// events, so that a burst of subscriptions is interleaved with them.
+		var startListeners <-chan struct{}
+		if len(h.pendingListeners) > 0 {
+			startListeners = listenersPending
+		}
+
 		select {
 		case <-h.ctx.Done():
 			h.logger.Info("hub shutting down")
 			// Close all client connections
 			for client := range h.clients {
-				close(client.Send)
+				client.closeSend()
 				delete(h.clients, client)
 			}
+			h.listenerWG.Wait()
 			return
 		case client := <-h.Register:
 			h.clients[client] = true
-			h.logger.Info("✅ hub: new client registered", "remote_addr", client.Conn.RemoteAddr(), "total_clients", len(h.clients))
+			h.logger.Info("✅ hub: new client registered", "remote_addr", client.Conn.RemoteAddr(), "client_ip", client.ClientIP, "user_id", client.UserID, "total_clients", len(h.clients))
 		case client := <-h.Unregister:
 			if _, ok := h.clients[client]; ok {
-				// Remove client from all its subscriptions
-				for marketID := range client.Subscriptions {
-					if market, ok := h.subscriptions[marketID]; ok {
-						delete(market, client)
-						if len(market) == 0 {
-							delete(h.subscriptions, marketID)
-							// Optionally, unsubscribe from the Redis channel if no clients are listening.
-							// For simplicity, we can leave the Redis subscription open.
-						}
-					}
-				}
-				delete(h.clients, client)
-				close(client.Send)
-				h.logger.Info("client unregistered", "remote_addr", client.Conn.RemoteAddr())
+				h.removeClient(client)
+				h.logger.Info("client unregistered", "remote_addr", client.Conn.RemoteAddr(), "client_ip", client.ClientIP, "user_id", client.UserID)
 			}
 		case sub := <-h.Subscribe:
+			if !h.clients[sub.client] {
+				// The client was evicted or unregistered; its Send channel is closed.
+				continue
+			}
 			// Normalize market ID (trim whitespace)
 			normalizedMarketID := strings.TrimSpace(sub.marketID)
 			if normalizedMarketID != sub.marketID {
@@ -130,15 +304,15 @@ func (h *Hub) Run() {
 				"market_id_bytes", []byte(normalizedMarketID),
 				"client_addr", sub.client.Conn.RemoteAddr())
 			if _, ok := h.subscriptions[normalizedMarketID]; !ok {
+				// First client for this market (its previous listener, if any, was stopped when
+				// the last client left), so we subscribe to the Redis channel.
 				h.subscriptions[normalizedMarketID] = make(map[*Client]bool)
-				// First client for this market, so we subscribe to the Redis channel.
-				h.logger.Info("🆕 hub: first subscription to market, starting Redis listener", 
-					"market_id", normalizedMarketID,
-					"market_id_hex", fmt.Sprintf("%x", []byte(normalizedMarketID)),
-					"redis_channel", "market:"+normalizedMarketID)
-				go h.listenToMarket(normalizedMarketID)
+				h.startListener(normalizedMarketID)
 			}
 			h.subscriptions[normalizedMarketID][sub.client] = true
+			if !isBarSubscription(normalizedMarketID) {
+				go h.fetchSnapshot(sub.client, normalizedMarketID, time.Now())
+			}
 			// Verify the subscription was stored correctly
 			if storedMarket, ok := h.subscriptions[normalizedMarketID]; ok {
 				h.logger.Info("✅ hub: client subscribed to market", 
@@ -160,18 +334,288 @@ func (h *Hub) Run() {
 				delete(market, sub.client)
 				if len(market) == 0 {
 					delete(h.subscriptions, normalizedMarketID)
-					// Again, optionally unsubscribe from Redis here.
+					h.stopListener(normalizedMarketID)
 				}
 				h.logger.Info("client unsubscribed from market", "market_id", normalizedMarketID, "client", sub.client.Conn.RemoteAddr())
 			}
+		case msg := <-h.broadcast:
+			h.broadcastToMarket(msg.marketID, msg.payload)
+		case snap := <-h.snapshots:
+			h.deliverSnapshot(snap)
+		case req := <-h.statsRequests:
+			req.reply <- h.snapshot(req.sampleLimit)
+		case <-startListeners:
+			h.startPendingListeners()
+		}
+	}
+}
+
+// startListener registers the Redis listener of a subscription key that just got its first
+// client: queued as a per-market listener, or served by a shared pattern listener once the
+// cap is reached. It must only be called from the Run loop.
+func (h *Hub) startListener(key string) {
+	if h.maxListeners > 0 && len(h.listeners) >= h.maxListeners {
+		h.routeShared(key)
+		return
+	}
+	h.logger.Info("🆕 hub: first subscription to market, queueing Redis listener", 
+		"market_id", key,
+		"market_id_hex", fmt.Sprintf("%x", []byte(key)),
+		"redis_channel", subscriptionChannel(key),
+		"pending_listeners", len(h.pendingListeners)+1)
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.listeners[key] = &redisListener{channel: subscriptionChannel(key), ctx: ctx, cancel: cancel}
+	h.pendingListeners = append(h.pendingListeners, key)
+}
+
+// stopListener stops the Redis listener of a subscription key whose last client left, or
+// stops serving it from the shared pattern listener. It must only be called from the Run loop.
+func (h *Hub) stopListener(key string) {
+	listener, ok := h.listeners[key]
+	if !ok {
+		h.unrouteShared(key)
+		return
+	}
+	delete(h.listeners, key)
+	if listener.startedAt.IsZero() {
+		h.pendingListeners = slices.DeleteFunc(h.pendingListeners, func(queued string) bool { return queued == key })
+	}
+	listener.cancel()
+	h.logger.Info("hub: last client left market, stopping Redis listener", "market_id", key, "redis_listeners", len(h.listeners))
+}
+
+// startPendingListeners starts up to listenerStartBatch queued Redis listeners.
+func (h *Hub) startPendingListeners() {
+	n := min(len(h.pendingListeners), listenerStartBatch)
+	for _, marketID := range h.pendingListeners[:n] {
+		listener := h.listeners[marketID]
+		listener.startedAt = time.Now()
+		h.listenerWG.Add(1)
+		go func() {
+			defer h.listenerWG.Done()
+			h.listenToMarket(marketID, listener)
+		}()
+	}
+	h.pendingListeners = h.pendingListeners[n:]
+	if len(h.pendingListeners) == 0 {
+		// Release the backing array grown by a large burst.
+		h.pendingListeners = nil
+	}
+}
+
+// IsMarketAllowed reports whether clients may subscribe to the given market.
+func (h *Hub) IsMarketAllowed(marketID string) bool {
+	return h.allowedMarkets == nil || h.allowedMarkets[marketID]
+}
+
+/**
+ * @description
+ * ResolveMarketID validates a subscription identifier and returns the condition ID to
+ * subscribe to. Translated and rejected identifiers are counted in the hub's stats.
+ *
+ * @param id The identifier sent by the client (condition ID or slug).
+ * @returns The condition ID, a near-match suggestion when the identifier is rejected,
+ *          and whether the identifier was accepted.
+ */
+func (h *Hub) ResolveMarketID(id string) (string, string, bool) {
+	if h.resolver == nil {
+		return id, "", true
+	}
+	conditionID, ok := h.resolver.ResolveMarketID(id)
+	if !ok {
+		h.rejectedSubscriptions.Add(1)
+		return "", h.resolver.SuggestMarketID(id), false
+	}
+	if conditionID != id {
+		h.translatedSubscriptions.Add(1)
+	}
+	return conditionID, "", true
+}
+
+/**
+ * @description
+ * Stats returns a point-in-time snapshot of connected clients, market subscriptions,
+ * and Redis listener states.
+ *
+ * @param sampleLimit The maximum number of markets to include in each map (0 for all).
+ * @returns The snapshot, or a zero value if the hub has shut down.
+ */
+func (h *Hub) Stats(sampleLimit int) HubStats {
+	req := statsRequest{sampleLimit: sampleLimit, reply: make(chan HubStats, 1)}
+	select {
+	case h.statsRequests <- req:
+		return <-req.reply
+	case <-h.ctx.Done():
+		return HubStats{}
+	}
+}
+
+// SetLimitsProvider sets the provider of per-plan client limits. It must be called before Run.
+func (h *Hub) SetLimitsProvider(limits LimitsProvider) {
+	h.limits = limits
+}
+
+// SetBarResolutions sets the resolutions clients may subscribe to bars of. It must be called before Run.
+func (h *Hub) SetBarResolutions(resolutions []string) {
+	h.barResolutions = make(map[string]bool, len(resolutions))
+	for _, resolution := range resolutions {
+		h.barResolutions[resolution] = true
+	}
+}
+
+// IsBarResolution reports whether clients may subscribe to bars of the given resolution.
+func (h *Hub) IsBarResolution(resolution string) bool {
+	return h.barResolutions[resolution]
+}
+
+// LimitsFor returns the limits of a plan, or no limits if no provider is set.
+func (h *Hub) LimitsFor(plan Plan) PlanLimits {
+	if h.limits == nil {
+		return PlanLimits{}
+	}
+	return h.limits.LimitsFor(plan)
+}
+
+// NewClientTraffic returns a traffic counter for a new client, which also feeds the hub's totals.
+func (h *Hub) NewClientTraffic() *TrafficCounter {
+	return &TrafficCounter{parent: &h.traffic}
+}
+
+// SubscribedMarkets returns the condition IDs of the markets at least one client is
+// subscribed to, to the book or to bars, or none if the hub has shut down.
+func (h *Hub) SubscribedMarkets() []string {
+	subscriptions := h.Stats(0).Subscriptions
+	seen := make(map[string]bool, len(subscriptions))
+	marketIDs := make([]string, 0, len(subscriptions))
+	for key := range subscriptions {
+		marketID := key
+		if barMarketID, _, ok := channels.ParseOHLCVChannel(key); ok {
+			marketID = barMarketID
+		}
+		if !seen[marketID] {
+			seen[marketID] = true
+			marketIDs = append(marketIDs, marketID)
+		}
+	}
+	return marketIDs
+}
+
+// snapshot builds a HubStats from the hub's state. It must only be called from the Run loop.
+func (h *Hub) snapshot(sampleLimit int) HubStats {
+	stats := HubStats{
+		Clients:            len(h.clients),
+		ClientsByPlan:      make(map[Plan]int, len(Plans)),
+		SubscribedMarkets:  len(h.subscriptions),
+		Subscriptions:      make(map[string]int),
+		RedisListenerCount: len(h.listeners) + len(h.patternListeners),
+		PendingListeners:   len(h.pendingListeners),
+		MaxRedisListeners:  h.maxListeners,
+		SharedSubs:         len(h.sharedKeys),
+		RedisListeners:     make(map[string]ListenerStats),
+		ConflatedMessages:  h.conflatedMessages.Load(),
+		RedisReconnects:    h.redisReconnects.Load(),
+		SnapshotsSent:      h.snapshotsSent.Load(),
+		TranslatedSubs:     h.translatedSubscriptions.Load(),
+		RejectedSubs:       h.rejectedSubscriptions.Load(),
+		Traffic:            h.traffic.Stats(),
+	}
+
+	for client := range h.clients {
+		stats.ClientsByPlan[client.plan()]++
+	}
+
+	marketIDs := make([]string, 0, len(h.subscriptions))
+	for marketID := range h.subscriptions {
+		marketIDs = append(marketIDs, marketID)
+	}
+	sort.Strings(marketIDs)
+	for i, marketID := range marketIDs {
+		if sampleLimit > 0 && i >= sampleLimit {
+			stats.Truncated = true
+			break
+		}
+		stats.Subscriptions[marketID] = len(h.subscriptions[marketID])
+	}
+
+	listenerIDs := make([]string, 0, len(h.listeners))
+	for marketID := range h.listeners {
+		listenerIDs = append(listenerIDs, marketID)
+	}
+	sort.Strings(listenerIDs)
+	for i, marketID := range listenerIDs {
+		if sampleLimit > 0 && i >= sampleLimit {
+			stats.Truncated = true
+			break
 		}
+		stats.RedisListeners[marketID] = h.listeners[marketID].stats()
 	}
+	for pattern, listener := range h.patternListeners {
+		stats.RedisListeners[pattern] = listener.stats()
+	}
+
+	return stats
+}
+
+// stats returns the listener's ListenerStats.
+func (l *redisListener) stats() ListenerStats {
+	listenerStats := ListenerStats{
+		Channel:    l.channel,
+		StartedAt:  l.startedAt,
+		Messages:   l.messages.Load(),
+		Reconnects: l.reconnects.Load(),
+	}
+	if last := l.lastMessageAt.Load(); last > 0 {
+		lastMessageAt := time.Unix(0, last)
+		listenerStats.LastMessageAt = &lastMessageAt
+	}
+	return listenerStats
 }
 
 // listenToMarket subscribes to a specific market's Redis channel and broadcasts messages.
-func (h *Hub) listenToMarket(marketID string) {
-	channel := "market:" + marketID
-	pubsub := h.redisClient.Subscribe(h.ctx, channel)
+// If the subscription cannot be established or is lost, it resubscribes with exponential
+// backoff until the hub shuts down.
+func (h *Hub) listenToMarket(marketID string, listener *redisListener) {
+	channel := subscriptionChannel(marketID)
+	h.keepSubscribed(listener.ctx, channel, func() bool {
+		return h.consumeMarket(marketID, channel, listener)
+	})
+}
+
+// keepSubscribed runs consume, which reports whether its subscription was ever confirmed,
+// again with exponential backoff each time it returns, until ctx is canceled.
+func (h *Hub) keepSubscribed(ctx context.Context, channel string, consume func() bool) {
+	backoff := minListenerBackoff
+
+	for {
+		subscribed := consume()
+		if ctx.Err() != nil {
+			h.logger.Info("stopping redis listener for channel", "channel", channel)
+			return
+		}
+		if subscribed {
+			backoff = minListenerBackoff
+		}
+
+		h.logger.Warn("redis listener lost its subscription, resubscribing", "channel", channel, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			h.logger.Info("stopping redis listener for channel", "channel", channel)
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxListenerBackoff {
+			backoff = maxListenerBackoff
+		}
+	}
+}
+
+// consumeMarket subscribes to a market channel and broadcasts its messages until the
+// subscription is lost or the listener is stopped. It reports whether the subscription was
+// ever confirmed. go-redis transparently reconnects a broken Pub/Sub connection; each
+// confirmation after the first is counted as a Redis reconnect.
+func (h *Hub) consumeMarket(marketID, channel string, listener *redisListener) bool {
+	ctx := listener.ctx
+	pubsub := h.redisClient.Subscribe(ctx, channel)
 	defer pubsub.Close()
 
 	h.logger.Info("subscribing to redis channel", 
@@ -180,16 +624,39 @@ func (h *Hub) listenToMarket(marketID string) {
 		"market_id_length", len(marketID),
 		"market_id_bytes", []byte(marketID))
 
-	ch := pubsub.Channel()
-	messageCount := 0
+	// Receive waits for the subscription confirmation, failing fast while Redis is down.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() == nil {
+			h.logger.Error("failed to subscribe to redis channel", "channel", channel, "error", err)
+		}
+		return false
+	}
 
+	ch := pubsub.ChannelWithSubscriptions()
 	for {
 		select {
-		case <-h.ctx.Done():
-			h.logger.Info("stopping redis listener for channel", "channel", channel)
-			return
-		case msg := <-ch:
-			messageCount++
+		case <-ctx.Done():
+			return true
+		case received, ok := <-ch:
+			if !ok {
+				return true
+			}
+			if sub, isSubscription := received.(*redis.Subscription); isSubscription {
+				// The initial confirmation was consumed by Receive, so any further
+				// subscribe confirmation means go-redis reconnected and resubscribed.
+				if sub.Kind == "subscribe" {
+					listener.reconnects.Add(1)
+					h.redisReconnects.Add(1)
+					h.logger.Warn("redis listener resubscribed after reconnect", "channel", channel, "reconnects", listener.reconnects.Load())
+				}
+				continue
+			}
+			msg, isMessage := received.(*redis.Message)
+			if !isMessage {
+				continue
+			}
+			messageCount := listener.messages.Add(1)
+			listener.lastMessageAt.Store(time.Now().UnixNano())
 			if messageCount == 1 {
 				h.logger.Info("✅ hub: received first message from Redis", 
 					"channel", channel, 
@@ -216,14 +683,19 @@ func (h *Hub) listenToMarket(marketID string) {
 						"has_asks", msgData["asks"] != nil)
 				}
 			}
-			h.broadcastToMarket(marketID, []byte(msg.Payload))
+			select {
+			case h.broadcast <- marketMessage{marketID: marketID, payload: []byte(msg.Payload)}:
+			case <-ctx.Done():
+				return true
+			}
 		}
 	}
 }
 
-// broadcastToMarket sends a message to all clients subscribed to a specific market.
 var broadcastCallCount int64
 
+// broadcastToMarket sends a message to all clients subscribed to a specific market.
+// It must only be called from the Run loop.
 func (h *Hub) broadcastToMarket(marketID string, message []byte) {
 	// Normalize market ID (trim whitespace)
 	normalizedMarketID := strings.TrimSpace(marketID)
@@ -256,6 +728,10 @@ func (h *Hub) broadcastToMarket(marketID string, message []byte) {
 			}
 		}
 		for client := range market {
+			// Throttled subscriptions hold the message back and deliver the latest later.
+			if client.conflate(normalizedMarketID, message) {
+				continue
+			}
 			select {
 			case client.Send <- message:
 				// Message sent successfully
@@ -263,10 +739,7 @@ func (h *Hub) broadcastToMarket(marketID string, message []byte) {
 				// If the client's send buffer is full, assume it's slow or disconnected.
 				// Unregister the client to prevent blocking.
 				h.logger.Warn("client send buffer full, unregistering", "market_id", normalizedMarketID, "client", client.Conn.RemoteAddr())
-				close(client.Send)
-				delete(h.clients, client)
-				// Also remove from the specific subscription
-				delete(market, client)
+				h.removeClient(client)
 			}
 		}
 	} else {
@@ -287,3 +760,109 @@ func (h *Hub) broadcastToMarket(marketID string, message []byte) {
 	}
 }
 
+// isBarSubscription reports whether a subscription key is a bar subscription's OHLCV channel
+// name rather than a market ID.
+func isBarSubscription(key string) bool {
+	_, _, ok := channels.ParseOHLCVChannel(key)
+	return ok
+}
+
+// subscriptionChannel returns the Redis channel of a subscription key: the key itself for bar
+// subscriptions, and the market channel of the market ID otherwise.
+func subscriptionChannel(key string) string {
+	if isBarSubscription(key) {
+		return key
+	}
+	return channels.MarketChannel(key)
+}
+
+// fetchSnapshot reads a market's cached order book and hands it to the Run loop for a client
+// that subscribed at subscribedAt. A missing snapshot or a failed read is not reported to
+// the client, which then receives the next live update as before.
+func (h *Hub) fetchSnapshot(client *Client, marketID string, subscribedAt time.Time) {
+	ctx, cancel := context.WithTimeout(h.ctx, snapshotFetchTimeout)
+	defer cancel()
+
+	payload, err := h.redisClient.Get(ctx, cachekeys.MarketSnapshot.Key(marketID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return
+	}
+	if err != nil {
+		if h.ctx.Err() == nil {
+			h.logger.Warn("failed to read order book snapshot", "market_id", marketID, "error", err)
+		}
+		return
+	}
+	payload, err = markSnapshot(payload)
+	if err != nil {
+		h.logger.Warn("ignoring malformed order book snapshot", "market_id", marketID, "error", err)
+		return
+	}
+
+	select {
+	case h.snapshots <- clientSnapshot{client: client, marketID: marketID, subscribedAt: subscribedAt, payload: payload}:
+	case <-h.ctx.Done():
+	}
+}
+
+// markSnapshot adds `"snapshot": true` to a cached order book payload.
+// The other fields, including `schema_version`, are kept as they are.
+func markSnapshot(payload []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	fields["snapshot"] = json.RawMessage("true")
+	return json.Marshal(fields)
+}
+
+// deliverSnapshot sends a snapshot to its client if the client is still subscribed and no
+// live update of the market was received since it subscribed, by the market's own listener
+// or by the shared pattern listener. A client whose send buffer is full is skipped rather
+// than evicted. It must only be called from the Run loop.
+func (h *Hub) deliverSnapshot(snap clientSnapshot) {
+	if !h.subscriptions[snap.marketID][snap.client] {
+		return
+	}
+	if h.lastLiveMessageAt(snap.marketID) > snap.subscribedAt.UnixNano() {
+		return
+	}
+	select {
+	case snap.client.Send <- snap.payload:
+		h.snapshotsSent.Add(1)
+	default:
+	}
+}
+
+// lastLiveMessageAt returns when the latest live update of a subscription key was received,
+// in Unix nanoseconds, or 0 if none was. A shared key's updates are tracked per key, since its
+// pattern listener also receives the updates of every other market of its kind. It must only
+// be called from the Run loop.
+func (h *Hub) lastLiveMessageAt(key string) int64 {
+	if listener, ok := h.listeners[key]; ok {
+		return listener.lastMessageAt.Load()
+	}
+	if shared := h.sharedKeys[key]; shared != nil {
+		return shared.lastMessageAt.Load()
+	}
+	return 0
+}
+
+// removeClient removes a client from the clients and from every market's subscribers,
+// stopping the listeners of markets it was the last subscriber of, and closes its Send channel, which makes its write pump close the connection. It is used both
+// for unregistration and to evict slow clients. The hub's subscription map is scanned
+// because the client's own Subscriptions map belongs to its read pump, which may still be
+// running. It must only be called from the Run loop.
+func (h *Hub) removeClient(client *Client) {
+	for marketID, market := range h.subscriptions {
+		if _, ok := market[client]; ok {
+			delete(market, client)
+			if len(market) == 0 {
+				delete(h.subscriptions, marketID)
+				h.stopListener(marketID)
+			}
+		}
+	}
+	delete(h.clients, client)
+	client.closeSend()
+}
@@ -0,0 +1,562 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestHub starts a hub whose Redis is unreachable, so its listeners and snapshot reads fail
+// and retry in the background. The hub is shut down when the test ends.
+func newTestHub(t *testing.T, configure ...func(*Hub)) *Hub {
+	t.Helper()
+	return newTestHubWithRedis(t, "127.0.0.1:1", configure...)
+}
+
+// newTestHubWithRedis is newTestHub with the hub's Redis at redisAddr.
+func newTestHubWithRedis(t *testing.T, redisAddr string, configure ...func(*Hub)) *Hub {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	redisClient := redis.NewClient(&redis.Options{Addr: redisAddr, MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := NewHub(ctx, logger, redisClient, nil, nil)
+	for _, apply := range configure {
+		apply(hub)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Run()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		redisClient.Close()
+	})
+	return hub
+}
+
+// newTestClient creates a client with a live server-side connection and a Send buffer of the
+// given size. The client is not registered with the hub.
+func newTestClient(t *testing.T, hub *Hub, sendBuffer int) *Client {
+	t.Helper()
+	client, _ := newTestClientPeer(t, hub, sendBuffer)
+	return client
+}
+
+// newTestClientPeer is newTestClient that also returns the dialing end of the connection.
+func newTestClientPeer(t *testing.T, hub *Hub, sendBuffer int) (*Client, *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { peer.Close() })
+	conn := <-conns
+	t.Cleanup(func() { conn.Close() })
+
+	return &Client{
+		Hub:           hub,
+		Conn:          conn,
+		Send:          make(chan []byte, sendBuffer),
+		Subscriptions: make(map[string]bool),
+		Logger:        hub.logger,
+	}, peer
+}
+
+// TestBroadcastEvictsSlowClient checks that a client whose Send buffer is full when a market
+// update is broadcast is removed from the hub and its market, and its Send channel closed.
+func TestBroadcastEvictsSlowClient(t *testing.T) {
+	hub := newTestHub(t)
+	client := newTestClient(t, hub, 1)
+	client.Send <- []byte("queued")
+
+	hub.Register <- client
+	hub.Subscribe <- subscription{client: client, marketID: "market-1"}
+	if stats := hub.Stats(0); stats.Clients != 1 || stats.Subscriptions["market-1"] != 1 {
+		t.Fatalf("before broadcast: clients = %d, subscribers = %d, want 1 and 1", stats.Clients, stats.Subscriptions["market-1"])
+	}
+
+	hub.broadcast <- marketMessage{marketID: "market-1", payload: []byte("update")}
+	stats := hub.Stats(0)
+	if stats.Clients != 0 {
+		t.Errorf("clients = %d after eviction, want 0", stats.Clients)
+	}
+	if _, ok := stats.Subscriptions["market-1"]; ok {
+		t.Errorf("market-1 still has subscribers after eviction: %v", stats.Subscriptions)
+	}
+
+	if got := <-client.Send; string(got) != "queued" {
+		t.Errorf("first queued message = %q, want %q", got, "queued")
+	}
+	select {
+	case msg, ok := <-client.Send:
+		if ok {
+			t.Fatalf("Send still open after eviction, received %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send not closed after eviction")
+	}
+
+	// Later events about the evicted client must not send on or close its Send channel again.
+	hub.broadcast <- marketMessage{marketID: "market-1", payload: []byte("update")}
+	hub.Subscribe <- subscription{client: client, marketID: "market-1"}
+	hub.Unregister <- client
+	if stats := hub.Stats(0); stats.Clients != 0 || len(stats.Subscriptions) != 0 {
+		t.Errorf("evicted client reappeared: clients = %d, subscriptions = %v", stats.Clients, stats.Subscriptions)
+	}
+}
+
+// TestHubConcurrentSubscribeBroadcast drives subscriptions, unsubscriptions, broadcasts and
+// stats requests from many goroutines at once. It is meant to be run with -race.
+func TestHubConcurrentSubscribeBroadcast(t *testing.T) {
+	const (
+		numClients = 32
+		numMarkets = 4
+		rounds     = 50
+	)
+	hub := newTestHub(t)
+	markets := make([]string, numMarkets)
+	for i := range markets {
+		markets[i] = fmt.Sprintf("market-%d", i)
+	}
+
+	clients := make([]*Client, numClients)
+	var drained sync.WaitGroup
+	for i := range clients {
+		// The buffer holds every broadcast, so that no client is evicted as slow however far
+		// its drain falls behind.
+		client := newTestClient(t, hub, numMarkets*rounds)
+		clients[i] = client
+		hub.Register <- client
+		drained.Add(1)
+		go func() {
+			defer drained.Done()
+			for range client.Send {
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				market := markets[(i+round)%numMarkets]
+				if i%2 == 0 {
+					// Half of the subscriptions are throttled, like a read pump would set them.
+					client.setThrottle(market, time.Millisecond)
+				}
+				hub.Subscribe <- subscription{client: client, marketID: market}
+				if round%3 == 0 {
+					hub.Unsubscribe <- subscription{client: client, marketID: market}
+				}
+			}
+			// End subscribed to every market.
+			for _, market := range markets {
+				hub.Subscribe <- subscription{client: client, marketID: market}
+			}
+		}()
+	}
+	for _, market := range markets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				hub.broadcast <- marketMessage{marketID: market, payload: []byte(`{"event_type":"book"}`)}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 0; round < rounds; round++ {
+			hub.Stats(2)
+		}
+	}()
+	wg.Wait()
+
+	stats := hub.Stats(0)
+	if stats.Clients != numClients {
+		t.Errorf("clients = %d, want %d", stats.Clients, numClients)
+	}
+	for _, market := range markets {
+		if got := stats.Subscriptions[market]; got != numClients {
+			t.Errorf("subscribers of %s = %d, want %d", market, got, numClients)
+		}
+	}
+
+	for _, client := range clients {
+		hub.Unregister <- client
+	}
+	drained.Wait()
+	if stats := hub.Stats(0); stats.Clients != 0 || len(stats.Subscriptions) != 0 {
+		t.Errorf("after unregistering: clients = %d, subscriptions = %v", stats.Clients, stats.Subscriptions)
+	}
+}
+
+// TestIdleListenerStopped checks that a market's listener is stopped when its last client
+// leaves, and that subscribing to the market again starts exactly one new listener.
+func TestIdleListenerStopped(t *testing.T) {
+	hub := newTestHub(t)
+	client := newTestClient(t, hub, 16)
+	other := newTestClient(t, hub, 16)
+	hub.Register <- client
+	hub.Register <- other
+
+	hub.Subscribe <- subscription{client: client, marketID: "market-1"}
+	hub.Subscribe <- subscription{client: other, marketID: "market-1"}
+	hub.Stats(0) // Orders the read of the listener map after the subscriptions.
+	first := hub.listeners["market-1"]
+	if first == nil {
+		t.Fatal("no listener after subscribing")
+	}
+
+	hub.Unsubscribe <- subscription{client: client, marketID: "market-1"}
+	if stats := hub.Stats(0); stats.RedisListenerCount != 1 || first.ctx.Err() != nil {
+		t.Fatalf("listener stopped while a client is still subscribed (listeners = %d)", stats.RedisListenerCount)
+	}
+	hub.Unsubscribe <- subscription{client: other, marketID: "market-1"}
+	if stats := hub.Stats(0); stats.RedisListenerCount != 0 {
+		t.Errorf("listeners after the last client left = %d, want 0", stats.RedisListenerCount)
+	}
+	if first.ctx.Err() == nil {
+		t.Error("the idle listener was not stopped")
+	}
+
+	hub.Subscribe <- subscription{client: client, marketID: "market-1"}
+	hub.Subscribe <- subscription{client: other, marketID: "market-1"}
+	stats := hub.Stats(0)
+	if stats.RedisListenerCount != 1 {
+		t.Errorf("listeners after subscribing again = %d, want 1", stats.RedisListenerCount)
+	}
+	if second := hub.listeners["market-1"]; second == nil || second == first || second.ctx.Err() != nil {
+		t.Error("subscribing again did not start a new listener")
+	}
+
+	// A client that disconnects stops the listeners it was the last subscriber of.
+	hub.Unsubscribe <- subscription{client: other, marketID: "market-1"}
+	hub.Unregister <- client
+	if stats := hub.Stats(0); stats.RedisListenerCount != 0 {
+		t.Errorf("listeners after the last client disconnected = %d, want 0", stats.RedisListenerCount)
+	}
+}
+
+// TestListenerCapCountsListenersInUse checks that only listeners of subscribed markets count
+// against the listener cap, and that shared keys are dropped when their last client leaves.
+func TestListenerCapCountsListenersInUse(t *testing.T) {
+	hub := newTestHub(t, func(h *Hub) { h.SetMaxListeners(1) })
+	client := newTestClient(t, hub, 16)
+	hub.Register <- client
+
+	hub.Subscribe <- subscription{client: client, marketID: "market-a"}
+	hub.Unsubscribe <- subscription{client: client, marketID: "market-a"}
+	hub.Subscribe <- subscription{client: client, marketID: "market-b"}
+	hub.Stats(0)
+	if hub.listeners["market-b"] == nil || hub.isShared("market-b") {
+		t.Error("the idle listener of market-a still counted against the cap")
+	}
+
+	hub.Subscribe <- subscription{client: client, marketID: "market-c"}
+	hub.Stats(0)
+	if !hub.isShared("market-c") {
+		t.Fatal("a subscription beyond the cap was not shared")
+	}
+	hub.Unsubscribe <- subscription{client: client, marketID: "market-c"}
+	if stats := hub.Stats(0); stats.SharedSubs != 0 || hub.isShared("market-c") {
+		t.Errorf("shared subscriptions after the last client left = %d, want 0", stats.SharedSubs)
+	}
+}
+
+// TestSnapshotStalenessOfSharedKeys checks that a snapshot is dropped after a live update of
+// its own shared key, but not after an update of another market on the same pattern listener.
+func TestSnapshotStalenessOfSharedKeys(t *testing.T) {
+	hub := newTestHub(t, func(h *Hub) { h.SetMaxListeners(1) })
+	client := newTestClient(t, hub, 16)
+	hub.Register <- client
+	hub.Subscribe <- subscription{client: client, marketID: "market-a"}
+	hub.Subscribe <- subscription{client: client, marketID: "market-b"}
+	hub.Stats(0)
+	shared := hub.sharedSubscription("market-b")
+	if shared == nil {
+		t.Fatal("market-b is not shared")
+	}
+
+	delivered := func(subscribedAt time.Time) bool {
+		t.Helper()
+		before := hub.Stats(0).SnapshotsSent
+		hub.snapshots <- clientSnapshot{client: client, marketID: "market-b", subscribedAt: subscribedAt, payload: []byte("snapshot")}
+		if hub.Stats(0).SnapshotsSent == before {
+			return false
+		}
+		if payload := <-client.Send; string(payload) != "snapshot" {
+			t.Fatalf("sent %q, want the snapshot", payload)
+		}
+		return true
+	}
+
+	subscribedAt := time.Now()
+	// An update of another market reaches the pattern listener, but not market-b.
+	hub.patternListeners[channels.Pattern(channels.KindMarket)].lastMessageAt.Store(subscribedAt.Add(time.Second).UnixNano())
+	if !delivered(subscribedAt) {
+		t.Error("an update of another market made market-b's snapshot stale")
+	}
+
+	shared.lastMessageAt.Store(subscribedAt.Add(time.Second).UnixNano())
+	if delivered(subscribedAt) {
+		t.Error("a snapshot older than market-b's live update was sent")
+	}
+	if !delivered(subscribedAt.Add(2 * time.Second)) {
+		t.Error("a snapshot newer than market-b's live update was dropped")
+	}
+}
+
+// fakeRedis is a Redis server that only speaks Pub/Sub: it confirms SUBSCRIBE, answers PING,
+// publishes the messages it is given, and rejects every other command.
+type fakeRedis struct {
+	listener   net.Listener
+	subscribes chan string // Channels subscribed to, including resubscriptions
+
+	mu          sync.Mutex // Guards the maps and writes to the connections
+	conns       map[net.Conn]bool
+	subscribers map[string][]net.Conn
+}
+
+// newFakeRedis starts a fakeRedis. It is shut down when the test ends.
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeRedis{
+		listener:    listener,
+		subscribes:  make(chan string, 64),
+		conns:       make(map[net.Conn]bool),
+		subscribers: make(map[string][]net.Conn),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns[conn] = true
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropConnections()
+	})
+	return server
+}
+
+// serve answers the commands sent on conn until it is closed.
+func (s *fakeRedis) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "SUBSCRIBE":
+			for i, channel := range args[1:] {
+				s.subscribers[channel] = append(s.subscribers[channel], conn)
+				fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n", len(channel), channel, i+1)
+				s.subscribes <- channel
+			}
+		case "PING":
+			io.WriteString(conn, "*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+		s.mu.Unlock()
+	}
+}
+
+// readRESPCommand reads a command sent as a RESP array of bulk strings.
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(reader, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+// waitSubscribe waits until channel is subscribed to again.
+func (s *fakeRedis) waitSubscribe(t *testing.T, channel string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case subscribed := <-s.subscribes:
+			if subscribed == channel {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%s was not subscribed to", channel)
+		}
+	}
+}
+
+// dropConnections closes every client connection, as a Redis restart would.
+func (s *fakeRedis) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = make(map[net.Conn]bool)
+	s.subscribers = make(map[string][]net.Conn)
+}
+
+// publish sends payload to the subscribers of channel.
+func (s *fakeRedis) publish(channel, payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.subscribers[channel] {
+		fmt.Fprintf(conn, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(channel), channel, len(payload), payload)
+	}
+}
+
+// readClientMessage reads messages written to peer, which the write pump may batch on
+// separate lines, until one of the given type arrives, and decodes it into v.
+func readClientMessage(t *testing.T, peer *websocket.Conn, messageType string, v interface{}) {
+	t.Helper()
+	peer.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := peer.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for a %s message: %v", messageType, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			var message struct {
+				Type      string `json:"type"`
+				EventType string `json:"event_type"`
+			}
+			if json.Unmarshal([]byte(line), &message) != nil {
+				continue
+			}
+			if message.Type == messageType || message.EventType == messageType {
+				if err := json.Unmarshal([]byte(line), v); err != nil {
+					t.Fatalf("decode %s: %v", line, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// TestSubscriptionsSurviveRedisReconnect subscribes a client through its read pump, drops
+// the hub's Redis connections, and checks that the hub resubscribes to the market's channel,
+// that the client still receives its updates, and that list_subscriptions still lists it.
+func TestSubscriptionsSurviveRedisReconnect(t *testing.T) {
+	const market = "0xmarket"
+	redisServer := newFakeRedis(t)
+	hub := newTestHubWithRedis(t, redisServer.listener.Addr().String(), func(h *Hub) { h.SetBarResolutions([]string{"1m"}) })
+	client, peer := newTestClientPeer(t, hub, 16)
+	hub.Register <- client
+	go client.WritePump()
+	go client.ReadPump()
+
+	send := func(message string) {
+		t.Helper()
+		if err := peer.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatalf("write %s: %v", message, err)
+		}
+	}
+	send(`{"type":"subscribe","market_ids":["` + market + `"]}`)
+	var ack subscribedMessage
+	readClientMessage(t, peer, "subscribed", &ack)
+	if ack.MarketID != market {
+		t.Fatalf("subscribed to %q, want %q", ack.MarketID, market)
+	}
+	channel := channels.MarketChannel(market)
+	redisServer.waitSubscribe(t, channel)
+
+	redisServer.dropConnections()
+	redisServer.waitSubscribe(t, channel)
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Stats(0).RedisReconnects == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the resubscription was not counted as a Redis reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	redisServer.publish(channel, `{"event_type":"book","market":"`+market+`"}`)
+	var update struct {
+		Market string `json:"market"`
+	}
+	readClientMessage(t, peer, "book", &update)
+	if update.Market != market {
+		t.Errorf("update for %q, want %q", update.Market, market)
+	}
+
+	send(`{"type":"subscribe_bars","market_ids":["` + market + `"],"resolution":"1m"}`)
+	readClientMessage(t, peer, "bars_subscribed", &barsSubscribedMessage{})
+	send(`{"type":"list_subscriptions"}`)
+	var listed subscriptionsMessage
+	readClientMessage(t, peer, "subscriptions", &listed)
+	if !reflect.DeepEqual(listed.MarketIDs, []string{market}) {
+		t.Errorf("listed markets = %q, want [%q]", listed.MarketIDs, market)
+	}
+	if !reflect.DeepEqual(listed.Bars, []barSubscription{{MarketID: market, Resolution: "1m"}}) {
+		t.Errorf("listed bars = %+v", listed.Bars)
+	}
+
+	send(`{"type":"unsubscribe","market_ids":["` + market + `"]}`)
+	send(`{"type":"unsubscribe_bars","market_ids":["` + market + `"],"resolution":"1m"}`)
+	send(`{"type":"list_subscriptions"}`)
+	listed = subscriptionsMessage{}
+	readClientMessage(t, peer, "subscriptions", &listed)
+	if len(listed.MarketIDs) != 0 || len(listed.Bars) != 0 {
+		t.Errorf("after unsubscribing, listed %q and %+v, want nothing", listed.MarketIDs, listed.Bars)
+	}
+}
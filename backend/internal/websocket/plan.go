@@ -0,0 +1,50 @@
+/**
+ * @description
+ * This file defines client plans: the identity class of a WebSocket client (anonymous,
+ * authenticated with a Clerk token, or identified by an API key), which selects the limits
+ * the client is held to.
+ *
+ * Key features:
+ * - Limits: Per plan, the maximum number of markets a client may be subscribed to at once
+ *   and a floor on the per-subscription throttle interval.
+ * - LimitsProvider: The hub consults a LimitsProvider, by default the configuration-backed
+ *   `StaticLimits`; plans without an entry are unlimited.
+ */
+
+package websocket
+
+import "time"
+
+// Plan is the identity class of a client.
+type Plan string
+
+const (
+	// PlanAnonymous is a client that presented no credentials.
+	PlanAnonymous Plan = "anonymous"
+	// PlanAuthenticated is a client that presented a valid Clerk session token.
+	PlanAuthenticated Plan = "authenticated"
+	// PlanAPIKey is a client that presented a valid API key.
+	PlanAPIKey Plan = "api_key"
+)
+
+// Plans lists every plan, e.g. to build per-plan configuration and stats.
+var Plans = []Plan{PlanAnonymous, PlanAuthenticated, PlanAPIKey}
+
+// PlanLimits are the limits of a plan. Zero values mean no limit.
+type PlanLimits struct {
+	MaxSubscriptions int           // Maximum markets subscribed at once
+	MinThrottle      time.Duration // Minimum per-subscription throttle interval
+}
+
+// LimitsProvider returns the limits of a plan.
+type LimitsProvider interface {
+	LimitsFor(plan Plan) PlanLimits
+}
+
+// StaticLimits is a LimitsProvider backed by configuration.
+type StaticLimits map[Plan]PlanLimits
+
+// LimitsFor returns the configured limits of a plan.
+func (l StaticLimits) LimitsFor(plan Plan) PlanLimits {
+	return l[plan]
+}
@@ -0,0 +1,173 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testPlanLimits caps anonymous clients tightly, authenticated clients loosely, and leaves
+// API key clients unlimited.
+var testPlanLimits = StaticLimits{
+	PlanAnonymous:     {MaxSubscriptions: 2, MinThrottle: time.Second},
+	PlanAuthenticated: {MaxSubscriptions: 4, MinThrottle: 250 * time.Millisecond},
+}
+
+// planMarket returns the condition ID of the i-th test market.
+func planMarket(i int) string {
+	return fmt.Sprintf("0x%064x", i)
+}
+
+// replyCodes reads n replies queued for a client and returns their types, with the error
+// code of error frames (e.g. "subscribed", "error:subscription_limit").
+func replyCodes(t *testing.T, client *Client, n int) []string {
+	t.Helper()
+	codes := make([]string, 0, n)
+	for len(codes) < n {
+		select {
+		case got := <-client.Send:
+			var reply struct {
+				Type string `json:"type"`
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(got, &reply); err != nil {
+				t.Fatalf("decode %s: %v", got, err)
+			}
+			if reply.Code != "" {
+				reply.Type += ":" + reply.Code
+			}
+			codes = append(codes, reply.Type)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d replies: %v", len(codes), n, codes)
+		}
+	}
+	return codes
+}
+
+// throttleOf returns the throttle interval of a client's subscription, 0 if unthrottled.
+func throttleOf(client *Client, marketID string) time.Duration {
+	client.throttleMu.Lock()
+	defer client.throttleMu.Unlock()
+	if throttle, ok := client.throttles[marketID]; ok {
+		return throttle.interval
+	}
+	return 0
+}
+
+// TestPlanLimitsDifferByPlan subscribes a client of each plan to five markets with a 100ms
+// throttle, and checks how many subscriptions each plan is allowed and the throttle applied.
+func TestPlanLimitsDifferByPlan(t *testing.T) {
+	tests := []struct {
+		plan         Plan
+		wantAccepted int
+		wantThrottle time.Duration
+	}{
+		{PlanAnonymous, 2, time.Second},
+		// A client created without a plan is anonymous.
+		{"", 2, time.Second},
+		{PlanAuthenticated, 4, 250 * time.Millisecond},
+		{PlanAPIKey, 5, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.plan), func(t *testing.T) {
+			hub := newTestHub(t, func(h *Hub) { h.SetLimitsProvider(testPlanLimits) })
+			client, peer := newTestClientPeer(t, hub, 16)
+			client.Plan = tt.plan
+			hub.Register <- client
+			go client.ReadPump()
+
+			message := fmt.Sprintf(`{"type":"subscribe","throttle_ms":100,"market_ids":["%s","%s","%s","%s","%s"]}`,
+				planMarket(0), planMarket(1), planMarket(2), planMarket(3), planMarket(4))
+			if err := peer.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+				t.Fatalf("write subscribe: %v", err)
+			}
+
+			want := make([]string, 5)
+			for i := range want {
+				want[i] = "subscribed"
+				if i >= tt.wantAccepted {
+					want[i] = "error:subscription_limit"
+				}
+			}
+			if got := replyCodes(t, client, 5); !reflect.DeepEqual(got, want) {
+				t.Errorf("replies = %v, want %v", got, want)
+			}
+			if got := throttleOf(client, planMarket(0)); got != tt.wantThrottle {
+				t.Errorf("throttle = %s, want %s", got, tt.wantThrottle)
+			}
+			if got := throttleOf(client, planMarket(tt.wantAccepted)); got != 0 {
+				t.Errorf("rejected market throttled at %s", got)
+			}
+		})
+	}
+}
+
+// TestPlanSubscriptionCapCountsEverySubscription checks that bar subscriptions count towards
+// the cap, that renewing a subscription at the cap is allowed, and that unsubscribing frees
+// a slot.
+func TestPlanSubscriptionCapCountsEverySubscription(t *testing.T) {
+	hub := newTestHub(t, func(h *Hub) {
+		h.SetLimitsProvider(testPlanLimits)
+		h.SetBarResolutions([]string{"1m"})
+	})
+	client, peer := newTestClientPeer(t, hub, 16)
+	hub.Register <- client
+	go client.ReadPump()
+	send := func(message string) {
+		t.Helper()
+		if err := peer.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatalf("write %s: %v", message, err)
+		}
+	}
+
+	send(`{"type":"subscribe","market_ids":["` + planMarket(0) + `"]}`)
+	send(`{"type":"subscribe_bars","resolution":"1m","market_ids":["` + planMarket(0) + `","` + planMarket(1) + `"]}`)
+	if got, want := replyCodes(t, client, 3), []string{"subscribed", "bars_subscribed", "error:subscription_limit"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replies = %v, want %v", got, want)
+	}
+
+	// At the cap, a subscription the client already has may be renewed, e.g. to change
+	// its throttle, but the floor still applies.
+	send(`{"type":"subscribe","throttle_ms":5000,"market_ids":["` + planMarket(0) + `","` + planMarket(2) + `"]}`)
+	if got, want := replyCodes(t, client, 2), []string{"subscribed", "error:subscription_limit"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replies at the cap = %v, want %v", got, want)
+	}
+	if got := throttleOf(client, planMarket(0)); got != 5*time.Second {
+		t.Errorf("renewed throttle = %s, want 5s", got)
+	}
+
+	send(`{"type":"unsubscribe_bars","resolution":"1m","market_ids":["` + planMarket(0) + `"]}`)
+	send(`{"type":"subscribe","market_ids":["` + planMarket(2) + `"]}`)
+	if got := replyCodes(t, client, 1); got[0] != "subscribed" {
+		t.Errorf("reply after unsubscribing = %s, want subscribed", got[0])
+	}
+}
+
+func TestHubStatsCountClientsByPlan(t *testing.T) {
+	hub := newTestHub(t)
+	for _, plan := range []Plan{PlanAnonymous, "", PlanAuthenticated, PlanAPIKey, PlanAPIKey, PlanAPIKey} {
+		client := newTestClient(t, hub, 1)
+		client.Plan = plan
+		hub.Register <- client
+	}
+
+	want := map[Plan]int{PlanAnonymous: 2, PlanAuthenticated: 1, PlanAPIKey: 3}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := hub.Stats(0)
+		if stats.Clients == 6 {
+			if !reflect.DeepEqual(stats.ClientsByPlan, want) {
+				t.Errorf("clients by plan = %v, want %v", stats.ClientsByPlan, want)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of 6 clients registered", stats.Clients)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
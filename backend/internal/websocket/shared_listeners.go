@@ -0,0 +1,166 @@
+/**
+ * @description
+ * This file implements the shared Redis listeners of the hub, which serve the subscriptions
+ * made once the per-market listener cap is reached, so that the number of Redis connections
+ * and listener goroutines stays bounded however many markets clients subscribe to.
+ *
+ * Key features:
+ * - Pattern Subscriptions: One PSUBSCRIBE listener per channel kind (`market:*` for order
+ *   books, `ohlcv:*` for bars), started with the first shared subscription of its kind.
+ * - Channel Routing: Each received message is mapped back to its subscription key from the
+ *   channel name, and relayed only if that key is shared, so markets with their own listener
+ *   are not relayed twice.
+ * - Per-Key Freshness: The time of each shared key's latest relayed message is kept, so that
+ *   a cached snapshot older than a live update of that key is not sent (see deliverSnapshot).
+ *
+ * @notes
+ * - A pattern listener receives the messages of every market of its kind, subscribed or not,
+ *   and discards the others. The cap trades that filtering for fewer connections.
+ * - A shared key is dropped when its last client leaves, like a per-market listener is stopped.
+ *   The pattern listeners themselves, at most one per kind, run until the hub shuts down.
+ */
+
+package websocket
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/poly-pro/backend/internal/channels"
+	"github.com/redis/go-redis/v9"
+)
+
+// sharedSubscription is a subscription key served by the shared pattern listeners.
+type sharedSubscription struct {
+	lastMessageAt atomic.Int64 // Unix nanoseconds, 0 if no message relayed yet
+}
+
+// SetMaxListeners caps the number of per-market Redis listeners; further subscriptions are
+// served by shared pattern listeners. Zero or less is unlimited. It must be called before Run.
+func (h *Hub) SetMaxListeners(limit int) {
+	h.maxListeners = max(limit, 0)
+}
+
+// routeShared serves a subscription key from the shared pattern listener of its kind,
+// starting the listener if needed. It must only be called from the Run loop.
+func (h *Hub) routeShared(key string) {
+	h.sharedMu.Lock()
+	h.sharedKeys[key] = &sharedSubscription{}
+	h.sharedMu.Unlock()
+
+	pattern := channels.Pattern(channels.KindMarket)
+	if isBarSubscription(key) {
+		pattern = channels.Pattern(channels.KindOHLCV)
+	}
+	h.logger.Info("hub: redis listener cap reached, routing subscription through a shared pattern listener",
+		"key", key,
+		"pattern", pattern,
+		"max_listeners", h.maxListeners,
+		"shared_subscriptions", len(h.sharedKeys))
+	if h.patternListeners[pattern] != nil {
+		return
+	}
+
+	listener := &redisListener{channel: pattern, startedAt: time.Now()}
+	h.patternListeners[pattern] = listener
+	h.listenerWG.Add(1)
+	go func() {
+		defer h.listenerWG.Done()
+		h.keepSubscribed(h.ctx, pattern, func() bool {
+			return h.consumePattern(pattern, listener)
+		})
+	}()
+}
+
+// unrouteShared stops relaying a subscription key whose last client left, if it was shared.
+// It must only be called from the Run loop.
+func (h *Hub) unrouteShared(key string) {
+	if h.sharedKeys[key] == nil {
+		return
+	}
+	h.sharedMu.Lock()
+	delete(h.sharedKeys, key)
+	h.sharedMu.Unlock()
+}
+
+// isShared reports whether a subscription key is served by the shared pattern listeners.
+func (h *Hub) isShared(key string) bool {
+	return h.sharedSubscription(key) != nil
+}
+
+// sharedSubscription returns a subscription key's state if it is served by the shared pattern
+// listeners, or nil.
+func (h *Hub) sharedSubscription(key string) *sharedSubscription {
+	h.sharedMu.RLock()
+	defer h.sharedMu.RUnlock()
+	return h.sharedKeys[key]
+}
+
+// sharedKey returns the subscription key of a channel received by a pattern listener: the
+// market ID for market channels, and the channel itself for OHLCV channels.
+func sharedKey(channel string) (string, bool) {
+	if marketID, ok := channels.ParseMarketChannel(channel); ok {
+		return marketID, true
+	}
+	if _, _, ok := channels.ParseOHLCVChannel(channel); ok {
+		return channel, true
+	}
+	return "", false
+}
+
+// consumePattern pattern-subscribes and relays the messages of shared subscription keys until
+// the subscription is lost or the hub shuts down. It reports whether the subscription was
+// ever confirmed; later confirmations are counted as Redis reconnects.
+func (h *Hub) consumePattern(pattern string, listener *redisListener) bool {
+	pubsub := h.redisClient.PSubscribe(h.ctx, pattern)
+	defer pubsub.Close()
+
+	h.logger.Info("subscribing to redis pattern", "pattern", pattern)
+	if _, err := pubsub.Receive(h.ctx); err != nil {
+		if h.ctx.Err() == nil {
+			h.logger.Error("failed to subscribe to redis pattern", "pattern", pattern, "error", err)
+		}
+		return false
+	}
+
+	ch := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-h.ctx.Done():
+			return true
+		case received, ok := <-ch:
+			if !ok {
+				return true
+			}
+			if sub, isSubscription := received.(*redis.Subscription); isSubscription {
+				if sub.Kind == "psubscribe" {
+					listener.reconnects.Add(1)
+					h.redisReconnects.Add(1)
+					h.logger.Warn("redis pattern listener resubscribed after reconnect", "pattern", pattern, "reconnects", listener.reconnects.Load())
+				}
+				continue
+			}
+			msg, isMessage := received.(*redis.Message)
+			if !isMessage {
+				continue
+			}
+			key, ok := sharedKey(msg.Channel)
+			if !ok {
+				continue
+			}
+			shared := h.sharedSubscription(key)
+			if shared == nil {
+				continue
+			}
+			receivedAt := time.Now().UnixNano()
+			listener.messages.Add(1)
+			listener.lastMessageAt.Store(receivedAt)
+			shared.lastMessageAt.Store(receivedAt)
+			select {
+			case h.broadcast <- marketMessage{marketID: key, payload: []byte(msg.Payload)}:
+			case <-h.ctx.Done():
+				return true
+			}
+		}
+	}
+}
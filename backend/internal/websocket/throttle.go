@@ -0,0 +1,123 @@
+/**
+ * @description
+ * This file implements per-subscription throttling (conflation) of market updates.
+ * A client may ask for at most one update per interval for a market by sending
+ * `throttle_ms` with its subscribe message.
+ *
+ * Key features:
+ * - Conflation: While a throttle window is open, only the most recent payload for the
+ *   market is kept; older pending payloads are dropped and counted as conflated.
+ * - Leading and Trailing Delivery: The first update in a window is delivered immediately,
+ *   and the latest pending update (if any) is delivered when the window elapses.
+ * - Isolation: Unthrottled subscriptions bypass this path entirely, so their delivery
+ *   order is unchanged.
+ *
+ * @notes
+ * - All throttle state is guarded by the client's throttleMu, which also guards closing
+ *   the Send channel so that timer callbacks never send on a closed channel.
+ */
+
+package websocket
+
+import "time"
+
+// conflator holds the throttling state for a single throttled market subscription.
+type conflator struct {
+	interval time.Duration
+	timer    *time.Timer
+	pending  []byte
+}
+
+// setThrottle configures the throttle interval for a market subscription.
+// An interval of zero removes any existing throttle.
+func (c *Client) setThrottle(marketID string, interval time.Duration) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	if existing, ok := c.throttles[marketID]; ok {
+		if existing.timer != nil {
+			existing.timer.Stop()
+		}
+		delete(c.throttles, marketID)
+	}
+	if interval <= 0 {
+		return
+	}
+	if c.throttles == nil {
+		c.throttles = make(map[string]*conflator)
+	}
+	c.throttles[marketID] = &conflator{interval: interval}
+}
+
+/**
+ * @description
+ * conflate applies the market's throttle (if any) to an outgoing message.
+ *
+ * @param marketID The market the message belongs to.
+ * @param message The message payload.
+ * @returns true if the message was held back by the throttle, false if the caller
+ *          should deliver it immediately.
+ */
+func (c *Client) conflate(marketID string, message []byte) bool {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	state, ok := c.throttles[marketID]
+	if !ok || c.sendClosed {
+		return false
+	}
+
+	// No open window: deliver now and open a new window.
+	if state.timer == nil {
+		state.timer = time.AfterFunc(state.interval, func() { c.flushThrottled(marketID, state) })
+		return false
+	}
+
+	// Window open: keep only the most recent payload.
+	if state.pending != nil {
+		c.Hub.conflatedMessages.Add(1)
+	}
+	state.pending = message
+	return true
+}
+
+// flushThrottled delivers the latest pending payload when a throttle window elapses.
+func (c *Client) flushThrottled(marketID string, state *conflator) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	// The subscription may have been removed or reconfigured since the timer was armed.
+	if c.sendClosed || c.throttles[marketID] != state {
+		return
+	}
+
+	if state.pending == nil {
+		state.timer = nil
+		return
+	}
+
+	select {
+	case c.Send <- state.pending:
+	default:
+		c.Logger.Warn("client send buffer full, dropping throttled update", "market_id", marketID, "client", c.Conn.RemoteAddr())
+	}
+	state.pending = nil
+	state.timer.Reset(state.interval)
+}
+
+// closeSend closes the client's Send channel exactly once and stops any throttle timers.
+func (c *Client) closeSend() {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	if c.sendClosed {
+		return
+	}
+	c.sendClosed = true
+	for _, state := range c.throttles {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+	}
+	close(c.Send)
+}
@@ -0,0 +1,159 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+const throttledMarket = "0xthrottled"
+
+// deliver sends a market update to a client like the hub's broadcast does: throttled
+// subscriptions may hold it back, and it is queued on Send otherwise.
+func deliver(t *testing.T, client *Client, payload string) {
+	t.Helper()
+	if client.conflate(throttledMarket, []byte(payload)) {
+		return
+	}
+	select {
+	case client.Send <- []byte(payload):
+	default:
+		t.Fatalf("send buffer full delivering %q", payload)
+	}
+}
+
+// receive returns the next message queued for a client, failing after timeout.
+func receive(t *testing.T, client *Client, timeout time.Duration) string {
+	t.Helper()
+	select {
+	case message := <-client.Send:
+		return string(message)
+	case <-time.After(timeout):
+		t.Fatalf("no message within %s", timeout)
+		return ""
+	}
+}
+
+// expectNothing fails if a message is queued for a client within wait.
+func expectNothing(t *testing.T, client *Client, wait time.Duration) {
+	t.Helper()
+	select {
+	case message := <-client.Send:
+		t.Fatalf("unexpected message %q", message)
+	case <-time.After(wait):
+	}
+}
+
+// TestThrottleConflatesBurst checks that a burst within one window delivers its first update
+// at once and only its latest one when the window ends, counting the dropped ones.
+func TestThrottleConflatesBurst(t *testing.T) {
+	hub := newTestHub(t)
+	client := newTestClient(t, hub, 16)
+	client.setThrottle(throttledMarket, 100*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		deliver(t, client, fmt.Sprintf("update-%d", i))
+	}
+	if got := receive(t, client, time.Second); got != "update-0" {
+		t.Fatalf("leading update = %q, want update-0", got)
+	}
+	if got := receive(t, client, time.Second); got != "update-4" {
+		t.Errorf("trailing update = %q, want update-4", got)
+	}
+	// update-1 to update-3 were replaced by a later update before the window ended.
+	if got := hub.conflatedMessages.Load(); got != 3 {
+		t.Errorf("conflated messages = %d, want 3", got)
+	}
+	expectNothing(t, client, 250*time.Millisecond)
+}
+
+// TestThrottleFlushesAtIntervalEnd checks that a held update is delivered when its window
+// ends, not before, and that an update after a quiet window is delivered at once.
+func TestThrottleFlushesAtIntervalEnd(t *testing.T) {
+	const interval = 150 * time.Millisecond
+	hub := newTestHub(t)
+	client := newTestClient(t, hub, 16)
+	client.setThrottle(throttledMarket, interval)
+
+	start := time.Now()
+	deliver(t, client, "leading")
+	deliver(t, client, "held")
+	if got := receive(t, client, time.Second); got != "leading" {
+		t.Fatalf("first message = %q, want leading", got)
+	}
+	if got := receive(t, client, time.Second); got != "held" {
+		t.Fatalf("second message = %q, want held", got)
+	}
+	if elapsed := time.Since(start); elapsed < interval {
+		t.Errorf("held update delivered after %s, before the %s window ended", elapsed, interval)
+	}
+
+	// The flush opened another window, which ends without updates and closes.
+	time.Sleep(2 * interval)
+	deliver(t, client, "after quiet window")
+	select {
+	case got := <-client.Send:
+		if string(got) != "after quiet window" {
+			t.Errorf("message = %q, want after quiet window", got)
+		}
+	default:
+		t.Error("update after a quiet window was held back")
+	}
+}
+
+// TestSetThrottleZeroDisablesConflation checks that clearing a throttle delivers every
+// update at once and drops the update held by the old window.
+func TestSetThrottleZeroDisablesConflation(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	hub := newTestHub(t)
+	client := newTestClient(t, hub, 16)
+	client.setThrottle(throttledMarket, interval)
+	deliver(t, client, "leading")
+	deliver(t, client, "held")
+
+	client.setThrottle(throttledMarket, 0)
+	for i := 0; i < 3; i++ {
+		deliver(t, client, fmt.Sprintf("unthrottled-%d", i))
+	}
+	for _, want := range []string{"leading", "unthrottled-0", "unthrottled-1", "unthrottled-2"} {
+		if got := receive(t, client, time.Second); got != want {
+			t.Fatalf("message = %q, want %q", got, want)
+		}
+	}
+	// The old window's timer was stopped, so the held update is never delivered.
+	expectNothing(t, client, 2*interval)
+}
+
+// TestCloseSendWhileThrottled closes a client's Send channel while throttle timers are
+// flushing held updates; the timers must not send on the closed channel (run with -race).
+func TestCloseSendWhileThrottled(t *testing.T) {
+	hub := newTestHub(t)
+	client := newTestClient(t, hub, 4)
+	for i := 0; i < 8; i++ {
+		client.setThrottle(fmt.Sprintf("market-%d", i), time.Millisecond)
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		for i := 0; i < 8; i++ {
+			// Held updates are flushed by the timers; the others would be sent by the hub.
+			client.conflate(fmt.Sprintf("market-%d", i), []byte("update"))
+		}
+		select {
+		case <-client.Send:
+		default:
+		}
+	}
+	client.closeSend()
+	client.closeSend() // Closing twice is a no-op
+
+	// Timers armed before the close fire and must find the channel closed.
+	time.Sleep(20 * time.Millisecond)
+	if client.conflate("market-0", []byte("late")) {
+		t.Error("update held for a client whose Send channel is closed")
+	}
+	client.sendError(errorMessage{Code: "late", Message: "sent after close"})
+	for range client.Send {
+		// Drain what was queued before the close; the range ends because Send is closed.
+	}
+}
@@ -0,0 +1,108 @@
+/**
+ * @description
+ * This file measures the bandwidth of client connections, so that the savings of
+ * permessage-deflate compression can be observed.
+ *
+ * Key features:
+ * - Payload Bytes: Counted by the write pump, before compression and framing.
+ * - Wire Bytes: Counted on the hijacked network connection, after compression and framing.
+ * - Aggregation: Each client's counter also feeds the hub's totals, reported in HubStats.
+ *
+ * @notes
+ * - Wire bytes include the handshake response and control frames (pings, close), so on
+ *   connections with little traffic the savings are slightly understated.
+ */
+
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// TrafficCounter counts the bytes written to one or more client connections.
+type TrafficCounter struct {
+	payload atomic.Int64
+	wire    atomic.Int64
+	parent  *TrafficCounter // Also credited with every count, e.g. the hub's totals
+}
+
+// TrafficStats reports the bytes written before and after compression and framing.
+type TrafficStats struct {
+	PayloadBytes int64   `json:"payload_bytes"`
+	WireBytes    int64   `json:"wire_bytes"`
+	SavingsRatio float64 `json:"savings_ratio"` // 1 - wire/payload; 0 when nothing was written
+}
+
+// addPayload counts message bytes queued for writing.
+func (t *TrafficCounter) addPayload(n int) {
+	for ; t != nil; t = t.parent {
+		t.payload.Add(int64(n))
+	}
+}
+
+// addWire counts bytes written to the network.
+func (t *TrafficCounter) addWire(n int) {
+	for ; t != nil; t = t.parent {
+		t.wire.Add(int64(n))
+	}
+}
+
+// Stats returns a snapshot of the counter. A nil counter reports zeros.
+func (t *TrafficCounter) Stats() TrafficStats {
+	if t == nil {
+		return TrafficStats{}
+	}
+	stats := TrafficStats{PayloadBytes: t.payload.Load(), WireBytes: t.wire.Load()}
+	if stats.PayloadBytes > 0 {
+		stats.SavingsRatio = 1 - float64(stats.WireBytes)/float64(stats.PayloadBytes)
+	}
+	return stats
+}
+
+/**
+ * @description
+ * WrapResponseWriter wraps the response writer of a WebSocket upgrade request so that the
+ * bytes written to the hijacked connection are counted.
+ *
+ * @param w The response writer passed to the upgrader.
+ * @returns A response writer whose Hijack returns a counting connection.
+ */
+func (t *TrafficCounter) WrapResponseWriter(w http.ResponseWriter) http.ResponseWriter {
+	return &countingResponseWriter{ResponseWriter: w, traffic: t}
+}
+
+// countingResponseWriter is an http.ResponseWriter whose hijacked connection counts written bytes.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	traffic *TrafficCounter
+}
+
+// Hijack hijacks the underlying connection and wraps it to count written bytes.
+func (w *countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not implement http.Hijacker")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &countingConn{Conn: conn, traffic: w.traffic}, rw, nil
+}
+
+// countingConn is a net.Conn that counts the bytes written to it.
+type countingConn struct {
+	net.Conn
+	traffic *TrafficCounter
+}
+
+// Write writes to the connection and counts the bytes written.
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.traffic.addWire(n)
+	return n, err
+}
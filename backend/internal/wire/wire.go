@@ -0,0 +1,96 @@
+/**
+ * @description
+ * This package defines the wire format of the payloads published to Redis Pub/Sub, which the
+ * WebSocket hub forwards to clients as they are. They are contracts with downstream consumers
+ * (the frontend and internal tools), so every payload is built from the structs in this
+ * package rather than ad hoc maps, and carries the schema version it was built with.
+ *
+ * Key features:
+ * - Schema Versioning: Every payload has a `schema_version` field set to `SchemaVersion`.
+ * - Payload Types: `Book` and `MarketMeta` on the market channels, `Bar` on the OHLCV
+ *   channels, `OrderUpdate` on the order channels, and `UserOrder` and `UserTrade` on the
+ *   user channels.
+ *
+ * @notes
+ * - Adding an optional field is backwards compatible. Removing or renaming a field, or
+ *   changing its type or meaning, breaks consumers and requires bumping SchemaVersion.
+ * - Consumers that forward payloads (e.g. the hub) must pass `schema_version` through untouched.
+ */
+
+package wire
+
+import "github.com/poly-pro/backend/internal/polymarket"
+
+// SchemaVersion is the version of the payload schemas defined in this package.
+const SchemaVersion = 1
+
+// BookLevel is a price level of an order book.
+type BookLevel struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+// Book is an order book update, published on a market channel.
+type Book struct {
+	SchemaVersion int         `json:"schema_version"`
+	EventType     string      `json:"event_type"` // "book"
+	AssetID       string      `json:"asset_id"`
+	Market        string      `json:"market"` // Condition ID
+	Bids          []BookLevel `json:"bids"`
+	Asks          []BookLevel `json:"asks"`
+	Timestamp     string      `json:"timestamp"` // Unix timestamp in milliseconds
+	Hash          string      `json:"hash"`
+}
+
+// MarketMeta is a change of a token's trading parameters, published on a market channel.
+type MarketMeta struct {
+	SchemaVersion int    `json:"schema_version"`
+	EventType     string `json:"event_type"` // "market_meta"
+	AssetID       string `json:"asset_id"`
+	Market        string `json:"market"` // Condition ID
+	TickSize      string `json:"tick_size"`
+	OldTickSize   string `json:"old_tick_size"`
+	Timestamp     string `json:"timestamp"` // Unix timestamp in milliseconds
+}
+
+// Bar is a completed OHLCV bar, published on the OHLCV channel of its market and resolution.
+// Its t/o/h/l/c/v fields have the shape of the history endpoint's bars.
+type Bar struct {
+	SchemaVersion int     `json:"schema_version"`
+	EventType     string  `json:"event_type"` // "bar"
+	Market        string  `json:"market"`     // Condition ID
+	Resolution    string  `json:"resolution"`
+	Time          int64   `json:"t"` // Bar start, Unix timestamp (seconds)
+	Open          float64 `json:"o"`
+	High          float64 `json:"h"`
+	Low           float64 `json:"l"`
+	Close         float64 `json:"c"`
+	Volume        float64 `json:"v"`
+}
+
+// OrderUpdate is a change of an order's local status, published on its user's order channel.
+type OrderUpdate struct {
+	SchemaVersion     int    `json:"schema_version"`
+	Type              string `json:"type"` // always "order_update"
+	OrderID           string `json:"order_id"`
+	PolymarketOrderID string `json:"polymarket_order_id,omitempty"`
+	MarketID          string `json:"market_id"`
+	PreviousStatus    string `json:"previous_status"`
+	Status            string `json:"status"`
+	EventSeq          int64  `json:"event_seq"` // Increases with every status update of the order
+	Timestamp         int64  `json:"timestamp"` // Unix timestamp in milliseconds
+}
+
+// UserOrder is a CLOB user channel order event, published as received on its owner's
+// orders channel.
+type UserOrder struct {
+	SchemaVersion int `json:"schema_version"`
+	polymarket.UserOrderMessage
+}
+
+// UserTrade is a CLOB user channel trade event, published as received on its owner's
+// trades channel.
+type UserTrade struct {
+	SchemaVersion int `json:"schema_version"`
+	polymarket.UserTradeMessage
+}
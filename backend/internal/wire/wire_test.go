@@ -0,0 +1,152 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/poly-pro/backend/internal/polymarket"
+)
+
+// payloads pairs each payload type with a value of it, and the name of its v1 fixture in
+// testdata/v1.
+var payloads = []struct {
+	fixture string
+	value   any
+}{
+	{"book.json", &Book{
+		SchemaVersion: SchemaVersion,
+		EventType:     "book",
+		AssetID:       "71321045679252212594626385532706912750332728571942532289631379312455583992563",
+		Market:        "0x5f65177b394277fd294cd75650044e32ba009a95022d88a0c1d565897d72f8f1",
+		Bids:          []BookLevel{{Price: "0.54", Size: "1200.5"}},
+		Asks:          []BookLevel{},
+		Timestamp:     "1760790615123",
+		Hash:          "0xhash",
+	}},
+	{"market_meta.json", &MarketMeta{
+		SchemaVersion: SchemaVersion,
+		EventType:     "market_meta",
+		AssetID:       "1",
+		Market:        "0xmarket",
+		TickSize:      "0.001",
+		OldTickSize:   "0.01",
+		Timestamp:     "1760790615123",
+	}},
+	{"bar.json", &Bar{
+		SchemaVersion: SchemaVersion,
+		EventType:     "bar",
+		Market:        "0xmarket",
+		Resolution:    "1D",
+		Time:          1760745600,
+		Open:          0.1,
+		High:          0.2,
+		Low:           0.05,
+		Close:         0.15,
+		Volume:        1e6,
+	}},
+	{"order_update.json", &OrderUpdate{
+		SchemaVersion:  SchemaVersion,
+		Type:           "order_update",
+		OrderID:        "6f1c2d9e-8a4b-4c3d-9e2f-1a2b3c4d5e01",
+		MarketID:       "0xmarket",
+		PreviousStatus: "pending",
+		Status:         "open",
+		EventSeq:       1,
+		Timestamp:      1760790615123,
+	}},
+	{"user_order.json", &UserOrder{
+		SchemaVersion: SchemaVersion,
+		UserOrderMessage: polymarket.UserOrderMessage{
+			EventType:       "order",
+			Type:            "PLACEMENT",
+			ID:              "0xorder",
+			Owner:           "owner",
+			Side:            "SELL",
+			Price:           "0.45",
+			OriginalSize:    "10",
+			SizeMatched:     "0",
+			AssociateTrades: []string{},
+		},
+	}},
+	{"user_trade.json", &UserTrade{
+		SchemaVersion: SchemaVersion,
+		UserTradeMessage: polymarket.UserTradeMessage{
+			EventType:   "trade",
+			Type:        "TRADE",
+			ID:          "trade",
+			Owner:       "owner",
+			Status:      "CONFIRMED",
+			MakerOrders: []polymarket.TradeMakerOrder{{OrderID: "0xmaker", MatchedAmount: "5"}},
+		},
+	}},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, payload := range payloads {
+		t.Run(payload.fixture, func(t *testing.T) {
+			encoded, err := json.Marshal(payload.value)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			decoded := reflect.New(reflect.TypeOf(payload.value).Elem()).Interface()
+			if err := json.Unmarshal(encoded, decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, payload.value) {
+				t.Errorf("round trip = %+v, want %+v", decoded, payload.value)
+			}
+
+			var fields map[string]any
+			if err := json.Unmarshal(encoded, &fields); err != nil {
+				t.Fatalf("unmarshal fields: %v", err)
+			}
+			if fields["schema_version"] != float64(SchemaVersion) {
+				t.Errorf("schema_version = %v, want %d", fields["schema_version"], SchemaVersion)
+			}
+		})
+	}
+}
+
+// TestV1Compatibility decodes payloads recorded with schema version 1 into the current types
+// and encodes them again. Every field of a recorded payload must survive with its value, so
+// that removing, renaming, or retyping a field fails here; new fields are allowed.
+func TestV1Compatibility(t *testing.T) {
+	for _, payload := range payloads {
+		t.Run(payload.fixture, func(t *testing.T) {
+			recorded, err := os.ReadFile(filepath.Join("testdata", "v1", payload.fixture))
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+			decoded := reflect.New(reflect.TypeOf(payload.value).Elem()).Interface()
+			decoder := json.NewDecoder(bytes.NewReader(recorded))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(decoded); err != nil {
+				t.Fatalf("decode v1 payload: %v", err)
+			}
+			encoded, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			var want, got map[string]any
+			if err := json.Unmarshal(recorded, &want); err != nil {
+				t.Fatalf("unmarshal fixture: %v", err)
+			}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatalf("unmarshal encoded: %v", err)
+			}
+			if want["schema_version"] != float64(1) {
+				t.Errorf("fixture schema_version = %v, want 1", want["schema_version"])
+			}
+			for field, value := range want {
+				if !reflect.DeepEqual(got[field], value) {
+					t.Errorf("field %q = %v after re-encoding, want %v", field, got[field], value)
+				}
+			}
+		})
+	}
+}
@@ -64,7 +64,11 @@ export interface Market {
   category?: string
   liquidity?: string
   volume?: string // Total volume for sorting/display
-  end_date?: string
+  // Dates are RFC3339 in UTC; null or absent when unknown
+  start_date?: string | null
+  end_date?: string | null
+  created_at?: string | null
+  updated_at?: string | null
 }
 
 /**
@@ -73,6 +77,7 @@ export interface Market {
  * This corresponds to the `MockOrderBookData` struct on the backend.
  */
 export interface WebSocketBookMessage {
+  schema_version: number // Version of the payload schema (see backend/internal/wire)
   event_type: 'book'
   asset_id: string
   market: string // This is the marketId (Condition ID)
@@ -82,6 +87,25 @@ export interface WebSocketBookMessage {
   hash: string
 }
 
+/**
+ * @interface WebSocketBarMessage
+ * @description Defines the shape of the `bar` event message sent by the backend WebSocket service to
+ * clients subscribed with `subscribe_bars` when an OHLCV bar completes. Its t/o/h/l/c/v fields have
+ * the shape of the bars returned by the market history endpoint.
+ */
+export interface WebSocketBarMessage {
+  schema_version: number
+  event_type: 'bar'
+  market: string // This is the marketId (Condition ID)
+  resolution: string
+  t: number // Bar start, Unix timestamp in seconds
+  o: number
+  h: number
+  l: number
+  c: number
+  v: number
+}
+
 /**
  * @interface NewsEvent
  * @description Represents a news event related to a market that can be displayed on the chart.
@@ -58,11 +58,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Chain the vault backends. The fallback is only configured on staging, where
+	// VAULT_FALLBACK_ALLOWED=true; production configs omit it entirely.
+	backends := []vault.Backend{{Name: "primary", Vault: mockVault}}
+	if cfg.VaultFallbackAllowed {
+		fallbackVault, err := vault.NewMockVault(cfg.VaultFallbackPrivateKey, logger)
+		if err != nil {
+			logger.Error("failed to initialize fallback vault", "error", err)
+			os.Exit(1)
+		}
+		backends = append(backends, vault.Backend{Name: "fallback", Vault: fallbackVault, Fallback: true})
+	}
+	vaultChain, err := vault.NewChain(logger, cfg.VaultFallbackAllowed, backends...)
+	if err != nil {
+		logger.Error("failed to initialize vault chain", "error", err)
+		os.Exit(1)
+	}
+
+	// Simulate signing is for load testing only and must never run against a real vault.
+	if cfg.SimulateSigning {
+		if !vault.IsMock(vaultChain) {
+			logger.Error("simulate signing mode is not allowed when a real vault backend is configured")
+			os.Exit(1)
+		}
+		logger.Warn("simulate signing mode enabled; all signatures are FAKE. THIS IS NOT FOR PRODUCTION USE.")
+	}
+
 	// Initialize the crypto signer.
 	signer := crypto.NewSigner(logger)
 
 	// Initialize the gRPC server implementation.
-	grpcServer := server.NewGRPCServer(logger, mockVault, signer)
+	grpcServer := server.NewGRPCServer(logger, vaultChain, signer, cfg.SimulateSigning)
 
 	// ------------------------------------------------------------------
 	// Server Setup with Connection Multiplexing (HTTP + gRPC)
@@ -101,7 +127,13 @@ func main() {
 	httpMux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"ok","service":"remote-signer","port":"%s"}`, cfg.Port)
+		stats := vaultChain.Stats()
+		mode := "live"
+		if cfg.SimulateSigning {
+			mode = server.SignerModeSimulate
+		}
+		fmt.Fprintf(w, `{"status":"ok","service":"remote-signer","port":"%s","mode":"%s","vault_served":%d,"vault_fallback_served":%d,"vault_failures":%d}`,
+			cfg.Port, mode, stats.Served, stats.FallbackServed, stats.Failures)
 	})
 	
 	httpServer := &http.Server{
@@ -26,6 +26,11 @@ import (
 type Config struct {
 	Port            string
 	DummyPrivateKey string
+	// Vault failover (staging only)
+	VaultFallbackAllowed    bool   // Allow serving test keys when the primary vault fails
+	VaultFallbackPrivateKey string // Test key served by the fallback vault
+	// Load testing
+	SimulateSigning bool // Return deterministic fake signatures without using the vault
 }
 
 /**
@@ -61,6 +66,17 @@ func LoadConfig(path string) (config Config, err error) {
 		return Config{}, errors.New("DUMMY_PRIVATE_KEY environment variable is not set")
 	}
 
+	// Read the optional vault fallback settings. The fallback must be enabled explicitly.
+	config.VaultFallbackAllowed = os.Getenv("VAULT_FALLBACK_ALLOWED") == "true"
+	config.VaultFallbackPrivateKey = os.Getenv("VAULT_FALLBACK_PRIVATE_KEY")
+	if config.VaultFallbackAllowed && config.VaultFallbackPrivateKey == "" {
+		return Config{}, errors.New("VAULT_FALLBACK_PRIVATE_KEY must be set when VAULT_FALLBACK_ALLOWED is true")
+	}
+
+	// Read the optional simulate signing mode for load testing. It must be enabled explicitly,
+	// and is refused at startup unless every vault backend is a mock vault.
+	config.SimulateSigning = os.Getenv("SIMULATE_SIGNING") == "true"
+
 	return
 }
 
@@ -48,6 +48,26 @@ func NewSigner(logger *slog.Logger) *Signer {
 	}
 }
 
+/**
+ * @description
+ * SimulatedSignature returns a deterministic fake signature for load testing. It has the
+ * shape of a real 65-byte signature (r, s, v) but is derived by hashing the user ID and
+ * payload, so it involves no private key and will not verify.
+ *
+ * @param userID The user the signature is requested for.
+ * @param payloadJSON The payload the signature is requested for.
+ * @returns The fake signature as a hexadecimal string.
+ */
+func SimulatedSignature(userID string, payloadJSON string) string {
+	r := crypto.Keccak256([]byte("simulated-signature:"), []byte(userID), []byte{0}, []byte(payloadJSON))
+	s := crypto.Keccak256(r)
+	signature := make([]byte, 0, 65)
+	signature = append(signature, r...)
+	signature = append(signature, s...)
+	signature = append(signature, 27)
+	return hexutil.Encode(signature)
+}
+
 /**
  * @description
  * SignTypedData signs an EIP-712 typed data payload with a given private key.
@@ -69,7 +89,7 @@ func (s *Signer) SignTypedData(privateKeyHex string, payloadJSON string) (string
 	// 2. Unmarshal the JSON payload into the EIP-712 TypedData structure.
 	var typedData apitypes.TypedData
 	if err := json.Unmarshal([]byte(payloadJSON), &typedData); err != nil {
-		s.logger.Error("failed to unmarshal EIP-712 payload JSON", "error", err, "payload", payloadJSON)
+		s.logger.Error("failed to unmarshal EIP-712 payload JSON", "error", err, "payload_bytes", len(payloadJSON))
 		return "", errors.New("invalid EIP-712 payload JSON")
 	}
 
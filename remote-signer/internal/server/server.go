@@ -27,16 +27,26 @@ import (
 	"github.com/poly-pro/remote-signer/internal/crypto"
 	"github.com/poly-pro/remote-signer/internal/vault"
 	"github.com/poly-pro/remote-signer/proto"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// SignerModeHeader is the gRPC response header carrying the signing mode.
+	SignerModeHeader = "x-signer-mode"
+	// SignerModeSimulate marks responses carrying a simulated signature.
+	SignerModeSimulate = "simulate"
+)
+
 // Server implements the gRPC Signer service.
 type Server struct {
 	proto.UnimplementedSignerServer // Recommended for forward compatibility
 	logger                          *slog.Logger
 	vault                           vault.Vault
 	signer                          *crypto.Signer
+	simulate                        bool // Return simulated signatures without using the vault
 }
 
 /**
@@ -46,13 +56,15 @@ type Server struct {
  * @param logger A structured logger.
  * @param v The vault implementation for fetching private keys.
  * @param s The crypto signer for performing signing operations.
+ * @param simulate Whether to return simulated signatures instead of signing (load testing only).
  * @returns A pointer to a new Server instance.
  */
-func NewGRPCServer(logger *slog.Logger, v vault.Vault, s *crypto.Signer) *Server {
+func NewGRPCServer(logger *slog.Logger, v vault.Vault, s *crypto.Signer, simulate bool) *Server {
 	return &Server{
-		logger: logger,
-		vault:  v,
-		signer: s,
+		logger:   logger,
+		vault:    v,
+		signer:   s,
+		simulate: simulate,
 	}
 }
 
@@ -80,6 +92,19 @@ func (s *Server) SignTransaction(ctx context.Context, req *proto.SignRequest) (*
 		return nil, status.Error(codes.InvalidArgument, "payload_json is required")
 	}
 
+	// In simulate mode, return a deterministic fake signature without touching the vault,
+	// flagged by a response header so that clients can tell it apart from a real one.
+	if s.simulate {
+		if err := grpc.SetHeader(ctx, metadata.Pairs(SignerModeHeader, SignerModeSimulate)); err != nil {
+			s.logger.Error("failed to set signer mode header", "error", err, "user_id", req.UserId)
+			return nil, status.Error(codes.Internal, "failed to flag simulated signature")
+		}
+		s.logger.Warn("returning SIMULATED signature (simulate signing mode)", "user_id", req.UserId)
+		return &proto.SignResponse{
+			Signature: crypto.SimulatedSignature(req.UserId, req.PayloadJson),
+		}, nil
+	}
+
 	// 2. Fetch the private key from the vault.
 	// This is a critical step where a real implementation would securely retrieve
 	// the user-specific key. Our mock vault returns a dummy key.
@@ -0,0 +1,150 @@
+/**
+ * @description
+ * This file implements `Chain`, a `Vault` that tries several backends in order, so that a
+ * staging signer can keep serving requests from a set of test keys while its primary secret
+ * store is unreachable.
+ *
+ * Key features:
+ * - Ordered Failover: Backends are tried in order until one returns a key.
+ * - Explicit Opt-In: Backends marked as fallbacks are only used when the chain is created
+ *   with fallback allowed (`VAULT_FALLBACK_ALLOWED=true`); otherwise they are never consulted.
+ * - Audit Trail: Every served key is recorded in the audit log with the backend that served it.
+ * - Fallback Metric: Requests served by a fallback backend are counted and logged as warnings.
+ *
+ * @notes
+ * - `ErrKeyNotFound` from a backend is authoritative and does not trigger failover, so a
+ *   missing user key is never silently replaced by a test key.
+ */
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// ErrKeyNotFound is returned by a backend that is healthy but holds no key for the user.
+var ErrKeyNotFound = errors.New("no private key found for user")
+
+// Backend is a named Vault in a Chain.
+type Backend struct {
+	Name     string
+	Vault    Vault
+	Fallback bool // Only consulted when the chain allows fallback
+}
+
+// ChainStats is a snapshot of the chain's counters.
+type ChainStats struct {
+	Served         int64 `json:"served"`
+	FallbackServed int64 `json:"fallback_served"`
+	Failures       int64 `json:"failures"`
+}
+
+// Chain is a Vault that tries its backends in order.
+type Chain struct {
+	backends      []Backend
+	allowFallback bool
+	logger        *slog.Logger
+
+	served         atomic.Int64
+	fallbackServed atomic.Int64
+	failures       atomic.Int64
+}
+
+/**
+ * @description
+ * NewChain creates a new Chain.
+ *
+ * @param logger A structured logger for audit and failover events.
+ * @param allowFallback Whether backends marked as fallbacks may be used.
+ * @param backends The backends to try, in order.
+ * @returns A pointer to a new Chain instance.
+ * @returns An error if no usable backend is configured.
+ */
+func NewChain(logger *slog.Logger, allowFallback bool, backends ...Backend) (*Chain, error) {
+	usable := 0
+	for _, backend := range backends {
+		if backend.Vault == nil {
+			return nil, fmt.Errorf("vault backend %q is nil", backend.Name)
+		}
+		if !backend.Fallback || allowFallback {
+			usable++
+		}
+	}
+	if usable == 0 {
+		return nil, errors.New("vault chain has no usable backends")
+	}
+	if allowFallback {
+		logger.Warn("vault fallback is allowed; test keys may be served when the primary vault is unavailable. THIS IS NOT FOR PRODUCTION USE.")
+	}
+	return &Chain{
+		backends:      backends,
+		allowFallback: allowFallback,
+		logger:        logger,
+	}, nil
+}
+
+/**
+ * @description
+ * GetPrivateKey returns the key from the first backend that serves it.
+ *
+ * @param ctx The context for the operation.
+ * @param userID The user whose key is requested.
+ * @returns The private key.
+ * @returns An error if every usable backend failed, or ErrKeyNotFound from a backend.
+ */
+func (c *Chain) GetPrivateKey(ctx context.Context, userID string) (string, error) {
+	var errs []error
+	for _, backend := range c.backends {
+		if backend.Fallback && !c.allowFallback {
+			continue
+		}
+
+		key, err := backend.Vault.GetPrivateKey(ctx, userID)
+		if err == nil {
+			c.served.Add(1)
+			if backend.Fallback {
+				c.fallbackServed.Add(1)
+				c.logger.Warn("vault fallback used to serve signing key",
+					"backend", backend.Name,
+					"user_id", userID,
+					"fallback_served_total", c.fallbackServed.Load())
+			}
+			c.logger.Info("audit: signing key served",
+				"audit", true,
+				"backend", backend.Name,
+				"fallback", backend.Fallback,
+				"user_id", userID)
+			return key, nil
+		}
+
+		if errors.Is(err, ErrKeyNotFound) {
+			c.failures.Add(1)
+			c.logger.Info("audit: signing key not found",
+				"audit", true,
+				"backend", backend.Name,
+				"user_id", userID)
+			return "", err
+		}
+		c.logger.Error("vault backend failed", "backend", backend.Name, "user_id", userID, "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", backend.Name, err))
+	}
+
+	c.failures.Add(1)
+	c.logger.Info("audit: signing key not served, all vault backends failed",
+		"audit", true,
+		"user_id", userID)
+	return "", fmt.Errorf("all vault backends failed: %w", errors.Join(errs...))
+}
+
+// Stats returns a snapshot of the chain's counters.
+func (c *Chain) Stats() ChainStats {
+	return ChainStats{
+		Served:         c.served.Load(),
+		FallbackServed: c.fallbackServed.Load(),
+		Failures:       c.failures.Load(),
+	}
+}
@@ -0,0 +1,162 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+const (
+	primaryKey  = "0x1111111111111111111111111111111111111111111111111111111111111111"
+	fallbackKey = "0x2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// stubVault returns key, or err when it is set, and counts its calls.
+type stubVault struct {
+	key   string
+	err   error
+	calls int
+}
+
+func (v *stubVault) GetPrivateKey(context.Context, string) (string, error) {
+	v.calls++
+	if v.err != nil {
+		return "", v.err
+	}
+	return v.key, nil
+}
+
+// newTestChain creates a chain that logs to the returned buffer.
+func newTestChain(t *testing.T, allowFallback bool, backends ...Backend) (*Chain, *bytes.Buffer) {
+	t.Helper()
+	logs := &bytes.Buffer{}
+	chain, err := NewChain(slog.New(slog.NewTextHandler(logs, nil)), allowFallback, backends...)
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	return chain, logs
+}
+
+func TestChainFirstBackendWins(t *testing.T) {
+	primary := &stubVault{key: primaryKey}
+	fallback := &stubVault{key: fallbackKey}
+	chain, logs := newTestChain(t, true,
+		Backend{Name: "primary", Vault: primary},
+		Backend{Name: "test-keys", Vault: fallback, Fallback: true})
+
+	key, err := chain.GetPrivateKey(context.Background(), "user_1")
+	if err != nil || key != primaryKey {
+		t.Fatalf("GetPrivateKey = %q, %v; want the primary key", key, err)
+	}
+	if fallback.calls != 0 {
+		t.Errorf("fallback consulted %d times after the primary served the key", fallback.calls)
+	}
+	if stats := chain.Stats(); stats != (ChainStats{Served: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	if !strings.Contains(logs.String(), "backend=primary") {
+		t.Errorf("audit log does not name the serving backend:\n%s", logs)
+	}
+}
+
+func TestChainFailsOverToNextBackend(t *testing.T) {
+	primary := &stubVault{err: errors.New("dial tcp: connection refused")}
+	fallback := &stubVault{key: fallbackKey}
+	chain, logs := newTestChain(t, true,
+		Backend{Name: "primary", Vault: primary},
+		Backend{Name: "test-keys", Vault: fallback, Fallback: true})
+
+	key, err := chain.GetPrivateKey(context.Background(), "user_1")
+	if err != nil || key != fallbackKey {
+		t.Fatalf("GetPrivateKey = %q, %v; want the fallback key", key, err)
+	}
+	if stats := chain.Stats(); stats != (ChainStats{Served: 1, FallbackServed: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	if !strings.Contains(logs.String(), "vault fallback used") {
+		t.Errorf("fallback use was not logged as a warning:\n%s", logs)
+	}
+}
+
+// TestChainKeyNotFoundDoesNotFailOver checks that a user without a key is never served a
+// test key instead.
+func TestChainKeyNotFoundDoesNotFailOver(t *testing.T) {
+	fallback := &stubVault{key: fallbackKey}
+	chain, _ := newTestChain(t, true,
+		Backend{Name: "primary", Vault: &stubVault{err: ErrKeyNotFound}},
+		Backend{Name: "test-keys", Vault: fallback, Fallback: true})
+
+	if _, err := chain.GetPrivateKey(context.Background(), "user_1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("GetPrivateKey error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if fallback.calls != 0 {
+		t.Errorf("fallback consulted %d times for a missing key", fallback.calls)
+	}
+}
+
+// TestChainAllBackendsFail checks that a chain that cannot serve a key returns an error,
+// and that neither the error nor the logs contain key material.
+func TestChainAllBackendsFail(t *testing.T) {
+	unreachable := errors.New("vault sealed")
+	tests := []struct {
+		name          string
+		allowFallback bool
+		backends      []Backend
+	}{
+		{"every backend fails", true, []Backend{
+			{Name: "primary", Vault: &stubVault{err: unreachable}},
+			{Name: "secondary", Vault: &stubVault{err: unreachable}},
+		}},
+		// The fallback holds a key, but may not be used.
+		{"fallback disallowed", false, []Backend{
+			{Name: "primary", Vault: &stubVault{err: unreachable}},
+			{Name: "test-keys", Vault: &stubVault{key: fallbackKey}, Fallback: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, logs := newTestChain(t, tt.allowFallback, tt.backends...)
+			key, err := chain.GetPrivateKey(context.Background(), "user_1")
+			if err == nil || key != "" {
+				t.Fatalf("GetPrivateKey = %q, %v; want no key and an error", key, err)
+			}
+			if !errors.Is(err, unreachable) {
+				t.Errorf("error %v does not wrap the backend error", err)
+			}
+			for _, output := range []string{err.Error(), logs.String()} {
+				if strings.Contains(output, fallbackKey) || strings.Contains(output, primaryKey) {
+					t.Errorf("key material leaked: %s", output)
+				}
+			}
+			if stats := chain.Stats(); stats != (ChainStats{Failures: 1}) {
+				t.Errorf("stats = %+v", stats)
+			}
+		})
+	}
+}
+
+func TestNewChainWithoutUsableBackends(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	tests := []struct {
+		name     string
+		backends []Backend
+	}{
+		{"empty chain", nil},
+		{"only disallowed fallbacks", []Backend{{Name: "test-keys", Vault: &stubVault{key: fallbackKey}, Fallback: true}}},
+		{"nil backend", []Backend{{Name: "primary"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := NewChain(logger, false, tt.backends...)
+			if err == nil || chain != nil {
+				t.Fatalf("NewChain = %v, %v; want an error", chain, err)
+			}
+			if strings.Contains(err.Error(), fallbackKey) {
+				t.Errorf("key material leaked: %v", err)
+			}
+		})
+	}
+}
@@ -60,6 +60,24 @@ func NewMockVault(privateKey string, logger *slog.Logger) (*MockVault, error) {
 	}, nil
 }
 
+// IsMock reports whether v only serves keys from mock vaults, i.e. it is a MockVault or a
+// Chain whose backends are all mock vaults. Any other backend is treated as a real vault.
+func IsMock(v Vault) bool {
+	switch v := v.(type) {
+	case *MockVault:
+		return true
+	case *Chain:
+		for _, backend := range v.backends {
+			if !IsMock(backend.Vault) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 /**
  * @description
  * GetPrivateKey for the MockVault returns the pre-configured dummy private key.